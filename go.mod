@@ -1,8 +1,19 @@
 module debug-gocui
 
-go 1.13
+go 1.22
 
 require (
-	github.com/jroimartin/gocui v0.5.0
-	github.com/mattn/go-runewidth v0.0.10 // indirect
+	github.com/cilium/ebpf v0.17.3
+	github.com/gdamore/tcell v1.4.0
+	github.com/mattn/go-runewidth v0.0.10
+	github.com/smacker/go-tree-sitter v0.0.0-20240827094217-dd81d9e9be82
+	golang.org/x/sys v0.30.0
+)
+
+require (
+	github.com/gdamore/encoding v1.0.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.0.3 // indirect
+	github.com/rivo/uniseg v0.1.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
 )
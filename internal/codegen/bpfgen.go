@@ -0,0 +1,770 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"path/filepath"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// 生成BPF代码
+func GenerateBPF(ctx *session.DebuggerContext) error {
+	if ctx.Project == nil || (len(ctx.Project.Breakpoints) == 0 && len(session.ProjectSpans(ctx)) == 0 && !session.LockProbesEnabled(ctx)) {
+		return errcode.Errorf(errcode.ErrNoBreakpoints, "没有设置断点")
+	}
+	
+	// 创建BPF文件
+	bpfPath := filepath.Join(ctx.Project.RootPath, "debug_breakpoints.bpf.c")
+	file, err := os.Create(bpfPath)
+	if err != nil {
+		return fmt.Errorf("创建BPF文件失败: %v", err)
+	}
+	defer file.Close()
+	
+	// 检测目标架构并生成对应的定义（模块ELF头优先于主机uname）
+	archDefine := session.TargetArchDefine(ctx)
+
+	// 写入BPF代码头部
+	fmt.Fprintln(file, "#include <linux/bpf.h>")
+	fmt.Fprintln(file, "#include <bpf/bpf_helpers.h>")
+	fmt.Fprintln(file, "#include <bpf/bpf_tracing.h>")
+	fmt.Fprintln(file, "#include <linux/ptrace.h>")
+	fmt.Fprintln(file, "#include <linux/types.h>")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "// 定义目标架构 - 解决PT_REGS_PARM错误")
+	fmt.Fprintf(file, "#define %s\n", archDefine)
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "// 自动生成的BPF调试代码")
+	fmt.Fprintln(file, "// 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(file, "")
+	
+	// 添加类型定义（兼容性处理）
+	fmt.Fprintln(file, "// 类型定义（确保兼容性）")
+	fmt.Fprintln(file, "#ifndef u32")
+	fmt.Fprintln(file, "typedef __u32 u32;")
+	fmt.Fprintln(file, "#endif")
+	fmt.Fprintln(file, "#ifndef u64")
+	fmt.Fprintln(file, "typedef __u64 u64;")
+	fmt.Fprintln(file, "#endif")
+	fmt.Fprintln(file, "")
+	
+	// 添加调试上下文结构
+	session.WriteDebugEventDecl(file)
+	arch, _ := session.DetectTargetArch(ctx)
+	filter := session.CurrentProbeFilter(ctx)
+	session.WriteRegsCaptureDecl(file, arch)
+	argRes := session.NewArgResolver(ctx)
+	probes := session.NewProbeChecker(ctx)
+	ctx.ProbeChecks = nil
+	
+	// 为探针计划中的每个断点生成探针（编号与事件中的断点编号一致）
+	validBreakpoints := 0
+	for _, probe := range BuildProbePlan(ctx, true).Probes {
+		bp := probe.Breakpoint
+		session.CheckGeneratedProbe(ctx, probes, bp)
+		session.ResolveGeneratedArgs(ctx, argRes, &bp)
+		ctx.Project.Breakpoints[probe.Index].Args = bp.Args
+		
+		fileName := filepath.Base(bp.File)
+		
+		// 内联函数中的断点在每个内联副本处各生成一个程序，共用断点编号
+		for s, site := range dwarf.BreakpointProbeSites(bp) {
+			bp := site
+			funcName := bp.Function
+			fmt.Fprintf(file, "// 断点 %d: %s:%d 在函数 %s\n", validBreakpoints+1, fileName, bp.Line, funcName)
+			fmt.Fprintf(file, "SEC(\"%s\")\n", probeSection(bp, false))
+			fmt.Fprintf(file, "int %s(struct pt_regs *ctx) {\n", probeProgramName("trace_breakpoint", validBreakpoints, s))
+			fmt.Fprintln(file, "    struct debug_event event = {};")
+			fmt.Fprintln(file, "    ")
+			session.WriteProbeFilter(file, filter)
+			fmt.Fprintln(file, "    // 获取进程信息")
+			fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+			fmt.Fprintln(file, "    event.pid = pid_tgid;")
+			fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+			fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+			fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", validBreakpoints+1)
+			fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+			fmt.Fprintf(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"%s\");\n", funcName)
+			fmt.Fprintln(file, "    ")
+			writeConditionFilter(file, arch, bp)
+			writeBreakpointEventOutput(file, fileName, bp)
+			fmt.Fprintf(file, "    // 打印调试信息\n")
+			fmt.Fprintf(file, "    debug_printk(\"[BREAKPOINT-%d] %s:%d in %%s() PID=%%d\\n\", \"%s\", event.pid);\n", 
+				validBreakpoints+1, fileName, bp.Line, funcName)
+			fmt.Fprintln(file, "    ")
+			session.WriteArgumentReads(file, arch, bp, validBreakpoints+1, nil)
+			session.WriteRegsCaptureSubmit(file, arch, validBreakpoints+1)
+			fmt.Fprintln(file, "    return 0;")
+			fmt.Fprintln(file, "}")
+			fmt.Fprintln(file, "")
+		}
+		if bp.RetVal && bp.InlinedFrom != "" {
+			// 内联展开的函数没有自己的返回，外层函数的返回值不是它的返回值
+			fmt.Fprintf(file, "// 断点 %d: %s() 被内联，不生成返回值探针\n\n", validBreakpoints+1, bp.InlinedFrom)
+		} else if bp.RetVal {
+			writeReturnProbe(file, validBreakpoints+1, bp, filter)
+		}
+		
+		validBreakpoints++
+	}
+	
+	// 成对探针的耗时测量（span）
+	spans := writeSpanProbes(ctx, file, arch, filter, argRes)
+	locks := session.WriteLockProbes(ctx, file, filter)
+	
+	if validBreakpoints == 0 && spans == 0 && !locks {
+		return errcode.Errorf(errcode.ErrNoBreakpoints, "没有找到有效的函数名，无法生成BPF探针")
+	}
+	
+	fmt.Fprintln(file, "char LICENSE[] SEC(\"license\") = \"GPL\";")
+	
+	// 生成编译和加载脚本
+	scriptPath := filepath.Join(ctx.Project.RootPath, "load_debug_bpf.sh")
+	if err := generateLoadScript(scriptPath, validBreakpoints); err != nil {
+		return fmt.Errorf("生成加载脚本失败: %v", err)
+	}
+	
+	// 生成卸载脚本  
+	unloadScriptPath := filepath.Join(ctx.Project.RootPath, "unload_debug_bpf.sh")
+	if err := generateUnloadScript(unloadScriptPath); err != nil {
+		return fmt.Errorf("生成卸载脚本失败: %v", err)
+	}
+	
+	// 保存更新后的断点信息（包含解析出的函数名）
+	if err := project.SaveBreakpoints(ctx.Project); err != nil {
+		// 这不是致命错误，只记录警告
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[WARNING] Failed to save breakpoints: %v", err))
+	}
+	
+	return nil
+}
+
+// 探针程序名：trace_breakpoint_0，内联断点的其他副本为 trace_breakpoint_0_1
+func probeProgramName(prefix string, index, site int) string {
+	if site == 0 {
+		return fmt.Sprintf("%s_%d", prefix, index)
+	}
+	return fmt.Sprintf("%s_%d_%d", prefix, index, site)
+}
+
+// 断点命中事件：填入类型、CPU和位置后写入perf buffer（用户态探针不采集内核调用栈）
+func writeBreakpointEventOutput(file *os.File, fileName string, bp project.Breakpoint) {
+	fmt.Fprintln(file, "    // 结构化事件（perf buffer）")
+	fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_BREAKPOINT;")
+	fmt.Fprintln(file, "    event.cpu = bpf_get_smp_processor_id();")
+	if bp.Binary != "" {
+		fmt.Fprintln(file, "    event.stack_id = -1;")
+	} else {
+		fmt.Fprintf(file, "    event.stack_id = bpf_get_stackid(ctx, &%s, 0);\n", session.DebugStacksMap)
+	}
+	fmt.Fprintf(file, "    bpf_probe_read_str(&event.location, sizeof(event.location), \"%s:%d\");\n", fileName, bp.Line)
+	session.WriteDebugEventOutput(file, "    ")
+	fmt.Fprintln(file, "    ")
+}
+
+// 生成函数返回探针：在函数返回时输出返回值（bp retval）
+func writeReturnProbe(file *os.File, breakpointID int, bp project.Breakpoint, filter *project.ProbeFilter) {
+	funcName := bp.Function
+	fmt.Fprintf(file, "// 断点 %d 返回值: %s\n", breakpointID, funcName)
+	fmt.Fprintf(file, "SEC(\"%s\")\n", probeSection(bp, true))
+	fmt.Fprintf(file, "int trace_return_%d(struct pt_regs *ctx) {\n", breakpointID)
+	session.WriteProbeFilter(file, filter)
+	fmt.Fprintln(file, "    struct debug_event event = {};")
+	fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+	fmt.Fprintln(file, "    event.pid = pid_tgid;")
+	fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+	fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+	fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", breakpointID)
+	fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_RETURN;")
+	fmt.Fprintln(file, "    event.cpu = bpf_get_smp_processor_id();")
+	fmt.Fprintln(file, "    event.var_type = 1;")
+	fmt.Fprintln(file, "    event.var_value = PT_REGS_RC(ctx);")
+	fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+	fmt.Fprintf(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"%s\");\n", funcName)
+	session.WriteDebugEventOutput(file, "    ")
+	fmt.Fprintf(file, "    debug_printk(\"[RETVAL-%d] %s=%%lld PID=%%d\\n\", event.var_value, event.pid);\n", breakpointID, funcName)
+	fmt.Fprintln(file, "    return 0;")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintln(file, "")
+}
+
+// 生成BPF加载脚本
+func generateLoadScript(scriptPath string, breakpointCount int) error {
+	file, err := os.Create(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	// 设置可执行权限
+	os.Chmod(scriptPath, 0755)
+	
+	fmt.Fprintln(file, "#!/bin/bash")
+	fmt.Fprintln(file, "# 自动生成的BPF调试程序加载脚本")
+	fmt.Fprintln(file, "# 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "set -e  # 遇到错误立即退出")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "BPF_FILE=\"debug_breakpoints.bpf.c\"")
+	fmt.Fprintln(file, "BPF_OBJ=\"debug_breakpoints.bpf.o\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] 开始编译和加载BPF调试程序...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查是否有root权限")
+	fmt.Fprintln(file, "if [ \"$EUID\" -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] 需要root权限来加载BPF程序\"")
+	fmt.Fprintln(file, "    echo \"请使用: sudo $0\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查BPF源文件是否存在")
+	fmt.Fprintln(file, "if [ ! -f \"$BPF_FILE\" ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF源文件 $BPF_FILE 不存在\"")
+	fmt.Fprintln(file, "    echo \"请先运行调试器并使用generate命令生成BPF代码\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 编译BPF程序")
+	fmt.Fprintln(file, "echo \"[INFO] 编译BPF程序...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检测架构并设置include路径")
+	fmt.Fprintln(file, "ARCH=$(uname -m)")
+	fmt.Fprintln(file, "INCLUDE_FLAGS=\"\"")
+	fmt.Fprintln(file, "case \"$ARCH\" in")
+	fmt.Fprintln(file, "    riscv64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/riscv64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    aarch64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/aarch64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    x86_64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/x86_64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    *)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "esac")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] 架构: $ARCH\"")
+	fmt.Fprintln(file, "echo \"[INFO] Include参数: $INCLUDE_FLAGS\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "clang -O2 -target bpf $INCLUDE_FLAGS -c \"$BPF_FILE\" -o \"$BPF_OBJ\"")
+	fmt.Fprintln(file, "if [ $? -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF程序编译失败\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 加载BPF程序")
+	fmt.Fprintln(file, "echo \"[INFO] 加载BPF程序...\"")
+	fmt.Fprintln(file, "bpftool prog load \"$BPF_OBJ\" /sys/fs/bpf/debug_breakpoints")
+	fmt.Fprintln(file, "if [ $? -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF程序加载失败\"")
+	fmt.Fprintln(file, "    echo \"请检查:\"")
+	fmt.Fprintln(file, "    echo \"1. 是否安装了bpftool\"")
+	fmt.Fprintln(file, "    echo \"2. 内核是否支持BPF\"")
+	fmt.Fprintln(file, "    echo \"3. 目标函数是否存在于内核中\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintf(file, "echo \"[SUCCESS] BPF调试程序已加载，监控 %d 个断点\"\n", breakpointCount)
+	fmt.Fprintln(file, "echo \"[INFO] 使用以下命令查看调试输出:\"")
+	fmt.Fprintln(file, "echo \"  sudo cat /sys/kernel/debug/tracing/trace_pipe\"")
+	fmt.Fprintln(file, "echo \"[INFO] 使用以下命令卸载:\"")
+	fmt.Fprintln(file, "echo \"  sudo ./unload_debug_bpf.sh\"")
+	
+	return nil
+}
+
+// 生成BPF卸载脚本
+func generateUnloadScript(scriptPath string) error {
+	file, err := os.Create(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	// 设置可执行权限
+	os.Chmod(scriptPath, 0755)
+	
+	fmt.Fprintln(file, "#!/bin/bash")
+	fmt.Fprintln(file, "# BPF调试程序卸载脚本")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] 卸载BPF调试程序...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查是否有root权限")
+	fmt.Fprintln(file, "if [ \"$EUID\" -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] 需要root权限来卸载BPF程序\"")
+	fmt.Fprintln(file, "    echo \"请使用: sudo $0\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 卸载BPF程序")
+	fmt.Fprintln(file, "rm -f /sys/fs/bpf/debug_breakpoints")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 清理编译产物")
+	fmt.Fprintln(file, "rm -f debug_breakpoints.bpf.o")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[SUCCESS] BPF调试程序已卸载\"")
+	
+	return nil
+}
+
+// 生成变量监控BPF加载脚本
+func GenerateVarsLoadScript(scriptPath string, breakpointCount int) error {
+	file, err := os.Create(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	// 设置可执行权限
+	os.Chmod(scriptPath, 0755)
+	
+	fmt.Fprintln(file, "#!/bin/bash")
+	fmt.Fprintln(file, "# 变量监控BPF程序加载脚本")
+	fmt.Fprintln(file, "# 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "set -e")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "BPF_FILE=\"debug_variables.bpf.c\"")
+	fmt.Fprintln(file, "BPF_OBJ=\"debug_variables.bpf.o\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] 🔥 Loading Variable Monitoring BPF Program...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查root权限")
+	fmt.Fprintln(file, "if [ \"$EUID\" -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] Root privileges required\"")
+	fmt.Fprintln(file, "    echo \"Please run: sudo $0\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查BPF源文件")
+	fmt.Fprintln(file, "if [ ! -f \"$BPF_FILE\" ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF source file $BPF_FILE not found\"")
+	fmt.Fprintln(file, "    echo \"Please run 'vars' command in debugger first\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 编译BPF程序")
+	fmt.Fprintln(file, "echo \"[INFO] Compiling variable monitoring BPF program...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检测架构并设置include路径")
+	fmt.Fprintln(file, "ARCH=$(uname -m)")
+	fmt.Fprintln(file, "INCLUDE_FLAGS=\"\"")
+	fmt.Fprintln(file, "case \"$ARCH\" in")
+	fmt.Fprintln(file, "    riscv64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/riscv64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    aarch64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/aarch64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    x86_64)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include/x86_64-linux-gnu -I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "    *)")
+	fmt.Fprintln(file, "        INCLUDE_FLAGS=\"-I/usr/include\"")
+	fmt.Fprintln(file, "        ;;")
+	fmt.Fprintln(file, "esac")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] Architecture: $ARCH\"")
+	fmt.Fprintln(file, "echo \"[INFO] Include flags: $INCLUDE_FLAGS\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "clang -O2 -target bpf $INCLUDE_FLAGS -c \"$BPF_FILE\" -o \"$BPF_OBJ\"")
+	fmt.Fprintln(file, "if [ $? -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF compilation failed\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 加载BPF程序")
+	fmt.Fprintln(file, "echo \"[INFO] Loading variable monitoring BPF program...\"")
+	fmt.Fprintln(file, "bpftool prog load \"$BPF_OBJ\" /sys/fs/bpf/debug_variables")
+	fmt.Fprintln(file, "if [ $? -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] BPF program loading failed\"")
+	fmt.Fprintln(file, "    echo \"Please check:\"")
+	fmt.Fprintln(file, "    echo \"• bpftool installation\"")
+	fmt.Fprintln(file, "    echo \"• Kernel BPF support\"")
+	fmt.Fprintln(file, "    echo \"• Target functions exist in kernel\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintf(file, "echo \"[SUCCESS] 🎯 Variable monitoring active for %d breakpoints\"\n", breakpointCount)
+	fmt.Fprintln(file, "echo \"\"")
+	fmt.Fprintln(file, "echo \"📊 View real-time variable monitoring:\"")
+	fmt.Fprintln(file, "echo \"  sudo cat /sys/kernel/debug/tracing/trace_pipe\"")
+	fmt.Fprintln(file, "echo \"\"")
+	fmt.Fprintln(file, "echo \"🛑 To stop monitoring:\"")
+	fmt.Fprintln(file, "echo \"  sudo ./unload_debug_vars.sh\"")
+	
+	return nil
+}
+
+// 生成变量监控BPF卸载脚本
+func GenerateVarsUnloadScript(scriptPath string) error {
+	file, err := os.Create(scriptPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	// 设置可执行权限
+	os.Chmod(scriptPath, 0755)
+	
+	fmt.Fprintln(file, "#!/bin/bash")
+	fmt.Fprintln(file, "# 变量监控BPF程序卸载脚本")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[INFO] 🛑 Unloading variable monitoring BPF program...\"")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 检查root权限")
+	fmt.Fprintln(file, "if [ \"$EUID\" -ne 0 ]; then")
+	fmt.Fprintln(file, "    echo \"[ERROR] Root privileges required\"")
+	fmt.Fprintln(file, "    echo \"Please run: sudo $0\"")
+	fmt.Fprintln(file, "    exit 1")
+	fmt.Fprintln(file, "fi")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 卸载BPF程序")
+	fmt.Fprintln(file, "rm -f /sys/fs/bpf/debug_variables")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "# 清理编译产物")
+	fmt.Fprintln(file, "rm -f debug_variables.bpf.o")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "echo \"[SUCCESS] ✅ Variable monitoring stopped and cleaned up\"")
+	
+	return nil
+}
+
+// 自动解析函数中的所有变量（新功能）
+func ParseAllFunctionVariables(filePath string, lineNumber int) []string {
+	// 首先尝试从源码中解析
+	if vars := parseVariablesFromSource(filePath, lineNumber); len(vars) > 0 {
+		return vars
+	}
+	
+	// 回退到DWARF解析（如果有调试信息）
+	if vars := dwarf.ParseVariablesFromDWARF(filePath, lineNumber); len(vars) > 0 {
+		return vars
+	}
+	
+	// 最后回退到常见变量模式
+	return []string{"local_var", "counter", "temp", "i", "len", "ret", "addr", "ptr", "data", "size", "index", "val", "result"}
+}
+
+// 从源码中解析函数的参数和所有局部变量
+func parseVariablesFromSource(filePath string, targetLine int) []string {
+	fn := project.CFunctionAt(filePath, targetLine)
+	if fn == nil {
+		return nil
+	}
+	var variables []string
+	variableSet := make(map[string]bool) // 去重（不同块中的同名变量）
+	for _, decl := range append(append([]project.CDecl(nil), fn.Params...), fn.Locals...) {
+		if !variableSet[decl.Name] {
+			variables = append(variables, decl.Name)
+			variableSet[decl.Name] = true
+		}
+	}
+	return variables
+}
+
+// 生成统一的BPF代码（包含基础断点+变量监控）
+func GenerateBPFWithVariables(ctx *session.DebuggerContext, requestedVars []string) error {
+	// 基本检查
+	if ctx == nil {
+		return fmt.Errorf("Debug context is null")
+	}
+	if ctx.Project == nil {
+		return fmt.Errorf("Project not opened")
+	}
+	if len(ctx.Project.Breakpoints) == 0 && len(session.ProjectSpans(ctx)) == 0 && !session.LockProbesEnabled(ctx) {
+		return errcode.Errorf(errcode.ErrNoBreakpoints, "No breakpoints set, current count: %d", len(ctx.Project.Breakpoints))
+	}
+	
+	// 能解析出地址的全局变量用bpf_probe_read_kernel读取，其余按局部变量处理
+	globals, requestedVars := session.SplitGlobalWatches(ctx, requestedVars)
+	
+	// 创建BPF文件
+	bpfPath := filepath.Join(ctx.Project.RootPath, "debug_variables.bpf.c")
+	file, err := os.Create(bpfPath)
+	if err != nil {
+		return fmt.Errorf("创建BPF文件失败: %v", err)
+	}
+	defer file.Close()
+	
+	// 检测目标架构并生成对应的定义（模块ELF头优先于主机uname）
+	archDefine := session.TargetArchDefine(ctx)
+
+	// 写入BPF代码头部
+	fmt.Fprintln(file, "#include <linux/bpf.h>")
+	fmt.Fprintln(file, "#include <bpf/bpf_helpers.h>")
+	fmt.Fprintln(file, "#include <bpf/bpf_tracing.h>")
+	fmt.Fprintln(file, "#include <linux/ptrace.h>")
+	fmt.Fprintln(file, "#include <linux/types.h>")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "// 定义目标架构 - 解决PT_REGS_PARM错误")
+	fmt.Fprintf(file, "#define %s\n", archDefine)
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "// 统一BPF调试程序（基础断点 + 变量监控）")
+	fmt.Fprintln(file, "// 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(file, "")
+	
+	// 添加类型定义（兼容性处理）
+	fmt.Fprintln(file, "// 类型定义（确保兼容性）")
+	fmt.Fprintln(file, "#ifndef u32")
+	fmt.Fprintln(file, "typedef __u32 u32;")
+	fmt.Fprintln(file, "#endif")
+	fmt.Fprintln(file, "#ifndef u64")
+	fmt.Fprintln(file, "typedef __u64 u64;")
+	fmt.Fprintln(file, "#endif")
+	fmt.Fprintln(file, "#ifndef s64")
+	fmt.Fprintln(file, "typedef __s64 s64;")
+	fmt.Fprintln(file, "#endif")
+	fmt.Fprintln(file, "")
+	
+	// 统一的调试事件结构（包含基础断点+变量信息）
+	session.WriteDebugEventDecl(file)
+	arch, _ := session.DetectTargetArch(ctx)
+	filter := session.CurrentProbeFilter(ctx)
+	session.WriteRegsCaptureDecl(file, arch)
+	argRes := session.NewArgResolver(ctx)
+	probes := session.NewProbeChecker(ctx)
+	ctx.ProbeChecks = nil
+	
+	validBreakpoints := 0
+	for _, probe := range BuildProbePlan(ctx, true).Probes {
+		bp := probe.Breakpoint
+		session.CheckGeneratedProbe(ctx, probes, bp)
+		session.ResolveGeneratedArgs(ctx, argRes, &bp)
+		ctx.Project.Breakpoints[probe.Index].Args = bp.Args
+		
+		fileName := filepath.Base(bp.File)
+		
+		// 内联函数中的断点在每个内联副本处各生成一个程序，共用断点编号
+		for s, site := range dwarf.BreakpointProbeSites(bp) {
+			bp := site
+			funcName := bp.Function
+			// 基础断点信息
+			fmt.Fprintf(file, "// 断点 %d: %s:%d 在函数 %s\n", validBreakpoints+1, fileName, bp.Line, funcName)
+			fmt.Fprintf(file, "// 功能: 基础断点监控")
+		
+			// 如果有变量请求，获取变量位置信息
+			var varLocations map[string]project.VariableLocation
+			var structs map[string][]dwarf.StructMember
+			// 变量位置来自模块的DWARF，用户态探针只报告命中
+			if len(requestedVars) > 0 && bp.Binary == "" {
+				varLocations = dwarf.ParseDWARFVariableLocations(project.FindProjectModule(ctx.Project.RootPath), arch, bp, requestedVars)
+				structs = session.WatchedStructPointers(ctx, funcName, requestedVars)
+				if len(varLocations) > 0 {
+					fmt.Fprintf(file, " + 变量监控")
+					fmt.Fprintf(file, " (")
+					first := true
+					for varName := range varLocations {
+						if !first {
+							fmt.Fprintf(file, ", ")
+						}
+						fmt.Fprintf(file, "%s", varName)
+						first = false
+					}
+					fmt.Fprintf(file, ")")
+				}
+			}
+			fmt.Fprintln(file)
+		
+			fmt.Fprintf(file, "SEC(\"%s\")\n", probeSection(bp, false))
+			fmt.Fprintf(file, "int %s(struct pt_regs *ctx) {\n", probeProgramName("trace_debug", validBreakpoints, s))
+			fmt.Fprintln(file, "    struct debug_event event = {};")
+			fmt.Fprintln(file, "")
+			session.WriteProbeFilter(file, filter)
+			fmt.Fprintln(file, "    // 基础断点信息收集")
+			fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+			fmt.Fprintln(file, "    event.pid = pid_tgid;")
+			fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+			fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+			fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", validBreakpoints+1)
+			fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+			fmt.Fprintf(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"%s\");\n", funcName)
+			fmt.Fprintln(file, "")
+			writeConditionFilter(file, arch, bp)
+			writeBreakpointEventOutput(file, fileName, bp)
+		
+			// 基础断点输出
+			fmt.Fprintf(file, "    // 基础断点输出\n")
+			fmt.Fprintf(file, "    debug_printk(\"[BREAKPOINT-%d] %s:%d in %%s() PID=%%d TGID=%%d at %%llu\\n\", \n", 
+				validBreakpoints+1, fileName, bp.Line)
+			fmt.Fprintln(file, "               event.function, event.pid, event.tgid, event.timestamp);")
+			fmt.Fprintln(file, "")
+			session.WriteArgumentReads(file, arch, bp, validBreakpoints+1, varLocations)
+		
+			// 如果有变量，生成变量读取代码
+			if len(varLocations) > 0 {
+				fmt.Fprintln(file, "    // 变量监控（如果有请求的变量）")
+				for varName, location := range varLocations {
+					fmt.Fprintf(file, "    // 读取变量: %s\n", varName)
+					fmt.Fprintf(file, "    bpf_probe_read_str(&event.var_name, sizeof(event.var_name), \"%s\");\n", varName)
+				
+					switch location.Type {
+					case "register":
+						// 寄存器按pt_regs中的下标读取（DWARF寄存器名与pt_regs字段名一致）
+						if expr := dwarf.TargetArchInfo(arch).CtxRegister(location.Register); expr != "" {
+							fmt.Fprintf(file, "    event.var_value = %s;  // %s\n", expr, location.Register)
+						} else {
+							fmt.Fprintf(file, "    // 寄存器 %s 不在 %s 的pt_regs中\n", location.Register, arch)
+						}
+					case "stack":
+						// 基址寄存器来自位置表达式或帧基址（CFA规则），没有时按帧指针近似
+						info := dwarf.TargetArchInfo(arch)
+						reg := location.Register
+						if reg == "" && info != nil {
+							reg = info.FP
+						}
+						base := info.CtxRegister(reg)
+						if base == "" {
+							base = "PT_REGS_FP(ctx)"
+						}
+						fmt.Fprintln(file, "    {")
+						fmt.Fprintf(file, "        void *stack_addr = (void *)(%s + %d);\n", base, location.StackOffset)
+						fmt.Fprintln(file, "        long temp_val = 0;")
+						fmt.Fprintf(file, "        if (bpf_probe_read_kernel(&temp_val, %d, stack_addr) == 0) {\n", location.Size)
+						fmt.Fprintln(file, "            event.var_value = temp_val;")
+						fmt.Fprintln(file, "        }")
+						fmt.Fprintln(file, "    }")
+					case "memory":
+						fmt.Fprintln(file, "    // Memory variable access not implemented yet")
+					}
+				
+					fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_VAR;")
+					fmt.Fprintln(file, "    event.var_type = 1;  // long，有符号")
+					session.WriteDebugEventOutput(file, "    ")
+					fmt.Fprintf(file, "    debug_printk(\"[VAR-%d] %s:%%s=%%ld PID=%%d\\n\", event.var_name, event.var_value, event.pid);\n", 
+						validBreakpoints+1, funcName)
+					if members, ok := structs[varName]; ok {
+						writeStructMemberReads(file, varName, members, validBreakpoints+1, funcName)
+					}
+					fmt.Fprintln(file, "")
+				}
+			}
+			writeGlobalWatchReads(file, globals, validBreakpoints+1, funcName)
+		
+			session.WriteRegsCaptureSubmit(file, arch, validBreakpoints+1)
+			fmt.Fprintln(file, "    return 0;")
+			fmt.Fprintln(file, "}")
+			fmt.Fprintln(file, "")
+		}
+		if bp.RetVal && bp.InlinedFrom != "" {
+			// 内联展开的函数没有自己的返回，外层函数的返回值不是它的返回值
+			fmt.Fprintf(file, "// 断点 %d: %s() 被内联，不生成返回值探针\n\n", validBreakpoints+1, bp.InlinedFrom)
+		} else if bp.RetVal {
+			writeReturnProbe(file, validBreakpoints+1, bp, filter)
+		}
+		
+		validBreakpoints++
+	}
+	
+	// 成对探针的耗时测量（span）
+	spans := writeSpanProbes(ctx, file, arch, filter, argRes)
+	locks := session.WriteLockProbes(ctx, file, filter)
+	
+	if validBreakpoints == 0 && spans == 0 && !locks {
+		return errcode.Errorf(errcode.ErrNoBreakpoints, "没有找到有效的函数名，无法生成BPF探针")
+	}
+	
+	fmt.Fprintln(file, "char LICENSE[] SEC(\"license\") = \"GPL\";")
+	
+	return nil
+}
+
+// 编译BPF代码（带架构参数）
+func CompileBPFWithArch(ctx *session.DebuggerContext, targetArch string) error {
+	if ctx.Project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	
+	// 检查BPF源文件是否存在
+	bpfSourcePath := filepath.Join(ctx.Project.RootPath, "debug_breakpoints.bpf.c")
+	if _, err := os.Stat(bpfSourcePath); os.IsNotExist(err) {
+		return errcode.Errorf(errcode.ErrBPFSource, "BPF源文件不存在: %s\n请先使用 'generate' 命令生成BPF代码", bpfSourcePath)
+	}
+	
+	// 目标文件路径
+	bpfObjectPath := filepath.Join(ctx.Project.RootPath, "debug_breakpoints.bpf.o")
+	
+	// 构建编译命令（clang、sysroot、内核头文件和附加选项来自 toolchain 配置）
+	compileCmd, err := session.BPFCompileCommand(ctx, targetArch, bpfSourcePath, bpfObjectPath)
+	if err != nil {
+		return err
+	}
+	
+	// 执行编译
+	output, err := compileCmd.CombinedOutput()
+	// 保留clang输出，compile 失败时按诊断行跳转
+	ctx.CompileOutput = nil
+	if err != nil {
+		ctx.CompileOutput = strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+		// 编译失败，返回详细错误信息
+		return errcode.Errorf(errcode.ErrBPFCompile, "BPF编译失败:\n编译命令: %s\n错误输出:\n%s\n\n常见问题排查:\n• 检查是否安装了linux-headers\n• 确认clang版本支持BPF目标\n• 验证BPF源代码语法", 
+			compileCmd.String(), string(output))
+	}
+	
+	// 检查输出文件是否生成
+	if _, err := os.Stat(bpfObjectPath); os.IsNotExist(err) {
+		return fmt.Errorf("编译完成但未找到输出文件: %s", bpfObjectPath)
+	}
+	
+	return nil
+}
+
+// 编译BPF代码（旧版本，保持向后兼容）
+func compileBPF(ctx *session.DebuggerContext) error {
+	targetArch, _ := session.DetectTargetArch(ctx)
+	return CompileBPFWithArch(ctx, targetArch)
+}
+
+// 编译变量监控BPF代码
+// 编译变量监控BPF代码（带架构参数）
+func CompileVariableBPFWithArch(ctx *session.DebuggerContext, targetArch string) error {
+	if ctx.Project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	
+	// 检查BPF源文件是否存在
+	bpfSourcePath := filepath.Join(ctx.Project.RootPath, "debug_variables.bpf.c")
+	if _, err := os.Stat(bpfSourcePath); os.IsNotExist(err) {
+		return errcode.Errorf(errcode.ErrBPFSource, "变量监控BPF源文件不存在: %s\n请先使用 'vars <variable_names>' 命令生成代码", bpfSourcePath)
+	}
+	
+	// 目标文件路径
+	bpfObjectPath := filepath.Join(ctx.Project.RootPath, "debug_variables.bpf.o")
+	
+	// 构建编译命令（clang、sysroot、内核头文件和附加选项来自 toolchain 配置）
+	compileCmd, err := session.BPFCompileCommand(ctx, targetArch, bpfSourcePath, bpfObjectPath)
+	if err != nil {
+		return err
+	}
+	
+	// 执行编译
+	output, err := compileCmd.CombinedOutput()
+	// 保留clang输出，compile 失败时按诊断行跳转
+	ctx.CompileOutput = nil
+	if err != nil {
+		ctx.CompileOutput = strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+		return errcode.Errorf(errcode.ErrBPFCompile, "变量监控BPF编译失败:\n编译命令: %s\n错误输出:\n%s\n\n常见问题排查:\n• 检查是否安装了linux-headers\n• 确认clang版本支持BPF目标\n• 验证变量监控BPF源代码语法", 
+			compileCmd.String(), string(output))
+	}
+	
+	// 检查输出文件是否生成
+	if _, err := os.Stat(bpfObjectPath); os.IsNotExist(err) {
+		return fmt.Errorf("编译完成但未找到输出文件: %s", bpfObjectPath)
+	}
+	
+	return nil
+}
+
+// 编译变量监控BPF代码（旧版本，保持向后兼容）
+func compileVariableBPF(ctx *session.DebuggerContext) error {
+	targetArch, _ := session.DetectTargetArch(ctx)
+	return CompileVariableBPFWithArch(ctx, targetArch)
+}
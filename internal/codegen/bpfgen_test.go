@@ -0,0 +1,115 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/testsupport"
+)
+
+// 读取生成的文件
+func readGenerated(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestGenerateBPF(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true, RetVal: true},
+		project.Breakpoint{File: "drv.c", Line: 5, Enabled: true, Condition: "pid == 42"},
+	)
+	if err := GenerateBPF(ctx); err != nil {
+		t.Fatal(err)
+	}
+	src := readGenerated(t, filepath.Join(ctx.Project.RootPath, "debug_breakpoints.bpf.c"))
+	for _, want := range []string{
+		`SEC("kprobe/do_work")`,
+		`SEC("kretprobe/do_work")`,
+		`SEC("kprobe/scale")`,
+		"event.breakpoint_id = 1;",
+		"event.breakpoint_id = 2;",
+		"[BREAKPOINT-1] drv.c:11",
+		"[BREAKPOINT-2] drv.c:5",
+		"42",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("debug_breakpoints.bpf.c does not contain %q", want)
+		}
+	}
+	for _, name := range []string{"load_debug_bpf.sh", "unload_debug_bpf.sh"} {
+		if _, err := os.Stat(filepath.Join(ctx.Project.RootPath, name)); err != nil {
+			t.Errorf("%s not generated: %v", name, err)
+		}
+	}
+}
+
+func TestGenerateBPFNoBreakpoints(t *testing.T) {
+	ctx := testsupport.NewProject(t, project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work"})
+	if err := GenerateBPF(ctx); err == nil {
+		t.Error("GenerateBPF with only disabled breakpoints should fail")
+	}
+}
+
+func TestGenerateSystemtapScript(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true},
+		project.Breakpoint{File: "drv.c", Line: 5, Function: "scale", Enabled: true},
+	)
+	path, err := GenerateSystemtapScript(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := readGenerated(t, path)
+	for _, want := range []string{
+		`probe kernel.statement("do_work@drv.c:11") !,`,
+		`kernel.function("scale") {`,
+		"[BREAKPOINT-2] drv.c:5 in scale()",
+		"[FALLBACK-1]",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("systemtap script does not contain %q", want)
+		}
+	}
+}
+
+func TestKprobeEventDefinitions(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true, RetVal: true},
+		project.Breakpoint{Binary: "/usr/bin/app", Function: "main", Enabled: true},
+		project.Breakpoint{File: "drv.c", Line: 5, Function: "scale", Enabled: true},
+	)
+	defs := KprobeEventDefinitions(ctx, BuildProbePlan(ctx, true))
+	// 用户态断点占用编号2但不生成kprobe
+	want := []string{"p:" + kprobeGroup + "/bp1 do_work", "r:" + kprobeGroup + "/ret1 do_work", "p:" + kprobeGroup + "/bp3 scale"}
+	if len(defs) != len(want) {
+		t.Fatalf("defs = %q, want %d definitions", defs, len(want))
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(defs[i], prefix) {
+			t.Errorf("defs[%d] = %q, want prefix %q", i, defs[i], prefix)
+		}
+	}
+}
+
+func TestParseAllFunctionVariables(t *testing.T) {
+	ctx := testsupport.NewProject(t)
+	file := filepath.Join(ctx.Project.RootPath, "drv.c")
+	got := ParseAllFunctionVariables(file, 12)
+	want := map[string]bool{"a": true, "b": true, "sum": true}
+	for _, name := range got {
+		delete(want, name)
+		if name == "factor" || name == "x" {
+			t.Errorf("variable %q of scale() reported in do_work()", name)
+		}
+	}
+	if len(want) > 0 {
+		t.Errorf("ParseAllFunctionVariables = %v, missing %v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== bpftrace 后端 ==========
+// 很多发行版上装一个bpftrace比配好clang+bpftool+内核头文件简单得多。为断点生成等价的
+// bpftrace脚本（debug_breakpoints.bt）：每个探针位置一个 kprobe:func+0x1c 块，变量按DWARF
+// 位置用 reg()/栈偏移读取，bp retval 的断点加 kretprobe，用户态断点为 uprobe，
+// 项目的pid/comm/cpu过滤写成谓词。输出与BPF程序相同格式的 [BREAKPOINT-N]/[VAR-N]/[RETVAL-N]
+// 行（断点行带trace_pipe格式的前缀），按trace_pipe行解析。
+// bpftrace run 在TUI中运行脚本（进程管理与 stap run 相同，见 session/stap.go），也可以 backend bpftrace
+// 后用 events start。断点条件、span和锁探针只在bpf后端生成。
+
+// 生成的bpftrace脚本
+const BpftraceScriptFile = "debug_breakpoints.bt"
+
+// bpftrace输出中需要显示的诊断
+var bpftraceDiagnosticRegex = regexp.MustCompile(`^(?:\S+:\d+(?::\d+(?:-\d+)?)?: )?(?:ERROR|WARNING|Attaching \d+ probes?)`)
+
+// 常见错误的排查提示（按输出中的关键字）
+var bpftraceHints = []struct {
+	Match string
+	Hint  string
+}{
+	{"only supports running as the root user", "Run as root (bpftrace needs CAP_BPF and CAP_PERFMON)"},
+	{"Permission denied", "Run as root (bpftrace needs CAP_BPF and CAP_PERFMON)"},
+	{"Could not resolve symbol", "Is the module loaded (lsmod)? Probes attach to the running kernel's symbols"},
+	{"No probes to attach", "Is the module loaded (lsmod)? Probes attach to the running kernel's symbols"},
+	{"Possible attachment attempt in the middle of an instruction", "bpftrace was built without instruction-boundary checks, set BPFTRACE_ALLOW_UNSAFE_PROBE or 'bp' on another line"},
+	{"Unknown identifier", "The installed bpftrace is older than the generated script expects, check 'bpftrace --version'"},
+}
+
+// 变量位置转换为bpftrace表达式，无法表示时返回空
+func bpftraceVarExpr(arch string, loc project.VariableLocation) string {
+	cast := "int64"
+	switch loc.Size {
+	case 1:
+		cast = "int8"
+	case 2:
+		cast = "int16"
+	case 4:
+		cast = "int32"
+	}
+	info := dwarf.TargetArchInfo(arch)
+	switch loc.Type {
+	case "register":
+		return fmt.Sprintf("(%s)reg(\"%s\")", cast, info.KprobeName(loc.Register))
+	case "stack":
+		// 位置中没有基址寄存器时与BPF生成器一样按帧指针近似
+		base := loc.Register
+		if base == "" && info != nil {
+			base = info.FP
+		}
+		if base == "" {
+			return ""
+		}
+		return fmt.Sprintf("*(%s *)(reg(\"%s\") + (%d))", cast, info.KprobeName(base), loc.StackOffset)
+	}
+	return ""
+}
+
+// 断点处要读取的变量（与kprobe_events后端相同：函数中出现的变量和监视表达式）
+func bpftraceVars(ctx *session.DebuggerContext, module, arch string, bp project.Breakpoint) [][2]string {
+	if module == "" {
+		return nil
+	}
+	names := ParseAllFunctionVariables(bp.File, bp.Line)
+	names = append(names, session.WatchExpressions(ctx)...)
+	locations := dwarf.ParseRealDWARF(module, bp, names)
+	vars := make([][2]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		loc, ok := locations[name]
+		if !ok || seen[name] || len(vars) >= maxKprobeFetchArgs {
+			continue
+		}
+		seen[name] = true
+		if expr := bpftraceVarExpr(arch, loc); expr != "" {
+			vars = append(vars, [2]string{name, expr})
+		}
+	}
+	return vars
+}
+
+// 项目过滤条件对应的谓词（没有过滤时为空）
+func bpftracePredicate(f *project.ProbeFilter) string {
+	if f == nil {
+		return ""
+	}
+	parts := make([]string, 0, 3)
+	if f.PID != 0 {
+		parts = append(parts, fmt.Sprintf("pid == %d", f.PID))
+	}
+	if f.Comm != "" {
+		parts = append(parts, fmt.Sprintf("comm == \"%s\"", f.Comm))
+	}
+	if f.CPU != nil {
+		parts = append(parts, fmt.Sprintf("cpu == %d", *f.CPU))
+	}
+	return "/" + strings.Join(parts, " && ") + "/\n"
+}
+
+// 生成bpftrace脚本，返回路径和生成时的提示
+func GenerateBpftraceScript(ctx *session.DebuggerContext) (string, []string, error) {
+	plan := BuildProbePlan(ctx, true)
+	if len(plan.Probes) == 0 {
+		return "", nil, errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	predicate := bpftracePredicate(plan.Filter)
+	warnings := make([]string, 0)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "#!/usr/bin/env bpftrace")
+	fmt.Fprintln(&b, "// 自动生成的bpftrace调试脚本")
+	fmt.Fprintln(&b, "// 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	if plan.Filter != nil {
+		fmt.Fprintf(&b, "// 探针过滤: %s\n", session.ProbeFilterSummary(plan.Filter))
+	}
+	fmt.Fprintln(&b, "")
+	for _, probe := range plan.Probes {
+		bp, id := probe.Breakpoint, probe.ID
+		fileName := probe.FileName()
+		fmt.Fprintf(&b, "// 断点 %d: %s:%d 在函数 %s\n", id, fileName, bp.Line, bp.Function)
+		if bp.Condition != "" {
+			fmt.Fprintf(&b, "// 断点条件只在bpf后端求值，已忽略: %s\n", bp.Condition)
+			warnings = append(warnings, fmt.Sprintf("bp%d: condition '%s' is not supported by bpftrace, probe fires on every hit", id, bp.Condition))
+		}
+		entryProbe := "kprobe:"
+		if bp.Binary != "" {
+			entryProbe = fmt.Sprintf("uprobe:%s:", bp.Binary)
+		}
+		// 内联函数中的断点每个内联副本一个探针块，共用断点编号
+		for _, site := range probe.Sites() {
+			fmt.Fprintf(&b, "%s%s\n%s{\n", entryProbe, dwarf.ProbeTarget(site.Function, site.Offset), predicate)
+			// 前缀与trace_pipe行相同：comm-tid [cpu] 秒.微秒: bpftrace: 消息
+			fmt.Fprintf(&b, "    printf(\"%%s-%%d [%%03d] %%llu.%%06llu: bpftrace: [BREAKPOINT-%d] %s:%d in %s() PID=%%d TGID=%%d\\n\",\n", id, fileName, bp.Line, bp.Function)
+			fmt.Fprintln(&b, "           comm, tid, cpu, nsecs / 1000000000, nsecs % 1000000000 / 1000, tid, pid);")
+			if bp.Binary == "" {
+				for _, v := range bpftraceVars(ctx, plan.Module, plan.Arch, site) {
+					fmt.Fprintf(&b, "    printf(\"[VAR-%d] %s:%s=%%lld PID=%%d\\n\", %s, tid);\n", id, bp.Function, v[0], v[1])
+				}
+			}
+			fmt.Fprintln(&b, "}")
+		}
+		if probe.ReturnProbe() {
+			retProbe := "kretprobe:"
+			if bp.Binary != "" {
+				retProbe = fmt.Sprintf("uretprobe:%s:", bp.Binary)
+			}
+			fmt.Fprintf(&b, "%s%s\n%s{\n", retProbe, bp.Function, predicate)
+			fmt.Fprintf(&b, "    printf(\"[RETVAL-%d] %s=%%lld PID=%%d\\n\", (int64)retval, tid);\n", id, bp.Function)
+			fmt.Fprintln(&b, "}")
+		}
+		fmt.Fprintln(&b, "")
+	}
+	if settings := ctx.Project.Settings; settings != nil && (len(settings.Spans) > 0 || settings.Locks) {
+		warnings = append(warnings, "span and lock probes are only generated for the bpf backend")
+	}
+	path := filepath.Join(ctx.Project.RootPath, BpftraceScriptFile)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", nil, fmt.Errorf("写入bpftrace脚本失败: %v", err)
+	}
+	return path, warnings, nil
+}
+
+// bpftrace输出中不是事件的行：错误、警告和附加探针的提示
+func handleBpftraceLine(ctx *session.DebuggerContext, line string) bool {
+	line = strings.TrimSpace(line)
+	if !bpftraceDiagnosticRegex.MatchString(line) {
+		return false
+	}
+	ctx.CommandHistory = append(ctx.CommandHistory, "[BPFTRACE] "+line)
+	session.NoteScriptDiagnostic(ctx, line)
+	for _, h := range bpftraceHints {
+		if strings.Contains(line, h.Match) {
+			ctx.CommandHistory = append(ctx.CommandHistory, "Hint: "+h.Hint)
+			break
+		}
+	}
+	ctx.CommandDirty = true
+	return true
+}
+
+// 运行bpftrace脚本，输出作为事件源
+func startBpftrace(ctx *session.DebuggerContext) (*session.ScriptProcess, string, error) {
+	if session.RemoteTarget(ctx) != nil {
+		return nil, "", errcode.Errorf(errcode.ErrInvalidArg, "bpftrace后端只支持本机内核，远程目标请使用bpf后端")
+	}
+	bpftrace, err := exec.LookPath("bpftrace")
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrToolMissing, "没有找到bpftrace，请安装bpftrace或使用 'backend kprobe'")
+	}
+	script, warnings, err := GenerateBpftraceScript(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, w := range warnings {
+		ctx.CommandHistory = append(ctx.CommandHistory, "Warning: "+w)
+	}
+	p, err := session.RunScriptProcess(ctx, "bpftrace", script, exec.Command(bpftrace, script), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, fmt.Sprintf("bpftrace %s (pid %d)", filepath.Base(script), p.Cmd.Process.Pid), nil
+}
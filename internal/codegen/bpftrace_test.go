@@ -0,0 +1,62 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestBpftracePredicate(t *testing.T) {
+	cpu := 1
+	tests := []struct {
+		filter *project.ProbeFilter
+		want   string
+	}{
+		{nil, ""},
+		{&project.ProbeFilter{PID: 42}, "/pid == 42/\n"},
+		{&project.ProbeFilter{Comm: "insmod", CPU: &cpu}, "/comm == \"insmod\" && cpu == 1/\n"},
+	}
+	for _, tt := range tests {
+		if got := bpftracePredicate(tt.filter); got != tt.want {
+			t.Errorf("bpftracePredicate(%+v) = %q, want %q", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestBpftraceVarExpr(t *testing.T) {
+	if got := bpftraceVarExpr("x86_64", project.VariableLocation{Type: "register", Register: "rdi", Size: 4}); got != `(int32)reg("di")` {
+		t.Errorf("register = %q", got)
+	}
+	if got := bpftraceVarExpr("x86_64", project.VariableLocation{Type: "optimized"}); got != "" {
+		t.Errorf("optimized = %q, want empty", got)
+	}
+}
+
+func TestGenerateBpftraceScript(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true, RetVal: true, Condition: "pid == 1"},
+		project.Breakpoint{Binary: "/usr/bin/app", Function: "main", Enabled: true},
+	)
+	path, warnings, err := GenerateBpftraceScript(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := readGenerated(t, path)
+	for _, want := range []string{
+		"kprobe:do_work\n",
+		"kretprobe:do_work\n",
+		"uprobe:/usr/bin/app:main\n",
+		"[BREAKPOINT-1] drv.c:11 in do_work()",
+		"[RETVAL-1] do_work=",
+		"[BREAKPOINT-2]",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("bpftrace script does not contain %q", want)
+		}
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "condition") {
+		t.Errorf("warnings = %q, want one condition warning", warnings)
+	}
+}
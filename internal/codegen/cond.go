@@ -0,0 +1,284 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 断点条件 ==========
+// 把 "arg0 > 1024 && pid == 1234" 这样的条件编译成生成的BPF代码中的过滤语句，
+// 条件不成立时探针直接返回，不输出事件也不提交寄存器快照。
+// 可用的名称：arg0..arg5（函数参数，按目标架构的调用约定从pt_regs读取）、
+// pid、tgid、cpu；运算符：|| && ! == != < <= > >= & | + - 和括号，整数支持十进制和0x十六进制。
+
+// 条件表达式的词法单元
+type condToken struct {
+	text string
+	pos  int
+}
+
+// 条件编译器
+type condCompiler struct {
+	tokens  []condToken
+	next    int
+	arch    string
+	usesArg bool
+}
+
+// 双字符运算符优先于单字符运算符匹配
+var condOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "&", "|", "+", "-", "(", ")"}
+
+// 拆分词法单元
+func tokenizeCondition(expr string) ([]condToken, error) {
+	tokens := make([]condToken, 0)
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case isCondWordChar(c):
+			start := i
+			for i < len(expr) && isCondWordChar(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, condToken{text: expr[start:i], pos: start})
+		default:
+			matched := ""
+			for _, op := range condOperators {
+				if strings.HasPrefix(expr[i:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, fmt.Errorf("第%d个字符: 不支持的符号 %q", i+1, c)
+			}
+			tokens = append(tokens, condToken{text: matched, pos: i})
+			i += len(matched)
+		}
+	}
+	return tokens, nil
+}
+
+func isCondWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// 把条件编译为C表达式（结果已完整加括号）
+// usesArg 表示条件读取了函数参数（只在函数入口可靠）
+func compileCondition(expr, arch string) (code string, usesArg bool, err error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return "", false, err
+	}
+	if len(tokens) == 0 {
+		return "", false, fmt.Errorf("条件为空")
+	}
+	c := &condCompiler{tokens: tokens, arch: dwarf.RegsArch(arch)}
+	code, err = c.parseOr()
+	if err != nil {
+		return "", false, err
+	}
+	if c.next < len(c.tokens) {
+		return "", false, c.unexpected()
+	}
+	return code, c.usesArg, nil
+}
+
+func (c *condCompiler) peek() string {
+	if c.next < len(c.tokens) {
+		return c.tokens[c.next].text
+	}
+	return ""
+}
+
+func (c *condCompiler) unexpected() error {
+	if c.next >= len(c.tokens) {
+		return fmt.Errorf("条件不完整")
+	}
+	t := c.tokens[c.next]
+	return fmt.Errorf("第%d个字符: 意外的 %q", t.pos+1, t.text)
+}
+
+// 左结合的二元运算层
+func (c *condCompiler) parseBinary(ops []string, operand func() (string, error)) (string, error) {
+	left, err := operand()
+	if err != nil {
+		return "", err
+	}
+	for {
+		op := c.peek()
+		found := false
+		for _, o := range ops {
+			if op == o {
+				found = true
+			}
+		}
+		if !found {
+			return left, nil
+		}
+		c.next++
+		right, err := operand()
+		if err != nil {
+			return "", err
+		}
+		left = fmt.Sprintf("(%s %s %s)", left, op, right)
+	}
+}
+
+func (c *condCompiler) parseOr() (string, error) {
+	return c.parseBinary([]string{"||"}, c.parseAnd)
+}
+
+func (c *condCompiler) parseAnd() (string, error) {
+	return c.parseBinary([]string{"&&"}, c.parseCompare)
+}
+
+// 比较不能连写（a < b < c 没有意义）
+func (c *condCompiler) parseCompare() (string, error) {
+	left, err := c.parseBits()
+	if err != nil {
+		return "", err
+	}
+	switch op := c.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		c.next++
+		right, err := c.parseBits()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, op, right), nil
+	}
+	return left, nil
+}
+
+// 位运算比比较结合得更紧（arg1 & 0x4 != 0 按直觉理解）
+func (c *condCompiler) parseBits() (string, error) {
+	return c.parseBinary([]string{"&", "|"}, c.parseSum)
+}
+
+func (c *condCompiler) parseSum() (string, error) {
+	return c.parseBinary([]string{"+", "-"}, c.parseUnary)
+}
+
+func (c *condCompiler) parseUnary() (string, error) {
+	switch op := c.peek(); op {
+	case "!", "-":
+		c.next++
+		operand, err := c.parseUnary()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s%s)", op, operand), nil
+	}
+	return c.parsePrimary()
+}
+
+func (c *condCompiler) parsePrimary() (string, error) {
+	if c.next >= len(c.tokens) {
+		return "", c.unexpected()
+	}
+	t := c.tokens[c.next]
+	switch {
+	case t.text == "(":
+		c.next++
+		inner, err := c.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if c.peek() != ")" {
+			return "", c.unexpected()
+		}
+		c.next++
+		return inner, nil
+	case t.text[0] >= '0' && t.text[0] <= '9':
+		value, err := strconv.ParseUint(t.text, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("第%d个字符: 无效的数字 %q", t.pos+1, t.text)
+		}
+		c.next++
+		if value > 1<<63-1 {
+			return fmt.Sprintf("%dULL", value), nil
+		}
+		return fmt.Sprintf("%dLL", value), nil
+	case isCondWordChar(t.text[0]):
+		code, err := c.operand(t)
+		if err != nil {
+			return "", err
+		}
+		c.next++
+		return code, nil
+	}
+	return "", c.unexpected()
+}
+
+// 名称对应的C表达式（参数按有符号数比较，负数参数也能写 arg1 < 0）
+func (c *condCompiler) operand(t condToken) (string, error) {
+	switch t.text {
+	case "pid":
+		return "(long long)event.pid", nil
+	case "tgid":
+		return "(long long)event.tgid", nil
+	case "cpu":
+		return "(long long)bpf_get_smp_processor_id()", nil
+	}
+	if strings.HasPrefix(t.text, "arg") {
+		n, err := strconv.Atoi(strings.TrimPrefix(t.text, "arg"))
+		if err != nil || n < 0 || n > 5 {
+			return "", fmt.Errorf("第%d个字符: 只支持 arg0..arg5", t.pos+1)
+		}
+		info := dwarf.TargetArchInfo(c.arch)
+		if info == nil {
+			return "", fmt.Errorf("目标架构 %s 不支持参数条件", c.arch)
+		}
+		c.usesArg = true
+		return "(long long)" + info.CtxRegister(info.Args[n]), nil
+	}
+	return "", fmt.Errorf("第%d个字符: 未知的名称 %q（可用 arg0..arg5、pid、tgid、cpu）", t.pos+1, t.text)
+}
+
+// 生成的BPF代码：条件不成立时直接返回
+func writeConditionFilter(file *os.File, arch string, bp project.Breakpoint) {
+	if bp.Condition == "" {
+		return
+	}
+	code, _, err := compileCondition(bp.Condition, arch)
+	if err != nil {
+		// 条件在 bp cond 时已校验，这里只会因目标架构变化失败
+		fmt.Fprintf(file, "    // 断点条件无效，已忽略: %s (%v)\n", bp.Condition, err)
+		return
+	}
+	fmt.Fprintf(file, "    // 断点条件: %s\n", bp.Condition)
+	fmt.Fprintf(file, "    if (!%s)\n", code)
+	fmt.Fprintln(file, "        return 0;")
+	fmt.Fprintln(file, "")
+}
+
+// 设置断点条件（n从1开始，空条件表示清除）；条件按目标架构编译校验后才保存
+// 返回条件是否读取了函数参数
+func SetBreakpointCondition(ctx *session.DebuggerContext, n int, condition string) (bool, error) {
+	if n < 1 || n > len(ctx.Project.Breakpoints) {
+		return false, fmt.Errorf("断点编号超出范围: %d (共%d个)", n, len(ctx.Project.Breakpoints))
+	}
+	condition = strings.Join(strings.Fields(condition), " ")
+	if len(condition) >= 2 && (condition[0] == '"' || condition[0] == '\'') && condition[len(condition)-1] == condition[0] {
+		condition = condition[1 : len(condition)-1]
+	}
+	usesArg := false
+	if condition != "" {
+		arch, _ := session.DetectTargetArch(ctx)
+		var err error
+		if _, usesArg, err = compileCondition(condition, arch); err != nil {
+			return false, errcode.Errorf(errcode.ErrInvalidArg, "条件无效: %v", err)
+		}
+	}
+	ctx.Project.Breakpoints[n-1].Condition = condition
+	return usesArg, project.SaveBreakpoints(ctx.Project)
+}
@@ -0,0 +1,29 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileCondition(t *testing.T) {
+	code, usesArg, err := compileCondition("arg0 > 0x400 && (pid == 1234 || !cpu)", "x86_64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !usesArg {
+		t.Error("usesArg = false, want true")
+	}
+	for _, want := range []string{"> 1024LL", "event.pid == 1234LL", "bpf_get_smp_processor_id()", "&&", "||"} {
+		if !strings.Contains(code, want) {
+			t.Errorf("code %q does not contain %q", code, want)
+		}
+	}
+	if _, usesArg, err := compileCondition("tgid != 1", "aarch64"); err != nil || usesArg {
+		t.Errorf("tgid != 1: usesArg=%v err=%v", usesArg, err)
+	}
+	for _, expr := range []string{"", "arg0 >", "(pid == 1", "foo == 1", "arg9 == 1", "pid = 1"} {
+		if _, _, err := compileCondition(expr, "x86_64"); err == nil {
+			t.Errorf("compileCondition(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 采集后端 ==========
+// bpf：默认，生成BPF程序（vars/generate + compile + bpf load），事件写入perf buffer（脚本加载时为trace_pipe）。
+// ftrace：不需要clang/bpftool，events start 时通过tracefs把断点所在函数写入set_graph_function，
+// 打开function_graph跟踪器，从trace_pipe解析调用图：进入断点函数时产生断点事件，并用
+// 当前的调用链填充调用栈窗口；events stop 时恢复跟踪器。
+// systemtap：events start 时为断点生成 .stp 脚本（statement探针，停在断点所在行）并运行stap，
+// 脚本输出与BPF程序相同格式的 [BREAKPOINT-N] 行；断点所在行被优化掉时退回函数入口探针，
+// 第一次从函数入口命中时输出一行 [FALLBACK-N]，在命令窗口中提示。断点行带与trace_pipe相同的
+// 前缀（进程名-线程号、CPU、内核时间戳），按trace_pipe行解析；stap的编译错误和警告（标准错误）
+// 与标准输出合并读取，显示在命令窗口中。stap的运行和退出状态见 session/stap.go。
+// kprobe：通过kprobe_events创建探针（见 kprobeevents.go）。
+// bpftrace：events start 时生成 .bt 脚本并运行bpftrace（见 bpftrace.go）。
+// perf：events start 时用 perf probe 创建探针并运行 perf record | perf script（见 perfprobe.go）。
+
+// 可用的后端
+func ValidBackend(name string) bool {
+	return name == session.BackendBPF || name == session.BackendFtrace || name == session.BackendSystemtap || name == session.BackendKprobe || name == session.BackendBpftrace || name == session.BackendPerf
+}
+
+// 已布防的断点函数：函数名 -> 事件中的断点编号（与生成的BPF程序的编号一致）
+type armedFunction struct {
+	ID         int
+	Breakpoint project.Breakpoint
+}
+
+func armedFunctions(ctx *session.DebuggerContext) (map[string]armedFunction, []string) {
+	armed := make(map[string]armedFunction)
+	order := make([]string, 0)
+	if ctx.Project == nil {
+		return armed, order
+	}
+	for _, probe := range BuildProbePlan(ctx, false).Probes {
+		bp := probe.Breakpoint
+		if bp.Binary != "" {
+			// 用户态函数不在内核中，只占用编号
+			continue
+		}
+		if _, ok := armed[bp.Function]; !ok {
+			armed[bp.Function] = armedFunction{ID: probe.ID, Breakpoint: bp}
+			order = append(order, bp.Function)
+		}
+	}
+	return armed, order
+}
+
+// 写tracefs控制文件
+func writeTracing(root, name, value string) error {
+	if err := os.WriteFile(filepath.Join(root, name), []byte(value+"\n"), 0644); err != nil {
+		if os.IsPermission(err) {
+			return errcode.Errorf(errcode.ErrPerm, "写入 %s 失败（需要root）: %v", name, err)
+		}
+		return fmt.Errorf("写入 %s 失败: %v", name, err)
+	}
+	return nil
+}
+
+// 打开function_graph跟踪断点所在的函数
+func armFtrace(ctx *session.DebuggerContext) error {
+	if session.RemoteTarget(ctx) != nil {
+		return errcode.Errorf(errcode.ErrInvalidArg, "ftrace后端只支持本机内核，远程目标请使用bpf后端")
+	}
+	_, functions := armedFunctions(ctx)
+	if len(functions) == 0 {
+		return errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	root, err := session.TracingRoot()
+	if err != nil {
+		return err
+	}
+	steps := [][2]string{
+		{"tracing_on", "0"},
+		{"current_tracer", "nop"},
+		{"set_graph_function", strings.Join(functions, " ")},
+		{"options/funcgraph-proc", "1"},
+		{"options/funcgraph-tail", "1"},
+		{"current_tracer", "function_graph"},
+		{"tracing_on", "1"},
+	}
+	for _, step := range steps {
+		if err := writeTracing(root, step[0], step[1]); err != nil {
+			if step[0] == "set_graph_function" {
+				err = errcode.Errorf(errcode.ErrNoSymbol, "%v（函数不在available_filter_functions中，模块是否已加载？）", err)
+			}
+			disarmFtrace(ctx)
+			return err
+		}
+	}
+	ctx.FtraceRoot = root
+	ctx.GraphStacks = make(map[int][]string)
+	return nil
+}
+
+// 恢复跟踪器（关闭function_graph并清空set_graph_function）
+func disarmFtrace(ctx *session.DebuggerContext) {
+	root := ctx.FtraceRoot
+	if root == "" {
+		var err error
+		if root, err = session.TracingRoot(); err != nil {
+			return
+		}
+	}
+	writeTracing(root, "tracing_on", "0")
+	writeTracing(root, "current_tracer", "nop")
+	// 以截断方式打开即清空列表
+	os.WriteFile(filepath.Join(root, "set_graph_function"), nil, 0644)
+	writeTracing(root, "tracing_on", "1")
+	ctx.FtraceRoot = ""
+	ctx.GraphStacks = nil
+}
+
+var (
+	//  1)   insmod-1234   |   0.300 us    |    helper();
+	graphLineRegex  = regexp.MustCompile(`^\s*(\d+)\)\s+(?:(\S.*?)-(\d+)\s+)?\|\s*(?:[+!#*@$]\s*)?(?:[\d.]+\s+us\s+)?\|(\s*)(\S.*)$`)
+	graphEntryRegex = regexp.MustCompile(`^([\w.]+)(?: \[[\w-]+\])?\(\)\s*(\{|;)$`)
+	graphExitRegex  = regexp.MustCompile(`^\}(?: /\* ([\w.]+)(?: \[[\w-]+\])? \*/)?$`)
+)
+
+// 解析function_graph输出的一行：维护每个CPU的调用链，进入断点函数时加入断点事件
+// 不是function_graph格式时返回false
+func handleGraphLine(ctx *session.DebuggerContext, line string) bool {
+	m := graphLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	cpu := 0
+	fmt.Sscanf(m[1], "%d", &cpu)
+	body := m[5]
+	stack := ctx.GraphStacks[cpu]
+	// 第0层前有两个空格，每深一层多两个空格；按缩进截断调用链，
+	// 这样丢失的行（trace_pipe溢出）不会让调用链一直错位
+	depth := (len(m[4]) - 2) / 2
+	if depth < 0 {
+		depth = 0
+	}
+	if depth < len(stack) {
+		stack = stack[:depth]
+	}
+
+	if graphExitRegex.MatchString(body) {
+		ctx.GraphStacks[cpu] = stack
+		return true
+	}
+	entry := graphEntryRegex.FindStringSubmatch(body)
+	if entry == nil {
+		return true
+	}
+	function := entry[1]
+	stack = append(stack, function)
+	armed, _ := armedFunctions(ctx)
+	if af, ok := armed[function]; ok {
+		event := session.DebugEvent{
+			Kind:         "breakpoint",
+			Time:         time.Now(),
+			BreakpointID: af.ID,
+			Location:     fmt.Sprintf("%s:%d", filepath.Base(af.Breakpoint.File), af.Breakpoint.Line),
+			Function:     function,
+			Comm:         strings.TrimSpace(m[2]),
+			CPU:          cpu,
+			Raw:          line,
+		}
+		fmt.Sscanf(m[3], "%d", &event.PID)
+		session.AppendEvent(ctx, event)
+		ctx.StackFrames = graphStackFrames(ctx, stack)
+	}
+	if entry[2] == ";" {
+		// 叶子函数在同一行返回
+		stack = stack[:len(stack)-1]
+	}
+	ctx.GraphStacks[cpu] = stack
+	return true
+}
+
+// 把调用链转换为调用栈（最内层在前），源码位置来自断点或模块的DWARF信息
+func graphStackFrames(ctx *session.DebuggerContext, stack []string) []session.StackFrame {
+	armed, _ := armedFunctions(ctx)
+	resolver, _ := session.ProjectLineResolver(ctx)
+	frames := make([]session.StackFrame, 0, len(stack))
+	for i := len(stack) - 1; i >= 0; i-- {
+		frame := session.StackFrame{Function: stack[i]}
+		if af, ok := armed[stack[i]]; ok {
+			frame.File, frame.Line = af.Breakpoint.File, af.Breakpoint.Line
+		} else if resolver != nil {
+			frame.File, frame.Line = resolver.FunctionDecl(stack[i])
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// ========== systemtap ==========
+
+// 行被优化掉、退回函数入口时脚本输出的提示行
+var stapFallbackRegex = regexp.MustCompile(`^\[FALLBACK-(\d+)\]\s+(.*)$`)
+
+// stap标准错误中需要显示的诊断（解析阶段的错误、警告和失败）
+var stapDiagnosticRegex = regexp.MustCompile(`^(?:semantic error|parse error|ERROR|WARNING|Pass \d+: .*failed)`)
+
+// 生成systemtap脚本：每个断点一个statement探针（找不到该行时用函数入口探针），输出与BPF程序相同格式的断点行
+func GenerateSystemtapScript(ctx *session.DebuggerContext) (string, error) {
+	plan := BuildProbePlan(ctx, false)
+	if len(plan.Probes) == 0 {
+		return "", errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	target := "kernel"
+	if plan.Module != "" {
+		target = fmt.Sprintf("module(\"%s\")", strings.TrimSuffix(filepath.Base(plan.Module), ".ko"))
+	}
+	path := filepath.Join(ctx.Project.RootPath, "debug_breakpoints.stp")
+	var b strings.Builder
+	fmt.Fprintln(&b, "#!/usr/bin/env stap")
+	fmt.Fprintln(&b, "// 自动生成的systemtap调试脚本")
+	fmt.Fprintln(&b, "// 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintln(&b, "")
+	fmt.Fprintln(&b, "// 已提示过退回函数入口的断点")
+	fmt.Fprintln(&b, "global fallback_reported")
+	fmt.Fprintln(&b, "")
+	for _, probe := range plan.Probes {
+		bp, id := probe.Breakpoint, probe.ID
+		fileName := probe.FileName()
+		fmt.Fprintf(&b, "// 断点 %d: %s:%d 在函数 %s\n", id, fileName, bp.Line, bp.Function)
+		probeTarget, function := target, bp.Function
+		if bp.Binary != "" {
+			probeTarget = fmt.Sprintf("process(\"%s\")", bp.Binary)
+		} else if bp.InlinedFrom != "" {
+			// systemtap按源码行自己找到被内联函数的每个副本
+			function = bp.InlinedFrom
+		}
+		// "!"：statement探针能解析时不再尝试后面的函数入口探针
+		fmt.Fprintf(&b, "probe %s.statement(\"%s@%s:%d\") !,\n", probeTarget, function, fileName, bp.Line)
+		fmt.Fprintf(&b, "      %s.function(\"%s\") {\n", probeTarget, function)
+		fmt.Fprintf(&b, "    if (isinstr(pp(), \".function(\") && !([%d] in fallback_reported)) {\n", id)
+		fmt.Fprintf(&b, "        fallback_reported[%d] = 1\n", id)
+		fmt.Fprintf(&b, "        printf(\"[FALLBACK-%d] %s:%d has no code (optimized away), probing %s() entry instead\\n\")\n", id, fileName, bp.Line, function)
+		fmt.Fprintln(&b, "    }")
+		// 前缀与trace_pipe行相同：comm-tid [cpu] 秒.微秒: stap: 消息
+		fmt.Fprintln(&b, "    t = local_clock_ns()")
+		fmt.Fprintf(&b, "    printf(\"%%s-%%d [%%03d] %%d.%%06d: stap: [BREAKPOINT-%d] %s:%d in %s() PID=%%d TGID=%%d\\n\",\n", id, fileName, bp.Line, bp.Function)
+		fmt.Fprintln(&b, "           execname(), tid(), cpu(), t / 1000000000, t % 1000000000 / 1000, tid(), pid())")
+		fmt.Fprintln(&b, "}")
+		fmt.Fprintln(&b, "")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("写入systemtap脚本失败: %v", err)
+	}
+	return path, nil
+}
+
+// systemtap输出中不是事件的行：退回函数入口的提示
+func handleSystemtapLine(ctx *session.DebuggerContext, line string) bool {
+	line = strings.TrimSpace(line)
+	if m := stapFallbackRegex.FindStringSubmatch(line); m != nil {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: bp%s: %s", m[1], m[2]))
+		ctx.CommandDirty = true
+		return true
+	}
+	if stapDiagnosticRegex.MatchString(line) {
+		ctx.CommandHistory = append(ctx.CommandHistory, "[STAP] "+line)
+		ctx.CommandHistory = append(ctx.CommandHistory, session.NoteStapDiagnostic(ctx, line)...)
+		ctx.CommandDirty = true
+		return true
+	}
+	return false
+}
+
+// 运行systemtap脚本，标准输出作为事件源
+func startSystemtap(ctx *session.DebuggerContext) (*session.ScriptProcess, string, error) {
+	if session.RemoteTarget(ctx) != nil {
+		return nil, "", errcode.Errorf(errcode.ErrInvalidArg, "systemtap后端只支持本机内核，远程目标请使用bpf后端")
+	}
+	stap, err := exec.LookPath("stap")
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrToolMissing, "没有找到stap，请安装systemtap或使用 'backend ftrace'")
+	}
+	script, err := GenerateSystemtapScript(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	p, err := session.RunScriptProcess(ctx, "stap", script, exec.Command(stap, script), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, fmt.Sprintf("stap %s (pid %d)", filepath.Base(script), p.Cmd.Process.Pid), nil
+}
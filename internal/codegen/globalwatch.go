@@ -0,0 +1,32 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/session"
+)
+
+// ========== 全局变量监视点 ==========
+
+// 生成的BPF代码：在探针中读取监视的全局变量
+func writeGlobalWatchReads(file *os.File, globals []*dwarf.GlobalSymbol, breakpointID int, funcName string) {
+	if len(globals) == 0 {
+		return
+	}
+	fmt.Fprintln(file, "    // 监视的全局变量（地址来自生成时的 /proc/kallsyms）")
+	for _, sym := range globals {
+		fmt.Fprintf(file, "    {\n")
+		fmt.Fprintf(file, "        %s value = 0; // %s\n", dwarf.GlobalCType(sym), sym.Type)
+		fmt.Fprintf(file, "        bpf_probe_read_kernel(&value, sizeof(value), (void *)0x%xULL);\n", sym.Addr)
+		session.WriteDebugVarOutput(file, "        ", sym.Name, "value", sym.Signed)
+		if sym.Signed {
+			fmt.Fprintf(file, "        debug_printk(\"[VAR-%d] %s:%s=%%lld PID=%%d\\n\", (long long)value, event.pid);\n", breakpointID, funcName, sym.Name)
+		} else {
+			fmt.Fprintf(file, "        debug_printk(\"[VAR-%d] %s:%s=%%llu PID=%%d\\n\", (unsigned long long)value, event.pid);\n", breakpointID, funcName, sym.Name)
+		}
+		fmt.Fprintf(file, "    }\n")
+	}
+	fmt.Fprintln(file, "")
+}
@@ -0,0 +1,272 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== kprobe_events 后端 ==========
+// 不能加载自定义BPF目标文件的内核（没有BTF、没有CAP_BPF、内核配置关闭了BPF）仍然可以
+// 通过tracefs的kprobe_events动态创建探针。events start 时为每个断点写入一条
+//   p:debug_tui/bpN [mod:]func[+0x1c] name=%di:s64 other=-20(%bp):s64
+// fetch-args来自模块DWARF中的变量位置，bp retval 的断点额外写入 r: 返回探针；
+// 命中行同样进入trace_pipe，events stop 时删除探针。
+
+// 探针所在的事件组
+const kprobeGroup = "debug_tui"
+
+// 单个探针最多采集的变量数
+const maxKprobeFetchArgs = 16
+
+// 断点的探针位置：kprobe_events的符号写法 [MOD:]SYM[+offs]
+func kprobeEventTarget(module string, bp project.Breakpoint) string {
+	target := dwarf.ProbeTarget(bp.Function, bp.Offset)
+	if module != "" {
+		target = strings.TrimSuffix(filepath.Base(module), ".ko") + ":" + target
+	}
+	return target
+}
+
+// 变量位置转换为fetch-arg，无法表示时返回空
+func kprobeFetchArg(arch string, loc project.VariableLocation) string {
+	size := loc.Size
+	if size != 1 && size != 2 && size != 4 && size != 8 {
+		size = 8
+	}
+	info := dwarf.TargetArchInfo(arch)
+	switch loc.Type {
+	case "register":
+		return fmt.Sprintf("%s=%%%s:s%d", loc.Name, info.KprobeName(loc.Register), size*8)
+	case "stack":
+		// 位置中没有基址寄存器时与BPF生成器一样按帧指针近似
+		base := loc.Register
+		if base == "" && info != nil {
+			base = info.FP
+		}
+		if base == "" {
+			return ""
+		}
+		base = info.KprobeName(base)
+		return fmt.Sprintf("%s=%+d(%%%s):s%d", loc.Name, loc.StackOffset, base, size*8)
+	}
+	return ""
+}
+
+// 断点处要采集的变量：函数中出现的变量和监视表达式，只保留DWARF中能找到位置的
+func kprobeFetchArgs(ctx *session.DebuggerContext, module, arch string, bp project.Breakpoint) []string {
+	if module == "" {
+		return nil
+	}
+	names := ParseAllFunctionVariables(bp.File, bp.Line)
+	for _, expr := range session.WatchExpressions(ctx) {
+		names = append(names, expr)
+	}
+	locations := dwarf.ParseRealDWARF(module, bp, names)
+	args := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		loc, ok := locations[name]
+		if !ok || seen[name] || len(args) >= maxKprobeFetchArgs {
+			continue
+		}
+		seen[name] = true
+		if arg := kprobeFetchArg(arch, loc); arg != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// kprobe_events的全部探针定义（bpN为断点编号，与事件中的断点编号一致）
+func KprobeEventDefinitions(ctx *session.DebuggerContext, plan *ProbePlan) []string {
+	defs := make([]string, 0)
+	for _, probe := range plan.Probes {
+		bp, id := probe.Breakpoint, probe.ID
+		if bp.Binary != "" {
+			// 用户态探针只在bpf和systemtap后端中生成，这里只占用编号
+			continue
+		}
+		// 内联函数中的断点每个内联副本一个探针，共用断点编号
+		for s, site := range probe.Sites() {
+			def := fmt.Sprintf("p:%s/%s %s", kprobeGroup, kprobeSiteName(id, s), kprobeEventTarget(plan.Module, site))
+			if args := kprobeFetchArgs(ctx, plan.Module, plan.Arch, site); len(args) > 0 {
+				def += " " + strings.Join(args, " ")
+			}
+			defs = append(defs, def)
+		}
+		if probe.ReturnProbe() {
+			// 返回探针挂在函数上，不带偏移
+			entry := bp
+			entry.Offset = 0
+			defs = append(defs, fmt.Sprintf("r:%s/ret%d %s ret=$retval:s64", kprobeGroup, id, kprobeEventTarget(plan.Module, entry)))
+		}
+	}
+	return defs
+}
+
+// 追加写入kprobe_events（每条定义单独写入，出错时能定位到具体探针）
+func appendKprobeEvent(root, def string) error {
+	file, err := os.OpenFile(filepath.Join(root, "kprobe_events"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		if os.IsPermission(err) {
+			return errcode.Errorf(errcode.ErrPerm, "打开kprobe_events失败（需要root）: %v", err)
+		}
+		return errcode.Errorf(errcode.ErrTracePipe, "打开kprobe_events失败: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(def + "\n"); err != nil {
+		return errcode.Errorf(errcode.ErrBPFAttach, "%s: %v", def, err)
+	}
+	return nil
+}
+
+// 创建并启用断点探针，返回没有成功应用的条件等警告
+func armKprobeEvents(ctx *session.DebuggerContext) ([]string, error) {
+	if session.RemoteTarget(ctx) != nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "kprobe后端只支持本机内核，远程目标请使用bpf后端")
+	}
+	root, err := session.TracingRoot()
+	if err != nil {
+		return nil, err
+	}
+	plan := BuildProbePlan(ctx, true)
+	defs := KprobeEventDefinitions(ctx, plan)
+	if len(defs) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	// 清掉上次异常退出留下的同组探针
+	ctx.KprobeEvents = kprobeEventNames(defs)
+	ctx.FtraceRoot = root
+	disarmKprobeEvents(ctx)
+
+	warnings := make([]string, 0)
+	created := make([]string, 0, len(defs))
+	for _, def := range defs {
+		if err := appendKprobeEvent(root, def); err != nil {
+			ctx.KprobeEvents = created
+			ctx.FtraceRoot = root
+			disarmKprobeEvents(ctx)
+			return nil, err
+		}
+		created = append(created, kprobeEventNames([]string{def})[0])
+	}
+	ctx.KprobeEvents = created
+	ctx.FtraceRoot = root
+
+	// 断点条件写入事件过滤器（只支持采集到的变量和pid）
+	for _, probe := range plan.Probes {
+		bp, id := probe.Breakpoint, probe.ID
+		if bp.Binary != "" {
+			warnings = append(warnings, fmt.Sprintf("bp%d: %s not armed (uprobes need the bpf or systemtap backend)", id, BreakpointTarget(bp)))
+			continue
+		}
+		if bp.Condition == "" {
+			continue
+		}
+		filter := kprobePidRegex.ReplaceAllString(bp.Condition, "common_pid")
+		for s := range probe.Sites() {
+			name := kprobeSiteName(id, s)
+			if err := writeTracing(root, fmt.Sprintf("events/%s/%s/filter", kprobeGroup, name), filter); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: condition '%s' not applied (kprobe filters only see fetched variables and pid)", name, bp.Condition))
+			}
+		}
+	}
+	if err := writeTracing(root, fmt.Sprintf("events/%s/enable", kprobeGroup), "1"); err != nil {
+		disarmKprobeEvents(ctx)
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// 断点探针名：bpN，内联断点的其他副本为 bpN_1、bpN_2...
+func kprobeSiteName(id, site int) string {
+	if site == 0 {
+		return fmt.Sprintf("bp%d", id)
+	}
+	return fmt.Sprintf("bp%d_%d", id, site)
+}
+
+// 定义中的探针名（p:debug_tui/bp1 ... -> bp1）
+func kprobeEventNames(defs []string) []string {
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		head := strings.Fields(def)[0]
+		names = append(names, head[strings.Index(head, "/")+1:])
+	}
+	return names
+}
+
+// 关闭并删除断点探针
+func disarmKprobeEvents(ctx *session.DebuggerContext) {
+	root := ctx.FtraceRoot
+	if root == "" {
+		return
+	}
+	writeTracing(root, fmt.Sprintf("events/%s/enable", kprobeGroup), "0")
+	for _, name := range ctx.KprobeEvents {
+		appendKprobeEvent(root, fmt.Sprintf("-:%s/%s", kprobeGroup, name))
+	}
+	ctx.KprobeEvents = nil
+	ctx.FtraceRoot = ""
+}
+
+var (
+	//  insmod-1234 [000] d.... 12.345678: bp1: (drv_probe+0x1c/0x40) len=5 ret=-1
+	kprobeEventRegex = regexp.MustCompile(`:\s+(bp|ret)(\d+)(?:_\d+)?:\s+\(([^)]*)\)\s*(.*)$`)
+	kprobeArgRegex   = regexp.MustCompile(`(\w+)=(\S+)`)
+	kprobePidRegex   = regexp.MustCompile(`\bpid\b`)
+)
+
+// 解析kprobe_events探针的输出行（命中加入断点事件，返回探针加入返回值事件）
+func handleKprobeEventLine(ctx *session.DebuggerContext, line string) bool {
+	m := kprobeEventRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	event := session.DebugEvent{Raw: line, Time: time.Now(), CPU: -1}
+	if pm := session.TracePipeLineRegex.FindStringSubmatch(line); pm != nil {
+		event.Comm = strings.TrimSpace(pm[1])
+		fmt.Sscanf(pm[2], "%d", &event.PID)
+		fmt.Sscanf(pm[3], "%d", &event.CPU)
+		fmt.Sscanf(pm[4], "%f", &event.TraceTime)
+	}
+	fmt.Sscanf(m[2], "%d", &event.BreakpointID)
+	for _, arg := range kprobeArgRegex.FindAllStringSubmatch(m[4], -1) {
+		event.Values = append(event.Values, project.EventValue{Name: arg[1], Value: arg[2]})
+	}
+	// 返回探针的位置是 "caller+0x12/0x50 <- func"
+	where := m[3]
+	if arrow := strings.Index(where, "<- "); arrow >= 0 {
+		where = where[arrow+3:]
+	}
+	event.Function, _ = dwarf.SplitProbeOffset(strings.SplitN(where, "/", 2)[0])
+	if colon := strings.Index(event.Function, ":"); colon >= 0 {
+		event.Function = event.Function[colon+1:]
+	}
+
+	if m[1] == "ret" {
+		event.Kind = "return"
+		for i, v := range event.Values {
+			if v.Name == "ret" {
+				event.Values = []project.EventValue{{Name: "return", Value: event.Values[i].Value}}
+				break
+			}
+		}
+	} else {
+		event.Kind = "breakpoint"
+		if bp := session.ArmedBreakpoint(ctx, event.BreakpointID); bp != nil {
+			event.Location = fmt.Sprintf("%s:%d", filepath.Base(bp.File), bp.Line)
+		}
+	}
+	session.AppendEvent(ctx, event)
+	return true
+}
@@ -0,0 +1,243 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== perf probe 后端 ==========
+// perf probe 自己读模块的DWARF：按 源文件:行号 放探针、按变量名取值（包括 dev->id 这样的成员），
+// 不需要我们解析变量位置，也不需要clang/bpftool。events start 时为每个断点执行
+//   perf probe -m drv.ko --add 'debug_tui_perf:bp1=drv.c:13 a x'
+// （bp retval 再加 ret1=do_work%return ret=$retval，用户态断点用 -x 程序），
+// 变量在该行取不到时去掉变量重试；然后运行
+//   perf record -e 'debug_tui_perf:*' -a -o - | perf script -i - -F comm,tid,cpu,time,event,trace
+// 解析perf script的输出行加入事件。perf退出后删除探针（记录中的探针不能删除）。
+
+// 探针所在的事件组（与kprobe后端的组分开）
+const perfProbeGroup = "debug_tui_perf"
+
+// gen 生成的探针命令
+const perfProbesScript = "debug_perf_probes.sh"
+
+// perf script 的输出行：comm tid [cpu] time: group:bpN[_k]: (ip) name=value ...
+var perfScriptLineRegex = regexp.MustCompile(`^\s*(.+?)\s+(\d+)\s+\[(\d+)\]\s+(\d+\.\d+):\s+` + perfProbeGroup + `:(bp|ret)(\d+)(?:_\d+)?:\s+\(([^)]*)\)\s*(.*)$`)
+
+// perf输出中需要显示的诊断
+var perfDiagnosticRegex = regexp.MustCompile(`^(?:Error|Warning|Failed|\[ perf record:)`)
+
+// 单个perf探针
+type perfProbeDef struct {
+	Name   string   // bpN 或 retN
+	Spec   string   // drv.c:13 或 do_work%return
+	Vars   []string // 变量名（或 name=fetcharg）
+	Binary string   // 用户态程序（为空表示内核模块）
+}
+
+// perf probe 的参数
+func (def perfProbeDef) args(module string) []string {
+	spec := fmt.Sprintf("%s:%s=%s", perfProbeGroup, def.Name, def.Spec)
+	if len(def.Vars) > 0 {
+		spec += " " + strings.Join(def.Vars, " ")
+	}
+	if def.Binary != "" {
+		return []string{"probe", "-x", def.Binary, "--add", spec}
+	}
+	return []string{"probe", "-m", module, "--add", spec}
+}
+
+// 断点处要读取的变量：函数中出现的变量和监视表达式（perf probe 自己解析位置）
+func perfProbeVars(ctx *session.DebuggerContext, bp project.Breakpoint) []string {
+	names := ParseAllFunctionVariables(bp.File, bp.Line)
+	names = append(names, session.WatchExpressions(ctx)...)
+	vars := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] || strings.ContainsAny(name, " ()[]*&") || len(vars) >= maxKprobeFetchArgs {
+			continue
+		}
+		seen[name] = true
+		vars = append(vars, name)
+	}
+	return vars
+}
+
+// 全部perf探针（bpN为断点编号，与事件中的断点编号一致）
+func perfProbeDefinitions(ctx *session.DebuggerContext) []perfProbeDef {
+	defs := make([]perfProbeDef, 0)
+	for _, probe := range BuildProbePlan(ctx, false).Probes {
+		bp, id := probe.Breakpoint, probe.ID
+		def := perfProbeDef{Name: fmt.Sprintf("bp%d", id), Binary: bp.Binary}
+		if bp.Binary != "" {
+			// 用户态程序不一定带调试信息，探针挂在函数入口
+			def.Spec = bp.Function
+		} else {
+			// perf按行号表找到该行（包括内联副本）
+			def.Spec = fmt.Sprintf("%s:%d", probe.FileName(), bp.Line)
+			def.Vars = perfProbeVars(ctx, bp)
+		}
+		defs = append(defs, def)
+		if probe.ReturnProbe() {
+			defs = append(defs, perfProbeDef{Name: fmt.Sprintf("ret%d", id), Spec: bp.Function + "%return", Vars: []string{"ret=$retval"}, Binary: bp.Binary})
+		}
+	}
+	return defs
+}
+
+// perf输出的最后一行（错误信息）
+func perfLastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// 删除本后端创建的全部探针
+func deletePerfProbes(perf string) {
+	exec.Command(perf, "probe", "-q", "-d", perfProbeGroup+":*").Run()
+}
+
+// 创建perf探针，返回创建的探针名和提示
+func armPerfProbes(ctx *session.DebuggerContext, perf, module string) ([]string, []string, error) {
+	defs := perfProbeDefinitions(ctx)
+	if len(defs) == 0 {
+		return nil, nil, errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	// 上一次异常退出留下的探针
+	deletePerfProbes(perf)
+	names := make([]string, 0, len(defs))
+	warnings := make([]string, 0)
+	for _, def := range defs {
+		output, err := exec.Command(perf, def.args(module)...).CombinedOutput()
+		if err != nil && len(def.Vars) > 0 && !strings.HasPrefix(def.Name, "ret") {
+			// 变量在该行被优化掉或不可见时，只放探针
+			warnings = append(warnings, fmt.Sprintf("%s: variables not available (%s), probing without them", def.Name, perfLastLine(output)))
+			def.Vars = nil
+			output, err = exec.Command(perf, def.args(module)...).CombinedOutput()
+		}
+		if err != nil {
+			deletePerfProbes(perf)
+			if os.Geteuid() != 0 {
+				return nil, nil, errcode.Errorf(errcode.ErrPerm, "perf probe %s 失败（需要root）: %s", def.Spec, perfLastLine(output))
+			}
+			return nil, nil, fmt.Errorf("perf probe %s 失败: %s", def.Spec, perfLastLine(output))
+		}
+		names = append(names, def.Name)
+	}
+	return names, warnings, nil
+}
+
+// perf probe 命令和记录命令写成脚本（gen 和在别处手动运行时使用）
+func WritePerfProbesScript(ctx *session.DebuggerContext, module string) (string, error) {
+	defs := perfProbeDefinitions(ctx)
+	if len(defs) == 0 {
+		return "", errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+	}
+	var b strings.Builder
+	fmt.Fprintln(&b, "#!/bin/sh")
+	fmt.Fprintln(&b, "# 自动生成的perf probe调试脚本")
+	fmt.Fprintln(&b, "# 生成时间:", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "perf probe -q -d '%s:*' 2>/dev/null\n", perfProbeGroup)
+	for _, def := range defs {
+		args := def.args(module)
+		for i := range args {
+			args[i] = session.ShellQuote(args[i])
+		}
+		fmt.Fprintf(&b, "perf %s || exit 1\n", strings.Join(args, " "))
+	}
+	fmt.Fprintf(&b, "trap \"perf probe -q -d '%s:*'\" EXIT\n", perfProbeGroup)
+	fmt.Fprintf(&b, "%s\n", perfRecordPipeline("perf"))
+	path := filepath.Join(ctx.Project.RootPath, perfProbesScript)
+	if err := os.WriteFile(path, []byte(b.String()), 0755); err != nil {
+		return "", fmt.Errorf("写入perf探针脚本失败: %v", err)
+	}
+	return path, nil
+}
+
+// 记录并实时转换为文本的管道
+func perfRecordPipeline(perf string) string {
+	return fmt.Sprintf("%s record -q -e '%s:*' -a -o - | %s script -i - -F comm,tid,cpu,time,event,trace", perf, perfProbeGroup, perf)
+}
+
+// 创建探针并运行 perf record | perf script，输出作为事件源
+func startPerfProbe(ctx *session.DebuggerContext) (*session.ScriptProcess, string, error) {
+	if session.RemoteTarget(ctx) != nil {
+		return nil, "", errcode.Errorf(errcode.ErrInvalidArg, "perf后端只支持本机内核，远程目标请使用bpf后端")
+	}
+	perf, err := exec.LookPath("perf")
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrToolMissing, "没有找到perf，请安装perf（linux-tools）或使用 'backend kprobe'")
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return nil, "", errcode.Errorf(errcode.ErrNotFound, "没有找到项目的 .ko，perf probe需要模块的调试信息")
+	}
+	script, err := WritePerfProbesScript(ctx, module)
+	if err != nil {
+		return nil, "", err
+	}
+	names, warnings, err := armPerfProbes(ctx, perf, module)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, w := range warnings {
+		ctx.CommandHistory = append(ctx.CommandHistory, "Warning: "+w)
+	}
+	cmd := exec.Command("sh", "-c", perfRecordPipeline(session.ShellQuote(perf)))
+	p, err := session.RunScriptProcess(ctx, "perf", script, cmd, func() { deletePerfProbes(perf) })
+	if err != nil {
+		deletePerfProbes(perf)
+		return nil, "", err
+	}
+	return p, fmt.Sprintf("perf record (%d probes in %s)", len(names), perfProbeGroup), nil
+}
+
+// 解析perf script的输出行（命中加入断点事件，返回探针加入返回值事件），其他诊断显示在命令窗口
+func handlePerfScriptLine(ctx *session.DebuggerContext, line string) bool {
+	m := perfScriptLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		line = strings.TrimSpace(line)
+		if !perfDiagnosticRegex.MatchString(line) {
+			return false
+		}
+		ctx.CommandHistory = append(ctx.CommandHistory, "[PERF] "+line)
+		session.NoteScriptDiagnostic(ctx, line)
+		ctx.CommandDirty = true
+		return true
+	}
+	event := session.DebugEvent{Raw: line, Time: time.Now(), Comm: strings.TrimSpace(m[1])}
+	fmt.Sscanf(m[2], "%d", &event.PID)
+	fmt.Sscanf(m[3], "%d", &event.CPU)
+	fmt.Sscanf(m[4], "%f", &event.TraceTime)
+	fmt.Sscanf(m[6], "%d", &event.BreakpointID)
+	for _, arg := range kprobeArgRegex.FindAllStringSubmatch(m[8], -1) {
+		event.Values = append(event.Values, project.EventValue{Name: arg[1], Value: arg[2]})
+	}
+	bp := session.ArmedBreakpoint(ctx, event.BreakpointID)
+	if bp != nil {
+		event.Function = bp.Function
+	}
+	if m[5] == "ret" {
+		event.Kind = "return"
+		for _, v := range event.Values {
+			if v.Name == "ret" {
+				event.Values = []project.EventValue{{Name: "return", Value: v.Value}}
+				break
+			}
+		}
+	} else {
+		event.Kind = "breakpoint"
+		if bp != nil {
+			event.Location = fmt.Sprintf("%s:%d", filepath.Base(bp.File), bp.Line)
+		}
+	}
+	session.AppendEvent(ctx, event)
+	return true
+}
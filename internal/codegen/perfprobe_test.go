@@ -0,0 +1,49 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestPerfProbeDefinitions(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true, RetVal: true},
+		project.Breakpoint{Binary: "/usr/bin/app", Function: "main", Enabled: true},
+	)
+	defs := perfProbeDefinitions(ctx)
+	if len(defs) != 3 {
+		t.Fatalf("defs = %+v, want 3 probes", defs)
+	}
+	if defs[0].Name != "bp1" || defs[0].Spec != "drv.c:11" || !strings.Contains(strings.Join(defs[0].Vars, " "), "sum") {
+		t.Errorf("bp1 = %+v", defs[0])
+	}
+	if defs[1].Name != "ret1" || defs[1].Spec != "do_work%return" {
+		t.Errorf("ret1 = %+v", defs[1])
+	}
+	// 用户态断点挂在函数入口，不读变量
+	if defs[2].Name != "bp2" || defs[2].Spec != "main" || len(defs[2].Vars) != 0 {
+		t.Errorf("bp2 = %+v", defs[2])
+	}
+
+	args := strings.Join(defs[0].args("drv"), " ")
+	if !strings.HasPrefix(args, "probe -m drv --add "+perfProbeGroup+":bp1=drv.c:11 ") {
+		t.Errorf("kernel args = %q", args)
+	}
+	if args := strings.Join(defs[2].args("drv"), " "); args != "probe -x /usr/bin/app --add "+perfProbeGroup+":bp2=main" {
+		t.Errorf("uprobe args = %q", args)
+	}
+}
+
+func TestPerfScriptLine(t *testing.T) {
+	line := "  insmod  1234 [002]  5321.000123: " + perfProbeGroup + ":bp3_1: (ffffffffc0123456) a=1 sum=7"
+	m := perfScriptLineRegex.FindStringSubmatch(line)
+	if m == nil {
+		t.Fatalf("perf script line not matched: %q", line)
+	}
+	if m[1] != "insmod" || m[2] != "1234" || m[3] != "002" || m[5] != "bp" || m[6] != "3" || m[8] != "a=1 sum=7" {
+		t.Errorf("fields = %q", m[1:])
+	}
+}
@@ -0,0 +1,214 @@
+package codegen
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 探针计划与采集后端 ==========
+// 各后端的生成器原来各自遍历 ctx.Project.Breakpoints：有的先用行号表解析偏移，有的先判断函数名，
+// 用户态断点是否占用编号也各不相同，断点编号容易与事件对不上。现在统一先建立探针计划：
+//   BuildProbePlan：用行号表解析每个启用的断点（函数名缺失时从源码补上），按同一规则分配编号，
+//                   BPF、systemtap、kprobe_events、bpftrace、perf probe、ftrace 都只消费计划中的探针；
+//   captureBackend：事件采集后端的统一接口（打开事件源、识别后端自己的输出行、结束时撤销设置），
+//                   按后端名注册在 session.CaptureBackends 中，events start/stop 不再按后端逐个分支。
+// 各后端产生的事件都交给 session.AppendEvent（事件缓冲、统计、录制、断言和窗口刷新的唯一入口）。
+// gdb/kdb 是停止模式的后端，不采集事件，不在这里注册。
+
+// 计划中的一个探针（对应一个断点）
+type Probe struct {
+	ID         int                // 事件中的断点编号（从1开始，与生成的程序一致）
+	Index      int                // 在 ctx.Project.Breakpoints 中的下标
+	Breakpoint project.Breakpoint // 解析后的断点（函数名、偏移、内联副本）
+	Resolved   bool               // 偏移来自DWARF行号表（否则探针在函数入口）
+}
+
+// 探针的全部位置：内联断点每个副本一个
+func (p *Probe) Sites() []project.Breakpoint {
+	return dwarf.BreakpointProbeSites(p.Breakpoint)
+}
+
+// 断点所在的源文件名
+func (p *Probe) FileName() string {
+	return filepath.Base(p.Breakpoint.File)
+}
+
+// 是否生成返回值探针（内联展开的函数没有自己的返回）
+func (p *Probe) ReturnProbe() bool {
+	return p.Breakpoint.RetVal && p.Breakpoint.InlinedFrom == ""
+}
+
+// 探针计划：一次生成或布防使用的全部探针和目标信息
+type ProbePlan struct {
+	Probes []*Probe
+	Module string               // 项目的内核模块（没有编译时为空）
+	Arch   string               // 目标架构
+	Filter *project.ProbeFilter // pid/comm/cpu过滤（没有时为nil）
+}
+
+// 按编号找到探针
+func (plan *ProbePlan) Probe(id int) *Probe {
+	if id < 1 || id > len(plan.Probes) {
+		return nil
+	}
+	return plan.Probes[id-1]
+}
+
+// 建立探针计划。resolve为true时（生成和布防）先用行号表解析断点的函数和偏移并写回项目，
+// 行号表不可用时回到函数入口，函数名缺失时从源码解析
+func BuildProbePlan(ctx *session.DebuggerContext, resolve bool) *ProbePlan {
+	plan := &ProbePlan{}
+	if ctx.Project == nil {
+		return plan
+	}
+	plan.Module = project.FindProjectModule(ctx.Project.RootPath)
+	plan.Arch, _ = session.DetectTargetArch(ctx)
+	plan.Filter = session.CurrentProbeFilter(ctx)
+	for i := range ctx.Project.Breakpoints {
+		bp := &ctx.Project.Breakpoints[i]
+		if !bp.Enabled {
+			continue
+		}
+		resolved := false
+		if resolve {
+			if _, err := session.ResolveBreakpointProbe(ctx, bp); err == nil {
+				resolved = true
+			} else {
+				// 行号表不可用时偏移可能已经过期，回到函数入口
+				bp.Offset = 0
+				bp.Inline = nil
+				bp.InlinedFrom = ""
+			}
+			if bp.Function == "" || bp.Function == "unknown" {
+				if name := project.ParseFunctionName(bp.File, bp.Line); name != "" {
+					bp.Function = name
+				}
+			}
+		}
+		if !session.Probeable(*bp) {
+			continue
+		}
+		plan.Probes = append(plan.Probes, &Probe{ID: len(plan.Probes) + 1, Index: i, Breakpoint: *bp, Resolved: resolved})
+	}
+	return plan
+}
+
+func init() {
+	session.CaptureBackends[session.BackendBPF] = tracePipeBackend{}
+	session.CaptureBackends[session.BackendFtrace] = ftraceBackend{}
+	session.CaptureBackends[session.BackendKprobe] = kprobeBackend{}
+	session.CaptureBackends[session.BackendSystemtap] = systemtapBackend{}
+	session.CaptureBackends[session.BackendBpftrace] = bpftraceBackend{}
+	session.CaptureBackends[session.BackendPerf] = perfBackend{}
+}
+
+// bpf：读取trace_pipe（本机或通过ssh读取远程目标），BPF程序由 bpf load 加载
+type tracePipeBackend struct{}
+
+func (tracePipeBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	if remote := session.RemoteTarget(ctx); remote != nil {
+		return session.OpenRemoteTracePipe(remote)
+	}
+	return session.OpenTracePipe()
+}
+
+func (tracePipeBackend) HandleLine(ctx *session.DebuggerContext, line string) bool { return false }
+func (tracePipeBackend) Stop(ctx *session.DebuggerContext)                         {}
+
+// ftrace：trace_pipe + function_graph
+type ftraceBackend struct{}
+
+func (ftraceBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	file, path, err := tracePipeBackend{}.Start(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := armFtrace(ctx); err != nil {
+		file.Close()
+		return nil, "", err
+	}
+	return file, path + " (function_graph)", nil
+}
+
+func (ftraceBackend) HandleLine(ctx *session.DebuggerContext, line string) bool {
+	return ctx.FtraceRoot != "" && handleGraphLine(ctx, line)
+}
+
+func (ftraceBackend) Stop(ctx *session.DebuggerContext) {
+	if ctx.FtraceRoot != "" {
+		disarmFtrace(ctx)
+	}
+}
+
+// kprobe：trace_pipe + kprobe_events
+type kprobeBackend struct{}
+
+func (kprobeBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	file, path, err := tracePipeBackend{}.Start(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	warnings, err := armKprobeEvents(ctx)
+	if err != nil {
+		file.Close()
+		return nil, "", err
+	}
+	for _, w := range warnings {
+		ctx.CommandHistory = append(ctx.CommandHistory, "[KPROBE] "+w)
+	}
+	return file, path + fmt.Sprintf(" (%d kprobe_events)", len(ctx.KprobeEvents)), nil
+}
+
+func (kprobeBackend) HandleLine(ctx *session.DebuggerContext, line string) bool {
+	return len(ctx.KprobeEvents) > 0 && handleKprobeEventLine(ctx, line)
+}
+
+func (kprobeBackend) Stop(ctx *session.DebuggerContext) {
+	if len(ctx.KprobeEvents) > 0 {
+		disarmKprobeEvents(ctx)
+	}
+}
+
+// systemtap：TUI运行的stap进程
+type systemtapBackend struct{}
+
+func (systemtapBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	return startSystemtap(ctx)
+}
+
+func (systemtapBackend) HandleLine(ctx *session.DebuggerContext, line string) bool {
+	return handleSystemtapLine(ctx, line)
+}
+
+func (systemtapBackend) Stop(ctx *session.DebuggerContext) { session.MarkScriptStopped(ctx) }
+
+// bpftrace：TUI运行的bpftrace进程
+type bpftraceBackend struct{}
+
+func (bpftraceBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	return startBpftrace(ctx)
+}
+
+func (bpftraceBackend) HandleLine(ctx *session.DebuggerContext, line string) bool {
+	return handleBpftraceLine(ctx, line)
+}
+
+func (bpftraceBackend) Stop(ctx *session.DebuggerContext) { session.MarkScriptStopped(ctx) }
+
+// perf：perf probe 创建的探针 + perf record | perf script
+type perfBackend struct{}
+
+func (perfBackend) Start(ctx *session.DebuggerContext) (io.ReadCloser, string, error) {
+	return startPerfProbe(ctx)
+}
+
+func (perfBackend) HandleLine(ctx *session.DebuggerContext, line string) bool {
+	return handlePerfScriptLine(ctx, line)
+}
+
+func (perfBackend) Stop(ctx *session.DebuggerContext) { session.MarkScriptStopped(ctx) }
@@ -0,0 +1,61 @@
+package codegen
+
+import (
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestBuildProbePlanNumbering(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: true},
+		project.Breakpoint{File: "drv.c", Line: 5, Function: "scale", Enabled: false},
+		project.Breakpoint{File: "drv.c", Line: 1, Function: "unknown", Enabled: true},
+		project.Breakpoint{File: "drv.c", Line: 6, Enabled: true, RetVal: true},
+	)
+	plan := BuildProbePlan(ctx, true)
+	if len(plan.Probes) != 2 {
+		t.Fatalf("probes = %d, want 2", len(plan.Probes))
+	}
+	if p := plan.Probe(1); p.Index != 0 || p.Breakpoint.Function != "do_work" {
+		t.Errorf("probe 1 = index %d %s, want index 0 do_work", p.Index, p.Breakpoint.Function)
+	}
+	// 缺少函数名的断点从源码解析并写回项目
+	p := plan.Probe(2)
+	if p.Index != 3 || p.Breakpoint.Function != "scale" || ctx.Project.Breakpoints[3].Function != "scale" {
+		t.Errorf("probe 2 = index %d %s, want index 3 scale", p.Index, p.Breakpoint.Function)
+	}
+	if !p.ReturnProbe() || p.Resolved {
+		t.Errorf("probe 2: ReturnProbe=%v Resolved=%v, want true false", p.ReturnProbe(), p.Resolved)
+	}
+	if plan.Probe(3) != nil {
+		t.Error("plan.Probe(3) should be nil")
+	}
+	// 事件中的编号与计划一致
+	for _, probe := range plan.Probes {
+		if bp := session.ArmedBreakpoint(ctx, probe.ID); bp != &ctx.Project.Breakpoints[probe.Index] {
+			t.Errorf("ArmedBreakpoint(%d) does not match the plan", probe.ID)
+		}
+		if id := session.ArmedBreakpointID(ctx, &ctx.Project.Breakpoints[probe.Index]); id != probe.ID {
+			t.Errorf("ArmedBreakpointID = %d, want %d", id, probe.ID)
+		}
+	}
+}
+
+func TestBuildProbePlanInlineSites(t *testing.T) {
+	ctx := testsupport.NewProject(t, project.Breakpoint{
+		File: "drv.c", Line: 5, Function: "do_work", Enabled: true, RetVal: true,
+		InlinedFrom: "scale", Inline: []project.InlineSite{{Function: "do_work", Offset: 0x10}, {Function: "other", Offset: 0x8}},
+	})
+	probe := BuildProbePlan(ctx, false).Probe(1)
+	sites := probe.Sites()
+	if len(sites) != 2 || sites[1].Function != "other" || sites[1].Offset != 0x8 {
+		t.Errorf("sites = %+v", sites)
+	}
+	// 内联展开的函数没有自己的返回
+	if probe.ReturnProbe() {
+		t.Error("inlined breakpoint should not get a return probe")
+	}
+}
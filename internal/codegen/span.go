@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 成对探针耗时测量 ==========
+
+// 配对键在探针中的C表达式（function为探针所在的函数）
+func spanKeyExpr(r *session.ArgResolver, info *dwarf.ArchInfo, key, function string) (string, error) {
+	switch key {
+	case "":
+		return "bpf_get_current_pid_tgid()", nil
+	case "tgid":
+		return "(bpf_get_current_pid_tgid() >> 32)", nil
+	}
+	if info == nil {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "目标架构没有寄存器约定，不能按参数配对")
+	}
+	index := -1
+	if m := session.SpanArgRegex.FindStringSubmatch(key); m != nil {
+		index, _ = strconv.Atoi(m[1])
+	} else {
+		args, err := r.Args(function)
+		if err != nil {
+			return "", err
+		}
+		for i, arg := range args {
+			if arg.Name == key {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", errcode.Errorf(errcode.ErrNotFound, "%s() 没有参数 %s", function, key)
+		}
+	}
+	if index >= len(info.Args) {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "%s() 的第%d个参数通过栈传递，不能作为配对键", function, index+1)
+	}
+	return fmt.Sprintf("(u64)%s", info.CtxRegister(info.Args[index])), nil
+}
+
+// 生成的BPF代码：每个span一个hash map、一个入口探针和一个出口探针，返回生成的span数
+func writeSpanProbes(ctx *session.DebuggerContext, file *os.File, arch string, filter *project.ProbeFilter, r *session.ArgResolver) int {
+	info := dwarf.TargetArchInfo(arch)
+	written := 0
+	for i, s := range session.ProjectSpans(ctx) {
+		id := i + 1
+		entryKey, err := spanKeyExpr(r, info, s.Key, s.Entry)
+		exitKey := entryKey
+		if err == nil && s.Exit != "" {
+			exitKey, err = spanKeyExpr(r, info, s.Key, s.Exit)
+		}
+		if err != nil {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: span %d (%s) not generated: %v", id, s, err))
+			ctx.CommandDirty = true
+			continue
+		}
+		exit := project.Breakpoint{Function: s.Entry}
+		if s.Exit != "" {
+			exit.Function = s.Exit
+		}
+
+		fmt.Fprintf(file, "// 耗时测量 %d: %s\n", id, s)
+		fmt.Fprintln(file, "struct {")
+		fmt.Fprintln(file, "    __uint(type, BPF_MAP_TYPE_HASH);")
+		fmt.Fprintf(file, "    __uint(max_entries, %d);\n", session.SpanMapEntries)
+		fmt.Fprintln(file, "    __type(key, u64);")
+		fmt.Fprintln(file, "    __type(value, u64);")
+		fmt.Fprintf(file, "} span_start_%d SEC(\".maps\");\n", id)
+		fmt.Fprintln(file, "")
+
+		// 入口：记录开始时间（过滤只作用于入口，出口只处理已记录的键）
+		fmt.Fprintf(file, "SEC(\"%s\")\n", probeSection(project.Breakpoint{Function: s.Entry}, false))
+		fmt.Fprintf(file, "int span_entry_%d(struct pt_regs *ctx) {\n", id)
+		session.WriteProbeFilter(file, filter)
+		fmt.Fprintf(file, "    u64 key = %s;\n", entryKey)
+		fmt.Fprintln(file, "    u64 start = bpf_ktime_get_ns();")
+		fmt.Fprintf(file, "    bpf_map_update_elem(&span_start_%d, &key, &start, BPF_ANY);\n", id)
+		fmt.Fprintln(file, "    return 0;")
+		fmt.Fprintln(file, "}")
+		fmt.Fprintln(file, "")
+
+		// 出口：计算耗时并输出
+		fmt.Fprintf(file, "SEC(\"%s\")\n", probeSection(exit, s.Exit == ""))
+		fmt.Fprintf(file, "int span_exit_%d(struct pt_regs *ctx) {\n", id)
+		fmt.Fprintf(file, "    u64 key = %s;\n", exitKey)
+		fmt.Fprintf(file, "    u64 *start = bpf_map_lookup_elem(&span_start_%d, &key);\n", id)
+		fmt.Fprintln(file, "    if (!start)")
+		fmt.Fprintln(file, "        return 0;")
+		fmt.Fprintln(file, "    struct debug_event event = {};")
+		fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+		fmt.Fprintln(file, "    event.pid = pid_tgid;")
+		fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+		fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+		fmt.Fprintln(file, "    event.var_value = event.timestamp - *start;")
+		fmt.Fprintf(file, "    bpf_map_delete_elem(&span_start_%d, &key);\n", id)
+		fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", id)
+		fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_SPAN;")
+		fmt.Fprintln(file, "    event.cpu = bpf_get_smp_processor_id();")
+		fmt.Fprintf(file, "    event.var_type = %d;\n", session.DebugEventUnsigned)
+		fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+		fmt.Fprintf(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"%s\");\n", s.Label())
+		session.WriteDebugEventOutput(file, "    ")
+		fmt.Fprintf(file, "    debug_printk(\"[SPAN-%d] %s %%lluns PID=%%d\\n\", event.var_value, event.pid);\n", id, s.Label())
+		fmt.Fprintln(file, "    return 0;")
+		fmt.Fprintln(file, "}")
+		fmt.Fprintln(file, "")
+		written++
+	}
+	return written
+}
@@ -0,0 +1,31 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/session"
+)
+
+// ========== 结构体指针展开 ==========
+
+// 生成的BPF代码：读出指针后逐个读取结构体成员（指针在 event.var_value 中）
+func writeStructMemberReads(file *os.File, varName string, members []dwarf.StructMember, breakpointID int, funcName string) {
+	fmt.Fprintf(file, "    // 展开结构体指针 %s（成员偏移来自DWARF）\n", varName)
+	fmt.Fprintln(file, "    if (event.var_value) {")
+	fmt.Fprintln(file, "        char *base = (char *)event.var_value;")
+	for _, m := range members {
+		fmt.Fprintf(file, "        {\n")
+		fmt.Fprintf(file, "            %s value = 0;\n", dwarf.ScalarCType(m.Size, m.Signed))
+		fmt.Fprintf(file, "            bpf_probe_read_kernel(&value, sizeof(value), base + %d);\n", m.Offset)
+		session.WriteDebugVarOutput(file, "            ", m.Path, "value", m.Signed)
+		if m.Signed {
+			fmt.Fprintf(file, "            debug_printk(\"[VAR-%d] %s:%s=%%lld PID=%%d\\n\", (long long)value, event.pid);\n", breakpointID, funcName, m.Path)
+		} else {
+			fmt.Fprintf(file, "            debug_printk(\"[VAR-%d] %s:%s=%%llu PID=%%d\\n\", (unsigned long long)value, event.pid);\n", breakpointID, funcName, m.Path)
+		}
+		fmt.Fprintf(file, "        }\n")
+	}
+	fmt.Fprintln(file, "    }")
+}
@@ -0,0 +1,115 @@
+package codegen
+
+import (
+	"debug/elf"
+	"fmt"
+	"path/filepath"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 用户态探针 ==========
+// 驱动通常带一个用户态测试程序。bp uprobe <程序> <函数> 在该程序的函数入口加一个断点，
+// 生成 SEC("uprobe/<程序>:<函数>") 探针，命中与内核断点使用同一种事件和同一套断点编号，
+// 因此用户态调用和它触发的内核路径按时间顺序出现在同一个事件列表中。
+// 程序带 -g 编译时用它的DWARF找到函数的声明位置，断点显示在对应的源码行上。
+// 程序按本机路径解析符号和调试信息，远程目标需要在开发板的相同路径上有同一个程序。
+
+// 在用户态程序中查找函数符号（静态符号表优先，strip后使用动态符号表）
+func findUserFunction(binary, function string) error {
+	file, err := elf.Open(binary)
+	if err != nil {
+		return errcode.Errorf(errcode.ErrInvalidArg, "无法打开用户态程序: %v", err)
+	}
+	defer file.Close()
+	for _, load := range []func() ([]elf.Symbol, error){file.Symbols, file.DynamicSymbols} {
+		symbols, err := load()
+		if err != nil {
+			continue
+		}
+		for _, sym := range symbols {
+			if sym.Name == function && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Value != 0 {
+				return nil
+			}
+		}
+	}
+	return errcode.Errorf(errcode.ErrNoSymbol, "%s 中没有函数 %s", filepath.Base(binary), function)
+}
+
+// 函数的源码位置：DWARF中的声明行，文件按路径替换规则和项目中的同名文件解析（没有调试信息时为空）
+func userFunctionSource(ctx *session.DebuggerContext, binary, function string) (string, int) {
+	r, err := dwarf.NewLineResolver(binary)
+	if err != nil {
+		return "", 0
+	}
+	file, line := r.FunctionDecl(function)
+	if file == "" {
+		return "", 0
+	}
+	if mapped := session.SubstituteSourcePath(ctx, file); project.FileExists(mapped) {
+		return mapped, line
+	}
+	if !filepath.IsAbs(file) {
+		if candidate := filepath.Join(filepath.Dir(binary), file); project.FileExists(candidate) {
+			return candidate, line
+		}
+	}
+	if found := project.FindInProject(ctx.Project.RootPath, filepath.Base(file)); found != "" {
+		return found, line
+	}
+	return file, line
+}
+
+// bp uprobe：添加用户态断点，已存在时切换启用状态。返回断点编号（从1开始）
+func ToggleUprobe(ctx *session.DebuggerContext, binary, function string) (int, error) {
+	if !filepath.IsAbs(binary) {
+		binary = filepath.Join(ctx.Project.RootPath, binary)
+	}
+	operation := fmt.Sprintf("bp uprobe %s %s", project.ProjectRelativePath(ctx.Project, binary), function)
+	for i, bp := range ctx.Project.Breakpoints {
+		if bp.Binary == binary && bp.Function == function {
+			session.RecordOperation(ctx, operation)
+			ctx.Project.Breakpoints[i].Enabled = !bp.Enabled
+			return i + 1, project.SaveBreakpoints(ctx.Project)
+		}
+	}
+	if err := findUserFunction(binary, function); err != nil {
+		return 0, err
+	}
+	session.RecordOperation(ctx, operation)
+	bp := project.Breakpoint{Binary: binary, Function: function, Enabled: true}
+	bp.File, bp.Line = userFunctionSource(ctx, binary, function)
+	if bp.File == "" {
+		// 没有调试信息：事件位置显示为 程序名:0
+		bp.File = binary
+	}
+	ctx.Project.Breakpoints = append(ctx.Project.Breakpoints, bp)
+	if bp.Line > 0 {
+		session.TouchWorkingSet(ctx, bp.File, bp.Line, "breakpoint")
+	}
+	return len(ctx.Project.Breakpoints), project.SaveBreakpoints(ctx.Project)
+}
+
+// 断点的BPF段名：kprobe/func+0x1c 或 uprobe/<程序>:<函数>；ret为true时是返回探针
+func probeSection(bp project.Breakpoint, ret bool) string {
+	switch {
+	case bp.Binary != "" && ret:
+		return "uretprobe/" + bp.Binary + ":" + bp.Function
+	case bp.Binary != "":
+		return "uprobe/" + bp.Binary + ":" + bp.Function
+	case ret:
+		return "kretprobe/" + bp.Function
+	}
+	return "kprobe/" + dwarf.ProbeTarget(bp.Function, bp.Offset)
+}
+
+// 显示用的探针位置
+func BreakpointTarget(bp project.Breakpoint) string {
+	if bp.Binary != "" {
+		return fmt.Sprintf("%s:%s (uprobe)", filepath.Base(bp.Binary), bp.Function)
+	}
+	return dwarf.ProbeTarget(bp.Function, bp.Offset)
+}
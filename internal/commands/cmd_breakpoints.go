@@ -0,0 +1,483 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：断点、监视和断言 ==========
+
+// bp：断点的列表、切换、条件、备注和修复
+func (app *AppContext) cmdBreakpoint(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		if args == "clear" {
+			return []string{"Tip: No project opened"}, nil
+		}
+		return nil, errcode.NoProject
+	}
+	switch {
+	case strings.HasPrefix(args, "toggle"):
+		// bp toggle <file>:<line> - 切换指定位置的断点
+		target := strings.TrimSpace(strings.TrimPrefix(args, "toggle"))
+		sep := strings.LastIndex(target, ":")
+		if sep <= 0 {
+			return nil, errcode.Usage("bp toggle <file>:<line>")
+		}
+		var line int
+		if _, err := fmt.Sscanf(target[sep+1:], "%d", &line); err != nil || line <= 0 {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "Invalid line number: %s", target[sep+1:])
+		}
+		file := target[:sep]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(app.Ctx.Project.RootPath, file)
+		}
+		session.AddBreakpoint(app.Ctx, file, line)
+		return []string{fmt.Sprintf("Toggled breakpoint at %s:%d", filepath.Base(file), line)}, nil
+	case strings.HasPrefix(args, "note"):
+		// bp note <n> [text] - 设置/清除断点备注
+		fields := strings.Fields(args)
+		if len(fields) < 2 {
+			return nil, errcode.Usage("bp note <n> \"text\" (no text clears the note)")
+		}
+		n, err := parseBreakpointNumber(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		note := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(args, "note")), fields[1]))
+		if err := session.SetBreakpointNote(app.Ctx, n, note); err != nil {
+			return nil, err
+		}
+		if bp := app.Ctx.Project.Breakpoints[n-1]; bp.Note != "" {
+			return []string{fmt.Sprintf("Breakpoint %d (%s:%d): %s", n, filepath.Base(bp.File), bp.Line, bp.Note)}, nil
+		}
+		return []string{fmt.Sprintf("Cleared note of breakpoint %d", n)}, nil
+	case strings.HasPrefix(args, "retval"):
+		// bp retval <n> [off] - 函数返回时报告返回值（kretprobe）
+		fields := strings.Fields(args)
+		if len(fields) < 2 || len(fields) > 3 || (len(fields) == 3 && fields[2] != "off") {
+			return nil, errcode.Usage("bp retval <n> [off]")
+		}
+		n, err := parseBreakpointNumber(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := session.SetBreakpointRetVal(app.Ctx, n, len(fields) == 2); err != nil {
+			return nil, err
+		}
+		if bp := app.Ctx.Project.Breakpoints[n-1]; bp.RetVal {
+			return []string{fmt.Sprintf("Breakpoint %d: %s() return value reported (kretprobe), run 'vars'/'generate' and 'compile' again", n, bp.Function)}, nil
+		}
+		return []string{fmt.Sprintf("Breakpoint %d: return value no longer reported", n)}, nil
+	case strings.HasPrefix(args, "cond"):
+		// bp cond <n> [expr] - 设置/清除断点条件（在BPF中求值）
+		fields := strings.Fields(args)
+		if len(fields) < 2 {
+			return nil, errcode.Usage("bp cond <n> \"arg0 > 1024 && pid == 1234\" (no expression clears the condition)")
+		}
+		n, err := parseBreakpointNumber(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		condition := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(args, "cond")), fields[1]))
+		usesArg, err := codegen.SetBreakpointCondition(app.Ctx, n, condition)
+		if err != nil {
+			return nil, errcode.WithHints(err, "Names: arg0..arg5 pid tgid cpu; operators: || && ! == != < <= > >= & | + -")
+		}
+		bp := app.Ctx.Project.Breakpoints[n-1]
+		if bp.Condition == "" {
+			return []string{fmt.Sprintf("Cleared condition of breakpoint %d", n)}, nil
+		}
+		output := []string{fmt.Sprintf("Breakpoint %d (%s:%d) fires only if: %s", n, filepath.Base(bp.File), bp.Line, bp.Condition),
+			"Run 'vars'/'generate' and 'compile' again to apply it"}
+		if usesArg && bp.Offset > 0 {
+			output = append(output, fmt.Sprintf("Warning: probe is at %s, argument registers may already be reused there", dwarf.ProbeTarget(bp.Function, bp.Offset)))
+		}
+		return output, nil
+	case strings.HasPrefix(args, "uprobe"):
+		// bp uprobe <binary> <function> - 用户态程序中的函数断点
+		fields := strings.Fields(args)
+		if len(fields) != 3 {
+			return nil, errcode.Usage("bp uprobe <binary> <function>")
+		}
+		n, err := codegen.ToggleUprobe(app.Ctx, fields[1], fields[2])
+		if err != nil && n == 0 {
+			return nil, err
+		}
+		bp := app.Ctx.Project.Breakpoints[n-1]
+		state := "enabled"
+		if !bp.Enabled {
+			state = "disabled"
+		}
+		output := []string{fmt.Sprintf("Breakpoint %d: %s %s", n, codegen.BreakpointTarget(bp), state)}
+		if bp.Line > 0 {
+			output = append(output, fmt.Sprintf("  source: %s:%d", bp.File, bp.Line))
+		} else {
+			output = append(output, fmt.Sprintf("  %s has no debug info, hits show as %s:0", filepath.Base(bp.Binary), filepath.Base(bp.Binary)))
+		}
+		if err != nil {
+			output = append(output, fmt.Sprintf("Warning: Failed to save breakpoints: %v", err))
+		}
+		return append(output, "Run 'vars'/'generate' and 'compile' again, then 'bpf load'"), nil
+	case strings.HasPrefix(args, "export") || strings.HasPrefix(args, "import"):
+		// bp export <file> [json|text] / bp import <file> [merge|overwrite]
+		return app.breakpointTransfer(strings.Fields(args))
+	case args == "repair" || strings.HasPrefix(args, "repair "):
+		// bp repair [<n> <file>|drop] - 列出/修复找不到源文件的断点
+		fields := strings.Fields(args)
+		switch {
+		case len(fields) == 1:
+			return project.BreakpointRepairLines(app.Ctx.Project), nil
+		case len(fields) == 2 && fields[1] == "drop":
+			count, err := project.DropUnmatchedBreakpoints(app.Ctx.Project)
+			if err != nil {
+				return []string{fmt.Sprintf("Warning: Removed %d breakpoints but save failed: %v", count, err)}, nil
+			}
+			return []string{fmt.Sprintf("Removed %d breakpoints with missing files", count)}, nil
+		case len(fields) != 3:
+			return nil, errcode.Usage("bp repair [<n> <file>|drop]")
+		}
+		n, err := parseBreakpointNumber(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		if err := project.RepairBreakpoint(app.Ctx.Project, n, fields[2]); err != nil {
+			return nil, err
+		}
+		bp := app.Ctx.Project.Breakpoints[n-1]
+		return []string{fmt.Sprintf("Breakpoint %d now at %s:%d (%s)", n, project.ProjectRelativePath(app.Ctx.Project, bp.File), bp.Line, bp.Function)}, nil
+	case args == "resolve":
+		// bp resolve - 用编译好的模块的DWARF行号表重新解析所有断点
+		return app.resolveBreakpoints()
+	case args == "check":
+		// bp check - 检查断点函数能否挂载kprobe
+		return session.CheckBreakpointProbes(app.Ctx), nil
+	case args == "clear":
+		// bp clear - 清除所有断点
+		count := len(app.Ctx.Project.Breakpoints)
+		app.Ctx.Project.Breakpoints = make([]project.Breakpoint, 0)
+		// 保存清空后的断点列表
+		if err := project.SaveBreakpoints(app.Ctx.Project); err != nil {
+			return []string{fmt.Sprintf("Warning: Breakpoints cleared but save failed: %v", err)}, nil
+		}
+		return []string{fmt.Sprintf("Success: Cleared %d breakpoints", count)}, nil
+	}
+	// bp - 查看断点（默认行为）
+	showBreakpointsPopup(app.Ctx)
+	return []string{"Breakpoint viewer window opened"}, nil
+}
+
+// 解析命令参数中的断点编号
+func parseBreakpointNumber(arg string) (int, error) {
+	n := 0
+	if _, err := fmt.Sscanf(arg, "%d", &n); err != nil {
+		return 0, errcode.Errorf(errcode.ErrInvalidArg, "invalid breakpoint number: %s", arg)
+	}
+	return n, nil
+}
+
+// bp export <file> [json|text] / bp import <file> [merge|overwrite]
+func (app *AppContext) breakpointTransfer(fields []string) ([]string, error) {
+	export := fields[0] == "export"
+	option := ""
+	if len(fields) == 3 {
+		option = fields[2]
+	}
+	validOption := option == "" || (export && (option == project.BreakpointFormatJSON || option == project.BreakpointFormatText)) ||
+		(!export && (option == "merge" || option == "overwrite"))
+	if len(fields) < 2 || len(fields) > 3 || !validOption {
+		return nil, errcode.Usage("bp export <file> [json|text] | bp import <file> [merge|overwrite]",
+			"  .json files use the .debug_breakpoints.json format, others one <file>:<line> [disabled] per line")
+	}
+	path := fields[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(app.Ctx.Project.RootPath, path)
+	}
+	format := project.BreakpointFileFormat(path)
+	if export && option != "" {
+		format = option
+	}
+	if export {
+		n, skipped, err := session.ExportBreakpoints(app.Ctx, path, format)
+		if err != nil {
+			return nil, err
+		}
+		output := []string{fmt.Sprintf("Exported %d breakpoints to %s (%s)", n, path, format)}
+		if skipped > 0 {
+			output = append(output, fmt.Sprintf("  Skipped %d uprobe breakpoints, use the json format to keep them", skipped))
+		}
+		if format == project.BreakpointFormatText {
+			output = append(output, "  The text format keeps locations only (no notes, conditions or retval)")
+		}
+		return output, nil
+	}
+	result, err := session.ImportBreakpoints(app.Ctx, path, format, option == "overwrite")
+	if result == nil {
+		return nil, err
+	}
+	output := []string{fmt.Sprintf("Imported %d breakpoints from %s (%d already set), %d total",
+		result.Added, filepath.Base(path), result.Duplicates, len(app.Ctx.Project.Breakpoints))}
+	if len(result.Missing) > 0 {
+		output = append(output, fmt.Sprintf("  Warning: source files not found in this project: %s", strings.Join(result.Missing, ", ")))
+	}
+	if err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save breakpoints: %v", err))
+	}
+	return append(output, "Run 'vars'/'generate' and 'compile' again to probe them"), nil
+}
+
+// bp resolve：用编译好的模块的DWARF行号表重新解析所有断点
+func (app *AppContext) resolveBreakpoints() ([]string, error) {
+	if _, err := session.ProjectLineResolver(app.Ctx); err != nil {
+		return nil, errcode.WithHints(err, "Tip: build the module with -g, breakpoints fall back to function entry until then")
+	}
+	var output []string
+	for i := range app.Ctx.Project.Breakpoints {
+		bp := &app.Ctx.Project.Breakpoints[i]
+		loc, err := session.ResolveBreakpointProbe(app.Ctx, bp)
+		if err != nil {
+			output = append(output, fmt.Sprintf("  %d. %s:%d -> %s (function entry: %v)", i+1, filepath.Base(bp.File), bp.Line, bp.Function, err))
+			continue
+		}
+		where := ""
+		if loc.Line != bp.Line {
+			// 目标行没有指令，落到其后第一条语句
+			where = fmt.Sprintf(" (code starts at line %d)", loc.Line)
+		}
+		output = append(output, fmt.Sprintf("  %d. %s:%d -> %s%s", i+1, filepath.Base(bp.File), bp.Line, dwarf.ProbeTarget(bp.Function, bp.Offset), where))
+		if note := dwarf.InlineSitesNote(*bp); note != "" {
+			output = append(output, "     "+note)
+		}
+	}
+	output = append([]string{fmt.Sprintf("Resolved %d breakpoints via %s:", len(app.Ctx.Project.Breakpoints), filepath.Base(app.Ctx.LineTable.Binary))}, output...)
+	if err := project.SaveBreakpoints(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save breakpoints: %v", err))
+	}
+	return append(output, "Run 'vars'/'generate' and 'compile' again to probe the new offsets"), nil
+}
+
+// watch [expr]：添加监视表达式或列出监视
+func (app *AppContext) cmdWatch(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args == "" {
+		// watch - 列出所有监视表达式
+		watches := app.Ctx.Project.Settings.Watches
+		if len(watches) == 0 {
+			return []string{"No watch expressions", "Usage: watch <expr>"}, nil
+		}
+		output := []string{fmt.Sprintf("Watch expressions (%d):", len(watches))}
+		for i, w := range watches {
+			state := "armed"
+			if w.Stale {
+				state = "stale"
+			}
+			output = append(output, fmt.Sprintf("  %d. %s = %s [%s]", i+1, w.Expr, session.WatchValueText(app.Ctx, w), state))
+		}
+		return output, nil
+	}
+	var output []string
+	for _, expr := range strings.Fields(args) {
+		if !session.AddWatch(app.Ctx, expr) {
+			output = append(output, fmt.Sprintf("Already watching: %s", expr))
+		} else if sym, err := session.ResolveGlobalSymbol(app.Ctx, expr); err == nil {
+			// 全局变量：生成的程序在每个探针中读取它
+			output = append(output, fmt.Sprintf("Watching global %s (%s, %d bytes) @0x%x", expr, sym.Type, sym.Size, sym.Addr))
+		} else {
+			output = append(output, fmt.Sprintf("Watching: %s", expr))
+		}
+	}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+	}
+	return append(output, "Tip: Watches are armed the next time 'vars' generates a program"), nil
+}
+
+// unwatch <n|expr>：删除监视表达式
+func (app *AppContext) cmdUnwatch(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args == "" {
+		return nil, errcode.Usage("unwatch <number|expr>")
+	}
+	expr, ok := session.RemoveWatch(app.Ctx, args)
+	if !ok {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "No such watch expression: %s", args)
+	}
+	output := []string{fmt.Sprintf("Removed watch: %s", expr)}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+	}
+	return output, nil
+}
+
+// filter：按pid/comm/cpu过滤探针
+func (app *AppContext) cmdFilter(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	fields := strings.Fields(args)
+	var err error
+	switch {
+	case len(fields) == 0:
+	case fields[0] == "clear" || fields[0] == "off":
+		kind := ""
+		if len(fields) > 1 {
+			kind = fields[1]
+		}
+		err = session.ClearProbeFilter(app.Ctx, kind)
+	case len(fields) == 2:
+		err = session.SetProbeFilter(app.Ctx, fields[0], fields[1])
+	default:
+		return nil, errcode.Usage("filter [pid <n>|comm <name>|cpu <n>|clear [pid|comm|cpu]]")
+	}
+	if err != nil {
+		return nil, err
+	}
+	output := []string{"Probe filter: " + session.ProbeFilterSummary(session.CurrentProbeFilter(app.Ctx))}
+	if len(fields) > 0 {
+		output = append(output, "  Takes effect after 'vars'/'generate' and 'compile' (bpf backend only)")
+	}
+	return output, nil
+}
+
+// assert：顺序断言
+func (app *AppContext) cmdAssert(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	settings := app.Ctx.Project.Settings
+	switch {
+	case len(fields) == 0:
+		output := []string{fmt.Sprintf("Ordering assertions (%d):", len(settings.Assertions))}
+		counts := make(map[int]int)
+		for _, v := range app.Ctx.AssertViolations {
+			counts[v.Assertion]++
+		}
+		for i, a := range settings.Assertions {
+			output = append(output, fmt.Sprintf("  %d. %s  [%d violations]", i+1, a, counts[i+1]))
+		}
+		if len(settings.Assertions) == 0 {
+			output = append(output, "  (none) Usage: assert bp1 before bp2 [within 10ms] [per pid]")
+		}
+		return output, nil
+	case fields[0] == "violations":
+		session.ShowAssertViolationsPopup(app.Ctx)
+		return []string{fmt.Sprintf("Violations window opened (%d violations)", len(app.Ctx.AssertViolations))}, nil
+	case fields[0] == "reset":
+		session.ResetAssertions(app.Ctx)
+		return []string{"Assertion state and violations cleared"}, nil
+	case fields[0] == "del" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > len(settings.Assertions) {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid assertion number: %s", fields[1])
+		}
+		removed := settings.Assertions[n-1]
+		settings.Assertions = append(settings.Assertions[:n-1], settings.Assertions[n:]...)
+		session.ResetAssertions(app.Ctx)
+		output := []string{fmt.Sprintf("Removed assertion: %s", removed)}
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+		}
+		return output, nil
+	}
+	a, err := session.ParseOrderAssertion(args)
+	if err != nil {
+		return nil, err
+	}
+	settings.Assertions = append(settings.Assertions, a)
+	session.ResetAssertions(app.Ctx)
+	output := []string{fmt.Sprintf("Assertion %d: %s", len(settings.Assertions), a)}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+	}
+	return output, nil
+}
+
+// hwbp：硬件断点（数据断点）
+func (app *AppContext) cmdHWBreakpoint(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		return session.HWBreakpointLines(app.Ctx), nil
+	case fields[0] == "del" && len(fields) == 2:
+		if fields[1] == "all" {
+			return []string{fmt.Sprintf("Deleted %d hardware breakpoint(s)", session.ClearHWBreakpoints(app.Ctx))}, nil
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(fields[1]), "hw"))
+		if err != nil || !session.DeleteHWBreakpoint(app.Ctx, id) {
+			return nil, errcode.Errorf(errcode.ErrNotFound, "no hardware breakpoint %s", fields[1])
+		}
+		return []string{fmt.Sprintf("Hardware breakpoint HW%d deleted", id)}, nil
+	case len(fields) == 2 || len(fields) == 3:
+		length := 0
+		if len(fields) == 3 {
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid length '%s'", fields[2])
+			}
+			length = n
+		}
+		hw, err := session.ArmHWBreakpoint(ui, app.Ctx, fields[0], fields[1], length)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("HW%d: %s breakpoint on %s (0x%x, %d bytes) armed on %d CPUs", hw.ID, hw.Type, hw.Target, hw.Addr, hw.Len, len(hw.FDs)),
+			"Hits are reported in the Events panel (events)",
+		}, nil
+	}
+	return nil, errcode.Usage("hwbp <addr|symbol> <r|w|rw|x> [len] | hwbp del <n|all>")
+}
+
+// ops [replay [reset]|clear]：操作日志
+func (app *AppContext) cmdOps(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	switch args {
+	case "", "list":
+		journal := app.Ctx.Project.Journal
+		if len(journal) == 0 {
+			return []string{"Operation journal is empty"}, nil
+		}
+		output := []string{fmt.Sprintf("Operation journal (%d):", len(journal))}
+		for i, op := range journal {
+			output = append(output, fmt.Sprintf("  %2d. [%s] %s", i+1, op.Time.Format("01-02 15:04:05"), op.Command))
+		}
+		return output, nil
+	case "replay", "replay reset":
+		breakpoints, watches := session.ReplayDiscards(app.Ctx)
+		if len(app.Ctx.Project.Journal) == 0 {
+			return []string{"Operation journal is empty, nothing to replay"}, nil
+		}
+		if args == "replay" && breakpoints+watches > 0 {
+			// 重放会清空现有状态，先确认（脚本中用 ops replay reset）
+			if ui == nil {
+				return nil, errcode.Errorf(errcode.ErrUsage, "Replay would discard %d breakpoints and %d watches, use 'ops replay reset' to confirm", breakpoints, watches)
+			}
+			app.confirmReplay(ui, breakpoints, watches)
+			return []string{fmt.Sprintf("[OPS] Replay would discard %d breakpoints and %d watches, confirm in the popup", breakpoints, watches)}, nil
+		}
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[OPS] Resetting project state and replaying journal...")
+		count := app.replayOperations(ui)
+		return []string{fmt.Sprintf("[OPS] Replayed %d operations", count)}, nil
+	case "clear":
+		app.Ctx.Project.Journal = nil
+		if err := session.SaveJournal(app.Ctx); err != nil {
+			return []string{fmt.Sprintf("Warning: Journal cleared but save failed: %v", err)}, nil
+		}
+		return []string{"Operation journal cleared"}, nil
+	}
+	return nil, errcode.Usage("ops [list|replay [reset]|clear]")
+}
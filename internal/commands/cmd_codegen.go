@@ -0,0 +1,640 @@
+package commands
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：代码生成、编译和加载 ==========
+
+// generate：生成只监视函数调用的BPF代码（旧命令，推荐 vars）
+func (app *AppContext) cmdGenerate(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if err := session.CheckSafeMode(app.Ctx, "BPF生成"); err != nil {
+		return nil, err
+	}
+	if err := codegen.GenerateBPF(app.Ctx); err != nil {
+		return nil, fmt.Errorf("Failed to generate BPF: %w", err)
+	}
+	output := []string{
+		"Success: Generated LEGACY BPF debug code",
+		"Files created:",
+		"  • debug_breakpoints.bpf.c (BPF program)",
+		"  • load_debug_bpf.sh (loading script)",
+		"  • unload_debug_bpf.sh (cleanup script)",
+		"",
+		"⚠️  Legacy Command Notice:",
+		"• This command generates OLD-STYLE basic breakpoint monitoring only",
+		"• For MODERN unified debugging, use 'vars' command instead:",
+		"  - vars               → basic function monitoring (recommended)",
+		"  - vars var1 var2     → function + variable monitoring",
+		"",
+		"🔄 Migration suggestion:",
+		"• Use 'vars' for future debugging sessions",
+		"• Current 'generate' output provides function-level monitoring only",
+		"",
+		"⚡ What this BPF program monitors:",
+		"✅ Function call detection (when functions are invoked)",
+		"✅ Process information (PID, TGID, process name)",
+		"✅ Precise timestamps (nanosecond precision)",
+		"❌ NO variable monitoring (use 'vars' for variables)",
+		"",
+		"Next steps:",
+		"1. Use 'compile' command to build BPF program",
+		"2. Run 'bpf load' (as root), or outside the TUI: sudo ./load_debug_bpf.sh",
+		"3. View output: events start",
+		"4. Cleanup: bpf unload (or sudo ./unload_debug_bpf.sh)",
+	}
+	app.Ctx.BpfLoaded = true
+	return output, nil
+}
+
+// vars [var...]：生成带变量采集的BPF代码和加载脚本
+func (app *AppContext) cmdVars(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.WithHints(errcode.NoProject, "Use 'open <project_path>' to open a project", "Example: open /tmp/test_project")
+	}
+	if err := session.CheckSafeMode(app.Ctx, "BPF生成"); err != nil {
+		return nil, err
+	}
+	// 添加状态诊断信息
+	output := []string{
+		fmt.Sprintf("Project Status: %s", filepath.Base(app.Ctx.Project.RootPath)),
+		fmt.Sprintf("Breakpoints Count: %d", len(app.Ctx.Project.Breakpoints)),
+	}
+	
+	// 如果断点为空，尝试重新加载
+	if len(app.Ctx.Project.Breakpoints) == 0 {
+		output = append(output, "No breakpoints in memory, attempting to reload...")
+		
+		// 手动重新加载断点
+		if err := project.LoadBreakpoints(app.Ctx.Project); err != nil {
+			output = append(output, fmt.Sprintf("Reload failed: %v", err))
+		} else {
+			output = append(output, fmt.Sprintf("Reload successful, found %d breakpoints", len(app.Ctx.Project.Breakpoints)))
+		}
+	}
+	
+	// 显示断点信息
+	if len(app.Ctx.Project.Breakpoints) > 0 {
+		output = append(output, "Current Breakpoints:")
+		for i, bp := range app.Ctx.Project.Breakpoints {
+			output = append(output, fmt.Sprintf("  %d. %s:%d (%s) enabled=%t", 
+				i+1, filepath.Base(bp.File), bp.Line, codegen.BreakpointTarget(bp), bp.Enabled))
+		}
+		output = append(output, "")
+	}
+	
+	// 解析变量名列表（增强功能：自动检测）
+	var varNames []string
+	autoDetected := false
+	
+	if args == "" || args == "auto" {
+		// 自动检测模式：扫描所有断点的函数变量
+		autoDetected = true
+		allVarsSet := make(map[string]bool)
+		
+		for _, bp := range app.Ctx.Project.Breakpoints {
+			if bp.Enabled {
+				if detectedVars := codegen.ParseAllFunctionVariables(bp.File, bp.Line); len(detectedVars) > 0 {
+					for _, v := range detectedVars {
+						allVarsSet[v] = true
+					}
+				}
+			}
+		}
+		
+		// 转换为slice
+		for v := range allVarsSet {
+			varNames = append(varNames, v)
+		}
+		
+		if len(varNames) > 0 {
+			output = append(output, fmt.Sprintf("🔍 Auto-detected %d variables from all breakpoint functions:", len(varNames)))
+			output = append(output, fmt.Sprintf("Variables: %v", varNames))
+			output = append(output, "")
+		} else {
+			output = append(output, "⚠️ No variables auto-detected, using common patterns")
+		}
+	} else {
+		// 手动指定变量模式
+		varNames = strings.Fields(args)
+		output = append(output, fmt.Sprintf("🎯 Manual variable specification: %v", varNames))
+		output = append(output, "")
+	}
+
+	// 合并项目中持久化的监视表达式
+	watchAdded := 0
+	for _, expr := range session.WatchExpressions(app.Ctx) {
+		exists := false
+		for _, name := range varNames {
+			if name == expr {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			varNames = append(varNames, expr)
+			watchAdded++
+		}
+	}
+	if watchAdded > 0 {
+		output = append(output, fmt.Sprintf("👁️ Added %d watch expressions from project settings", watchAdded))
+		output = append(output, "")
+	}
+
+	// 生成统一的BPF程序
+	if err := codegen.GenerateBPFWithVariables(app.Ctx, varNames); err != nil {
+		return output, fmt.Errorf("Failed to generate BPF: %w", err)
+	}
+
+	// 生成加载和卸载脚本
+	scriptPath := filepath.Join(app.Ctx.Project.RootPath, "load_debug_vars.sh")
+	codegen.GenerateVarsLoadScript(scriptPath, len(app.Ctx.Project.Breakpoints))
+	
+	unloadScriptPath := filepath.Join(app.Ctx.Project.RootPath, "unload_debug_vars.sh")
+	codegen.GenerateVarsUnloadScript(unloadScriptPath)
+	
+	// 监视表达式已编入新生成的程序，收到后端数据时才清除过期标记
+	if watches := len(session.WatchExpressions(app.Ctx)); watches > 0 {
+		output = append(output, fmt.Sprintf("👁️ %d watch expressions included, values refresh when events arrive", watches))
+	}
+	
+	if len(varNames) > 0 {
+		// 有变量的情况
+		modeDesc := "manual specification"
+		if autoDetected {
+			modeDesc = "auto-detection"
+		}
+		
+		output = append(output, []string{
+			fmt.Sprintf("Success: Generated UNIFIED BPF debugging (breakpoints + variables via %s)", modeDesc),
+			fmt.Sprintf("Monitoring %d variables: %v", len(varNames), varNames),
+			"",
+			"🔥 What this BPF program monitors:",
+			"✅ Function call detection (when functions are invoked)",
+			"✅ Process information (PID, TGID, process name)",
+			"✅ Precise timestamps (nanosecond precision)",
+			"✅ Variable value monitoring (real-time tracking)",
+			"✅ Register and stack variable support",
+			"",
+			"⚠️  Important understanding:",
+			"• BPF sets probes at FUNCTION ENTRY, not specific code lines",
+			"• Can detect IF a function runs, but NOT which lines inside execute",
+			"• This is a BPF/kprobe technical limitation",
+			"• Line numbers in output show where you set breakpoints for reference",
+		}...)
+		
+		if autoDetected {
+			output = append(output, []string{
+				"",
+				"🤖 Auto-Detection Features:",
+				"• Automatically scanned all functions with breakpoints",
+				"• Parsed source code for local variable declarations",
+				"• Extracted variable names using pattern matching",
+				"• Next: Use 'vars var1 var2' to manually specify variables",
+			}...)
+		}
+	} else {
+		// 仅基础断点的情况
+		output = append(output, []string{
+			"Success: Generated BASIC BPF debugging (function monitoring only)",
+			"",
+			"🔥 What this BPF program monitors:",
+			"✅ Function call detection (when functions are invoked)",
+			"✅ Process information (PID, TGID, process name)",
+			"✅ Precise timestamps (nanosecond precision)",
+			"",
+			"⚠️  Important understanding:",
+			"• BPF sets probes at FUNCTION ENTRY, not specific code lines",
+			"• Can detect IF a function runs, but NOT which lines inside execute",
+			"• To monitor variables, use: vars var1 var2 var3",
+			"",
+			"💡 Usage examples:",
+			"• vars                    → auto-detect all function variables",
+			"• vars auto               → same as above",
+			"• vars local_var counter  → manual variable specification",
+		}...)
+	}
+	
+	output = append(output, []string{
+		"",
+		"📁 Files created:",
+		"  • debug_variables.bpf.c (unified BPF program)",
+		"  • load_debug_vars.sh (loading script)",  
+		"  • unload_debug_vars.sh (cleanup script)",
+		"",
+		"⚡ Quick Start:",
+		"1. Use 'compile' command to build BPF program",
+		"2. Run 'bpf load' (as root), or outside the TUI: sudo ./load_debug_vars.sh",
+		"3. View output: events start",
+		"4. Cleanup: bpf unload (or sudo ./unload_debug_vars.sh)",
+	}...)
+	return output, nil
+}
+
+// compile [arch]：编译生成的BPF程序
+func (app *AppContext) cmdCompile(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if err := session.CheckSafeMode(app.Ctx, "BPF编译"); err != nil {
+		return nil, err
+	}
+	// 解析架构参数
+	var output []string
+	var targetArch string
+	if args == "" {
+		// 没有指定架构，按 项目配置 > 模块ELF头 > 主机uname 自动检测
+		var source string
+		targetArch, source = session.DetectTargetArch(app.Ctx)
+		output = []string{
+			"🏗️ Architecture Selection",
+			fmt.Sprintf("Auto-detected: %s (%s)", targetArch, session.ArchDisplayNames[targetArch]),
+			fmt.Sprintf("Source: %s", source),
+			"",
+			"💡 Available architectures:",
+			"  compile x86     - Intel/AMD 64-bit",
+			"  compile arm64   - ARM 64-bit",
+			"  compile riscv64 - RISC-V 64-bit",
+			"  compile s390x   - IBM System z",
+			"  compile ppc64le - PowerPC 64-bit LE",
+			"  compile mips64  - MIPS 64-bit",
+			"",
+			"  compile         - Auto-detect target arch (module ELF header, then host)",
+			"  arch <name>     - Pin the target arch for this project",
+			"",
+			fmt.Sprintf("✅ Using target architecture: %s", targetArch),
+		}
+	} else {
+		// 用户指定了架构
+		var ok bool
+		if targetArch, ok = session.ParseArchName(args); !ok {
+			return nil, errcode.WithHints(errcode.Errorf(errcode.ErrArch, "Unsupported architecture '%s'", args),
+				"",
+				"Supported architectures:",
+				"  x86, x86_64     - Intel/AMD 64-bit",
+				"  arm64, aarch64  - ARM 64-bit",
+				"  riscv64, riscv  - RISC-V 64-bit",
+				"  s390x           - IBM System z",
+				"  ppc64le         - PowerPC 64-bit LE",
+				"  mips64          - MIPS 64-bit",
+				"",
+				"Examples:",
+				"  compile         - Auto-detect target arch",
+				"  compile x86     - Target x86_64",
+				"  compile arm64   - Target ARM64",
+			)
+		}
+		output = []string{
+			"🏗️ Architecture Selection",
+			fmt.Sprintf("User specified: %s (%s)", targetArch, session.ArchDisplayNames[targetArch]),
+			"",
+			fmt.Sprintf("✅ Using target architecture: %s", targetArch),
+		}
+	}
+
+	// 远程目标配置了 build target 时在开发板上编译
+	if remote := session.RemoteTarget(app.Ctx); remote != nil && remote.BuildOnTarget {
+		lines, err := session.StartRemoteCompile(ui, app.Ctx, targetArch)
+		if err != nil {
+			return append(output, ""), err
+		}
+		return append(append(output, ""), lines...), nil
+	}
+
+	// 智能检测编译哪种BPF文件
+	varsFile := filepath.Join(app.Ctx.Project.RootPath, "debug_variables.bpf.c")
+	breakpointsFile := filepath.Join(app.Ctx.Project.RootPath, "debug_breakpoints.bpf.c")
+
+	var err error
+	var compiledFile string
+	var scriptFile string
+
+	// 优先编译变量监控版本（如果存在）
+	if _, varsErr := os.Stat(varsFile); varsErr == nil {
+		err = codegen.CompileVariableBPFWithArch(app.Ctx, targetArch)
+		compiledFile = "debug_variables.bpf.o"
+		scriptFile = "./load_debug_vars.sh"
+	} else if _, bpErr := os.Stat(breakpointsFile); bpErr == nil {
+		err = codegen.CompileBPFWithArch(app.Ctx, targetArch)
+		compiledFile = "debug_breakpoints.bpf.o"
+		scriptFile = "./load_debug_bpf.sh"
+	} else {
+		return append(output, ""), errcode.WithHints(errcode.Errorf(errcode.ErrBPFSource, "No BPF source files found"),
+			"",
+			"Please generate BPF code first:",
+			"• Use 'vars' for modern unified debugging (recommended)",
+			"• Use 'vars var1 var2' for debugging with variable monitoring",
+			"• Use 'generate' for legacy basic breakpoint debugging only",
+		)
+	}
+
+	if err != nil {
+		output = append(output, "")
+		if len(app.Ctx.CompileOutput) > 0 {
+			// clang诊断显示在可跳转的窗口中（生成的BPF源码或被引用的原始C源码）
+			errors, warnings := session.ShowDiagnosticsPopup(app.Ctx, "compile", "Compile Errors", app.Ctx.CompileOutput, []string{app.Ctx.Project.RootPath})
+			output = append(output,
+				fmt.Sprintf("📋 %d errors, %d warnings in the Compile Errors popup (Enter jumps to the line)", errors, warnings),
+				"")
+		}
+		return output, errcode.WithHints(errcode.Wrap(errcode.ErrBPFCompile, fmt.Errorf("Compilation failed: %w", err)),
+			"",
+			"💡 Troubleshooting:",
+			"• Check if clang supports BPF: clang -target bpf --help",
+			"• Install headers: sudo apt install linux-headers-$(uname -r)",
+			"• Try different architecture: compile <arch>",
+		)
+	}
+	output = append(output, []string{
+		"",
+		"✅ BPF code compilation completed successfully!",
+		fmt.Sprintf("📁 Output file: %s", compiledFile),
+		fmt.Sprintf("🏗️ Target arch: %s (%s)", targetArch, session.ArchDisplayNames[targetArch]),
+		"",
+		"🔥 BPF Compilation Details:",
+		"• Uses clang BPF backend for optimal code generation",
+		"• Architecture-specific PT_REGS macro selection",
+		"• O2 optimization level for BPF verifier compatibility",
+		"• Cross-platform bytecode generation",
+		"",
+		fmt.Sprintf("⚡ Next step: bpf load (or sudo %s outside the TUI)", scriptFile),
+		"📊 Monitor: sudo cat /sys/kernel/debug/tracing/trace_pipe",
+	}...)
+	return output, nil
+}
+
+// toolchain：交叉编译工具链的查看、设置和检查
+func (app *AppContext) cmdToolchain(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	fields := strings.Fields(args)
+	// 第一个参数是架构名时配置该架构，否则配置当前目标架构
+	arch, _ := session.DetectTargetArch(app.Ctx)
+	if len(fields) > 0 {
+		if named, ok := session.ParseArchName(fields[0]); ok {
+			arch, fields = named, fields[1:]
+		}
+	}
+	switch {
+	case len(fields) == 0:
+		return session.ToolchainSummary(app.Ctx, arch), nil
+	case fields[0] == "check" && len(fields) == 1:
+		output, problems := session.ToolchainCheck(app.Ctx, arch)
+		if problems > 0 {
+			return output, errcode.Errorf(errcode.ErrConfig, "%s的BPF编译工具链有%d个问题", dwarf.RegsArch(arch), problems)
+		}
+		return output, nil
+	case fields[0] == "dry-run" && len(fields) == 1:
+		return session.ToolchainDryRun(app.Ctx, arch)
+	case fields[0] == "reset" && len(fields) <= 2:
+		key := ""
+		if len(fields) == 2 {
+			key = fields[1]
+		}
+		if err := session.ResetToolchain(app.Ctx, arch, key); err != nil {
+			return nil, err
+		}
+		return session.ToolchainSummary(app.Ctx, arch), nil
+	case len(fields) >= 2:
+		if err := session.SetToolchain(app.Ctx, arch, fields[0], fields[1:]); err != nil {
+			return nil, err
+		}
+		return append(session.ToolchainSummary(app.Ctx, arch), "  'toolchain check' validates it, 'toolchain dry-run' shows the clang command"), nil
+	}
+	return nil, errcode.Usage("toolchain [<arch>] [clang <path>|sysroot <dir>|headers <dir>|cflags <flags...>|reset [key]|check|dry-run]")
+}
+
+// make [build|clean|info]：在后台构建内核模块
+func (app *AppContext) cmdMake(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	target := strings.TrimSpace(args)
+	switch target {
+	case "", "info":
+		app.Ctx.Project.Kbuild = project.ParseKbuild(app.Ctx.Project.RootPath)
+		output := project.KbuildInfoLines(app.Ctx.Project.Kbuild, app.Ctx.Project.RootPath)
+		return append(output, "  Usage: make "+strings.Join(project.MakeTargets(app.Ctx.Project.Kbuild), "|")), nil
+	case "build":
+		target = ""
+	}
+	command, err := session.StartModuleBuild(ui, app.Ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Building in the background: %s", command)}, nil
+}
+
+// bpf load|unload|verify：在进程内加载BPF程序
+func (app *AppContext) cmdBPF(ui session.UI, cmd, args string) ([]string, error) {
+	switch {
+	case args == "" || args == "status":
+		return session.BPFStatusLines(app.Ctx), nil
+	case (args == "load" || args == "unload") && app.Ctx.Project != nil && session.RemoteTarget(app.Ctx) != nil:
+		// 远程目标：在开发板上执行加载/卸载脚本
+		lines, err := session.StartRemoteLoad(ui, app.Ctx, args == "load")
+		if err != nil {
+			return nil, err
+		}
+		if args == "load" {
+			lines = append(lines, "Use 'events start' to stream hits from the target's trace_pipe")
+		}
+		return lines, nil
+	case args == "load":
+		warnings, err := session.LoadBPF(ui, app.Ctx)
+		if err != nil {
+			return nil, err
+		}
+		output := append(session.BPFStatusLines(app.Ctx), warnings...)
+		if session.BPFObjectStale(app.Ctx.BPF.Object) {
+			output = append(output, "⚠️  The .bpf.o is older than its source, run 'compile' and reload")
+		}
+		return append(output, "Use 'events start' to stream hits, 'bpf unload' to detach"), nil
+	case args == "verify":
+		object, results, err := session.VerifyBPF(app.Ctx)
+		if err != nil {
+			return nil, err
+		}
+		report, rejected := session.BPFVerifyReport(object, results, true)
+		session.ShowDiagnosticsPopup(app.Ctx, "verify", "BPF Verifier", report, []string{app.Ctx.Project.RootPath})
+		output, _ := session.BPFVerifyReport(object, results, false)
+		if rejected > 0 {
+			return output, errcode.Errorf(errcode.ErrBPFVerifier, "校验器拒绝了%d个程序，Enter在BPF Verifier窗口中跳转到出错的源码行", rejected)
+		}
+		return append(output, "All programs pass the verifier, 'bpf load' to attach them"), nil
+	case args == "unload":
+		if session.UnloadBPF(app.Ctx) {
+			return []string{"BPF programs detached and unloaded"}, nil
+		}
+		return []string{"Tip: No BPF programs loaded"}, nil
+	}
+	return nil, errcode.Usage("bpf [load|unload|verify|status]")
+}
+
+// stap gen|run|stop：SystemTap脚本
+func (app *AppContext) cmdStap(ui session.UI, cmd, args string) ([]string, error) {
+	switch args {
+	case "":
+		return session.ScriptStatusLines(app.Ctx, "stap"), nil
+	case "run":
+		if app.Ctx.Project == nil {
+			return nil, errcode.NoProject
+		}
+		path, err := session.StartBackendCapture(ui, app.Ctx, session.BackendSystemtap)
+		if err != nil {
+			return nil, err
+		}
+		if session.FindPopupWindow(app.Ctx, "events") == nil {
+			session.ShowEventsPopup(app.Ctx)
+		}
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{
+			fmt.Sprintf("Running %s (compiling the probe module can take a while)", path),
+			"Hits stream into the Events window; stap errors and the exit status show up here",
+		}, nil
+	case "stop":
+		if !session.ScriptRunning(app.Ctx, "stap") {
+			return []string{"SystemTap is not running"}, nil
+		}
+		session.StopEventCapture(app.Ctx)
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{"Stopping SystemTap (SIGINT, the probe module is unloaded on exit)"}, nil
+	}
+	return nil, errcode.Usage("stap [run|stop]")
+}
+
+// bpftrace gen|run|stop：bpftrace脚本
+func (app *AppContext) cmdBpftrace(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	switch args {
+	case "":
+		return session.ScriptStatusLines(app.Ctx, "bpftrace"), nil
+	case "gen":
+		path, warnings, err := codegen.GenerateBpftraceScript(app.Ctx)
+		if err != nil {
+			return nil, err
+		}
+		output := []string{fmt.Sprintf("Generated %s:", path)}
+		if data, err := os.ReadFile(path); err == nil {
+			for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+				output = append(output, "  "+line)
+			}
+		}
+		for _, w := range warnings {
+			output = append(output, "Warning: "+w)
+		}
+		return append(output, fmt.Sprintf("Run it here with 'bpftrace run', or elsewhere with 'bpftrace %s'", filepath.Base(path))), nil
+	case "run":
+		path, err := session.StartBackendCapture(ui, app.Ctx, session.BackendBpftrace)
+		if err != nil {
+			return nil, err
+		}
+		if session.FindPopupWindow(app.Ctx, "events") == nil {
+			session.ShowEventsPopup(app.Ctx)
+		}
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{
+			fmt.Sprintf("Running %s", path),
+			"Hits stream into the Events window; bpftrace errors and the exit status show up here",
+		}, nil
+	case "stop":
+		if !session.ScriptRunning(app.Ctx, "bpftrace") {
+			return []string{"bpftrace is not running"}, nil
+		}
+		session.StopEventCapture(app.Ctx)
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{"Stopping bpftrace"}, nil
+	}
+	return nil, errcode.Usage("bpftrace [gen|run|stop]")
+}
+
+// debuginfo：模块的DWARF调试信息
+func (app *AppContext) cmdDebugInfo(ui session.UI, cmd, args string) ([]string, error) {
+	if args == "" {
+		return nil, errcode.Usage("debuginfo <module.ko|vmlinux>")
+	}
+	binaryPath := args
+	if !filepath.IsAbs(binaryPath) && app.Ctx.Project != nil {
+		binaryPath = filepath.Join(app.Ctx.Project.RootPath, binaryPath)
+	}
+	file, debugPath, err := dwarf.OpenDebugELF(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	compressed := file.Section(".zdebug_info") != nil
+	if section := file.Section(".debug_info"); section != nil && section.Flags&elf.SHF_COMPRESSED != 0 {
+		compressed = true
+	}
+	file.Close()
+	output := []string{fmt.Sprintf("Debug info: %s", debugPath)}
+	if debugPath != binaryPath {
+		output = append(output, "Source: separate debug file (build-id/debuglink)")
+	} else {
+		output = append(output, "Source: embedded in binary")
+	}
+	if compressed {
+		output = append(output, "Sections: compressed (decompressed on load)")
+	}
+	return output, nil
+}
+
+// modinfo [ko]：模块版本信息与运行中的内核比较
+func (app *AppContext) cmdModinfo(ui session.UI, cmd, args string) ([]string, error) {
+	module := args
+	if module == "" && app.Ctx.Project != nil {
+		module = project.FindProjectModule(app.Ctx.Project.RootPath)
+	}
+	if module == "" {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "No module found, usage: modinfo <module.ko>")
+	}
+	info, err := session.ReadModuleInfo(module)
+	if err != nil {
+		return nil, err
+	}
+	return session.ModuleInfoLines(info), nil
+}
+
+// diagnose [func]：检查kprobe不能挂载的原因
+func (app *AppContext) cmdDiagnose(ui session.UI, cmd, args string) ([]string, error) {
+	functions := strings.Fields(args)
+	if len(functions) == 0 && app.Ctx.Project != nil {
+		seen := make(map[string]bool)
+		for _, bp := range app.Ctx.Project.Breakpoints {
+			if bp.Enabled && bp.Function != "" && !seen[bp.Function] {
+				seen[bp.Function] = true
+				functions = append(functions, bp.Function)
+			}
+		}
+	}
+	if len(functions) == 0 {
+		return nil, errcode.Usage("diagnose <function> (defaults to the breakpoint functions)")
+	}
+	module := ""
+	if app.Ctx.Project != nil {
+		module = project.FindProjectModule(app.Ctx.Project.RootPath)
+	}
+	content := make([]string, 0)
+	for _, function := range functions {
+		content = append(content, session.DiagnoseAttachFailure(function, module, nil)...)
+		content = append(content, "")
+	}
+	session.ClosePopupWindow(app.Ctx, "diagnose")
+	session.ShowPopupWindow(app.Ctx, session.CreatePopupWindow(app.Ctx, "diagnose", "Probe Attach Diagnosis", 100, 25, content))
+	return []string{fmt.Sprintf("Checked %d functions for probe attach problems", len(functions))}, nil
+}
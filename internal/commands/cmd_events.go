@@ -0,0 +1,538 @@
+package commands
+
+import (
+	"debug/elf"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：事件采集和分析 ==========
+
+// events start|stop|clear|fold|filter：事件采集和事件窗口
+func (app *AppContext) cmdEvents(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	sub := ""
+	if len(fields) > 0 {
+		sub = fields[0]
+	}
+	switch sub {
+	case "", "list":
+		session.ShowEventsPopup(app.Ctx)
+		return []string{fmt.Sprintf("Events window opened (%d events)", len(app.Ctx.Events))}, nil
+	case "start":
+		if owner := app.localCaptureOwner(); owner != 0 {
+			return nil, errcode.WithHints(errcode.Errorf(errcode.ErrTracePipe, "workspace %d is already reading the local trace_pipe", owner),
+				"Each trace_pipe line goes to only one reader, set 'remote ssh' for this workspace or stop that capture")
+		}
+		path, err := session.StartEventCapture(ui, app.Ctx)
+		if err != nil {
+			return nil, errcode.WithHints(err, "Reading trace_pipe usually requires root")
+		}
+		if session.FindPopupWindow(app.Ctx, "events") == nil {
+			session.ShowEventsPopup(app.Ctx)
+		}
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{fmt.Sprintf("Capturing events from %s (live in the Events window)", path)}, nil
+	case "stop":
+		if session.StopEventCapture(app.Ctx) {
+			session.RefreshEventsPopup(app.Ctx)
+			return []string{"Event capture stopped"}, nil
+		}
+		return []string{"Event capture is not running"}, nil
+	case "clear":
+		app.Ctx.Events = nil
+		app.Ctx.RegisterHistory = nil
+		session.TimelineLive(app.Ctx)
+		app.Ctx.ExpandedEventGroups = nil
+		app.Ctx.EventsDropped = 0
+		app.Ctx.EventsUnparsed = 0
+		session.ResetAssertions(app.Ctx)
+		session.RefreshEventsPopup(app.Ctx)
+		return []string{"Events cleared"}, nil
+	case "fold":
+		if len(fields) > 1 && fields[1] == "off" {
+			app.Ctx.EventFoldOff = true
+		} else if len(fields) > 1 && fields[1] == "on" {
+			app.Ctx.EventFoldOff = false
+		}
+		session.RefreshEventsPopup(app.Ctx)
+		if app.Ctx.EventFoldOff {
+			return []string{"Event folding: off (every event on its own row)"}, nil
+		}
+		return []string{"Event folding: on (identical consecutive events shown as ×N)"}, nil
+	case "filter":
+		defer session.RefreshEventsPopup(app.Ctx)
+		switch {
+		case len(fields) == 1:
+			if len(app.Ctx.EventFilter) == 0 {
+				return []string{"Event filter: off (all breakpoints shown)"}, nil
+			}
+			return []string{"Event filter: " + session.EventFilterText(app.Ctx)}, nil
+		case fields[1] == "off":
+			app.Ctx.EventFilter = nil
+			return []string{"Event filter: off (all breakpoints shown)"}, nil
+		}
+		filter := make(map[int]bool)
+		for _, field := range fields[1:] {
+			n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(field), "bp"))
+			if err != nil || n < 1 {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid breakpoint number: %s", field)
+			}
+			filter[n] = true
+		}
+		app.Ctx.EventFilter = filter
+		return []string{"Event filter: " + session.EventFilterText(app.Ctx)}, nil
+	case "expand":
+		n := 0
+		if len(fields) > 1 {
+			n, _ = strconv.Atoi(fields[1])
+		}
+		if err := session.ToggleEventGroup(app.Ctx, n); err != nil {
+			return nil, err
+		}
+		session.ShowEventsPopup(app.Ctx)
+		return []string{fmt.Sprintf("Toggled event group %d", n)}, nil
+	}
+	return nil, errcode.Usage("events [list|start|stop|clear|filter <bp...>|off|fold on|off|expand <n>]")
+}
+
+// dmesg start|stop：读取内核日志
+func (app *AppContext) cmdDmesg(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	sub := ""
+	if len(fields) > 0 {
+		sub = fields[0]
+	}
+	switch sub {
+	case "":
+		session.ShowKmsgPopup(app.Ctx)
+		return []string{"Kernel log window opened (breakpoint hits shown inline)"}, nil
+	case "start":
+		all := len(fields) > 1 && fields[1] == "all"
+		path, err := session.StartKmsgCapture(ui, app.Ctx, all)
+		if err != nil {
+			return nil, err
+		}
+		if session.FindPopupWindow(app.Ctx, "dmesg") == nil {
+			session.ShowKmsgPopup(app.Ctx)
+		}
+		output := []string{fmt.Sprintf("Reading kernel log from %s (interleaved with events by timestamp)", path)}
+		if all {
+			output = append(output, "Existing ring buffer records imported")
+		}
+		return output, nil
+	case "stop":
+		if session.StopKmsgCapture(app.Ctx) {
+			session.RefreshKmsgPopup(app.Ctx)
+			return []string{"Kernel log reading stopped"}, nil
+		}
+		return []string{"Kernel log reading is not running"}, nil
+	case "around":
+		window := session.DefaultKmsgWindow
+		id := 0
+		if len(fields) > 1 {
+			id, _ = strconv.Atoi(strings.TrimPrefix(strings.ToLower(fields[1]), "bp"))
+		}
+		if len(fields) > 2 {
+			ms, err := strconv.Atoi(strings.TrimSuffix(fields[2], "ms"))
+			if err != nil || ms <= 0 {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid window: %s", fields[2])
+			}
+			window = time.Duration(ms) * time.Millisecond
+		}
+		if id < 1 {
+			return nil, errcode.Usage("dmesg around <bp> [ms]")
+		}
+		return session.KmsgAroundLines(app.Ctx, id, window), nil
+	}
+	return nil, errcode.Usage("dmesg [start [all]|stop|around <bp> [ms]]")
+}
+
+// timeline：时间线窗口
+func (app *AppContext) cmdTimeline(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	sub := ""
+	if len(fields) > 0 {
+		sub = fields[0]
+	}
+	switch {
+	case sub == "" && app.Ctx.Timeline != nil, sub == "off":
+		app.Ctx.Timeline = nil
+		return []string{"Timeline closed, panels show live data"}, nil
+	case sub == "", sub == "on":
+		if app.Ctx.Timeline == nil {
+			app.Ctx.Timeline = &session.TimelineState{Follow: true}
+		}
+		return []string{fmt.Sprintf("Timeline opened (%d events)", len(app.Ctx.Events))}, nil
+	case sub == "fit":
+		if app.Ctx.Timeline == nil {
+			app.Ctx.Timeline = &session.TimelineState{}
+		}
+		tl := app.Ctx.Timeline
+		tl.Span, tl.Start, tl.Follow = 0, 0, true
+		return []string{"Timeline shows all events"}, nil
+	case sub == "live":
+		session.TimelineLive(app.Ctx)
+		return []string{"Panels show live data"}, nil
+	case sub == "zoom" && len(fields) == 2:
+		seconds, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "s"), 64)
+		if err != nil || seconds < session.MinTimelineSpan {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid span: %s", fields[1])
+		}
+		if app.Ctx.Timeline == nil {
+			app.Ctx.Timeline = &session.TimelineState{}
+		}
+		app.Ctx.Timeline.Span = seconds
+		app.Ctx.Timeline.Follow = true
+		return []string{fmt.Sprintf("Timeline shows the last %s", session.FormatTimelineSpan(seconds))}, nil
+	case sub == "goto" && len(fields) == 2:
+		seq, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		if err != nil {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid event: %s", fields[1])
+		}
+		event, err := session.SelectTimelineEvent(ui, app.Ctx, seq)
+		if event == nil {
+			return nil, err
+		}
+		output := []string{fmt.Sprintf("At #%d %s", event.Seq, session.StripANSI(session.FormatEvent(app.Ctx, *event)))}
+		if err != nil {
+			output = append(output, fmt.Sprintf("Warning: %v", err))
+		}
+		return output, nil
+	}
+	return nil, errcode.Usage("timeline [on|off|fit|live|zoom <seconds>|goto <seq>]")
+}
+
+// record start|stop：录制帧文件
+func (app *AppContext) cmdRecord(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		return session.RecordingLines(app.Ctx), nil
+	case fields[0] == "start" && len(fields) <= 2:
+		path := ""
+		if len(fields) == 2 {
+			path = fields[1]
+		}
+		path, err := session.StartRecording(ui, app.Ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return []string{
+			fmt.Sprintf("Recording breakpoint hits as frames to %s", path),
+			"Each frame keeps the source line, variables, registers and call stack; 'record stop' to finish",
+		}, nil
+	case fields[0] == "stop" && len(fields) == 1:
+		rec, err := session.StopRecording(app.Ctx)
+		switch {
+		case rec == nil:
+			return []string{"Not recording"}, nil
+		case err != nil:
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Recorded %d frames to %s, 'replay %s' to step through them", rec.Frames, rec.Path, rec.Path)}, nil
+	}
+	return nil, errcode.Usage("record [start [file]|stop]")
+}
+
+// replay <file>：回放帧文件
+func (app *AppContext) cmdReplay(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		return session.RecordingLines(app.Ctx), nil
+	case len(fields) != 1:
+		return nil, errcode.Usage("replay <file>|next|prev|first|last|<n>|off")
+	case fields[0] == "off":
+		if app.Ctx.Replay == nil {
+			return []string{"Not replaying"}, nil
+		}
+		app.Ctx.Replay = nil
+		return []string{"Replay closed, panels show live data"}, nil
+	}
+	var output []string
+	index, step := session.ReplayFrameIndex(app.Ctx.Replay, fields[0])
+	if step && app.Ctx.Replay == nil {
+		return nil, errcode.Errorf(errcode.ErrUsage, "no recording loaded, 'replay <file>' first")
+	}
+	if !step {
+		replay, err := session.LoadFrames(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		app.Ctx.Replay = replay
+		output = []string{fmt.Sprintf("Loaded %d frames from %s (recorded %s), F9/F10 to step", len(replay.Frames), replay.Path, replay.Header.Started.Format("2006-01-02 15:04:05"))}
+	}
+	frame, err := session.JumpToReplayFrame(ui, app.Ctx, index)
+	if frame == nil {
+		return output, err
+	}
+	output = append(output, session.ReplayFrameSummary(app.Ctx.Replay, frame))
+	if err != nil {
+		output = append(output, fmt.Sprintf("Warning: %v", err))
+	}
+	return output, nil
+}
+
+// import-trace <file>：导入外部trace
+func (app *AppContext) cmdImportTrace(ui session.UI, cmd, args string) ([]string, error) {
+	path := strings.TrimSpace(args)
+	if path == "" {
+		return nil, errcode.Usage("import-trace <file>")
+	}
+	result, err := session.ImportTrace(app.Ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	output := []string{fmt.Sprintf("Imported %d events (%d frames) from %s", result.Events, result.Frames, path)}
+	if result.Unparsed > 0 {
+		output = append(output, fmt.Sprintf("%d lines without breakpoint markers skipped", result.Unparsed))
+	}
+	captured := make([]int, 0, len(result.Remapped))
+	for id := range result.Remapped {
+		captured = append(captured, id)
+	}
+	sort.Ints(captured)
+	for _, id := range captured {
+		output = append(output, fmt.Sprintf("BP%d in the capture is breakpoint %d now (matched by source line)", id, result.Remapped[id]))
+	}
+	for _, w := range result.Warnings {
+		output = append(output, "Warning: "+w)
+	}
+	replay, err := session.LoadFrames(result.Path)
+	if err != nil {
+		return output, err
+	}
+	app.Ctx.Replay = replay
+	output = append(output, fmt.Sprintf("Frames written to %s, F9/F10 to step", result.Path))
+	if frame, err := session.JumpToReplayFrame(ui, app.Ctx, 0); frame != nil {
+		output = append(output, session.ReplayFrameSummary(replay, frame))
+		if err != nil {
+			output = append(output, fmt.Sprintf("Warning: %v", err))
+		}
+	}
+	return output, nil
+}
+
+// diff-frames <a> <b>：比较两次命中的帧
+func (app *AppContext) cmdDiffFrames(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	var a, b int
+	if len(fields) == 2 {
+		a, _ = strconv.Atoi(fields[0])
+		b, _ = strconv.Atoi(fields[1])
+	}
+	if a == 0 || b == 0 {
+		return nil, errcode.Usage("diff-frames <a> <b>")
+	}
+	changed, err := session.ShowFrameDiffPopup(app.Ctx, a, b)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{fmt.Sprintf("Frame diff window opened (frame %d → %d)", a, b)}, changed...), nil
+}
+
+// export：导出事件
+func (app *AppContext) cmdExport(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if len(fields) > 1 && (fields[0] == "perfetto" || fields[0] == "chrome") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || len(fields) > 2 || fields[0] == "perfetto" || fields[0] == "chrome" {
+		return nil, errcode.Usage("export [perfetto] <file> [recording.frames]")
+	}
+	path := session.ExportPath(app.Ctx, fields[0])
+	count, err := 0, error(nil)
+	if len(fields) == 2 {
+		// 导出录制的帧文件，而不是当前的事件缓冲区
+		var replay *session.ReplayState
+		if replay, err = session.LoadFrames(fields[1]); err == nil {
+			count, err = session.ExportRecording(replay, path)
+		}
+	} else if len(app.Ctx.Events) == 0 && app.Ctx.Replay != nil {
+		// 没有实时事件时导出正在回放的录制
+		count, err = session.ExportRecording(app.Ctx.Replay, path)
+	} else {
+		count, err = session.ExportPerfetto(app.Ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{
+		fmt.Sprintf("Exported %d trace events to %s", count, path),
+		"Open it in https://ui.perfetto.dev or chrome://tracing",
+	}, nil
+}
+
+// stats：断点命中统计
+func (app *AppContext) cmdStats(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		session.ShowStatsPopup(app.Ctx)
+		return []string{fmt.Sprintf("Statistics window opened (%d events)", len(app.Ctx.Events))}, nil
+	case fields[0] == "bp" && len(fields) <= 2:
+		id := 0
+		if len(fields) == 2 {
+			n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(fields[1]), "bp"))
+			if err != nil || n < 1 {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid breakpoint: %s", fields[1])
+			}
+			id = n
+		}
+		session.ShowBreakpointStatsPopup(app.Ctx, id)
+		return []string{"Breakpoint statistics window opened (hits, rate, intervals, top processes)"}, nil
+	}
+	return nil, errcode.Usage("stats [bp [n]]")
+}
+
+// span <entry> [exit]：测量调用耗时
+func (app *AppContext) cmdSpan(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	settings := app.Ctx.Project.Settings
+	switch {
+	case len(fields) == 0:
+		output := []string{fmt.Sprintf("Latency spans (%d):", len(settings.Spans))}
+		for i, s := range settings.Spans {
+			output = append(output, fmt.Sprintf("  %d. %s  [%s]", i+1, s, session.SpanSummary(session.SpanDurations(app.Ctx, i+1))))
+		}
+		if len(settings.Spans) == 0 {
+			output = append(output, "  (none) Usage: span <entry> [exit] [by pid|tgid|<arg>]")
+		}
+		return output, nil
+	case fields[0] == "hist" && len(fields) <= 2:
+		id := 0
+		if len(fields) == 2 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 || n > len(settings.Spans) {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid span number: %s", fields[1])
+			}
+			id = n
+		}
+		session.ShowSpanHistPopup(app.Ctx, id)
+		return []string{"Span latency window opened (log2 histogram, live during capture)"}, nil
+	case fields[0] == "clear" && len(fields) == 1:
+		settings.Spans = nil
+		output := []string{"All spans removed (regenerate to drop their probes)"}
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+		}
+		return output, nil
+	case fields[0] == "del" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > len(settings.Spans) {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid span number: %s", fields[1])
+		}
+		removed := settings.Spans[n-1]
+		settings.Spans = append(settings.Spans[:n-1], settings.Spans[n:]...)
+		output := []string{fmt.Sprintf("Removed span: %s", removed)}
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+		}
+		return output, nil
+	}
+	s, err := session.ParseSpanProbe(args)
+	if err != nil {
+		return nil, err
+	}
+	settings.Spans = append(settings.Spans, s)
+	output := []string{
+		fmt.Sprintf("Span %d: %s", len(settings.Spans), s),
+		"Run 'generate' (or 'vars') and 'bpf load', then 'span hist' to see the latency histogram",
+	}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+	}
+	return output, nil
+}
+
+// locks：锁竞争探针
+func (app *AppContext) cmdLocks(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	switch args {
+	case "":
+		session.ShowLocksPopup(app.Ctx)
+		sites := 0
+		if app.Ctx.LockStats != nil {
+			sites = len(app.Ctx.LockStats.Sites)
+		}
+		return []string{fmt.Sprintf("Lock contention window opened (%d call sites)", sites)}, nil
+	case "on", "off":
+		app.Ctx.Project.Settings.Locks = args == "on"
+		output := []string{fmt.Sprintf("Lock probes %s: run 'generate' (or 'vars') and 'bpf load' to apply", args)}
+		if args == "on" {
+			output = append(output, "mutex_lock*/_raw_spin_lock* called from the module are measured; schedule() under a module spinlock is reported")
+		}
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+		}
+		return output, nil
+	case "reset":
+		app.Ctx.LockStats = nil
+		session.RefreshStatsPopup(app.Ctx)
+		return []string{"Lock statistics and source annotations cleared"}, nil
+	}
+	return nil, errcode.Usage("locks [on|off|reset]")
+}
+
+// snapshot：目标状态快照
+func (app *AppContext) cmdSnapshot(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		if app.Ctx.SnapshotStop != nil {
+			return []string{fmt.Sprintf("Snapshots: every %s (%d watches)", app.Ctx.SnapshotInterval, len(session.WatchExpressions(app.Ctx)))}, nil
+		}
+		return []string{"Snapshots: off", "Usage: snapshot [every <seconds>|now|off]"}, nil
+	case fields[0] == "every" && len(fields) == 2:
+		seconds, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid interval: %s", fields[1])
+		}
+		if err := session.StartSnapshots(ui, app.Ctx, time.Duration(seconds)*time.Second); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Sampling %d watched globals every %ds into the event timeline", len(session.WatchExpressions(app.Ctx)), seconds)}, nil
+	case fields[0] == "now":
+		if err := session.CheckSafeMode(app.Ctx, "定时快照"); err != nil {
+			return nil, err
+		}
+		kcore, err := elf.Open("/proc/kcore")
+		if err != nil {
+			return nil, errcode.Wrap(errcode.ErrKcore, fmt.Errorf("cannot open /proc/kcore (root required): %w", err))
+		}
+		samples := session.TakeSnapshot(kcore, session.ResolveSnapshotTargets(app.Ctx))
+		kcore.Close()
+		session.RecordSnapshot(app.Ctx, samples)
+		if len(samples) == 0 {
+			return []string{"No watch expressions to sample"}, nil
+		}
+		var output []string
+		for _, sample := range samples {
+			if sample.Err != nil {
+				output = append(output, fmt.Sprintf("  %s: %v", sample.Expr, sample.Err))
+			} else {
+				output = append(output, fmt.Sprintf("  %s = %s", sample.Expr, session.FormatValue(app.Ctx, sample.Expr, sample.Value)))
+			}
+		}
+		return output, nil
+	case fields[0] == "off":
+		if session.StopSnapshots(app.Ctx) {
+			return []string{"Snapshots stopped"}, nil
+		}
+		return []string{"Snapshots are not running"}, nil
+	}
+	return nil, errcode.Usage("snapshot [every <seconds>|now|off]")
+}
@@ -0,0 +1,187 @@
+package commands
+
+import "debug-gocui/internal/session"
+
+// ========== 命令：帮助 ==========
+
+// help：命令参考
+func (app *AppContext) cmdHelp(ui session.UI, cmd, args string) ([]string, error) {
+	var output []string
+	output = []string{
+		"🎯 Kernel Debugger - Command Reference",
+		"",
+		"🚀 Quick Start:",
+		"  1. open /path/to/project    - Open project",
+		"  2. Double-click code lines  - Set breakpoints", 
+		"  3. vars                    - Auto-generate debug code",
+		"  4. compile                 - Build BPF program",
+		"",
+		"📂 Project Commands:",
+		"  open <path>    - Open project directory",
+		"  close          - Close current project",
+		"  pwd            - Show current directory",
+		"  status         - Show debugger status",
+		"  env            - Show environment (kernel, arch, KASLR offset)",
+		"  arch [name|auto] - Show/pin target arch (default: module ELF header)",
+		"  demo [on|off]  - Show/hide SIMULATED sample data in Registers/Variables/Stack",
+		"  highlight [on|off] - C syntax highlighting in the code view",
+		"  theme [dark|light|high-contrast|dark256] - Switch the color scheme",
+		"  safe [off]     - Show/leave safe mode (started with --safe)",
+		"  why [code|list] - Troubleshooting for the last (or given) error code",
+		"  perf / about   - The debugger's own CPU, memory and latency",
+		"  modinfo [ko]   - Module vermagic/srcversion vs. running kernel and loaded module",
+		"  diagnose [func] - Check why a kprobe cannot attach (kallsyms, module, versions)",
+		"  history [n]    - Show the last n commands (Ctrl+R searches history)",
+		"  history save <file> | size <lines> | age <duration|off> - Save/bound the history",
+		"  remote [ssh <user@host>|ping <cmd>|attach <cmd>|off] - Capture from a remote board",
+		"  remote [user|key|port|dir|build host|target|sync] - SSH options; bpf load/compile run on the board",
+		"  watchdog [on [sec]|off] - Detect remote board hangs/reboots and reconnect",
+		"  workspace [n|new [name]|name <name>|close [n]] - Independent capture workspaces (Alt+1..Alt+4)",
+		"  selftest       - End-to-end check with the bundled sample module (needs root)",
+		"",
+		"📡 Event Commands:",
+		"  events         - Show event list (repeated hits folded as ×N)",
+		"  events start|stop - Capture events from trace_pipe (opens the live Events window)",
+		"  backend [bpf|ftrace|kprobe|systemtap|bpftrace|perf] - Choose how 'events start' traces breakpoints",
+		"  backend detect - Check tools and kernel features, pick a backend from the recommendations",
+		"  backend gdb [host:port|/dev/tty*] - Debug through QEMU's gdbstub or kgdboc (default :1234)",
+		"  backend kdb <tty> [baud] - Debug through the kernel's kdb on a kgdboc serial port",
+		"  break [<file:line|symbol|0xaddr>|delete <n|all>] - Target breakpoints (gdb/kdb backend)",
+		"  continue|c, interrupt, step|s, next|n, stepi|si - Run and single-step (gdb/kdb backend)",
+		"  filter [pid <n>|comm <name>|cpu <n>|clear] - Only fire generated BPF probes for this process/CPU",
+		"  events filter <bp...>|off - Only show hits of these breakpoints (1-9 in the window)",
+		"  events expand <n> - Expand/collapse folded row n",
+		"  events fold on|off - Toggle folding of identical consecutive events",
+		"  events clear   - Clear captured events",
+		"  dmesg [start [all]|stop] - Kernel log panel, printk interleaved with hits by timestamp",
+		"  dmesg around <bp> [ms] - Kernel log around the last hits of a breakpoint (default ±50ms)",
+		"  stats          - Session statistics dashboard (live during capture)",
+		"  stats bp [n]   - Per-breakpoint hits, rate, min/avg/max interval and top processes",
+		"  timeline [on|off]     - Timeline panel of hits, variable changes and dmesg (click/[ ] jumps panels)",
+		"  timeline zoom <s>|goto <seq>|fit|live - Zoom, jump all panels to an event, show all, back to live",
+		"  record start [file]|stop - Record breakpoint hits as frames (.frames file)",
+		"  replay <file>|next|prev|<n>|off - Step through recorded frames (F9/F10)",
+		"  import-trace <file>   - Replay a saved trace_pipe / trace-cmd report text dump",
+		"  diff-frames <a> <b>   - Colored diff of variables, registers and stack between two frames",
+		"  export [perfetto] <file> [rec.frames] - Export events or a recording as Chrome trace JSON (ui.perfetto.dev)",
+		"  assert bp1 before bp2 [within 10ms] [per pid] - Add ordering assertion",
+		"  assert [del <n>|violations|reset] - List/remove assertions, show violations",
+		"  span <entry> [exit] [by pid|tgid|<arg>] - Measure per-call latency (exit omitted: entry's return)",
+		"  span [hist [n]|del <n>|clear] - List spans, latency histogram, remove spans",
+		"  locks [on|off|reset] - Lock hold/contention report; on adds mutex/spinlock probes",
+		"  stap [run|stop] - Run the generated SystemTap script under the TUI, or show its status",
+		"  bpftrace [gen|run|stop] - Generate/run an equivalent bpftrace script (no clang/bpftool needed)",
+		"  debuginfo <ko> - Locate DWARF (embedded, build-id or debuglink)",
+		"  ops [list]     - Show operation journal",
+		"  ops replay [reset] - Reset project state and replay the journal (asks first unless 'reset')",
+		"  ops clear      - Clear the operation journal",
+		"",
+		"🔴 Breakpoint Commands:",
+		"  bp             - View all breakpoints",
+		"  bp clear       - Clear all breakpoints",
+		"  bp toggle <file>:<line> - Toggle breakpoint at location",
+		"  bp note <n> \"text\" - Annotate breakpoint n (no text clears it)",
+		"  bp retval <n> [off] - Also report the function's return value (kretprobe)",
+		"  bp uprobe <binary> <function> - Breakpoint in a user-space helper (uprobe, same event stream)",
+		"  bp cond <n> [expr] - Fire only when expr holds, e.g. arg0 > 1024 && pid == 1234 (evaluated in BPF)",
+		"  bp export <file> [json|text] - Save breakpoints (paths relative to the project)",
+		"  bp repair [<n> <file>|drop] - List breakpoints whose files are missing, move or remove them",
+		"  bp import <file> [merge|overwrite] - Load breakpoints from a .json or text file",
+		"  bp resolve - Map breakpoints to function+offset via the module's DWARF line table",
+		"  bp check   - Check kallsyms and the kprobe blacklist, suggest .isra/.constprop or caller alternatives",
+		"  hwbp <addr|symbol> <r|w|rw|x> [len] - Hardware breakpoint via perf (no kprobes; data access too)",
+		"  hwbp [del <n|all>] - List or delete hardware breakpoints",
+		"  (Interactive)  - Double-click code line to set/toggle breakpoint",
+		"",
+		"📌 Mark Commands:",
+		"  m <a-z>        - Set mark at current code line (saved per project)",
+		"  ' <a-z>        - Jump to mark ('' jumps back)",
+		"  marks          - List marks",
+		"  :<line>        - Go to line in the current file (:50% by position, :$ last line)",
+		"  delmarks <a-z> - Delete mark",
+		"  ws [n]         - Working set: recently touched files/functions (w in panels)",
+		"",
+		"📂 Source Commands:",
+		"  frame <n>      - Jump to stack frame source (Enter in Call Stack)",
+		"  callgraph [func] [depth] - Static call tree (Enter on a node sets a breakpoint)",
+		"  symbols [pattern] - Module symbol table (Enter: breakpoint on func, watch on var)",
+		"  grep <term>    - Search all project sources (Enter jumps to the match)",
+		"  replace <pattern> <text> - Replace across project sources, confirm each (y/n/a/s, q cancels)",
+		"  def [name]     - Go to definition (gd on the identifier under the code cursor, '' jumps back)",
+		"  refs [name]    - List references in a popup (gr in the code view)",
+		"  outline        - Functions of the current file with breakpoint markers (Ctrl+O)",
+		"  make [info|build|clean|<target>] - Parse Makefile/Kbuild, build in the background",
+		"  disasm [func|off] - Source/assembly view of a module function (probe address highlighted)",
+		"  src <path>[:line] - Open file referenced by debug info",
+		"  srcmap         - List source path substitutions",
+		"  srcmap add <from> <to> - Map build path prefix to local path",
+		"  srcmap del <n> - Remove substitution",
+		"  srcfetch [git <tree> [ref]|url <template>|off] - Fetch missing files",
+		"",
+		"👁️ Watch Commands:",
+		"  watch          - List watch expressions",
+		"  watch <expr>   - Add watch expression (saved per project)",
+		"  unwatch <n|expr> - Remove watch expression",
+		"  fmt <var> [dec|hex|bin|enum <Type>] - Value display format (x in Variables cycles)",
+		"  snapshot every <N> - Sample watched globals every N seconds (needs root)",
+		"  snapshot now|off - Take one sample / stop periodic sampling",
+		"  mem read <addr|symbol|link:addr> <len> - Hex/ASCII dump of kernel memory (link: = vmlinux address + KASLR offset)",
+		"  mem width <n>|refresh|close - Bytes per line (+/- in Memory) / re-read / hide",
+		"",
+		"🤖 Debug Code Generation:",
+		"  vars           - 🔥 Auto-detect all variables + generate BPF",
+		"  vars auto      - Same as above (explicit auto mode)",
+		"  vars <names>   - Manual variable specification (e.g. vars local_var i)",
+		"  compile        - 🏗️ Auto-detect current architecture and compile",
+		"  compile <arch> - Compile for specific architecture (x86/arm64/riscv64/etc)",
+		"  toolchain [<arch>] [clang|sysroot|headers|cflags <v>|reset|check|dry-run] - Cross-compile settings per arch",
+		"  bpf load|unload|status - Load the compiled .bpf.o and attach kprobes in-process (root)",
+		"  bpf verify     - Run every program through the kernel verifier, map rejections to source lines",
+		"  generate       - Basic function monitoring only (legacy)",
+		"",
+		"⌨️ Interface:",
+		"  help, h        - Show this help",
+		"  clear          - Clear command output",
+		"  Ctrl+F         - Search in code",
+		"  F3             - Next search result",
+		"  Tab            - Switch windows (completes commands and paths in the command window)",
+		"  Up/Down        - Recall previous commands in the command window",
+		"  F1-F6          - Direct window switch (Files/Registers/Variables/Stack/Code/Command)",
+		"  F11            - Toggle fullscreen",
+		"  Ctrl+X <key>   - Leader key: run a panel shortcut (` w x + -) from any window",
+		"  Ctrl+P         - Command palette (fuzzy search all actions, e.g. generate, bp clear)",
+		"                   terminals send the same byte for Ctrl+Shift+P, so Ctrl+P is the only binding",
+		"  keys           - List key bindings (remap in ~/.config/kdebug-tui/keys.toml)",
+		"  rpc [start [socket]|stop] - JSON-RPC control socket for editors (also: --rpc <socket>)",
+		"  source <file>  - Run commands from a script (also: debug-gocui --script <file> [--tui])",
+		"  tab [n|close [n]] - List/switch/close open files (Ctrl+B, or click a tab)",
+		"  ESC            - Exit fullscreen/search",
+		"  q              - Close popup windows",
+		"",
+		"🏗️ Architecture Support:",
+		"  ✅ x86_64 (Intel/AMD 64-bit)",
+		"  ✅ ARM64/AArch64",
+		"  ✅ RISC-V 64-bit",
+		"  ✅ IBM System z (s390x)",
+		"  ✅ PowerPC 64-bit LE",
+		"  ✅ MIPS 64-bit",
+		"  📋 Interactive selection during compile",
+		"",
+		"🔍 BPF Monitoring Capabilities:",
+		"  ✅ Function call detection",
+		"  ✅ Process info (PID, name)",
+		"  ✅ Precise timestamps",
+		"  ✅ Variable values (with vars)",
+		"  ⚠️  Limitation: Function entry only, not specific lines",
+		"",
+		"📁 Generated Files (vars command):",
+		"  • debug_variables.bpf.c",
+		"  • load_debug_vars.sh",
+		"  • unload_debug_vars.sh",
+		"",
+		"🔄 Typical Workflow:",
+		"  open . → Double-click lines → vars → compile → bpf load → events start",
+		"  bpf unload when done (scripts remain for use outside the TUI)",
+	}
+	return output, nil
+}
@@ -0,0 +1,450 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：项目、界面和会话 ==========
+// 命令名到处理函数的对应见 commands.go 命令表。
+
+// clear：清空命令窗口
+func (app *AppContext) cmdClear(ui session.UI, cmd, args string) ([]string, error) {
+	// 清屏 - 清空命令历史
+	app.Ctx.CommandHistory = []string{}
+	app.Ctx.CurrentInput = ""
+	// 标记需要重绘
+	app.Ctx.CommandDirty = true
+	return nil, nil
+}
+
+// pwd：显示当前目录
+func (app *AppContext) cmdPwd(ui session.UI, cmd, args string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	return []string{wd}, nil
+}
+
+// open <path>：打开项目目录（路径可以包含空格）
+func (app *AppContext) cmdOpen(ui session.UI, cmd, args string) ([]string, error) {
+	if args == "" {
+		return nil, errcode.Usage("open <project_path>", "Tip: Supports paths with spaces, e.g.: open /path/to/folder with spaces")
+	}
+	projectPath := args // 直接使用args，保留所有空格
+	output := []string{fmt.Sprintf("Processing path: %s", projectPath)}
+
+	// 如果是相对路径，转换为绝对路径
+	if !filepath.IsAbs(projectPath) {
+		wd, _ := os.Getwd()
+		projectPath = filepath.Join(wd, projectPath)
+		output = append(output, fmt.Sprintf("Converting to absolute path: %s", projectPath))
+	}
+
+	// 检查路径是否存在
+	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "Path does not exist: %s", projectPath)
+	}
+	output = append(output, "Path exists, opening project...")
+
+	proj, err := project.OpenProject(projectPath)
+	if err != nil {
+		return output, fmt.Errorf("Failed to open project: %w", err)
+	}
+	app.Ctx.Project = proj
+	app.Ctx.WorkingSet = nil
+	app.Ctx.ProbeChecks = nil
+	// 日志中记录打开项目（重新打开同一路径时不重复记录）
+	if n := len(proj.Journal); n == 0 || proj.Journal[n-1].Command != "open "+projectPath {
+		session.RecordOperation(app.Ctx, "open "+projectPath)
+	}
+	fileCount := countFiles(proj.FileTree)
+	output = append(output, []string{
+		fmt.Sprintf("Successfully opened project: %s", filepath.Base(projectPath)),
+		fmt.Sprintf("Found %d files (subfolders load when expanded)", fileCount),
+		"Use F1 to switch to file browser to view file tree",
+	}...)
+	if app.Ctx.SafeMode {
+		output = append(output, session.Styled(session.ActiveTheme.Warning, "[SAFE MODE]")+fmt.Sprintf(" %d saved breakpoints loaded, none armed", len(proj.Breakpoints)))
+	}
+	if unmatched := project.UnmatchedBreakpoints(app.Ctx.Project); len(unmatched) > 0 {
+		output = append(output, fmt.Sprintf("Warning: %d breakpoints point to missing files, see 'bp repair'", len(unmatched)))
+	}
+
+	// 符号索引（gd/gr、def/refs）在后台建立，无界面时在第一次使用时建立
+	if ui != nil {
+		session.StartSymbolIndex(ui, app.Ctx, proj)
+		output = append(output, "Indexing symbols in the background (gd/gr in the code view)")
+	}
+
+	// 检测KASLR，地址相关功能依赖该偏移
+	app.Ctx.KASLR = session.DetectKASLR(projectPath)
+	if app.Ctx.KASLR.Enabled && !app.Ctx.KASLR.Known {
+		output = append(output, fmt.Sprintf("⚠️  %s", session.DescribeKASLR(app.Ctx.KASLR)))
+		output = append(output, "   Address-based results may be wrong, see 'env' for details")
+	} else if app.Ctx.KASLR.Enabled {
+		output = append(output, session.DescribeKASLR(app.Ctx.KASLR))
+	}
+
+	// 目标架构（交叉调试时与主机不同）
+	if arch, source := session.DetectTargetArch(app.Ctx); arch != dwarf.DetectCurrentArch() {
+		output = append(output, fmt.Sprintf("Target arch: %s (%s), host is %s", arch, source, dwarf.DetectCurrentArch()))
+	}
+
+	// 检测可用的采集后端，还没选过时弹出选择窗口
+	caps := session.DetectCapabilities(app.Ctx)
+	output = append(output, session.BackendSummaryLine(caps))
+	if !proj.Settings.BackendChosen && ui != nil {
+		session.ShowBackendWizard(app.Ctx, caps)
+		output = append(output, "Choose a backend in the popup (Enter/1-6), 'backend detect' reopens it")
+	}
+	return output, nil
+}
+
+// close：关闭当前项目
+func (app *AppContext) cmdClose(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return []string{"Tip: No project opened"}, nil
+	}
+	projectName := filepath.Base(app.Ctx.Project.RootPath)
+	session.UnloadBPF(app.Ctx)
+	session.StopSnapshots(app.Ctx)
+	session.StopWatchdog(app.Ctx)
+	app.Ctx.Project = nil
+	app.Ctx.WorkingSet = nil
+	app.Ctx.ProbeChecks = nil
+	return []string{fmt.Sprintf("Success: Closed project %s", projectName)}, nil
+}
+
+// status：调试器和项目状态
+func (app *AppContext) cmdStatus(ui session.UI, cmd, args string) ([]string, error) {
+	output := []string{
+		fmt.Sprintf("Debugger status: %s", app.Ctx.CurrentFunc),
+		fmt.Sprintf("Current address: 0x%X", app.Ctx.CurrentAddr),
+	}
+	if app.Ctx.Project != nil {
+		output = append(output, fmt.Sprintf("Project: %s", filepath.Base(app.Ctx.Project.RootPath)))
+		output = append(output, fmt.Sprintf("Breakpoints: %d", len(app.Ctx.Project.Breakpoints)))
+		output = append(output, session.DescribeKASLR(app.Ctx.KASLR))
+	} else {
+		output = append(output, "Project: Not opened")
+	}
+	return output, nil
+}
+
+// env：目标环境（内核、架构、KASLR偏移）
+func (app *AppContext) cmdEnv(ui session.UI, cmd, args string) ([]string, error) {
+	session.ShowEnvironmentPopup(app.Ctx)
+	return []string{"Environment window opened"}, nil
+}
+
+// arch [name|auto]：显示或固定目标架构
+func (app *AppContext) cmdArch(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args != "" {
+		if strings.ToLower(args) == "auto" {
+			app.Ctx.Project.Settings.TargetArch = ""
+		} else if arch, ok := session.ParseArchName(args); ok {
+			app.Ctx.Project.Settings.TargetArch = arch
+		} else {
+			return nil, errcode.Errorf(errcode.ErrArch, "Unsupported architecture '%s' (x86_64/arm64/riscv64/s390x/ppc64le/mips64/auto)", args)
+		}
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			return nil, err
+		}
+	}
+	arch, source := session.DetectTargetArch(app.Ctx)
+	output := []string{
+		fmt.Sprintf("Target architecture: %s (%s)", arch, session.ArchDisplayNames[arch]),
+		fmt.Sprintf("Source: %s", source),
+	}
+	if host := dwarf.DetectCurrentArch(); host != arch {
+		output = append(output, fmt.Sprintf("Cross-debugging: host is %s", host))
+	}
+	return output, nil
+}
+
+// demo [on|off]：未接入数据后端时是否显示示例数据
+func (app *AppContext) cmdDemo(ui session.UI, cmd, args string) ([]string, error) {
+	switch strings.ToLower(args) {
+	case "on":
+		app.Ctx.DemoMode = true
+	case "off":
+		app.Ctx.DemoMode = false
+	case "":
+	default:
+		return nil, errcode.Usage("demo [on|off]")
+	}
+	if app.Ctx.DemoMode {
+		return []string{"Demo mode: on (Registers/Variables/Call Stack show SIMULATED sample data)"}, nil
+	}
+	return []string{"Demo mode: off (panels stay empty until a data backend is attached)"}, nil
+}
+
+// theme [name]：切换配色方案
+func (app *AppContext) cmdTheme(ui session.UI, cmd, args string) ([]string, error) {
+	if args == "" {
+		output := []string{fmt.Sprintf("Theme: %s", session.ActiveTheme.Name)}
+		for _, name := range session.ThemeNames() {
+			marker := " "
+			if name == session.ActiveTheme.Name {
+				marker = "*"
+			}
+			description := session.Themes[name].Description
+			if !session.ThemeAvailable(session.Themes[name]) {
+				description += " (needs a 256-color terminal)"
+			}
+			output = append(output, fmt.Sprintf(" %s %-14s %s", marker, name, description))
+		}
+		return output, nil
+	}
+	if theme, ok := session.Themes[strings.ToLower(args)]; ok && !session.ThemeAvailable(theme) {
+		return nil, errcode.WithHints(errcode.Errorf(errcode.ErrConfig, "Theme '%s' needs a 256-color terminal", theme.Name), "Hint: Start with TERM=xterm-256color or DEBUG_TUI_COLORS=256")
+	}
+	if !session.SetTheme(args) {
+		return nil, errcode.WithHints(errcode.Errorf(errcode.ErrInvalidArg, "Unknown theme '%s'", args), "Usage: theme ["+strings.Join(session.ThemeNames(), "|")+"]")
+	}
+	return []string{fmt.Sprintf("Theme: %s (saved with the session on exit)", session.ActiveTheme.Name)}, nil
+}
+
+// highlight [on|off]：代码窗口的C语法高亮
+func (app *AppContext) cmdHighlight(ui session.UI, cmd, args string) ([]string, error) {
+	switch strings.ToLower(args) {
+	case "on":
+		app.Ctx.HighlightOff = false
+	case "off":
+		app.Ctx.HighlightOff = true
+	case "":
+	default:
+		return nil, errcode.Usage("highlight [on|off]")
+	}
+	if app.Ctx.HighlightOff {
+		return []string{"Syntax highlighting: off"}, nil
+	}
+	return []string{"Syntax highlighting: on (keywords, types, strings, comments, preprocessor)"}, nil
+}
+
+// safe [off]：显示或退出安全模式
+func (app *AppContext) cmdSafe(ui session.UI, cmd, args string) ([]string, error) {
+	switch strings.ToLower(args) {
+	case "off":
+		if !app.Ctx.SafeMode {
+			return []string{"Safe mode is not active"}, nil
+		}
+		app.Ctx.SafeMode = false
+		return []string{"Safe mode: off, backends enabled", "Breakpoints are armed the next time 'vars' or 'generate' builds a program"}, nil
+	case "":
+		if app.Ctx.SafeMode {
+			return []string{"Safe mode: on (breakpoints not armed, backends disabled), 'safe off' to leave"}, nil
+		}
+		return []string{"Safe mode: off (start with --safe to enable)"}, nil
+	}
+	return nil, errcode.Usage("safe [off]")
+}
+
+// why [code|list]：错误码的排查说明
+func (app *AppContext) cmdWhy(ui session.UI, cmd, args string) ([]string, error) {
+	switch {
+	case args == "list":
+		var output []string
+		for _, code := range errcode.Codes() {
+			output = append(output, fmt.Sprintf("  %-18s %s", code, errcode.Guide[code].Summary))
+		}
+		return output, nil
+	case args == "" && app.Ctx.LastErrorCode == "":
+		return []string{"No failures yet", "Usage: why [code|list]"}, nil
+	case args == "":
+		showTroubleshootingPopup(app, app.Ctx.LastErrorCode)
+		return []string{fmt.Sprintf("Troubleshooting for %s opened", app.Ctx.LastErrorCode)}, nil
+	}
+	code, ok := errcode.Parse(args)
+	if !ok {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "Unknown error code: %s ('why list' shows all codes)", args)
+	}
+	showTroubleshootingPopup(app, code)
+	return []string{fmt.Sprintf("Troubleshooting for %s opened", code)}, nil
+}
+
+// perf / about：调试器自身的资源占用和刷新延迟
+func (app *AppContext) cmdPerf(ui session.UI, cmd, args string) ([]string, error) {
+	session.ShowPerfPopup(app.Ctx)
+	return []string{"Performance window opened (CPU, RSS, goroutines, refresh and event latency)"}, nil
+}
+
+// keys：生效的按键绑定
+func (app *AppContext) cmdKeys(ui session.UI, cmd, args string) ([]string, error) {
+	if app.KeyBindingLines == nil {
+		return nil, nil
+	}
+	return app.KeyBindingLines(), nil
+}
+
+// history：查看、保存命令历史，设置历史上限
+func (app *AppContext) cmdHistory(ui session.UI, cmd, args string) ([]string, error) {
+	const usage = "history [n|save <file>|size <lines>|age <duration|off>]"
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0 || (len(fields) == 1 && fields[0] != "size" && fields[0] != "age"):
+		count := 20
+		if len(fields) == 1 {
+			n, err := strconv.Atoi(fields[0])
+			if err != nil || n <= 0 {
+				return nil, errcode.Usage(usage)
+			}
+			count = n
+		}
+		commands := session.HistoryCommands(app.Ctx)
+		start := len(commands) - count
+		if start < 0 {
+			start = 0
+		}
+		var output []string
+		for i := start; i < len(commands); i++ {
+			output = append(output, fmt.Sprintf("%5d  %s", i+1, commands[i]))
+		}
+		limit := app.Ctx.HistoryLimit
+		if limit <= 0 {
+			limit = session.DefaultHistoryLimit
+		}
+		age := "unlimited"
+		if app.Ctx.HistoryMaxAge > 0 {
+			age = app.Ctx.HistoryMaxAge.String()
+		}
+		output = append(output, fmt.Sprintf("History: %d lines (limit %d, max age %s, %d dropped) | Ctrl+R to search", len(app.Ctx.CommandHistory), limit, age, app.Ctx.HistoryDropped))
+		return output, nil
+	case fields[0] == "save" && len(fields) >= 2:
+		path := session.HistoryPath(app.Ctx, strings.TrimSpace(strings.TrimPrefix(args, "save")))
+		n, err := session.SaveCommandHistory(app.Ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Saved %d history lines to %s", n, path)}, nil
+	case fields[0] == "size" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 100 {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid history size: %s (at least 100 lines)", fields[1])
+		}
+		app.Ctx.HistoryLimit = n
+		return []string{fmt.Sprintf("History limited to %d lines", n)}, nil
+	case fields[0] == "age" && len(fields) == 2:
+		if fields[1] == "off" {
+			app.Ctx.HistoryMaxAge = 0
+			return []string{"History kept regardless of age"}, nil
+		}
+		age, err := time.ParseDuration(fields[1])
+		if err != nil || age < time.Minute {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid history age: %s (e.g. 30m, 2h; at least 1m)", fields[1])
+		}
+		app.Ctx.HistoryMaxAge = age
+		return []string{fmt.Sprintf("History lines older than %s are dropped", age)}, nil
+	}
+	return nil, errcode.Usage(usage)
+}
+
+// source <file>：执行命令脚本
+func (app *AppContext) cmdSource(ui session.UI, cmd, args string) ([]string, error) {
+	if args == "" {
+		return nil, errcode.Usage("source <file>  (one command per line, # comments)")
+	}
+	path := scriptPath(app.Ctx, args)
+	ran, err := app.runScript(ui, path, nil)
+	if err != nil {
+		return nil, errcode.WithHints(err, fmt.Sprintf("Script stopped after %d commands", ran))
+	}
+	return []string{fmt.Sprintf("Script %s: %d commands OK", filepath.Base(path), ran)}, nil
+}
+
+// workspace：工作区列表、新建、切换和关闭
+func (app *AppContext) cmdWorkspace(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	n := 0
+	switch {
+	case len(fields) == 0 || args == "list":
+		return append([]string{"Workspaces (Alt+N or 'workspace <n>' switches):"}, app.workspaceLines()...), nil
+	case fields[0] == "new":
+		created, err := app.newWorkspace(strings.TrimSpace(strings.TrimPrefix(args, "new")))
+		if err != nil {
+			return nil, err
+		}
+		if err := app.SwitchWorkspace(ui, created); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Switched to new workspace %d", created)}, nil
+	case fields[0] == "name":
+		if len(fields) < 2 {
+			return nil, errcode.Usage("workspace name <name>")
+		}
+		app.WorkspaceList()[app.Workspace].Name = strings.Join(fields[1:], " ")
+		return []string{fmt.Sprintf("Workspace %d renamed to %s", app.Workspace+1, strings.Join(fields[1:], " "))}, nil
+	case fields[0] == "close":
+		n = app.Workspace + 1
+		if len(fields) > 1 {
+			fmt.Sscanf(fields[1], "%d", &n)
+		}
+		if err := app.closeWorkspace(ui, n); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Closed workspace %d, now in workspace %d", n, app.Workspace+1)}, nil
+	}
+	if _, err := fmt.Sscanf(fields[0], "%d", &n); err != nil {
+		return nil, errcode.Usage("workspace [n|new [name]|name <name>|close [n]]")
+	}
+	if err := app.SwitchWorkspace(ui, n); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Workspace %d: %s", n, session.WorkspaceLabel(app.WorkspaceList()[n-1]))}, nil
+}
+
+// rpc [start [socket]|stop]：JSON-RPC控制接口
+func (app *AppContext) cmdRPC(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		if app.rpc == nil {
+			return []string{"RPC: off ('rpc start [socket]' to listen)"}, nil
+		}
+		return []string{fmt.Sprintf("RPC: listening on %s (JSON-RPC 2.0, one object per line)", app.rpc.path)}, nil
+	case fields[0] == "start":
+		path := ""
+		if len(fields) > 1 {
+			path = strings.TrimSpace(strings.TrimPrefix(args, "start"))
+		}
+		if ui == nil {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "RPC needs the interactive UI")
+		}
+		path, err := app.StartRPC(ui, path)
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("RPC: listening on %s", path),
+			`  e.g. echo '{"jsonrpc":"2.0","id":1,"method":"state"}' | socat - UNIX-CONNECT:` + path}, nil
+	case fields[0] == "stop":
+		if app.rpc == nil {
+			return []string{"RPC: not running"}, nil
+		}
+		path := app.rpc.path
+		app.StopRPC()
+		return []string{fmt.Sprintf("RPC: stopped (%s removed)", path)}, nil
+	}
+	return nil, errcode.Usage("rpc [start [socket]|stop]")
+}
+
+// selftest：检查运行环境
+func (app *AppContext) cmdSelftest(ui session.UI, cmd, args string) ([]string, error) {
+	if err := startSelftest(ui, app.Ctx); err != nil {
+		return nil, err
+	}
+	return []string{"Self-test started: build/load sample module → breakpoint → BPF → attach → event round-trip"}, nil
+}
@@ -0,0 +1,384 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：源码导航、搜索和编辑 ==========
+
+// m <a-z>：在光标行设置标记
+func (app *AppContext) cmdMark(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if !session.IsValidMarkName(args) {
+		return nil, errcode.Usage("m <a-z> - set mark at the current code line")
+	}
+	file, line, ok := session.CurrentCodeLocation(ui, app.Ctx)
+	if !ok {
+		return nil, errcode.Errorf(errcode.ErrUsage, "Please open a file first")
+	}
+	if err := session.SetMark(app.Ctx, args, file, line); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Mark '%s' set at %s:%d", args, project.ProjectRelativePath(app.Ctx.Project, file), line)}, nil
+}
+
+// '<a-z>：跳转到标记（” 回到跳转前的位置）
+func (app *AppContext) cmdJumpMark(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if !session.IsValidMarkName(args) && args != session.LastJumpMark {
+		return nil, errcode.Usage("' <a-z> - jump to mark, '' - jump back")
+	}
+	mark, err := session.JumpToMark(ui, app.Ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Jumped to mark '%s' (%s:%d)", args, mark.File, mark.Line)}, nil
+}
+
+// :<line>|:<n>%|:$ / goto：跳转到行
+func (app *AppContext) cmdGoto(ui session.UI, cmd, args string) ([]string, error) {
+	if args == "" {
+		return nil, errcode.Usage(":<line> | :<percent>% | :$ - jump in the current file")
+	}
+	line, total, err := session.GotoCodeLine(ui, app.Ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s %s", project.ProjectRelativePath(app.Ctx.Project, app.Ctx.Project.CurrentFile), session.CodePosition(line, total))}, nil
+}
+
+// marks：列出标记
+func (app *AppContext) cmdMarks(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	session.ShowMarksPopup(app.Ctx)
+	return []string{fmt.Sprintf("Marks window opened (%d marks)", len(app.Ctx.Project.Settings.Marks))}, nil
+}
+
+// delmarks <a-z...>|all：删除标记
+func (app *AppContext) cmdDelMarks(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if !session.DeleteMark(app.Ctx, args) {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "mark '%s' is not set", args)
+	}
+	return []string{fmt.Sprintf("Mark '%s' deleted", args)}, nil
+}
+
+// ws：工作集
+func (app *AppContext) cmdWorkingSet(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args == "" {
+		count := session.ShowWorkingSetPopup(app.Ctx)
+		return []string{fmt.Sprintf("Working set opened (%d entries)", count)}, nil
+	}
+	n, err := strconv.Atoi(args)
+	if err != nil {
+		return nil, errcode.Usage("ws [n]")
+	}
+	entry, err := session.JumpToWorkingSet(ui, app.Ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Jumped to %s:%d", project.ProjectRelativePath(app.Ctx.Project, entry.File), entry.Line)}, nil
+}
+
+// src <file>：打开源码文件
+func (app *AppContext) cmdSrc(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args == "" {
+		return nil, errcode.Usage("src <path>[:line]")
+	}
+	path, line := session.ParseSourceLocation(args)
+	local, source, err := session.OpenDebugSource(ui, app.Ctx, path, line)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Opened %s:%d (%s)", project.ProjectRelativePath(app.Ctx.Project, local), line, source)}, nil
+}
+
+// srcmap：源码路径映射
+func (app *AppContext) cmdSrcMap(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	settings := app.Ctx.Project.Settings
+	switch {
+	case len(fields) == 0:
+		output := []string{"Source path substitutions:"}
+		for i, sub := range settings.SourceMap {
+			output = append(output, fmt.Sprintf("  %d. %s -> %s", i+1, sub.From, sub.To))
+		}
+		if len(settings.SourceMap) == 0 {
+			output = append(output, "  (none)")
+		}
+		return output, nil
+	case fields[0] == "add" && len(fields) == 3:
+		settings.SourceMap = append(settings.SourceMap, project.SourceSubstitution{From: fields[1], To: fields[2]})
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Mapped %s -> %s", fields[1], fields[2])}, nil
+	case fields[0] == "del" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > len(settings.SourceMap) {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid substitution number: %s", fields[1])
+		}
+		settings.SourceMap = append(settings.SourceMap[:n-1], settings.SourceMap[n:]...)
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Substitution %d removed", n)}, nil
+	}
+	return nil, errcode.Usage("srcmap [add <from> <to>|del <n>]")
+}
+
+// srcfetch：从远程目标取回源码
+func (app *AppContext) cmdSrcFetch(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	settings := app.Ctx.Project.Settings
+	var output []string
+	switch {
+	case len(fields) == 0:
+		cfg := settings.SourceFetch
+		switch {
+		case cfg == nil:
+			return []string{"Source fetch: off"}, nil
+		case cfg.GitTree != "":
+			return []string{fmt.Sprintf("Source fetch: git %s (ref %s)", cfg.GitTree, cfg.GitRef)}, nil
+		}
+		return []string{fmt.Sprintf("Source fetch: url %s", cfg.URL)}, nil
+	case fields[0] == "git" && (len(fields) == 2 || len(fields) == 3):
+		cfg := &project.SourceFetchConfig{GitTree: fields[1], GitRef: "HEAD"}
+		if len(fields) == 3 {
+			cfg.GitRef = fields[2]
+		}
+		settings.SourceFetch = cfg
+		output = []string{fmt.Sprintf("Missing sources will be fetched from git %s (ref %s)", cfg.GitTree, cfg.GitRef)}
+	case fields[0] == "url" && len(fields) == 2:
+		settings.SourceFetch = &project.SourceFetchConfig{URL: fields[1]}
+		output = []string{fmt.Sprintf("Missing sources will be downloaded from %s", fields[1])}
+	case fields[0] == "off":
+		settings.SourceFetch = nil
+		output = []string{"Source fetch disabled"}
+	default:
+		return nil, errcode.Usage("srcfetch [git <tree> [ref]|url <template with {path}/{ref}>|off]")
+	}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		return output, err
+	}
+	return output, nil
+}
+
+// grep <pattern>：在项目中搜索
+func (app *AppContext) cmdGrep(ui session.UI, cmd, args string) ([]string, error) {
+	term := strings.TrimSpace(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if term == "" {
+		return nil, errcode.Usage("grep <term>")
+	}
+	matches, files, truncated, err := session.ShowGrepPopup(app.Ctx, term)
+	if err != nil {
+		return nil, err
+	}
+	output := []string{fmt.Sprintf("Grep '%s': %d matches in %d files", term, matches, files)}
+	if truncated {
+		output = append(output, fmt.Sprintf("  Stopped after %d matches", session.MaxProjectMatches))
+	}
+	return output, nil
+}
+
+// def / refs <symbol>：跳转到定义、列出引用
+func (app *AppContext) cmdXref(ui session.UI, cmd, args string) ([]string, error) {
+	name := strings.TrimSpace(args)
+	if name == "" && ui != nil {
+		name = session.IdentifierAtCodeCursor(ui)
+	}
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if name == "" {
+		return nil, errcode.Usage(fmt.Sprintf("%s <name> (defaults to the identifier under the code cursor)", cmd))
+	}
+	if cmd == "def" && ui == nil {
+		// 无界面执行脚本时只列出定义
+		return session.DescribeDefinitions(app.Ctx, name)
+	}
+	var msg string
+	var err error
+	if cmd == "def" {
+		msg, err = session.GotoDefinition(ui, app.Ctx, name)
+	} else {
+		msg, err = session.ShowReferences(app.Ctx, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if msg == "" {
+		return nil, nil
+	}
+	return []string{msg}, nil
+}
+
+// replace：在项目中替换
+func (app *AppContext) cmdReplace(ui session.UI, cmd, args string) ([]string, error) {
+	pattern, replacement, ok := session.ParseReplaceArgs(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if !ok {
+		return nil, errcode.Usage("replace <pattern> <replacement>  (\"\" replaces with nothing)",
+			"       replace \"old text\" \"new text\"  |  replace /old text/new text/  (patterns with spaces)",
+			fmt.Sprintf("  Search mode: %s (Alt+R/Alt+C/Alt+W in the code view)", session.SearchModeLabel(app.Ctx.SearchOptions)))
+	}
+	matches, files, truncated, err := session.ShowReplacePopup(app.Ctx, pattern, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if matches == 0 {
+		return []string{fmt.Sprintf("Replace '%s': no matches [%s]", pattern, session.SearchModeLabel(app.Ctx.SearchOptions))}, nil
+	}
+	output := []string{fmt.Sprintf("Replace '%s' → '%s': %d matches in %d files [%s], confirm in the popup",
+		pattern, replacement, matches, files, session.SearchModeLabel(app.Ctx.SearchOptions))}
+	if truncated {
+		output = append(output, fmt.Sprintf("  Stopped after %d matches", session.MaxProjectMatches))
+	}
+	return output, nil
+}
+
+// symbols [pattern]：模块符号
+func (app *AppContext) cmdSymbols(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	shown, total, err := session.ShowSymbolsPopup(app.Ctx, strings.TrimSpace(args))
+	if err != nil {
+		return nil, err
+	}
+	if args != "" {
+		return []string{fmt.Sprintf("Symbols matching '%s': %d of %d", strings.TrimSpace(args), shown, total)}, nil
+	}
+	return []string{fmt.Sprintf("Module symbols: %d", total)}, nil
+}
+
+// fmt：值的显示格式
+func (app *AppContext) cmdFormat(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if len(fields) == 0 {
+		output := []string{"Value display formats:"}
+		for name, vf := range app.Ctx.Project.Settings.ValueFormats {
+			if vf.Enum != "" {
+				output = append(output, fmt.Sprintf("  %-16s %s (%s)", name, vf.Format, vf.Enum))
+			} else {
+				output = append(output, fmt.Sprintf("  %-16s %s", name, vf.Format))
+			}
+		}
+		if len(app.Ctx.Project.Settings.ValueFormats) == 0 {
+			output = append(output, "  (all decimal)")
+		}
+		return output, nil
+	}
+	name := fields[0]
+	vf := session.ValueFormatFor(app.Ctx, name)
+	var err error
+	switch {
+	case len(fields) == 1:
+		vf, err = session.CycleValueFormat(app.Ctx, name)
+	case fields[1] == "enum":
+		if len(fields) > 2 {
+			vf.Enum = fields[2]
+		}
+		if vf.Enum == "" {
+			return nil, errcode.Usage(fmt.Sprintf("fmt %s enum <EnumName>", name))
+		}
+		if session.EnumConstants(app.Ctx, vf.Enum) == nil {
+			return nil, errcode.Errorf(errcode.ErrNotFound, "项目源码中未找到枚举: %s", vf.Enum)
+		}
+		vf.Format = "enum"
+		err = session.SetValueFormat(app.Ctx, name, vf)
+	case fields[1] == "dec" || fields[1] == "hex" || fields[1] == "bin":
+		vf.Format = fields[1]
+		err = session.SetValueFormat(app.Ctx, name, vf)
+	default:
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "未知格式: %s (dec/hex/bin/enum)", fields[1])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s: displayed as %s", name, vf.Format)}, nil
+}
+
+// outline：当前文件的函数大纲
+func (app *AppContext) cmdOutline(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	n, err := session.ShowOutlinePopup(ui, app.Ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("%s: %d functions (Ctrl+O)", project.ProjectRelativePath(app.Ctx.Project, app.Ctx.Project.CurrentFile), n)}, nil
+}
+
+// tab：代码窗口标签
+func (app *AppContext) cmdTabs(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	tabs := app.Ctx.Project.Tabs
+	switch {
+	case len(fields) == 0:
+		if len(tabs) == 0 {
+			return []string{"No open files"}, nil
+		}
+		session.SaveFileViewState(app.Ctx)
+		session.ShowBuffersPopup(app.Ctx)
+		return []string{fmt.Sprintf("%d open files (Ctrl+B)", len(tabs))}, nil
+	case fields[0] == "close":
+		path := app.Ctx.Project.CurrentFile
+		if len(fields) > 1 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 || n > len(tabs) {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "Invalid tab number: %s (1-%d)", fields[1], len(tabs))
+			}
+			path = tabs[n-1]
+		}
+		if path == "" {
+			return nil, errcode.Errorf(errcode.ErrUsage, "No open file")
+		}
+		session.CloseCodeTab(app.Ctx, path)
+		return []string{fmt.Sprintf("Closed %s", project.ProjectRelativePath(app.Ctx.Project, path))}, nil
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 || n > len(tabs) {
+		return nil, errcode.Usage("tab [<n>|close [n]]")
+	}
+	session.SwitchCodeFile(app.Ctx, tabs[n-1])
+	return []string{fmt.Sprintf("Switched to %s", project.ProjectRelativePath(app.Ctx.Project, tabs[n-1]))}, nil
+}
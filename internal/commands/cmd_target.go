@@ -0,0 +1,405 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令：目标控制（后端、gdb、远程、内存） ==========
+
+// backend [name|detect]：选择数据采集后端
+func (app *AppContext) cmdBackend(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if args == "detect" {
+		caps := session.DetectCapabilities(app.Ctx)
+		session.ShowBackendWizard(app.Ctx, caps)
+		return append(session.CapabilityLines(caps), session.BackendSummaryLine(caps)), nil
+	}
+	if args != "" {
+		fields := strings.Fields(args)
+		name := strings.ToLower(fields[0])
+		if name == "stap" {
+			name = session.BackendSystemtap
+		}
+		if !codegen.ValidBackend(name) && !session.StopModeBackend(name) {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "Unknown backend '%s' (bpf/ftrace/kprobe/systemtap/bpftrace/perf/gdb/kdb)", args)
+		}
+		baud := 0
+		if name == session.BackendKDB {
+			if len(fields) < 2 && app.Ctx.Project.Settings.KDB == "" {
+				return nil, errcode.Usage("backend kdb <tty> [baud]")
+			}
+			if len(fields) > 2 {
+				var err error
+				if baud, err = strconv.Atoi(fields[2]); err != nil || baud <= 0 {
+					return nil, errcode.Errorf(errcode.ErrInvalidArg, "Invalid baud rate '%s'", fields[2])
+				}
+			}
+		}
+		if app.Ctx.EventSource != nil {
+			return nil, errcode.Errorf(errcode.ErrTarget, "Event capture is running, 'events stop' before switching backends")
+		}
+		if app.Ctx.GDB != nil && app.Ctx.GDB.Busy != "" {
+			return nil, errcode.Errorf(errcode.ErrTarget, "The target is running, 'interrupt' before switching backends")
+		}
+		// 切换后端或目标地址时断开旧连接
+		session.DisconnectGDB(app.Ctx)
+		app.Ctx.Project.Settings.Backend = name
+		if name == session.BackendBPF {
+			app.Ctx.Project.Settings.Backend = ""
+		}
+		if name == session.BackendGDB && len(fields) > 1 {
+			app.Ctx.Project.Settings.GDB = fields[1]
+		}
+		if name == session.BackendKDB && len(fields) > 1 {
+			app.Ctx.Project.Settings.KDB = fields[1]
+			app.Ctx.Project.Settings.KDBBaud = baud
+		}
+		app.Ctx.Project.Settings.BackendChosen = true
+		if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+			return nil, err
+		}
+		if session.StopModeBackend(name) {
+			// 立即连接，显示目标停在哪里
+			output := []string{"Backend: " + name}
+			lines, err := session.RefreshGDBStop(ui, app.Ctx)
+			if err != nil {
+				return output, err
+			}
+			return append(append(output, lines...), "  break/continue/step/next/stepi drive the target, Registers and 'mem read' read from it"), nil
+		}
+	}
+	output := []string{fmt.Sprintf("Backend: %s", session.CurrentBackend(app.Ctx))}
+	switch session.CurrentBackend(app.Ctx) {
+	case session.BackendFtrace:
+		output = append(output, "  'events start' traces the breakpointed functions with function_graph (no clang/bpftool needed)",
+			"  Entering a breakpointed function is a hit, the call chain fills the Call Stack window")
+	case session.BackendSystemtap:
+		output = append(output, "  'events start' writes debug_breakpoints.stp and runs stap (line-level probes)")
+	case session.BackendKprobe:
+		output = append(output, "  'events start' creates kprobe_events probes (variables fetched from DWARF locations)")
+	case session.BackendBpftrace:
+		output = append(output, "  'events start' writes "+codegen.BpftraceScriptFile+" and runs bpftrace (no clang/bpftool needed)")
+	case session.BackendPerf:
+		output = append(output, "  'events start' adds probes with 'perf probe -m <module.ko>' (perf fetches variables itself) and runs perf record")
+	case session.BackendGDB, session.BackendKDB:
+		output = append(output, session.GDBStatusLines(app.Ctx)...)
+	default:
+		output = append(output, "  'vars'/'generate', 'compile' and 'bpf load', then 'events start'")
+	}
+	return output, nil
+}
+
+// step / next / stepi：单步执行（gdb后端）
+func (app *AppContext) cmdStep(ui session.UI, cmd, args string) ([]string, error) {
+	mode, title := session.GDBStepInto, "step"
+	switch cmd {
+	case "next", "n":
+		mode, title = session.GDBStepOver, "next"
+	case "stepi", "si":
+		mode, title = session.GDBStepInstruction, "stepi"
+	}
+	return session.StartGDBRun(ui, app.Ctx, title, func(s *session.GDBSession) (*session.GDBStop, error) { return s.Step(mode) })
+}
+
+// continue：继续运行（gdb后端）
+func (app *AppContext) cmdContinue(ui session.UI, cmd, args string) ([]string, error) {
+	output, err := session.StartGDBRun(ui, app.Ctx, "continue", func(s *session.GDBSession) (*session.GDBStop, error) { return s.Resume() })
+	if err != nil {
+		return nil, err
+	}
+	if ui != nil {
+		output = append(output, "Use 'interrupt' to stop the target")
+	}
+	return output, nil
+}
+
+// interrupt：停下目标（gdb后端）
+func (app *AppContext) cmdInterrupt(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.GDB == nil || app.Ctx.GDB.Busy == "" {
+		return nil, errcode.Errorf(errcode.ErrTarget, "The target is not running")
+	}
+	if err := app.Ctx.GDB.RequestStop(); err != nil {
+		return nil, err
+	}
+	return []string{app.Ctx.GDB.Tag() + " Interrupt sent"}, nil
+}
+
+// break：目标上的断点（gdb/kdb后端）
+func (app *AppContext) cmdBreak(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		s, err := session.ActiveGDB(app.Ctx)
+		if err != nil {
+			return nil, err
+		}
+		return session.GDBBreakpointLines(s), nil
+	case (fields[0] == "delete" || fields[0] == "del") && len(fields) == 2:
+		n, err := session.DeleteGDBBreakpoints(app.Ctx, fields[1])
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Deleted %d breakpoints", n)}, nil
+	case len(fields) == 1:
+		bp, err := session.AddGDBBreakpoint(app.Ctx, fields[0])
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Breakpoint %d at 0x%x (%s)", len(app.Ctx.GDB.Breakpoints), bp.Addr, bp.Spec)}, nil
+	}
+	return nil, errcode.Usage("break [<file:line|symbol|0xaddr> | delete <n|all>]")
+}
+
+// remote：远程目标板
+func (app *AppContext) cmdRemote(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	settings := app.Ctx.Project.Settings
+	if len(fields) == 0 {
+		return remoteTargetLines(settings.Remote), nil
+	}
+	if fields[0] == "sync" && len(fields) == 1 && settings.Remote != nil {
+		return session.StartRemoteSync(ui, app.Ctx)
+	}
+	var output []string
+	switch {
+	case fields[0] == "ssh" && len(fields) == 2:
+		if settings.Remote == nil {
+			settings.Remote = &project.RemoteTarget{}
+		}
+		settings.Remote.SSH = fields[1]
+		output = []string{fmt.Sprintf("Remote target: %s ('events start' reads its trace_pipe over ssh)", fields[1])}
+	case fields[0] != "ssh" && fields[0] != "off" && settings.Remote == nil:
+		return nil, errcode.Errorf(errcode.ErrConfig, "No remote target, use 'remote ssh <user@host>' first")
+	case fields[0] == "user" && len(fields) == 2:
+		settings.Remote.User = fields[1]
+		output = []string{fmt.Sprintf("Remote target: %s", settings.Remote.Dest())}
+	case fields[0] == "key" && len(fields) == 2:
+		settings.Remote.Key = fields[1]
+		if fields[1] == "none" {
+			settings.Remote.Key = ""
+		}
+		output = []string{fmt.Sprintf("SSH key: %s", fields[1])}
+	case fields[0] == "port" && len(fields) == 2:
+		port, err := strconv.Atoi(fields[1])
+		if err != nil || port < 0 || port > 65535 {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid port: %s", fields[1])
+		}
+		settings.Remote.Port = port
+		output = []string{fmt.Sprintf("SSH port: %d", port)}
+	case fields[0] == "dir" && len(fields) == 2:
+		settings.Remote.Dir = fields[1]
+		output = []string{fmt.Sprintf("Artifacts are copied to %s:%s", settings.Remote.Dest(), settings.Remote.Directory())}
+	case fields[0] == "build" && len(fields) == 2 && (fields[1] == "host" || fields[1] == "target"):
+		settings.Remote.BuildOnTarget = fields[1] == "target"
+		output = []string{fmt.Sprintf("'compile' runs clang on the %s", fields[1])}
+	case fields[0] == "ping":
+		settings.Remote.PingCommand = strings.TrimSpace(strings.TrimPrefix(args, "ping"))
+		if settings.Remote.PingCommand == "" {
+			output = []string{"Watchdog check: ssh " + settings.Remote.SSH + " cat /proc/uptime"}
+		} else {
+			output = []string{"Watchdog check: " + settings.Remote.PingCommand}
+		}
+	case fields[0] == "attach":
+		settings.Remote.AttachCommand = strings.TrimSpace(strings.TrimPrefix(args, "attach"))
+		output = []string{"Re-attach command after reboot: " + settings.Remote.AttachCommand}
+	case fields[0] == "off" && len(fields) == 1:
+		session.StopWatchdog(app.Ctx)
+		settings.Remote = nil
+		output = []string{"Remote target removed, events are read from the local trace_pipe"}
+	default:
+		return nil, errcode.Usage("remote [ssh <user@host>|user <name>|key <path>|port <n>|dir <path>|build host|target|sync|ping <command>|attach <command>|off]")
+	}
+	if err := project.SaveProjectSettings(app.Ctx.Project); err != nil {
+		output = append(output, fmt.Sprintf("Warning: Failed to save project settings: %v", err))
+	}
+	return output, nil
+}
+
+// 远程目标的配置（remote 不带参数）
+func remoteTargetLines(remote *project.RemoteTarget) []string {
+	if remote == nil {
+		return []string{"Remote target: none (events are read from the local trace_pipe)", "Usage: remote [ssh <user@host>|user|key|port|dir|build host|target|sync|ping|attach|off]"}
+	}
+	build := "host"
+	if remote.BuildOnTarget {
+		build = "target"
+	}
+	output := []string{fmt.Sprintf("Remote target: %s (events read over ssh)", remote.Dest())}
+	if remote.Key != "" || remote.Port > 0 {
+		output = append(output, fmt.Sprintf("  key: %s  port: %d", remote.Key, remote.Port))
+	}
+	output = append(output, fmt.Sprintf("  dir: %s  compile on: %s  ('bpf load' runs the load script there)", remote.Directory(), build))
+	if remote.PingCommand != "" {
+		output = append(output, "  ping:   "+remote.PingCommand)
+	}
+	if remote.AttachCommand != "" {
+		output = append(output, "  attach: "+remote.AttachCommand)
+	}
+	return output
+}
+
+// watchdog：远程目标看门狗
+func (app *AppContext) cmdWatchdog(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		session.ShowWatchdogPopup(app.Ctx)
+		return []string{"Watchdog window opened"}, nil
+	case fields[0] == "on" && len(fields) <= 2:
+		interval := 2
+		if len(fields) == 2 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid interval: %s", fields[1])
+			}
+			interval = n
+		}
+		if err := session.StartWatchdog(ui, app.Ctx, time.Duration(interval)*time.Second); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Watchdog: checking %s every %ds (hang after %d missed checks)", session.RemoteTarget(app.Ctx).SSH, interval, session.WatchdogHangMisses)}, nil
+	case fields[0] == "off":
+		if session.StopWatchdog(app.Ctx) {
+			return []string{"Watchdog stopped"}, nil
+		}
+		return []string{"Watchdog is not running"}, nil
+	}
+	return nil, errcode.Usage("watchdog [on [seconds]|off]")
+}
+
+// mem read|write|close：内存窗口
+func (app *AppContext) cmdMemory(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	sub := ""
+	if len(fields) > 0 {
+		sub = fields[0]
+	}
+	switch {
+	case sub == "read" && len(fields) == 3:
+		length, err := strconv.ParseInt(fields[2], 0, 32)
+		if err != nil {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "invalid length '%s'", fields[2])
+		}
+		dump, err := session.MemoryRead(app.Ctx, fields[1], int(length))
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Read %d bytes at 0x%x via %s (Memory window, Tab to focus)", len(dump.Data), dump.Addr, dump.Source)}, nil
+	case sub == "width" && len(fields) == 2:
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, errcode.Usage("mem width <bytes per line>")
+		}
+		return []string{fmt.Sprintf("Memory width: %d bytes per line", session.SetMemoryWidth(app.Ctx, n))}, nil
+	case sub == "refresh":
+		if app.Ctx.Memory == nil {
+			return nil, errcode.Errorf(errcode.ErrUsage, "nothing to refresh, use 'mem read <addr> <len>' first")
+		}
+		dump, err := session.MemoryRead(app.Ctx, fmt.Sprintf("0x%x", app.Ctx.Memory.Addr), len(app.Ctx.Memory.Data))
+		if err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("Re-read %d bytes at 0x%x via %s", len(dump.Data), dump.Addr, dump.Source)}, nil
+	case sub == "close":
+		app.Ctx.Memory = nil
+		return []string{"Memory window closed"}, nil
+	}
+	return nil, errcode.Usage("mem read <addr|symbol[+off]> <len> | mem width <n> | mem refresh | mem close")
+}
+
+// frame <n>：切换到调用栈的一帧
+func (app *AppContext) cmdFrame(ui session.UI, cmd, args string) ([]string, error) {
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil {
+		return nil, errcode.Usage("frame <n>")
+	}
+	frame, where, err := session.JumpToFrame(ui, app.Ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("#%d %s() -> %s:%d", n, frame.Function, where, frame.Line)}, nil
+}
+
+// callgraph <func>：调用图
+func (app *AppContext) cmdCallGraph(ui session.UI, cmd, args string) ([]string, error) {
+	fields := strings.Fields(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	function := ""
+	depth := session.DefaultCallGraphDepth
+	if len(fields) > 0 {
+		function = fields[0]
+	} else if file, line, ok := session.CurrentCodeLocation(ui, app.Ctx); ok {
+		function = project.ParseFunctionName(file, line)
+	}
+	if len(fields) > 1 {
+		if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+			depth = n
+		}
+	}
+	if function == "" {
+		return nil, errcode.Usage("callgraph <function> [depth] (defaults to the function at the code cursor)")
+	}
+	count, backend, err := session.ShowCallGraphPopup(app.Ctx, function, depth)
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("Call graph of %s: %d nodes, depth %d (%s)", function, count, depth, backend)}, nil
+}
+
+// disasm [func|off]：反汇编
+func (app *AppContext) cmdDisasm(ui session.UI, cmd, args string) ([]string, error) {
+	function := strings.TrimSpace(args)
+	if app.Ctx.Project == nil {
+		return nil, errcode.NoProject
+	}
+	if function == "off" {
+		if app.Ctx.Disasm != nil {
+			app.Ctx.CodeScroll = app.Ctx.Disasm.SavedScroll
+			app.Ctx.Disasm = nil
+		}
+		return []string{"Code view shows source"}, nil
+	}
+	if function == "" {
+		file, line, _ := session.CurrentCodeLocation(ui, app.Ctx)
+		function = session.DefaultDisasmFunction(app.Ctx, file, line)
+	}
+	if function == "" {
+		return nil, errcode.Usage("disasm <function> (defaults to the last hit breakpoint or the function at the code cursor)")
+	}
+	d, err := session.DisassembleFunction(app.Ctx, function)
+	if err != nil {
+		return nil, err
+	}
+	d.SavedScroll = app.Ctx.CodeScroll
+	if app.Ctx.Disasm != nil {
+		d.SavedScroll = app.Ctx.Disasm.SavedScroll
+	}
+	app.Ctx.Disasm = d
+	app.Ctx.CodeScroll = 0
+	if d.ProbeLine > 3 {
+		app.Ctx.CodeScroll = d.ProbeLine - 3
+	}
+	output := []string{fmt.Sprintf("Disassembly of %s (%s), 'disasm off' returns to source", function, d.Tool)}
+	if d.ProbeLine < 0 {
+		output = append(output, "No enabled breakpoint probes in this function")
+	}
+	return output, nil
+}
@@ -0,0 +1,348 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// 执行命令窗口中输入的命令，命令和输出写入历史，返回命令失败的原因
+func (app *AppContext) SubmitInput(ui session.UI) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	// 反向搜索中按Enter：执行匹配到的命令
+	if app.Ctx.HistorySearch {
+		session.EndHistorySearch(app.Ctx, true)
+	}
+	
+	session.ResetHistoryBrowse(app.Ctx)
+
+	// 获取当前输入的命令
+	command := strings.TrimSpace(app.Ctx.CurrentInput)
+	
+	// 如果命令为空，只是换行
+	if command == "" {
+		// 添加空行到历史记录
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, ">")
+		app.Ctx.CurrentInput = ""
+		// 标记需要重绘
+		app.Ctx.CommandDirty = true
+		return nil
+	}
+	
+	// 调试信息：记录截断检测
+	if len(command) > 40 && strings.Contains(command, "linux-6.") {
+		debugInfo := fmt.Sprintf("[DEBUG] Path command length=%d: %s", len(command), command)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugInfo)
+	}
+	
+	// 将命令添加到历史记录
+	app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("> %s", command))
+	
+	// 执行命令并将输出添加到历史记录
+	output, err := app.runCommand(ui, command)
+	app.appendCommandOutput(output, err)
+	
+	// 清空当前输入，准备下一条命令
+	app.Ctx.CurrentInput = ""
+	
+	return err
+}
+
+// 把命令的输出和错误写入命令窗口（命令可能切换了工作区，写入当前工作区）
+func (app *AppContext) appendCommandOutput(output []string, err error) {
+	app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, output...)
+	if err != nil {
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, errcode.Format(err)...)
+	}
+	// 标记需要重绘
+	app.Ctx.CommandDirty = true
+}
+
+// 命令处理函数：cmd是输入的命令名（同一处理函数的别名行为不同时使用），args是命令名之后的参数。
+// 返回给人看的输出行；失败时返回error（带错误码，可以带提示行），输出行是失败前已经产生的内容
+type commandFunc func(app *AppContext, ui session.UI, cmd, args string) ([]string, error)
+
+// 命令表中的一条命令
+type commandSpec struct {
+	names     []string               // 命令名和别名
+	run       commandFunc
+	journaled func(args string) bool // 是否记入操作日志供 ops replay 使用（为nil表示不记录）
+	needsUI   func(args string) bool // 无界面（--script）时不能执行（为nil表示都能执行）
+}
+
+// 按命令名和别名索引的命令表。处理函数（source、ops replay）会间接执行命令，所以在init中建立
+var commandTable map[string]*commandSpec
+
+func always(string) bool { return true }
+
+// 有子命令时才执行的判断：子命令是subs之一（subs为空表示任意子命令）
+func withSub(subs ...string) func(string) bool {
+	return func(args string) bool {
+		fields := strings.Fields(args)
+		if len(fields) == 0 {
+			return false
+		}
+		if len(subs) == 0 {
+			return true
+		}
+		for _, sub := range subs {
+			if fields[0] == sub {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// 子命令不是subs之一（没有子命令时也算）
+func exceptSub(subs ...string) func(string) bool {
+	match := withSub(subs...)
+	return func(args string) bool { return !match(args) }
+}
+
+func init() {
+	commands := []*commandSpec{
+		// 项目与界面（cmd_project.go）
+		{names: []string{"help", "h"}, run: (*AppContext).cmdHelp},
+		{names: []string{"clear"}, run: (*AppContext).cmdClear},
+		{names: []string{"pwd"}, run: (*AppContext).cmdPwd},
+		{names: []string{"open"}, run: (*AppContext).cmdOpen},
+		{names: []string{"close"}, run: (*AppContext).cmdClose},
+		{names: []string{"status"}, run: (*AppContext).cmdStatus},
+		{names: []string{"env"}, run: (*AppContext).cmdEnv},
+		{names: []string{"arch"}, run: (*AppContext).cmdArch},
+		{names: []string{"demo"}, run: (*AppContext).cmdDemo},
+		{names: []string{"theme"}, run: (*AppContext).cmdTheme},
+		{names: []string{"highlight"}, run: (*AppContext).cmdHighlight},
+		{names: []string{"safe"}, run: (*AppContext).cmdSafe},
+		{names: []string{"why"}, run: (*AppContext).cmdWhy},
+		{names: []string{"perf", "about"}, run: (*AppContext).cmdPerf},
+		{names: []string{"keys"}, run: (*AppContext).cmdKeys},
+		{names: []string{"history"}, run: (*AppContext).cmdHistory},
+		{names: []string{"source", "."}, run: (*AppContext).cmdSource},
+		{names: []string{"workspace", "wsp"}, run: (*AppContext).cmdWorkspace, needsUI: always},
+		{names: []string{"rpc"}, run: (*AppContext).cmdRPC, needsUI: always},
+		{names: []string{"selftest"}, run: (*AppContext).cmdSelftest, needsUI: always},
+
+		// 代码生成和编译（cmd_codegen.go）
+		{names: []string{"generate", "g"}, run: (*AppContext).cmdGenerate, journaled: always},
+		{names: []string{"vars"}, run: (*AppContext).cmdVars, journaled: always},
+		{names: []string{"compile"}, run: (*AppContext).cmdCompile, journaled: always},
+		{names: []string{"toolchain", "tc"}, run: (*AppContext).cmdToolchain},
+		{names: []string{"make"}, run: (*AppContext).cmdMake, needsUI: exceptSub("info")},
+		{names: []string{"bpf"}, run: (*AppContext).cmdBPF, needsUI: withSub("load")},
+		{names: []string{"stap"}, run: (*AppContext).cmdStap, needsUI: withSub("run")},
+		{names: []string{"bpftrace"}, run: (*AppContext).cmdBpftrace, needsUI: withSub("run")},
+		{names: []string{"debuginfo"}, run: (*AppContext).cmdDebugInfo},
+		{names: []string{"modinfo"}, run: (*AppContext).cmdModinfo},
+		{names: []string{"diagnose"}, run: (*AppContext).cmdDiagnose},
+
+		// 断点、监视和断言（cmd_breakpoints.go）
+		// open 和 bp toggle 分别在成功打开项目和 session.AddBreakpoint 中记录，避免重复
+		{names: []string{"bp"}, run: (*AppContext).cmdBreakpoint, journaled: session.IsJournaledBreakpointCommand},
+		{names: []string{"watch"}, run: (*AppContext).cmdWatch, journaled: withSub()},
+		{names: []string{"unwatch"}, run: (*AppContext).cmdUnwatch, journaled: always},
+		{names: []string{"filter"}, run: (*AppContext).cmdFilter},
+		{names: []string{"assert"}, run: (*AppContext).cmdAssert},
+		{names: []string{"hwbp"}, run: (*AppContext).cmdHWBreakpoint, needsUI: exceptSub("del")},
+		{names: []string{"ops"}, run: (*AppContext).cmdOps},
+
+		// 事件采集和分析（cmd_events.go）
+		{names: []string{"events", "ev"}, run: (*AppContext).cmdEvents, needsUI: withSub("start")},
+		{names: []string{"dmesg", "kmsg"}, run: (*AppContext).cmdDmesg, needsUI: withSub("start")},
+		{names: []string{"timeline"}, run: (*AppContext).cmdTimeline},
+		{names: []string{"record"}, run: (*AppContext).cmdRecord, needsUI: withSub("start")},
+		{names: []string{"replay"}, run: (*AppContext).cmdReplay},
+		{names: []string{"import-trace"}, run: (*AppContext).cmdImportTrace},
+		{names: []string{"diff-frames"}, run: (*AppContext).cmdDiffFrames},
+		{names: []string{"export"}, run: (*AppContext).cmdExport},
+		{names: []string{"stats"}, run: (*AppContext).cmdStats},
+		{names: []string{"span"}, run: (*AppContext).cmdSpan},
+		{names: []string{"locks"}, run: (*AppContext).cmdLocks},
+		{names: []string{"snapshot", "snap"}, run: (*AppContext).cmdSnapshot, needsUI: always},
+
+		// 目标控制（cmd_target.go）
+		{names: []string{"backend"}, run: (*AppContext).cmdBackend},
+		{names: []string{"step", "s", "next", "n", "stepi", "si"}, run: (*AppContext).cmdStep},
+		{names: []string{"continue", "cont", "c"}, run: (*AppContext).cmdContinue},
+		{names: []string{"interrupt"}, run: (*AppContext).cmdInterrupt},
+		{names: []string{"break", "b"}, run: (*AppContext).cmdBreak},
+		{names: []string{"remote"}, run: (*AppContext).cmdRemote},
+		{names: []string{"watchdog", "wd"}, run: (*AppContext).cmdWatchdog, needsUI: always},
+		{names: []string{"mem", "memory"}, run: (*AppContext).cmdMemory},
+		{names: []string{"frame", "f"}, run: (*AppContext).cmdFrame, needsUI: always},
+		{names: []string{"callgraph", "cg"}, run: (*AppContext).cmdCallGraph, needsUI: always},
+		{names: []string{"disasm", "asm"}, run: (*AppContext).cmdDisasm, needsUI: always},
+
+		// 源码导航（cmd_source.go）
+		{names: []string{"m", "mark"}, run: (*AppContext).cmdMark, needsUI: always},
+		{names: []string{"'"}, run: (*AppContext).cmdJumpMark, needsUI: always},
+		{names: []string{":", "goto"}, run: (*AppContext).cmdGoto},
+		{names: []string{"marks"}, run: (*AppContext).cmdMarks},
+		{names: []string{"delmarks"}, run: (*AppContext).cmdDelMarks},
+		{names: []string{"ws", "workset"}, run: (*AppContext).cmdWorkingSet, needsUI: always},
+		{names: []string{"src"}, run: (*AppContext).cmdSrc, needsUI: always},
+		{names: []string{"srcmap"}, run: (*AppContext).cmdSrcMap},
+		{names: []string{"srcfetch"}, run: (*AppContext).cmdSrcFetch},
+		{names: []string{"grep"}, run: (*AppContext).cmdGrep},
+		{names: []string{"def", "refs"}, run: (*AppContext).cmdXref},
+		{names: []string{"replace"}, run: (*AppContext).cmdReplace},
+		{names: []string{"symbols", "syms"}, run: (*AppContext).cmdSymbols},
+		{names: []string{"fmt", "format"}, run: (*AppContext).cmdFormat},
+		{names: []string{"outline"}, run: (*AppContext).cmdOutline},
+		{names: []string{"tab", "tabs", "buffers"}, run: (*AppContext).cmdTabs},
+	}
+	commandTable = make(map[string]*commandSpec)
+	for _, spec := range commands {
+		for _, name := range spec.names {
+			commandTable[name] = spec
+		}
+	}
+}
+
+// 拆分命令行：命令名和参数（参数保留内部空格）
+func parseCommandLine(command string) (cmd, args string) {
+	cmd = command
+	if spaceIndex := strings.Index(command, " "); spaceIndex >= 0 {
+		cmd = command[:spaceIndex]
+		args = strings.TrimSpace(command[spaceIndex+1:])
+	}
+
+	// 标记跳转支持 'a 和 '' 的紧凑写法
+	if strings.HasPrefix(cmd, "'") && len(cmd) > 1 {
+		args = cmd[1:]
+		cmd = "'"
+	}
+
+	// 跳转行号 :1234、百分比位置 :50% 和文件末尾 :$
+	if strings.HasPrefix(cmd, ":") && len(cmd) > 1 {
+		args = cmd[1:]
+		cmd = ":"
+	}
+	return cmd, args
+}
+
+// 执行一条命令（命令窗口、脚本和RPC共用）：返回输出行，失败时同时返回错误。
+// 界面把错误显示为带错误码的 Error: 行，RPC作为JSON-RPC错误返回。
+// g为nil时表示没有界面（--script、gen），依赖窗口的命令不能执行
+func (app *AppContext) runCommand(ui session.UI, command string) ([]string, error) {
+	cmd, args := parseCommandLine(command)
+	spec := commandTable[cmd]
+	if spec == nil {
+		return nil, errcode.WithHints(errcode.Errorf(errcode.ErrUsage, "%s: command not found", cmd), "Type 'help' to see available commands")
+	}
+
+	output, err := spec.run(app, ui, cmd, args)
+	if err != nil {
+		// why 命令默认显示最近一次失败的排查步骤
+		app.Ctx.LastErrorCode = errcode.Of(err)
+		return output, err
+	}
+
+	// 记录会改变项目状态的命令，供 ops replay 使用
+	if spec.journaled != nil && spec.journaled(args) {
+		session.RecordOperation(app.Ctx, command)
+	}
+
+	return output, nil
+}
+
+// 辅助函数：计算文件树中的文件数量
+func countFiles(node *project.FileNode) int {
+	if node == nil {
+		return 0
+	}
+	
+	count := 0
+	if !node.IsDir {
+		count = 1
+	}
+	
+	for _, child := range node.Children {
+		count += countFiles(child)
+	}
+	
+	return count
+}
+
+// 显示断点查看弹出窗口
+func showBreakpointsPopup(ctx *session.DebuggerContext) {
+	if ctx == nil || ctx.Project == nil {
+		return
+	}
+	
+	var content []string
+	
+	if len(ctx.Project.Breakpoints) == 0 {
+		content = []string{
+			"No breakpoints set currently",
+			"",
+			"Usage:",
+			"• Double-click code line in code view to set breakpoint",
+			"• Press Enter key also sets breakpoint",
+			"• Click same line again to toggle breakpoint enable/disable status",
+		}
+	} else {
+		content = append(content, fmt.Sprintf("Total %d breakpoints:", len(ctx.Project.Breakpoints)))
+		content = append(content, "")
+		content = append(content, "Status | File | Line | Function")
+		content = append(content, "------ | ---- | ---- | --------")
+		
+		for i, bp := range ctx.Project.Breakpoints {
+			status := "✓ Enabled"
+			if !bp.Enabled {
+				status = "✗ Disabled"
+			}
+			
+			fileName := filepath.Base(bp.File)
+			function := codegen.BreakpointTarget(bp)
+			if bp.Function == "unknown" {
+				function = "-"
+			}
+			
+			line := fmt.Sprintf("%2d.  %s | %s | %d | %s", 
+				i+1, status, fileName, bp.Line, function)
+			content = append(content, line)
+			if bp.RetVal {
+				content[len(content)-1] += " | ↩ retval"
+			}
+			if bp.Condition != "" {
+				content[len(content)-1] += " | if " + bp.Condition
+			}
+			if bp.Note != "" {
+				content = append(content, "      "+session.Styled(session.ActiveTheme.Note, "✎ "+bp.Note))
+			}
+		}
+		
+		content = append(content, "")
+		content = append(content, "Operations:")
+		content = append(content, "• Breakpoints auto-saved to .debug_breakpoints.json")
+		content = append(content, "• Auto-load breakpoints when reopening project")
+		content = append(content, "• Use 'generate' command to create BPF debug code")
+		content = append(content, "• Use 'bp note <n> \"text\"' to annotate a breakpoint")
+		content = append(content, "")
+		content = append(content, "🔥 Close window: Press q key or click outside window border")
+	}
+	
+	// 计算合适的窗口大小
+	width := 80
+	height := len(content) + 5 // 内容 + 边框 + 提示行
+	if height > 20 {
+		height = 20 // 最大高度
+	}
+	if height < 8 {
+		height = 8 // 最小高度
+	}
+	
+	// 创建弹出窗口
+	popup := session.CreatePopupWindow(ctx, "breakpoints", "Breakpoint Viewer", width, height, content)
+	session.ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/session"
+)
+
+// ========== 结构化错误码 ==========
+
+// 显示错误码排查窗口，Enter执行光标所在的诊断命令
+func showTroubleshootingPopup(app *AppContext, code errcode.Code) {
+	guide := errcode.Guide[code]
+	content := []string{fmt.Sprintf("\x1b[1m%s\x1b[0m  %s", code, guide.Summary), "", "Possible causes:"}
+	for _, cause := range guide.Causes {
+		content = append(content, "  • "+cause)
+	}
+	if len(guide.Checks) > 0 {
+		content = append(content, "", "Checks to run in a shell:")
+		for _, check := range guide.Checks {
+			content = append(content, "  \x1b[36m$ "+check+"\x1b[0m")
+		}
+	}
+	content = append(content, "", "Related checks (Enter runs the command):")
+	related := len(content)
+	for _, command := range guide.Related {
+		content = append(content, "  \x1b[33m> "+command+"\x1b[0m")
+	}
+
+	session.ClosePopupWindow(app.Ctx, "why")
+	popup := session.CreatePopupWindow(app.Ctx, "why", "Troubleshooting: "+string(code), 90, 22, content)
+	popup.OnSelect = func(ui session.UI, index int) error {
+		if index < related || index >= related+len(guide.Related) {
+			return nil
+		}
+		command := guide.Related[index-related]
+		session.ClosePopupWindow(app.Ctx, "why")
+		if strings.Contains(command, "<") {
+			// 需要参数：填入命令窗口等待补全
+			app.Ctx.CurrentInput = command[:strings.Index(command, "<")]
+			app.Ctx.CommandDirty = true
+			ui.Focus("command")
+			return nil
+		}
+		app.Ctx.CurrentInput = command
+		app.SubmitInput(ui)
+		return nil
+	}
+	session.ShowPopupWindow(app.Ctx, popup)
+}
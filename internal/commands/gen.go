@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 无界面生成调试产物 ==========
+// debug-gocui gen --project <dir> [--breakpoints bp.json] [--arch riscv64] [--backend bpf] [--vars "a b"] [--compile]
+// 不初始化gocui（不需要终端），在构建服务器或ssh管道中按断点生成调试产物：
+//   bpf        debug_variables.bpf.c 和加载/卸载脚本（与 vars 命令相同），--compile 时再用clang编译
+//   systemtap  debug_breakpoints.stp
+//   kprobe     debug_kprobe_events（kprobe_events定义，每行一条，可直接写入tracefs）
+//   bpftrace   debug_breakpoints.bt
+//   perf       debug_perf_probes.sh（perf probe 命令和 perf record | perf script 管道）
+// 产物写在项目根目录下，过程输出打印到stdout，失败时退出码为1，参数错误时为2。
+
+// kprobe后端生成的探针定义文件
+const kprobeEventsFile = "debug_kprobe_events"
+
+// gen 子命令的参数
+type genOptions struct {
+	Project     string
+	Breakpoints string
+	Arch        string
+	Backend     string
+	Vars        string
+	Compile     bool
+}
+
+// 解析 gen 子命令的参数
+func parseGenFlags(args []string) (*genOptions, error) {
+	opts := &genOptions{}
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.StringVar(&opts.Project, "project", ".", "project directory (kernel module source)")
+	flags.StringVar(&opts.Breakpoints, "breakpoints", "", "breakpoints JSON in .debug_breakpoints.json format (default: the project's own)")
+	flags.StringVar(&opts.Arch, "arch", "", "target architecture: x86, arm64, riscv64, s390x, ppc64le, mips64 (default: module ELF header, then host)")
+	flags.StringVar(&opts.Backend, "backend", session.BackendBPF, "artifact type: bpf, systemtap, kprobe, bpftrace or perf")
+	flags.StringVar(&opts.Vars, "vars", "", "variables to monitor with the bpf backend, space or comma separated (default: auto-detect)")
+	flags.BoolVar(&opts.Compile, "compile", false, "compile the generated BPF program with clang")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("多余的参数: %s", strings.Join(flags.Args(), " "))
+	}
+	opts.Backend = strings.ToLower(opts.Backend)
+	if opts.Backend == "stap" {
+		opts.Backend = session.BackendSystemtap
+	}
+	return opts, nil
+}
+
+// 断点文件中的相对路径以项目根目录为基准，缺少函数名时从源码解析
+func resolveGenBreakpoints(root string, breakpoints []project.Breakpoint) []project.Breakpoint {
+	for i := range breakpoints {
+		bp := &breakpoints[i]
+		bp.File = project.ResolveBreakpointPath(root, bp.File)
+		if bp.Function == "" {
+			bp.Function = project.ParseFunctionName(bp.File, bp.Line)
+		}
+	}
+	return breakpoints
+}
+
+// 按参数打开项目并生成产物，返回生成的文件
+func (app *AppContext) generateArtifacts(opts *genOptions) ([]string, error) {
+	root, err := filepath.Abs(opts.Project)
+	if err != nil {
+		return nil, fmt.Errorf("项目路径无效: %v", err)
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "项目目录不存在: %s", root)
+	}
+	proj, err := project.OpenProject(root)
+	if err != nil {
+		return nil, err
+	}
+	ctx := app.Ctx
+	ctx.Project = proj
+
+	if opts.Breakpoints != "" {
+		breakpoints, err := project.ReadBreakpointsFile(opts.Breakpoints)
+		if err != nil {
+			return nil, err
+		}
+		proj.Breakpoints = resolveGenBreakpoints(root, breakpoints)
+	}
+	if len(proj.Breakpoints) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNoBreakpoints, "没有断点（项目中没有 .debug_breakpoints.json，也没有指定 --breakpoints）")
+	}
+	if opts.Arch != "" {
+		arch, ok := session.ParseArchName(opts.Arch)
+		if !ok {
+			return nil, errcode.Errorf(errcode.ErrInvalidArg, "不支持的架构: %s", opts.Arch)
+		}
+		// 只影响本次生成，不写回项目设置
+		proj.Settings.TargetArch = arch
+	}
+
+	switch opts.Backend {
+	case session.BackendBPF:
+		command := "vars"
+		if vars := strings.Join(strings.Fields(strings.Replace(opts.Vars, ",", " ", -1)), " "); vars != "" {
+			command += " " + vars
+		}
+		commands := []string{command}
+		if opts.Compile {
+			commands = append(commands, "compile")
+		}
+		for _, command := range commands {
+			output, err := app.runCommandLine(nil, command)
+			for _, line := range output {
+				fmt.Println(session.StripANSI(line))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s 执行失败: %w", command, err)
+			}
+		}
+		files := []string{"debug_variables.bpf.c", "load_debug_vars.sh", "unload_debug_vars.sh"}
+		if opts.Compile {
+			files = append(files, "debug_variables.bpf.o")
+		}
+		for i, name := range files {
+			files[i] = filepath.Join(root, name)
+		}
+		return files, nil
+	case session.BackendSystemtap:
+		path, err := codegen.GenerateSystemtapScript(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	case session.BackendBpftrace:
+		path, warnings, err := codegen.GenerateBpftraceScript(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range warnings {
+			fmt.Println("Warning: " + w)
+		}
+		return []string{path}, nil
+	case session.BackendPerf:
+		module := project.FindProjectModule(root)
+		if module == "" {
+			return nil, errcode.Errorf(errcode.ErrNotFound, "没有找到项目的 .ko，perf probe需要模块的调试信息")
+		}
+		path, err := codegen.WritePerfProbesScript(ctx, module)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	case session.BackendKprobe:
+		defs := codegen.KprobeEventDefinitions(ctx, codegen.BuildProbePlan(ctx, true))
+		if len(defs) == 0 {
+			return nil, errcode.Errorf(errcode.ErrNoBreakpoints, "没有可跟踪的断点函数")
+		}
+		path := filepath.Join(root, kprobeEventsFile)
+		if err := ioutil.WriteFile(path, []byte(strings.Join(defs, "\n")+"\n"), 0644); err != nil {
+			return nil, fmt.Errorf("写入kprobe定义失败: %v", err)
+		}
+		return []string{path}, nil
+	}
+	return nil, errcode.Errorf(errcode.ErrInvalidArg, "%s 后端没有生成物（可用: bpf/systemtap/kprobe/bpftrace/perf）", opts.Backend)
+}
+
+// debug-gocui gen：返回进程退出码
+func RunGenCommand(args []string) int {
+	opts, err := parseGenFlags(args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	ctx := session.NewDebuggerContext()
+	ctx.DemoMode = false
+	app := &AppContext{Ctx: ctx}
+	files, err := app.generateArtifacts(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	for _, file := range files {
+		fmt.Printf("Generated %s\n", file)
+	}
+	return 0
+}
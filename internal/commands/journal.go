@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 操作日志（可重放的项目设置步骤） ==========
+
+// 在全新的项目状态上重放操作日志（bp toggle 等操作依赖执行前的状态，只能从空状态重放）。
+// 日志中的 open 是打开本项目的记录，项目已经打开，重放时跳过
+func (app *AppContext) replayOperations(ui session.UI) int {
+	ctx := app.Ctx
+	if ctx == nil || ctx.Project == nil {
+		return 0
+	}
+
+	// 重置为全新的项目状态：清空断点和监视表达式
+	ctx.Project.Breakpoints = make([]project.Breakpoint, 0)
+	if ctx.Project.Settings != nil {
+		ctx.Project.Settings.Watches = nil
+	}
+
+	journal := make([]project.DebugOperation, len(ctx.Project.Journal))
+	copy(journal, ctx.Project.Journal)
+
+	ctx.Replaying = true
+	defer func() { ctx.Replaying = false }()
+
+	replayed := 0
+	for _, op := range journal {
+		if strings.HasPrefix(op.Command, "open ") {
+			continue
+		}
+		ctx.CurrentInput = op.Command
+		app.SubmitInput(ui)
+		replayed++
+	}
+
+	// 重放后的状态写回磁盘
+	project.SaveBreakpoints(ctx.Project)
+	project.SaveProjectSettings(ctx.Project)
+
+	return replayed
+}
+
+// 重放前确认：会清空现有的断点和监视表达式，y 重放，其余键取消
+func (app *AppContext) confirmReplay(ui session.UI, breakpoints, watches int) {
+	ctx := app.Ctx
+	session.ClosePopupWindow(ctx, "replay")
+	content := []string{
+		fmt.Sprintf("Replaying %d journaled operations starts from an empty project state.", len(ctx.Project.Journal)),
+		"",
+		session.Styled(session.ActiveTheme.Warning, fmt.Sprintf("This discards %d breakpoints and %d watch expressions.", breakpoints, watches)),
+		"",
+		session.Styled(session.ActiveTheme.Dim, "y reset and replay | n cancel"),
+	}
+	popup := session.CreatePopupWindow(ctx, "replay", "Replay operation journal?", 80, 9, content)
+	popup.OnKey = func(ui session.UI, ch rune) error {
+		session.ClosePopupWindow(ctx, "replay")
+		if ch == 'y' {
+			count := app.replayOperations(ui)
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[OPS] Replayed %d operations", count))
+		} else {
+			ctx.CommandHistory = append(ctx.CommandHistory, "[OPS] Replay cancelled")
+		}
+		ctx.CommandDirty = true
+		ui.Focus("command")
+		return nil
+	}
+	session.ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+func TestReplayKeepsStateUntilConfirmed(t *testing.T) {
+	root := t.TempDir()
+	proj, err := project.OpenProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &session.DebuggerContext{Project: proj}
+	app := &AppContext{Ctx: ctx}
+
+	app.runCommand(nil, "open "+root)
+	app.runCommand(nil, "watch counter")
+	journal := ctx.Project.Journal
+	if len(journal) != 2 || journal[0].Command != "open "+root || journal[1].Command != "watch counter" {
+		t.Fatalf("journal = %+v", journal)
+	}
+	ctx = app.Ctx
+	ctx.Project.Settings.Watches = append(ctx.Project.Settings.Watches, project.WatchExpression{Expr: "unjournaled"})
+
+	// 有现有状态时不确认不重放
+	if _, err := app.runCommand(nil, "ops replay"); err == nil || len(ctx.Project.Settings.Watches) != 2 {
+		t.Fatalf("ops replay without confirmation: %v, watches %+v", err, ctx.Project.Settings.Watches)
+	}
+
+	output, err := app.runCommand(nil, "ops replay reset")
+	if err != nil || !strings.Contains(strings.Join(output, "\n"), "Replayed 1 operations") {
+		t.Fatalf("ops replay reset: %v, %v", output, err)
+	}
+	if exprs := session.WatchExpressions(ctx); len(exprs) != 1 || exprs[0] != "counter" {
+		t.Fatalf("watches after replay = %v", exprs)
+	}
+}
+
+func TestCommandErrorsAreStructured(t *testing.T) {
+	app := &AppContext{Ctx: &session.DebuggerContext{}}
+
+	cases := []struct {
+		command string
+		code    errcode.Code
+	}{
+		{"nosuchcommand", errcode.ErrUsage},
+		{"vars", errcode.ErrNoProject},
+		{"watch counter", errcode.ErrNoProject},
+		{"compile", errcode.ErrNoProject},
+	}
+	for _, c := range cases {
+		_, err := app.runCommand(nil, c.command)
+		if err == nil || errcode.Of(err) != c.code {
+			t.Errorf("%s: err = %v, want %s", c.command, err, c.code)
+		}
+	}
+
+	project, err := project.OpenProject(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Ctx.Project = project
+	if _, err := app.runCommand(nil, "unwatch"); errcode.Of(err) != errcode.ErrUsage {
+		t.Errorf("unwatch without arguments: err = %v", err)
+	}
+	if _, err := app.runCommand(nil, "hwbp del 7"); errcode.Of(err) != errcode.ErrNotFound {
+		t.Errorf("hwbp del 7: err = %v", err)
+	}
+	output, err := app.runCommand(nil, "watch counter")
+	if err != nil || len(output) == 0 {
+		t.Fatalf("watch counter: %v, %v", output, err)
+	}
+	for _, line := range output {
+		if strings.HasPrefix(line, "Error:") {
+			t.Errorf("successful command printed an error line: %q", line)
+		}
+	}
+
+	// 命令窗口格式：错误码、提示，用法错误不显示 why 提示
+	lines := errcode.Format(errcode.WithHints(errcode.Errorf(errcode.ErrPerm, "denied"), "run as root"))
+	if len(lines) != 3 || lines[0] != "Error: [E_PERM] denied" || lines[1] != "run as root" {
+		t.Errorf("errcode.Format = %q", lines)
+	}
+	if lines := errcode.Format(errcode.Usage("watch <expr>")); len(lines) != 1 {
+		t.Errorf("usage error lines = %q", lines)
+	}
+}
@@ -0,0 +1,436 @@
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== JSON-RPC 控制接口 ==========
+// rpc start [socket] 在Unix socket上提供JSON-RPC 2.0接口（每行一个JSON对象），编辑器（VS Code、Neovim）
+// 等外部工具可以在TUI运行时驱动调试器。所有方法都在界面线程中执行，与在命令窗口中输入命令相同，
+// 命令和输出以 [rpc] 标记显示在命令窗口中。方法：
+//   command           {"command": "bp toggle drv.c:42"}  执行任意命令
+//   open              {"path": "/src/driver"}
+//   breakpoint.add    {"file": "drv.c", "line": 42}      已存在时不变
+//   breakpoint.remove {"file": "drv.c", "line": 42}
+//   breakpoint.list   所有断点
+//   generate          {"vars": ["dev", "len"]}           与 vars 命令相同（为空时自动检测）
+//   compile           {"arch": "arm64"}                  arch可省略
+//   load / unload     bpf load / bpf unload
+//   events.start / events.stop
+//   events.subscribe  之后每个新事件以 "event" 通知按序号推送（params为事件）；变量值（[VAR-N]）
+//                     合并进已推送的断点命中时不会再次推送，需要时用 command "events" 重新读取
+//   state             项目、当前文件、断点数、后端等
+// 命令失败时返回错误（code -32000），message是失败原因，data中是错误码（error_code）、命令输出和提示。
+
+// JSON-RPC错误码
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcCommandFailed  = -32000
+)
+
+// 事件推送的检查间隔
+const rpcEventInterval = 200 * time.Millisecond
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// 命令方法的返回值
+type rpcCommandResult struct {
+	Output []string `json:"output"`
+}
+
+// 命令失败时错误中的data
+type rpcCommandFailure struct {
+	ErrorCode errcode.Code `json:"error_code"`      // 错误码（why <code> 的排查说明）
+	Output    []string     `json:"output"`          // 失败前命令已经输出的内容
+	Hints     []string     `json:"hints,omitempty"` // 用法示例、下一步操作
+}
+
+// state 方法的返回值
+type rpcState struct {
+	Project     string `json:"project,omitempty"`
+	File        string `json:"file,omitempty"`
+	Breakpoints int    `json:"breakpoints"`
+	Backend     string `json:"backend,omitempty"`
+	Capturing   bool   `json:"capturing"`
+	Events      int    `json:"events"`
+	BPFLoaded   bool   `json:"bpf_loaded"`
+	SafeMode    bool   `json:"safe_mode"`
+}
+
+// 正在运行的RPC服务
+type rpcServer struct {
+	path     string
+	listener net.Listener
+	closed   chan struct{}
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+}
+
+// 一个客户端连接（响应和事件通知可能来自不同的goroutine）
+type rpcConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+	enc  *json.Encoder
+}
+
+func (c *rpcConn) send(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enc.Encode(v)
+}
+
+// 默认socket路径（$XDG_RUNTIME_DIR只有当前用户可访问，没有时放在临时目录并带上uid）
+func defaultRPCSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "kdebug-tui.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("kdebug-tui-%d.sock", os.Getuid()))
+}
+
+// 启动RPC服务，返回监听的socket路径
+func (app *AppContext) StartRPC(ui session.UI, path string) (string, error) {
+	if app.rpc != nil {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "RPC服务已在 %s 上运行", app.rpc.path)
+	}
+	if path == "" {
+		path = defaultRPCSocket()
+	}
+	if _, err := os.Stat(path); err == nil {
+		// 另一个调试器仍在监听时不抢占，否则是上次异常退出留下的socket
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return "", errcode.Errorf(errcode.ErrInvalidArg, "%s 已被另一个调试器使用", path)
+		}
+		os.Remove(path)
+	}
+	listener, err := listenPrivateUnix(path)
+	if err != nil {
+		return "", fmt.Errorf("监听 %s 失败: %v", path, err)
+	}
+
+	server := &rpcServer{path: path, listener: listener, closed: make(chan struct{}), conns: make(map[net.Conn]bool)}
+	app.rpc = server
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns[conn] = true
+			server.mu.Unlock()
+			go app.serveRPCConn(ui, server, conn)
+		}
+	}()
+	return path, nil
+}
+
+// 在path上监听，只允许当前用户连接：socket先在同一目录下新建的私有目录（0700）中创建、改为0600，
+// 再改名到path，权限收紧之前其他用户无法连上。socket文件由 StopRPC 删除
+func listenPrivateUnix(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".kdebug-rpc-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	listener.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// 停止RPC服务并断开所有客户端
+func (app *AppContext) StopRPC() {
+	server := app.rpc
+	if server == nil {
+		return
+	}
+	app.rpc = nil
+	close(server.closed)
+	server.listener.Close()
+	server.mu.Lock()
+	for conn := range server.conns {
+		conn.Close()
+	}
+	server.mu.Unlock()
+	os.Remove(server.path)
+}
+
+// 处理一个连接：逐行读取请求
+func (app *AppContext) serveRPCConn(ui session.UI, server *rpcServer, conn net.Conn) {
+	defer func() {
+		conn.Close()
+		server.mu.Lock()
+		delete(server.conns, conn)
+		server.mu.Unlock()
+	}()
+	client := &rpcConn{conn: conn, enc: json.NewEncoder(conn)}
+	stopEvents := make(chan struct{})
+	defer close(stopEvents)
+	subscribed := false
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			client.send(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		var result interface{}
+		var rpcErr *rpcError
+		if req.Method == "events.subscribe" {
+			if !subscribed {
+				subscribed = true
+				go app.pushRPCEvents(ui, server, client, stopEvents)
+			}
+			result = true
+		} else {
+			result, rpcErr = app.handleRPC(ui, server, &req)
+		}
+		if len(req.ID) == 0 {
+			// 通知不需要响应
+			continue
+		}
+		if rpcErr != nil {
+			result = nil
+		}
+		if err := client.send(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}); err != nil {
+			return
+		}
+	}
+}
+
+// 在界面线程中执行，服务停止时返回false
+func runOnUI(ui session.UI, server *rpcServer, fn func()) bool {
+	done := make(chan struct{})
+	ui.Update(func(ui session.UI) error {
+		fn()
+		close(done)
+		return nil
+	})
+	select {
+	case <-done:
+		return true
+	case <-server.closed:
+		return false
+	}
+}
+
+// 执行命令（显示在命令窗口中），失败时返回错误
+func (app *AppContext) rpcCommand(ui session.UI, server *rpcServer, command string) (interface{}, *rpcError) {
+	var output []string
+	var cmdErr error
+	ok := runOnUI(ui, server, func() {
+		ctx := app.Ctx
+		ctx.CommandHistory = append(ctx.CommandHistory, session.Styled(session.ActiveTheme.Dim, "[rpc]")+" "+command)
+		output, cmdErr = app.runCommand(ui, command)
+		app.appendCommandOutput(output, cmdErr)
+	})
+	if !ok {
+		return nil, &rpcError{Code: rpcCommandFailed, Message: "debugger is shutting down"}
+	}
+	plain := make([]string, len(output))
+	for i, line := range output {
+		plain[i] = session.StripANSI(line)
+	}
+	if cmdErr != nil {
+		var hints []string
+		for _, line := range errcode.Hints(cmdErr) {
+			hints = append(hints, session.StripANSI(line))
+		}
+		return nil, &rpcError{Code: rpcCommandFailed, Message: session.StripANSI(errcode.Message(cmdErr)), Data: rpcCommandFailure{
+			ErrorCode: errcode.Of(cmdErr),
+			Output:    plain,
+			Hints:     hints,
+		}}
+	}
+	return rpcCommandResult{Output: plain}, nil
+}
+
+// 分发请求
+func (app *AppContext) handleRPC(ui session.UI, server *rpcServer, req *rpcRequest) (interface{}, *rpcError) {
+	var params struct {
+		Command string   `json:"command"`
+		Path    string   `json:"path"`
+		File    string   `json:"file"`
+		Line    int      `json:"line"`
+		Vars    []string `json:"vars"`
+		Arch    string   `json:"arch"`
+	}
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+	}
+	invalid := func(format string, args ...interface{}) *rpcError {
+		return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch req.Method {
+	case "":
+		return nil, &rpcError{Code: rpcInvalidRequest, Message: "missing method"}
+	case "command":
+		if strings.TrimSpace(params.Command) == "" {
+			return nil, invalid("command is required")
+		}
+		return app.rpcCommand(ui, server, strings.TrimSpace(params.Command))
+	case "open":
+		if params.Path == "" {
+			return nil, invalid("path is required")
+		}
+		return app.rpcCommand(ui, server, "open "+params.Path)
+	case "breakpoint.add", "breakpoint.remove":
+		if params.File == "" || params.Line <= 0 {
+			return nil, invalid("file and line are required")
+		}
+		want := req.Method == "breakpoint.add"
+		exists, hasProject := false, false
+		runOnUI(ui, server, func() {
+			if app.Ctx.Project == nil {
+				return
+			}
+			hasProject = true
+			file := params.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(app.Ctx.Project.RootPath, file)
+			}
+			for _, bp := range app.Ctx.Project.Breakpoints {
+				if bp.File == file && bp.Line == params.Line {
+					exists = true
+				}
+			}
+		})
+		if hasProject && exists == want {
+			return rpcCommandResult{Output: []string{}}, nil
+		}
+		return app.rpcCommand(ui, server, fmt.Sprintf("bp toggle %s:%d", params.File, params.Line))
+	case "breakpoint.list":
+		breakpoints := []project.Breakpoint{}
+		runOnUI(ui, server, func() {
+			if app.Ctx.Project != nil {
+				breakpoints = append(breakpoints, app.Ctx.Project.Breakpoints...)
+			}
+		})
+		return breakpoints, nil
+	case "generate":
+		return app.rpcCommand(ui, server, strings.TrimSpace("vars "+strings.Join(params.Vars, " ")))
+	case "compile":
+		return app.rpcCommand(ui, server, strings.TrimSpace("compile "+params.Arch))
+	case "load":
+		return app.rpcCommand(ui, server, "bpf load")
+	case "unload":
+		return app.rpcCommand(ui, server, "bpf unload")
+	case "events.start":
+		return app.rpcCommand(ui, server, "events start")
+	case "events.stop":
+		return app.rpcCommand(ui, server, "events stop")
+	case "state":
+		var state rpcState
+		runOnUI(ui, server, func() {
+			ctx := app.Ctx
+			state.Events = len(ctx.Events)
+			state.Capturing = ctx.EventSource != nil
+			state.BPFLoaded = ctx.BPF != nil
+			state.SafeMode = ctx.SafeMode
+			if ctx.Project != nil {
+				state.Project = ctx.Project.RootPath
+				state.File = ctx.Project.CurrentFile
+				state.Breakpoints = len(ctx.Project.Breakpoints)
+				state.Backend = session.CurrentBackend(ctx)
+			}
+		})
+		return state, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "unknown method: " + req.Method}
+}
+
+// 把订阅之后的新事件推送给客户端
+func (app *AppContext) pushRPCEvents(ui session.UI, server *rpcServer, client *rpcConn, stop chan struct{}) {
+	lastSeq := -1
+	runOnUI(ui, server, func() {
+		lastSeq = app.Ctx.EventSeq
+	})
+	ticker := time.NewTicker(rpcEventInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-server.closed:
+			return
+		case <-ticker.C:
+		}
+		var events []session.DebugEvent
+		runOnUI(ui, server, func() {
+			for _, event := range app.Ctx.Events {
+				if event.Seq > lastSeq {
+					events = append(events, event)
+				}
+			}
+		})
+		// 内核日志和硬件断点按时间戳插在较晚的事件之前，事件列表不一定按序号排列
+		sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+		for _, event := range events {
+			lastSeq = event.Seq
+			if err := client.send(rpcNotification{JSONRPC: "2.0", Method: "event", Params: event}); err != nil {
+				return
+			}
+		}
+	}
+}
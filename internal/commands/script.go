@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令脚本 ==========
+// 脚本文件每行一条TUI命令（open、bp add、vars、compile ...），空行和 # 开头的行忽略：
+//   source <file>                     在TUI中执行脚本（可嵌套）
+//   debug-gocui --script <file>       不启动界面执行脚本，输出打印到stdout，用于CI冒烟测试
+//   debug-gocui --script <file> --tui 启动界面后执行脚本（可复现的调试环境）
+// 遇到第一条失败的命令（包括未知命令）即停止；无界面运行时退出码为1。
+
+// 脚本嵌套深度上限（防止脚本互相source）
+const maxScriptDepth = 8
+
+// 读取脚本中的命令（返回命令和所在行号）
+func readScript(path string) ([]string, []int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开脚本失败: %v", err)
+	}
+	defer file.Close()
+
+	var commands []string
+	var lineNums []int
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, line)
+		lineNums = append(lineNums, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("读取脚本失败: %v", err)
+	}
+	return commands, lineNums, nil
+}
+
+// 无界面运行时不能执行的命令（跳转窗口、后台任务等依赖gocui，见 commands.go 命令表）
+func scriptNeedsUI(command string) bool {
+	cmd, args := parseCommandLine(command)
+	spec := commandTable[cmd]
+	return spec != nil && spec.needsUI != nil && spec.needsUI(args)
+}
+
+// 执行脚本：每条命令与在命令窗口输入相同，echo不为nil时收到每条命令新增的历史行。
+// g为nil表示无界面运行。返回执行的命令数，遇到失败的命令时返回错误
+func (app *AppContext) runScript(ui session.UI, path string, echo func(lines []string)) (int, error) {
+	ctx := app.Ctx
+	if ctx.ScriptDepth >= maxScriptDepth {
+		return 0, fmt.Errorf("脚本嵌套超过%d层: %s", maxScriptDepth, path)
+	}
+	commands, lineNums, err := readScript(path)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx.ScriptDepth++
+	defer func() { ctx.ScriptDepth-- }()
+	for i, command := range commands {
+		if ui == nil && scriptNeedsUI(command) {
+			return i, fmt.Errorf("%s:%d: %s 需要界面，请使用 --tui 运行", filepath.Base(path), lineNums[i], command)
+		}
+		output, err := app.runCommandLine(ui, command)
+		if echo != nil {
+			echo(output)
+		}
+		if err != nil {
+			return i + 1, fmt.Errorf("%s:%d: %s 执行失败: %w", filepath.Base(path), lineNums[i], command, err)
+		}
+	}
+	return len(commands), nil
+}
+
+// 执行一条命令（与在命令窗口输入相同），返回它新增的历史行和命令失败的原因
+func (app *AppContext) runCommandLine(ui session.UI, command string) ([]string, error) {
+	ctx := app.Ctx
+	start := len(ctx.CommandHistory)
+	ctx.CurrentInput = command
+	err := app.SubmitInput(ui)
+	if start > len(ctx.CommandHistory) {
+		// 历史被清空（clear）
+		start = 0
+	}
+	return ctx.CommandHistory[start:], err
+}
+
+// 脚本路径：相对路径以项目根目录为基准（没有项目时为当前目录）
+func scriptPath(ctx *session.DebuggerContext, name string) string {
+	if filepath.IsAbs(name) || ctx.Project == nil {
+		return name
+	}
+	return filepath.Join(ctx.Project.RootPath, name)
+}
+
+// --script 无界面运行：打印每条命令及其输出，返回进程退出码
+func (app *AppContext) RunScriptHeadless(path string) int {
+	ran, err := app.runScript(nil, path, func(lines []string) {
+		for _, line := range lines {
+			fmt.Println(session.StripANSI(line))
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Script %s: %d commands OK\n", path, ran)
+	return 0
+}
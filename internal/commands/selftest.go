@@ -0,0 +1,411 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 端到端自检 ==========
+// 使用 selftest/ 下的示例模块走一遍完整流程：编译/加载模块 → 设置断点 → 生成并编译BPF →
+// 挂载探针 → 触发 → 确认事件回到事件列表。任一阶段失败即停止，并报告失败的阶段。
+
+// 示例模块名称和触发接口
+const (
+	selftestModule   = "debug_selftest"
+	selftestFunction = "debug_selftest_trigger"
+	selftestProcFile = "/proc/debug_selftest"
+	selftestPinDir   = "/sys/fs/bpf/debug_selftest"
+	selftestMarker   = "SELFTEST_BREAKPOINT"
+)
+
+// 等待事件回到界面的超时
+const selftestEventTimeout = 5 * time.Second
+
+// 自检阶段结果
+type selftestStage struct {
+	Name   string
+	Status string // ok / fail / skip
+	Detail string
+}
+
+// 自检运行状态
+type selftestRun struct {
+	ui      session.UI
+	ctx     *session.DebuggerContext
+	stages  []selftestStage
+	failed  bool
+	workDir string
+	koPath  string
+	arch    string
+	probe   *session.DebuggerContext // 示例模块的临时项目（不影响当前打开的项目）
+	started bool                     // 是否由自检启动了事件采集
+	loaded  bool
+	pinned  bool
+}
+
+// 查找示例模块目录：环境变量 > 可执行文件旁 > 当前目录
+func findSelftestDir() (string, error) {
+	candidates := make([]string, 0, 3)
+	if dir := os.Getenv("DEBUG_TUI_SELFTEST_DIR"); dir != "" {
+		candidates = append(candidates, dir)
+	}
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), "selftest"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, filepath.Join(cwd, "selftest"))
+	}
+	for _, dir := range candidates {
+		if project.FileExists(filepath.Join(dir, selftestModule+".c")) {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("找不到示例模块源码 selftest/%s.c (可通过 DEBUG_TUI_SELFTEST_DIR 指定)", selftestModule)
+}
+
+// 在UI线程中记录进度
+func (run *selftestRun) progress(format string, a ...interface{}) {
+	msg := fmt.Sprintf("[SELFTEST] "+format, a...)
+	run.ui.Update(func(ui session.UI) error {
+		run.ctx.CommandHistory = append(run.ctx.CommandHistory, msg)
+		run.ctx.CommandDirty = true
+		return nil
+	})
+}
+
+// 执行一个阶段（之前的阶段失败时跳过）
+func (run *selftestRun) stage(name string, fn func() (string, error)) {
+	if run.failed {
+		run.stages = append(run.stages, selftestStage{Name: name, Status: "skip"})
+		return
+	}
+	detail, err := fn()
+	if err != nil {
+		run.failed = true
+		run.stages = append(run.stages, selftestStage{Name: name, Status: "fail", Detail: err.Error()})
+		run.progress("%s: FAILED - %v", name, err)
+		return
+	}
+	run.stages = append(run.stages, selftestStage{Name: name, Status: "ok", Detail: detail})
+	run.progress("%s: ok %s", name, detail)
+}
+
+// 在UI线程中执行函数并等待结果
+func (run *selftestRun) onUI(fn func() error) error {
+	done := make(chan error, 1)
+	run.ui.Update(func(ui session.UI) error {
+		done <- fn()
+		return nil
+	})
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(selftestEventTimeout):
+		return fmt.Errorf("界面无响应")
+	}
+}
+
+// 运行一个外部命令，失败时返回命令输出
+func runSelftestCommand(dir string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		text := strings.TrimSpace(string(output))
+		if lines := strings.Split(text, "\n"); len(lines) > 8 {
+			text = strings.Join(lines[len(lines)-8:], "\n")
+		}
+		return fmt.Errorf("%s 失败: %v\n%s", strings.Join(append([]string{name}, args...), " "), err, text)
+	}
+	return nil
+}
+
+// 阶段1：检查运行环境
+func (run *selftestRun) checkEnvironment() (string, error) {
+	missing := make([]string, 0)
+	if os.Geteuid() != 0 {
+		missing = append(missing, "root权限")
+	}
+	if !tracePipeAvailable() {
+		missing = append(missing, "trace_pipe")
+	}
+	for _, tool := range []string{"clang", "bpftool", "insmod", "rmmod"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			missing = append(missing, tool)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("缺少: %s", strings.Join(missing, ", "))
+	}
+	run.arch = dwarf.DetectCurrentArch()
+	return fmt.Sprintf("(%s, kernel %s)", run.arch, project.KernelRelease()), nil
+}
+
+// 检查trace_pipe是否存在（不打开，避免抢占正在运行的事件采集）
+func tracePipeAvailable() bool {
+	for _, path := range session.TracePipePaths {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// 阶段2：准备示例模块（优先使用匹配当前内核的预编译模块，否则从源码编译）
+func (run *selftestRun) prepareModule() (string, error) {
+	srcDir, err := findSelftestDir()
+	if err != nil {
+		return "", err
+	}
+	workDir, err := ioutil.TempDir("", "debug-selftest-")
+	if err != nil {
+		return "", fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	run.workDir = workDir
+	for _, name := range []string{selftestModule + ".c", "Makefile"} {
+		data, err := ioutil.ReadFile(filepath.Join(srcDir, name))
+		if err != nil {
+			return "", fmt.Errorf("读取示例模块失败: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(workDir, name), data, 0644); err != nil {
+			return "", fmt.Errorf("复制示例模块失败: %v", err)
+		}
+	}
+
+	prebuilt := filepath.Join(srcDir, "prebuilt", run.arch, project.KernelRelease(), selftestModule+".ko")
+	if project.FileExists(prebuilt) {
+		run.koPath = prebuilt
+		return "(prebuilt " + prebuilt + ")", nil
+	}
+
+	kdir := filepath.Join("/lib/modules", project.KernelRelease(), "build")
+	if _, err := os.Stat(kdir); err != nil {
+		return "", fmt.Errorf("没有匹配的预编译模块，且找不到内核头文件: %s", kdir)
+	}
+	if err := runSelftestCommand(workDir, "make", "KDIR="+kdir); err != nil {
+		return "", err
+	}
+	run.koPath = filepath.Join(workDir, selftestModule+".ko")
+	if !project.FileExists(run.koPath) {
+		return "", fmt.Errorf("编译完成但未找到 %s", run.koPath)
+	}
+	return "(built from source)", nil
+}
+
+// 阶段3：加载模块（先卸载之前残留的实例）
+func (run *selftestRun) loadModule() (string, error) {
+	exec.Command("rmmod", selftestModule).Run()
+	if err := runSelftestCommand(run.workDir, "insmod", run.koPath); err != nil {
+		return "", err
+	}
+	run.loaded = true
+	if _, err := os.Stat(selftestProcFile); err != nil {
+		return "", fmt.Errorf("模块已加载但 %s 不存在", selftestProcFile)
+	}
+	return "", nil
+}
+
+// 阶段4：在示例模块源码上设置断点（使用临时项目，不修改当前项目）
+func (run *selftestRun) setBreakpoint() (string, error) {
+	srcPath := filepath.Join(run.workDir, selftestModule+".c")
+	lines, err := project.ReadFileContent(srcPath)
+	if err != nil {
+		return "", err
+	}
+	line := 0
+	for i, text := range lines {
+		if strings.Contains(text, selftestMarker) {
+			line = i + 1
+			break
+		}
+	}
+	if line == 0 {
+		return "", fmt.Errorf("示例源码中找不到断点标记 %s", selftestMarker)
+	}
+
+	run.probe = &session.DebuggerContext{
+		Replaying: true,
+		Project: &project.ProjectInfo{
+			RootPath:    run.workDir,
+			OpenFiles:   make(map[string]*project.SourceFile),
+			Breakpoints: make([]project.Breakpoint, 0),
+			Settings:    &project.ProjectSettings{TargetArch: run.arch},
+		},
+	}
+	session.AddBreakpoint(run.probe, srcPath, line)
+	if len(run.probe.Project.Breakpoints) != 1 || run.probe.Project.Breakpoints[0].Function != selftestFunction {
+		return "", fmt.Errorf("断点函数解析错误: 期望 %s", selftestFunction)
+	}
+	return fmt.Sprintf("(%s.c:%d in %s)", selftestModule, line, selftestFunction), nil
+}
+
+// 阶段5：生成并编译BPF探针
+func (run *selftestRun) buildProbe() (string, error) {
+	if err := codegen.GenerateBPF(run.probe); err != nil {
+		return "", err
+	}
+	if err := codegen.CompileBPFWithArch(run.probe, run.arch); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// 阶段6：加载并挂载探针
+func (run *selftestRun) attachProbe() (string, error) {
+	os.RemoveAll(selftestPinDir)
+	obj := filepath.Join(run.workDir, "debug_breakpoints.bpf.o")
+	if err := runSelftestCommand(run.workDir, "bpftool", "prog", "loadall", obj, selftestPinDir, "autoattach"); err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "verifier") {
+			return "", &errcode.CodedError{Code: errcode.ErrBPFVerifier, Err: err}
+		}
+		if session.IsAttachENOENT(err) {
+			// 挂载ENOENT：附上符号、模块加载状态和版本的诊断
+			diagnosis := session.DiagnoseAttachFailure(selftestFunction, run.koPath, err)
+			return "", errcode.Errorf(errcode.ErrBPFAttach, "%v\n%s", err, strings.Join(diagnosis, "\n"))
+		}
+		return "", &errcode.CodedError{Code: errcode.ErrBPFAttach, Err: err}
+	}
+	run.pinned = true
+	return "", nil
+}
+
+// 阶段7：触发断点并确认事件回到事件列表
+func (run *selftestRun) verifyRoundTrip() (string, error) {
+	baseline := 0
+	err := run.onUI(func() error {
+		baseline = run.ctx.EventSeq
+		if run.ctx.EventSource != nil {
+			return nil
+		}
+		if _, err := session.StartEventCapture(run.ui, run.ctx); err != nil {
+			return err
+		}
+		run.started = true
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := ioutil.WriteFile(selftestProcFile, []byte("21\n"), 0200); err != nil {
+		return "", fmt.Errorf("触发 %s 失败: %v", selftestProcFile, err)
+	}
+
+	deadline := time.Now().Add(selftestEventTimeout)
+	for time.Now().Before(deadline) {
+		found := ""
+		run.onUI(func() error {
+			for _, event := range run.ctx.Events {
+				if event.Seq > baseline && event.Kind == "breakpoint" && event.Function == selftestFunction {
+					found = fmt.Sprintf("(event #%d pid=%d %s)", event.Seq, event.PID, event.Location)
+				}
+			}
+			return nil
+		})
+		if found != "" {
+			return found, nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return "", fmt.Errorf("%s内未收到 %s 的断点事件", selftestEventTimeout, selftestFunction)
+}
+
+// 清理：卸载探针和模块，停止由自检启动的事件采集
+func (run *selftestRun) cleanup() {
+	if run.pinned {
+		os.RemoveAll(selftestPinDir)
+	}
+	if run.loaded {
+		exec.Command("rmmod", selftestModule).Run()
+	}
+	if run.started {
+		run.onUI(func() error {
+			session.StopEventCapture(run.ctx)
+			return nil
+		})
+	}
+	if run.workDir != "" {
+		os.RemoveAll(run.workDir)
+	}
+}
+
+// 自检结果弹出窗口内容
+func (run *selftestRun) report() []string {
+	content := make([]string, 0, len(run.stages)+4)
+	for i, stage := range run.stages {
+		mark := "\x1b[32m✓\x1b[0m"
+		switch stage.Status {
+		case "fail":
+			mark = "\x1b[31m✗\x1b[0m"
+		case "skip":
+			mark = "\x1b[90m-\x1b[0m"
+		}
+		content = append(content, fmt.Sprintf(" %s %d. %-22s %s", mark, i+1, stage.Name, strings.SplitN(stage.Detail, "\n", 2)[0]))
+		if stage.Status == "fail" {
+			for _, line := range strings.Split(stage.Detail, "\n")[1:] {
+				content = append(content, "      "+line)
+			}
+		}
+	}
+	content = append(content, "")
+	if run.failed {
+		content = append(content, "\x1b[31mSelf-test FAILED\x1b[0m - see the first failed stage above")
+		for _, stage := range run.stages {
+			if stage.Status == "fail" {
+				content = append(content, fmt.Sprintf("'why %s' shows troubleshooting steps", errcode.Classify(stage.Detail)))
+				break
+			}
+		}
+	} else {
+		content = append(content, "\x1b[32mSelf-test PASSED\x1b[0m - breakpoint events reach the UI")
+	}
+	return content
+}
+
+// 启动自检（在后台协程中执行，结果以弹出窗口显示）
+func startSelftest(ui session.UI, ctx *session.DebuggerContext) error {
+	if ctx.SelftestRunning {
+		return fmt.Errorf("自检正在运行")
+	}
+	if err := session.CheckSafeMode(ctx, "自检"); err != nil {
+		return err
+	}
+	ctx.SelftestRunning = true
+
+	run := &selftestRun{ui: ui, ctx: ctx}
+	go func() {
+		run.stage("environment", run.checkEnvironment)
+		run.stage("build sample module", run.prepareModule)
+		run.stage("load module", run.loadModule)
+		run.stage("set breakpoint", run.setBreakpoint)
+		run.stage("generate/compile BPF", run.buildProbe)
+		run.stage("attach probe", run.attachProbe)
+		run.stage("event round-trip", run.verifyRoundTrip)
+		run.cleanup()
+
+		content := run.report()
+		height := len(content) + 5
+		if height > 30 {
+			height = 30
+		}
+		ui.Update(func(ui session.UI) error {
+			ctx.SelftestRunning = false
+			session.ClosePopupWindow(ctx, "selftest")
+			popup := session.CreatePopupWindow(ctx, "selftest", "Self-test", 100, height, content)
+			session.ShowPopupWindow(ctx, popup)
+			return nil
+		})
+	}()
+	return nil
+}
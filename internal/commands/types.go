@@ -0,0 +1,15 @@
+package commands
+
+import "debug-gocui/internal/session"
+
+// AppContext 应用上下文
+// 原版gocui没有UserData字段，所有依赖调试器状态的回调都定义为AppContext的方法，
+// 通过方法值注册到gocui，从而显式注入上下文而不是依赖全局变量。
+// 命令层只持有工作区和RPC状态，界面的布局层（ui/layout）和输入层（ui/input）各自嵌入下一层的AppContext
+type AppContext struct {
+	Ctx             *session.DebuggerContext
+	Workspaces      []*session.Workspace // 所有工作区（Ctx为当前工作区的上下文）
+	Workspace       int                  // 当前工作区下标
+	rpc             *rpcServer           // JSON-RPC控制接口（为nil表示未启动，见 rpc.go）
+	KeyBindingLines func() []string      // keys 命令列出的按键绑定（由界面提供，为nil时不列出）
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/session"
+)
+
+// ========== 多工作区 ==========
+// 在同一个界面中运行多个独立的采集（例如本地的模块A和远程开发板上的模块B），
+// 避免同时开两个调试器争抢终端。每个工作区有自己的DebuggerContext：项目、断点、
+// 事件流、命令历史和弹出窗口；后台工作区的采集协程继续写入自己的上下文。
+// 布局、全屏、鼠标、安全模式和自身性能统计在工作区之间共享。
+
+// 所有工作区（首次调用时把启动时的上下文作为工作区1）
+func (app *AppContext) WorkspaceList() []*session.Workspace {
+	if len(app.Workspaces) == 0 {
+		app.Workspaces = []*session.Workspace{{Ctx: app.Ctx}}
+		app.Workspace = 0
+	}
+	return app.Workspaces
+}
+
+// 创建新工作区（共享界面状态，其余状态全新）
+func (app *AppContext) newWorkspace(name string) (int, error) {
+	list := app.WorkspaceList()
+	if len(list) >= session.MaxWorkspaces {
+		return 0, fmt.Errorf("最多支持%d个工作区", session.MaxWorkspaces)
+	}
+	ctx := session.NewDebuggerContext()
+	ctx.DemoMode = app.Ctx.DemoMode
+	ctx.HistoryLimit = app.Ctx.HistoryLimit
+	ctx.HistoryMaxAge = app.Ctx.HistoryMaxAge
+	app.Workspaces = append(list, &session.Workspace{Ctx: ctx, Name: name})
+	n := len(app.Workspaces)
+	ctx.CommandHistory = append(ctx.CommandHistory,
+		fmt.Sprintf("[WORKSPACE %d] New workspace, use 'open <path>' to load a project", n),
+		fmt.Sprintf("Alt+1..Alt+%d or 'workspace <n>' switches workspaces, captures keep running in the background", n))
+	return n, nil
+}
+
+// 切换到第n个工作区（从1开始）
+func (app *AppContext) SwitchWorkspace(ui session.UI, n int) error {
+	list := app.WorkspaceList()
+	if n < 1 || n > len(list) {
+		return fmt.Errorf("工作区不存在: %d (共%d个)", n, len(list))
+	}
+	if n-1 == app.Workspace {
+		return nil
+	}
+	old := list[app.Workspace]
+	next := list[n-1]
+
+	// 收起当前工作区的命令面板和弹出窗口（弹出窗口保留在列表中，切回时重新显示；
+	// 视图在下次渲染时按新工作区的列表删除）
+	old.Ctx.PaletteOpen = false
+	old.Ctx.DraggingPopup = nil
+	old.Ctx.LeaderPending = false
+	old.SeenEvents = old.Ctx.EventSeq
+
+	// 界面状态在工作区之间共享
+	next.Ctx.Layout = old.Ctx.Layout
+	next.Ctx.IsFullscreen = old.Ctx.IsFullscreen
+	next.Ctx.FullscreenView = old.Ctx.FullscreenView
+	next.Ctx.SavedLayout = old.Ctx.SavedLayout
+	next.Ctx.MouseEnabled = old.Ctx.MouseEnabled
+	next.Ctx.SafeMode = old.Ctx.SafeMode
+	next.Ctx.Perf = old.Ctx.Perf
+
+	app.Ctx = next.Ctx
+	app.Workspace = n - 1
+	app.Ctx.CommandDirty = true
+	app.Ctx.SearchDirty = true
+
+	// 当前焦点可能是将要删除的弹出窗口或命令面板，新工作区的弹出窗口渲染时会重新获得焦点。
+	// 窗口内容由界面刷新重绘
+	if ui != nil {
+		if v := ui.CurrentView(); v == "" || v == "palette" || strings.HasPrefix(v, "popup_") {
+			ui.Focus("command")
+		}
+	}
+	return nil
+}
+
+// 关闭第n个工作区（停止其采集；至少保留一个工作区）
+func (app *AppContext) closeWorkspace(ui session.UI, n int) error {
+	list := app.WorkspaceList()
+	if n < 1 || n > len(list) {
+		return fmt.Errorf("工作区不存在: %d (共%d个)", n, len(list))
+	}
+	if len(list) == 1 {
+		return fmt.Errorf("不能关闭最后一个工作区")
+	}
+	if n-1 == app.Workspace {
+		other := n - 1
+		if other < 1 {
+			other = 2
+		}
+		if err := app.SwitchWorkspace(ui, other); err != nil {
+			return err
+		}
+	}
+	ctx := list[n-1].Ctx
+	session.UnloadBPF(ctx)
+	session.StopEventCapture(ctx)
+	session.StopKmsgCapture(ctx)
+	session.ClearHWBreakpoints(ctx)
+	session.StopSnapshots(ctx)
+	session.StopWatchdog(ctx)
+	session.StopRecording(ctx)
+	app.Workspaces = append(list[:n-1], list[n:]...)
+	for i, ws := range app.Workspaces {
+		if ws.Ctx == app.Ctx {
+			app.Workspace = i
+		}
+	}
+	return nil
+}
+
+// 另一个工作区是否已在读取本地trace_pipe（同一文件的行只会被一个读者读到）
+func (app *AppContext) localCaptureOwner() int {
+	if session.RemoteTarget(app.Ctx) != nil {
+		return 0
+	}
+	for i, ws := range app.WorkspaceList() {
+		if i != app.Workspace && ws.Ctx.EventSource != nil && session.RemoteTarget(ws.Ctx) == nil {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// 工作区列表
+func (app *AppContext) workspaceLines() []string {
+	lines := make([]string, 0)
+	for i, ws := range app.WorkspaceList() {
+		marker := " "
+		if i == app.Workspace {
+			marker = "*"
+		}
+		capture := "idle"
+		if ws.Ctx.EventSource != nil {
+			capture = "capturing"
+		}
+		lines = append(lines, fmt.Sprintf("%s %d  %-16s %-24s %-9s %d breakpoints, %d events",
+			marker, i+1, session.WorkspaceLabel(ws), session.WorkspaceTarget(ws.Ctx), capture, session.WorkspaceBreakpoints(ws.Ctx), len(ws.Ctx.Events)))
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package dwarf
+
+import (
+	"debug/elf"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// 检测当前系统架构
+func DetectCurrentArch() string {
+	output, err := exec.Command("uname", "-m").Output()
+	if err != nil {
+		return "x86_64" // 默认架构
+	}
+	
+	arch := strings.TrimSpace(string(output))
+	
+	// 标准化架构名称
+	switch arch {
+	case "x86_64", "amd64":
+		return "x86_64"
+	case "aarch64", "arm64":
+		return "aarch64"
+	case "riscv64":
+		return "riscv64"
+	case "s390x":
+		return "s390x"
+	case "ppc64le":
+		return "ppc64le"
+	case "mips64":
+		return "mips64"
+	default:
+		return "x86_64" // 默认使用x86_64
+	}
+}
+
+// 根据ELF头判断架构
+func ArchFromELF(file *elf.File) (string, error) {
+	is64 := file.Class == elf.ELFCLASS64
+	switch file.Machine {
+	case elf.EM_X86_64:
+		return "x86_64", nil
+	case elf.EM_AARCH64:
+		return "aarch64", nil
+	case elf.EM_RISCV:
+		if is64 {
+			return "riscv64", nil
+		}
+	case elf.EM_S390:
+		if is64 {
+			return "s390x", nil
+		}
+	case elf.EM_PPC64:
+		if file.Data == elf.ELFDATA2LSB {
+			return "ppc64le", nil
+		}
+	case elf.EM_MIPS:
+		if is64 {
+			return "mips64", nil
+		}
+	}
+	return "", fmt.Errorf("不支持的ELF架构: %v (%v)", file.Machine, file.Class)
+}
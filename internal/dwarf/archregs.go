@@ -0,0 +1,133 @@
+package dwarf
+
+import "fmt"
+
+// ========== 目标架构的寄存器约定 ==========
+// 寄存器名称、pt_regs布局、DWARF寄存器编号、调用约定和特殊寄存器都按目标架构（session.DetectTargetArch）取自这里的表，
+// DWARF变量定位、生成的BPF代码、条件断点、kprobe_events、寄存器窗口和gdb后端使用同一套名称。
+// 表中的寄存器名与pt_regs字段名一致（x86_64带r前缀，RISC-V用ABI名称）。
+// 没有表的架构（s390x、ppc64le、mips64）只生成不依赖寄存器的代码：没有寄存器采集、参数读取和参数条件。
+
+// 一个架构的寄存器约定
+type ArchInfo struct {
+	Name   string            // 归一后的架构名（arm64而不是aarch64）
+	PtRegs []string          // pt_regs开头的寄存器，生成的代码把kprobe的ctx按u64数组访问
+	Key    []string          // 寄存器窗口优先显示的寄存器
+	DWARF  []string          // DWARF寄存器编号对应的寄存器
+	Args   []string          // 传递前6个参数的寄存器
+	Return string            // 返回值寄存器
+	PC     string            // 程序计数器
+	SP     string            // 栈指针
+	FP     string            // 帧指针
+	Link   string            // 返回地址寄存器（x86_64为空，返回地址在栈顶）
+	Kprobe map[string]string // kprobe_events中写法不同的寄存器
+}
+
+var archInfos = map[string]*ArchInfo{
+	"x86_64": {
+		Name: "x86_64",
+		PtRegs: []string{"r15", "r14", "r13", "r12", "rbp", "rbx", "r11", "r10", "r9", "r8",
+			"rax", "rcx", "rdx", "rsi", "rdi", "orig_rax", "rip", "cs", "eflags", "rsp", "ss"},
+		Key: []string{"rip", "rsp", "rbp", "rdi", "rsi", "rdx", "rcx", "rax"},
+		// System V x86-64 ABI的DWARF编号（16是返回地址列）
+		DWARF: []string{"rax", "rdx", "rcx", "rbx", "rsi", "rdi", "rbp", "rsp",
+			"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15", "rip"},
+		Args:   []string{"rdi", "rsi", "rdx", "rcx", "r8", "r9"},
+		Return: "rax",
+		PC:     "rip",
+		SP:     "rsp",
+		FP:     "rbp",
+		Kprobe: map[string]string{"rax": "ax", "rbx": "bx", "rcx": "cx", "rdx": "dx", "rsi": "si", "rdi": "di",
+			"rbp": "bp", "rsp": "sp", "rip": "ip", "eflags": "flags"},
+	},
+	"arm64": {
+		Name: "arm64",
+		PtRegs: []string{"x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7", "x8", "x9",
+			"x10", "x11", "x12", "x13", "x14", "x15", "x16", "x17", "x18", "x19",
+			"x20", "x21", "x22", "x23", "x24", "x25", "x26", "x27", "x28", "x29",
+			"x30", "sp", "pc", "pstate"},
+		Key: []string{"pc", "x30", "sp", "x29", "x0", "x1", "x2", "x3"},
+		DWARF: []string{"x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7", "x8", "x9",
+			"x10", "x11", "x12", "x13", "x14", "x15", "x16", "x17", "x18", "x19",
+			"x20", "x21", "x22", "x23", "x24", "x25", "x26", "x27", "x28", "x29",
+			"x30", "sp"},
+		Args:   []string{"x0", "x1", "x2", "x3", "x4", "x5"},
+		Return: "x0",
+		PC:     "pc",
+		SP:     "sp",
+		FP:     "x29",
+		Link:   "x30",
+	},
+	"riscv64": {
+		Name: "riscv64",
+		PtRegs: []string{"pc", "ra", "sp", "gp", "tp", "t0", "t1", "t2", "s0", "s1",
+			"a0", "a1", "a2", "a3", "a4", "a5", "a6", "a7",
+			"s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9", "s10", "s11",
+			"t3", "t4", "t5", "t6"},
+		Key: []string{"pc", "ra", "sp", "s0", "a0", "a1", "a2", "a3"},
+		DWARF: []string{"zero", "ra", "sp", "gp", "tp", "t0", "t1", "t2",
+			"s0", "s1", "a0", "a1", "a2", "a3", "a4", "a5",
+			"a6", "a7", "s2", "s3", "s4", "s5", "s6", "s7",
+			"s8", "s9", "s10", "s11", "t3", "t4", "t5", "t6"},
+		Args:   []string{"a0", "a1", "a2", "a3", "a4", "a5"},
+		Return: "a0",
+		PC:     "pc",
+		SP:     "sp",
+		FP:     "s0",
+		Link:   "ra",
+		Kprobe: map[string]string{"pc": "epc"},
+	},
+}
+
+// 架构名称归一（aarch64 → arm64）
+func RegsArch(arch string) string {
+	if arch == "aarch64" {
+		return "arm64"
+	}
+	return arch
+}
+
+// 目标架构的寄存器约定，不支持的架构返回nil（以下方法都接受nil）
+func TargetArchInfo(arch string) *ArchInfo {
+	return archInfos[RegsArch(arch)]
+}
+
+// 寄存器在pt_regs中的下标（找不到时为-1）
+func (a *ArchInfo) index(reg string) int {
+	if a == nil {
+		return -1
+	}
+	for i, name := range a.PtRegs {
+		if name == reg {
+			return i
+		}
+	}
+	return -1
+}
+
+// 生成的BPF代码中读取寄存器的表达式，寄存器不在pt_regs中时返回空
+func (a *ArchInfo) CtxRegister(reg string) string {
+	index := a.index(reg)
+	if index < 0 {
+		return ""
+	}
+	return fmt.Sprintf("((u64 *)ctx)[%d]", index)
+}
+
+// DWARF寄存器编号对应的寄存器名称
+func (a *ArchInfo) dwarfName(regNum int) string {
+	if a != nil && regNum >= 0 && regNum < len(a.DWARF) {
+		return a.DWARF[regNum]
+	}
+	return fmt.Sprintf("reg%d", regNum)
+}
+
+// kprobe_events中的寄存器写法（x86_64用不带r前缀的名称）
+func (a *ArchInfo) KprobeName(reg string) string {
+	if a != nil {
+		if name, ok := a.Kprobe[reg]; ok {
+			return name
+		}
+	}
+	return reg
+}
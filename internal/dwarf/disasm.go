@@ -0,0 +1,48 @@
+package dwarf
+
+// ========== 反汇编视图 ==========
+
+// 按名称查找有地址范围的函数
+func (r *LineResolver) LineFunction(name string) *lineFunc {
+	for i := range r.funcs {
+		if r.funcs[i].name == name {
+			return &r.funcs[i]
+		}
+	}
+	return nil
+}
+
+// 函数内各地址对应的源码行
+// .ko中各段都从0开始，同一编译单元里不同段的行可能落在相同地址上，
+// 优先取函数所在文件中声明行之后最近的一行
+func (r *LineResolver) FunctionRows(fn *lineFunc) map[uint64]LineRow {
+	rows := make(map[uint64]LineRow)
+	for _, row := range r.rows {
+		if row.cu != fn.cu {
+			continue
+		}
+		inside := false
+		for _, rg := range fn.Ranges {
+			if row.Addr >= rg[0] && row.Addr < rg[1] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			continue
+		}
+		prev, seen := rows[row.Addr]
+		if !seen || disasmRowScore(fn, row) < disasmRowScore(fn, prev) {
+			rows[row.Addr] = row
+		}
+	}
+	return rows
+}
+
+// 行与函数的距离（越小越可能属于该函数）
+func disasmRowScore(fn *lineFunc, row LineRow) int {
+	if fn.file == "" || !sameSourceFile(row.File, fn.file) || row.Line < fn.declLine {
+		return 1 << 30
+	}
+	return row.Line - fn.declLine
+}
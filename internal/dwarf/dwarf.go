@@ -0,0 +1,410 @@
+package dwarf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"path/filepath"
+	"hash/crc32"
+	"io/ioutil"
+	"debug/dwarf"
+	"debug/elf"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// 从DWARF信息中解析变量（更高级的实现）
+func ParseVariablesFromDWARF(filePath string, lineNumber int) []string {
+	// 这里可以实现真正的DWARF解析
+	// 暂时返回空，因为需要复杂的DWARF解析逻辑
+	return nil
+}
+
+// 解析DWARF调试信息获取局部变量位置（module为编译好的.ko，按断点的函数和偏移求值）
+func ParseDWARFVariableLocations(module, arch string, bp project.Breakpoint, varNames []string) map[string]project.VariableLocation {
+	locations := make(map[string]project.VariableLocation)
+	
+	// 尝试真正的DWARF解析
+	if realLocations := ParseRealDWARF(module, bp, varNames); len(realLocations) > 0 {
+		return realLocations
+	}
+	
+	// 回退到模式匹配（保持向后兼容），栈变量相对帧指针
+	commonLocations := map[string]project.VariableLocation{
+		"local_var": {
+			Name:        "local_var",
+			Type:        "stack",
+			StackOffset: -8,
+			Size:        4,
+		},
+		"temp": {
+			Name:        "temp",
+			Type:        "stack",
+			StackOffset: -16,
+			Size:        8,
+		},
+		"i": {
+			Name:        "i",
+			Type:        "stack",
+			StackOffset: -4,
+			Size:        4,
+		},
+		"len": {
+			Name:        "len",
+			Type:        "stack", 
+			StackOffset: -12,
+			Size:        4,
+		},
+	}
+	// 寄存器中的常见变量按目标架构的调用约定猜测
+	if info := TargetArchInfo(arch); info != nil {
+		commonLocations["counter"] = project.VariableLocation{Name: "counter", Type: "register", Register: info.Return, Size: 4}
+		commonLocations["ret"] = project.VariableLocation{Name: "ret", Type: "register", Register: info.Return, Size: 8}
+		commonLocations["ptr"] = project.VariableLocation{Name: "ptr", Type: "register", Register: info.Args[0], Size: 8}
+		commonLocations["addr"] = project.VariableLocation{Name: "addr", Type: "register", Register: info.Args[1], Size: 8}
+	}
+	
+	// 返回请求的变量位置
+	for _, varName := range varNames {
+		if loc, exists := commonLocations[varName]; exists {
+			locations[varName] = loc
+		}
+	}
+	
+	return locations
+}
+
+// 真正的DWARF解析实现：在断点所在函数中按断点地址（函数入口 + 探针偏移）求值变量的位置
+func ParseRealDWARF(binaryPath string, bp project.Breakpoint, varNames []string) map[string]project.VariableLocation {
+	locations := make(map[string]project.VariableLocation)
+	
+	// 检查是否为ELF文件并且存在
+	if binaryPath == "" || bp.Function == "" {
+		return locations
+	}
+	if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
+		return locations
+	}
+	
+	// 使用Go标准库解析DWARF（支持分离的调试文件和压缩的调试段）
+	file, _, err := OpenDebugELF(binaryPath)
+	if err != nil {
+		return locations
+	}
+	defer file.Close()
+	
+	// 获取DWARF数据
+	dwarfData, err := file.DWARF()
+	if err != nil {
+		return locations
+	}
+	
+	// 寄存器编号的含义取决于目标架构，以ELF头为准
+	arch, err := ArchFromELF(file)
+	if err != nil {
+		arch = DetectCurrentArch()
+	}
+	locator := newDwarfLocator(file, dwarfData)
+	
+	// 遍历DWARF编译单元，找到断点所在函数的独立副本（有地址范围的那一个）
+	reader := dwarfData.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			locator.setUnit(entry)
+			continue
+		case dwarf.TagSubprogram:
+			name, _, _ := subprogramDecl(dwarfData, entry, nil)
+			ranges, err := dwarfData.Ranges(entry)
+			if name == bp.Function && err == nil && len(ranges) > 0 {
+				return parseFunctionVariables(dwarfData, locator, reader, entry, ranges, bp.Offset, varNames, arch)
+			}
+		}
+		reader.SkipChildren()
+	}
+	
+	return locations
+}
+
+// 解析函数内的变量：只看包含断点地址的词法块，内层块中的同名变量覆盖外层的
+func parseFunctionVariables(dwarfData *dwarf.Data, locator *dwarfLocator, reader *dwarf.Reader, funcEntry *dwarf.Entry, ranges [][2]uint64, offset uint64, varNames []string, arch string) map[string]project.VariableLocation {
+	locations := make(map[string]project.VariableLocation)
+	
+	lowPC, ok := funcEntry.Val(dwarf.AttrLowpc).(uint64)
+	if !ok {
+		lowPC = ranges[0][0]
+	}
+	pc := lowPC + offset
+	
+	// 帧基址只在用到DW_OP_fbreg时求值，一个函数只求值一次
+	var frameBase dwarfLocValue
+	var frameErr error
+	frameDone := false
+	frameBaseAt := func() (dwarfLocValue, error) {
+		if !frameDone {
+			frameDone = true
+			var expr []byte
+			if expr, frameErr = locator.expression(funcEntry, dwarf.AttrFrameBase, pc); frameErr == nil {
+				frameBase, frameErr = evalFrameBase(expr, func() (dwarfLocValue, error) {
+					return locator.cfaAt(pc, lowPC)
+				})
+			}
+		}
+		return frameBase, frameErr
+	}
+	
+	wanted := make(map[string]bool)
+	for _, name := range varNames {
+		wanted[name] = true
+	}
+	
+	if !funcEntry.Children {
+		return locations
+	}
+	for depth := 1; depth > 0; {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		switch entry.Tag {
+		case 0:
+			depth--
+			continue
+		case dwarf.TagLexDwarfBlock:
+			if !dwarfRangesContain(dwarfData, entry, pc) {
+				reader.SkipChildren()
+				continue
+			}
+		case dwarf.TagInlinedSubroutine, dwarf.TagSubprogram:
+			// 内联展开的函数中的变量属于被内联的函数
+			reader.SkipChildren()
+			continue
+		case dwarf.TagVariable, dwarf.TagFormalParameter:
+			if varLoc := parseVariableEntry(dwarfData, locator, entry, wanted, pc, frameBaseAt, arch); varLoc != nil {
+				locations[varLoc.Name] = *varLoc
+			}
+		}
+		if entry.Children {
+			depth++
+		}
+	}
+	
+	return locations
+}
+
+// 地址是否在DIE的地址范围内（没有地址范围的块视为包含）
+func dwarfRangesContain(dwarfData *dwarf.Data, entry *dwarf.Entry, pc uint64) bool {
+	ranges, err := dwarfData.Ranges(entry)
+	if err != nil || len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if pc >= r[0] && pc < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析单个变量entry（优化编译的变量只有 DW_AT_abstract_origin，名称和类型在抽象实例中）
+func parseVariableEntry(dwarfData *dwarf.Data, locator *dwarfLocator, entry *dwarf.Entry, wanted map[string]bool, pc uint64, frameBase func() (dwarfLocValue, error), arch string) *project.VariableLocation {
+	varName, _, _ := subprogramDecl(dwarfData, entry, nil)
+	if varName == "" || !wanted[varName] {
+		return nil
+	}
+	
+	// 获取变量在断点处的位置
+	expr, err := locator.expression(entry, dwarf.AttrLocation, pc)
+	if err != nil {
+		return nil
+	}
+	value, err := evalLocationExpr(expr, frameBase)
+	if err != nil {
+		return nil
+	}
+	
+	location := &project.VariableLocation{
+		Name:     varName,
+		Register: TargetArchInfo(arch).dwarfName(value.reg),
+		Size:     dwarfVariableSize(dwarfData, entry),
+	}
+	if value.inReg {
+		location.Type = "register"
+	} else {
+		location.Type = "stack"
+		location.StackOffset = int(value.offset)
+	}
+	return location
+}
+
+// 变量类型的大小（找不到类型或大于8字节时按8字节读取）
+func dwarfVariableSize(dwarfData *dwarf.Data, entry *dwarf.Entry) int {
+	typeOffset, ok := entry.Val(dwarf.AttrType).(dwarf.Offset)
+	if !ok {
+		origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			return 8
+		}
+		reader := dwarfData.Reader()
+		reader.Seek(origin)
+		if abstract, err := reader.Next(); err == nil && abstract != nil {
+			typeOffset, ok = abstract.Val(dwarf.AttrType).(dwarf.Offset)
+		}
+		if !ok {
+			return 8
+		}
+	}
+	typ, err := dwarfData.Type(typeOffset)
+	if err != nil {
+		return 8
+	}
+	if size := typ.Size(); size > 0 && size <= 8 {
+		return int(size)
+	}
+	return 8
+}
+
+// ========== 分离调试信息查找 ==========
+
+// 全局调试信息目录（发行版的 -dbg/-debuginfo 包安装在这里）
+var debugInfoDirs = []string{"/usr/lib/debug"}
+
+// 检查ELF是否包含DWARF调试信息（包括压缩的 .zdebug_info）
+func HasDebugInfo(file *elf.File) bool {
+	return file.Section(".debug_info") != nil || file.Section(".zdebug_info") != nil
+}
+
+// 读取 .note.gnu.build-id 中的构建ID（十六进制字符串）
+func readBuildID(file *elf.File) string {
+	section := file.Section(".note.gnu.build-id")
+	if section == nil {
+		return ""
+	}
+	data, err := section.Data()
+	if err != nil || len(data) < 16 {
+		return ""
+	}
+
+	// ELF note格式: namesz(4) descsz(4) type(4) name(对齐到4) desc
+	order := file.ByteOrder
+	nameSize := order.Uint32(data[0:4])
+	descSize := order.Uint32(data[4:8])
+	descStart := 12 + (nameSize+3)&^3
+	if uint32(len(data)) < descStart+descSize {
+		return ""
+	}
+	return fmt.Sprintf("%x", data[descStart:descStart+descSize])
+}
+
+// 读取 .gnu_debuglink 中的调试文件名和CRC32
+func readDebugLink(file *elf.File) (string, uint32) {
+	section := file.Section(".gnu_debuglink")
+	if section == nil {
+		return "", 0
+	}
+	data, err := section.Data()
+	if err != nil {
+		return "", 0
+	}
+
+	// 格式: 以NUL结尾的文件名，填充到4字节对齐，之后是CRC32
+	nameEnd := strings.IndexByte(string(data), 0)
+	if nameEnd <= 0 {
+		return "", 0
+	}
+	crcStart := (nameEnd + 4) &^ 3
+	if len(data) < crcStart+4 {
+		return string(data[:nameEnd]), 0
+	}
+	return string(data[:nameEnd]), file.ByteOrder.Uint32(data[crcStart : crcStart+4])
+}
+
+// 计算文件的CRC32（与 .gnu_debuglink 中的校验值比较）
+func fileCRC32(path string) (uint32, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return crc32.ChecksumIEEE(data), nil
+}
+
+// 根据build-id和debuglink查找分离的调试文件
+func findSeparateDebugFile(binaryPath string, file *elf.File) string {
+	// 1. build-id: /usr/lib/debug/.build-id/ab/cdef....debug
+	if buildID := readBuildID(file); len(buildID) > 2 {
+		for _, dir := range debugInfoDirs {
+			path := filepath.Join(dir, ".build-id", buildID[:2], buildID[2:]+".debug")
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+
+	// 2. .gnu_debuglink: 与gdb相同的搜索顺序
+	name, crc := readDebugLink(file)
+	if name == "" {
+		return ""
+	}
+	absPath, err := filepath.Abs(binaryPath)
+	if err != nil {
+		absPath = binaryPath
+	}
+	binDir := filepath.Dir(absPath)
+	candidates := []string{
+		filepath.Join(binDir, name),
+		filepath.Join(binDir, ".debug", name),
+	}
+	for _, dir := range debugInfoDirs {
+		candidates = append(candidates, filepath.Join(dir, binDir, name))
+	}
+	for _, path := range candidates {
+		if path == absPath {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		// CRC不匹配说明调试文件与二进制不是同一次构建
+		if crc != 0 {
+			if sum, err := fileCRC32(path); err != nil || sum != crc {
+				continue
+			}
+		}
+		return path
+	}
+
+	return ""
+}
+
+// 打开包含DWARF信息的ELF文件：优先使用内嵌调试信息，否则查找分离的调试文件
+// 压缩的调试段（.zdebug_* 和 SHF_COMPRESSED）由 debug/elf 自动解压
+func OpenDebugELF(binaryPath string) (*elf.File, string, error) {
+	file, err := elf.Open(binaryPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if HasDebugInfo(file) {
+		return file, binaryPath, nil
+	}
+
+	debugPath := findSeparateDebugFile(binaryPath, file)
+	file.Close()
+	if debugPath == "" {
+		return nil, "", errcode.Errorf(errcode.ErrNoDebugInfo, "%s 不包含调试信息，且未找到分离的调试文件", binaryPath)
+	}
+
+	debugFile, err := elf.Open(debugPath)
+	if err != nil {
+		return nil, "", err
+	}
+	if !HasDebugInfo(debugFile) {
+		debugFile.Close()
+		return nil, "", errcode.Errorf(errcode.ErrNoDebugInfo, "调试文件 %s 不包含DWARF信息", debugPath)
+	}
+	return debugFile, debugPath, nil
+}
@@ -0,0 +1,660 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== DWARF位置表达式 ==========
+// 变量的位置来自模块DWARF中的 DW_AT_location，按断点处的指令地址（函数入口 + 探针偏移）求值：
+//   DW_OP_reg0..31 / DW_OP_regx            变量在寄存器中
+//   DW_OP_breg0..31 / DW_OP_bregx          变量在 寄存器+偏移 处的内存中
+//   DW_OP_fbreg                            变量在 帧基址+偏移 处，帧基址来自函数的 DW_AT_frame_base
+// 优化编译的变量在不同指令区间位于不同位置，DW_AT_location 是位置列表：
+// DWARF 4 在 .debug_loc 中，DWARF 5 在 .debug_loclists 中（地址可能是 .debug_addr 的下标），取包含断点地址的一项。
+// 帧基址通常是 DW_OP_call_frame_cfa，按 .debug_frame 中断点地址处的CFA规则（寄存器+偏移）换算，
+// 没有帧指针的优化编译中栈变量也能得到以sp为基址的正确偏移。
+// .ko是可重定位文件，这些段中的地址和段内偏移要按重定位项修正（debug/elf 只修正它自己读取的段）。
+// 被优化成常量（DW_OP_stack_value）、只剩入口值（DW_OP_entry_value）或拆成多段的变量不读取。
+
+// 求值结果：inReg为true时变量在寄存器reg中，否则变量在 寄存器reg的值+offset 处的内存中
+type dwarfLocValue struct {
+	inReg  bool
+	reg    int
+	offset int64
+}
+
+// 一个模块中与变量位置相关的DWARF段
+type dwarfLocator struct {
+	data     *dwarf.Data
+	order    binary.ByteOrder
+	loc      []byte // .debug_loc（DWARF 4）
+	loclists []byte // .debug_loclists（DWARF 5）
+	addr     []byte // .debug_addr（DWARF 5）
+	frame    []byte // .debug_frame
+
+	// 当前编译单元
+	cuBase       uint64
+	addrBase     uint64
+	loclistsBase uint64
+}
+
+// 读取模块中的位置列表和调用帧信息段
+func newDwarfLocator(file *elf.File, data *dwarf.Data) *dwarfLocator {
+	return &dwarfLocator{
+		data:     data,
+		order:    file.ByteOrder,
+		loc:      relocatedDebugSection(file, ".debug_loc"),
+		loclists: relocatedDebugSection(file, ".debug_loclists"),
+		addr:     relocatedDebugSection(file, ".debug_addr"),
+		frame:    relocatedDebugSection(file, ".debug_frame"),
+	}
+}
+
+// 切换到变量所在的编译单元（位置列表的基地址和DWARF 5的各段基址）
+func (l *dwarfLocator) setUnit(cu *dwarf.Entry) {
+	l.cuBase, _ = cu.Val(dwarf.AttrLowpc).(uint64)
+	base, _ := cu.Val(dwarf.AttrAddrBase).(int64)
+	l.addrBase = uint64(base)
+	base, _ = cu.Val(dwarf.AttrLoclistsBase).(int64)
+	l.loclistsBase = uint64(base)
+}
+
+// ---------- LEB128 ----------
+
+// 解码无符号LEB128，返回值和占用的字节数（数据不完整时字节数为0）
+func readULEB128(b []byte) (uint64, int) {
+	var value uint64
+	var shift uint
+	for i, c := range b {
+		if shift < 64 {
+			value |= uint64(c&0x7f) << shift
+		}
+		shift += 7
+		if c&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// 解码有符号LEB128，返回值和占用的字节数（数据不完整时字节数为0）
+func readSLEB128(b []byte) (int64, int) {
+	var value int64
+	var shift uint
+	for i, c := range b {
+		if shift < 64 {
+			value |= int64(c&0x7f) << shift
+		}
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				value |= -1 << shift
+			}
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// 顺序读取DWARF数据（越界时err被设置，之后的读取都返回0）
+type dwarfBuf struct {
+	data  []byte
+	off   int
+	order binary.ByteOrder
+	err   error
+}
+
+func (b *dwarfBuf) need(n int) bool {
+	if b.err == nil && (n < 0 || b.off+n > len(b.data)) {
+		b.err = fmt.Errorf("DWARF数据在偏移 %d 处被截断", b.off)
+	}
+	return b.err == nil
+}
+
+func (b *dwarfBuf) u8() uint8 {
+	if !b.need(1) {
+		return 0
+	}
+	b.off++
+	return b.data[b.off-1]
+}
+
+func (b *dwarfBuf) u16() uint16 {
+	if !b.need(2) {
+		return 0
+	}
+	b.off += 2
+	return b.order.Uint16(b.data[b.off-2:])
+}
+
+func (b *dwarfBuf) u32() uint32 {
+	if !b.need(4) {
+		return 0
+	}
+	b.off += 4
+	return b.order.Uint32(b.data[b.off-4:])
+}
+
+func (b *dwarfBuf) u64() uint64 {
+	if !b.need(8) {
+		return 0
+	}
+	b.off += 8
+	return b.order.Uint64(b.data[b.off-8:])
+}
+
+func (b *dwarfBuf) uleb() uint64 {
+	if b.err != nil {
+		return 0
+	}
+	v, n := readULEB128(b.data[b.off:])
+	if n == 0 {
+		b.need(len(b.data) + 1)
+	}
+	b.off += n
+	return v
+}
+
+func (b *dwarfBuf) sleb() int64 {
+	if b.err != nil {
+		return 0
+	}
+	v, n := readSLEB128(b.data[b.off:])
+	if n == 0 {
+		b.need(len(b.data) + 1)
+	}
+	b.off += n
+	return v
+}
+
+func (b *dwarfBuf) bytes(n int) []byte {
+	if !b.need(n) {
+		return nil
+	}
+	b.off += n
+	return b.data[b.off-n : b.off]
+}
+
+// ---------- 位置表达式 ----------
+
+// DWARF操作码
+const (
+	dwOpAddr          = 0x03
+	dwOpPlusUconst    = 0x23
+	dwOpReg0          = 0x50
+	dwOpReg31         = 0x6f
+	dwOpBreg0         = 0x70
+	dwOpBreg31        = 0x8f
+	dwOpRegx          = 0x90
+	dwOpFbreg         = 0x91
+	dwOpBregx         = 0x92
+	dwOpPiece         = 0x93
+	dwOpCallFrameCFA  = 0x9c
+	dwOpStackValue    = 0x9f
+	dwOpEntryValue    = 0xa3
+	dwOpGNUEntryValue = 0xf3
+)
+
+// 求值变量的位置表达式；frameBase为nil时不能求值DW_OP_fbreg
+func evalLocationExpr(expr []byte, frameBase func() (dwarfLocValue, error)) (dwarfLocValue, error) {
+	b := &dwarfBuf{data: expr}
+	var result dwarfLocValue
+	have := false
+	for b.off < len(expr) && b.err == nil {
+		op := b.u8()
+		switch {
+		case op >= dwOpReg0 && op <= dwOpReg31:
+			result, have = dwarfLocValue{inReg: true, reg: int(op - dwOpReg0)}, true
+		case op == dwOpRegx:
+			result, have = dwarfLocValue{inReg: true, reg: int(b.uleb())}, true
+		case op >= dwOpBreg0 && op <= dwOpBreg31:
+			result, have = dwarfLocValue{reg: int(op - dwOpBreg0), offset: b.sleb()}, true
+		case op == dwOpBregx:
+			reg := int(b.uleb())
+			result, have = dwarfLocValue{reg: reg, offset: b.sleb()}, true
+		case op == dwOpFbreg:
+			offset := b.sleb()
+			if frameBase == nil {
+				return result, fmt.Errorf("函数没有帧基址")
+			}
+			base, err := frameBase()
+			if err != nil {
+				return result, err
+			}
+			result, have = dwarfLocValue{reg: base.reg, offset: base.offset + offset}, true
+		case op == dwOpPlusUconst && have && !result.inReg:
+			result.offset += int64(b.uleb())
+		case op == dwOpPiece:
+			// 拆成多段的变量（如寄存器对中的结构体）只取第一段
+			if !have {
+				return result, fmt.Errorf("变量的第一段被优化掉")
+			}
+			return result, nil
+		case op == dwOpStackValue:
+			return result, fmt.Errorf("变量被优化为计算值")
+		case op == dwOpEntryValue || op == dwOpGNUEntryValue:
+			return result, fmt.Errorf("变量只剩函数入口时的值")
+		case op == dwOpAddr:
+			return result, fmt.Errorf("静态变量请用全局变量监视")
+		default:
+			return result, fmt.Errorf("不支持的DWARF操作 0x%02x", op)
+		}
+	}
+	if b.err != nil {
+		return result, b.err
+	}
+	if !have {
+		return result, fmt.Errorf("变量被优化掉")
+	}
+	return result, nil
+}
+
+// 求值帧基址表达式，结果是地址 寄存器+偏移（DW_OP_regN 表示寄存器的值本身）
+func evalFrameBase(expr []byte, cfa func() (dwarfLocValue, error)) (dwarfLocValue, error) {
+	if len(expr) == 1 && expr[0] == dwOpCallFrameCFA {
+		return cfa()
+	}
+	value, err := evalLocationExpr(expr, nil)
+	if err != nil {
+		return value, err
+	}
+	value.inReg = false
+	return value, nil
+}
+
+// ---------- 位置列表 ----------
+
+// 属性在pc处的位置表达式：单个表达式直接返回，位置列表取包含pc的一项
+func (l *dwarfLocator) expression(entry *dwarf.Entry, attr dwarf.Attr, pc uint64) ([]byte, error) {
+	field := entry.AttrField(attr)
+	if field == nil {
+		return nil, fmt.Errorf("没有位置信息（被优化掉）")
+	}
+	switch field.Class {
+	case dwarf.ClassExprLoc, dwarf.ClassBlock:
+		expr, _ := field.Val.([]byte)
+		return expr, nil
+	case dwarf.ClassLocListPtr:
+		off, _ := field.Val.(int64)
+		// DWARF 5 的 sec_offset 指向 .debug_loclists
+		if len(l.loc) == 0 && len(l.loclists) > 0 {
+			return l.loclistsExpr(uint64(off), pc)
+		}
+		return l.locExpr(uint64(off), pc)
+	case dwarf.ClassLocList:
+		// DW_FORM_loclistx：偏移表中的下标，偏移相对 DW_AT_loclists_base
+		index, _ := field.Val.(uint64)
+		b := &dwarfBuf{data: l.loclists, off: int(l.loclistsBase + index*4), order: l.order}
+		off := b.u32()
+		if b.err != nil {
+			return nil, b.err
+		}
+		return l.loclistsExpr(l.loclistsBase+uint64(off), pc)
+	}
+	return nil, fmt.Errorf("不支持的位置属性类型 %v", field.Class)
+}
+
+// DWARF 4 位置列表：(起始, 结束) 相对编译单元基地址，起始为全1时是基地址选择项
+func (l *dwarfLocator) locExpr(off, pc uint64) ([]byte, error) {
+	b := &dwarfBuf{data: l.loc, off: int(off), order: l.order}
+	base := l.cuBase
+	for b.err == nil {
+		begin, end := b.u64(), b.u64()
+		if begin == 0 && end == 0 {
+			break
+		}
+		if begin == ^uint64(0) {
+			base = end
+			continue
+		}
+		expr := b.bytes(int(b.u16()))
+		if pc >= base+begin && pc < base+end {
+			return expr, b.err
+		}
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	return nil, fmt.Errorf("在断点处没有位置（被优化掉）")
+}
+
+// .debug_addr 中的地址
+func (l *dwarfLocator) addrx(index uint64) uint64 {
+	b := &dwarfBuf{data: l.addr, off: int(l.addrBase + index*8), order: l.order}
+	return b.u64()
+}
+
+// DWARF 5 位置列表（DW_LLE_*）
+func (l *dwarfLocator) loclistsExpr(off, pc uint64) ([]byte, error) {
+	b := &dwarfBuf{data: l.loclists, off: int(off), order: l.order}
+	base := l.cuBase
+	var fallback []byte
+	for b.err == nil {
+		var begin, end uint64
+		bounded := true
+		switch kind := b.u8(); kind {
+		case 0x00: // DW_LLE_end_of_list
+			if fallback != nil {
+				return fallback, nil
+			}
+			return nil, fmt.Errorf("在断点处没有位置（被优化掉）")
+		case 0x01: // DW_LLE_base_addressx
+			base = l.addrx(b.uleb())
+			continue
+		case 0x02: // DW_LLE_startx_endx
+			begin = l.addrx(b.uleb())
+			end = l.addrx(b.uleb())
+		case 0x03: // DW_LLE_startx_length
+			begin = l.addrx(b.uleb())
+			end = begin + b.uleb()
+		case 0x04: // DW_LLE_offset_pair
+			begin = base + b.uleb()
+			end = base + b.uleb()
+		case 0x05: // DW_LLE_default_location
+			bounded = false
+		case 0x06: // DW_LLE_base_address
+			base = b.u64()
+			continue
+		case 0x07: // DW_LLE_start_end
+			begin, end = b.u64(), b.u64()
+		case 0x08: // DW_LLE_start_length
+			begin = b.u64()
+			end = begin + b.uleb()
+		default:
+			return nil, fmt.Errorf("未知的位置列表项 0x%02x", kind)
+		}
+		expr := b.bytes(int(b.uleb()))
+		if !bounded {
+			fallback = expr
+		} else if pc >= begin && pc < end {
+			return expr, b.err
+		}
+	}
+	return nil, b.err
+}
+
+// ---------- 调用帧信息（CFA） ----------
+
+// .debug_frame 中的CIE
+type cieInfo struct {
+	codeAlign    uint64
+	dataAlign    int64
+	instructions []byte
+}
+
+// 解析 .debug_frame 中偏移off处的CIE
+func (l *dwarfLocator) parseCIE(off uint64) (*cieInfo, error) {
+	b := &dwarfBuf{data: l.frame, off: int(off), order: l.order}
+	length := b.u32()
+	end := b.off + int(length)
+	if id := b.u32(); b.err == nil && id != 0xffffffff {
+		return nil, fmt.Errorf("偏移 %d 处不是CIE", off)
+	}
+	version := b.u8()
+	augmentation := ""
+	for c := b.u8(); c != 0 && b.err == nil; c = b.u8() {
+		augmentation += string(c)
+	}
+	if augmentation != "" {
+		return nil, fmt.Errorf("不支持的CIE扩展 %q", augmentation)
+	}
+	if version >= 4 {
+		b.u8() // address_size
+		b.u8() // segment_selector_size
+	}
+	cie := &cieInfo{codeAlign: b.uleb(), dataAlign: b.sleb()}
+	if version == 1 {
+		b.u8()
+	} else {
+		b.uleb()
+	}
+	if b.err != nil || end > len(l.frame) || end < b.off {
+		return nil, fmt.Errorf("CIE格式错误")
+	}
+	cie.instructions = l.frame[b.off:end]
+	return cie, nil
+}
+
+// pc处的CFA规则（寄存器+偏移）；函数在.ko的不同段中地址可能重叠，优先取起始地址为funcLow的FDE
+func (l *dwarfLocator) cfaAt(pc, funcLow uint64) (dwarfLocValue, error) {
+	if len(l.frame) == 0 {
+		return dwarfLocValue{}, errcode.Errorf(errcode.ErrNoDebugInfo, "模块没有 .debug_frame，无法确定帧基址")
+	}
+	var bestCIE uint64
+	var bestStart uint64
+	var bestInstr []byte
+	found := false
+	b := &dwarfBuf{data: l.frame, order: l.order}
+	for b.off+4 <= len(l.frame) && b.err == nil {
+		length := b.u32()
+		if length == 0xffffffff {
+			return dwarfLocValue{}, fmt.Errorf("不支持64位DWARF的 .debug_frame")
+		}
+		next := b.off + int(length)
+		id := b.u32()
+		if id != 0xffffffff {
+			start, size := b.u64(), b.u64()
+			if b.err == nil && next <= len(l.frame) && pc >= start && pc < start+size && (!found || start == funcLow) {
+				bestCIE, bestStart, bestInstr, found = uint64(id), start, l.frame[b.off:next], true
+			}
+		}
+		b.off = next
+	}
+	if !found {
+		return dwarfLocValue{}, fmt.Errorf(".debug_frame 中没有地址 0x%x", pc)
+	}
+	cie, err := l.parseCIE(bestCIE)
+	if err != nil {
+		return dwarfLocValue{}, err
+	}
+	state := &cfaState{loc: bestStart}
+	if err := state.run(cie, cie.instructions, pc, l.order); err != nil {
+		return dwarfLocValue{}, err
+	}
+	if err := state.run(cie, bestInstr, pc, l.order); err != nil {
+		return dwarfLocValue{}, err
+	}
+	return state.cfa, nil
+}
+
+// 执行CFA指令时的状态（只跟踪CFA，不跟踪其他寄存器的保存位置）
+type cfaState struct {
+	loc   uint64
+	cfa   dwarfLocValue
+	stack []dwarfLocValue
+}
+
+// 执行CFA指令直到地址超过pc
+func (s *cfaState) run(cie *cieInfo, instructions []byte, pc uint64, order binary.ByteOrder) error {
+	b := &dwarfBuf{data: instructions, order: order}
+	advance := func(delta uint64) bool {
+		s.loc += delta * cie.codeAlign
+		return s.loc > pc
+	}
+	for b.off < len(instructions) && b.err == nil {
+		op := b.u8()
+		switch op & 0xc0 {
+		case 0x40: // DW_CFA_advance_loc
+			if advance(uint64(op & 0x3f)) {
+				return nil
+			}
+			continue
+		case 0x80: // DW_CFA_offset
+			b.uleb()
+			continue
+		case 0xc0: // DW_CFA_restore
+			continue
+		}
+		switch op {
+		case 0x00: // DW_CFA_nop
+		case 0x01: // DW_CFA_set_loc
+			if s.loc = b.u64(); s.loc > pc {
+				return nil
+			}
+		case 0x02: // DW_CFA_advance_loc1
+			if advance(uint64(b.u8())) {
+				return nil
+			}
+		case 0x03: // DW_CFA_advance_loc2
+			if advance(uint64(b.u16())) {
+				return nil
+			}
+		case 0x04: // DW_CFA_advance_loc4
+			if advance(uint64(b.u32())) {
+				return nil
+			}
+		case 0x05, 0x09, 0x14, 0x2f: // offset_extended, register, val_offset, GNU_negative_offset_extended
+			b.uleb()
+			b.uleb()
+		case 0x06, 0x07, 0x08, 0x2e: // restore_extended, undefined, same_value, GNU_args_size
+			b.uleb()
+		case 0x0a: // DW_CFA_remember_state
+			s.stack = append(s.stack, s.cfa)
+		case 0x0b: // DW_CFA_restore_state
+			if len(s.stack) > 0 {
+				s.cfa = s.stack[len(s.stack)-1]
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+		case 0x0c: // DW_CFA_def_cfa
+			s.cfa.reg = int(b.uleb())
+			s.cfa.offset = int64(b.uleb())
+		case 0x0d: // DW_CFA_def_cfa_register
+			s.cfa.reg = int(b.uleb())
+		case 0x0e: // DW_CFA_def_cfa_offset
+			s.cfa.offset = int64(b.uleb())
+		case 0x0f: // DW_CFA_def_cfa_expression
+			return fmt.Errorf("CFA由表达式定义，无法换算帧基址")
+		case 0x10, 0x16: // expression, val_expression
+			b.uleb()
+			b.bytes(int(b.uleb()))
+		case 0x11, 0x15: // offset_extended_sf, val_offset_sf
+			b.uleb()
+			b.sleb()
+		case 0x12: // DW_CFA_def_cfa_sf
+			s.cfa.reg = int(b.uleb())
+			s.cfa.offset = b.sleb() * cie.dataAlign
+		case 0x13: // DW_CFA_def_cfa_offset_sf
+			s.cfa.offset = b.sleb() * cie.dataAlign
+		case 0x2d: // DW_CFA_GNU_window_save / AArch64 negate_ra_state
+		default:
+			return fmt.Errorf("不支持的CFA指令 0x%02x", op)
+		}
+	}
+	return b.err
+}
+
+// ---------- 重定位 ----------
+
+// 读取调试段；.ko（ET_REL）按对应的 .rela 段修正其中的地址和段内偏移
+func relocatedDebugSection(file *elf.File, name string) []byte {
+	section := file.Section(name)
+	if section == nil {
+		return nil
+	}
+	data, err := section.Data()
+	if err != nil {
+		return nil
+	}
+	if file.Type != elf.ET_REL || file.Class != elf.ELFCLASS64 {
+		return data
+	}
+	symbols, _ := file.Symbols()
+	for _, rel := range file.Sections {
+		if rel.Type != elf.SHT_RELA || int(rel.Info) >= len(file.Sections) || file.Sections[rel.Info] != section {
+			continue
+		}
+		rels, err := rel.Data()
+		if err != nil {
+			continue
+		}
+		for i := 0; i+24 <= len(rels); i += 24 {
+			off := file.ByteOrder.Uint64(rels[i:])
+			info := file.ByteOrder.Uint64(rels[i+8:])
+			addend := file.ByteOrder.Uint64(rels[i+16:])
+			var value uint64
+			// Symbols() 不含0号空符号
+			if index := info >> 32; index > 0 && int(index) <= len(symbols) {
+				value = symbols[index-1].Value
+			}
+			applyDebugRelocation(file, data, off, uint32(info), value+addend)
+		}
+	}
+	return data
+}
+
+// 应用一个重定位项（只处理调试段中出现的绝对地址和RISC-V的差值重定位）
+func applyDebugRelocation(file *elf.File, data []byte, off uint64, typ uint32, value uint64) {
+	order := file.ByteOrder
+	put := func(width int, op func(old uint64) uint64) {
+		if off+uint64(width) > uint64(len(data)) {
+			return
+		}
+		p := data[off:]
+		switch width {
+		case 1:
+			p[0] = byte(op(uint64(p[0])))
+		case 2:
+			order.PutUint16(p, uint16(op(uint64(order.Uint16(p)))))
+		case 4:
+			order.PutUint32(p, uint32(op(uint64(order.Uint32(p)))))
+		case 8:
+			order.PutUint64(p, op(order.Uint64(p)))
+		}
+	}
+	set := func(uint64) uint64 { return value }
+	add := func(old uint64) uint64 { return old + value }
+	sub := func(old uint64) uint64 { return old - value }
+	switch file.Machine {
+	case elf.EM_X86_64:
+		switch elf.R_X86_64(typ) {
+		case elf.R_X86_64_64:
+			put(8, set)
+		case elf.R_X86_64_32, elf.R_X86_64_32S:
+			put(4, set)
+		}
+	case elf.EM_AARCH64:
+		switch elf.R_AARCH64(typ) {
+		case elf.R_AARCH64_ABS64:
+			put(8, set)
+		case elf.R_AARCH64_ABS32:
+			put(4, set)
+		}
+	case elf.EM_RISCV:
+		// 链接器松弛使指令长度在链接前不确定，.debug_frame 等段中的地址差用 ADD/SUB/SET 重定位表示
+		switch elf.R_RISCV(typ) {
+		case elf.R_RISCV_64:
+			put(8, set)
+		case elf.R_RISCV_32, elf.R_RISCV_SET32:
+			put(4, set)
+		case elf.R_RISCV_SET16:
+			put(2, set)
+		case elf.R_RISCV_SET8:
+			put(1, set)
+		case elf.R_RISCV_ADD8:
+			put(1, add)
+		case elf.R_RISCV_ADD16:
+			put(2, add)
+		case elf.R_RISCV_ADD32:
+			put(4, add)
+		case elf.R_RISCV_ADD64:
+			put(8, add)
+		case elf.R_RISCV_SUB8:
+			put(1, sub)
+		case elf.R_RISCV_SUB16:
+			put(2, sub)
+		case elf.R_RISCV_SUB32:
+			put(4, sub)
+		case elf.R_RISCV_SUB64:
+			put(8, sub)
+		case elf.R_RISCV_SET6:
+			put(1, func(old uint64) uint64 { return old&0xc0 | value&0x3f })
+		case elf.R_RISCV_SUB6:
+			put(1, func(old uint64) uint64 { return old&0xc0 | (old-value)&0x3f })
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package dwarf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestReadLEB128(t *testing.T) {
+	unsigned := []struct {
+		in   []byte
+		want uint64
+		n    int
+	}{
+		{[]byte{0x02}, 2, 1},
+		{[]byte{0x7f}, 127, 1},
+		{[]byte{0x80, 0x01}, 128, 2},
+		{[]byte{0xe5, 0x8e, 0x26, 0xff}, 624485, 3}, // 之后的字节不读取
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, ^uint64(0), 10},
+		{[]byte{0x80, 0x80}, 0, 0}, // 不完整
+		{nil, 0, 0},
+	}
+	for _, tt := range unsigned {
+		if got, n := readULEB128(tt.in); got != tt.want || n != tt.n {
+			t.Errorf("readULEB128(% x) = %d, %d, want %d, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+
+	signed := []struct {
+		in   []byte
+		want int64
+		n    int
+	}{
+		{[]byte{0x02}, 2, 1},
+		{[]byte{0x7e}, -2, 1},
+		{[]byte{0xff, 0x00}, 127, 2},
+		{[]byte{0x81, 0x7f}, -127, 2},
+		{[]byte{0x80, 0x7f}, -128, 2},
+		{[]byte{0xc0, 0xbb, 0x78}, -123456, 3},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}, -1 << 63, 10},
+		{[]byte{0xff}, 0, 0}, // 不完整
+	}
+	for _, tt := range signed {
+		if got, n := readSLEB128(tt.in); got != tt.want || n != tt.n {
+			t.Errorf("readSLEB128(% x) = %d, %d, want %d, %d", tt.in, got, n, tt.want, tt.n)
+		}
+	}
+}
+
+func TestEvalLocationExpr(t *testing.T) {
+	// 帧基址：rsp(7)+16
+	frameBase := func() (dwarfLocValue, error) { return dwarfLocValue{reg: 7, offset: 16}, nil }
+	tests := []struct {
+		name    string
+		expr    []byte
+		noFrame bool
+		want    dwarfLocValue
+		wantErr bool
+	}{
+		{"reg5", []byte{dwOpReg0 + 5}, false, dwarfLocValue{inReg: true, reg: 5}, false},
+		{"regx", []byte{dwOpRegx, 0x11}, false, dwarfLocValue{inReg: true, reg: 17}, false},
+		{"breg6 -20", []byte{dwOpBreg0 + 6, 0x6c}, false, dwarfLocValue{reg: 6, offset: -20}, false},
+		{"breg31 +200", []byte{dwOpBreg31, 0xc8, 0x01}, false, dwarfLocValue{reg: 31, offset: 200}, false},
+		{"bregx", []byte{dwOpBregx, 0x20, 0x08}, false, dwarfLocValue{reg: 32, offset: 8}, false},
+		{"fbreg -24", []byte{dwOpFbreg, 0x68}, false, dwarfLocValue{reg: 7, offset: -8}, false},
+		{"fbreg plus_uconst", []byte{dwOpFbreg, 0x08, dwOpPlusUconst, 0x04}, false, dwarfLocValue{reg: 7, offset: 28}, false},
+		{"fbreg without frame base", []byte{dwOpFbreg, 0x08}, true, dwarfLocValue{}, true},
+		{"piece", []byte{dwOpReg0 + 3, dwOpPiece, 0x08, dwOpReg0 + 4, dwOpPiece, 0x08}, false, dwarfLocValue{inReg: true, reg: 3}, false},
+		{"addr", []byte{dwOpAddr, 0, 0x10, 0, 0, 0, 0, 0, 0}, false, dwarfLocValue{}, true},
+		{"stack value", []byte{dwOpBreg0, 0x00, dwOpStackValue}, false, dwarfLocValue{}, true},
+		{"truncated breg", []byte{dwOpBreg0 + 1, 0x80}, false, dwarfLocValue{}, true},
+		{"empty", nil, false, dwarfLocValue{}, true},
+	}
+	for _, tt := range tests {
+		fb := frameBase
+		if tt.noFrame {
+			fb = nil
+		}
+		got, err := evalLocationExpr(tt.expr, fb)
+		if (err != nil) != tt.wantErr || (err == nil && got != tt.want) {
+			t.Errorf("%s: evalLocationExpr = %+v, %v, want %+v (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	failing := func() (dwarfLocValue, error) { return dwarfLocValue{}, errors.New("no CFA") }
+	if _, err := evalLocationExpr([]byte{dwOpFbreg, 0x08}, failing); err == nil {
+		t.Error("fbreg with a failing frame base should fail")
+	}
+	if got, err := evalFrameBase([]byte{dwOpReg0 + 6}, nil); err != nil || got != (dwarfLocValue{reg: 6}) {
+		t.Errorf("evalFrameBase(reg6) = %+v, %v", got, err)
+	}
+}
+
+func TestLocationListLookup(t *testing.T) {
+	le := binary.LittleEndian
+	exprA, exprB := []byte{dwOpReg0 + 5}, []byte{dwOpFbreg, 0x68}
+
+	// DWARF 4：(起始, 结束, 长度, 表达式)，起始全1时切换基地址
+	var loc bytes.Buffer
+	entry := func(begin, end uint64, expr []byte) {
+		binary.Write(&loc, le, begin)
+		binary.Write(&loc, le, end)
+		if expr != nil {
+			binary.Write(&loc, le, uint16(len(expr)))
+			loc.Write(expr)
+		}
+	}
+	loc.Write(make([]byte, 8)) // 列表不从段首开始
+	entry(0x10, 0x20, exprA)
+	entry(^uint64(0), 0x2000, nil)
+	entry(0x00, 0x08, exprB)
+	entry(0, 0, nil)
+	l := &dwarfLocator{order: le, loc: loc.Bytes(), cuBase: 0x1000}
+
+	tests := []struct {
+		pc   uint64
+		want []byte
+	}{
+		{0x1010, exprA},
+		{0x101f, exprA},
+		{0x1020, nil}, // 结束地址不含
+		{0x2004, exprB},
+		{0x0010, nil},
+	}
+	for _, tt := range tests {
+		got, err := l.locExpr(8, tt.pc)
+		if !bytes.Equal(got, tt.want) || (err == nil) != (tt.want != nil) {
+			t.Errorf("locExpr(pc=%#x) = % x, %v, want % x", tt.pc, got, err, tt.want)
+		}
+	}
+
+	// DWARF 5：基地址、偏移对、.debug_addr 下标加长度、默认位置
+	addr := make([]byte, 24)
+	le.PutUint64(addr[8:], 0x3000) // addr_base=8 处的下标0
+	lists := []byte{
+		0x06, 0, 0x10, 0, 0, 0, 0, 0, 0, // DW_LLE_base_address 0x1000
+		0x04, 0x10, 0x20, 1, dwOpReg0 + 5, // DW_LLE_offset_pair [0x1010, 0x1020)
+		0x03, 0x00, 0x10, 2, dwOpFbreg, 0x68, // DW_LLE_startx_length [0x3000, 0x3010)
+		0x05, 1, dwOpReg0 + 1, // DW_LLE_default_location
+		0x00,
+	}
+	l = &dwarfLocator{order: le, loclists: lists, addr: addr, addrBase: 8}
+	tests = []struct {
+		pc   uint64
+		want []byte
+	}{
+		{0x1018, exprA},
+		{0x3008, exprB},
+		{0x3010, []byte{dwOpReg0 + 1}},
+	}
+	for _, tt := range tests {
+		if got, err := l.loclistsExpr(0, tt.pc); err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("loclistsExpr(pc=%#x) = % x, %v, want % x", tt.pc, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 全局变量监视点 ==========
+// watch <symbol> 监视的名称如果是模块中的全局变量，地址从 /proc/kallsyms（远程目标通过ssh）读取，
+// 类型和大小从模块的DWARF信息读取，生成的BPF程序在每个断点探针中用bpf_probe_read_kernel读取它，
+// 以 [VAR-N] 的格式输出，变量窗口显示新旧值的变化。局部变量仍按原来的DWARF位置解析。
+// 地址是生成时的运行时地址，重新加载模块后需要重新执行 vars 和 compile。
+
+// 解析出的全局变量
+type GlobalSymbol struct {
+	Name   string
+	Addr   uint64 // 运行时地址
+	Size   int
+	Signed bool
+	Type   string // C类型名（显示用）
+}
+
+// 从DWARF查找编译单元级别的变量（全局或文件内static），返回类型名、大小和有无符号
+func DWARFGlobalType(binaryPath, name string) (string, int, bool, error) {
+	file, _, err := OpenDebugELF(binaryPath)
+	if err != nil {
+		return "", 0, false, err
+	}
+	defer file.Close()
+	data, err := file.DWARF()
+	if err != nil {
+		return "", 0, false, errcode.Errorf(errcode.ErrNoDebugInfo, "读取DWARF信息失败: %v", err)
+	}
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagCompileUnit {
+			reader.SkipChildren()
+			continue
+		}
+		// 只看编译单元的直接子节点，函数内的局部变量不算
+		for {
+			child, err := reader.Next()
+			if err != nil || child == nil || child.Tag == 0 {
+				break
+			}
+			if child.Children {
+				reader.SkipChildren()
+			}
+			if child.Tag != dwarf.TagVariable {
+				continue
+			}
+			if n, _ := child.Val(dwarf.AttrName).(string); n != name {
+				continue
+			}
+			typeOff, ok := child.Val(dwarf.AttrType).(dwarf.Offset)
+			if !ok {
+				continue
+			}
+			t, err := data.Type(typeOff)
+			if err != nil {
+				return "", 0, false, fmt.Errorf("解析 %s 的类型失败: %v", name, err)
+			}
+			size, signed := scalarLayout(t)
+			if size == 0 {
+				return "", 0, false, fmt.Errorf("%s 的类型 %s 不是整数或指针，无法监视", name, t.String())
+			}
+			return t.String(), size, signed, nil
+		}
+	}
+	return "", 0, false, errcode.Errorf(errcode.ErrNoSymbol, "%s 的DWARF信息中没有全局变量 %s", binaryPath, name)
+}
+
+// 标量类型的大小和有无符号（typedef、const、volatile展开；不是标量时大小为0）
+func scalarLayout(t dwarf.Type) (int, bool) {
+	for i := 0; i < 8; i++ {
+		switch tt := t.(type) {
+		case *dwarf.TypedefType:
+			t = tt.Type
+		case *dwarf.QualType:
+			t = tt.Type
+		case *dwarf.IntType, *dwarf.CharType:
+			return int(t.Size()), true
+		case *dwarf.EnumType:
+			return int(t.Size()), true
+		case *dwarf.UintType, *dwarf.UcharType, *dwarf.BoolType, *dwarf.PtrType:
+			return int(t.Size()), false
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// C中读取该大小的临时变量类型
+func GlobalCType(sym *GlobalSymbol) string {
+	return ScalarCType(sym.Size, sym.Signed)
+}
+
+func ScalarCType(size int, signed bool) string {
+	prefix := "__u"
+	if signed {
+		prefix = "__s"
+	}
+	return fmt.Sprintf("%s%d", prefix, size*8)
+}
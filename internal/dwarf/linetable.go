@@ -0,0 +1,380 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== DWARF行号表解析 ==========
+// 用编译好的.ko（或vmlinux）的DWARF行号表把 file:line 断点映射到真正包含该行的函数，
+// 以及该行第一条指令相对函数入口的偏移，生成 kprobe/func+0x1c 这样的偏移探针，
+// 断点不再只能停在函数入口。内联展开的行会落到实际包含这些指令的外层函数中：
+// 被内联的函数在每个调用处都有一份副本（DW_TAG_inlined_subroutine），每个副本各生成一个
+// 外层函数+偏移的探针，共用同一个断点编号。
+// 没有编译产物、没有调试信息或源文件比模块新时回退到源码扫描（project.ParseFunctionName）。
+
+// 行号表中的一行（只保留语句起始位置）
+type LineRow struct {
+	cu   int
+	File string
+	Line int
+	Addr uint64
+}
+
+// 有地址范围的函数
+type lineFunc struct {
+	cu       int
+	name     string
+	file     string
+	declLine int
+	Ranges   [][2]uint64
+}
+
+// 函数被内联到别的函数中的一份副本
+type lineInline struct {
+	cu       int
+	name     string
+	file     string
+	declLine int
+	ranges   [][2]uint64
+	depth    int // 嵌套深度，多层内联时取最内层
+	outer    int // 所在的外层函数（funcs中的下标）
+}
+
+// 断点解析结果
+type LineLocation struct {
+	Function string
+	Offset   uint64               // 相对函数入口的偏移
+	Line     int                  // 实际落到的源码行（目标行没有指令时为其后第一条语句所在行）
+	Inlined  string               // 目标行所在的函数被内联时为该函数名
+	Sites    []project.InlineSite // 内联时每个副本的探针位置（第一个与Function/Offset相同）
+}
+
+// 一个模块的行号表
+type LineResolver struct {
+	Binary  string
+	ModTime time.Time
+	rows    []LineRow
+	funcs   []lineFunc
+	inlines []lineInline
+}
+
+// 读取模块的全部行号表和函数地址范围
+func NewLineResolver(binaryPath string) (*LineResolver, error) {
+	info, err := os.Stat(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	file, _, err := OpenDebugELF(binaryPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := file.DWARF()
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrNoDebugInfo, "读取DWARF信息失败: %v", err)
+	}
+
+	r := &LineResolver{Binary: binaryPath, ModTime: info.ModTime()}
+	reader := data.Reader()
+	cu := -1
+	var files []*dwarf.LineFile
+	// 每层子条目所在的外层函数（-1表示不在有地址的函数中），条目Tag为0时退出一层
+	var scopes []int
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, fmt.Errorf("解析DWARF条目失败: %v", err)
+		}
+		if entry == nil {
+			break
+		}
+		if entry.Tag == 0 {
+			if len(scopes) > 0 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			continue
+		}
+		scope := -1
+		if len(scopes) > 0 {
+			scope = scopes[len(scopes)-1]
+		}
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			cu++
+			files = nil
+			scope = -1
+			if lr, err := data.LineReader(entry); err == nil && lr != nil {
+				files = lr.Files()
+				var le dwarf.LineEntry
+				for lr.Next(&le) == nil {
+					if le.EndSequence || !le.IsStmt || le.File == nil {
+						continue
+					}
+					r.rows = append(r.rows, LineRow{cu: cu, File: le.File.Name, Line: le.Line, Addr: le.Address})
+				}
+			}
+		case dwarf.TagSubprogram:
+			// 只有声明或只在内联时存在的函数没有地址
+			scope = -1
+			if ranges, err := data.Ranges(entry); err == nil && len(ranges) > 0 {
+				fn := lineFunc{cu: cu, Ranges: ranges}
+				fn.name, fn.declLine, fn.file = subprogramDecl(data, entry, files)
+				if fn.name != "" {
+					r.funcs = append(r.funcs, fn)
+					scope = len(r.funcs) - 1
+				}
+			}
+		case dwarf.TagInlinedSubroutine:
+			if ranges, err := data.Ranges(entry); err == nil && len(ranges) > 0 && scope >= 0 {
+				in := lineInline{cu: cu, ranges: ranges, depth: len(scopes), outer: scope}
+				in.name, in.declLine, in.file = subprogramDecl(data, entry, files)
+				if in.name != "" {
+					r.inlines = append(r.inlines, in)
+				}
+			}
+		}
+		if entry.Children {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(r.rows) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNoDebugInfo, "%s 没有行号表（编译时需要 -g）", filepath.Base(binaryPath))
+	}
+	return r, nil
+}
+
+// 函数名和声明位置（内联函数的独立副本只有 DW_AT_abstract_origin）
+func subprogramDecl(data *dwarf.Data, entry *dwarf.Entry, files []*dwarf.LineFile) (string, int, string) {
+	for depth := 0; depth < 4 && entry != nil; depth++ {
+		if name, ok := entry.Val(dwarf.AttrName).(string); ok {
+			line, _ := entry.Val(dwarf.AttrDeclLine).(int64)
+			file := ""
+			if idx, ok := entry.Val(dwarf.AttrDeclFile).(int64); ok && idx >= 0 && int(idx) < len(files) && files[idx] != nil {
+				file = files[idx].Name
+			}
+			return name, int(line), file
+		}
+		origin, ok := entry.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			origin, ok = entry.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		}
+		if !ok {
+			break
+		}
+		reader := data.Reader()
+		reader.Seek(origin)
+		entry, _ = reader.Next()
+	}
+	return "", 0, ""
+}
+
+// 行号表中的文件名与断点文件是否为同一文件（编译路径可能与本机不同，按文件名比较）
+func sameSourceFile(compiled, file string) bool {
+	return compiled != "" && filepath.Base(compiled) == filepath.Base(file)
+}
+
+// 包含地址的函数：同一编译单元中声明在目标行之前的最近的函数
+// （.text 和 .init.text 等段在.ko中都从0开始，只按地址会匹配到别的段里的函数）
+// exact为true时该地址正好属于目标行，内联展开的行声明在外层函数之前，也接受外层函数
+func (r *LineResolver) functionAt(cu int, addr uint64, file string, line int, exact bool) *lineFunc {
+	var best, outer *lineFunc
+	for i := range r.funcs {
+		fn := &r.funcs[i]
+		if fn.cu != cu || (fn.file != "" && !sameSourceFile(fn.file, file)) {
+			continue
+		}
+		inside := false
+		for _, rg := range fn.Ranges {
+			if addr >= rg[0] && addr < rg[1] {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			continue
+		}
+		if fn.declLine > line {
+			if outer == nil {
+				outer = fn
+			}
+			continue
+		}
+		if best == nil || fn.declLine > best.declLine {
+			best = fn
+		}
+	}
+	if best == nil && exact {
+		return outer
+	}
+	return best
+}
+
+// 把 file:line 解析为函数和偏移
+// 目标行没有生成指令（空行、注释、声明）时使用其后第一条有指令的语句
+func (r *LineResolver) Resolve(file string, line int) (*LineLocation, error) {
+	var best *LineLocation
+	var bestAddr uint64
+	for _, row := range r.rows {
+		if row.Line < line || !sameSourceFile(row.File, file) {
+			continue
+		}
+		if best != nil && (row.Line > best.Line || (row.Line == best.Line && row.Addr >= bestAddr)) {
+			continue
+		}
+		fn := r.functionAt(row.cu, row.Addr, file, line, row.Line == line)
+		if fn == nil {
+			continue
+		}
+		best = &LineLocation{Function: fn.name, Offset: row.Addr - fn.entry(), Line: row.Line}
+		bestAddr = row.Addr
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%s:%d 在 %s 的行号表中没有对应的指令", filepath.Base(file), line, filepath.Base(r.Binary))
+	}
+	r.resolveInlineSites(best, file)
+	return best, nil
+}
+
+// 函数入口（最低的地址）
+func (fn *lineFunc) entry() uint64 {
+	entry := fn.Ranges[0][0]
+	for _, rg := range fn.Ranges {
+		if rg[0] < entry {
+			entry = rg[0]
+		}
+	}
+	return entry
+}
+
+// 包含地址的最内层内联副本（只看声明在目标文件中、不晚于目标行的函数）
+func (r *LineResolver) inlineAt(cu int, addr uint64, file string, line int) *lineInline {
+	var best *lineInline
+	for i := range r.inlines {
+		in := &r.inlines[i]
+		if in.cu != cu || in.declLine > line || !sameSourceFile(in.file, file) {
+			continue
+		}
+		for _, rg := range in.ranges {
+			if addr >= rg[0] && addr < rg[1] && (best == nil || in.depth > best.depth) {
+				best = in
+				break
+			}
+		}
+	}
+	return best
+}
+
+// 目标行在内联函数中时，找出该行在每个内联副本（以及可能存在的独立副本）中的第一条指令，
+// 每处一个探针位置；目标行不在内联函数中时不修改解析结果
+func (r *LineResolver) resolveInlineSites(loc *LineLocation, file string) {
+	type siteKey struct {
+		fn     *lineFunc
+		inline *lineInline
+	}
+	first := make(map[siteKey]uint64)
+	inlined := ""
+	for _, row := range r.rows {
+		if row.Line != loc.Line || !sameSourceFile(row.File, file) {
+			continue
+		}
+		key := siteKey{}
+		if in := r.inlineAt(row.cu, row.Addr, file, loc.Line); in != nil {
+			key = siteKey{fn: &r.funcs[in.outer], inline: in}
+			inlined = in.name
+		} else if key.fn = r.functionAt(row.cu, row.Addr, file, loc.Line, true); key.fn == nil {
+			continue
+		}
+		if addr, ok := first[key]; !ok || row.Addr < addr {
+			first[key] = row.Addr
+		}
+	}
+	if inlined == "" {
+		return
+	}
+	sites := make([]project.InlineSite, 0, len(first))
+	seen := make(map[project.InlineSite]bool)
+	for key, addr := range first {
+		site := project.InlineSite{Function: key.fn.name, Offset: addr - key.fn.entry()}
+		if !seen[site] {
+			seen[site] = true
+			sites = append(sites, site)
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Function != sites[j].Function {
+			return sites[i].Function < sites[j].Function
+		}
+		return sites[i].Offset < sites[j].Offset
+	})
+	loc.Inlined = inlined
+	loc.Sites = sites
+	loc.Function = sites[0].Function
+	loc.Offset = sites[0].Offset
+}
+
+// 函数的声明位置（找不到时文件为空）
+func (r *LineResolver) FunctionDecl(name string) (string, int) {
+	for _, fn := range r.funcs {
+		if fn.name == name && fn.file != "" {
+			return fn.file, fn.declLine
+		}
+	}
+	return "", 0
+}
+
+// 断点的全部探针位置：内联函数中的断点每个内联副本一个，其他断点只有Function/Offset一个
+func BreakpointProbeSites(bp project.Breakpoint) []project.Breakpoint {
+	if len(bp.Inline) == 0 {
+		return []project.Breakpoint{bp}
+	}
+	sites := make([]project.Breakpoint, 0, len(bp.Inline))
+	for _, site := range bp.Inline {
+		probe := bp
+		probe.Function = site.Function
+		probe.Offset = site.Offset
+		sites = append(sites, probe)
+	}
+	return sites
+}
+
+// 内联断点的说明：inlined inner(): probes in do_work+0x4, other+0x8
+func InlineSitesNote(bp project.Breakpoint) string {
+	if bp.InlinedFrom == "" || len(bp.Inline) == 0 {
+		return ""
+	}
+	targets := make([]string, 0, len(bp.Inline))
+	for _, site := range bp.Inline {
+		targets = append(targets, ProbeTarget(site.Function, site.Offset))
+	}
+	return fmt.Sprintf("inlined %s(): %d probes in %s", bp.InlinedFrom, len(targets), strings.Join(targets, ", "))
+}
+
+// 探针位置：func 或 func+0x1c（kprobe段名和显示使用）
+func ProbeTarget(function string, offset uint64) string {
+	if offset == 0 {
+		return function
+	}
+	return fmt.Sprintf("%s+0x%x", function, offset)
+}
+
+// 拆分探针位置 func+0x1c（挂载时使用）
+func SplitProbeOffset(target string) (string, uint64) {
+	plus := strings.LastIndex(target, "+")
+	if plus <= 0 {
+		return target, 0
+	}
+	var offset uint64
+	if _, err := fmt.Sscanf(target[plus+1:], "0x%x", &offset); err != nil {
+		return target, 0
+	}
+	return target[:plus], offset
+}
@@ -0,0 +1,155 @@
+package dwarf
+
+import (
+	"debug/dwarf"
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 结构体指针展开 ==========
+// 监视的局部变量或参数如果是结构体指针（按模块DWARF类型判断），生成的BPF程序在读出指针后
+// 按DWARF中的成员偏移逐个bpf_probe_read_kernel读取标量成员，以 [VAR-N] func:dev->id=5 的
+// 格式输出；变量窗口把这些成员缩进显示在监视表达式下面。
+// 嵌套的结构体成员就地展开（dev->stats.rx），位域、数组和超出深度的成员不读取。
+
+// 结构体展开的最大嵌套深度和成员数（每个成员一次读取和一次事件输出）
+const (
+	maxStructDepth   = 3
+	maxStructMembers = 16
+)
+
+// 要读取的结构体成员
+type StructMember struct {
+	Path   string // dev->stats.rx
+	Offset int64  // 相对结构体起始的偏移
+	Size   int
+	Signed bool
+}
+
+// 去掉typedef、const、volatile
+func unwrapDwarfType(t dwarf.Type) dwarf.Type {
+	for i := 0; i < 8; i++ {
+		switch tt := t.(type) {
+		case *dwarf.TypedefType:
+			t = tt.Type
+		case *dwarf.QualType:
+			t = tt.Type
+		default:
+			return t
+		}
+	}
+	return t
+}
+
+// 在函数的DWARF条目中查找变量或参数的类型（包括嵌套作用域中的变量）
+func dwarfLocalVariableType(data *dwarf.Data, funcName, varName string) (dwarf.Type, error) {
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil || entry == nil {
+			break
+		}
+		if entry.Tag != dwarf.TagSubprogram || !entry.Children {
+			continue
+		}
+		if name, _ := entry.Val(dwarf.AttrName).(string); name != funcName {
+			reader.SkipChildren()
+			continue
+		}
+		for depth := 1; depth > 0; {
+			child, err := reader.Next()
+			if err != nil || child == nil {
+				break
+			}
+			if child.Tag == 0 {
+				depth--
+				continue
+			}
+			if child.Children {
+				depth++
+			}
+			if child.Tag != dwarf.TagVariable && child.Tag != dwarf.TagFormalParameter {
+				continue
+			}
+			if name, _ := child.Val(dwarf.AttrName).(string); name != varName {
+				continue
+			}
+			typeOff, ok := child.Val(dwarf.AttrType).(dwarf.Offset)
+			if !ok {
+				continue
+			}
+			return data.Type(typeOff)
+		}
+	}
+	return nil, errcode.Errorf(errcode.ErrNoSymbol, "函数 %s 的DWARF信息中没有变量 %s", funcName, varName)
+}
+
+// 变量是结构体指针时返回结构体类型名和要读取的成员
+func DWARFStructPointerMembers(binaryPath, funcName, varName string) (string, []StructMember, error) {
+	file, _, err := OpenDebugELF(binaryPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+	data, err := file.DWARF()
+	if err != nil {
+		return "", nil, errcode.Errorf(errcode.ErrNoDebugInfo, "读取DWARF信息失败: %v", err)
+	}
+	t, err := dwarfLocalVariableType(data, funcName, varName)
+	if err != nil {
+		return "", nil, err
+	}
+	ptr, ok := unwrapDwarfType(t).(*dwarf.PtrType)
+	if !ok {
+		return "", nil, fmt.Errorf("%s 的类型 %s 不是指针", varName, t.String())
+	}
+	st, ok := unwrapDwarfType(ptr.Type).(*dwarf.StructType)
+	if !ok || st.Incomplete {
+		return "", nil, fmt.Errorf("%s 的类型 %s 不是结构体指针", varName, t.String())
+	}
+	members := make([]StructMember, 0)
+	flattenStructMembers(st, varName+"->", 0, 1, &members)
+	if len(members) == 0 {
+		return "", nil, fmt.Errorf("%s 没有可读取的标量成员", st.String())
+	}
+	return st.String(), members, nil
+}
+
+// 按声明顺序展开结构体的标量成员（匿名结构体/联合体的成员直接挂在外层）
+func flattenStructMembers(st *dwarf.StructType, prefix string, base int64, depth int, out *[]StructMember) {
+	for _, field := range st.Field {
+		if len(*out) >= maxStructMembers {
+			return
+		}
+		if field.BitSize != 0 {
+			continue
+		}
+		offset := base + field.ByteOffset
+		if nested, ok := unwrapDwarfType(field.Type).(*dwarf.StructType); ok {
+			if depth >= maxStructDepth || nested.Incomplete {
+				continue
+			}
+			inner := prefix
+			if field.Name != "" {
+				inner = prefix + field.Name + "."
+			}
+			flattenStructMembers(nested, inner, offset, depth+1, out)
+			continue
+		}
+		size, signed := scalarLayout(field.Type)
+		if field.Name == "" || (size != 1 && size != 2 && size != 4 && size != 8) {
+			continue
+		}
+		*out = append(*out, StructMember{Path: prefix + field.Name, Offset: offset, Size: size, Signed: signed})
+	}
+}
+
+// 结构体成员在变量窗口中的显示：缩进在监视表达式下面，省略变量名（dev->st.rx 显示为 .st.rx）
+func StructMemberLabel(path string) string {
+	if arrow := strings.Index(path, "->"); arrow >= 0 {
+		path = path[arrow+2:]
+	}
+	return "  ." + path
+}
@@ -0,0 +1,401 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ========== 结构化错误码 ==========
+// 每条失败输出都带一个错误码（Error: [E_PERM] ...），why <code> 打开对应的排查窗口：
+// 可能原因、需要执行的检查，以及相关的诊断命令（env / selftest / debuginfo）。
+// 命令处理函数失败时返回error（见 commands/commands.go），后端明确知道失败类型时用 Errorf
+// 返回带码的错误，其余错误按内容归类。界面和RPC各自格式化错误码、信息和提示。
+
+// 错误码
+type Code string
+
+const (
+	ErrNoProject     Code = "E_NO_PROJECT"
+	ErrUsage         Code = "E_USAGE"
+	ErrInvalidArg    Code = "E_INVALID_ARG"
+	ErrNotFound      Code = "E_NOT_FOUND"
+	ErrPerm          Code = "E_PERM"
+	ErrSafeMode      Code = "E_SAFE_MODE"
+	ErrArch          Code = "E_ARCH"
+	ErrToolMissing   Code = "E_TOOL_MISSING"
+	ErrNoDebugInfo   Code = "E_NO_DEBUGINFO"
+	ErrNoSymbol      Code = "E_NO_SYMBOL"
+	ErrNoBreakpoints Code = "E_NO_BREAKPOINTS"
+	ErrBPFSource     Code = "E_BPF_SOURCE"
+	ErrBPFCompile    Code = "E_BPF_COMPILE"
+	ErrBPFVerifier   Code = "E_BPF_VERIFIER"
+	ErrBPFAttach     Code = "E_BPF_ATTACH"
+	ErrTracePipe     Code = "E_TRACE_PIPE"
+	ErrKcore         Code = "E_KCORE"
+	ErrSourceFetch   Code = "E_SOURCE_FETCH"
+	ErrConfig        Code = "E_CONFIG"
+	ErrTarget        Code = "E_TARGET"
+	ErrUnknown       Code = "E_UNKNOWN"
+)
+
+// 带错误码的错误
+type CodedError struct {
+	Code Code
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return fmt.Sprintf("[%s] %v", e.Code, e.Err)
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// 创建带错误码的错误
+func Errorf(code Code, format string, a ...interface{}) error {
+	return &CodedError{Code: code, Err: fmt.Errorf(format, a...)}
+}
+
+// 为没有错误码的错误加上错误码（已经带码的保持原来的码）
+func Wrap(code Code, err error) error {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+// 需要项目的命令在没有打开项目时返回的错误
+var NoProject = Errorf(ErrNoProject, "Please open a project first")
+
+// 错误码的排查说明
+type Troubleshooting struct {
+	Summary string
+	Causes  []string
+	Checks  []string // 需要在shell中执行的检查
+	Related []string // 相关的诊断命令（在本工具中执行）
+}
+
+var Guide = map[Code]Troubleshooting{
+	ErrNoProject: {
+		Summary: "No project (or file) is open",
+		Causes:  []string{"The command works on the open project", "Safe mode (--safe) starts without a project"},
+		Checks:  []string{"ls <driver source dir>"},
+		Related: []string{"open <project_path>", "pwd"},
+	},
+	ErrUsage: {
+		Summary: "The command was called with missing or extra arguments",
+		Causes:  []string{"Arguments missing or in the wrong order"},
+		Related: []string{"help"},
+	},
+	ErrInvalidArg: {
+		Summary: "An argument is out of range or malformed",
+		Causes:  []string{"Number out of range (list it first)", "Typo in a name, mark or expression"},
+		Related: []string{"help", "marks", "watch", "assert"},
+	},
+	ErrNotFound: {
+		Summary: "A file or path does not exist",
+		Causes:  []string{"Relative path resolved against the wrong directory", "File was removed or never built"},
+		Checks:  []string{"ls -l <path>"},
+		Related: []string{"pwd", "srcmap"},
+	},
+	ErrPerm: {
+		Summary: "Insufficient privileges for kernel interfaces",
+		Causes: []string{
+			"tracefs, /proc/kcore, bpftool and insmod need root (or CAP_BPF/CAP_PERFMON/CAP_SYS_ADMIN)",
+			"kptr_restrict hides kernel addresses from unprivileged users",
+			"Kernel lockdown (secure boot) blocks kcore and kprobes",
+		},
+		Checks: []string{
+			"id -u",
+			"cat /proc/sys/kernel/kptr_restrict",
+			"cat /sys/kernel/security/lockdown",
+		},
+		Related: []string{"env", "selftest"},
+	},
+	ErrSafeMode: {
+		Summary: "The feature is disabled in safe mode",
+		Causes:  []string{"The debugger was started with --safe"},
+		Related: []string{"safe", "safe off"},
+	},
+	ErrArch: {
+		Summary: "Target architecture is not supported or detected wrongly",
+		Causes:  []string{"Module built for a different architecture than expected", "Pinned arch in project settings is stale"},
+		Checks:  []string{"file <module.ko>", "uname -m"},
+		Related: []string{"arch auto", "env"},
+	},
+	ErrToolMissing: {
+		Summary: "A required external tool is not installed",
+		Causes:  []string{"clang, bpftool, make or cscope missing from PATH"},
+		Checks:  []string{"which clang bpftool make", "clang --version"},
+		Related: []string{"selftest"},
+	},
+	ErrNoDebugInfo: {
+		Summary: "No DWARF debug information for the module or vmlinux",
+		Causes: []string{
+			"Module built without CONFIG_DEBUG_INFO / -g",
+			"Debug info stripped and the separate debug file is not installed",
+			"build-id / .gnu_debuglink points to a file that is not on this machine",
+		},
+		Checks: []string{
+			"readelf -S <module.ko> | grep debug_info",
+			"readelf -n <module.ko> | grep 'Build ID'",
+		},
+		Related: []string{"debuginfo <module.ko>", "env"},
+	},
+	ErrNoSymbol: {
+		Summary: "Symbol not found in kallsyms or the module symbol table",
+		Causes: []string{
+			"Module not loaded (module symbols only appear after insmod)",
+			"Function was inlined or renamed (.isra/.constprop) by the compiler",
+			"kptr_restrict hides addresses (all zero)",
+		},
+		Checks: []string{
+			"grep <symbol> /proc/kallsyms",
+			"lsmod | grep <module>",
+			"nm <module.ko> | grep <symbol>",
+		},
+		Related: []string{"diagnose <func>", "modinfo", "callgraph <func>"},
+	},
+	ErrNoBreakpoints: {
+		Summary: "No usable breakpoints to build probes from",
+		Causes:  []string{"No breakpoints set", "Breakpoint lines are outside any function"},
+		Related: []string{"bp", "ws"},
+	},
+	ErrBPFSource: {
+		Summary: "The generated BPF source is missing",
+		Causes:  []string{"compile was run before generate/vars"},
+		Related: []string{"generate", "vars"},
+	},
+	ErrBPFCompile: {
+		Summary: "clang failed to compile the generated BPF program",
+		Causes: []string{
+			"linux-headers / libbpf headers missing",
+			"clang too old for the BPF target",
+			"Variable types in the generated code do not match the kernel",
+		},
+		Checks: []string{
+			"clang --version",
+			"ls /usr/include/bpf/bpf_helpers.h",
+			"ls /lib/modules/$(uname -r)/build",
+		},
+		Related: []string{"arch", "selftest"},
+	},
+	ErrBPFVerifier: {
+		Summary: "The kernel BPF verifier rejected the program",
+		Causes: []string{
+			"Unbounded loop or stack usage over 512 bytes",
+			"Reading kernel memory without bpf_probe_read_kernel",
+			"Program too large for the kernel's instruction limit",
+		},
+		Checks: []string{
+			"bpftool prog loadall <obj> /sys/fs/bpf/x -d 2>&1 | tail -50",
+			"uname -r",
+		},
+		Related: []string{"selftest", "env"},
+	},
+	ErrBPFAttach: {
+		Summary: "Loading or attaching the probe failed",
+		Causes: []string{
+			"Probed function is not traceable (notrace, inlined, or in a blacklist)",
+			"Module not loaded or a different build than the one compiled against",
+			"kprobes disabled (CONFIG_KPROBES) or lockdown active",
+		},
+		Checks: []string{
+			"grep <func> /sys/kernel/debug/tracing/available_filter_functions",
+			"cat /sys/kernel/debug/kprobes/blacklist | grep <func>",
+			"lsmod | grep <module>",
+		},
+		Related: []string{"diagnose", "modinfo", "selftest"},
+	},
+	ErrTracePipe: {
+		Summary: "Cannot read the ftrace trace_pipe",
+		Causes: []string{
+			"tracefs / debugfs not mounted",
+			"Not running as root",
+			"Another reader is consuming trace_pipe",
+		},
+		Checks: []string{
+			"mount | grep -E 'tracefs|debugfs'",
+			"sudo mount -t tracefs nodev /sys/kernel/tracing",
+			"sudo lsof /sys/kernel/tracing/trace_pipe",
+		},
+		Related: []string{"events start", "selftest"},
+	},
+	ErrKcore: {
+		Summary: "Cannot read kernel memory through /proc/kcore",
+		Causes: []string{
+			"Not running as root",
+			"Kernel built without CONFIG_PROC_KCORE",
+			"Kernel lockdown blocks /proc/kcore",
+			"Address not covered by a kcore segment (module memory on some arches)",
+		},
+		Checks:  []string{"ls -l /proc/kcore", "cat /sys/kernel/security/lockdown"},
+		Related: []string{"env", "snapshot now"},
+	},
+	ErrSourceFetch: {
+		Summary: "Source file not available locally and could not be fetched",
+		Causes: []string{
+			"Build paths in DWARF differ from the local tree",
+			"No fetch source configured, or the git ref / URL is wrong",
+		},
+		Checks:  []string{"git -C <tree> show <ref>:<path> | head"},
+		Related: []string{"srcmap", "srcfetch"},
+	},
+	ErrConfig: {
+		Summary: "A saved project file could not be read or written",
+		Causes: []string{
+			".debug_breakpoints.json / .debug_settings.json is corrupt",
+			"Project directory is read-only",
+		},
+		Checks:  []string{"ls -l .debug_*.json", "python3 -m json.tool .debug_settings.json"},
+		Related: []string{"ops", "safe"},
+	},
+	ErrTarget: {
+		Summary: "The stop-mode debug target (gdbstub or kdb) did not answer",
+		Causes: []string{
+			"QEMU was started without -s / -gdb tcp::1234, or another gdb is attached",
+			"Kernel built without CONFIG_KGDB / CONFIG_KGDB_KDB, or no kgdboc=<tty>,<baud> on the command line",
+			"Wrong serial device or baud rate, or the console is also using the port",
+			"The target is running: 'interrupt' stops it first",
+		},
+		Checks: []string{
+			"ss -ltnp | grep 1234",
+			"cat /sys/module/kgdboc/parameters/kgdboc  # on the target",
+			"stty -F /dev/ttyUSB0",
+		},
+		Related: []string{"backend", "interrupt"},
+	},
+	ErrUnknown: {
+		Summary: "Unclassified failure",
+		Causes:  []string{"See the full message in the command history"},
+		Related: []string{"env", "selftest"},
+	},
+}
+
+// 未带错误码的错误按内容归类（按顺序匹配，具体子系统优先）
+var errorClassifiers = []struct {
+	code     Code
+	patterns []string
+}{
+	{ErrSafeMode, []string{"安全模式", "safe mode"}},
+	{ErrKcore, []string{"/proc/kcore"}},
+	{ErrTarget, []string{"gdbstub", "kdb", "目标正在运行"}},
+	{ErrTracePipe, []string{"trace_pipe", "tracefs"}},
+	{ErrBPFVerifier, []string{"verifier"}},
+	{ErrNoSymbol, []string{"kallsyms", "符号"}},
+	{ErrNoDebugInfo, []string{"调试信息", "dwarf", "debuginfo", "debug info"}},
+	{ErrToolMissing, []string{"executable file not found", "找不到clang", "缺少:"}},
+	{ErrBPFCompile, []string{"编译", "clang"}},
+	{ErrPerm, []string{"permission denied", "operation not permitted", "root"}},
+	{ErrNoProject, []string{"open a project first", "open a file first", "没有打开的项目", "project not opened"}},
+	{ErrUsage, []string{"usage:"}},
+	{ErrArch, []string{"architecture", "不支持的架构"}},
+	{ErrNoBreakpoints, []string{"没有设置断点", "no breakpoints"}},
+	{ErrNotFound, []string{"does not exist", "no such file", "不存在"}},
+	{ErrConfig, []string{"解析", "序列化", "保存", "settings", "json"}},
+	{ErrInvalidArg, []string{"invalid", "无效", "超出范围", "not set", "no such", "无法解析"}},
+}
+
+var errorCodeRegex = regexp.MustCompile(`\[(E_[A-Z_]+)\] ?`)
+
+// 判断错误信息对应的错误码
+func Classify(message string) Code {
+	if m := errorCodeRegex.FindStringSubmatch(message); m != nil {
+		return Code(m[1])
+	}
+	lower := strings.ToLower(message)
+	for _, c := range errorClassifiers {
+		for _, p := range c.patterns {
+			if strings.Contains(lower, p) {
+				return c.code
+			}
+		}
+	}
+	return ErrUnknown
+}
+
+// 错误的错误码：带码的错误取其码，其余按内容归类
+func Of(err error) Code {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return Classify(err.Error())
+}
+
+// 去掉错误码后的错误信息（错误码单独显示）
+func Message(err error) string {
+	return errorCodeRegex.ReplaceAllString(err.Error(), "")
+}
+
+// 带提示的错误：提示行（用法示例、下一步操作）显示在错误之后
+type hintedError struct {
+	Err   error
+	Hints []string
+}
+
+func (e *hintedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *hintedError) Unwrap() error {
+	return e.Err
+}
+
+// 为错误加上提示行
+func WithHints(err error, hints ...string) error {
+	return &hintedError{Err: err, Hints: hints}
+}
+
+// 错误的提示行
+func Hints(err error) []string {
+	var hinted *hintedError
+	if errors.As(err, &hinted) {
+		return hinted.Hints
+	}
+	return nil
+}
+
+// 命令用法错误
+func Usage(usage string, hints ...string) error {
+	err := Errorf(ErrUsage, "Usage: %s", usage)
+	if len(hints) > 0 {
+		return WithHints(err, hints...)
+	}
+	return err
+}
+
+// 命令窗口中的错误行：Error: [错误码] 信息，之后是提示和 why 的用法
+func Format(err error) []string {
+	code := Of(err)
+	lines := append([]string{fmt.Sprintf("Error: [%s] %s", code, Message(err))}, Hints(err)...)
+	if code != ErrUsage {
+		lines = append(lines, fmt.Sprintf("\x1b[90mTip: 'why' shows troubleshooting for %s\x1b[0m", code))
+	}
+	return lines
+}
+
+// 所有错误码（按名称排序）
+func Codes() []Code {
+	codes := make([]Code, 0, len(Guide))
+	for code := range Guide {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// 解析用户输入的错误码（不区分大小写，可省略 E_ 前缀）
+func Parse(s string) (Code, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "E_") {
+		s = "E_" + s
+	}
+	_, ok := Guide[Code(s)]
+	return Code(s), ok
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "github.com/gdamore/tcell"
+
+// Attribute represents a terminal attribute, like color, font style, etc. They
+// can be combined using bitwise OR (|). Note that it is not possible to
+// combine multiple color attributes.
+//
+// The low 9 bits hold the color: 0 is the terminal's default color and
+// 1-256 are the palette colors 0-255 (see output256 in escape.go).
+type Attribute uint16
+
+// Color attributes.
+const (
+	ColorDefault Attribute = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+)
+
+// Text style attributes.
+const (
+	AttrBold Attribute = 1 << (iota + 9)
+	AttrUnderline
+	AttrReverse
+)
+
+// colorMask selects the color bits of an Attribute.
+const colorMask Attribute = 0x1ff
+
+// color returns the tcell color of the attribute.
+func (a Attribute) color() tcell.Color {
+	c := a & colorMask
+	if c == ColorDefault {
+		return tcell.ColorDefault
+	}
+	return tcell.Color(c - 1)
+}
+
+// style returns the tcell style for the given foreground and background
+// attributes. Bold and underline are taken from the foreground, reverse
+// from either of them.
+func style(fgColor, bgColor Attribute) tcell.Style {
+	st := tcell.StyleDefault.Foreground(fgColor.color()).Background(bgColor.color())
+	if fgColor&AttrBold != 0 {
+		st = st.Bold(true)
+	}
+	if fgColor&AttrUnderline != 0 {
+		st = st.Underline(true)
+	}
+	if (fgColor|bgColor)&AttrReverse != 0 {
+		st = st.Reverse(true)
+	}
+	return st
+}
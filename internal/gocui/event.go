@@ -0,0 +1,109 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "github.com/gdamore/tcell"
+
+// eventType is the kind of a gocuiEvent.
+type eventType uint8
+
+const (
+	eventNone eventType = iota
+	eventKey
+	eventMouse
+	eventResize
+	eventError
+)
+
+// gocuiEvent is a terminal event translated from tcell into the key, rune
+// and modifier model used by the keybindings.
+type gocuiEvent struct {
+	Type           eventType
+	Key            Key
+	Ch             rune
+	Mod            Modifier
+	MouseX, MouseY int
+	Err            error
+}
+
+// pollEvent waits for the next terminal event and translates it. It returns
+// false once the screen has been finalized.
+func (g *Gui) pollEvent() (gocuiEvent, bool) {
+	for {
+		switch ev := g.screen.PollEvent().(type) {
+		case nil:
+			return gocuiEvent{}, false
+		case *tcell.EventKey:
+			return translateKey(ev), true
+		case *tcell.EventMouse:
+			if gev, ok := g.translateMouse(ev); ok {
+				return gev, true
+			}
+		case *tcell.EventResize:
+			return gocuiEvent{Type: eventResize}, true
+		case *tcell.EventError:
+			return gocuiEvent{Type: eventError, Err: ev}, true
+		}
+	}
+}
+
+// translateKey translates a key event. Runes are reported with a zero Key,
+// the space bar as KeySpace and control keys without their rune. Only the
+// Alt modifier is kept: tcell also reports Ctrl for the control codes and
+// Shift for some special keys, which the keybindings do not distinguish.
+func translateKey(ev *tcell.EventKey) gocuiEvent {
+	gev := gocuiEvent{Type: eventKey, Key: Key(ev.Key())}
+	if ev.Modifiers()&tcell.ModAlt != 0 {
+		gev.Mod = ModAlt
+	}
+	if ev.Key() == tcell.KeyRune {
+		if ev.Rune() == ' ' && gev.Mod == ModNone {
+			gev.Key = KeySpace
+		} else {
+			gev.Key, gev.Ch = 0, ev.Rune()
+		}
+	}
+	return gev
+}
+
+// translateMouse translates a mouse event. tcell reports the buttons held at
+// the time of the event, so pressing, dragging and releasing are told apart
+// by the buttons held at the previous event. Motion with no button held is
+// dropped.
+func (g *Gui) translateMouse(ev *tcell.EventMouse) (gocuiEvent, bool) {
+	gev := gocuiEvent{Type: eventMouse}
+	gev.MouseX, gev.MouseY = ev.Position()
+
+	buttons := ev.Buttons()
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		gev.Key = MouseWheelUp
+		return gev, true
+	case buttons&tcell.WheelDown != 0:
+		gev.Key = MouseWheelDown
+		return gev, true
+	}
+
+	buttons &= tcell.Button1 | tcell.Button2 | tcell.Button3
+	held := g.mouseButtons
+	g.mouseButtons = buttons
+	switch {
+	case buttons == tcell.ButtonNone && held == tcell.ButtonNone:
+		return gev, false
+	case buttons == tcell.ButtonNone:
+		gev.Key = MouseRelease
+		return gev, true
+	case buttons&tcell.Button1 != 0:
+		gev.Key = MouseLeft
+	case buttons&tcell.Button3 != 0:
+		gev.Key = MouseRight
+	default:
+		gev.Key = MouseMiddle
+	}
+	if buttons&held != 0 {
+		gev.Mod = ModMotion
+	}
+	return gev, true
+}
@@ -0,0 +1,148 @@
+package gocui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+// 在模拟终端上创建GUI（不启动主循环）
+func newTestGui(t *testing.T, w, h int) (*Gui, tcell.SimulationScreen) {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(w, h)
+	g := &Gui{screen: screen, outputMode: Output256}
+	g.maxX, g.maxY = screen.Size()
+	t.Cleanup(g.Close)
+	return g, screen
+}
+
+func TestTranslateMouse(t *testing.T) {
+	g, _ := newTestGui(t, 20, 5)
+	steps := []struct {
+		buttons tcell.ButtonMask
+		key     Key
+		mod     Modifier
+		ok      bool
+	}{
+		{tcell.ButtonNone, 0, ModNone, false}, // 没有按键的移动被丢弃
+		{tcell.Button1, MouseLeft, ModNone, true},
+		{tcell.Button1, MouseLeft, ModMotion, true},
+		{tcell.ButtonNone, MouseRelease, ModNone, true},
+		{tcell.WheelDown, MouseWheelDown, ModNone, true},
+		{tcell.Button3, MouseRight, ModNone, true},
+	}
+	for i, s := range steps {
+		ev, ok := g.translateMouse(tcell.NewEventMouse(7, 3, s.buttons, tcell.ModNone))
+		if ok != s.ok || (ok && (ev.Key != s.key || ev.Mod != s.mod || ev.MouseX != 7 || ev.MouseY != 3)) {
+			t.Errorf("step %d: got %+v ok=%v, want key=%d mod=%d ok=%v", i, ev, ok, s.key, s.mod, s.ok)
+		}
+	}
+}
+
+func TestTranslateKey(t *testing.T) {
+	tests := []struct {
+		ev  *tcell.EventKey
+		key Key
+		ch  rune
+		mod Modifier
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone), 0, 'x', ModNone},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), KeySpace, 0, ModNone},
+		{tcell.NewEventKey(tcell.KeyRune, '1', tcell.ModAlt), 0, '1', ModAlt},
+		{tcell.NewEventKey(tcell.KeyCtrlX, 0, tcell.ModCtrl), KeyCtrlX, 0, ModNone},
+		{tcell.NewEventKey(tcell.KeyF11, 0, tcell.ModNone), KeyF11, 0, ModNone},
+	}
+	for _, tt := range tests {
+		ev := translateKey(tt.ev)
+		if ev.Key != tt.key || ev.Ch != tt.ch || ev.Mod != tt.mod {
+			t.Errorf("translateKey(%s) = %+v, want key=%d ch=%q mod=%d", tt.ev.Name(), ev, tt.key, tt.ch, tt.mod)
+		}
+	}
+}
+
+func TestViewWideRunes(t *testing.T) {
+	g, screen := newTestGui(t, 12, 3)
+	v, err := g.SetView("v", 0, 0, 11, 2)
+	if err != ErrUnknownView {
+		t.Fatal(err)
+	}
+	fmt.Fprint(v, "a中b \x1b[31m文件\x1b[0m")
+
+	// 缓冲区按屏幕列存放，读出时去掉宽字符的右半格
+	if got := v.Buffer(); got != "a中b 文件\n" {
+		t.Errorf("Buffer() = %q", got)
+	}
+	if got, _ := v.Word(2, 0); got != "a中b" {
+		t.Errorf("Word(2, 0) = %q", got)
+	}
+	if got, _ := v.Word(6, 0); got != "文件" {
+		t.Errorf("Word(6, 0) = %q", got)
+	}
+
+	if err := g.flush(); err != nil {
+		t.Fatal(err)
+	}
+	cells, w, _ := screen.GetContents()
+	// 视图内部从第1列开始：a 中(2列) b 空格 文(2列) 件(2列，最后一列之前放得下)
+	want := []rune{'a', '中', ' ', 'b', ' ', '文', ' ', '件', ' ', ' '}
+	for i, r := range want {
+		c := cells[w+1+i]
+		// 宽字符的右半格没有内容
+		if r != ' ' && (len(c.Runes) == 0 || c.Runes[0] != r) {
+			t.Errorf("column %d = %q, want %q", 1+i, string(c.Runes), string(r))
+		}
+	}
+	if c := cells[w+6]; c.Style != tcell.StyleDefault.Foreground(tcell.ColorMaroon).Background(tcell.ColorDefault) {
+		t.Errorf("style of 文 = %v", c.Style)
+	}
+}
+
+func TestEditWideRunes(t *testing.T) {
+	g, _ := newTestGui(t, 20, 3)
+	v, _ := g.SetView("v", 0, 0, 19, 2)
+	v.Editable = true
+	for _, ch := range "a中b" {
+		v.EditWrite(ch)
+	}
+	if cx, _ := v.Cursor(); cx != 4 {
+		t.Errorf("cursor after writing = %d, want 4", cx)
+	}
+	// 删除按绘制后的视图行定位，和主循环一样每次编辑后重绘
+	for i := 0; i < 2; i++ { // b，中（两格）
+		if err := g.flush(); err != nil {
+			t.Fatal(err)
+		}
+		v.EditDelete(true)
+	}
+	if cx, _ := v.Cursor(); cx != 1 || v.Buffer() != "a\n" {
+		t.Errorf("after deleting: cursor %d, buffer %q", cx, v.Buffer())
+	}
+}
+
+func TestEscapeBrightColors(t *testing.T) {
+	tests := []struct {
+		seq    string
+		fg, bg Attribute
+	}{
+		{"\x1b[90m", Attribute(9), ColorDefault},
+		{"\x1b[41;97m", Attribute(16), ColorRed},
+		{"\x1b[1;103m", AttrBold, Attribute(12)},
+		{"\x1b[33m", ColorYellow, ColorDefault},
+	}
+	for _, tt := range tests {
+		ei := newEscapeInterpreter(OutputNormal)
+		for _, ch := range tt.seq {
+			if _, err := ei.parseOne(ch); err != nil {
+				t.Fatalf("%q: %v", tt.seq, err)
+			}
+		}
+		if ei.curFgColor != tt.fg || ei.curBgColor != tt.bg {
+			t.Errorf("%q: fg=%d bg=%d, want fg=%d bg=%d", tt.seq, ei.curFgColor, ei.curBgColor, tt.fg, tt.bg)
+		}
+	}
+}
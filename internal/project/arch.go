@@ -0,0 +1,30 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 在项目目录中查找已编译的内核模块（最近修改的优先）
+func FindProjectModule(projectRoot string) string {
+	var best string
+	var bestTime int64
+	filepath.Walk(projectRoot, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != projectRoot && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), ".ko") && info.ModTime().UnixNano() > bestTime {
+			best = path
+			bestTime = info.ModTime().UnixNano()
+		}
+		return nil
+	})
+	return best
+}
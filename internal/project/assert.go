@@ -0,0 +1,28 @@
+package project
+
+import (
+	"fmt"
+	"time"
+)
+
+// ========== 断点顺序断言 ==========
+
+// 顺序断言
+type OrderAssertion struct {
+	First  int           `json:"first"`            // 先发生的断点编号
+	Second int           `json:"second"`           // 后发生的断点编号
+	Within time.Duration `json:"within,omitempty"` // 时间窗口（0表示不限）
+	PerPID bool          `json:"per_pid,omitempty"`
+}
+
+// 断言的文本形式
+func (a OrderAssertion) String() string {
+	s := fmt.Sprintf("bp%d before bp%d", a.First, a.Second)
+	if a.Within > 0 {
+		s += " within " + a.Within.String()
+	}
+	if a.PerPID {
+		s += " per pid"
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ========== 断点导入导出 ==========
+
+// 断点文件格式
+const (
+	BreakpointFormatJSON = "json"
+	BreakpointFormatText = "text"
+)
+
+// 按扩展名判断断点文件格式
+func BreakpointFileFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return BreakpointFormatJSON
+	}
+	return BreakpointFormatText
+}
+
+// 解析文本格式的断点文件
+func ParseBreakpointText(path string) ([]Breakpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取断点文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var breakpoints []Breakpoint
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		sep := strings.LastIndex(fields[0], ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("第%d行: 需要 <file>:<line>", lineNum)
+		}
+		line, err := strconv.Atoi(fields[0][sep+1:])
+		if err != nil || line <= 0 {
+			return nil, fmt.Errorf("第%d行: 无效的行号 %s", lineNum, fields[0][sep+1:])
+		}
+		bp := Breakpoint{File: fields[0][:sep], Line: line, Enabled: true}
+		for _, flag := range fields[1:] {
+			switch flag {
+			case "disabled":
+				bp.Enabled = false
+			case "enabled":
+			default:
+				return nil, fmt.Errorf("第%d行: 未知的选项 %s", lineNum, flag)
+			}
+		}
+		breakpoints = append(breakpoints, bp)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取断点文件失败: %v", err)
+	}
+	return breakpoints, nil
+}
+
+// 断点导入结果
+type BreakpointImport struct {
+	Added      int
+	Duplicates int      // 合并时已存在的位置
+	Missing    []string // 在当前项目中找不到的源文件
+}
+
+// 是否为同一位置的断点
+func SameBreakpointLocation(a, b Breakpoint) bool {
+	if a.Binary != "" || b.Binary != "" {
+		return a.Binary == b.Binary && a.Function == b.Function
+	}
+	return a.File == b.File && a.Line == b.Line
+}
@@ -0,0 +1,109 @@
+package project_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestBreakpointPathAmbiguousSuffix(t *testing.T) {
+	root := t.TempDir()
+	for _, rel := range []string{"net/core.c", "usb/core.c", "usb/host/hcd.c", "pci/host/hcd.c", "pci/quirks.c"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("int x;\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for stored, want := range map[string]string{
+		"/old/tree/core.c":             "/old/tree/core.c", // net/core.c 和 usb/core.c 都匹配，不猜
+		"/old/tree/drivers/usb/core.c": filepath.Join(root, "usb/core.c"),
+		"/old/tree/host/hcd.c":         "/old/tree/host/hcd.c",
+		"/old/tree/quirks.c":           filepath.Join(root, "pci/quirks.c"),
+		"/old/tree/missing.c":          "/old/tree/missing.c",
+	} {
+		if got := project.ResolveBreakpointPath(root, stored); got != want {
+			t.Errorf("ResolveBreakpointPath(%s) = %s, want %s", stored, got, want)
+		}
+	}
+}
+
+func TestBreakpointPathMigration(t *testing.T) {
+	root := filepath.Dir(testsupport.WriteDriver(t))
+	proj := &project.ProjectInfo{RootPath: root}
+	stored := `[
+  {"File": "` + filepath.Join(root, "drv.c") + `", "Line": 5, "Function": "scale", "Enabled": true},
+  {"File": "/old/checkout/drv.c", "Line": 11, "Function": "do_work", "Enabled": true},
+  {"File": "gone.c", "Line": 3, "Function": "gone", "Enabled": true}
+]`
+	if err := os.WriteFile(filepath.Join(root, ".debug_breakpoints.json"), []byte(stored), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := project.LoadBreakpoints(proj); err != nil {
+		t.Fatal(err)
+	}
+	bps := proj.Breakpoints
+	drv := filepath.Join(root, "drv.c")
+	if bps[0].File != drv || bps[1].File != drv || bps[2].File != filepath.Join(root, "gone.c") {
+		t.Errorf("resolved paths = %s, %s, %s", bps[0].File, bps[1].File, bps[2].File)
+	}
+	// 迁移后写回的文件只有相对路径
+	saved, err := project.ReadBreakpointsFile(filepath.Join(root, ".debug_breakpoints.json"))
+	if err != nil || saved[0].File != "drv.c" || saved[1].File != "drv.c" {
+		t.Errorf("saved = %+v, %v", saved, err)
+	}
+
+	if unmatched := project.UnmatchedBreakpoints(proj); len(unmatched) != 1 || unmatched[0] != 2 {
+		t.Errorf("UnmatchedBreakpoints = %v", unmatched)
+	}
+	if err := project.RepairBreakpoint(proj, 3, "drv.c"); err != nil || proj.Breakpoints[2].Function != "scale" {
+		t.Errorf("RepairBreakpoint = %v, %+v", err, proj.Breakpoints[2])
+	}
+	if n, err := project.DropUnmatchedBreakpoints(proj); err != nil || n != 0 {
+		t.Errorf("DropUnmatchedBreakpoints = %d, %v", n, err)
+	}
+}
+
+func TestParseBreakpointText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bps.txt")
+	text := `# 断点列表
+drv.c:5
+drv/core.c:120 disabled   # 暂不启用
+
+drv.c:11 enabled
+`
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bps, err := project.ParseBreakpointText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []project.Breakpoint{
+		{File: "drv.c", Line: 5, Enabled: true},
+		{File: "drv/core.c", Line: 120, Enabled: false},
+		{File: "drv.c", Line: 11, Enabled: true},
+	}
+	if len(bps) != len(want) {
+		t.Fatalf("breakpoints = %+v", bps)
+	}
+	for i := range want {
+		if !project.SameBreakpointLocation(bps[i], want[i]) || bps[i].Enabled != want[i].Enabled {
+			t.Errorf("breakpoint %d = %+v, want %+v", i, bps[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"drv.c\n", "drv.c:0\n", "drv.c:5 once\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := project.ParseBreakpointText(path); err == nil {
+			t.Errorf("ParseBreakpointText(%q) should fail", bad)
+		}
+	}
+}
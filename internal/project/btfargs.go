@@ -0,0 +1,11 @@
+package project
+
+// ========== 函数参数（BTF） ==========
+
+// 函数的一个参数
+type FuncArg struct {
+	Name   string
+	Type   string // C类型（显示用）
+	Size   int
+	Signed bool
+}
@@ -0,0 +1,338 @@
+package project
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+)
+
+// ========== C源码解析 ==========
+// 断点所在的函数名和 vars auto 的变量列表来自对驱动源码的语法分析（tree-sitter-c），而不是逐行匹配：
+// 跨行的声明、typedef类型（u32、my_handle_t）、一行多个变量、for循环中的声明都能识别，
+// 结构体成员的赋值（dev->count = 1）不会被当成变量。解析不需要内核头文件，宏和语法错误只影响所在的语句。
+// 解析前把 __init、__user、__attribute__((...)) 等内核注解替换为等长的空格，行号不变。
+// DEFINE_MUTEX(lock)、DECLARE_WAITQUEUE(wait, current)、LIST_HEAD(list) 这类宏定义的局部变量按第一个参数识别。
+// 解析结果按文件的修改时间缓存。
+
+// 一个声明（参数或局部变量）
+type CDecl struct {
+	Name string
+	Type string // C类型（显示用）
+	Line int
+}
+
+// 源码中的一个函数定义
+type CFunction struct {
+	Name      string
+	StartLine int // 函数定义的第一行（含返回类型），从1开始
+	EndLine   int // 右大括号所在行
+	Params    []CDecl
+	Locals    []CDecl
+}
+
+// 解析结果缓存（文件修改时间或大小变化时重新解析）
+type cSourceEntry struct {
+	modTime   time.Time
+	size      int64
+	functions []CFunction
+}
+
+var cSourceCache = struct {
+	sync.Mutex
+	entries map[string]cSourceEntry
+}{entries: make(map[string]cSourceEntry)}
+
+// 解析前去掉的内核注解（后面带括号时连同括号一起去掉）
+var kernelAnnotations = map[string]bool{
+	"__init": true, "__exit": true, "__initdata": true, "__exitdata": true, "__initconst": true,
+	"__devinit": true, "__devexit": true, "__ref": true, "__sched": true,
+	"__user": true, "__iomem": true, "__kernel": true, "__force": true, "__rcu": true, "__percpu": true,
+	"__bitwise": true, "__nocast": true,
+	"__must_check": true, "__maybe_unused": true, "__always_unused": true, "__used": true,
+	"__always_inline": true, "noinline": true, "notrace": true, "__cold": true, "__hot": true,
+	"__visible": true, "asmlinkage": true, "__weak": true, "__pure": true, "__noreturn": true,
+	"__read_mostly": true, "__ro_after_init": true, "__packed": true, "__aligned": true,
+	"__section": true, "__printf": true, "__attribute__": true,
+}
+
+// 声明局部变量的宏：DEFINE_*/DECLARE_* 和 LIST_HEAD，第一个参数是变量名
+func isDeclaringMacro(name string) bool {
+	return strings.HasPrefix(name, "DEFINE_") || strings.HasPrefix(name, "DECLARE_") || name == "LIST_HEAD"
+}
+
+// 把内核注解替换为空格（保留换行，字节偏移和行号不变）
+func blankKernelAnnotations(src []byte) []byte {
+	out := append([]byte(nil), src...)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' {
+				out[i] = ' '
+			}
+		}
+	}
+	isIdent := func(b byte) bool {
+		return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+	}
+	for i := 0; i < len(out); {
+		if !isIdent(out[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(out) && isIdent(out[i]) {
+			i++
+		}
+		if !kernelAnnotations[string(out[start:i])] {
+			continue
+		}
+		blank(start, i)
+		// 带参数的注解：__aligned(8)、__attribute__((packed))
+		j := i
+		for j < len(out) && (out[j] == ' ' || out[j] == '\t') {
+			j++
+		}
+		if j >= len(out) || out[j] != '(' {
+			continue
+		}
+		depth := 0
+		for ; j < len(out); j++ {
+			if out[j] == '(' {
+				depth++
+			} else if out[j] == ')' {
+				depth--
+				if depth == 0 {
+					j++
+					break
+				}
+			}
+		}
+		blank(i, j)
+		i = j
+	}
+	return out
+}
+
+// 解析C源文件中的所有函数定义
+func ParseCSource(path string) ([]CFunction, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	cSourceCache.Lock()
+	entry, ok := cSourceCache.entries[path]
+	cSourceCache.Unlock()
+	if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.functions, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src := blankKernelAnnotations(content)
+	parser := sitter.NewParser()
+	parser.SetLanguage(c.GetLanguage())
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	functions := make([]CFunction, 0)
+	collectFunctions(tree.RootNode(), src, &functions)
+
+	cSourceCache.Lock()
+	cSourceCache.entries[path] = cSourceEntry{modTime: info.ModTime(), size: info.Size(), functions: functions}
+	cSourceCache.Unlock()
+	return functions, nil
+}
+
+// 查找包含指定行的函数（行号从1开始），不在函数中时返回nil
+func CFunctionAt(path string, line int) *CFunction {
+	functions, err := ParseCSource(path)
+	if err != nil {
+		return nil
+	}
+	for i := range functions {
+		if line >= functions[i].StartLine && line <= functions[i].EndLine {
+			return &functions[i]
+		}
+	}
+	return nil
+}
+
+// 遍历语法树收集函数定义（函数定义可能在 #ifdef 等预处理块中）
+func collectFunctions(node *sitter.Node, src []byte, functions *[]CFunction) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		if child.Type() != "function_definition" {
+			collectFunctions(child, src, functions)
+			continue
+		}
+		fn, ok := parseFunctionDefinition(child, src)
+		if ok {
+			*functions = append(*functions, fn)
+		}
+	}
+}
+
+// 解析一个函数定义：名称、参数和函数体中的所有局部变量
+func parseFunctionDefinition(node *sitter.Node, src []byte) (CFunction, bool) {
+	fn := CFunction{
+		StartLine: int(node.StartPoint().Row) + 1,
+		EndLine:   int(node.EndPoint().Row) + 1,
+	}
+	// 返回指针的函数：pointer_declarator -> function_declarator
+	declarator := node.ChildByFieldName("declarator")
+	for declarator != nil && declarator.Type() != "function_declarator" {
+		declarator = declarator.ChildByFieldName("declarator")
+	}
+	if declarator == nil {
+		return fn, false
+	}
+	name := declarator.ChildByFieldName("declarator")
+	if name == nil || name.Type() != "identifier" {
+		return fn, false
+	}
+	fn.Name = name.Content(src)
+
+	if params := declarator.ChildByFieldName("parameters"); params != nil {
+		for i := 0; i < int(params.NamedChildCount()); i++ {
+			param := params.NamedChild(i)
+			if param.Type() != "parameter_declaration" {
+				continue
+			}
+			fn.Params = append(fn.Params, declarationNames(param, src)...)
+		}
+	}
+	if body := node.ChildByFieldName("body"); body != nil {
+		collectLocals(body, src, &fn.Locals)
+	}
+	return fn, true
+}
+
+// 收集函数体（含嵌套的块、for循环初始化、预处理块）中声明的变量
+func collectLocals(node *sitter.Node, src []byte, locals *[]CDecl) {
+	switch node.Type() {
+	case "declaration":
+		*locals = append(*locals, declarationNames(node, src)...)
+		return
+	case "call_expression":
+		// DEFINE_MUTEX(lock); 被解析为函数调用
+		function := node.ChildByFieldName("function")
+		args := node.ChildByFieldName("arguments")
+		if function != nil && function.Type() == "identifier" && isDeclaringMacro(function.Content(src)) &&
+			args != nil && args.NamedChildCount() > 0 && args.NamedChild(0).Type() == "identifier" &&
+			node.Parent() != nil && node.Parent().Type() == "expression_statement" {
+			*locals = append(*locals, CDecl{
+				Name: args.NamedChild(0).Content(src),
+				Type: function.Content(src),
+				Line: int(node.StartPoint().Row) + 1,
+			})
+		}
+		return
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		collectLocals(node.NamedChild(i), src, locals)
+	}
+}
+
+// 声明或参数中的每个变量：int a, *b = NULL; 得到 a(int) 和 b(int *)
+func declarationNames(node *sitter.Node, src []byte) []CDecl {
+	typeNode := node.ChildByFieldName("type")
+	if typeNode == nil {
+		return nil
+	}
+	base := declarationType(node, typeNode, src)
+	decls := make([]CDecl, 0, 1)
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.FieldNameForChild(i) != "declarator" {
+			continue
+		}
+		name, suffix := declaratorName(node.Child(i), src)
+		if name == "" {
+			continue
+		}
+		typ := base
+		if suffix != "" && suffix[0] != '[' {
+			typ += " "
+		}
+		decls = append(decls, CDecl{Name: name, Type: typ + suffix, Line: int(node.Child(i).StartPoint().Row) + 1})
+	}
+	return decls
+}
+
+// 声明的基本类型：限定符 + 类型说明（内联定义的结构体只保留 struct 名称）
+func declarationType(node, typeNode *sitter.Node, src []byte) string {
+	parts := make([]string, 0, 2)
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		if child := node.NamedChild(i); child.Type() == "type_qualifier" {
+			parts = append(parts, child.Content(src))
+		}
+	}
+	typ := typeNode.Content(src)
+	if brace := strings.Index(typ, "{"); brace >= 0 {
+		typ = typ[:brace]
+	}
+	parts = append(parts, strings.Join(strings.Fields(typ), " "))
+	return strings.Join(parts, " ")
+}
+
+// 声明符中的变量名和类型后缀（指针星号、数组维数）
+func declaratorName(node *sitter.Node, src []byte) (string, string) {
+	switch node.Type() {
+	case "identifier", "field_identifier":
+		return node.Content(src), ""
+	case "init_declarator", "attributed_declarator":
+		if inner := node.ChildByFieldName("declarator"); inner != nil {
+			return declaratorName(inner, src)
+		}
+		if node.NamedChildCount() > 0 {
+			return declaratorName(node.NamedChild(0), src)
+		}
+	case "pointer_declarator":
+		if inner := node.ChildByFieldName("declarator"); inner != nil {
+			name, suffix := declaratorName(inner, src)
+			return name, "*" + suffix
+		}
+	case "array_declarator":
+		if inner := node.ChildByFieldName("declarator"); inner != nil {
+			name, suffix := declaratorName(inner, src)
+			size := ""
+			if n := node.ChildByFieldName("size"); n != nil {
+				size = n.Content(src)
+			}
+			return name, suffix + "[" + size + "]"
+		}
+	case "function_declarator":
+		// 函数指针：int (*handler)(int)
+		if inner := node.ChildByFieldName("declarator"); inner != nil {
+			name, suffix := declaratorName(inner, src)
+			return name, "(" + suffix + ")()"
+		}
+	case "parenthesized_declarator":
+		if node.NamedChildCount() > 0 {
+			return declaratorName(node.NamedChild(0), src)
+		}
+	}
+	return "", ""
+}
+
+// 检查是否是C关键字
+func IsKeyword(word string) bool {
+	keywords := map[string]bool{
+		"if": true, "else": true, "while": true, "for": true, "do": true,
+		"switch": true, "case": true, "default": true, "break": true, "continue": true,
+		"return": true, "goto": true, "sizeof": true, "typedef": true,
+		"struct": true, "union": true, "enum": true, "const": true, "static": true,
+		"extern": true, "inline": true, "volatile": true, "register": true,
+		"int": true, "char": true, "void": true, "long": true, "short": true,
+		"unsigned": true, "signed": true, "float": true, "double": true,
+	}
+	return keywords[word]
+}
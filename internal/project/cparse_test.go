@@ -0,0 +1,50 @@
+package project_test
+
+import (
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestParseFunctionName(t *testing.T) {
+	file := testsupport.WriteDriver(t)
+	tests := []struct {
+		line int
+		want string
+	}{
+		{1, ""},
+		{5, "scale"},
+		{6, "scale"},
+		{11, "do_work"},
+		{13, "do_work"},
+	}
+	for _, tt := range tests {
+		if got := project.ParseFunctionName(file, tt.line); got != tt.want {
+			t.Errorf("ParseFunctionName(drv.c, %d) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseCSource(t *testing.T) {
+	functions, err := project.ParseCSource(testsupport.WriteDriver(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 2 {
+		t.Fatalf("functions = %+v", functions)
+	}
+	scale, work := functions[0], functions[1]
+	if scale.Name != "scale" || scale.StartLine != 3 || scale.EndLine != 7 {
+		t.Errorf("scale = %s %d-%d", scale.Name, scale.StartLine, scale.EndLine)
+	}
+	if len(scale.Params) != 1 || scale.Params[0].Name != "x" || scale.Params[0].Type != "int" {
+		t.Errorf("scale params = %+v", scale.Params)
+	}
+	if len(scale.Locals) != 1 || scale.Locals[0].Name != "factor" || scale.Locals[0].Line != 5 {
+		t.Errorf("scale locals = %+v", scale.Locals)
+	}
+	if work.Name != "do_work" || len(work.Params) != 2 || work.Params[1].Name != "b" {
+		t.Errorf("do_work = %+v", work)
+	}
+}
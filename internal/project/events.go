@@ -0,0 +1,9 @@
+package project
+
+// ========== 调试事件 ==========
+
+// 变量值
+type EventValue struct {
+	Name  string
+	Value string
+}
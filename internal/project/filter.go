@@ -0,0 +1,10 @@
+package project
+
+// ========== 探针过滤 ==========
+
+// 探针过滤条件（保存在项目设置中）
+type ProbeFilter struct {
+	PID  int    `json:"pid,omitempty"`  // 进程号（tgid，包含进程的所有线程）
+	Comm string `json:"comm,omitempty"` // 进程名（内核中最多15个字符）
+	CPU  *int   `json:"cpu,omitempty"`  // CPU编号
+}
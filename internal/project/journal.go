@@ -0,0 +1,58 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"path/filepath"
+	"encoding/json"
+	"io/ioutil"
+)
+
+// ========== 操作日志（可重放的项目设置步骤） ==========
+
+// 操作日志文件名
+const JournalFile = ".debug_journal.json"
+
+// 调试操作记录
+type DebugOperation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"` // 可重放的命令行
+}
+
+// 从文件加载操作日志
+func loadJournal(project *ProjectInfo) error {
+	if project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+
+	journalPath := filepath.Join(project.RootPath, JournalFile)
+	if _, err := os.Stat(journalPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(journalPath)
+	if err != nil {
+		return fmt.Errorf("读取操作日志失败: %v", err)
+	}
+
+	var journal []DebugOperation
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return fmt.Errorf("解析操作日志失败: %v", err)
+	}
+	project.Journal = journal
+
+	return nil
+}
+
+// 将断点路径转换为项目相对路径，便于在其他位置重放
+func ProjectRelativePath(project *ProjectInfo, path string) string {
+	if project == nil {
+		return path
+	}
+	if rel, err := filepath.Rel(project.RootPath, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
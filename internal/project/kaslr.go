@@ -0,0 +1,49 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"bufio"
+	"io/ioutil"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== KASLR检测 ==========
+
+// 读取当前内核版本号
+func KernelRelease() string {
+	data, err := ioutil.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// 从 /proc/kallsyms 读取符号的运行时地址
+func ReadKallsymsSymbol(name string) (uint64, error) {
+	file, err := os.Open("/proc/kallsyms")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 格式: <地址> <类型> <符号名> [模块]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[2] != name {
+			continue
+		}
+		var addr uint64
+		if _, err := fmt.Sscanf(fields[0], "%x", &addr); err != nil {
+			return 0, err
+		}
+		return addr, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errcode.Errorf(errcode.ErrNoSymbol, "符号 %s 不在 /proc/kallsyms 中", name)
+}
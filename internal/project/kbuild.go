@@ -0,0 +1,233 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ========== Makefile/Kbuild项目模型 ==========
+// 打开项目时解析根目录的Kbuild和Makefile：obj-m（模块）、<模块>-objs/-y（组成模块的目标文件）、
+// ccflags-y/EXTRA_CFLAGS和KDIR，以及Makefile中的目标。make build/clean 在后台运行make，
+// 输出显示在Build Output窗口中，编译器诊断（file:line:col: error）按Enter跳转到源码。
+
+// 构建输出窗口保留的最大行数（超出时保留最后的部分）
+const MaxBuildOutputLines = 2000
+
+// 从Makefile/Kbuild解析出的项目信息
+type KbuildInfo struct {
+	Files   []string            // 解析过的文件（Kbuild、Makefile）
+	Modules []string            // obj-m 中的模块（不含.o）
+	Objects map[string][]string // 模块 -> 组成它的目标文件
+	CFlags  []string            // ccflags-y / EXTRA_CFLAGS
+	KDIR    string              // 内核构建目录（Makefile中的原始写法）
+	Targets []string            // Makefile中的目标
+}
+
+var (
+	// obj-m += foo.o / foo-objs := a.o b.o / KDIR ?= ...
+	kbuildAssignRegex = regexp.MustCompile(`^([A-Za-z0-9_.$()-]+)\s*(\+=|:=|\?=|=)\s*(.*)$`)
+	// all: / clean: / modules_install:
+	makeTargetRegex = regexp.MustCompile(`^([A-Za-z0-9_.-]+)\s*:([^=]|$)`)
+)
+
+// 读取Makefile/Kbuild的逻辑行（合并反斜杠续行，去掉注释）
+func readMakeLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	lines := make([]string, 0)
+	pending := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			pending += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		line = pending + line
+		pending = ""
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// 解析项目根目录的Kbuild和Makefile（都不存在时返回nil）
+func ParseKbuild(root string) *KbuildInfo {
+	info := &KbuildInfo{Objects: make(map[string][]string)}
+	for _, name := range []string{"Kbuild", "Makefile", "makefile"} {
+		path := filepath.Join(root, name)
+		lines, err := readMakeLines(path)
+		if err != nil {
+			continue
+		}
+		info.Files = append(info.Files, name)
+		for _, line := range lines {
+			if strings.HasPrefix(line, "\t") {
+				// 规则的命令
+				continue
+			}
+			line = strings.TrimSpace(line)
+			if m := kbuildAssignRegex.FindStringSubmatch(line); m != nil {
+				info.assign(m[1], m[2], strings.Fields(m[3]))
+				continue
+			}
+			if m := makeTargetRegex.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[1], ".") {
+				info.addTarget(m[1])
+			}
+		}
+	}
+	if len(info.Files) == 0 {
+		return nil
+	}
+	return info
+}
+
+// 处理一个变量赋值
+func (k *KbuildInfo) assign(name, op string, values []string) {
+	switch {
+	case name == "obj-m" || name == "obj-$(CONFIG_m)":
+		if op == ":=" || op == "=" {
+			k.Modules = nil
+		}
+		for _, v := range values {
+			if strings.HasSuffix(v, ".o") {
+				k.Modules = append(k.Modules, strings.TrimSuffix(v, ".o"))
+			}
+		}
+	case name == "ccflags-y" || name == "EXTRA_CFLAGS":
+		if op == ":=" || op == "=" {
+			k.CFlags = nil
+		}
+		k.CFlags = append(k.CFlags, values...)
+	case name == "KDIR" || name == "KERNELDIR" || name == "KERNEL_DIR" || name == "KSRC":
+		if op != "?=" || k.KDIR == "" {
+			k.KDIR = strings.Join(values, " ")
+		}
+	case strings.HasSuffix(name, "-objs") || strings.HasSuffix(name, "-y"):
+		module := strings.TrimSuffix(strings.TrimSuffix(name, "-objs"), "-y")
+		if module == "obj" || module == "ccflags" || module == "ldflags" || module == "asflags" {
+			return
+		}
+		if op == ":=" || op == "=" {
+			k.Objects[module] = nil
+		}
+		k.Objects[module] = append(k.Objects[module], values...)
+	}
+}
+
+func (k *KbuildInfo) addTarget(name string) {
+	for _, t := range k.Targets {
+		if t == name {
+			return
+		}
+	}
+	k.Targets = append(k.Targets, name)
+}
+
+// 展开KDIR中常见的写法（$(shell uname -r)、$(PWD)），为空时使用当前内核的构建目录
+func (k *KbuildInfo) KernelDir(root string) string {
+	dir := k.KDIR
+	if dir == "" {
+		return filepath.Join("/lib/modules", KernelRelease(), "build")
+	}
+	for _, r := range []struct{ from, to string }{
+		{"$(shell uname -r)", KernelRelease()},
+		{"$(PWD)", root},
+		{"$(CURDIR)", root},
+		{"$(src)", root},
+	} {
+		dir = strings.ReplaceAll(dir, r.from, r.to)
+	}
+	return dir
+}
+
+// make info 的显示内容
+func KbuildInfoLines(k *KbuildInfo, root string) []string {
+	if k == nil {
+		return []string{"No Makefile or Kbuild in the project root"}
+	}
+	lines := []string{fmt.Sprintf("Kbuild: %s", strings.Join(k.Files, ", "))}
+	if len(k.Modules) == 0 {
+		lines = append(lines, "  obj-m: (none)")
+	}
+	for _, module := range k.Modules {
+		objects := k.Objects[module]
+		if len(objects) == 0 {
+			objects = []string{module + ".o"}
+		}
+		lines = append(lines, fmt.Sprintf("  %s.ko <- %s", module, strings.Join(objects, " ")))
+	}
+	if len(k.CFlags) > 0 {
+		lines = append(lines, "  ccflags-y: "+strings.Join(k.CFlags, " "))
+	}
+	lines = append(lines, fmt.Sprintf("  KDIR: %s", k.KernelDir(root)))
+	if len(k.Targets) > 0 {
+		lines = append(lines, "  Targets: "+strings.Join(k.Targets, " "))
+	}
+	return lines
+}
+
+// ========== 编译器诊断 ==========
+
+// 一条编译器诊断
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string // error / warning / note
+	Message  string
+}
+
+// foo.c:12:5: error: ... / foo.c:12: warning: ...（gcc、clang和ld）
+var diagnosticRegex = regexp.MustCompile(`^([^:\s][^:]*):(\d+):(?:(\d+):)?\s+(fatal error|error|warning|note):\s+(.*)$`)
+
+// 解析一行编译器输出
+func ParseDiagnostic(line string) (Diagnostic, bool) {
+	m := diagnosticRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	d := Diagnostic{File: m[1], Severity: m[4], Message: m[5]}
+	d.Line, _ = strconv.Atoi(m[2])
+	d.Column, _ = strconv.Atoi(m[3])
+	if d.Severity == "fatal error" {
+		d.Severity = "error"
+	}
+	return d, true
+}
+
+// 诊断行的颜色
+func DiagnosticColor(severity string) string {
+	switch severity {
+	case "error":
+		return "\x1b[31m"
+	case "warning":
+		return "\x1b[33m"
+	}
+	return "\x1b[90m"
+}
+
+// 可用的make目标（build和clean总是可用）
+func MakeTargets(k *KbuildInfo) []string {
+	targets := []string{"build", "clean"}
+	if k != nil {
+		for _, t := range k.Targets {
+			if t != "clean" {
+				targets = append(targets, t)
+			}
+		}
+	}
+	sort.Strings(targets[2:])
+	return targets
+}
@@ -0,0 +1,71 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseKbuild(t *testing.T) {
+	root := t.TempDir()
+	makefile := `# 外部模块
+obj-m += drv.o
+drv-objs := core.o \
+	hw.o   # 硬件相关
+ccflags-y := -DDEBUG
+ccflags-y += -I$(src)/include
+KDIR ?= /lib/modules/$(shell uname -r)/build
+
+all:
+	$(MAKE) -C $(KDIR) M=$(PWD) modules
+clean:
+	$(MAKE) -C $(KDIR) M=$(PWD) clean
+.PHONY: all clean
+`
+	if err := os.WriteFile(filepath.Join(root, "Makefile"), []byte(makefile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info := ParseKbuild(root)
+	if info == nil {
+		t.Fatal("ParseKbuild = nil")
+	}
+	if !reflect.DeepEqual(info.Modules, []string{"drv"}) {
+		t.Errorf("Modules = %q", info.Modules)
+	}
+	if !reflect.DeepEqual(info.Objects["drv"], []string{"core.o", "hw.o"}) {
+		t.Errorf("Objects = %q", info.Objects)
+	}
+	if !reflect.DeepEqual(info.CFlags, []string{"-DDEBUG", "-I$(src)/include"}) {
+		t.Errorf("CFlags = %q", info.CFlags)
+	}
+	if info.KDIR != "/lib/modules/$(shell uname -r)/build" {
+		t.Errorf("KDIR = %q", info.KDIR)
+	}
+	if !reflect.DeepEqual(info.Targets, []string{"all", "clean"}) {
+		t.Errorf("Targets = %q", info.Targets)
+	}
+
+	if ParseKbuild(t.TempDir()) != nil {
+		t.Error("ParseKbuild without Makefile should return nil")
+	}
+}
+
+func TestParseDiagnostic(t *testing.T) {
+	tests := []struct {
+		line string
+		want Diagnostic
+		ok   bool
+	}{
+		{"drv.c:12:5: error: 'foo' undeclared", Diagnostic{File: "drv.c", Line: 12, Column: 5, Severity: "error", Message: "'foo' undeclared"}, true},
+		{"  include/drv.h:3: warning: unused variable", Diagnostic{File: "include/drv.h", Line: 3, Severity: "warning", Message: "unused variable"}, true},
+		{"drv.c:1:10: fatal error: linux/foo.h: No such file", Diagnostic{File: "drv.c", Line: 1, Column: 10, Severity: "error", Message: "linux/foo.h: No such file"}, true},
+		{"make[1]: Entering directory '/src'", Diagnostic{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDiagnostic(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseDiagnostic(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package project
+
+// ========== 标记（vim风格的跨文件书签） ==========
+
+// 标记位置
+type Mark struct {
+	File string `json:"file"` // 相对项目根目录的路径
+	Line int    `json:"line"`
+}
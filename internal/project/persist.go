@@ -0,0 +1,313 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+)
+
+// ========== 断点持久化功能 ==========
+// .debug_breakpoints.json 中的源文件路径相对于项目根目录，移动项目或在另一台机器上打开时断点仍然有效；
+// 内存中的断点使用绝对路径。旧版本保存的绝对路径在加载时迁移：项目内的路径直接改为相对路径，
+// 不存在的路径按最长的路径后缀在项目中重新定位（/old/checkout/drivers/foo.c → drivers/foo.c），
+// 迁移后立即写回。仍然找不到源文件的断点用 bp repair 列出和修复。
+
+// 保存断点到文件
+func SaveBreakpoints(project *ProjectInfo) error {
+	if project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	
+	breakpointsPath := filepath.Join(project.RootPath, ".debug_breakpoints.json")
+	
+	// 将断点序列化为JSON（路径相对于项目根目录）
+	portable := make([]Breakpoint, 0, len(project.Breakpoints))
+	for _, bp := range project.Breakpoints {
+		portable = append(portable, PortableBreakpoint(project, bp))
+	}
+	data, err := json.MarshalIndent(portable, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化断点失败: %v", err)
+	}
+	
+	// 写入文件
+	err = ioutil.WriteFile(breakpointsPath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("保存断点文件失败: %v", err)
+	}
+	
+	return nil
+}
+
+// 从文件加载断点
+func LoadBreakpoints(project *ProjectInfo) error {
+	if project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	
+	breakpointsPath := filepath.Join(project.RootPath, ".debug_breakpoints.json")
+	
+	// 检查文件是否存在
+	if _, err := os.Stat(breakpointsPath); os.IsNotExist(err) {
+		// 文件不存在，不是错误，只是没有保存的断点
+		return nil
+	}
+	
+	breakpoints, err := ReadBreakpointsFile(breakpointsPath)
+	if err != nil {
+		return err
+	}
+	
+	// 加载断点到项目，旧的绝对路径迁移后写回
+	migrated := false
+	for i := range breakpoints {
+		stored := breakpoints[i].File
+		breakpoints[i].File = ResolveBreakpointPath(project.RootPath, stored)
+		if filepath.IsAbs(stored) && ProjectRelativePath(project, breakpoints[i].File) != breakpoints[i].File {
+			migrated = true
+		}
+	}
+	project.Breakpoints = breakpoints
+	if migrated {
+		return SaveBreakpoints(project)
+	}
+	
+	return nil
+}
+
+// 断点的存储副本：路径改为相对项目根目录（项目外的路径不变）
+func PortableBreakpoint(project *ProjectInfo, bp Breakpoint) Breakpoint {
+	if bp.File != "" {
+		bp.File = ProjectRelativePath(project, bp.File)
+	}
+	return bp
+}
+
+// 断点文件中的路径在当前项目中的位置：相对路径以项目根目录为基准；
+// 不存在的绝对路径按最长的相同后缀在项目中查找，只有唯一的文件匹配时才重定位，
+// 找不到或有多个文件匹配时原样返回（bp repair 中列出，由用户选择）
+func ResolveBreakpointPath(root, path string) string {
+	if path == "" {
+		return path
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(root, path)
+	}
+	if FileExists(path) {
+		return path
+	}
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	var best []string
+	bestLen := 0
+	WalkProjectSources(root, func(p string) bool {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return true
+		}
+		n := commonSuffixLen(parts, strings.Split(filepath.ToSlash(rel), "/"))
+		switch {
+		case n == 0 || n < bestLen:
+		case n > bestLen:
+			best, bestLen = []string{p}, n
+		default:
+			best = append(best, p)
+		}
+		return true
+	})
+	if len(best) == 1 {
+		return best[0]
+	}
+	return path
+}
+
+// 两个路径末尾相同的路径分量数
+func commonSuffixLen(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+	return n
+}
+
+// 源文件不存在的断点（用户态探针没有源码时不算），返回从0开始的下标
+func UnmatchedBreakpoints(project *ProjectInfo) []int {
+	var unmatched []int
+	for i, bp := range project.Breakpoints {
+		if bp.File != "" && !FileExists(bp.File) {
+			unmatched = append(unmatched, i)
+		}
+	}
+	return unmatched
+}
+
+// 项目中与断点源文件同名的文件，作为修复时的候选
+func repairCandidates(root, path string) []string {
+	var candidates []string
+	name := filepath.Base(path)
+	WalkProjectSources(root, func(p string) bool {
+		if filepath.Base(p) == name {
+			candidates = append(candidates, p)
+		}
+		return true
+	})
+	return candidates
+}
+
+// bp repair：列出找不到源文件的断点和候选文件
+func BreakpointRepairLines(project *ProjectInfo) []string {
+	unmatched := UnmatchedBreakpoints(project)
+	if len(unmatched) == 0 {
+		return []string{fmt.Sprintf("All %d breakpoints match files in this project", len(project.Breakpoints))}
+	}
+	output := []string{fmt.Sprintf("%d breakpoints point to missing files:", len(unmatched))}
+	for _, i := range unmatched {
+		bp := project.Breakpoints[i]
+		output = append(output, fmt.Sprintf("  %d. %s:%d (%s)", i+1, ProjectRelativePath(project, bp.File), bp.Line, bp.Function))
+		for _, candidate := range repairCandidates(project.RootPath, bp.File) {
+			output = append(output, fmt.Sprintf("       candidate: bp repair %d %s", i+1, ProjectRelativePath(project, candidate)))
+		}
+	}
+	return append(output, "Fix with 'bp repair <n> <file>' (relative to the project), remove all with 'bp repair drop'")
+}
+
+// 把第n个断点（从1开始）移到项目中的另一个文件（同一行号）
+func RepairBreakpoint(project *ProjectInfo, n int, file string) error {
+	if n < 1 || n > len(project.Breakpoints) {
+		return fmt.Errorf("断点编号超出范围: %d (共%d个)", n, len(project.Breakpoints))
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(project.RootPath, file)
+	}
+	if !FileExists(file) {
+		return fmt.Errorf("文件不存在: %s", file)
+	}
+	bp := &project.Breakpoints[n-1]
+	bp.File = file
+	if function := ParseFunctionName(file, bp.Line); function != "" {
+		bp.Function = function
+	}
+	return SaveBreakpoints(project)
+}
+
+// 删除所有找不到源文件的断点，返回删除的个数
+func DropUnmatchedBreakpoints(project *ProjectInfo) (int, error) {
+	unmatched := UnmatchedBreakpoints(project)
+	if len(unmatched) == 0 {
+		return 0, nil
+	}
+	kept := make([]Breakpoint, 0, len(project.Breakpoints)-len(unmatched))
+	for _, bp := range project.Breakpoints {
+		if bp.File == "" || FileExists(bp.File) {
+			kept = append(kept, bp)
+		}
+	}
+	project.Breakpoints = kept
+	return len(unmatched), SaveBreakpoints(project)
+}
+
+// 读取断点文件（.debug_breakpoints.json 格式）
+func ReadBreakpointsFile(path string) ([]Breakpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取断点文件失败: %v", err)
+	}
+	var breakpoints []Breakpoint
+	if err := json.Unmarshal(data, &breakpoints); err != nil {
+		return nil, fmt.Errorf("解析断点文件失败: %v", err)
+	}
+	return breakpoints, nil
+}
+
+// ========== 项目设置持久化 ==========
+
+// 项目设置文件名（与 .debug_breakpoints.json 并列存放在项目根目录）
+const projectSettingsFile = ".debug_settings.json"
+
+// 监视表达式
+type WatchExpression struct {
+	Expr      string       `json:"expr"`                 // 变量名或显示表达式
+	LastValue string       `json:"last_value,omitempty"` // 最近一次捕获的值
+	Stale     bool         `json:"-"`                    // 值是否来自上一次会话（尚未被后端刷新）
+	PrevValue string       `json:"-"`                    // 变化前的值（变量窗口显示新旧值）
+	Members   []EventValue `json:"-"`                    // 结构体指针展开的成员（dev->id 等，按首次出现的顺序）
+}
+
+// 项目设置
+type ProjectSettings struct {
+	Watches    []WatchExpression `json:"watches"`
+	TargetArch string            `json:"target_arch,omitempty"` // 手动指定的目标架构（为空时自动检测）
+	Backend    string            `json:"backend,omitempty"`     // 采集后端：bpf（默认）、ftrace、systemtap、kprobe、gdb、kdb
+	BackendChosen bool           `json:"backend_chosen,omitempty"` // 已选过后端（open 时不再弹出选择窗口）
+	GDB        string            `json:"gdb,omitempty"`         // gdb后端连接的gdbstub（host:port 或串口设备）
+	KDB        string            `json:"kdb,omitempty"`         // kdb后端的串口设备
+	KDBBaud    int               `json:"kdb_baud,omitempty"`    // kdb串口波特率（0为115200）
+	Marks      map[string]Mark   `json:"marks,omitempty"`       // vim风格标记
+	ValueFormats map[string]ValueFormat `json:"value_formats,omitempty"` // 每个变量的数值显示格式
+	SourceMap    []SourceSubstitution   `json:"source_map,omitempty"`    // 源码路径替换规则
+	SourceFetch  *SourceFetchConfig     `json:"source_fetch,omitempty"`  // 缺失源码的获取方式
+	Assertions   []OrderAssertion       `json:"assertions,omitempty"`    // 断点顺序断言
+	Spans        []SpanProbe            `json:"spans,omitempty"`         // 成对探针耗时测量
+	Locks        bool                   `json:"locks,omitempty"`         // 生成锁竞争探针
+	Remote       *RemoteTarget          `json:"remote,omitempty"`        // 远程目标（通过ssh采集事件）
+	Filter       *ProbeFilter           `json:"filter,omitempty"`        // 生成的BPF程序中的pid/comm/cpu过滤
+	Toolchains   map[string]*ToolchainConfig `json:"toolchains,omitempty"` // 按目标架构的BPF编译工具链
+}
+
+// 保存项目设置到文件
+func SaveProjectSettings(project *ProjectInfo) error {
+	if project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	if project.Settings == nil {
+		project.Settings = &ProjectSettings{}
+	}
+
+	settingsPath := filepath.Join(project.RootPath, projectSettingsFile)
+
+	data, err := json.MarshalIndent(project.Settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化项目设置失败: %v", err)
+	}
+
+	if err := ioutil.WriteFile(settingsPath, data, 0644); err != nil {
+		return fmt.Errorf("保存项目设置失败: %v", err)
+	}
+
+	return nil
+}
+
+// 从文件加载项目设置
+func loadProjectSettings(project *ProjectInfo) error {
+	if project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+
+	project.Settings = &ProjectSettings{}
+
+	settingsPath := filepath.Join(project.RootPath, projectSettingsFile)
+	if _, err := os.Stat(settingsPath); os.IsNotExist(err) {
+		// 文件不存在，使用默认设置
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		return fmt.Errorf("读取项目设置失败: %v", err)
+	}
+
+	var settings ProjectSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("解析项目设置失败: %v", err)
+	}
+
+	// 恢复的监视值都来自上一次会话，标记为过期，等待后端重新挂载后刷新
+	for i := range settings.Watches {
+		settings.Watches[i].Stale = true
+	}
+	project.Settings = &settings
+
+	return nil
+}
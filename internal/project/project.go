@@ -0,0 +1,137 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"path/filepath"
+	"bufio"
+	"io/ioutil"
+)
+
+// ========== 项目管理功能 ==========
+
+// 打开项目目录
+func OpenProject(projectPath string) (*ProjectInfo, error) {
+	// 检查目录是否存在
+	if _, err := os.Stat(projectPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("Project directory does not exist: %s", projectPath)
+	}
+	
+	// 创建项目信息
+	project := &ProjectInfo{
+		RootPath:    projectPath,
+		OpenFiles:   make(map[string]*SourceFile),
+		Breakpoints: make([]Breakpoint, 0),
+	}
+	
+	// 构建文件树
+	fileTree, err := buildFileTree(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("构建文件树失败: %v", err)
+	}
+	project.FileTree = fileTree
+	
+	// 解析Makefile/Kbuild（没有时为nil）
+	project.Kbuild = ParseKbuild(projectPath)
+	
+	// 尝试加载保存的断点
+	if err := LoadBreakpoints(project); err != nil {
+		// 如果加载断点失败，记录错误但不影响项目打开
+		// 静默处理，不输出到终端
+	}
+	
+	// 加载项目设置（监视表达式等），失败时使用默认设置
+	if err := loadProjectSettings(project); err != nil {
+		project.Settings = &ProjectSettings{}
+	}
+	
+	// 加载操作日志
+	loadJournal(project)
+	
+	return project, nil
+}
+
+// 构建文件树（只读取根目录，子目录在展开时再读取，见 toggleDirNode）
+func buildFileTree(rootPath string) (*FileNode, error) {
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	
+	root := &FileNode{
+		Name:     filepath.Base(rootPath),
+		Path:     rootPath,
+		IsDir:    info.IsDir(),
+		Children: make([]*FileNode, 0),
+		Expanded: true, // 根目录默认展开
+		Loaded:   true,
+	}
+	
+	if root.IsDir {
+		children, err := ReadDirNodes(rootPath)
+		if err != nil {
+			return root, nil // 返回空的根节点而不是错误
+		}
+		root.Children = children
+	}
+	
+	return root, nil
+}
+
+// 读取一层目录：子目录在前，只保留C/C++源文件和头文件，跳过隐藏文件
+func ReadDirNodes(dirPath string) ([]*FileNode, error) {
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]*FileNode, 0)
+	sources := make([]*FileNode, 0)
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		node := &FileNode{
+			Name:     file.Name(),
+			Path:     filepath.Join(dirPath, file.Name()),
+			IsDir:    file.IsDir(),
+			Children: make([]*FileNode, 0),
+		}
+		if node.IsDir {
+			dirs = append(dirs, node)
+		} else if IsProjectSourceFile(file.Name()) {
+			node.Loaded = true
+			sources = append(sources, node)
+		}
+	}
+	return append(dirs, sources...), nil
+}
+
+// 读取文件内容
+func ReadFileContent(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	
+	return lines, nil
+}
+
+// 从C源码中解析指定行所在的函数名
+func ParseFunctionName(filePath string, targetLine int) string {
+	if fn := CFunctionAt(filePath, targetLine); fn != nil {
+		return fn.Name
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+package project
+
+// ========== 远程目标与看门狗 ==========
+
+// 远程目标配置（保存在项目设置中）
+type RemoteTarget struct {
+	SSH           string `json:"ssh"`                       // ssh目标（user@host 或 ~/.ssh/config 中的别名）
+	PingCommand   string `json:"ping_command,omitempty"`    // 自定义存活检查命令，输出 /proc/uptime 格式时可检测重启
+	AttachCommand string `json:"attach_command,omitempty"`  // 目标重启后重新挂载探针的命令（本地shell执行）
+	User          string `json:"user,omitempty"`            // ssh用户（SSH中没有 user@ 时使用）
+	Key           string `json:"key,omitempty"`             // ssh私钥文件
+	Port          int    `json:"port,omitempty"`            // ssh端口（0表示默认）
+	Dir           string `json:"dir,omitempty"`             // 开发板上存放调试产物的目录（默认 /tmp/kdebug-tui）
+	BuildOnTarget bool   `json:"build_on_target,omitempty"` // compile 在开发板上执行（默认在本机编译）
+}
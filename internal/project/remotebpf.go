@@ -0,0 +1,66 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ========== 远程编译与加载 ==========
+
+// 开发板上存放调试产物的默认目录
+const defaultRemoteDir = "/tmp/kdebug-tui"
+
+// ssh目标：SSH中没有 user@ 时加上配置的用户
+func (r *RemoteTarget) Dest() string {
+	if r.User != "" && !strings.Contains(r.SSH, "@") {
+		return r.User + "@" + r.SSH
+	}
+	return r.SSH
+}
+
+// 开发板上的工作目录
+func (r *RemoteTarget) Directory() string {
+	if r.Dir != "" {
+		return r.Dir
+	}
+	return defaultRemoteDir
+}
+
+// ssh/scp共用的选项（scp的端口参数是 -P）
+func (r *RemoteTarget) sshOptions(portFlag string, options ...string) []string {
+	args := []string{"-o", "BatchMode=yes"}
+	for _, option := range options {
+		args = append(args, "-o", option)
+	}
+	if r.Key != "" {
+		key := r.Key
+		if strings.HasPrefix(key, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				key = filepath.Join(home, key[2:])
+			}
+		}
+		args = append(args, "-i", key)
+	}
+	if r.Port > 0 {
+		args = append(args, portFlag, strconv.Itoa(r.Port))
+	}
+	return args
+}
+
+// 在开发板上执行命令的ssh参数
+func (r *RemoteTarget) SSHArgs(command string, options ...string) []string {
+	return append(r.sshOptions("-p", options...), r.Dest(), command)
+}
+
+// 把本地文件复制到开发板工作目录的scp参数
+func (r *RemoteTarget) SCPToArgs(files []string) []string {
+	args := append(r.sshOptions("-P"), files...)
+	return append(args, r.Dest()+":"+r.Directory()+"/")
+}
+
+// 把开发板上的文件复制回本机的scp参数
+func (r *RemoteTarget) SCPFromArgs(remotePath, localPath string) []string {
+	return append(r.sshOptions("-P"), r.Dest()+":"+remotePath, localPath)
+}
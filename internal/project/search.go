@@ -0,0 +1,39 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ========== 代码搜索功能 ==========
+
+// 与文件树相同的文件类型
+func IsProjectSourceFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".c", ".cpp", ".h", ".hpp":
+		return true
+	}
+	return false
+}
+
+// 依次访问项目中的源文件（跳过隐藏目录），visit 返回false时停止
+func WalkProjectSources(root string, visit func(path string) bool) {
+	stopped := false
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || stopped {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsProjectSourceFile(info.Name()) && !visit(path) {
+			stopped = true
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
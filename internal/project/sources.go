@@ -0,0 +1,46 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ========== 源码定位与按需获取 ==========
+
+// 源码路径替换规则：From前缀替换为To
+type SourceSubstitution struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// 源码获取配置
+type SourceFetchConfig struct {
+	GitTree string `json:"git_tree,omitempty"` // 本地内核git仓库（git show <ref>:<path>）
+	GitRef  string `json:"git_ref,omitempty"`  // git版本，默认HEAD
+	URL     string `json:"url,omitempty"`      // URL模板，{path}和{ref}会被替换
+}
+
+// 判断文件是否存在
+func FileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// 在项目中按文件名查找（第一个匹配）
+func FindInProject(root, name string) string {
+	found := ""
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || found != "" {
+			return nil
+		}
+		if info.IsDir() && path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == name {
+			found = path
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,31 @@
+package project
+
+// ========== 成对探针耗时测量 ==========
+
+// 一对测量耗时的探针
+type SpanProbe struct {
+	Entry string `json:"entry"`
+	Exit  string `json:"exit,omitempty"` // 为空时测量entry函数自身（入口到返回）
+	Key   string `json:"key,omitempty"`  // 配对键：pid（默认）、tgid 或参数名
+}
+
+// span的文本形式
+func (s SpanProbe) String() string {
+	text := s.Entry + "() → return"
+	if s.Exit != "" {
+		text = s.Entry + " → " + s.Exit
+	}
+	key := s.Key
+	if key == "" {
+		key = "pid"
+	}
+	return text + " by " + key
+}
+
+// 事件和bpf_printk中的名称（只用ASCII）
+func (s SpanProbe) Label() string {
+	if s.Exit == "" {
+		return s.Entry
+	}
+	return s.Entry + "->" + s.Exit
+}
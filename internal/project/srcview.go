@@ -0,0 +1,119 @@
+package project
+
+import (
+	"bytes"
+	"os"
+)
+
+// ========== 源码文件（按行索引） ==========
+// 代码窗口打开的文件只读取一次：内容保存为一整块字节，另建每行起始偏移的索引，
+// 渲染时只取窗口可见的几十行，几万行的内核源码和几MB的生成文件滚动时也不需要重新切分。
+// 语法高亮需要首个显示行之前的注释/宏状态，每隔 lexCheckpointLines 行缓存一次，
+// 跳到文件深处时只从最近的检查点向后扫描。跳转行号用命令 :1234，按百分比跳转用 :50%。
+
+// 词法状态检查点的间隔（行）
+const lexCheckpointLines = 512
+
+// 按行索引的源码文件
+type SourceFile struct {
+	data      []byte
+	offsets   []int       // 每行的起始偏移
+	lexStates []CLexState // lexStates[k] 为第 k*lexCheckpointLines 行之前的词法状态
+}
+
+// 为文件内容建立行索引（行尾的 \r\n 和 \n 不属于行内容，末尾没有换行的最后一行也算一行）
+func newSourceFile(data []byte) *SourceFile {
+	f := &SourceFile{data: data, lexStates: []CLexState{{}}}
+	for start := 0; start < len(data); {
+		f.offsets = append(f.offsets, start)
+		next := bytes.IndexByte(data[start:], '\n')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return f
+}
+
+// 读取文件并建立行索引
+func LoadSourceFile(path string) (*SourceFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return newSourceFile(data), nil
+}
+
+// 行数
+func (f *SourceFile) Len() int {
+	return len(f.offsets)
+}
+
+// 第i行（从0开始）的内容
+func (f *SourceFile) Line(i int) string {
+	if i < 0 || i >= len(f.offsets) {
+		return ""
+	}
+	end := len(f.data)
+	if i+1 < len(f.offsets) {
+		end = f.offsets[i+1]
+	}
+	line := f.data[f.offsets[i]:end]
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return string(line)
+}
+
+// 替换部分行（从0开始的行号 → 新内容）后的文件内容，各行原来的换行符保持不变
+func (f *SourceFile) WithLines(lines map[int]string) []byte {
+	var b bytes.Buffer
+	b.Grow(len(f.data))
+	prev := 0
+	for i, start := range f.offsets {
+		text, ok := lines[i]
+		if !ok {
+			continue
+		}
+		b.Write(f.data[prev:start])
+		b.WriteString(text)
+		prev = start + len(f.Line(i))
+	}
+	b.Write(f.data[prev:])
+	return b.Bytes()
+}
+
+// 第n行（从0开始）之前的词法状态：从最近的检查点扫描，沿途补齐检查点
+func (f *SourceFile) LexStateAt(n int) CLexState {
+	if n > len(f.offsets) {
+		n = len(f.offsets)
+	}
+	if n < 0 {
+		n = 0
+	}
+	for k := len(f.lexStates); k <= n/lexCheckpointLines; k++ {
+		f.lexStates = append(f.lexStates, f.scanLex(f.lexStates[k-1], (k-1)*lexCheckpointLines, k*lexCheckpointLines))
+	}
+	base := n / lexCheckpointLines * lexCheckpointLines
+	return f.scanLex(f.lexStates[n/lexCheckpointLines], base, n)
+}
+
+// 从from行的状态state扫描到to行之前
+func (f *SourceFile) scanLex(state CLexState, from, to int) CLexState {
+	for i := from; i < to; i++ {
+		state = ScanCLine(f.Line(i), state, nil)
+	}
+	return state
+}
+
+// 取得已打开的文件，第一次访问时读取并缓存
+func ProjectSourceFile(project *ProjectInfo, path string) (*SourceFile, error) {
+	if file, ok := project.OpenFiles[path]; ok {
+		return file, nil
+	}
+	file, err := LoadSourceFile(path)
+	if err != nil {
+		return nil, err
+	}
+	project.OpenFiles[path] = file
+	return file, nil
+}
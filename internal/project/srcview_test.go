@@ -0,0 +1,60 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSourceFileLines(t *testing.T) {
+	f := newSourceFile([]byte("a\r\nbb\n\nlast"))
+	want := []string{"a", "bb", "", "last"}
+	if f.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", f.Len(), len(want))
+	}
+	for i, line := range want {
+		if got := f.Line(i); got != line {
+			t.Errorf("Line(%d) = %q, want %q", i, got, line)
+		}
+	}
+	if n := newSourceFile([]byte("x\n")).Len(); n != 1 {
+		t.Errorf("trailing newline: Len() = %d, want 1", n)
+	}
+	if n := newSourceFile(nil).Len(); n != 0 {
+		t.Errorf("empty file: Len() = %d, want 0", n)
+	}
+}
+
+func TestSourceFileLexStateAt(t *testing.T) {
+	// 跨过检查点的注释：检查点缓存的状态和从头扫描的结果一致
+	var b strings.Builder
+	for i := 0; i < 3*lexCheckpointLines; i++ {
+		switch i {
+		case lexCheckpointLines - 2:
+			b.WriteString("/* start\n")
+		case 2*lexCheckpointLines + 5:
+			b.WriteString("end */\n")
+		default:
+			fmt.Fprintf(&b, "int x%d;\n", i)
+		}
+	}
+	f := newSourceFile([]byte(b.String()))
+	for _, n := range []int{2*lexCheckpointLines + 10, 0, lexCheckpointLines, 2*lexCheckpointLines + 5, 2*lexCheckpointLines + 6} {
+		if got, want := f.LexStateAt(n), f.scanLex(CLexState{}, 0, n); got != want {
+			t.Errorf("lexStateAt(%d) = %+v, want %+v", n, got, want)
+		}
+	}
+	if !f.LexStateAt(lexCheckpointLines).inComment {
+		t.Error("line after the checkpoint should be inside the comment")
+	}
+}
+
+func TestSourceFileWithLines(t *testing.T) {
+	f := newSourceFile([]byte("a\r\nbb\ncc"))
+	if got := string(f.WithLines(map[int]string{0: "x", 2: "yyy"})); got != "x\r\nbb\nyyy" {
+		t.Errorf("withLines = %q", got)
+	}
+	if got := string(f.WithLines(nil)); got != "a\r\nbb\ncc" {
+		t.Errorf("withLines(nil) = %q", got)
+	}
+}
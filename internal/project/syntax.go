@@ -0,0 +1,130 @@
+package project
+
+import "strings"
+
+// ========== C语法高亮 ==========
+// 代码窗口按行做轻量的词法扫描：关键字、类型、字符串/字符常量、注释和预处理指令着色。
+// 跨行的 /* */ 注释和以 \ 续行的宏需要前面各行的状态，渲染时从最近的检查点扫描到首个显示行（SourceFile.lexStateAt）。
+// 颜色取自当前主题，搜索匹配的背景色优先于语法颜色（highlight off 关闭语法高亮）。
+
+// C关键字（含常用的GCC扩展）
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "const": true, "continue": true,
+	"default": true, "do": true, "else": true, "enum": true, "extern": true,
+	"for": true, "goto": true, "if": true, "inline": true, "register": true,
+	"restrict": true, "return": true, "sizeof": true, "static": true, "struct": true,
+	"switch": true, "typedef": true, "union": true, "volatile": true, "while": true,
+	"__inline": true, "__inline__": true, "__always_inline": true, "asm": true,
+	"__asm__": true, "__volatile__": true, "__attribute__": true, "typeof": true,
+	"__typeof__": true, "__init": true, "__exit": true, "__user": true, "__iomem": true,
+}
+
+// 基本类型和内核常用类型（另外 xxx_t 也按类型着色）
+var cTypes = map[string]bool{
+	"void": true, "char": true, "short": true, "int": true, "long": true,
+	"float": true, "double": true, "signed": true, "unsigned": true, "bool": true,
+	"_Bool": true, "u8": true, "u16": true, "u32": true, "u64": true,
+	"s8": true, "s16": true, "s32": true, "s64": true, "__u8": true,
+	"__u16": true, "__u32": true, "__u64": true, "__s8": true, "__s16": true,
+	"__s32": true, "__s64": true, "__le16": true, "__le32": true, "__le64": true,
+	"__be16": true, "__be32": true, "__be64": true,
+}
+
+// 一个字节所属的词法单元（决定语法颜色）
+type CToken uint8
+
+const (
+	TokenPlain CToken = iota
+	TokenPreproc
+	TokenComment
+	TokenString
+	TokenKeyword
+	TokenType
+)
+
+// 跨行的词法状态
+type CLexState struct {
+	inComment bool // 在 /* */ 注释中
+	inMacro   bool // 上一行是以 \ 结尾的预处理指令
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// 扫描一行：tokens不为nil时按字节填入词法单元，返回下一行开始时的状态
+func ScanCLine(line string, state CLexState, tokens []CToken) CLexState {
+	paint := func(from, to int, token CToken) {
+		if tokens != nil {
+			for i := from; i < to && i < len(tokens); i++ {
+				tokens[i] = token
+			}
+		}
+	}
+	macro := state.inMacro || strings.HasPrefix(strings.TrimSpace(line), "#")
+	base := TokenPlain
+	if macro {
+		base = TokenPreproc
+	}
+	i := 0
+	for i < len(line) {
+		if state.inComment {
+			end := strings.Index(line[i:], "*/")
+			if end < 0 {
+				paint(i, len(line), TokenComment)
+				i = len(line)
+				break
+			}
+			paint(i, i+end+2, TokenComment)
+			i += end + 2
+			state.inComment = false
+			continue
+		}
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "//"):
+			paint(i, len(line), TokenComment)
+			// 行注释中的 \ 不续行
+			return CLexState{}
+		case strings.HasPrefix(line[i:], "/*"):
+			paint(i, i+2, TokenComment)
+			i += 2
+			state.inComment = true
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(line) && line[j] != c {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j > len(line) {
+				j = len(line)
+			} else if j < len(line) {
+				j++
+			}
+			paint(i, j, TokenString)
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(line) && isIdentByte(line[j]) {
+				j++
+			}
+			word := line[i:j]
+			switch {
+			case macro:
+				paint(i, j, base)
+			case cKeywords[word]:
+				paint(i, j, TokenKeyword)
+			case cTypes[word] || strings.HasSuffix(word, "_t") && !(word[0] >= '0' && word[0] <= '9'):
+				paint(i, j, TokenType)
+			}
+			i = j
+		default:
+			paint(i, i+1, base)
+			i++
+		}
+	}
+	state.inMacro = macro && strings.HasSuffix(strings.TrimRight(line, " \t"), "\\")
+	return state
+}
@@ -0,0 +1,58 @@
+package project
+
+import "testing"
+
+// 按字节的词法单元写成一个字符一个（. 普通，# 预处理，c 注释，s 字符串，k 关键字，t 类型）
+func tokenString(tokens []CToken) string {
+	const names = ".#cskt"
+	b := make([]byte, len(tokens))
+	for i, token := range tokens {
+		b[i] = names[token]
+	}
+	return string(b)
+}
+
+func TestScanCLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`return x;`, `kkkkkk...`},
+		{`u32 n = "a;b";`, `ttt.....sssss.`},
+		{`int i; // c`, `ttt....cccc`},
+		{`#define N 4`, `###########`},
+		{`size_t n;`, `tttttt...`},
+	}
+	for _, tt := range tests {
+		tokens := make([]CToken, len(tt.line))
+		if state := ScanCLine(tt.line, CLexState{}, tokens); state != (CLexState{}) {
+			t.Errorf("ScanCLine(%q) state = %+v", tt.line, state)
+		}
+		if got := tokenString(tokens); got != tt.want {
+			t.Errorf("ScanCLine(%q) = %s, want %s", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestScanCLineState(t *testing.T) {
+	// 跨行的注释
+	state := ScanCLine("x = 1; /* start", CLexState{}, nil)
+	if !state.inComment {
+		t.Fatal("comment not carried to the next line")
+	}
+	tokens := make([]CToken, len("end */ if"))
+	state = ScanCLine("end */ if", state, tokens)
+	if state.inComment || tokenString(tokens) != "cccccc.kk" {
+		t.Errorf("comment end = %s, %+v", tokenString(tokens), state)
+	}
+
+	// 以 \ 续行的宏
+	state = ScanCLine("#define MAX(a, b) \\", CLexState{}, nil)
+	if !state.inMacro {
+		t.Fatal("macro continuation not carried to the next line")
+	}
+	tokens = make([]CToken, len("	((a) > (b))"))
+	if state = ScanCLine("	((a) > (b))", state, tokens); state.inMacro || tokenString(tokens) != "############" {
+		t.Errorf("macro body = %s, %+v", tokenString(tokens), state)
+	}
+}
@@ -0,0 +1,13 @@
+package project
+
+// ========== 多文件标签 ==========
+
+// 一个打开的文件在代码窗口中的状态
+type FileViewState struct {
+	Scroll        int
+	SearchMode    bool
+	SearchInput   string
+	SearchTerm    string
+	SearchResults []SearchResult
+	CurrentMatch  int
+}
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ========== 文本显示宽度 ==========
+// 中文等全角字符在终端占两列。internal/gocui 把宽字符画成两列，视图的光标和鼠标坐标都按屏幕列计算，
+// 从视图文本中按光标取字符时用 SliceColumns 把列换算成字符。
+// 弹出窗口的内容按显示宽度截断，对齐用 FitWidth 按显示宽度补空格。
+
+// 是否是占两列的字符（和gocui、tcell的判断一致）
+func isWideRune(r rune) bool {
+	return runewidth.RuneWidth(r) == 2
+}
+
+// 字符的显示宽度（控制字符和零宽字符按0列）
+func runeDisplayWidth(r rune) int {
+	if isWideRune(r) {
+		return 2
+	}
+	if r < ' ' || unicode.Is(unicode.Mn, r) {
+		return 0
+	}
+	return 1
+}
+
+// 跳过ANSI转义序列（\x1b[...字母），返回序列的长度，不是转义序列时返回0
+func ansiSequenceLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= '@' && c <= '~' {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// 字符串的显示宽度（不含ANSI颜色序列）
+func DisplayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if n := ansiSequenceLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		width += runeDisplayWidth(r)
+		i += size
+	}
+	return width
+}
+
+// 按显示宽度截断（保留ANSI颜色序列），超出时以…结尾
+func TruncateWidth(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if DisplayWidth(s) <= n {
+		return s
+	}
+	var b strings.Builder
+	width := 0
+	for i := 0; i < len(s); {
+		if seq := ansiSequenceLen(s[i:]); seq > 0 {
+			b.WriteString(s[i : i+seq])
+			i += seq
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		w := runeDisplayWidth(r)
+		if width+w > n-1 {
+			break
+		}
+		b.WriteRune(r)
+		width += w
+		i += size
+	}
+	b.WriteString("…")
+	if strings.Contains(s, "\x1b[") {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
+
+// 按显示宽度截断并用空格补齐到n列（代替 %-Ns，后者按字符数补齐）
+func FitWidth(s string, n int) string {
+	s = TruncateWidth(s, n)
+	if pad := n - DisplayWidth(s); pad > 0 {
+		s += strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// 覆盖第col列（从0开始）的字符的下标，超出行尾时返回字符数
+func ColumnIndex(s string, col int) int {
+	i, width := 0, 0
+	for _, r := range s {
+		width += runeDisplayWidth(r)
+		if width > col {
+			return i
+		}
+		i++
+	}
+	return i
+}
+
+// 按显示列截取（不含ANSI颜色序列的纯文本）：取起始列在 [start, end) 中的字符，end<0 表示到行尾。
+// 视图的光标列是屏幕列，宽字符占两列
+func SliceColumns(s string, start, end int) string {
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if end >= 0 && col >= end {
+			break
+		}
+		if col >= start {
+			b.WriteRune(r)
+		}
+		col += runeDisplayWidth(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package project
+
+import "testing"
+
+func TestDisplayWidth(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"abc", 3},
+		{"可拖动", 6},
+		{"\x1b[31m错误\x1b[0m: x", 7},
+		{"中文ab", 6},
+		{"→●", 2},
+	}
+	for _, tt := range tests {
+		if got := DisplayWidth(tt.s); got != tt.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateAndFitWidth(t *testing.T) {
+	if got := TruncateWidth("驱动调试工具", 7); got != "驱动调…" {
+		t.Errorf("TruncateWidth = %q", got)
+	}
+	if got := TruncateWidth("short", 10); got != "short" {
+		t.Errorf("TruncateWidth = %q", got)
+	}
+	if got := FitWidth("文件.c", 8); got != "文件.c  " {
+		t.Errorf("FitWidth = %q", got)
+	}
+	if got := DisplayWidth(FitWidth("驱动调试工具", 7)); got != 7 {
+		t.Errorf("FitWidth width = %d, want 7", got)
+	}
+}
+
+func TestSliceColumns(t *testing.T) {
+	s := "a中b"
+	// 列：a=0，中=1-2，b=3
+	tests := []struct {
+		start, end int
+		want       string
+	}{
+		{0, -1, s},
+		{1, 3, "中"},
+		{1, 2, "中"},
+		{2, 4, "b"},
+		{3, -1, "b"},
+		{0, 1, "a"},
+		{5, -1, ""},
+	}
+	for _, tt := range tests {
+		if got := SliceColumns(s, tt.start, tt.end); got != tt.want {
+			t.Errorf("SliceColumns(%q, %d, %d) = %q, want %q", s, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	s := "a中b"
+	for col, want := range []int{0, 1, 1, 2, 3, 3} {
+		if got := ColumnIndex(s, col); got != want {
+			t.Errorf("ColumnIndex(%q, %d) = %d, want %d", s, col, got, want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package project
+
+// ========== BPF交叉编译工具链 ==========
+
+// 一个目标架构的BPF编译工具链
+type ToolchainConfig struct {
+	Clang   string   `json:"clang,omitempty"`   // clang路径（为空时使用PATH中的clang）
+	Sysroot string   `json:"sysroot,omitempty"` // 目标系统的根目录
+	Headers string   `json:"headers,omitempty"` // 目标内核头文件目录
+	CFlags  []string `json:"cflags,omitempty"`  // 附加的编译选项
+}
@@ -0,0 +1,70 @@
+package project
+
+// 文件节点结构
+type FileNode struct {
+	Name     string
+	Path     string
+	IsDir    bool
+	Children []*FileNode
+	Expanded bool
+	Loaded   bool   // 子节点已读取（目录第一次展开时在后台读取，之后缓存）
+	Loading  bool   // 正在后台读取子节点
+	LoadErr  string // 读取子节点失败的原因
+}
+
+// 断点信息
+type Breakpoint struct {
+	File      string
+	Line      int
+	Function  string
+	Enabled   bool
+	Note      string `json:",omitempty"` // 备注（bp note）
+	RetVal    bool   `json:",omitempty"` // 同时生成kretprobe报告返回值（bp retval）
+	Offset    uint64 `json:",omitempty"` // 该行第一条指令相对函数入口的偏移（DWARF行号表）
+	Condition string `json:",omitempty"` // 命中条件，在BPF中求值（bp cond）
+	Binary    string `json:",omitempty"` // 用户态程序（bp uprobe），为空表示内核断点
+	Args      []FuncArg `json:",omitempty"` // 函数参数（生成时从BTF解析，只在函数入口读取）
+	InlinedFrom string       `json:",omitempty"` // 断点所在的函数被内联时为该函数名
+	Inline      []InlineSite `json:",omitempty"` // 内联副本所在的外层函数和偏移，每处一个探针
+}
+
+// 内联函数的一个副本的探针位置
+type InlineSite struct {
+	Function string
+	Offset   uint64
+}
+
+// 项目信息
+type ProjectInfo struct {
+	RootPath    string
+	FileTree    *FileNode
+	OpenFiles   map[string]*SourceFile // 文件路径 -> 按行索引的文件内容
+	CurrentFile string
+	Breakpoints []Breakpoint
+	Settings    *ProjectSettings            // 项目设置（监视表达式等）
+	Journal     []DebugOperation            // 操作日志（可重放）
+	EnumCache   map[string]map[int64]string // 源码中的枚举定义（按需解析）
+	Kbuild      *KbuildInfo                 // Makefile/Kbuild解析结果（没有时为nil）
+	Tabs        []string                    // 代码窗口标签栏中的文件（按打开顺序）
+	FileViews   map[string]*FileViewState   // 每个文件的滚动位置和搜索状态（切换标签时保存）
+	Modified    map[string]bool             // 本次会话中被 replace 改写过的文件
+	Xref        *SymbolIndex                // 符号索引（打开项目时在后台建立，完成前为nil）
+	XrefLoading bool                        // 符号索引是否正在后台建立
+}
+
+// 搜索结果结构
+type SearchResult struct {
+	LineNumber  int // 行号（从1开始）
+	StartColumn int // 匹配在行内的开始字节偏移（从0开始）
+	EndColumn   int // 匹配在行内的结束字节偏移（不含）
+	Text        string // 匹配的文本
+}
+
+// DWARF变量位置信息
+type VariableLocation struct {
+	Name        string
+	Type        string // "register", "stack", "memory"
+	Register    string // 寄存器名称 (如 "rax", "rbx")；stack时是基址寄存器，为空时按帧指针
+	StackOffset int    // 栈偏移量（相对基址寄存器）
+	Size        int    // 变量大小
+}
@@ -0,0 +1,9 @@
+package project
+
+// ========== 数值显示格式 ==========
+
+// 变量的显示格式
+type ValueFormat struct {
+	Format string `json:"format"`         // dec/hex/bin/enum
+	Enum   string `json:"enum,omitempty"` // 枚举类型名（format为enum时使用）
+}
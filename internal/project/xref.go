@@ -0,0 +1,180 @@
+package project
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+)
+
+// ========== 符号导航（转到定义 / 查找引用） ==========
+
+// 定义种类的优先级（有多个定义时按此排序，函数原型排在最后）
+var XrefKindRank = map[string]int{
+	"function": 0, "macro": 1, "struct": 2, "union": 2, "enum": 2, "typedef": 3,
+	"variable": 4, "enumerator": 5, "field": 6, "prototype": 7,
+}
+
+// 定义或引用的位置
+type XrefLocation struct {
+	File   string
+	Line   int    // 从1开始
+	Column int    // 行内字节偏移
+	Kind   string // 定义的种类，引用为空
+}
+
+// 项目的符号索引
+type SymbolIndex struct {
+	Defs  map[string][]XrefLocation
+	Refs  map[string][]XrefLocation
+	Files int
+}
+
+// 解析一个文件，把其中的定义和引用加入索引
+func (idx *SymbolIndex) IndexFile(parser *sitter.Parser, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	// 去掉内核注解后字节偏移和行号不变
+	src := blankKernelAnnotations(content)
+	tree, err := parser.ParseCtx(context.Background(), nil, src)
+	if err != nil {
+		return err
+	}
+	defer tree.Close()
+	idx.indexNode(tree.RootNode(), src, path, false)
+	return nil
+}
+
+// 重新索引被修改的文件
+func (idx *SymbolIndex) UpdateFile(path string) {
+	drop := func(index map[string][]XrefLocation) {
+		for name, locs := range index {
+			kept := locs[:0]
+			for _, loc := range locs {
+				if loc.File != path {
+					kept = append(kept, loc)
+				}
+			}
+			if len(kept) == 0 {
+				delete(index, name)
+			} else {
+				index[name] = kept
+			}
+		}
+	}
+	drop(idx.Defs)
+	drop(idx.Refs)
+	parser := sitter.NewParser()
+	parser.SetLanguage(c.GetLanguage())
+	idx.IndexFile(parser, path)
+	for _, locs := range idx.Refs {
+		sort.SliceStable(locs, func(i, j int) bool {
+			if locs[i].File != locs[j].File {
+				return locs[i].File < locs[j].File
+			}
+			return locs[i].Line < locs[j].Line || locs[i].Line == locs[j].Line && locs[i].Column < locs[j].Column
+		})
+	}
+}
+
+func (idx *SymbolIndex) add(index map[string][]XrefLocation, node *sitter.Node, src []byte, path, kind string) {
+	name := node.Content(src)
+	index[name] = append(index[name], XrefLocation{
+		File:   path,
+		Line:   int(node.StartPoint().Row) + 1,
+		Column: int(node.StartPoint().Column),
+		Kind:   kind,
+	})
+}
+
+// 遍历语法树：标识符都记为引用，声明处的名称同时记为定义（函数体内的局部声明除外）
+func (idx *SymbolIndex) indexNode(node *sitter.Node, src []byte, path string, inFunction bool) {
+	switch node.Type() {
+	case "identifier", "field_identifier", "type_identifier":
+		idx.add(idx.Refs, node, src, path, "")
+		return
+	case "function_definition":
+		if name := declaratorNameNode(node.ChildByFieldName("declarator")); name != nil {
+			idx.add(idx.Defs, name, src, path, "function")
+		}
+		inFunction = true
+	case "declaration":
+		if !inFunction {
+			idx.addDeclarators(node, src, path, "variable")
+		}
+	case "field_declaration":
+		idx.addDeclarators(node, src, path, "field")
+	case "type_definition":
+		idx.addDeclarators(node, src, path, "typedef")
+	case "struct_specifier", "union_specifier", "enum_specifier":
+		if name := node.ChildByFieldName("name"); name != nil && node.ChildByFieldName("body") != nil {
+			idx.add(idx.Defs, name, src, path, strings.TrimSuffix(node.Type(), "_specifier"))
+		}
+	case "enumerator":
+		if name := node.ChildByFieldName("name"); name != nil {
+			idx.add(idx.Defs, name, src, path, "enumerator")
+		}
+	case "preproc_def", "preproc_function_def":
+		if name := node.ChildByFieldName("name"); name != nil {
+			idx.add(idx.Defs, name, src, path, "macro")
+		}
+	}
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		idx.indexNode(node.NamedChild(i), src, path, inFunction)
+	}
+}
+
+// 声明中的每个名称记为定义：int a, *b; 得到 a 和 b，函数声明记为 prototype
+func (idx *SymbolIndex) addDeclarators(node *sitter.Node, src []byte, path, kind string) {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.FieldNameForChild(i) != "declarator" {
+			continue
+		}
+		declarator := node.Child(i)
+		name := declaratorNameNode(declarator)
+		if name == nil {
+			continue
+		}
+		if kind == "variable" && declarator.Type() == "function_declarator" {
+			idx.add(idx.Defs, name, src, path, "prototype")
+		} else {
+			idx.add(idx.Defs, name, src, path, kind)
+		}
+	}
+}
+
+// 声明符中的名称节点（穿过指针、数组、函数、括号和初始化）
+func declaratorNameNode(node *sitter.Node) *sitter.Node {
+	for node != nil {
+		switch node.Type() {
+		case "identifier", "field_identifier", "type_identifier":
+			return node
+		case "parenthesized_declarator", "attributed_declarator":
+			if node.NamedChildCount() == 0 {
+				return nil
+			}
+			node = node.NamedChild(0)
+		default:
+			node = node.ChildByFieldName("declarator")
+		}
+	}
+	return nil
+}
+
+// 名称的定义，按种类优先级排序，同一种类中当前文件的排在前面
+func (idx *SymbolIndex) Definitions(name, currentFile string) []XrefLocation {
+	defs := append([]XrefLocation(nil), idx.Defs[name]...)
+	sort.SliceStable(defs, func(i, j int) bool {
+		ri, rj := XrefKindRank[defs[i].Kind], XrefKindRank[defs[j].Kind]
+		if ri != rj {
+			return ri < rj
+		}
+		return defs[i].File == currentFile && defs[j].File != currentFile
+	})
+	return defs
+}
@@ -0,0 +1,92 @@
+package session
+
+import (
+	"debug/elf"
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/project"
+)
+
+// BPF支持的架构映射
+var SupportedArchitectures = map[string]string{
+	"x86_64":  "__TARGET_ARCH_x86",
+	"aarch64": "__TARGET_ARCH_arm64", 
+	"arm64":   "__TARGET_ARCH_arm64",
+	"riscv64": "__TARGET_ARCH_riscv",
+	"s390x":   "__TARGET_ARCH_s390",
+	"ppc64le": "__TARGET_ARCH_powerpc",
+	"mips64":  "__TARGET_ARCH_mips",
+}
+
+// 架构显示名称映射
+var ArchDisplayNames = map[string]string{
+	"x86_64":  "x86_64 (Intel/AMD 64-bit)",
+	"aarch64": "ARM64/AArch64",
+	"arm64":   "ARM64/AArch64", 
+	"riscv64": "RISC-V 64-bit",
+	"s390x":   "IBM System z",
+	"ppc64le": "PowerPC 64-bit LE",
+	"mips64":  "MIPS 64-bit",
+}
+
+// 注意：selectTargetArchitecture 函数已废弃
+// 现在使用命令行参数方式进行架构选择，避免TUI环境下的输入冲突
+
+// ========== 目标架构检测 ==========
+// 交叉调试时（例如在x86主机上调试RISC-V板卡），目标架构应来自模块的ELF头而不是uname
+
+// 解析用户输入的架构名称
+func ParseArchName(name string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "x86", "x86_64", "amd64":
+		return "x86_64", true
+	case "arm64", "aarch64":
+		return "aarch64", true
+	case "riscv64", "riscv":
+		return "riscv64", true
+	case "s390x":
+		return "s390x", true
+	case "ppc64le", "powerpc":
+		return "ppc64le", true
+	case "mips64":
+		return "mips64", true
+	}
+	return "", false
+}
+
+// 读取ELF文件的目标架构
+func detectELFArch(path string) (string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开ELF文件失败: %v", err)
+	}
+	defer file.Close()
+	return dwarf.ArchFromELF(file)
+}
+
+// 确定目标架构：项目配置 > 模块ELF头 > 主机uname
+// 返回架构名称和来源说明
+func DetectTargetArch(ctx *DebuggerContext) (string, string) {
+	if ctx != nil && ctx.Project != nil {
+		if ctx.Project.Settings != nil && ctx.Project.Settings.TargetArch != "" {
+			return ctx.Project.Settings.TargetArch, "project setting"
+		}
+		if module := project.FindProjectModule(ctx.Project.RootPath); module != "" {
+			if arch, err := detectELFArch(module); err == nil {
+				return arch, "ELF header of " + project.ProjectRelativePath(ctx.Project, module)
+			}
+		}
+	}
+	return dwarf.DetectCurrentArch(), "host uname"
+}
+
+// 获取目标架构对应的BPF架构定义
+func TargetArchDefine(ctx *DebuggerContext) string {
+	arch, _ := DetectTargetArch(ctx)
+	if define, exists := SupportedArchitectures[arch]; exists {
+		return define
+	}
+	return "__TARGET_ARCH_x86" // 默认架构
+}
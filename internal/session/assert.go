@@ -0,0 +1,157 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 断点顺序断言 ==========
+// assert bp1 before bp2 [within 10ms] [per pid]
+//   - bp2命中时，之前必须有一次尚未配对的bp1命中（且在时间窗口内）
+//   - 指定within时，bp1命中后超过时间窗口仍未出现bp2也算违反
+// 每个断点事件到达时检查，违反的断言在状态栏和命令窗口中醒目提示。
+
+// 违反记录上限
+const maxAssertViolations = 1000
+
+// 断言违反记录
+type AssertViolation struct {
+	Assertion int // 断言序号（从1开始）
+	EventSeq  int // 触发违反的事件序号
+	PID       int
+	Message   string
+}
+
+// 断言运行状态：每个断言、每个PID（或全局）尚未配对的bp1命中时间
+type assertState struct {
+	pending map[string][]float64
+}
+
+var assertSpecRegex = regexp.MustCompile(`^bp(\d+)\s+before\s+bp(\d+)(?:\s+within\s+(\S+))?(\s+per\s+pid)?$`)
+
+// 解析断言表达式
+func ParseOrderAssertion(spec string) (project.OrderAssertion, error) {
+	m := assertSpecRegex.FindStringSubmatch(strings.ToLower(strings.TrimSpace(spec)))
+	if m == nil {
+		return project.OrderAssertion{}, fmt.Errorf("无法解析断言: %s (格式: bp1 before bp2 [within 10ms] [per pid])", spec)
+	}
+	a := project.OrderAssertion{PerPID: m[4] != ""}
+	a.First, _ = strconv.Atoi(m[1])
+	a.Second, _ = strconv.Atoi(m[2])
+	if a.First == a.Second {
+		return a, fmt.Errorf("断言的两个断点不能相同")
+	}
+	if m[3] != "" {
+		within, err := time.ParseDuration(m[3])
+		if err != nil || within <= 0 {
+			return a, fmt.Errorf("无效的时间窗口: %s", m[3])
+		}
+		a.Within = within
+	}
+	return a, nil
+}
+
+// 事件时间（秒）：优先使用trace_pipe中的内核时间戳
+func eventSeconds(event DebugEvent) float64 {
+	if event.TraceTime > 0 {
+		return event.TraceTime
+	}
+	return float64(event.Time.UnixNano()) / 1e9
+}
+
+// 检查断点事件是否违反断言（在appendEvent中调用）
+func checkAssertions(ctx *DebuggerContext, event DebugEvent) {
+	if event.Kind != "breakpoint" || ctx.Project == nil || ctx.Project.Settings == nil {
+		return
+	}
+	assertions := ctx.Project.Settings.Assertions
+	if len(ctx.AssertStates) != len(assertions) {
+		ctx.AssertStates = make([]assertState, len(assertions))
+	}
+
+	now := eventSeconds(event)
+	for i, a := range assertions {
+		if event.BreakpointID != a.First && event.BreakpointID != a.Second {
+			continue
+		}
+		state := &ctx.AssertStates[i]
+		if state.pending == nil {
+			state.pending = make(map[string][]float64)
+		}
+		key := ""
+		if a.PerPID {
+			key = strconv.Itoa(event.PID)
+		}
+
+		// 超时未配对的bp1命中
+		if a.Within > 0 {
+			window := a.Within.Seconds()
+			kept := state.pending[key][:0]
+			for _, t := range state.pending[key] {
+				if now-t > window {
+					reportViolation(ctx, i, event, fmt.Sprintf("bp%d hit but no bp%d within %s", a.First, a.Second, a.Within))
+				} else {
+					kept = append(kept, t)
+				}
+			}
+			state.pending[key] = kept
+		}
+
+		if event.BreakpointID == a.First {
+			state.pending[key] = append(state.pending[key], now)
+			continue
+		}
+
+		// bp2命中：与最早的未配对bp1配对
+		if len(state.pending[key]) == 0 {
+			reportViolation(ctx, i, event, fmt.Sprintf("bp%d hit without a preceding bp%d", a.Second, a.First))
+			continue
+		}
+		state.pending[key] = state.pending[key][1:]
+	}
+}
+
+// 记录断言违反并在命令窗口中提示
+func reportViolation(ctx *DebuggerContext, index int, event DebugEvent, message string) {
+	if ctx.AssertViolated == nil {
+		ctx.AssertViolated = make(map[int]bool)
+	}
+	ctx.AssertViolated[event.Seq] = true
+	ctx.AssertViolations = append(ctx.AssertViolations, AssertViolation{
+		Assertion: index + 1,
+		EventSeq:  event.Seq,
+		PID:       event.PID,
+		Message:   message,
+	})
+	if len(ctx.AssertViolations) > maxAssertViolations {
+		ctx.AssertViolations = ctx.AssertViolations[len(ctx.AssertViolations)-maxAssertViolations:]
+	}
+	ctx.CommandHistory = append(ctx.CommandHistory, Styled(ActiveTheme.Alert, fmt.Sprintf("[ASSERT #%d]", index+1))+fmt.Sprintf(" %s (event #%d pid=%d)", message, event.Seq, event.PID))
+	ctx.CommandDirty = true
+}
+
+// 清除断言运行状态和违反记录
+func ResetAssertions(ctx *DebuggerContext) {
+	ctx.AssertStates = nil
+	ctx.AssertViolations = nil
+	ctx.AssertViolated = nil
+}
+
+// 显示违反记录弹出窗口
+func ShowAssertViolationsPopup(ctx *DebuggerContext) {
+	content := make([]string, 0, len(ctx.AssertViolations)+2)
+	for _, v := range ctx.AssertViolations {
+		content = append(content, fmt.Sprintf("#%d  event %-6d pid %-6d %s", v.Assertion, v.EventSeq, v.PID, v.Message))
+	}
+	if len(content) == 0 {
+		content = append(content, "No assertion violations")
+	}
+	ClosePopupWindow(ctx, "assertions")
+	popup := CreatePopupWindow(ctx, "assertions", fmt.Sprintf("Assertion Violations (%d)", len(ctx.AssertViolations)), 100, 25, content)
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,330 @@
+package session
+
+import (
+	"bufio"
+	"compress/gzip"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 后端能力检测 ==========
+// open 时检测本机可用的工具（clang+bpftool、bpftrace、stap、perf）和内核特性（BTF、kprobe_events、
+// function_graph、CONFIG_DEBUG_INFO、内核头文件、模块的调试信息），按结果列出每个采集后端
+// 能否使用、缺少什么，并推荐一个。项目还没有选过后端时弹出选择窗口（Enter/1-6 选择），
+// 选择保存在项目设置（.debug_settings.json）中；backend detect 重新打开窗口。
+// 远程目标的检测结果只代表本机。
+
+const backendWizardID = "backends"
+
+// 检测到的环境
+type systemCapabilities struct {
+	Tools         map[string]bool // 工具名 → 是否在PATH中
+	BTF           bool            // /sys/kernel/btf/vmlinux
+	KprobeEvents  bool            // tracefs中的kprobe_events
+	FunctionGraph bool            // available_tracers中有function_graph
+	Tracefs       bool
+	KernelConfig  map[string]bool // 关心的CONFIG_*项（读不到内核配置时为nil）
+	Headers       bool            // /lib/modules/<release>/build
+	Module        string          // 项目中的内核模块
+	ModuleDebug   bool            // 模块带DWARF调试信息
+}
+
+// 单个后端的检测结果
+type backendOption struct {
+	Name        string
+	Description string
+	Missing     []string // 缺少的必需条件（非空时不可用）
+	Degraded    []string // 缺少时功能受限的条件
+}
+
+// 检测的工具（顺序即显示顺序）
+var capabilityTools = []string{"clang", "bpftool", "bpftrace", "stap", "perf"}
+
+// 读取的内核配置项
+var capabilityConfigs = []string{"CONFIG_DEBUG_INFO", "CONFIG_DEBUG_INFO_BTF", "CONFIG_KPROBE_EVENTS", "CONFIG_FUNCTION_GRAPH_TRACER"}
+
+// 读取运行中内核的配置（/proc/config.gz 或 /boot/config-<release>），只返回关心的项
+func readKernelConfig() map[string]bool {
+	var reader io.Reader
+	if file, err := os.Open("/proc/config.gz"); err == nil {
+		defer file.Close()
+		if gz, err := gzip.NewReader(file); err == nil {
+			reader = gz
+		}
+	}
+	if reader == nil {
+		file, err := os.Open(filepath.Join("/boot", "config-"+project.KernelRelease()))
+		if err != nil {
+			return nil
+		}
+		defer file.Close()
+		reader = file
+	}
+	wanted := make(map[string]bool)
+	for _, name := range capabilityConfigs {
+		wanted[name] = true
+	}
+	config := make(map[string]bool)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && wanted[name] {
+			config[name] = value == "y" || value == "m"
+		}
+	}
+	return config
+}
+
+// 检测本机的工具和内核特性
+func DetectCapabilities(ctx *DebuggerContext) *systemCapabilities {
+	caps := &systemCapabilities{Tools: make(map[string]bool)}
+	for _, tool := range capabilityTools {
+		_, err := exec.LookPath(tool)
+		caps.Tools[tool] = err == nil
+	}
+	caps.BTF = project.FileExists("/sys/kernel/btf/vmlinux")
+	if root, err := TracingRoot(); err == nil {
+		caps.Tracefs = true
+		caps.KprobeEvents = project.FileExists(filepath.Join(root, "kprobe_events"))
+		if data, err := os.ReadFile(filepath.Join(root, "available_tracers")); err == nil {
+			caps.FunctionGraph = strings.Contains(string(data), "function_graph")
+		}
+	}
+	caps.KernelConfig = readKernelConfig()
+	caps.Headers = dirExists(filepath.Join("/lib/modules", project.KernelRelease(), "build"))
+	if ctx.Project != nil {
+		caps.Module = project.FindProjectModule(ctx.Project.RootPath)
+		if caps.Module != "" {
+			if file, err := elf.Open(caps.Module); err == nil {
+				caps.ModuleDebug = dwarf.HasDebugInfo(file)
+				file.Close()
+			}
+		}
+	}
+	return caps
+}
+
+// 内核配置项是否打开（读不到配置时按打开处理，不误报）
+func (caps *systemCapabilities) config(name string) bool {
+	if caps.KernelConfig == nil {
+		return true
+	}
+	return caps.KernelConfig[name]
+}
+
+// 各采集后端的可用性（顺序即推荐顺序）
+func backendOptions(caps *systemCapabilities) []backendOption {
+	bpf := backendOption{Name: BackendBPF, Description: "BPF program per breakpoint: variables, stacks, spans, locks"}
+	for _, tool := range []string{"clang", "bpftool"} {
+		if !caps.Tools[tool] {
+			bpf.Missing = append(bpf.Missing, tool)
+		}
+	}
+	if !caps.Tracefs {
+		bpf.Missing = append(bpf.Missing, "tracefs")
+	}
+	if !caps.BTF {
+		bpf.Degraded = append(bpf.Degraded, "BTF (function arguments)")
+	}
+
+	kprobe := backendOption{Name: BackendKprobe, Description: "kprobe_events, no compiler needed; variables from DWARF"}
+	if !caps.KprobeEvents || !caps.config("CONFIG_KPROBE_EVENTS") {
+		kprobe.Missing = append(kprobe.Missing, "kprobe_events")
+	}
+
+	stap := backendOption{Name: BackendSystemtap, Description: "SystemTap statement probes (line level)"}
+	if !caps.Tools["stap"] {
+		stap.Missing = append(stap.Missing, "stap")
+	}
+	if !caps.Headers {
+		stap.Missing = append(stap.Missing, "kernel headers ("+project.KernelRelease()+")")
+	}
+	if !caps.config("CONFIG_DEBUG_INFO") {
+		stap.Degraded = append(stap.Degraded, "CONFIG_DEBUG_INFO (kernel debuginfo)")
+	}
+
+	bpftrace := backendOption{Name: BackendBpftrace, Description: "bpftrace script, no clang/bpftool needed; variables from DWARF"}
+	if !caps.Tools["bpftrace"] {
+		bpftrace.Missing = append(bpftrace.Missing, "bpftrace")
+	}
+
+	perf := backendOption{Name: BackendPerf, Description: "perf probe + perf record, perf fetches variables from DWARF"}
+	if !caps.Tools["perf"] {
+		perf.Missing = append(perf.Missing, "perf")
+	}
+	if !caps.KprobeEvents || !caps.config("CONFIG_KPROBE_EVENTS") {
+		perf.Missing = append(perf.Missing, "kprobe_events")
+	}
+
+	ftrace := backendOption{Name: BackendFtrace, Description: "function_graph call graphs, function level only"}
+	if !caps.FunctionGraph || !caps.config("CONFIG_FUNCTION_GRAPH_TRACER") {
+		ftrace.Missing = append(ftrace.Missing, "function_graph tracer")
+	}
+
+	// 模块没有调试信息时，行级探针和变量都不可用
+	if caps.Module != "" && !caps.ModuleDebug {
+		for _, opt := range []*backendOption{&bpf, &bpftrace, &kprobe, &perf, &stap} {
+			opt.Degraded = append(opt.Degraded, "module debug info (-g)")
+		}
+	}
+	return []backendOption{bpf, bpftrace, kprobe, perf, stap, ftrace}
+}
+
+// 推荐的后端：第一个可用且不受限的，其次第一个可用的；都不可用时为bpf
+func recommendedBackend(options []backendOption) string {
+	for _, opt := range options {
+		if len(opt.Missing) == 0 && len(opt.Degraded) == 0 {
+			return opt.Name
+		}
+	}
+	for _, opt := range options {
+		if len(opt.Missing) == 0 {
+			return opt.Name
+		}
+	}
+	return BackendBPF
+}
+
+// 检测结果的勾选标记
+func capabilityMark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return Styled(ActiveTheme.Error, "✗")
+}
+
+// 一行检测摘要（open 的输出）
+func BackendSummaryLine(caps *systemCapabilities) string {
+	options := backendOptions(caps)
+	usable := make([]string, 0, len(options))
+	for _, opt := range options {
+		if len(opt.Missing) == 0 {
+			usable = append(usable, opt.Name)
+		}
+	}
+	if len(usable) == 0 {
+		return "Backends: none usable on this machine, see 'backend detect'"
+	}
+	return fmt.Sprintf("Backends: %s usable (recommended: %s)", strings.Join(usable, ", "), recommendedBackend(options))
+}
+
+// 检测结果的环境部分
+func CapabilityLines(caps *systemCapabilities) []string {
+	tools := make([]string, 0, len(capabilityTools))
+	for _, tool := range capabilityTools {
+		tools = append(tools, tool+" "+capabilityMark(caps.Tools[tool]))
+	}
+	lines := []string{
+		"Tools:   " + strings.Join(tools, "  "),
+		fmt.Sprintf("Kernel:  %s  BTF %s  kprobe_events %s  function_graph %s  headers %s",
+			project.KernelRelease(), capabilityMark(caps.BTF), capabilityMark(caps.KprobeEvents), capabilityMark(caps.FunctionGraph), capabilityMark(caps.Headers)),
+	}
+	if caps.KernelConfig == nil {
+		lines = append(lines, "Config:  "+Styled(ActiveTheme.Dim, "not readable (/proc/config.gz, /boot/config-*)"))
+	} else {
+		configs := make([]string, 0, len(capabilityConfigs))
+		for _, name := range capabilityConfigs {
+			configs = append(configs, strings.TrimPrefix(name, "CONFIG_")+" "+capabilityMark(caps.KernelConfig[name]))
+		}
+		lines = append(lines, "Config:  "+strings.Join(configs, "  "))
+	}
+	if caps.Module == "" {
+		lines = append(lines, "Module:  "+Styled(ActiveTheme.Dim, "no .ko built yet"))
+	} else {
+		lines = append(lines, fmt.Sprintf("Module:  %s  debug info %s", filepath.Base(caps.Module), capabilityMark(caps.ModuleDebug)))
+	}
+	return lines
+}
+
+// 后端选择窗口：当前后端标记*，推荐的后端标注，Enter或1-6选择
+func ShowBackendWizard(ctx *DebuggerContext, caps *systemCapabilities) {
+	options := backendOptions(caps)
+	recommended := recommendedBackend(options)
+	content := CapabilityLines(caps)
+	if RemoteTarget(ctx) != nil {
+		content = append(content, Styled(ActiveTheme.Warning, "Remote target configured: the checks above are for this machine"))
+	}
+	content = append(content, "")
+	rows := make(map[int]string)
+	for i, opt := range options {
+		marker := " "
+		if opt.Name == CurrentBackend(ctx) {
+			marker = "*"
+		}
+		status := "✓"
+		if len(opt.Missing) > 0 {
+			status = Styled(ActiveTheme.Error, "✗")
+		} else if len(opt.Degraded) > 0 {
+			status = Styled(ActiveTheme.Warning, "~")
+		}
+		line := fmt.Sprintf("%s%d. %s %-10s %s", marker, i+1, status, opt.Name, opt.Description)
+		if opt.Name == recommended {
+			line += Styled(ActiveTheme.Focused, " (recommended)")
+		}
+		rows[len(content)] = opt.Name
+		content = append(content, line)
+		if len(opt.Missing) > 0 {
+			content = append(content, Styled(ActiveTheme.Dim, "        missing: "+strings.Join(opt.Missing, ", ")))
+		}
+		if len(opt.Degraded) > 0 {
+			content = append(content, Styled(ActiveTheme.Dim, "        limited without: "+strings.Join(opt.Degraded, ", ")))
+		}
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, "Enter/1-6 select | gdb/kdb: 'backend gdb <host:port>' / 'backend kdb <tty>'"))
+
+	ClosePopupWindow(ctx, backendWizardID)
+	popup := CreatePopupWindow(ctx, backendWizardID, "Backend Selection", 100, len(content)+4, content)
+	choose := func(ui UI, name string) error {
+		if err := chooseBackend(ctx, name); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ctx.CommandHistory = append(ctx.CommandHistory, "Backend: "+name)
+			ClosePopupWindow(ctx, backendWizardID)
+			ui.Focus("command")
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	popup.OnSelect = func(ui UI, index int) error {
+		if name, ok := rows[index]; ok {
+			return choose(ui, name)
+		}
+		return nil
+	}
+	popup.OnDigit = func(ui UI, n int) error {
+		if n < 1 || n > len(options) {
+			return nil
+		}
+		return choose(ui, options[n-1].Name)
+	}
+	ShowPopupWindow(ctx, popup)
+}
+
+// 保存选择的采集后端
+func chooseBackend(ctx *DebuggerContext, name string) error {
+	if ctx.Project == nil {
+		return errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	if ctx.EventSource != nil {
+		return fmt.Errorf("事件采集正在运行，请先 events stop")
+	}
+	if ctx.GDB != nil && ctx.GDB.Busy != "" {
+		return fmt.Errorf("目标正在运行，请先 interrupt")
+	}
+	DisconnectGDB(ctx)
+	ctx.Project.Settings.Backend = name
+	if name == BackendBPF {
+		ctx.Project.Settings.Backend = ""
+	}
+	ctx.Project.Settings.BackendChosen = true
+	return project.SaveProjectSettings(ctx.Project)
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 断点导入导出 ==========
+// bp export <file> [json|text] 把断点写到文件，bp import <file> [merge|overwrite] 从文件读入。
+// 格式按扩展名判断（.json 为 JSON，其余为文本），也可以显式指定：
+//   - JSON：与 .debug_breakpoints.json 相同，保留备注、条件、retval 和用户态探针
+//   - 文本：每行一个 <file>:<line>，禁用的断点后跟 disabled，# 之后是注释（导出时写函数名）
+// 导出的路径相对于项目根目录，导入时与加载 .debug_breakpoints.json 一样按当前项目解析（见 project/persist.go），
+// 换一台机器或换一个检出目录也能用。
+// 导入默认合并（已存在的位置保持不变），overwrite 先清空现有断点。
+
+// 导出断点，返回写入的断点数和文本格式下跳过的用户态探针数
+func ExportBreakpoints(ctx *DebuggerContext, path, format string) (int, int, error) {
+	breakpoints := ctx.Project.Breakpoints
+	var data []byte
+	skipped := 0
+	if format == project.BreakpointFormatJSON {
+		portable := make([]project.Breakpoint, 0, len(breakpoints))
+		for _, bp := range breakpoints {
+			portable = append(portable, project.PortableBreakpoint(ctx.Project, bp))
+		}
+		var err error
+		if data, err = json.MarshalIndent(portable, "", "  "); err != nil {
+			return 0, 0, fmt.Errorf("序列化断点失败: %v", err)
+		}
+	} else {
+		var b strings.Builder
+		fmt.Fprintf(&b, "# breakpoints of %s: <file>:<line> [disabled]\n", filepath.Base(ctx.Project.RootPath))
+		for _, bp := range breakpoints {
+			if bp.Binary != "" {
+				// 用户态探针没有源码位置时无法用文本表示
+				skipped++
+				continue
+			}
+			fmt.Fprintf(&b, "%s:%d", project.ProjectRelativePath(ctx.Project, bp.File), bp.Line)
+			if !bp.Enabled {
+				b.WriteString(" disabled")
+			}
+			if bp.Function != "" && bp.Function != "unknown" {
+				fmt.Fprintf(&b, "  # %s", bp.Function)
+			}
+			b.WriteString("\n")
+		}
+		data = []byte(b.String())
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return 0, 0, fmt.Errorf("写入断点文件失败: %v", err)
+	}
+	return len(breakpoints) - skipped, skipped, nil
+}
+
+// 导入断点：相对路径按项目根目录解析，缺少函数名时从源码解析
+func ImportBreakpoints(ctx *DebuggerContext, path, format string, overwrite bool) (*project.BreakpointImport, error) {
+	var imported []project.Breakpoint
+	var err error
+	if format == project.BreakpointFormatJSON {
+		imported, err = project.ReadBreakpointsFile(path)
+	} else {
+		imported, err = project.ParseBreakpointText(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &project.BreakpointImport{}
+	if overwrite {
+		ctx.Project.Breakpoints = make([]project.Breakpoint, 0, len(imported))
+	}
+	missing := make(map[string]bool)
+	for _, bp := range imported {
+		bp.File = project.ResolveBreakpointPath(ctx.Project.RootPath, bp.File)
+		duplicate := false
+		for _, existing := range ctx.Project.Breakpoints {
+			if project.SameBreakpointLocation(existing, bp) {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			result.Duplicates++
+			continue
+		}
+		if bp.Binary == "" && !project.FileExists(bp.File) {
+			if !missing[bp.File] {
+				missing[bp.File] = true
+				result.Missing = append(result.Missing, project.ProjectRelativePath(ctx.Project, bp.File))
+			}
+		} else if bp.Binary == "" && (bp.Function == "" || bp.Function == "unknown") {
+			if bp.Function = project.ParseFunctionName(bp.File, bp.Line); bp.Function == "" {
+				bp.Function = "unknown"
+			}
+			ResolveBreakpointProbe(ctx, &bp)
+		}
+		ctx.Project.Breakpoints = append(ctx.Project.Breakpoints, bp)
+		result.Added++
+	}
+	return result, project.SaveBreakpoints(ctx.Project)
+}
@@ -0,0 +1,42 @@
+package session_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestBreakpointExportImport(t *testing.T) {
+	for _, format := range []string{project.BreakpointFormatJSON, project.BreakpointFormatText} {
+		ctx := testsupport.NewProject(t,
+			project.Breakpoint{File: "drv.c", Line: 5, Function: "scale", Enabled: true, Note: "check factor"},
+			project.Breakpoint{File: "drv.c", Line: 11, Function: "do_work", Enabled: false},
+		)
+		path := filepath.Join(t.TempDir(), "bps."+format)
+		if n, _, err := session.ExportBreakpoints(ctx, path, format); err != nil || n != 2 {
+			t.Fatalf("%s: ExportBreakpoints = %d, %v", format, n, err)
+		}
+
+		// 合并：已有的位置不重复添加
+		result, err := session.ImportBreakpoints(ctx, path, format, false)
+		if err != nil || result.Added != 0 || result.Duplicates != 2 {
+			t.Errorf("%s: merge = %+v, %v", format, result, err)
+		}
+
+		// 覆盖：相对路径按项目根目录解析，函数名从源码解析
+		result, err = session.ImportBreakpoints(ctx, path, format, true)
+		if err != nil || result.Added != 2 || len(result.Missing) != 0 {
+			t.Fatalf("%s: overwrite = %+v, %v", format, result, err)
+		}
+		bps := ctx.Project.Breakpoints
+		if len(bps) != 2 || bps[0].File != filepath.Join(ctx.Project.RootPath, "drv.c") || bps[1].Function != "do_work" || bps[1].Enabled {
+			t.Errorf("%s: imported %+v", format, bps)
+		}
+		if wantNote := format == project.BreakpointFormatJSON; (bps[0].Note != "") != wantNote {
+			t.Errorf("%s: note = %q", format, bps[0].Note)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/rlimit"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 进程内加载BPF程序 ==========
+// 用cilium/ebpf直接加载compile生成的.bpf.o并挂载kprobe，不再需要退出界面、
+// 切换root执行load/unload脚本。程序和探针不做pin，只由本进程的文件描述符持有：
+// bpf unload、关闭项目或退出调试器时内核会自动卸载，不会留下残留探针。
+
+// 已加载的BPF程序
+type LoadedBPF struct {
+	Object       string // .bpf.o路径
+	Collection   *ebpf.Collection
+	Links        []link.Link
+	Probes       []string // 已挂载的探针（kprobe/func）
+	LoadedAt     time.Time
+	regsReader   io.Closer // 寄存器ring buffer读取器（目标文件没有regs_events时为nil）
+	eventsReader io.Closer // 事件perf buffer读取器（目标文件没有debug_events时为nil）
+}
+
+// 选择要加载的目标文件：与compile相同，优先变量监控版本
+func bpfObjectPath(ctx *DebuggerContext) (string, error) {
+	for _, name := range []string{"debug_variables.bpf.o", "debug_breakpoints.bpf.o"} {
+		path := filepath.Join(ctx.Project.RootPath, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", errcode.Errorf(errcode.ErrBPFSource, "没有找到编译好的BPF目标文件，请先执行 'vars' 或 'generate'，再执行 'compile'")
+}
+
+// 目标文件比源文件旧时给出提示
+func BPFObjectStale(object string) bool {
+	source := strings.TrimSuffix(object, ".o") + ".c"
+	objInfo, err1 := os.Stat(object)
+	srcInfo, err2 := os.Stat(source)
+	return err1 == nil && err2 == nil && srcInfo.ModTime().After(objInfo.ModTime())
+}
+
+// 加载目标文件中的所有程序并挂载kprobe/kretprobe（bp uprobe 生成的uprobe/uretprobe挂到用户态程序上）
+// 返回的警告为挂载失败的探针（至少一个探针挂载成功时不视为失败）
+func LoadBPF(ui UI, ctx *DebuggerContext) ([]string, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	if ctx.BPF != nil {
+		return nil, fmt.Errorf("BPF程序已加载（%s），请先执行 'bpf unload'", filepath.Base(ctx.BPF.Object))
+	}
+	if err := CheckSafeMode(ctx, "BPF加载"); err != nil {
+		return nil, err
+	}
+	if remote := RemoteTarget(ctx); remote != nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "bpf load 只能加载到本机内核，远程目标 %s 请用 'remote attach' 配置的命令挂载", remote.SSH)
+	}
+	object, err := bpfObjectPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5.11之前的内核按memlock限制计算BPF内存
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return nil, errcode.Errorf(errcode.ErrPerm, "无法解除memlock限制（需要root或CAP_SYS_RESOURCE）: %v", err)
+	}
+	spec, err := ebpf.LoadCollectionSpec(object)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrBPFSource, "读取BPF目标文件失败: %v", err)
+	}
+	// 事件由本进程从perf buffer读取，关闭生成代码中的bpf_printk（旧的目标文件没有这个开关）
+	if v := spec.Variables[printkSwitch]; v != nil && spec.Maps[debugEventsMap] != nil {
+		if err := v.Set(uint32(0)); err != nil {
+			return nil, errcode.Errorf(errcode.ErrBPFSource, "设置 %s 失败: %v", printkSwitch, err)
+		}
+	}
+	// 锁探针的模块代码段范围按当前加载的模块设置
+	lockErr := setLockModuleRange(ctx, spec)
+	coll, err := ebpf.NewCollection(spec)
+	if err != nil {
+		var verr *ebpf.VerifierError
+		if errors.As(err, &verr) {
+			return nil, errcode.Errorf(errcode.ErrBPFVerifier, "BPF校验器拒绝了程序: %v（'bpf verify' 显示出错的源码行）", verr)
+		}
+		if errors.Is(err, os.ErrPermission) {
+			return nil, errcode.Errorf(errcode.ErrPerm, "加载BPF程序失败（需要root或CAP_BPF）: %v", err)
+		}
+		return nil, errcode.Errorf(errcode.ErrBPFAttach, "加载BPF程序失败: %v", err)
+	}
+
+	loaded := &LoadedBPF{Object: object, Collection: coll, LoadedAt: time.Now()}
+	warnings := make([]string, 0)
+	if lockErr != nil {
+		warnings = append(warnings, fmt.Sprintf("Locks: %v", lockErr))
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+
+	names := make([]string, 0, len(spec.Programs))
+	for name := range spec.Programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		progSpec := spec.Programs[name]
+		if progSpec.Type != ebpf.Kprobe {
+			continue
+		}
+		if isUprobeSection(progSpec.SectionName) {
+			l, err := attachUprobe(progSpec.SectionName, coll.Programs[name])
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %v", progSpec.SectionName, err))
+				continue
+			}
+			loaded.Links = append(loaded.Links, l)
+			loaded.Probes = append(loaded.Probes, progSpec.SectionName)
+			continue
+		}
+		ret := strings.HasPrefix(progSpec.SectionName, "kretprobe/")
+		function := progSpec.AttachTo
+		if function == "" {
+			function = progSpec.SectionName[strings.Index(progSpec.SectionName, "/")+1:]
+		}
+		// kprobe/func+0x1c：DWARF行号表解析出的行内偏移
+		function, offset := dwarf.SplitProbeOffset(function)
+		var l link.Link
+		if ret {
+			l, err = link.Kretprobe(function, coll.Programs[name], nil)
+		} else if offset > 0 {
+			l, err = link.Kprobe(function, coll.Programs[name], &link.KprobeOptions{Offset: offset})
+		} else {
+			l, err = link.Kprobe(function, coll.Programs[name], nil)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", progSpec.SectionName, err))
+			if errors.Is(err, os.ErrNotExist) {
+				// 挂载ENOENT：附上符号、模块加载状态和版本的诊断
+				for _, line := range DiagnoseAttachFailure(function, module, err) {
+					warnings = append(warnings, "  "+line)
+				}
+			}
+			continue
+		}
+		loaded.Links = append(loaded.Links, l)
+		loaded.Probes = append(loaded.Probes, progSpec.SectionName)
+	}
+
+	if len(loaded.Links) == 0 {
+		coll.Close()
+		if len(warnings) == 0 {
+			return nil, errcode.Errorf(errcode.ErrBPFSource, "%s 中没有kprobe程序", filepath.Base(object))
+		}
+		return nil, errcode.Errorf(errcode.ErrBPFAttach, "没有探针挂载成功:\n%s", strings.Join(warnings, "\n"))
+	}
+	reader, err := startRegsReader(ui, ctx, coll)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("Registers: %v", err))
+	}
+	loaded.regsReader = reader
+	events, err := startPerfEventReader(ui, ctx, coll)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("Events: %v", err))
+	}
+	loaded.eventsReader = events
+	ctx.Registers = nil
+	ctx.BPF = loaded
+	ctx.BpfLoaded = true
+	return warnings, nil
+}
+
+// 卸载进程内加载的BPF程序（先断开探针再释放程序）
+func UnloadBPF(ctx *DebuggerContext) bool {
+	if ctx.BPF == nil {
+		return false
+	}
+	if ctx.BPF.regsReader != nil {
+		ctx.BPF.regsReader.Close()
+	}
+	if ctx.BPF.eventsReader != nil {
+		ctx.BPF.eventsReader.Close()
+	}
+	for _, l := range ctx.BPF.Links {
+		l.Close()
+	}
+	ctx.BPF.Collection.Close()
+	ctx.BPF = nil
+	ctx.BpfLoaded = false
+	return true
+}
+
+// bpf status 的显示内容
+func BPFStatusLines(ctx *DebuggerContext) []string {
+	if ctx.BPF == nil {
+		return []string{"BPF: not loaded (use 'bpf load' after 'compile')"}
+	}
+	lines := []string{
+		fmt.Sprintf("BPF: %s loaded %s ago, %d probes attached", filepath.Base(ctx.BPF.Object),
+			time.Since(ctx.BPF.LoadedAt).Truncate(time.Second), len(ctx.BPF.Probes)),
+		perfEventStatus(ctx.BPF),
+	}
+	for _, probe := range ctx.BPF.Probes {
+		lines = append(lines, "  "+probe)
+	}
+	return lines
+}
@@ -0,0 +1,258 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/rlimit"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== BPF目标文件校验 ==========
+// bpf verify 在挂载探针之前把 compile 生成的.bpf.o逐个程序交给内核校验器（与 bpf load 相同的
+// cilium/ebpf加载流程，包括对内核BTF的CO-RE重定位），加载成功后立即释放，不挂载任何探针。
+// 校验器拒绝的程序按日志中最后执行的指令，经目标文件中的BTF行号信息映射回BPF源码行，
+// 结果显示在诊断窗口中（Enter跳转到出错行），不必等到 bpf load 或 events start 时才失败。
+
+// 校验器日志中的指令行：12: (85) call bpf_probe_read_kernel#113
+var verifierInsnRegex = regexp.MustCompile(`^(\d+): \(`)
+
+// 6.x内核日志中的源码注释：; int x = y; @ debug_variables.bpf.c:123
+var verifierSourceRegex = regexp.MustCompile(`^; (.*) @ (\S+):(\d+)$`)
+
+// CO-RE重定位不满足时cilium/ebpf写入的调用（call 0xbad2310）
+const poisonedRelocation = "195896080"
+
+// 一个程序的校验结果
+type bpfVerifyResult struct {
+	Section   string // 节名（kprobe/foo+0x1c）
+	Insns     int    // 指令数
+	CORE      int    // CO-RE重定位数量
+	Processed string // 校验器统计（processed N insns ...）
+	Err       error
+	Message   string   // 校验器给出的原因
+	Log       []string // 校验器日志的最后几行
+	File      string   // 出错指令对应的源码位置
+	Line      int
+	Source    string
+}
+
+// 失败时在窗口中保留的校验器日志行数
+const verifierLogTail = 12
+
+// 逐个程序校验当前的BPF目标文件，返回目标文件路径和每个程序的结果
+func VerifyBPF(ctx *DebuggerContext) (string, []bpfVerifyResult, error) {
+	if ctx.Project == nil {
+		return "", nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	if err := CheckSafeMode(ctx, "BPF校验"); err != nil {
+		return "", nil, err
+	}
+	if remote := RemoteTarget(ctx); remote != nil {
+		return "", nil, errcode.Errorf(errcode.ErrInvalidArg, "bpf verify 使用本机内核的校验器，远程目标 %s 的内核可能不同", remote.SSH)
+	}
+	object, err := bpfObjectPath(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return "", nil, errcode.Errorf(errcode.ErrPerm, "无法解除memlock限制（需要root或CAP_SYS_RESOURCE）: %v", err)
+	}
+	spec, err := ebpf.LoadCollectionSpec(object)
+	if err != nil {
+		return "", nil, errcode.Errorf(errcode.ErrBPFSource, "读取BPF目标文件失败: %v", err)
+	}
+
+	names := make([]string, 0, len(spec.Programs))
+	for name := range spec.Programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]bpfVerifyResult, 0, len(names))
+	for _, name := range names {
+		result := verifyBPFProgram(spec, name)
+		// 权限不足时每个程序都会失败，直接报告（校验器拒绝同样返回EACCES，但带有日志）
+		var verr *ebpf.VerifierError
+		if errors.Is(result.Err, os.ErrPermission) && !errors.As(result.Err, &verr) {
+			return "", nil, errcode.Errorf(errcode.ErrPerm, "校验BPF程序失败（需要root或CAP_BPF）: %v", result.Err)
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return "", nil, errcode.Errorf(errcode.ErrBPFSource, "%s 中没有BPF程序", filepath.Base(object))
+	}
+	return object, results, nil
+}
+
+// 只加载一个程序（和它引用的map），记录校验器的统计或拒绝原因
+func verifyBPFProgram(spec *ebpf.CollectionSpec, name string) bpfVerifyResult {
+	progSpec := spec.Programs[name]
+	result := bpfVerifyResult{Section: progSpec.SectionName}
+	for _, ins := range progSpec.Instructions {
+		result.Insns += int(ins.Size() / asm.InstructionSize)
+		if btf.CORERelocationMetadata(&ins) != nil {
+			result.CORE++
+		}
+	}
+
+	single := spec.Copy()
+	single.Programs = map[string]*ebpf.ProgramSpec{name: single.Programs[name]}
+	coll, err := ebpf.NewCollectionWithOptions(single, ebpf.CollectionOptions{
+		Programs: ebpf.ProgramOptions{LogLevel: ebpf.LogLevelBranch | ebpf.LogLevelStats},
+	})
+	if err == nil {
+		if prog := coll.Programs[name]; prog != nil {
+			result.Processed = verifierStats(strings.Split(strings.TrimSpace(prog.VerifierLog), "\n"))
+		}
+		coll.Close()
+		return result
+	}
+
+	result.Err = err
+	result.Message = err.Error()
+	var verr *ebpf.VerifierError
+	if !errors.As(err, &verr) {
+		if strings.Contains(err.Error(), "CO-RE") {
+			result.Message = "CO-RE relocation failed against the kernel BTF: " + err.Error()
+		}
+		return result
+	}
+	log := trimVerifierLog(verr.Log)
+	result.Processed = verifierStats(log)
+	if n := len(log); n > 0 {
+		result.Message = log[n-1]
+		if n > verifierLogTail {
+			log = log[n-verifierLogTail:]
+		}
+		result.Log = log
+	}
+	if strings.Contains(result.Message, poisonedRelocation) {
+		result.Message = "CO-RE relocation not satisfied by the kernel BTF (" + result.Message + ")"
+	}
+	result.File, result.Line, result.Source = verifierErrorSource(progSpec.Instructions, verr.Log)
+	return result
+}
+
+// 去掉日志末尾的统计和空行
+func trimVerifierLog(log []string) []string {
+	end := len(log)
+	for end > 0 {
+		line := strings.TrimSpace(log[end-1])
+		if line == "" || strings.HasPrefix(line, "processed ") || strings.HasPrefix(line, "verification time") ||
+			strings.HasPrefix(line, "stack depth") {
+			end--
+			continue
+		}
+		break
+	}
+	return log[:end]
+}
+
+// 校验器的统计行
+func verifierStats(log []string) string {
+	for i := len(log) - 1; i >= 0; i-- {
+		if strings.HasPrefix(log[i], "processed ") {
+			return log[i]
+		}
+	}
+	return ""
+}
+
+// 出错指令对应的源码行：日志中最后一条指令的编号按BTF行号信息查找，
+// 没有行号信息时使用日志中的源码注释（6.x内核）
+func verifierErrorSource(insns asm.Instructions, log []string) (string, int, string) {
+	for i := len(log) - 1; i >= 0; i-- {
+		m := verifierInsnRegex.FindStringSubmatch(log[i])
+		if m == nil {
+			continue
+		}
+		index, _ := strconv.Atoi(m[1])
+		if line := instructionLine(insns, index); line != nil {
+			return line.FileName(), int(line.LineNumber()), strings.TrimSpace(line.Line())
+		}
+		break
+	}
+	for i := len(log) - 1; i >= 0; i-- {
+		if m := verifierSourceRegex.FindStringSubmatch(log[i]); m != nil {
+			n, _ := strconv.Atoi(m[3])
+			return m[2], n, strings.TrimSpace(m[1])
+		}
+	}
+	return "", 0, ""
+}
+
+// 第index条指令（按8字节计，与校验器日志中的编号一致）所在的源码行
+func instructionLine(insns asm.Instructions, index int) *btf.Line {
+	var current *btf.Line
+	offset := 0
+	for _, ins := range insns {
+		if offset > index {
+			break
+		}
+		if line, ok := ins.Source().(*btf.Line); ok {
+			current = line
+		}
+		offset += int(ins.Size() / asm.InstructionSize)
+	}
+	return current
+}
+
+// 校验结果的报告：拒绝的程序按编译器诊断格式输出（file:line: error: ...），诊断窗口中可跳转；
+// details为true时附上校验器日志的最后几行
+func BPFVerifyReport(object string, results []bpfVerifyResult, details bool) ([]string, int) {
+	rejected := 0
+	core := 0
+	for _, r := range results {
+		core += r.CORE
+		if r.Err != nil {
+			rejected++
+		}
+	}
+	lines := []string{fmt.Sprintf("Verified %s: %d programs, %d passed, %d rejected",
+		filepath.Base(object), len(results), len(results)-rejected, rejected)}
+	if core > 0 {
+		kernelBTF := "/sys/kernel/btf/vmlinux"
+		if !project.FileExists(kernelBTF) {
+			kernelBTF += " missing"
+		}
+		lines = append(lines, fmt.Sprintf("CO-RE relocations: %d (kernel BTF: %s)", core, kernelBTF))
+	}
+	for _, r := range results {
+		if r.Err == nil {
+			stats := r.Processed
+			if stats == "" {
+				stats = "ok"
+			}
+			lines = append(lines, fmt.Sprintf("✅ %s (%d insns): %s", r.Section, r.Insns, stats))
+			continue
+		}
+		if r.File != "" {
+			lines = append(lines, fmt.Sprintf("%s:%d: error: %s: %s", r.File, r.Line, r.Section, r.Message))
+			if r.Source != "" {
+				lines = append(lines, "    "+r.Source)
+			}
+		} else {
+			lines = append(lines, fmt.Sprintf("❌ %s: %s", r.Section, r.Message))
+		}
+		if details {
+			for _, line := range r.Log {
+				lines = append(lines, "    | "+line)
+			}
+		}
+	}
+	if BPFObjectStale(object) {
+		lines = append(lines, "⚠️  The .bpf.o is older than its source, run 'compile' and verify again")
+	}
+	return lines, rejected
+}
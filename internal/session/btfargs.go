@@ -0,0 +1,279 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf/btf"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 函数参数（BTF） ==========
+// generate/vars 生成探针时从内核的BTF（/sys/kernel/btf/<模块> 优先，其次 vmlinux）查出断点函数的原型，
+// 在函数入口按目标架构的调用约定从pt_regs读取每个参数，以参数名作为 [VAR-N] 事件输出。
+// 参数名和类型保存在断点中，变量窗口的 Arguments 一节显示最近一次命中的参数，不需要先用 vars 猜变量名。
+// 模块的BTF需要模块已加载且内核开启 CONFIG_DEBUG_INFO_BTF_MODULES；配置了远程目标时通过ssh读取开发板的BTF。
+// 只在函数入口（偏移为0）读取参数，函数体中的断点寄存器可能已被覆盖。
+
+// 目标内核的BTF（按需加载，一次生成中共用）
+type ArgResolver struct {
+	ctx     *DebuggerContext
+	module  string // kallsyms中的模块名
+	vmlinux *btf.Spec
+	modSpec *btf.Spec
+	loadErr error
+	loaded  bool
+}
+
+// 创建参数解析器（BTF在第一次查询时加载）
+func NewArgResolver(ctx *DebuggerContext) *ArgResolver {
+	r := &ArgResolver{ctx: ctx}
+	if module := project.FindProjectModule(ctx.Project.RootPath); module != "" {
+		r.module = kallsymsModuleName(module)
+	}
+	return r
+}
+
+// 读取目标上的文件（远程目标通过ssh）
+func readTargetFile(ctx *DebuggerContext, path string) ([]byte, error) {
+	remote := RemoteTarget(ctx)
+	if remote == nil {
+		return os.ReadFile(path)
+	}
+	out, err := exec.Command("ssh", remote.SSHArgs("cat "+path)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 的 %s 失败: %v", remote.SSH, path, err)
+	}
+	return out, nil
+}
+
+// 加载vmlinux和项目模块的BTF（模块没有BTF时只用vmlinux）
+func (r *ArgResolver) load() error {
+	if r.loaded {
+		return r.loadErr
+	}
+	r.loaded = true
+	if RemoteTarget(r.ctx) == nil {
+		r.vmlinux, r.loadErr = btf.LoadKernelSpec()
+		if r.loadErr != nil {
+			r.loadErr = errcode.Errorf(errcode.ErrNoDebugInfo, "内核没有BTF（/sys/kernel/btf/vmlinux，需要CONFIG_DEBUG_INFO_BTF）: %v", r.loadErr)
+			return r.loadErr
+		}
+		if r.module != "" {
+			r.modSpec, _ = btf.LoadKernelModuleSpec(r.module)
+		}
+		return nil
+	}
+	data, err := readTargetFile(r.ctx, "/sys/kernel/btf/vmlinux")
+	if err != nil {
+		r.loadErr = errcode.Errorf(errcode.ErrNoDebugInfo, "目标内核没有BTF: %v", err)
+		return r.loadErr
+	}
+	if r.vmlinux, err = btf.LoadSpecFromReader(bytes.NewReader(data)); err != nil {
+		r.loadErr = errcode.Errorf(errcode.ErrNoDebugInfo, "解析目标内核的BTF失败: %v", err)
+		return r.loadErr
+	}
+	if r.module != "" {
+		if data, err := readTargetFile(r.ctx, "/sys/kernel/btf/"+r.module); err == nil {
+			r.modSpec, _ = btf.LoadSplitSpecFromReader(bytes.NewReader(data), r.vmlinux)
+		}
+	}
+	return nil
+}
+
+// 查找函数原型中的参数（模块BTF优先）
+func (r *ArgResolver) Args(function string) ([]project.FuncArg, error) {
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	var fn *btf.Func
+	for _, spec := range []*btf.Spec{r.modSpec, r.vmlinux} {
+		if spec == nil {
+			continue
+		}
+		if err := spec.TypeByName(function, &fn); err == nil {
+			break
+		}
+		fn = nil
+	}
+	if fn == nil {
+		hint := "模块未加载或没有BTF（CONFIG_DEBUG_INFO_BTF_MODULES）"
+		if r.modSpec != nil {
+			hint = "static inline或被优化掉的函数没有BTF"
+		}
+		return nil, errcode.Errorf(errcode.ErrNoDebugInfo, "BTF中没有函数 %s（%s）", function, hint)
+	}
+	proto, ok := fn.Type.(*btf.FuncProto)
+	if !ok {
+		return nil, fmt.Errorf("%s 的BTF类型不是函数原型", function)
+	}
+	args := make([]project.FuncArg, 0, len(proto.Params))
+	for i, param := range proto.Params {
+		arg := project.FuncArg{Name: param.Name, Type: btfTypeName(param.Type)}
+		if arg.Name == "" {
+			arg.Name = "arg" + strconv.Itoa(i)
+		}
+		if size, err := btf.Sizeof(param.Type); err == nil {
+			arg.Size = size
+		}
+		switch t := btf.UnderlyingType(param.Type).(type) {
+		case *btf.Int:
+			arg.Signed = t.Encoding&btf.Signed != 0
+		case *btf.Enum:
+			arg.Signed = t.Signed
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// BTF类型的C写法（typedef保留名称，不展开）
+func btfTypeName(t btf.Type) string {
+	switch t := t.(type) {
+	case nil, *btf.Void:
+		return "void"
+	case *btf.Pointer:
+		return strings.TrimSuffix(btfTypeName(t.Target), " ") + " *"
+	case *btf.Const:
+		return "const " + btfTypeName(t.Type)
+	case *btf.Volatile:
+		return "volatile " + btfTypeName(t.Type)
+	case *btf.Restrict:
+		return btfTypeName(t.Type)
+	case *btf.TypeTag:
+		return btfTypeName(t.Type)
+	case *btf.Struct:
+		return "struct " + t.Name
+	case *btf.Union:
+		return "union " + t.Name
+	case *btf.Enum:
+		return "enum " + t.Name
+	case *btf.Fwd:
+		return t.Kind.String() + " " + t.Name
+	case *btf.FuncProto:
+		return "func"
+	case *btf.Array:
+		return btfTypeName(t.Type) + "[" + strconv.Itoa(int(t.Nelems)) + "]"
+	}
+	return t.TypeName()
+}
+
+// 为断点解析参数并保存到断点中；不在函数入口、在内联副本中或是用户态探针时清空
+func resolveBreakpointArgs(r *ArgResolver, bp *project.Breakpoint) error {
+	bp.Args = nil
+	if bp.Binary != "" || bp.Offset != 0 || bp.Function == "" || bp.InlinedFrom != "" {
+		return nil
+	}
+	args, err := r.Args(bp.Function)
+	if err != nil {
+		return err
+	}
+	bp.Args = args
+	return nil
+}
+
+// 生成的BPF代码：按调用约定读取参数，每个参数输出一个 [VAR-N] 事件（skip中的名称已作为变量读取）
+func WriteArgumentReads(file *os.File, arch string, bp project.Breakpoint, breakpointID int, skip map[string]project.VariableLocation) {
+	if len(bp.Args) == 0 {
+		return
+	}
+	info := dwarf.TargetArchInfo(arch)
+	if info == nil {
+		fmt.Fprintf(file, "    // 目标架构 %s 没有寄存器约定，不读取函数参数\n\n", arch)
+		return
+	}
+	regs := info.Args
+	fmt.Fprintln(file, "    // 函数参数（原型来自BTF，按调用约定从pt_regs读取）")
+	for i, arg := range bp.Args {
+		if _, ok := skip[arg.Name]; ok {
+			continue
+		}
+		if i >= len(regs) {
+			fmt.Fprintf(file, "    // %s %s: 第%d个参数通过栈传递，不读取\n", arg.Type, arg.Name, i+1)
+			continue
+		}
+		if arg.Size == 0 || arg.Size > 8 {
+			fmt.Fprintf(file, "    // %s %s: 按值传递的结构体，不读取\n", arg.Type, arg.Name)
+			continue
+		}
+		value := fmt.Sprintf("(%s)%s", dwarf.ScalarCType(arg.Size, arg.Signed), info.CtxRegister(regs[i]))
+		fmt.Fprintf(file, "    // %s %s\n", arg.Type, arg.Name)
+		WriteDebugVarOutput(file, "    ", arg.Name, value, arg.Signed)
+		if arg.Signed {
+			fmt.Fprintf(file, "    debug_printk(\"[VAR-%d] %s:%s=%%lld PID=%%d\\n\", (long long)%s, event.pid);\n", breakpointID, bp.Function, arg.Name, value)
+		} else {
+			fmt.Fprintf(file, "    debug_printk(\"[VAR-%d] %s:%s=%%llu PID=%%d\\n\", (unsigned long long)%s, event.pid);\n", breakpointID, bp.Function, arg.Name, value)
+		}
+	}
+	fmt.Fprintln(file, "")
+}
+
+// 生成时解析参数，失败时在命令窗口给出警告（BTF整体不可用时只报告一次）
+func ResolveGeneratedArgs(ctx *DebuggerContext, r *ArgResolver, bp *project.Breakpoint) {
+	reported := r.loadErr != nil
+	if err := resolveBreakpointArgs(r, bp); err != nil && !reported {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: arguments of %s not captured: %v", bp.Function, err))
+		ctx.CommandDirty = true
+	}
+}
+
+// 参数值的显示：指针没有设置显示格式时按十六进制显示
+func formatArgValue(ctx *DebuggerContext, arg project.FuncArg, raw string) string {
+	if strings.HasSuffix(arg.Type, "*") && ValueFormatFor(ctx, arg.Name).Format == "dec" {
+		if n, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			if n == 0 {
+				return "NULL"
+			}
+			return fmt.Sprintf("0x%x", n)
+		}
+	}
+	return FormatValue(ctx, arg.Name, raw)
+}
+
+// 变量窗口中的参数：每个断点最近一次命中（标题 + 每个参数一行 + 空行）
+func ArgumentLines(ctx *DebuggerContext) ([]string, []string) {
+	latest := make(map[int]DebugEvent)
+	order := make([]int, 0)
+	for _, event := range EventsUntilCursor(ctx) {
+		if event.Kind != "breakpoint" {
+			continue
+		}
+		bp := breakpointForEvent(ctx, event)
+		if bp == nil || len(bp.Args) == 0 {
+			continue
+		}
+		if _, seen := latest[event.BreakpointID]; !seen {
+			order = append(order, event.BreakpointID)
+		}
+		latest[event.BreakpointID] = event
+	}
+	if len(order) == 0 {
+		return nil, nil
+	}
+	lines := []string{"Arguments:"}
+	names := []string{""}
+	for _, id := range order {
+		event := latest[id]
+		bp := breakpointForEvent(ctx, event)
+		lines = append(lines, fmt.Sprintf("BP%d %s() pid %d", id, event.Function, event.PID))
+		names = append(names, "")
+		for _, arg := range bp.Args {
+			value := "?"
+			for _, v := range event.Values {
+				if v.Name == arg.Name {
+					value = formatArgValue(ctx, arg, v.Value)
+				}
+			}
+			lines = append(lines, fmt.Sprintf("  %-8s %-15s %s", arg.Name, arg.Type, value))
+			names = append(names, arg.Name)
+		}
+	}
+	return append(lines, ""), append(names, "")
+}
@@ -0,0 +1,399 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 静态调用图 ==========
+// 被调用函数的来源优先级：项目中的cscope数据库 > 模块反汇编中的调用重定位 > 源码扫描。
+// 调用图以缩进树显示，在弹出窗口中选中项目内的函数按Enter即可在其入口设置断点。
+
+// 默认展开深度
+const DefaultCallGraphDepth = 3
+
+// 调用图最多显示的节点数
+const maxCallGraphNodes = 500
+
+// 项目中的函数定义
+type funcDef struct {
+	Name      string
+	File      string
+	Line      int // 定义所在行（从1开始）
+	BodyStart int // 函数体第一行
+	BodyEnd   int
+}
+
+// 调用图节点（按显示顺序展开）
+type callGraphNode struct {
+	Name  string
+	Depth int
+	Def   *funcDef // 为nil表示不在项目中（内核API等）
+	Note  string   // recursive / seen / truncated
+}
+
+var (
+	funcDefRegex  = regexp.MustCompile(`^(?:[A-Za-z_][\w\s\*]*[\s\*])?([A-Za-z_]\w*)\s*\(`)
+	callSiteRegex = regexp.MustCompile(`\b([A-Za-z_]\w*)\s*\(`)
+	stringRegex   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	// 模块反汇编中的调用重定位（x86_64/arm64/riscv）
+	callRelocRegex   = regexp.MustCompile(`R_(?:X86_64_PLT32|X86_64_PC32|AARCH64_CALL26|AARCH64_JUMP26|RISCV_CALL|RISCV_CALL_PLT)\s+([A-Za-z_][\w.]*)`)
+	objdumpFuncRegex = regexp.MustCompile(`^[0-9a-f]+ <([^>]+)>:$`)
+)
+
+// 非函数调用的关键字和常见宏形式
+func isCallKeyword(name string) bool {
+	switch name {
+	case "sizeof", "typeof", "__typeof__", "return", "defined", "__attribute__", "offsetof", "container_of":
+		return true
+	}
+	return project.IsKeyword(name)
+}
+
+// 全大写的标识符视为宏
+func isMacroName(name string) bool {
+	return strings.ToUpper(name) == name
+}
+
+// 去掉行内注释和字符串，避免误判调用
+func stripCodeLine(line string) string {
+	line = stringRegex.ReplaceAllString(line, `""`)
+	if idx := strings.Index(line, "//"); idx >= 0 {
+		line = line[:idx]
+	}
+	for {
+		start := strings.Index(line, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(line[start:], "*/")
+		if end < 0 {
+			line = line[:start]
+			break
+		}
+		line = line[:start] + line[start+end+2:]
+	}
+	return line
+}
+
+// 扫描项目源码，建立函数定义索引
+func indexProjectFunctions(root string) map[string]*funcDef {
+	defs := make(map[string]*funcDef)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".c") {
+			return nil
+		}
+		lines, err := project.ReadFileContent(path)
+		if err != nil {
+			return nil
+		}
+		for i := 0; i < len(lines); i++ {
+			def := parseFuncDef(lines, i)
+			if def == nil {
+				continue
+			}
+			def.File = path
+			if _, exists := defs[def.Name]; !exists {
+				defs[def.Name] = def
+			}
+			i = def.BodyEnd - 1
+		}
+		return nil
+	})
+	return defs
+}
+
+// 判断第i行是否是函数定义的开始（顶格书写、后面紧跟函数体）
+func parseFuncDef(lines []string, i int) *funcDef {
+	line := lines[i]
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '}' {
+		return nil
+	}
+	m := funcDefRegex.FindStringSubmatch(stripCodeLine(line))
+	if m == nil || isCallKeyword(m[1]) || isMacroName(m[1]) {
+		return nil
+	}
+
+	// 参数列表可能跨多行：找到第一个 { 之前不能出现 ;
+	depth := 0
+	for j := i; j < len(lines) && j < i+20; j++ {
+		code := stripCodeLine(lines[j])
+		if strings.Contains(code, ";") && !strings.Contains(code, "{") {
+			return nil
+		}
+		idx := strings.Index(code, "{")
+		if idx < 0 {
+			continue
+		}
+		// 从 { 开始数括号，找到函数体结束
+		for k := j; k < len(lines); k++ {
+			text := stripCodeLine(lines[k])
+			if k == j {
+				text = text[idx:]
+			}
+			depth += strings.Count(text, "{") - strings.Count(text, "}")
+			if depth <= 0 {
+				return &funcDef{Name: m[1], Line: i + 1, BodyStart: j + 1, BodyEnd: k + 1}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// 从源码扫描函数体中的调用
+func sourceCallees(def *funcDef) []string {
+	lines, err := project.ReadFileContent(def.File)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	callees := make([]string, 0)
+	for i := def.BodyStart - 1; i < def.BodyEnd && i < len(lines); i++ {
+		for _, m := range callSiteRegex.FindAllStringSubmatch(stripCodeLine(lines[i]), -1) {
+			name := m[1]
+			if name == def.Name && i == def.Line-1 {
+				continue
+			}
+			if isCallKeyword(name) || isMacroName(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			callees = append(callees, name)
+		}
+	}
+	return callees
+}
+
+// 使用cscope数据库查询被调用函数
+func cscopeCallees(root, name string) ([]string, bool) {
+	db := filepath.Join(root, "cscope.out")
+	if _, err := os.Stat(db); err != nil {
+		return nil, false
+	}
+	cmd := exec.Command("cscope", "-d", "-f", db, "-L", "-2", name)
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+	seen := make(map[string]bool)
+	callees := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		// 格式: <文件> <被调用函数> <行号> <代码>
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && !seen[fields[1]] {
+			seen[fields[1]] = true
+			callees = append(callees, fields[1])
+		}
+	}
+	return callees, true
+}
+
+// 从模块反汇编的调用重定位中提取调用关系：函数名 -> 被调用函数
+func disassemblyCallGraph(module string) map[string][]string {
+	if module == "" {
+		return nil
+	}
+	output, err := exec.Command("objdump", "-dr", module).Output()
+	if err != nil {
+		return nil
+	}
+	graph := make(map[string][]string)
+	current := ""
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if m := objdumpFuncRegex.FindStringSubmatch(line); m != nil {
+			current = m[1]
+			seen = make(map[string]bool)
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		if m := callRelocRegex.FindStringSubmatch(line); m != nil {
+			callee := strings.SplitN(m[1], "+", 2)[0]
+			if !seen[callee] && callee != current && !strings.HasPrefix(callee, ".") {
+				seen[callee] = true
+				graph[current] = append(graph[current], callee)
+			}
+		}
+	}
+	return graph
+}
+
+// 调用图数据来源
+type callGraphSource struct {
+	root      string
+	defs      map[string]*funcDef
+	disasm    map[string][]string
+	useCscope bool
+	Backend   string
+}
+
+// 准备调用图数据来源
+func newCallGraphSource(ctx *DebuggerContext) *callGraphSource {
+	src := &callGraphSource{root: ctx.Project.RootPath, defs: indexProjectFunctions(ctx.Project.RootPath)}
+	if _, err := os.Stat(filepath.Join(src.root, "cscope.out")); err == nil {
+		if _, err := exec.LookPath("cscope"); err == nil {
+			src.useCscope = true
+			src.Backend = "cscope"
+			return src
+		}
+	}
+	if src.disasm = disassemblyCallGraph(project.FindProjectModule(src.root)); len(src.disasm) > 0 {
+		src.Backend = "disassembly"
+		return src
+	}
+	src.Backend = "source scan"
+	return src
+}
+
+// 查询函数的被调用函数
+func (src *callGraphSource) callees(name string) []string {
+	if src.useCscope {
+		if callees, ok := cscopeCallees(src.root, name); ok {
+			return callees
+		}
+	}
+	if callees, exists := src.disasm[name]; exists {
+		return callees
+	}
+	if def := src.defs[name]; def != nil {
+		return sourceCallees(def)
+	}
+	return nil
+}
+
+// 深度优先展开调用树：同一函数只展开一次，递归调用单独标记
+func buildCallGraph(src *callGraphSource, root string, maxDepth int) []callGraphNode {
+	nodes := make([]callGraphNode, 0)
+	expanded := make(map[string]bool)
+	var walk func(name string, depth int, path map[string]bool)
+	walk = func(name string, depth int, path map[string]bool) {
+		if len(nodes) >= maxCallGraphNodes {
+			return
+		}
+		node := callGraphNode{Name: name, Depth: depth, Def: src.defs[name]}
+		switch {
+		case path[name]:
+			node.Note = "recursive"
+		case expanded[name]:
+			node.Note = "seen above"
+		}
+		nodes = append(nodes, node)
+		if node.Note != "" {
+			return
+		}
+		callees := src.callees(name)
+		if depth >= maxDepth {
+			if len(callees) > 0 && node.Def != nil {
+				nodes[len(nodes)-1].Note = fmt.Sprintf("+%d", len(callees))
+			}
+			return
+		}
+		expanded[name] = true
+		path[name] = true
+		for _, callee := range callees {
+			walk(callee, depth+1, path)
+		}
+		delete(path, name)
+	}
+	walk(root, 0, make(map[string]bool))
+	return nodes
+}
+
+// 调用树的显示内容
+func callGraphLines(ctx *DebuggerContext, nodes []callGraphNode) []string {
+	lines := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		indent := strings.Repeat("  ", node.Depth)
+		prefix := ""
+		if node.Depth > 0 {
+			prefix = "└ "
+		}
+		var text string
+		if node.Def != nil {
+			text = fmt.Sprintf("%s%s\x1b[36m%s\x1b[0m \x1b[90m%s:%d\x1b[0m", indent, prefix, node.Name, project.ProjectRelativePath(ctx.Project, node.Def.File), node.Def.Line)
+		} else {
+			text = fmt.Sprintf("%s%s\x1b[90m%s\x1b[0m", indent, prefix, node.Name)
+		}
+		if node.Note != "" {
+			text += fmt.Sprintf(" \x1b[33m(%s)\x1b[0m", node.Note)
+		}
+		lines = append(lines, text)
+	}
+	return lines
+}
+
+// 在函数入口（函数体第一行之后）设置断点
+func breakpointOnFunction(ctx *DebuggerContext, def *funcDef) (string, int, error) {
+	lines, err := project.ReadFileContent(def.File)
+	if err != nil {
+		return "", 0, err
+	}
+	line := def.BodyStart + 1
+	for line < def.BodyEnd && strings.TrimSpace(lines[line-1]) == "" {
+		line++
+	}
+	if line >= def.BodyEnd {
+		line = def.BodyStart
+	}
+	AddBreakpoint(ctx, def.File, line)
+	return def.File, line, nil
+}
+
+// 显示调用图弹出窗口
+func ShowCallGraphPopup(ctx *DebuggerContext, function string, depth int) (int, string, error) {
+	if ctx.Project == nil {
+		return 0, "", fmt.Errorf("没有打开的项目")
+	}
+	src := newCallGraphSource(ctx)
+	if src.defs[function] == nil && len(src.callees(function)) == 0 {
+		return 0, src.Backend, fmt.Errorf("项目中找不到函数: %s", function)
+	}
+	nodes := buildCallGraph(src, function, depth)
+
+	content := callGraphLines(ctx, nodes)
+	content = append(content, "", fmt.Sprintf("\x1b[90mSource: %s | cyan = in project, Enter on a node sets a breakpoint at its entry\x1b[0m", src.Backend))
+
+	ClosePopupWindow(ctx, "callgraph")
+	popup := CreatePopupWindow(ctx, "callgraph", fmt.Sprintf("Call Graph: %s", function), 90, 25, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		if index < 0 || index >= len(nodes) {
+			return nil
+		}
+		node := nodes[index]
+		if node.Def == nil {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[CALLGRAPH] %s is not defined in this project, cannot set a breakpoint", node.Name))
+		} else if file, line, err := breakpointOnFunction(ctx, node.Def); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[CALLGRAPH] Breakpoint toggled at %s:%d (%s)", project.ProjectRelativePath(ctx.Project, file), line, node.Name))
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	ShowPopupWindow(ctx, popup)
+	return len(nodes), src.Backend, nil
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ========== 命令窗口Tab补全 ==========
+
+// 候选列表最多显示的个数
+const MaxCompletionCandidates = 40
+
+// 命令字符串中以prefix开头的那些，在prefix所在位置的词（排序去重）
+func CompleteWords(prefix string, commands []string) []string {
+	wordStart := strings.LastIndex(prefix, " ") + 1
+	seen := make(map[string]bool)
+	words := make([]string, 0)
+	for _, command := range commands {
+		if !strings.HasPrefix(command, prefix) {
+			continue
+		}
+		word := command[wordStart:]
+		if end := strings.Index(word, " "); end >= 0 {
+			word = word[:end]
+		}
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+	return words
+}
+
+// 路径补全：返回路径在输入中的起始位置、已输入的目录部分和匹配的文件名（目录带 /）
+func CompletePath(input string, base string) (int, string, []string) {
+	for i := strings.Index(input, " ") + 1; i > 0 && i <= len(input); i++ {
+		if input[i-1] != ' ' {
+			continue
+		}
+		token := input[i:]
+		dirPart, filePart := "", token
+		if slash := strings.LastIndex(token, "/"); slash >= 0 {
+			dirPart, filePart = token[:slash+1], token[slash+1:]
+		}
+		dir := dirPart
+		if strings.HasPrefix(dir, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(home, dir[2:])
+			}
+		}
+		if dir == "" {
+			dir = base
+		} else if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		matches := make([]string, 0)
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, filePart) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(filePart, ".")) {
+				continue
+			}
+			if entry.IsDir() {
+				name += "/"
+			}
+			matches = append(matches, name)
+		}
+		if len(matches) > 0 {
+			sort.Strings(matches)
+			return i, dirPart, matches
+		}
+	}
+	return -1, "", nil
+}
+
+// 最长公共前缀
+func LongestCommonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	// 不截断多字节字符
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
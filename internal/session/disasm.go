@@ -0,0 +1,236 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 反汇编视图 ==========
+// 用objdump反汇编模块中的函数，按DWARF行号表在指令之间插入对应的源码行，
+// 断点的探针地址（函数入口 + 行号表解析出的偏移）高亮显示。
+// 模块中没有的函数从与当前内核匹配的vmlinux中反汇编，地址加上KASLR偏移显示为运行时地址。
+// 交叉编译的模块优先使用对应架构的 <triple>-objdump，其次是支持多架构的llvm-objdump。
+
+// 一个函数的反汇编结果（已渲染为代码窗口的行）
+type Disassembly struct {
+	Function    string
+	Module      string
+	Tool        string
+	Lines       []string
+	ProbeLine   int // 第一个探针指令所在的行，没有时为-1
+	SavedScroll int // 进入反汇编视图前代码窗口的滚动位置
+}
+
+// 各架构的objdump候选（按顺序查找）
+var objdumpCandidates = map[string][]string{
+	"x86_64":  {"objdump", "x86_64-linux-gnu-objdump", "llvm-objdump"},
+	"arm64":   {"aarch64-linux-gnu-objdump", "aarch64-none-linux-gnu-objdump", "llvm-objdump", "objdump"},
+	"riscv64": {"riscv64-linux-gnu-objdump", "riscv64-unknown-linux-gnu-objdump", "llvm-objdump", "objdump"},
+}
+
+// 查找能反汇编目标架构的objdump
+func findObjdump(arch string) (string, error) {
+	candidates, ok := objdumpCandidates[dwarf.RegsArch(arch)]
+	if !ok {
+		candidates = []string{"llvm-objdump", "objdump"}
+	}
+	for _, name := range candidates {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errcode.Errorf(errcode.ErrToolMissing, "没有找到 %s 的反汇编工具（%s）", arch, strings.Join(candidates, " / "))
+}
+
+var (
+	//    1c:	mov    %edi,-0x4(%rbp)
+	asmInsnRegex = regexp.MustCompile(`^\s*([0-9a-f]+):\s+(\S.*)$`)
+	//    			1d: R_X86_64_PLT32	helper-0x4
+	asmRelocRegex = regexp.MustCompile(`^\s*[0-9a-f]+:\s+R_\w+\s+([A-Za-z_.][\w.]*)`)
+)
+
+// 反汇编的一条指令
+type asmInsn struct {
+	addr  uint64
+	text  string
+	reloc string // 指令引用的重定位符号（.ko中调用和全局变量的目标在加载时才确定）
+}
+
+// 反汇编模块中的一个函数（地址为段内偏移，与DWARF行号表一致），extra 是附加的objdump参数
+func objdumpFunction(tool, module, function string, extra ...string) ([]asmInsn, error) {
+	args := append([]string{"-d", "-r", "--no-show-raw-insn"}, extra...)
+	output, err := exec.Command(tool, append(args, module)...).Output()
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrToolMissing, "%s 反汇编失败: %v", filepath.Base(tool), err)
+	}
+	insns := make([]asmInsn, 0)
+	inside := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := objdumpFuncRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			if inside {
+				break
+			}
+			inside = m[1] == function
+			continue
+		}
+		if !inside {
+			continue
+		}
+		if m := asmRelocRegex.FindStringSubmatch(line); m != nil {
+			if len(insns) > 0 && insns[len(insns)-1].reloc == "" {
+				insns[len(insns)-1].reloc = m[1]
+			}
+			continue
+		}
+		if m := asmInsnRegex.FindStringSubmatch(line); m != nil {
+			var addr uint64
+			fmt.Sscanf(m[1], "%x", &addr)
+			insns = append(insns, asmInsn{addr: addr, text: strings.Join(strings.Fields(m[2]), " ")})
+		}
+	}
+	if len(insns) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNoSymbol, "%s 中没有函数 %s", filepath.Base(module), function)
+	}
+	return insns, nil
+}
+
+// 调试信息中的源码路径对应的本地文件内容（只查本地，不触发源码获取）
+func disasmSourceLines(ctx *DebuggerContext, cache map[string][]string, path string) []string {
+	if lines, ok := cache[path]; ok {
+		return lines
+	}
+	local := SubstituteSourcePath(ctx, path)
+	if !filepath.IsAbs(local) {
+		local = filepath.Join(ctx.Project.RootPath, local)
+	}
+	if !project.FileExists(local) {
+		local = project.FindInProject(ctx.Project.RootPath, filepath.Base(path))
+	}
+	var lines []string
+	if local != "" {
+		lines, _ = project.ReadFileContent(local)
+	}
+	cache[path] = lines
+	return lines
+}
+
+// 反汇编函数并与源码交错，断点的探针地址高亮
+func DisassembleFunction(ctx *DebuggerContext, function string) (*Disassembly, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "项目中没有编译好的.ko")
+	}
+	arch, _ := DetectTargetArch(ctx)
+	tool, err := findObjdump(arch)
+	if err != nil {
+		return nil, err
+	}
+	insns, err := objdumpFunction(tool, module, function)
+	kernelImage := false
+	if err != nil {
+		// 模块中没有的函数到与当前内核匹配的vmlinux中找，显示加上KASLR偏移后的运行时地址
+		vmlinux := kaslrSymbolFile(ctx)
+		if vmlinux == "" {
+			return nil, err
+		}
+		if insns, err = objdumpFunction(tool, vmlinux, function, "--disassemble="+function); err != nil {
+			return nil, err
+		}
+		module, kernelImage = vmlinux, true
+	}
+
+	// 源码行（没有调试信息时只显示指令）
+	var rows map[uint64]dwarf.LineRow
+	entry := insns[0].addr
+	if resolver, err := ProjectLineResolver(ctx); err == nil && !kernelImage {
+		if fn := resolver.LineFunction(function); fn != nil {
+			rows = resolver.FunctionRows(fn)
+		}
+	}
+
+	// 该函数中断点的探针偏移
+	probes := make(map[uint64]bool)
+	for _, bp := range ctx.Project.Breakpoints {
+		if !bp.Enabled {
+			continue
+		}
+		for _, site := range dwarf.BreakpointProbeSites(bp) {
+			if site.Function == function {
+				probes[site.Offset] = true
+			}
+		}
+	}
+
+	d := &Disassembly{Function: function, Module: module, Tool: filepath.Base(tool), ProbeLine: -1}
+	sources := make(map[string][]string)
+	lastFile, lastLine := "", 0
+	for _, insn := range insns {
+		if row, ok := rows[insn.addr]; ok && (row.Line != lastLine || row.File != lastFile) {
+			text := ""
+			if lines := disasmSourceLines(ctx, sources, row.File); row.Line > 0 && row.Line-1 < len(lines) {
+				text = strings.TrimSpace(lines[row.Line-1])
+			}
+			d.Lines = append(d.Lines, fmt.Sprintf("\x1b[36m%s:%d\x1b[0m  %s", filepath.Base(row.File), row.Line, text))
+			lastFile, lastLine = row.File, row.Line
+		}
+		offset := insn.addr - entry
+		addr := fmt.Sprintf("%6x", insn.addr)
+		if kernelImage {
+			addr = fmt.Sprintf("%16x", translateAddress(ctx, insn.addr))
+		}
+		text := insn.text
+		if insn.reloc != "" {
+			text += "  \x1b[90m; " + insn.reloc + "\x1b[0m"
+		}
+		if probes[offset] {
+			if d.ProbeLine < 0 {
+				d.ProbeLine = len(d.Lines)
+			}
+			d.Lines = append(d.Lines, fmt.Sprintf("\x1b[41;97m●=>\x1b[0m %s <+%-4d> %s", addr, offset, text))
+		} else {
+			d.Lines = append(d.Lines, fmt.Sprintf("    %s <+%-4d> %s", addr, offset, text))
+		}
+	}
+	return d, nil
+}
+
+// 要反汇编的默认函数：最近命中的断点 > 代码光标所在函数 > 第一个启用的断点
+func DefaultDisasmFunction(ctx *DebuggerContext, file string, line int) string {
+	for i := len(ctx.Events) - 1; i >= 0; i-- {
+		if ctx.Events[i].Kind == "breakpoint" && ctx.Events[i].Function != "" {
+			return ctx.Events[i].Function
+		}
+	}
+	if file != "" {
+		if resolver, err := ProjectLineResolver(ctx); err == nil {
+			if loc, err := resolver.Resolve(file, line); err == nil {
+				return loc.Function
+			}
+		}
+		if name := project.ParseFunctionName(file, line); name != "" && name != "unknown" {
+			return name
+		}
+	}
+	if ctx.Project != nil {
+		for _, bp := range ctx.Project.Breakpoints {
+			if bp.Enabled && bp.Function != "" && bp.Function != "unknown" {
+				return bp.Function
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,12 @@
+package session
+
+import "debug-gocui/internal/errcode"
+
+// ========== 结构化错误码 ==========
+
+// 后台任务和弹出窗口回调的错误写入命令窗口（与命令失败的格式相同）
+func ReportError(ctx *DebuggerContext, err error) {
+	ctx.LastErrorCode = errcode.Of(err)
+	ctx.CommandHistory = append(ctx.CommandHistory, errcode.Format(err)...)
+	ctx.CommandDirty = true
+}
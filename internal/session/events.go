@@ -0,0 +1,563 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 调试事件 ==========
+// 生成的BPF程序通过bpf_printk输出到trace_pipe，每行解析为一个事件：
+//   [BREAKPOINT-N] file.c:42 in func() PID=123 TGID=123 at 456
+//   [VAR-N] func:name=value PID=123
+//   [RETVAL-N] func=value PID=123
+//   [SPAN-N] entry->exit 12345ns PID=123（span.go）
+//   [LOCK-N] caller=0xffff... wait=120 hold=3400、[SLEEP-ATOMIC] caller=0xffff... depth=1 PID=123（locks.go）
+// bpf load 进程内加载时事件改从perf buffer读取（perfevents.go），解码后同样进入appendEvent。
+
+// 事件缓冲区上限（超出后丢弃最旧的事件）
+const maxEvents = 10000
+
+// 折叠组中保留的原始事件上限（用于展开显示）
+const maxFoldedSamples = 200
+
+// 调试事件
+type DebugEvent struct {
+	Seq          int       // 事件序号（单调递增）
+	Time         time.Time // 接收时间
+	TraceTime    float64   // trace_pipe中的时间戳（秒）
+	Kind         string    // "breakpoint"、"var" 或 "return"
+	BreakpointID int       // 断点编号
+	Location     string    // file:line
+	Function     string
+	PID          int
+	TGID         int
+	Comm         string
+	CPU          int
+	Values       []project.EventValue // 同一次命中中采集到的变量
+	Stack        []StackFrame         // 命中时的内核调用栈（perf buffer，没有时为nil）
+	Raw          string
+}
+
+// 折叠后的事件组：连续相同的事件（同一断点、PID、变量值）合并为一行
+type EventGroup struct {
+	First  DebugEvent
+	Last   DebugEvent
+	Count  int
+	Events []DebugEvent // 组内原始事件（最多maxFoldedSamples个）
+}
+
+var (
+	// trace_pipe行前缀：  comm-pid  [cpu] flags  12345.678901: bpf_trace_printk: msg
+	TracePipeLineRegex = regexp.MustCompile(`^\s*(.+)-(\d+)\s+(?:\(\s*[\d-]+\)\s+)?\[(\d+)\]\s+(?:\S+\s+)?(\d+\.\d+):\s+\S+:\s+(.*)$`)
+	breakpointMsgRegex = regexp.MustCompile(`^\[BREAKPOINT-(\d+)\]\s+(\S+)\s+in\s+(\S+?)(?:\(\))?\s+PID=(\d+)(?:\s+TGID=(\d+))?`)
+	varMsgRegex        = regexp.MustCompile(`^\[VAR-(\d+)\]\s+([^:\s]+):([^=\s]+)=(\S+)\s+PID=(\d+)`)
+	retvalMsgRegex     = regexp.MustCompile(`^\[RETVAL-(\d+)\]\s+([^=\s]+)=(\S+)\s+PID=(\d+)`)
+	spanMsgRegex       = regexp.MustCompile(`^\[SPAN-(\d+)\]\s+(\S+)\s+(\d+)ns\s+PID=(\d+)`)
+	lockMsgRegex       = regexp.MustCompile(`^\[LOCK-(\d+)\]\s+caller=(0x[0-9a-fA-F]+)\s+wait=(\d+)\s+hold=(\d+)`)
+	sleepMsgRegex      = regexp.MustCompile(`^\[SLEEP-ATOMIC\]\s+caller=(0x[0-9a-fA-F]+)\s+depth=(\d+)\s+PID=(\d+)`)
+)
+
+// 解析trace_pipe中的一行，无法识别的行返回false
+func parseTraceLine(line string) (DebugEvent, bool) {
+	event := DebugEvent{Raw: line, Time: time.Now(), CPU: -1}
+	msg := strings.TrimSpace(line)
+	linePID := 0
+	if m := TracePipeLineRegex.FindStringSubmatch(line); m != nil {
+		event.Comm = strings.TrimSpace(m[1])
+		linePID, _ = strconv.Atoi(m[2])
+		event.CPU, _ = strconv.Atoi(m[3])
+		event.TraceTime, _ = strconv.ParseFloat(m[4], 64)
+		msg = m[5]
+	}
+
+	if m := breakpointMsgRegex.FindStringSubmatch(msg); m != nil {
+		event.Kind = "breakpoint"
+		event.BreakpointID, _ = strconv.Atoi(m[1])
+		event.Location = m[2]
+		event.Function = m[3]
+		event.PID, _ = strconv.Atoi(m[4])
+		event.TGID, _ = strconv.Atoi(m[5])
+		return event, true
+	}
+	if m := varMsgRegex.FindStringSubmatch(msg); m != nil {
+		event.Kind = "var"
+		event.BreakpointID, _ = strconv.Atoi(m[1])
+		event.Function = m[2]
+		event.PID, _ = strconv.Atoi(m[5])
+		event.Values = []project.EventValue{{Name: m[3], Value: m[4]}}
+		return event, true
+	}
+	if m := retvalMsgRegex.FindStringSubmatch(msg); m != nil {
+		event.Kind = "return"
+		event.BreakpointID, _ = strconv.Atoi(m[1])
+		event.Function = m[2]
+		event.PID, _ = strconv.Atoi(m[4])
+		event.Values = []project.EventValue{{Name: "return", Value: m[3]}}
+		return event, true
+	}
+	if m := spanMsgRegex.FindStringSubmatch(msg); m != nil {
+		event.Kind = "span"
+		event.BreakpointID, _ = strconv.Atoi(m[1])
+		event.Function = m[2]
+		event.PID, _ = strconv.Atoi(m[4])
+		event.Values = []project.EventValue{{Name: "duration_ns", Value: m[3]}}
+		return event, true
+	}
+	if m := lockMsgRegex.FindStringSubmatch(msg); m != nil {
+		// bpf_printk最多3个参数，PID取trace_pipe行前缀中的
+		event.Kind = "lock"
+		event.BreakpointID, _ = strconv.Atoi(m[1])
+		event.Function = lockKindName(event.BreakpointID)
+		event.PID = linePID
+		event.Values = []project.EventValue{{Name: "caller", Value: m[2]}, {Name: "wait_ns", Value: m[3]}, {Name: "hold_ns", Value: m[4]}}
+		return event, true
+	}
+	if m := sleepMsgRegex.FindStringSubmatch(msg); m != nil {
+		event.Kind = "atomic-sleep"
+		event.BreakpointID = lockSpinKind()
+		event.Function = "schedule"
+		event.PID, _ = strconv.Atoi(m[3])
+		event.Values = []project.EventValue{{Name: "caller", Value: m[1]}, {Name: "depth", Value: m[2]}}
+		return event, true
+	}
+	return event, false
+}
+
+// 添加事件：变量输出合并到同一断点、同一PID的上一次命中中
+func AppendEvent(ctx *DebuggerContext, event DebugEvent) {
+	// 锁探针的加锁记录只做汇总（locks窗口和源码行标注）
+	if recordLockEvent(ctx, &event) {
+		return
+	}
+	// 事件中的值同时刷新监视表达式（全局变量监视点、快照）
+	for _, v := range event.Values {
+		updateWatchValue(ctx, v.Name, v.Value)
+	}
+	if event.Kind == "var" {
+		// 内核日志和硬件断点命中可能已插在命中之后
+		i := len(ctx.Events) - 1
+		for i >= 0 && separateStream(ctx.Events[i].Kind) {
+			i--
+		}
+		if i >= 0 {
+			last := &ctx.Events[i]
+			if last.Kind == "breakpoint" && last.BreakpointID == event.BreakpointID && last.PID == event.PID {
+				last.Values = append(last.Values, event.Values...)
+				last.Raw += "\n" + event.Raw
+				return
+			}
+		}
+	}
+
+	ctx.EventSeq++
+	event.Seq = ctx.EventSeq
+	checkAssertions(ctx, event)
+	// 内核日志（kmsg.go）、硬件断点（hwbp.go）与trace_pipe是独立读取的流，彼此之间的到达顺序不确定：
+	// 它们按时间戳插到更晚的事件之前；trace_pipe和perf buffer的事件经updateBatcher按读取顺序到达
+	at := len(ctx.Events)
+	for event.TraceTime > 0 && at > 0 {
+		prev := ctx.Events[at-1]
+		if (!separateStream(prev.Kind) && !separateStream(event.Kind)) || prev.TraceTime <= event.TraceTime {
+			break
+		}
+		at--
+	}
+	ctx.Events = append(ctx.Events, event)
+	if at < len(ctx.Events)-1 {
+		copy(ctx.Events[at+1:], ctx.Events[at:])
+		ctx.Events[at] = event
+	}
+	if len(ctx.Events) > maxEvents {
+		ctx.EventsDropped += len(ctx.Events) - maxEvents
+		ctx.Events = ctx.Events[len(ctx.Events)-maxEvents:]
+	}
+}
+
+// 是否来自独立于trace_pipe读取的事件流
+func separateStream(kind string) bool {
+	return kind == "kmsg" || kind == "hwbp"
+}
+
+// 事件折叠键：断点、PID和变量值都相同的事件视为重复
+func eventFoldKey(event DebugEvent) string {
+	parts := []string{event.Kind, strconv.Itoa(event.BreakpointID), strconv.Itoa(event.PID), event.Location}
+	for _, v := range event.Values {
+		parts = append(parts, v.Name+"="+v.Value)
+	}
+	return strings.Join(parts, "|")
+}
+
+// 将连续相同的事件折叠为组（显示时计算，因此实时采集和重放都适用）
+func foldEvents(events []DebugEvent, fold bool) []EventGroup {
+	groups := make([]EventGroup, 0)
+	lastKey := ""
+	for _, event := range events {
+		key := eventFoldKey(event)
+		if fold && len(groups) > 0 && key == lastKey {
+			group := &groups[len(groups)-1]
+			group.Count++
+			group.Last = event
+			if len(group.Events) < maxFoldedSamples {
+				group.Events = append(group.Events, event)
+			}
+			continue
+		}
+		groups = append(groups, EventGroup{First: event, Last: event, Count: 1, Events: []DebugEvent{event}})
+		lastKey = key
+	}
+	return groups
+}
+
+// 格式化单个事件（变量值按各自的显示格式渲染）
+func FormatEvent(ctx *DebuggerContext, event DebugEvent) string {
+	var b strings.Builder
+	if event.TraceTime > 0 {
+		fmt.Fprintf(&b, "%12.6f ", event.TraceTime)
+	} else {
+		b.WriteString(event.Time.Format("15:04:05.000") + " ")
+	}
+	switch event.Kind {
+	case "breakpoint":
+		fmt.Fprintf(&b, "BP%-3d %s %s()", event.BreakpointID, event.Location, event.Function)
+		if bp := breakpointForEvent(ctx, event); bp != nil && bp.Binary != "" {
+			b.WriteString(" \x1b[35m[user]\x1b[0m")
+		}
+	case "snapshot":
+		b.WriteString("SNAP ")
+		for _, v := range event.Values {
+			fmt.Fprintf(&b, " %s=%s", v.Name, FormatValue(ctx, v.Name, v.Value))
+		}
+		return b.String()
+	case "watchdog":
+		fmt.Fprintf(&b, "\x1b[41;97mWDOG\x1b[0m %s", event.Location)
+		return b.String()
+	case "kmsg":
+		b.WriteString("\x1b[90mKMSG\x1b[0m " + formatKmsg(event))
+		return b.String()
+	case "hwbp":
+		fmt.Fprintf(&b, "\x1b[33mHW%-3d\x1b[0m ", event.BreakpointID)
+		if event.Location != "" {
+			fmt.Fprintf(&b, "%s %s()", event.Location, event.Function)
+		} else {
+			b.WriteString(event.Function)
+		}
+	case "span":
+		ns, _ := spanEventNanos(event)
+		fmt.Fprintf(&b, "SPAN%-2d %s %s pid=%d", event.BreakpointID, event.Function, formatSpanNanos(ns), event.PID)
+		if event.Comm != "" {
+			fmt.Fprintf(&b, " [%s]", event.Comm)
+		}
+		return b.String()
+	case "atomic-sleep":
+		fmt.Fprintf(&b, "%s %s sleeps holding spinlock from %s pid=%d", Styled(ActiveTheme.Alert, "SLEEP"), event.Function, event.Location, event.PID)
+		if event.Comm != "" {
+			fmt.Fprintf(&b, " [%s]", event.Comm)
+		}
+		return b.String()
+	case "return":
+		fmt.Fprintf(&b, "RET%-3d %s() = %s pid=%d", event.BreakpointID, event.Function, FormatValue(ctx, "return", event.Values[0].Value), event.PID)
+		if event.Comm != "" {
+			fmt.Fprintf(&b, " [%s]", event.Comm)
+		}
+		return b.String()
+	default:
+		fmt.Fprintf(&b, "VAR%-2d %s()", event.BreakpointID, event.Function)
+	}
+	fmt.Fprintf(&b, " pid=%d", event.PID)
+	if event.Comm != "" {
+		fmt.Fprintf(&b, " [%s]", event.Comm)
+	}
+	for _, v := range event.Values {
+		fmt.Fprintf(&b, " %s=%s", v.Name, FormatValue(ctx, v.Name, v.Value))
+	}
+	return b.String()
+}
+
+// 按断点过滤后的事件（快照、看门狗标记和内核日志不属于任何断点，始终显示）
+func visibleEvents(ctx *DebuggerContext) []DebugEvent {
+	if len(ctx.EventFilter) == 0 {
+		return ctx.Events
+	}
+	events := make([]DebugEvent, 0, len(ctx.Events))
+	for _, event := range ctx.Events {
+		if (event.Kind != "breakpoint" && event.Kind != "var" && event.Kind != "return") || ctx.EventFilter[event.BreakpointID] {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// 切换断点过滤（n为事件中的断点编号）
+func toggleEventFilter(ctx *DebuggerContext, n int) {
+	if ctx.EventFilter == nil {
+		ctx.EventFilter = make(map[int]bool)
+	}
+	if ctx.EventFilter[n] {
+		delete(ctx.EventFilter, n)
+	} else {
+		ctx.EventFilter[n] = true
+	}
+}
+
+// 过滤条件描述
+func EventFilterText(ctx *DebuggerContext) string {
+	ids := make([]int, 0, len(ctx.EventFilter))
+	for id := range ctx.EventFilter {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, fmt.Sprintf("BP%d", id))
+	}
+	return strings.Join(names, ",")
+}
+
+// 生成事件列表显示内容（折叠组带×N计数，展开的组列出组内事件）
+func eventListLines(ctx *DebuggerContext) []string {
+	groups := foldEvents(visibleEvents(ctx), !ctx.EventFoldOff)
+	lines := make([]string, 0, len(groups))
+	for i, group := range groups {
+		line := fmt.Sprintf("%4d  %s", i+1, FormatEvent(ctx, group.First))
+		if ctx.AssertViolated[group.First.Seq] {
+			line = fmt.Sprintf("%4d [41;97m![0m%s", i+1, FormatEvent(ctx, group.First))
+		}
+		if group.Count > 1 {
+			line += fmt.Sprintf(" \x1b[33m×%d\x1b[0m", group.Count)
+			if !ctx.ExpandedEventGroups[group.First.Seq] {
+				line += " \x1b[90m(+)\x1b[0m"
+			}
+		}
+		lines = append(lines, line)
+		if group.Count > 1 && ctx.ExpandedEventGroups[group.First.Seq] {
+			for _, event := range group.Events {
+				mark := "\x1b[90m└\x1b[0m "
+				if ctx.AssertViolated[event.Seq] {
+					mark = "\x1b[41;97m!\x1b[0m "
+				}
+				lines = append(lines, "        "+mark+FormatEvent(ctx, event))
+			}
+			if group.Count > len(group.Events) {
+				lines = append(lines, fmt.Sprintf("        \x1b[90m… %d more\x1b[0m", group.Count-len(group.Events)))
+			}
+		}
+	}
+	return lines
+}
+
+// 切换折叠组的展开状态（n为列表中的组编号，从1开始）
+func ToggleEventGroup(ctx *DebuggerContext, n int) error {
+	groups := foldEvents(visibleEvents(ctx), !ctx.EventFoldOff)
+	if n < 1 || n > len(groups) {
+		return fmt.Errorf("事件组编号超出范围: %d (共%d组)", n, len(groups))
+	}
+	if ctx.ExpandedEventGroups == nil {
+		ctx.ExpandedEventGroups = make(map[int]bool)
+	}
+	seq := groups[n-1].First.Seq
+	ctx.ExpandedEventGroups[seq] = !ctx.ExpandedEventGroups[seq]
+	return nil
+}
+
+// 事件窗口标题：事件数、采集状态和过滤条件
+func eventsPopupTitle(ctx *DebuggerContext) string {
+	title := fmt.Sprintf("Events (%d)", len(ctx.Events))
+	if ctx.EventSource != nil {
+		title += " ● live"
+	}
+	if len(ctx.EventFilter) > 0 {
+		title += " | filter: " + EventFilterText(ctx)
+	}
+	return title
+}
+
+// 事件窗口内容
+func eventsPopupContent(ctx *DebuggerContext) []string {
+	content := eventListLines(ctx)
+	if len(content) == 0 {
+		if len(ctx.EventFilter) > 0 && len(ctx.Events) > 0 {
+			return []string{fmt.Sprintf("No events for %s (%d hidden)", EventFilterText(ctx), len(ctx.Events)), "", "Press 1-9 to toggle breakpoints, 'events filter off' shows all"}
+		}
+		return []string{"No events captured yet", "", "Use 'events start' to read trace_pipe"}
+	}
+	return content
+}
+
+// 滚动到末尾（跟随最新事件）
+func scrollPopupToEnd(popup *PopupWindow) {
+	popup.ScrollY = len(popup.Content) - (popup.Height - 3)
+	if popup.ScrollY < 0 {
+		popup.ScrollY = 0
+	}
+}
+
+// 显示事件列表弹出窗口：采集时实时追加，按1-9切换只看某个断点
+func ShowEventsPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, "events")
+	popup := CreatePopupWindow(ctx, "events", eventsPopupTitle(ctx), 110, 25, eventsPopupContent(ctx))
+	popup.OnDigit = func(ui UI, n int) error {
+		toggleEventFilter(ctx, n)
+		RefreshEventsPopup(ctx)
+		if popup := FindPopupWindow(ctx, "events"); popup != nil {
+			scrollPopupToEnd(popup)
+		}
+		return nil
+	}
+	scrollPopupToEnd(popup)
+	ShowPopupWindow(ctx, popup)
+}
+
+// 事件窗口打开时刷新内容；停在末尾时继续跟随新事件，向上翻看时保持位置（内核日志窗口一起刷新）
+func RefreshEventsPopup(ctx *DebuggerContext) {
+	RefreshKmsgPopup(ctx)
+	popup := FindPopupWindow(ctx, "events")
+	if popup == nil {
+		return
+	}
+	following := popup.ScrollY+(popup.Height-3) >= len(popup.Content)
+	popup.Title = eventsPopupTitle(ctx)
+	popup.Content = eventsPopupContent(ctx)
+	if following {
+		scrollPopupToEnd(popup)
+	}
+}
+
+// ========== trace_pipe读取 ==========
+
+// trace_pipe可能的位置（新内核的tracefs与旧的debugfs挂载点）
+var TracePipePaths = []string{
+	"/sys/kernel/tracing/trace_pipe",
+	"/sys/kernel/debug/tracing/trace_pipe",
+}
+
+// 打开trace_pipe
+func OpenTracePipe() (*os.File, string, error) {
+	var lastErr error
+	for _, path := range TracePipePaths {
+		file, err := os.Open(path)
+		if err == nil {
+			return file, path, nil
+		}
+		lastErr = err
+	}
+	return nil, "", errcode.Errorf(errcode.ErrTracePipe, "无法打开trace_pipe: %v", lastErr)
+}
+
+// 启动trace_pipe读取协程，事件通过ui.Update回到UI线程
+func StartEventCapture(ui UI, ctx *DebuggerContext) (string, error) {
+	return StartBackendCapture(ui, ctx, CurrentBackend(ctx))
+}
+
+// 用指定的后端启动事件采集（stap run 不改变项目设置的后端）
+func StartBackendCapture(ui UI, ctx *DebuggerContext, backend string) (string, error) {
+	if ctx.EventSource != nil {
+		return "", fmt.Errorf("事件采集已在运行")
+	}
+	if err := CheckSafeMode(ctx, "事件采集"); err != nil {
+		return "", err
+	}
+	if StopModeBackend(backend) {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "%s后端不采集事件，请使用 break/continue/step", backend)
+	}
+	capture, ok := CaptureBackends[backend]
+	if !ok {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "未知的后端: %s", backend)
+	}
+	file, path, err := capture.Start(ctx)
+	if err != nil {
+		return "", err
+	}
+	ctx.EventSource = file
+	ctx.CaptureBackend = backend
+	ctx.CaptureStart = time.Now()
+	ctx.CaptureStop = time.Time{}
+
+	go func() {
+		// 按读取顺序成批交给UI线程：[VAR-N] 要并入前面的 [BREAKPOINT-N]，Seq 也按到达顺序编号
+		batcher := newUpdateBatcher(ui, func(ui UI, lines []traceLine) {
+			for _, l := range lines {
+				if event, ok := parseTraceLine(l.text); ok {
+					AppendEvent(ctx, event)
+				} else if handleBackendLine(ctx, l.text) {
+					// function_graph调用图或kprobe_events探针
+				} else {
+					ctx.EventsUnparsed++
+				}
+				recordEventLatency(ctx, time.Since(l.readAt))
+			}
+			RefreshEventsPopup(ctx)
+			RefreshStatsPopup(ctx)
+		})
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			batcher.add(traceLine{text: scanner.Text(), readAt: time.Now()})
+		}
+	}()
+	return path, nil
+}
+
+// trace_pipe中读到的一行
+type traceLine struct {
+	text   string
+	readAt time.Time
+}
+
+// 读取协程到UI线程的有序批量投递。gocui的g.Update为每个回调单独起协程发送，
+// 连续的回调到达UI线程的顺序不确定；这里同一时刻最多只有一个待执行的回调，
+// 它一次取走积压的全部数据，因此数据按读取顺序处理，弹出窗口每批只刷新一次
+type updateBatcher[T any] struct {
+	update    func(func(UI) error)
+	apply     func(UI, []T)
+	mu        sync.Mutex
+	pending   []T
+	scheduled bool
+}
+
+func newUpdateBatcher[T any](ui UI, apply func(UI, []T)) *updateBatcher[T] {
+	return &updateBatcher[T]{update: ui.Update, apply: apply}
+}
+
+// 在读取协程中调用：加入队列，没有待执行的回调时安排一个
+func (b *updateBatcher[T]) add(item T) {
+	b.mu.Lock()
+	b.pending = append(b.pending, item)
+	schedule := !b.scheduled
+	b.scheduled = true
+	b.mu.Unlock()
+	if schedule {
+		b.update(b.flush)
+	}
+}
+
+// 在UI线程中执行：取走积压的数据（之后到达的数据安排下一个回调）
+func (b *updateBatcher[T]) flush(ui UI) error {
+	b.mu.Lock()
+	items := b.pending
+	b.pending = nil
+	b.scheduled = false
+	b.mu.Unlock()
+	b.apply(ui, items)
+	return nil
+}
+
+// 停止trace_pipe读取（关闭文件使读取协程退出）
+func StopEventCapture(ctx *DebuggerContext) bool {
+	if ctx.EventSource == nil {
+		return false
+	}
+	ctx.EventSource.Close()
+	ctx.EventSource = nil
+	disarmBackend(ctx)
+	ctx.CaptureStop = time.Now()
+	return true
+}
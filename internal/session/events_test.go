@@ -0,0 +1,125 @@
+package session
+
+import (
+	"testing"
+
+	"debug-gocui/internal/project"
+)
+
+func TestParseTraceLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		kind     string
+		id       int
+		function string
+		pid      int
+		cpu      int
+		values   []project.EventValue
+	}{
+		{
+			line: "  insmod-1234  [002] d..1  5678.123456: bpf_trace_printk: [BREAKPOINT-1] drv.c:11 in do_work() PID=1234 TGID=1230",
+			kind: "breakpoint", id: 1, function: "do_work", pid: 1234, cpu: 2,
+		},
+		{
+			line: "[VAR-2] scale:factor=3 PID=42",
+			kind: "var", id: 2, function: "scale", pid: 42, cpu: -1,
+			values: []project.EventValue{{Name: "factor", Value: "3"}},
+		},
+		{
+			line: "cat-7 [000] 1.000001: bpf_trace_printk: [RETVAL-3] do_work=-22 PID=7",
+			kind: "return", id: 3, function: "do_work", pid: 7, cpu: 0,
+			values: []project.EventValue{{Name: "return", Value: "-22"}},
+		},
+		{
+			line: "[SPAN-1] probe 1500ns PID=9",
+			kind: "span", id: 1, function: "probe", pid: 9, cpu: -1,
+			values: []project.EventValue{{Name: "duration_ns", Value: "1500"}},
+		},
+	}
+	for _, tt := range tests {
+		event, ok := parseTraceLine(tt.line)
+		if !ok {
+			t.Errorf("parseTraceLine(%q) not recognized", tt.line)
+			continue
+		}
+		if event.Kind != tt.kind || event.BreakpointID != tt.id || event.Function != tt.function || event.PID != tt.pid || event.CPU != tt.cpu {
+			t.Errorf("parseTraceLine(%q) = %s bp%d %s pid %d cpu %d", tt.line, event.Kind, event.BreakpointID, event.Function, event.PID, event.CPU)
+		}
+		if len(event.Values) != len(tt.values) {
+			t.Errorf("parseTraceLine(%q) values = %v, want %v", tt.line, event.Values, tt.values)
+			continue
+		}
+		for i, v := range tt.values {
+			if event.Values[i] != v {
+				t.Errorf("parseTraceLine(%q) value %d = %v, want %v", tt.line, i, event.Values[i], v)
+			}
+		}
+	}
+	for _, line := range []string{"", "CPU:3 [LOST 12 EVENTS]", "[BREAKPOINT-x] drv.c:1 in f() PID=1"} {
+		if _, ok := parseTraceLine(line); ok {
+			t.Errorf("parseTraceLine(%q) should not be recognized", line)
+		}
+	}
+}
+
+func TestAppendEventMergesVars(t *testing.T) {
+	ctx := NewDebuggerContext()
+	for _, line := range []string{
+		"[BREAKPOINT-1] drv.c:11 in do_work() PID=5",
+		"[VAR-1] do_work:a=1 PID=5",
+		"[VAR-1] do_work:b=2 PID=5",
+		"[VAR-1] do_work:a=9 PID=6",
+	} {
+		event, ok := parseTraceLine(line)
+		if !ok {
+			t.Fatalf("parseTraceLine(%q) not recognized", line)
+		}
+		AppendEvent(ctx, event)
+	}
+	// 同一断点、同一PID的变量合并到命中中，其他PID的变量单独成为事件
+	if len(ctx.Events) != 2 {
+		t.Fatalf("events = %d, want 2", len(ctx.Events))
+	}
+	if values := ctx.Events[0].Values; len(values) != 2 || values[1].Name != "b" {
+		t.Errorf("merged values = %v", values)
+	}
+	if ctx.Events[1].Kind != "var" || ctx.Events[1].PID != 6 {
+		t.Errorf("second event = %s pid %d", ctx.Events[1].Kind, ctx.Events[1].PID)
+	}
+}
+
+func TestUpdateBatcherKeepsReadOrder(t *testing.T) {
+	var queued []func(UI) error
+	var applied []int
+	batches := 0
+	b := &updateBatcher[int]{
+		update: func(f func(UI) error) { queued = append(queued, f) },
+		apply: func(ui UI, items []int) {
+			applied = append(applied, items...)
+			batches++
+		},
+	}
+	// 模拟ui.Update乱序执行：每轮读入若干行后倒序执行积压的回调
+	next := 0
+	for round := 0; round < 4; round++ {
+		for i := 0; i < 3; i++ {
+			b.add(next)
+			next++
+		}
+		if len(queued) > 1 {
+			t.Fatalf("%d callbacks pending, want at most 1", len(queued))
+		}
+		for i := len(queued) - 1; i >= 0; i-- {
+			queued[i](nil)
+		}
+		queued = nil
+	}
+	for i, v := range applied {
+		if v != i {
+			t.Fatalf("applied %v, want read order", applied)
+		}
+	}
+	if len(applied) != next || batches != 4 {
+		t.Fatalf("applied %d items in %d batches, want %d in 4", len(applied), batches, next)
+	}
+}
@@ -0,0 +1,331 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 导出时间线 ==========
+// 导出为Chrome trace-event JSON格式，ui.perfetto.dev 和 chrome://tracing 都可以直接打开：
+//   - 断点命中 → 瞬时事件（变量值和断点备注放在args中，用户态探针的类别为uprobe）
+//   - 函数返回（bp retval） → 瞬时事件（返回值放在args中）
+//   - 定时快照 → 计数器轨道
+//   - 顺序断言的 bp1→bp2 配对 → 区间事件（时长即延迟）
+//   - 看门狗检测到的挂死/重启 → 全局瞬时事件
+//   - 内核日志（dmesg start） → "kernel log" 进程轨道上的瞬时事件，与断点命中共用开机时间轴
+// 带内核时间戳的事件使用开机时间（CLOCK_MONOTONIC），与同一次运行的perf/ftrace trace对齐。
+// 录制的帧文件（record start）也可以导出，帧中的调用栈放在args中。
+
+// trace-event格式的单个事件
+type traceEvent struct {
+	Name  string                 `json:"name"`
+	Cat   string                 `json:"cat,omitempty"`
+	Ph    string                 `json:"ph"`
+	Ts    float64                `json:"ts"`            // 微秒
+	Dur   float64                `json:"dur,omitempty"` // 微秒（ph=X）
+	Pid   int                    `json:"pid"`
+	Tid   int                    `json:"tid"`
+	Scope string                 `json:"s,omitempty"` // 瞬时事件范围
+	Args  map[string]interface{} `json:"args,omitempty"`
+}
+
+// trace-event文件
+type traceFile struct {
+	TraceEvents     []traceEvent      `json:"traceEvents"`
+	DisplayTimeUnit string            `json:"displayTimeUnit"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+}
+
+// 内核日志轨道使用的进程号（大于pid_max的上限，不与真实进程冲突）
+const kmsgTracePID = 4194305
+
+// 事件时间（微秒）
+func eventMicros(event DebugEvent) float64 {
+	return eventSeconds(event) * 1e6
+}
+
+// 事件所属进程：优先使用TGID，使同一进程的线程归到一起
+func eventProcess(event DebugEvent) int {
+	if event.TGID != 0 {
+		return event.TGID
+	}
+	return event.PID
+}
+
+// 计算顺序断言对应的区间：每个bp2与最早的未配对bp1配对
+func assertionSpans(ctx *DebuggerContext) []traceEvent {
+	spans := make([]traceEvent, 0)
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return spans
+	}
+	for _, a := range ctx.Project.Settings.Assertions {
+		pending := make(map[string][]DebugEvent)
+		for _, event := range ctx.Events {
+			if event.Kind != "breakpoint" {
+				continue
+			}
+			key := ""
+			if a.PerPID {
+				key = strconv.Itoa(event.PID)
+			}
+			switch event.BreakpointID {
+			case a.First:
+				pending[key] = append(pending[key], event)
+			case a.Second:
+				if len(pending[key]) == 0 {
+					continue
+				}
+				start := pending[key][0]
+				pending[key] = pending[key][1:]
+				spans = append(spans, traceEvent{
+					Name: fmt.Sprintf("bp%d→bp%d", a.First, a.Second),
+					Cat:  "latency",
+					Ph:   "X",
+					Ts:   eventMicros(start),
+					Dur:  eventMicros(event) - eventMicros(start),
+					Pid:  eventProcess(start),
+					Tid:  start.PID,
+					Args: map[string]interface{}{"assertion": a.String(), "from": start.Location, "to": event.Location},
+				})
+			}
+		}
+	}
+	return spans
+}
+
+// 将事件缓冲区转换为trace-event列表
+func buildTraceEvents(ctx *DebuggerContext) []traceEvent {
+	events := make([]traceEvent, 0, len(ctx.Events)+16)
+	threads := make(map[int]DebugEvent)
+	kmsg := false
+	for _, event := range ctx.Events {
+		switch event.Kind {
+		case "breakpoint":
+			args := map[string]interface{}{"location": event.Location, "cpu": event.CPU, "seq": event.Seq}
+			for _, v := range event.Values {
+				args[v.Name] = v.Value
+			}
+			if ctx.AssertViolated[event.Seq] {
+				args["assertion_violated"] = true
+			}
+			if len(event.Stack) > 0 {
+				args["stack"] = traceStack(event.Stack)
+			}
+			cat := "breakpoint"
+			if bp := breakpointForEvent(ctx, event); bp != nil {
+				if bp.Note != "" {
+					args["note"] = bp.Note
+				}
+				if bp.Binary != "" {
+					cat = "uprobe"
+				}
+			}
+			events = append(events, traceEvent{
+				Name:  fmt.Sprintf("BP%d %s", event.BreakpointID, event.Function),
+				Cat:   cat,
+				Ph:    "i",
+				Ts:    eventMicros(event),
+				Pid:   eventProcess(event),
+				Tid:   event.PID,
+				Scope: "t",
+				Args:  args,
+			})
+			if event.Comm != "" {
+				threads[event.PID] = event
+			}
+		case "return":
+			events = append(events, traceEvent{
+				Name:  fmt.Sprintf("RET%d %s", event.BreakpointID, event.Function),
+				Cat:   "return",
+				Ph:    "i",
+				Ts:    eventMicros(event),
+				Pid:   eventProcess(event),
+				Tid:   event.PID,
+				Scope: "t",
+				Args:  map[string]interface{}{"return": event.Values[0].Value},
+			})
+		case "span":
+			// 输出时刻是出口探针的时间，开始时间按耗时倒推
+			ns, _ := spanEventNanos(event)
+			events = append(events, traceEvent{
+				Name: fmt.Sprintf("SPAN%d %s", event.BreakpointID, event.Function),
+				Cat:  "span",
+				Ph:   "X",
+				Ts:   eventMicros(event) - float64(ns)/1e3,
+				Dur:  float64(ns) / 1e3,
+				Pid:  eventProcess(event),
+				Tid:  event.PID,
+				Args: map[string]interface{}{"duration_ns": ns},
+			})
+		case "watchdog":
+			events = append(events, traceEvent{
+				Name:  "watchdog: " + event.Function,
+				Cat:   "watchdog",
+				Ph:    "i",
+				Ts:    eventMicros(event),
+				Scope: "g",
+				Args:  map[string]interface{}{"message": event.Location},
+			})
+		case "kmsg":
+			events = append(events, traceEvent{
+				Name:  event.Location,
+				Cat:   "kmsg",
+				Ph:    "i",
+				Ts:    eventMicros(event),
+				Pid:   kmsgTracePID,
+				Tid:   event.PID,
+				Scope: "t",
+				Args:  map[string]interface{}{"level": event.Function},
+			})
+			kmsg = true
+		case "hwbp":
+			args := map[string]interface{}{"location": event.Location, "cpu": event.CPU, "seq": event.Seq}
+			for _, v := range event.Values {
+				args[v.Name] = v.Value
+			}
+			if len(event.Stack) > 0 {
+				args["stack"] = traceStack(event.Stack)
+			}
+			events = append(events, traceEvent{
+				Name:  fmt.Sprintf("HW%d %s", event.BreakpointID, event.Function),
+				Cat:   "hwbp",
+				Ph:    "i",
+				Ts:    eventMicros(event),
+				Pid:   eventProcess(event),
+				Tid:   event.PID,
+				Scope: "t",
+				Args:  args,
+			})
+			if event.Comm != "" {
+				threads[event.PID] = event
+			}
+		case "snapshot":
+			for _, v := range event.Values {
+				n, err := strconv.ParseInt(v.Value, 0, 64)
+				if err != nil {
+					continue
+				}
+				events = append(events, traceEvent{
+					Name: v.Name,
+					Cat:  "snapshot",
+					Ph:   "C",
+					Ts:   eventMicros(event),
+					Args: map[string]interface{}{"value": n},
+				})
+			}
+		}
+	}
+	events = append(events, assertionSpans(ctx)...)
+
+	if kmsg {
+		events = append(events, traceEvent{Name: "process_name", Ph: "M", Pid: kmsgTracePID, Args: map[string]interface{}{"name": "kernel log"}})
+	}
+	// 线程名元数据
+	for tid, event := range threads {
+		events = append(events, traceEvent{Name: "thread_name", Ph: "M", Pid: eventProcess(event), Tid: tid, Args: map[string]interface{}{"name": event.Comm}})
+	}
+	return events
+}
+
+// 调用栈在args中的写法（每层一项）
+func traceStack(frames []StackFrame) []string {
+	stack := make([]string, len(frames))
+	for i, frame := range frames {
+		stack[i] = frameStackEntry(frame)
+	}
+	return stack
+}
+
+// 导出为Chrome trace-event JSON
+func ExportPerfetto(ctx *DebuggerContext, path string) (int, error) {
+	if len(ctx.Events) == 0 {
+		return 0, fmt.Errorf("没有可导出的事件")
+	}
+	metadata := map[string]string{"source": "debug-gocui", "kernel": project.KernelRelease()}
+	// 断点备注随会话一起导出
+	if ctx.Project != nil {
+		for i, bp := range ctx.Project.Breakpoints {
+			if bp.Note != "" {
+				metadata[fmt.Sprintf("bp%d %s:%d", i+1, filepath.Base(bp.File), bp.Line)] = bp.Note
+			}
+		}
+	}
+	return writeTraceFile(buildTraceEvents(ctx), metadata, path)
+}
+
+// 录制的帧转换为事件（帧中的变量是这次命中采集到的值）
+func recordedEvents(replay *ReplayState) []DebugEvent {
+	events := make([]DebugEvent, 0, len(replay.Frames))
+	for _, frame := range replay.Frames {
+		event := DebugEvent{
+			Seq:          frame.Seq,
+			Time:         frame.Time,
+			TraceTime:    frame.TraceTime,
+			Kind:         frame.Kind,
+			BreakpointID: frame.BreakpointID,
+			Function:     frame.Function,
+			PID:          frame.PID,
+			Comm:         frame.Comm,
+			CPU:          frame.CPU,
+			Values:       frame.Values,
+			Stack:        frame.Stack,
+		}
+		if frame.File != "" {
+			event.Location = fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+		if event.Kind == "return" && len(event.Values) == 0 {
+			event.Values = []project.EventValue{{Name: "return", Value: "?"}}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// 导出录制的帧文件
+func ExportRecording(replay *ReplayState, path string) (int, error) {
+	// 帧中没有项目信息（断点备注、断言），只转换事件本身
+	ctx := &DebuggerContext{Events: recordedEvents(replay)}
+	metadata := map[string]string{
+		"source":    "debug-gocui",
+		"recording": replay.Path,
+		"recorded":  replay.Header.Started.Format(time.RFC3339),
+	}
+	if replay.Header.Arch != "" {
+		metadata["arch"] = replay.Header.Arch
+	}
+	return writeTraceFile(buildTraceEvents(ctx), metadata, path)
+}
+
+// 写入trace-event文件，返回事件数
+func writeTraceFile(events []traceEvent, metadata map[string]string, path string) (int, error) {
+	trace := traceFile{
+		TraceEvents:     events,
+		DisplayTimeUnit: "ns",
+		Metadata:        metadata,
+	}
+	data, err := json.MarshalIndent(trace, "", " ")
+	if err != nil {
+		return 0, fmt.Errorf("序列化trace失败: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return 0, fmt.Errorf("写入trace文件失败: %v", err)
+	}
+	return len(events), nil
+}
+
+// 导出文件路径：相对路径放在项目根目录下
+func ExportPath(ctx *DebuggerContext, name string) string {
+	if filepath.Ext(name) == "" {
+		name += ".json"
+	}
+	if filepath.IsAbs(name) || ctx.Project == nil {
+		return name
+	}
+	return filepath.Join(ctx.Project.RootPath, name)
+}
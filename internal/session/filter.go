@@ -0,0 +1,143 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 探针过滤 ==========
+// filter pid/comm/cpu 把过滤条件写入生成的BPF程序（每个断点探针和返回探针的开头），
+// 繁忙函数上的断点不会被无关进程的命中淹没。条件之间是"与"的关系，
+// 修改后需要重新执行 vars（或 generate）和 compile 才生效。
+// 与 events filter（只影响事件窗口的显示）不同，被过滤的命中根本不会产生输出。
+
+// 内核中comm的最大长度（不含结尾的0）
+const maxCommLen = 15
+
+// 当前项目的过滤条件，没有任何条件时返回nil
+func CurrentProbeFilter(ctx *DebuggerContext) *project.ProbeFilter {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return nil
+	}
+	f := ctx.Project.Settings.Filter
+	if f == nil || (f.PID == 0 && f.Comm == "" && f.CPU == nil) {
+		return nil
+	}
+	return f
+}
+
+// 设置一项过滤条件
+func SetProbeFilter(ctx *DebuggerContext, kind, value string) error {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	f := ctx.Project.Settings.Filter
+	if f == nil {
+		f = &project.ProbeFilter{}
+	}
+	switch kind {
+	case "pid":
+		pid, err := strconv.Atoi(value)
+		if err != nil || pid <= 0 {
+			return errcode.Errorf(errcode.ErrInvalidArg, "无效的进程号: %s", value)
+		}
+		f.PID = pid
+	case "comm":
+		if value == "" || len(value) > maxCommLen {
+			return errcode.Errorf(errcode.ErrInvalidArg, "进程名必须是1到%d个字符（内核会截断更长的名称）", maxCommLen)
+		}
+		for _, c := range value {
+			if c < 0x20 || c > 0x7e || c == '\'' || c == '\\' {
+				return errcode.Errorf(errcode.ErrInvalidArg, "进程名只支持可打印的ASCII字符: %q", value)
+			}
+		}
+		f.Comm = value
+	case "cpu":
+		cpu, err := strconv.Atoi(value)
+		if err != nil || cpu < 0 {
+			return errcode.Errorf(errcode.ErrInvalidArg, "无效的CPU编号: %s", value)
+		}
+		f.CPU = &cpu
+	default:
+		return errcode.Errorf(errcode.ErrInvalidArg, "未知的过滤条件: %s（可用 pid、comm、cpu）", kind)
+	}
+	ctx.Project.Settings.Filter = f
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 清除一项或全部（kind为空）过滤条件
+func ClearProbeFilter(ctx *DebuggerContext, kind string) error {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	f := ctx.Project.Settings.Filter
+	switch {
+	case kind == "":
+		f = nil
+	case f == nil:
+	case kind == "pid":
+		f.PID = 0
+	case kind == "comm":
+		f.Comm = ""
+	case kind == "cpu":
+		f.CPU = nil
+	default:
+		return errcode.Errorf(errcode.ErrInvalidArg, "未知的过滤条件: %s（可用 pid、comm、cpu）", kind)
+	}
+	ctx.Project.Settings.Filter = f
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 过滤条件的文字描述
+func ProbeFilterSummary(f *project.ProbeFilter) string {
+	if f == nil {
+		return "none"
+	}
+	parts := make([]string, 0, 3)
+	if f.PID != 0 {
+		parts = append(parts, fmt.Sprintf("pid %d", f.PID))
+	}
+	if f.Comm != "" {
+		parts = append(parts, fmt.Sprintf("comm %s", f.Comm))
+	}
+	if f.CPU != nil {
+		parts = append(parts, fmt.Sprintf("cpu %d", *f.CPU))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 生成的BPF代码：不满足过滤条件时直接返回（不依赖event，kretprobe中同样可用）
+func WriteProbeFilter(file *os.File, f *project.ProbeFilter) {
+	if f == nil {
+		return
+	}
+	fmt.Fprintf(file, "    // 探针过滤: %s\n", ProbeFilterSummary(f))
+	if f.PID != 0 {
+		fmt.Fprintf(file, "    if ((bpf_get_current_pid_tgid() >> 32) != %d)\n", f.PID)
+		fmt.Fprintln(file, "        return 0;")
+	}
+	if f.CPU != nil {
+		fmt.Fprintf(file, "    if (bpf_get_smp_processor_id() != %d)\n", *f.CPU)
+		fmt.Fprintln(file, "        return 0;")
+	}
+	if f.Comm != "" {
+		// 逐字符比较（包括结尾的0），BPF中没有strcmp
+		fmt.Fprintln(file, "    {")
+		fmt.Fprintln(file, "        char filter_comm[16] = {};")
+		fmt.Fprintln(file, "        bpf_get_current_comm(&filter_comm, sizeof(filter_comm));")
+		checks := make([]string, 0, len(f.Comm)+1)
+		for i := 0; i < len(f.Comm); i++ {
+			checks = append(checks, fmt.Sprintf("filter_comm[%d] != '%c'", i, f.Comm[i]))
+		}
+		checks = append(checks, fmt.Sprintf("filter_comm[%d] != 0", len(f.Comm)))
+		fmt.Fprintf(file, "        if (%s)\n", strings.Join(checks, " || "))
+		fmt.Fprintln(file, "            return 0;")
+		fmt.Fprintln(file, "    }")
+	}
+	fmt.Fprintln(file, "")
+}
@@ -0,0 +1,194 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 帧对比 ==========
+// 回放时 diff-frames <a> <b> 对比两帧记录的变量、寄存器和调用栈，在弹出窗口中用颜色标出变化：
+// 旧值为红色，新值为绿色，没有变化的变量和栈帧灰色显示。结构体指针的成员（dev->stats.rx）
+// 在录制时是单独的值，逐个成员对比，能直接看出两次命中之间结构体的哪个字段变了。
+
+// 帧对比窗口ID
+const frameDiffPopupID = "frame-diff"
+
+// 一项的对比行（第二个返回值表示是否有变化，没有变化时灰色显示）
+func frameDiffLine(name, before, after string, width int) (string, bool) {
+	switch {
+	case before == after:
+		return Styled(ActiveTheme.Dim, fmt.Sprintf("    %-*s %s", width, name, before)), false
+	case before == "":
+		return fmt.Sprintf("  + %-*s %s", width, name, Styled(ActiveTheme.DiffNew, after)), true
+	case after == "":
+		return fmt.Sprintf("  - %-*s %s", width, name, Styled(ActiveTheme.DiffOld, before)), true
+	}
+	return fmt.Sprintf("  ~ %-*s %s → %s", width, name, Styled(ActiveTheme.DiffOld, before), Styled(ActiveTheme.DiffNew, after)), true
+}
+
+// 按名称合并两组值（a中的顺序在前，b中新出现的在后）
+func mergeFrameValues(a, b []project.EventValue) ([]string, map[string]string, map[string]string) {
+	order := make([]string, 0, len(a)+len(b))
+	oldValues := make(map[string]string)
+	newValues := make(map[string]string)
+	for _, v := range a {
+		if _, seen := oldValues[v.Name]; !seen {
+			order = append(order, v.Name)
+		}
+		oldValues[v.Name] = v.Value
+	}
+	for _, v := range b {
+		if _, seen := oldValues[v.Name]; !seen {
+			if _, dup := newValues[v.Name]; !dup {
+				order = append(order, v.Name)
+			}
+		}
+		newValues[v.Name] = v.Value
+	}
+	return order, oldValues, newValues
+}
+
+// 变量对比：变化的在前，没有变化的在后
+func frameVariableDiff(ctx *DebuggerContext, a, b *RecordedFrame) []string {
+	order, oldValues, newValues := mergeFrameValues(a.Variables, b.Variables)
+	width := 8
+	for _, name := range order {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	changed := make([]string, 0)
+	same := make([]string, 0)
+	for _, name := range order {
+		before, after := oldValues[name], newValues[name]
+		if before != "" {
+			before = FormatValue(ctx, name, before)
+		}
+		if after != "" {
+			after = FormatValue(ctx, name, after)
+		}
+		if line, diff := frameDiffLine(name, before, after, width); diff {
+			changed = append(changed, line)
+		} else {
+			same = append(same, line)
+		}
+	}
+	lines := []string{fmt.Sprintf("Variables: %d changed, %d unchanged", len(changed), len(same))}
+	if len(order) == 0 {
+		lines = append(lines, Styled(ActiveTheme.Dim, "    no variable values recorded"))
+	}
+	return append(append(lines, changed...), same...)
+}
+
+// 寄存器对比：只列出变化的寄存器
+func frameRegisterDiff(a, b *RecordedFrame) []string {
+	if a.Registers == nil || b.Registers == nil {
+		missing := a.Index
+		if a.Registers != nil {
+			missing = b.Index
+		}
+		return []string{"Registers:", Styled(ActiveTheme.Dim, fmt.Sprintf("    no registers recorded in frame %d", missing))}
+	}
+	toValues := func(snap *RegisterSnapshot) []project.EventValue {
+		values := make([]project.EventValue, len(snap.Names))
+		for i, name := range snap.Names {
+			values[i] = project.EventValue{Name: name, Value: fmt.Sprintf("0x%016x", snap.Values[i])}
+		}
+		return values
+	}
+	order, oldValues, newValues := mergeFrameValues(toValues(a.Registers), toValues(b.Registers))
+	lines := make([]string, 0)
+	for _, name := range order {
+		if line, diff := frameDiffLine(name, oldValues[name], newValues[name], 6); diff {
+			lines = append(lines, line)
+		}
+	}
+	header := fmt.Sprintf("Registers: %d of %d changed", len(lines), len(order))
+	return append([]string{header}, lines...)
+}
+
+// 栈帧的显示
+func frameStackEntry(frame StackFrame) string {
+	if frame.File == "" {
+		return frame.Function
+	}
+	return fmt.Sprintf("%s %s:%d", frame.Function, filepath.Base(frame.File), frame.Line)
+}
+
+// 调用栈对比：按层对比，相同的层灰色显示
+func frameStackDiff(a, b *RecordedFrame) []string {
+	depth := len(a.Stack)
+	if len(b.Stack) > depth {
+		depth = len(b.Stack)
+	}
+	lines := []string{"Call stack:"}
+	if depth == 0 {
+		return append(lines, Styled(ActiveTheme.Dim, "    no stack recorded"))
+	}
+	for i := 0; i < depth; i++ {
+		before, after := "", ""
+		if i < len(a.Stack) {
+			before = frameStackEntry(a.Stack[i])
+		}
+		if i < len(b.Stack) {
+			after = frameStackEntry(b.Stack[i])
+		}
+		line, _ := frameDiffLine(fmt.Sprintf("#%d", i), before, after, 3)
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// 帧对比窗口内容
+func frameDiffLines(ctx *DebuggerContext, a, b *RecordedFrame) []string {
+	delta := b.Time.Sub(a.Time)
+	if a.TraceTime > 0 && b.TraceTime > 0 {
+		delta = time.Duration((b.TraceTime - a.TraceTime) * float64(time.Second))
+	}
+	lines := []string{
+		fmt.Sprintf("%s Frame %d  %s  %s", Styled(ActiveTheme.DiffOld, "---"), a.Index, a.Time.Format("15:04:05.000000"), a.Summary),
+		fmt.Sprintf("%s Frame %d  %s  %s", Styled(ActiveTheme.DiffNew, "+++"), b.Index, b.Time.Format("15:04:05.000000"), b.Summary),
+		fmt.Sprintf("    %v apart", delta),
+		"",
+	}
+	lines = append(lines, frameVariableDiff(ctx, a, b)...)
+	lines = append(lines, "")
+	lines = append(lines, frameRegisterDiff(a, b)...)
+	lines = append(lines, "")
+	return append(lines, frameStackDiff(a, b)...)
+}
+
+// 显示两帧（帧序号从1开始）的对比窗口，返回变化的变量（命令输出中显示）
+func ShowFrameDiffPopup(ctx *DebuggerContext, a, b int) ([]string, error) {
+	replay := ctx.Replay
+	if replay == nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "没有载入帧文件，请先 replay <file>")
+	}
+	for _, n := range []int{a, b} {
+		if n < 1 || n > len(replay.Frames) {
+			return nil, errcode.Errorf(errcode.ErrNotFound, "没有第 %d 帧（共 %d 帧）", n, len(replay.Frames))
+		}
+	}
+	fa, fb := &replay.Frames[a-1], &replay.Frames[b-1]
+	content := frameDiffLines(ctx, fa, fb)
+	height := len(content) + 5
+	if height > 30 {
+		height = 30
+	}
+	ClosePopupWindow(ctx, frameDiffPopupID)
+	popup := CreatePopupWindow(ctx, frameDiffPopupID, fmt.Sprintf("Frame Diff %d → %d", a, b), 100, height, content)
+	ShowPopupWindow(ctx, popup)
+
+	changed := make([]string, 0)
+	for _, line := range frameVariableDiff(ctx, fa, fb) {
+		if plain := StripANSI(line); !strings.HasPrefix(plain, "    ") {
+			changed = append(changed, plain)
+		}
+	}
+	return changed, nil
+}
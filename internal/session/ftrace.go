@@ -0,0 +1,59 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 采集后端 ==========
+
+const (
+	BackendBPF       = "bpf"
+	BackendFtrace    = "ftrace"
+	BackendSystemtap = "systemtap"
+	BackendKprobe    = "kprobe"
+	BackendBpftrace  = "bpftrace"
+	BackendPerf      = "perf"
+)
+
+// 当前项目的采集后端
+func CurrentBackend(ctx *DebuggerContext) string {
+	if ctx.Project != nil && ctx.Project.Settings != nil && ctx.Project.Settings.Backend != "" {
+		return ctx.Project.Settings.Backend
+	}
+	return BackendBPF
+}
+
+// 由后端布防的tracefs探针输出（不是bpf_printk格式的行），没有识别时返回false
+func handleBackendLine(ctx *DebuggerContext, line string) bool {
+	if strings.HasPrefix(line, scriptExitMarker) {
+		return handleScriptExit(ctx, line)
+	}
+	if capture, ok := CaptureBackends[ctx.CaptureBackend]; ok {
+		return capture.HandleLine(ctx, line)
+	}
+	return false
+}
+
+// 撤销后端的设置（events stop 时调用）
+func disarmBackend(ctx *DebuggerContext) {
+	if capture, ok := CaptureBackends[ctx.CaptureBackend]; ok {
+		capture.Stop(ctx)
+	}
+}
+
+// ========== ftrace function_graph ==========
+
+// tracefs挂载点（与trace_pipe相同的两个位置）
+func TracingRoot() (string, error) {
+	for _, path := range TracePipePaths {
+		dir := filepath.Dir(path)
+		if _, err := os.Stat(filepath.Join(dir, "current_tracer")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", errcode.Errorf(errcode.ErrTracePipe, "没有找到tracefs（/sys/kernel/tracing 或 /sys/kernel/debug/tracing）")
+}
@@ -0,0 +1,382 @@
+package session
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== gdb-remote协议客户端 ==========
+// 连接QEMU的gdbstub（-s / -gdb tcp::1234）或kgdboc（串口设备），使用GDB远程串行协议：
+//   $<数据>#<两位十六进制校验和>，对方回复 + 确认（QStartNoAckMode 后不再确认）
+//   g 读全部寄存器、m 读内存、s 单步、c 继续、Z0/z0 插入/删除断点、? 停止原因
+// 继续执行后目标停下时才返回停止应答（S05/T05...），期间只能发送中断字节 0x03。
+
+// 等待普通应答的超时（继续执行的停止应答没有超时）
+const gdbReplyTimeout = 5 * time.Second
+
+// 一次 m 请求读取的最大字节数（QEMU/kgdb的包缓冲区都大于512字节的十六进制）
+const gdbMemChunk = 256
+
+// 寄存器在 g 应答中的名称和字节数
+type gdbRegister struct {
+	name string
+	size int
+}
+
+// 各架构 g 应答中的通用寄存器（顺序与GDB的目标描述一致，后面的浮点/系统寄存器不解码）
+var gdbRegLayouts = map[string][]gdbRegister{
+	"riscv64": gdbRegs(8, "zero", "ra", "sp", "gp", "tp", "t0", "t1", "t2", "s0", "s1",
+		"a0", "a1", "a2", "a3", "a4", "a5", "a6", "a7",
+		"s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9", "s10", "s11",
+		"t3", "t4", "t5", "t6", "pc"),
+	"arm64": append(gdbRegs(8, "x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7", "x8", "x9",
+		"x10", "x11", "x12", "x13", "x14", "x15", "x16", "x17", "x18", "x19",
+		"x20", "x21", "x22", "x23", "x24", "x25", "x26", "x27", "x28", "x29",
+		"x30", "sp", "pc"), gdbRegs(4, "cpsr")...),
+	"x86_64": append(gdbRegs(8, "rax", "rbx", "rcx", "rdx", "rsi", "rdi", "rbp", "rsp",
+		"r8", "r9", "r10", "r11", "r12", "r13", "r14", "r15", "rip"),
+		gdbRegs(4, "eflags", "cs", "ss", "ds", "es", "fs", "gs")...),
+}
+
+func gdbRegs(size int, names ...string) []gdbRegister {
+	regs := make([]gdbRegister, len(names))
+	for i, name := range names {
+		regs[i] = gdbRegister{name, size}
+	}
+	return regs
+}
+
+// 软件断点的kind参数（断点指令的字节数）
+var gdbBreakKinds = map[string]int{"riscv64": 4, "arm64": 4, "x86_64": 1}
+
+// 目标停止的原因
+type GDBStop struct {
+	Signal int    // 信号编号（5=SIGTRAP，2=SIGINT）
+	Exited bool   // W/X 应答：目标已退出，连接不再可用
+	Reason string // kdb给出的原因（Breakpoint、SS trap ...）
+	Reply  string // 原始应答
+}
+
+// 停止原因的显示
+func (s *GDBStop) String() string {
+	if s.Exited {
+		return "target exited (" + s.Reply + ")"
+	}
+	if s.Reason != "" {
+		return "stopped (" + s.Reason + ")"
+	}
+	switch s.Signal {
+	case 2:
+		return "interrupted (SIGINT)"
+	case 5:
+		return "stopped (SIGTRAP)"
+	}
+	return fmt.Sprintf("stopped (signal %d)", s.Signal)
+}
+
+// 解析停止应答：S05、T05thread:01;...、W00、X09
+func parseGDBStop(reply string) (*GDBStop, error) {
+	if len(reply) < 3 {
+		return nil, fmt.Errorf("无效的停止应答: %q", reply)
+	}
+	signal, err := strconv.ParseUint(reply[1:3], 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("无效的停止应答: %q", reply)
+	}
+	switch reply[0] {
+	case 'S', 'T':
+		return &GDBStop{Signal: int(signal), Reply: reply}, nil
+	case 'W', 'X':
+		return &GDBStop{Signal: int(signal), Exited: true, Reply: reply}, nil
+	}
+	return nil, fmt.Errorf("无效的停止应答: %q", reply)
+}
+
+// gdb-remote连接（除interrupt外只能在一个协程中使用）
+type gdbClient struct {
+	addr    string
+	arch    string
+	conn    io.ReadWriteCloser
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+	noAck   bool
+}
+
+// 连接gdbstub：host:port（:1234 表示本机），/dev/ 开头时打开串口设备（kgdboc，波特率需预先用stty设置）
+func dialGDB(addr, arch string) (*gdbClient, error) {
+	if _, ok := gdbRegLayouts[arch]; !ok {
+		return nil, errcode.Errorf(errcode.ErrArch, "gdb后端不支持 %s 的寄存器布局（支持 riscv64/arm64/x86_64）", arch)
+	}
+	var conn io.ReadWriteCloser
+	var err error
+	if strings.HasPrefix(addr, "/dev/") {
+		conn, err = os.OpenFile(addr, os.O_RDWR, 0)
+	} else {
+		if strings.HasPrefix(addr, ":") {
+			addr = "localhost" + addr
+		}
+		conn, err = net.DialTimeout("tcp", addr, gdbReplyTimeout)
+	}
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrTarget, "连接gdbstub %s 失败: %v", addr, err)
+	}
+	c := &gdbClient{addr: addr, arch: arch, conn: conn, reader: bufio.NewReader(conn)}
+	// 能力协商失败不影响基本命令；支持时关闭确认，减少单步的往返
+	if reply, err := c.request("qSupported:swbreak+;hwbreak+"); err == nil && strings.Contains(reply, "QStartNoAckMode+") {
+		if reply, err := c.request("QStartNoAckMode"); err == nil && reply == "OK" {
+			c.noAck = true
+		}
+	}
+	return c, nil
+}
+
+func (c *gdbClient) Close() error {
+	return c.conn.Close()
+}
+
+// 设置读写超时（串口设备不支持，忽略）
+func (c *gdbClient) deadline(timeout time.Duration) {
+	if conn, ok := c.conn.(net.Conn); ok {
+		if timeout > 0 {
+			conn.SetDeadline(time.Now().Add(timeout))
+		} else {
+			conn.SetDeadline(time.Time{})
+		}
+	}
+}
+
+// 发送一个包（需要确认时等待 +，收到 - 时重发）
+func (c *gdbClient) send(data string) error {
+	var sum byte
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+	packet := fmt.Sprintf("$%s#%02x", data, sum)
+	for attempt := 0; attempt < 3; attempt++ {
+		c.writeMu.Lock()
+		_, err := io.WriteString(c.conn, packet)
+		c.writeMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("发送gdb请求失败: %v", err)
+		}
+		if c.noAck {
+			return nil
+		}
+		ack, err := c.reader.ReadByte()
+		if err != nil {
+			return fmt.Errorf("等待gdb确认失败: %v", err)
+		}
+		if ack == '+' {
+			return nil
+		}
+	}
+	return fmt.Errorf("gdbstub拒绝请求: %s", data)
+}
+
+// 接收一个包（跳过确认字节和 O 控制台输出包，展开游程编码）
+func (c *gdbClient) recv() (string, error) {
+	for {
+		if _, err := c.reader.ReadString('$'); err != nil {
+			return "", fmt.Errorf("读取gdb应答失败: %v", err)
+		}
+		body, err := c.reader.ReadString('#')
+		if err != nil {
+			return "", fmt.Errorf("读取gdb应答失败: %v", err)
+		}
+		var sum [2]byte
+		if _, err := io.ReadFull(c.reader, sum[:]); err != nil {
+			return "", fmt.Errorf("读取gdb应答失败: %v", err)
+		}
+		if !c.noAck {
+			c.writeMu.Lock()
+			io.WriteString(c.conn, "+")
+			c.writeMu.Unlock()
+		}
+		body = expandGDBRunLength(body[:len(body)-1])
+		if strings.HasPrefix(body, "O") && body != "OK" {
+			continue
+		}
+		return body, nil
+	}
+}
+
+// 游程编码：x*n 表示x再重复 n-29 次
+func expandGDBRunLength(body string) string {
+	if !strings.Contains(body, "*") {
+		return body
+	}
+	var out strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] == '*' && i > 0 && i+1 < len(body) {
+			prev := body[i-1]
+			out.WriteString(strings.Repeat(string(prev), int(body[i+1])-29))
+			i++
+			continue
+		}
+		out.WriteByte(body[i])
+	}
+	return out.String()
+}
+
+// 发送请求并等待应答（Exx 应答返回错误）
+func (c *gdbClient) request(data string) (string, error) {
+	c.deadline(gdbReplyTimeout)
+	defer c.deadline(0)
+	if err := c.send(data); err != nil {
+		return "", err
+	}
+	reply, err := c.recv()
+	if err != nil {
+		return "", err
+	}
+	if len(reply) == 3 && reply[0] == 'E' {
+		return reply, fmt.Errorf("gdbstub返回错误 %s（请求 %s）", reply, strings.SplitN(data, ",", 2)[0])
+	}
+	return reply, nil
+}
+
+// 查询停止原因
+func (c *gdbClient) haltReason() (*GDBStop, error) {
+	reply, err := c.request("?")
+	if err != nil {
+		return nil, err
+	}
+	return parseGDBStop(reply)
+}
+
+// 单步一条指令
+func (c *gdbClient) stepInstruction() (*GDBStop, error) {
+	reply, err := c.request("s")
+	if err != nil {
+		return nil, err
+	}
+	return parseGDBStop(reply)
+}
+
+// 继续执行，阻塞到目标停下（命中断点或 interrupt）
+func (c *gdbClient) resume() (*GDBStop, error) {
+	if err := c.send("c"); err != nil {
+		return nil, err
+	}
+	reply, err := c.recv()
+	if err != nil {
+		return nil, err
+	}
+	return parseGDBStop(reply)
+}
+
+// 让正在运行的目标停下（可以在其他协程等待 resume 时调用）
+func (c *gdbClient) interrupt() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.conn.Write([]byte{0x03}); err != nil {
+		return fmt.Errorf("发送中断失败: %v", err)
+	}
+	return nil
+}
+
+// 插入/删除软件断点
+func (c *gdbClient) setBreakpoint(addr uint64, insert bool) error {
+	op := "z0"
+	if insert {
+		op = "Z0"
+	}
+	reply, err := c.request(fmt.Sprintf("%s,%x,%d", op, addr, gdbBreakKinds[c.arch]))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("gdbstub不支持软件断点（应答 %q）", reply)
+	}
+	return nil
+}
+
+// 读取全部通用寄存器
+func (c *gdbClient) readRegisters() (*RegisterSnapshot, error) {
+	reply, err := c.request("g")
+	if err != nil {
+		return nil, err
+	}
+	layout := gdbRegLayouts[c.arch]
+	snap := &RegisterSnapshot{Arch: c.arch, Source: "gdb " + c.addr, Time: time.Now()}
+	offset := 0
+	for _, reg := range layout {
+		end := offset + 2*reg.size
+		if end > len(reply) {
+			break
+		}
+		field := reply[offset:end]
+		offset = end
+		// 不可用的寄存器为 xx
+		raw, err := hex.DecodeString(field)
+		var value uint64
+		if err == nil {
+			buf := make([]byte, 8)
+			copy(buf, raw)
+			value = binary.LittleEndian.Uint64(buf)
+		}
+		snap.Names = append(snap.Names, reg.name)
+		snap.Values = append(snap.Values, value)
+	}
+	if len(snap.Names) == 0 {
+		return nil, fmt.Errorf("寄存器应答为空")
+	}
+	return snap, nil
+}
+
+// 读取一段内存，返回能读到的字节（读取失败的块对应 readable 为false）
+func (c *gdbClient) readMemory(addr uint64, length int) ([]byte, []bool, error) {
+	data := make([]byte, length)
+	readable := make([]bool, length)
+	for off := 0; off < length; off += gdbMemChunk {
+		n := length - off
+		if n > gdbMemChunk {
+			n = gdbMemChunk
+		}
+		reply, err := c.request(fmt.Sprintf("m%x,%x", addr+uint64(off), n))
+		if err != nil {
+			if strings.HasPrefix(reply, "E") {
+				continue
+			}
+			return nil, nil, err
+		}
+		raw, err := hex.DecodeString(reply)
+		if err != nil {
+			return nil, nil, fmt.Errorf("无效的内存应答: %v", err)
+		}
+		copy(data[off:], raw)
+		for i := 0; i < len(raw) && i < n; i++ {
+			readable[off+i] = true
+		}
+	}
+	return data, readable, nil
+}
+
+// 断开：目标继续运行
+func (c *gdbClient) detach() error {
+	_, err := c.request("D")
+	return err
+}
+
+// 读取一个8字节的值（x86_64上取返回地址）
+func readTargetUint64(c stopTarget, addr uint64) (uint64, error) {
+	data, readable, err := c.readMemory(addr, 8)
+	if err != nil {
+		return 0, err
+	}
+	if !readable[0] {
+		return 0, fmt.Errorf("无法读取 0x%x", addr)
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
@@ -0,0 +1,537 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== gdb/kdb后端：真正的单步调试 ==========
+// BPF探针只能观察，不能让内核停下。backend gdb <host:port> 连接QEMU的gdbstub
+// （qemu ... -s 或 -gdb tcp::1234）或kgdboc串口（backend gdb /dev/ttyUSB0）；
+// backend kdb <tty> [baud] 在同一个串口上直接使用内核的kdb命令（见 kdb.go）。之后：
+//   break <file:line|符号|0x地址>  通过 Z0 插入软件断点
+//   continue / interrupt           运行到断点 / 让目标停下
+//   step / next / stepi            源码行单步（进入/越过函数调用）/ 单条指令
+// 停下时读取寄存器填充寄存器窗口，PC按kallsyms和模块的DWARF行号表解析到源码行，
+// 代码窗口跳到该行并用 ▶ 标出；mem read 通过 m 请求读取目标内存。
+// 配置了 remote ssh 时（例如ssh到QEMU中的系统）kallsyms从目标读取，否则使用本机的。
+// 单步和继续在后台执行（无界面运行时同步执行），结束后结果写入命令窗口。
+
+const (
+	BackendGDB = "gdb"
+	BackendKDB = "kdb"
+)
+
+// 默认的gdbstub地址（qemu -s）
+const defaultGDBAddr = ":1234"
+
+// 源码行单步最多执行的指令数
+const maxGDBLineSteps = 5000
+
+// 单步方式
+const (
+	GDBStepInstruction = iota // stepi：一条指令
+	GDBStepInto               // step：到下一源码行，进入有源码的函数
+	GDBStepOver               // next：到下一源码行，越过函数调用
+)
+
+// break 插入的断点
+type gdbBreakpoint struct {
+	Addr uint64
+	Spec string
+}
+
+// PC对应的位置
+type gdbLocation struct {
+	Symbol string // kallsyms中的函数名（找不到时为空）
+	Offset uint64
+	File   string // 项目模块中的函数才有源码行
+	Line   int
+}
+
+// 位置的显示：函数+偏移 (文件:行)
+func (l gdbLocation) String() string {
+	where := dwarf.ProbeTarget(l.Symbol, l.Offset)
+	if l.Symbol == "" {
+		where = "??"
+	}
+	if l.File != "" {
+		where += fmt.Sprintf(" (%s:%d)", filepath.Base(l.File), l.Line)
+	}
+	return where
+}
+
+// 停止式调试的目标连接：gdb-remote（gdbClient）或kdb串口（kdbClient）
+// 除interrupt外只在一个协程中使用
+type stopTarget interface {
+	haltReason() (*GDBStop, error)
+	stepInstruction() (*GDBStop, error)
+	resume() (*GDBStop, error)
+	interrupt() error
+	setBreakpoint(addr uint64, insert bool) error
+	readRegisters() (*RegisterSnapshot, error)
+	readMemory(addr uint64, length int) ([]byte, []bool, error)
+	detach() error
+	Close() error
+}
+
+// 一个gdbstub或kdb连接
+type GDBSession struct {
+	client      stopTarget
+	Kind        string // gdb / kdb
+	Addr        string
+	Arch        string
+	stopping    int32  // interrupt 请求停下（单步循环中检查）
+	resuming    int32  // 正在等待继续执行的停止应答
+	Busy        string // 正在后台执行的操作（continue/step/next），为空表示目标已停下
+	Stop        *GDBStop
+	Where       gdbLocation
+	StopFile    string // 停止位置对应的本地源码文件（代码窗口显示 ▶）
+	Breakpoints []gdbBreakpoint
+	resolver    *stackResolver // PC到源码行的解析（复用调用栈的解析，没有kallsyms时为nil）
+}
+
+// 按项目设置连接gdbstub或kdb并读取当前的停止原因，返回会话和警告
+func connectGDB(ctx *DebuggerContext) (*GDBSession, []string, error) {
+	arch, _ := DetectTargetArch(ctx)
+	settings := ctx.Project.Settings
+	s := &GDBSession{Kind: CurrentBackend(ctx), Arch: dwarf.RegsArch(arch)}
+	var err error
+	if s.Kind == BackendKDB {
+		if settings.KDB == "" {
+			return nil, nil, errcode.Errorf(errcode.ErrUsage, "没有设置串口，请执行 'backend kdb <tty> [baud]'")
+		}
+		s.Addr = settings.KDB
+		s.client, err = dialKDB(settings.KDB, settings.KDBBaud, s.Arch)
+	} else {
+		s.Addr = settings.GDB
+		if s.Addr == "" {
+			s.Addr = defaultGDBAddr
+		}
+		s.client, err = dialGDB(s.Addr, s.Arch)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Stop, err = s.client.haltReason(); err != nil {
+		s.client.Close()
+		return nil, nil, errcode.Errorf(errcode.ErrTarget, "%s没有应答: %v", s.Addr, err)
+	}
+
+	var warnings []string
+	syms, err := loadTargetKallsymsTable(ctx)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("kallsyms unavailable, stops are shown as raw addresses: %v", err))
+	} else {
+		s.resolver = &stackResolver{syms: syms, rows: make(map[string]map[uint64]dwarf.LineRow)}
+		if module := project.FindProjectModule(ctx.Project.RootPath); module != "" {
+			s.resolver.module = kallsymsModuleName(module)
+		}
+		if s.resolver.lines, err = ProjectLineResolver(ctx); err != nil {
+			warnings = append(warnings, fmt.Sprintf("No line table, stops are shown as function+offset: %v", err))
+		}
+	}
+	return s, warnings, nil
+}
+
+// 断开连接：删除插入的断点，detach后目标继续运行
+func DisconnectGDB(ctx *DebuggerContext) {
+	s := ctx.GDB
+	if s == nil {
+		return
+	}
+	ctx.GDB = nil
+	if s.Busy != "" {
+		s.client.interrupt()
+	} else {
+		for _, bp := range s.Breakpoints {
+			s.client.setBreakpoint(bp.Addr, false)
+		}
+		s.client.detach()
+	}
+	s.client.Close()
+	ctx.Running = false
+}
+
+// 能让目标停下的后端
+func StopModeBackend(name string) bool {
+	return name == BackendGDB || name == BackendKDB
+}
+
+// 输出行的前缀
+func (s *GDBSession) Tag() string {
+	return "[" + strings.ToUpper(s.Kind) + "]"
+}
+
+// 继续执行到目标停下（期间 interrupt 可以让它停下）
+func (s *GDBSession) Resume() (*GDBStop, error) {
+	atomic.StoreInt32(&s.resuming, 1)
+	defer atomic.StoreInt32(&s.resuming, 0)
+	if atomic.LoadInt32(&s.stopping) != 0 {
+		return &GDBStop{Signal: 2}, nil
+	}
+	return s.client.resume()
+}
+
+// interrupt：正在继续执行时让目标停下，正在单步时在下一条指令后停止
+func (s *GDBSession) RequestStop() error {
+	atomic.StoreInt32(&s.stopping, 1)
+	if atomic.LoadInt32(&s.resuming) != 0 {
+		return s.client.interrupt()
+	}
+	return nil
+}
+
+// 当前可用的gdb会话：没有连接时按项目设置中的地址连接，目标正在运行时返回错误
+func ActiveGDB(ctx *DebuggerContext) (*GDBSession, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	if !StopModeBackend(CurrentBackend(ctx)) {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "当前后端是 %s，单步调试需要先执行 'backend gdb <host:port>' 或 'backend kdb <tty>'", CurrentBackend(ctx))
+	}
+	if ctx.GDB == nil {
+		s, warnings, err := connectGDB(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, w := range warnings {
+			ctx.CommandHistory = append(ctx.CommandHistory, s.Tag()+" "+w)
+		}
+		ctx.GDB = s
+	}
+	if ctx.GDB.Busy != "" {
+		return nil, fmt.Errorf("目标正在运行（%s），请先执行 'interrupt'", ctx.GDB.Busy)
+	}
+	return ctx.GDB, nil
+}
+
+// PC所在的函数和源码行
+func (s *GDBSession) locate(pc uint64) gdbLocation {
+	if s.resolver == nil {
+		return gdbLocation{}
+	}
+	sym, offset, ok := s.resolver.syms.lookup(pc)
+	if !ok {
+		return gdbLocation{}
+	}
+	loc := gdbLocation{Symbol: sym.name, Offset: offset}
+	if sym.module != "" && sym.module == s.resolver.module && s.resolver.lines != nil {
+		loc.File, loc.Line = s.resolver.sourceLine(sym.name, offset)
+	}
+	return loc
+}
+
+// 栈指针和返回地址所在的寄存器
+func (s *GDBSession) stackPointer(regs *RegisterSnapshot) uint64 {
+	info := dwarf.TargetArchInfo(s.Arch)
+	if info == nil {
+		return 0
+	}
+	sp, _ := regs.Value(info.SP)
+	return sp
+}
+
+// 刚进入被调函数时的返回地址（x86_64在栈顶，其余架构在链接寄存器中）
+func (s *GDBSession) returnAddress(regs *RegisterSnapshot) (uint64, error) {
+	info := dwarf.TargetArchInfo(s.Arch)
+	if info == nil {
+		return 0, errcode.Errorf(errcode.ErrArch, "不支持的架构: %s", s.Arch)
+	}
+	if info.Link == "" {
+		return readTargetUint64(s.client, s.stackPointer(regs))
+	}
+	ret, _ := regs.Value(info.Link)
+	return ret, nil
+}
+
+// 是否有 break 插入的断点
+func (s *GDBSession) hasBreakpoint(addr uint64) bool {
+	for _, bp := range s.Breakpoints {
+		if bp.Addr == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// 运行到地址（临时断点），途中命中其他断点或被中断时停在那里
+func (s *GDBSession) runTo(addr uint64) (*GDBStop, error) {
+	temporary := !s.hasBreakpoint(addr)
+	if temporary {
+		if err := s.client.setBreakpoint(addr, true); err != nil {
+			return nil, err
+		}
+	}
+	stop, err := s.Resume()
+	if temporary && err == nil && !stop.Exited {
+		s.client.setBreakpoint(addr, false)
+	}
+	return stop, err
+}
+
+// 单步：指令级直接执行一次 s；源码行级单步到行号变化（起点没有源码时只执行一条指令）
+func (s *GDBSession) Step(mode int) (*GDBStop, error) {
+	c := s.client
+	regs, err := c.readRegisters()
+	if err != nil {
+		return nil, err
+	}
+	start := s.locate(regs.PC())
+	startSP := s.stackPointer(regs)
+	for i := 0; i < maxGDBLineSteps; i++ {
+		if i > 0 && atomic.LoadInt32(&s.stopping) != 0 {
+			return &GDBStop{Signal: 2}, nil
+		}
+		stop, err := c.stepInstruction()
+		if err != nil || stop.Exited || mode == GDBStepInstruction || start.File == "" {
+			return stop, err
+		}
+		if regs, err = c.readRegisters(); err != nil {
+			return nil, err
+		}
+		here := s.locate(regs.PC())
+		if here.Symbol != start.Symbol {
+			if s.stackPointer(regs) > startSP {
+				// 从起点函数返回到了调用者
+				return stop, nil
+			}
+			if mode == GDBStepInto && here.File != "" {
+				return stop, nil
+			}
+			// 进入了被调函数（next，或step进入没有源码的函数）：执行到返回地址
+			ret, err := s.returnAddress(regs)
+			if err != nil {
+				return nil, err
+			}
+			if stop, err = s.runTo(ret); err != nil || stop.Exited || stop.Signal != 5 {
+				return stop, err
+			}
+			if regs, err = c.readRegisters(); err != nil {
+				return nil, err
+			}
+			if regs.PC() != ret {
+				// 被调函数中命中了断点
+				return stop, nil
+			}
+			here = s.locate(ret)
+		}
+		if here.File != "" && (here.File != start.File || here.Line != start.Line) {
+			return stop, nil
+		}
+	}
+	return nil, fmt.Errorf("执行%d条指令后仍在 %s，已停止单步（可用 break + continue）", maxGDBLineSteps, start)
+}
+
+// 解析断点位置：file:line（DWARF行号表）、符号+偏移或0x地址（kallsyms）
+func resolveGDBBreakpoint(ctx *DebuggerContext, spec string) (uint64, error) {
+	if file, line := ParseSourceLocation(spec); strings.Contains(spec, ":") && line > 0 {
+		r, err := ProjectLineResolver(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(ctx.Project.RootPath, file)
+		}
+		loc, err := r.Resolve(file, line)
+		if err != nil {
+			return 0, err
+		}
+		base, err := targetKallsymsSymbol(ctx, loc.Function)
+		if err != nil {
+			return 0, err
+		}
+		if base == 0 {
+			return 0, errcode.Errorf(errcode.ErrPerm, "/proc/kallsyms 中 %s 的地址为0（kptr_restrict，需要root）", loc.Function)
+		}
+		return base + loc.Offset, nil
+	}
+	return parseMemoryAddress(ctx, spec)
+}
+
+// break <位置>：插入断点
+func AddGDBBreakpoint(ctx *DebuggerContext, spec string) (gdbBreakpoint, error) {
+	s, err := ActiveGDB(ctx)
+	if err != nil {
+		return gdbBreakpoint{}, err
+	}
+	addr, err := resolveGDBBreakpoint(ctx, spec)
+	if err != nil {
+		return gdbBreakpoint{}, err
+	}
+	bp := gdbBreakpoint{Addr: addr, Spec: spec}
+	if s.hasBreakpoint(addr) {
+		return bp, fmt.Errorf("0x%x 已有断点", addr)
+	}
+	if err := s.client.setBreakpoint(addr, true); err != nil {
+		return bp, err
+	}
+	s.Breakpoints = append(s.Breakpoints, bp)
+	return bp, nil
+}
+
+// break delete <n|all>：删除断点（编号从1开始）
+func DeleteGDBBreakpoints(ctx *DebuggerContext, which string) (int, error) {
+	s, err := ActiveGDB(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var remove []gdbBreakpoint
+	keep := s.Breakpoints[:0:0]
+	if which == "all" {
+		remove = s.Breakpoints
+	} else {
+		n, err := strconv.Atoi(which)
+		if err != nil || n < 1 || n > len(s.Breakpoints) {
+			return 0, errcode.Errorf(errcode.ErrInvalidArg, "断点编号超出范围: %s (共%d个)", which, len(s.Breakpoints))
+		}
+		remove = s.Breakpoints[n-1 : n]
+		keep = append(append(keep, s.Breakpoints[:n-1]...), s.Breakpoints[n:]...)
+	}
+	for _, bp := range remove {
+		if err := s.client.setBreakpoint(bp.Addr, false); err != nil {
+			return 0, err
+		}
+	}
+	s.Breakpoints = keep
+	return len(remove), nil
+}
+
+// 断点列表
+func GDBBreakpointLines(s *GDBSession) []string {
+	if len(s.Breakpoints) == 0 {
+		return []string{"No breakpoints on the target ('break <file:line|symbol|0xaddr>')"}
+	}
+	lines := make([]string, 0, len(s.Breakpoints))
+	for i, bp := range s.Breakpoints {
+		where := s.locate(bp.Addr)
+		lines = append(lines, fmt.Sprintf("  %d. 0x%x %s  %s", i+1, bp.Addr, bp.Spec, where))
+	}
+	return lines
+}
+
+// 在后台执行继续或单步（g为nil时同步执行，返回目标停下后的输出或错误）；目标停下后更新寄存器和停止位置
+func StartGDBRun(ui UI, ctx *DebuggerContext, title string, run func(s *GDBSession) (*GDBStop, error)) ([]string, error) {
+	s, err := ActiveGDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.Busy = title
+	atomic.StoreInt32(&s.stopping, 0)
+	ctx.Running = true
+	work := func() func() ([]string, error) {
+		stop, err := run(s)
+		var regs *RegisterSnapshot
+		var where gdbLocation
+		if err == nil && !stop.Exited {
+			if regs, err = s.client.readRegisters(); err == nil {
+				where = s.locate(regs.PC())
+			}
+		}
+		// 以下在界面线程中执行
+		return func() ([]string, error) {
+			s.Busy = ""
+			ctx.Running = false
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", s.Tag(), title, err)
+			}
+			s.Stop = stop
+			if stop.Exited {
+				DisconnectGDB(ctx)
+				return []string{s.Tag() + " " + stop.String() + ", disconnected"}, nil
+			}
+			return applyGDBStop(ui, ctx, s, regs, where), nil
+		}
+	}
+	if ui == nil {
+		return work()()
+	}
+	go func() {
+		apply := work()
+		ui.Update(func(ui UI) error {
+			if ctx.GDB != s {
+				// 等待期间已断开
+				return nil
+			}
+			lines, err := apply()
+			ctx.CommandHistory = append(ctx.CommandHistory, lines...)
+			ctx.CommandDirty = true
+			if err != nil {
+				ReportError(ctx, err)
+			}
+			return nil
+		})
+	}()
+	return []string{fmt.Sprintf("%s %s ...", s.Tag(), title)}, nil
+}
+
+// 读取当前的停止位置（连接后显示目标停在哪里）
+func RefreshGDBStop(ui UI, ctx *DebuggerContext) ([]string, error) {
+	s, err := ActiveGDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+	regs, err := s.client.readRegisters()
+	if err != nil {
+		return nil, err
+	}
+	return applyGDBStop(ui, ctx, s, regs, s.locate(regs.PC())), nil
+}
+
+// 目标停下：更新寄存器窗口、当前函数和地址，代码窗口跳到停止的源码行
+func applyGDBStop(ui UI, ctx *DebuggerContext, s *GDBSession, regs *RegisterSnapshot, where gdbLocation) []string {
+	ctx.Registers = regs
+	ctx.CurrentAddr = regs.PC()
+	if where.Symbol != "" {
+		ctx.CurrentFunc = where.Symbol
+	}
+	s.Where = where
+	s.StopFile = ""
+	lines := []string{fmt.Sprintf("%s %s at 0x%x %s", s.Tag(), s.Stop, regs.PC(), where)}
+	if where.File == "" {
+		return lines
+	}
+	local, _, err := resolveSourcePath(ctx, where.File)
+	if err != nil {
+		return append(lines, fmt.Sprintf("Warning: %v", err))
+	}
+	s.StopFile = local
+	if ui != nil {
+		if err := OpenSourceAt(ui, ctx, local, where.Line); err != nil {
+			lines = append(lines, fmt.Sprintf("Warning: %v", err))
+		}
+	}
+	return lines
+}
+
+// 连接状态
+func GDBStatusLines(ctx *DebuggerContext) []string {
+	s := ctx.GDB
+	if s == nil {
+		target := "gdbstub " + defaultGDBAddr
+		if settings := ctx.Project.Settings; CurrentBackend(ctx) == BackendKDB {
+			target = "kdb " + settings.KDB
+		} else if settings.GDB != "" {
+			target = "gdbstub " + settings.GDB
+		}
+		return []string{fmt.Sprintf("%s: not connected (step/next/continue/break connect on first use)", target)}
+	}
+	state := "stopped"
+	if s.Busy != "" {
+		state = "running (" + s.Busy + ")"
+	} else if s.Stop != nil {
+		state = s.Stop.String()
+	}
+	return []string{
+		fmt.Sprintf("%s %s (%s): %s", s.Kind, s.Addr, s.Arch, state),
+		fmt.Sprintf("  at 0x%x %s", ctx.CurrentAddr, s.Where),
+		fmt.Sprintf("  %d breakpoints", len(s.Breakpoints)),
+	}
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 全局变量监视点 ==========
+
+// 读取目标内核中符号的运行时地址（远程目标通过ssh读取开发板的 /proc/kallsyms）
+func targetKallsymsSymbol(ctx *DebuggerContext, name string) (uint64, error) {
+	remote := RemoteTarget(ctx)
+	if remote == nil {
+		return project.ReadKallsymsSymbol(name)
+	}
+	script := fmt.Sprintf("awk '$3 == \"%s\" { print $1; exit }' /proc/kallsyms", name)
+	out, err := exec.Command("ssh", remote.SSHArgs(script)...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("读取 %s 的 /proc/kallsyms 失败: %v", remote.SSH, err)
+	}
+	var addr uint64
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(out)), "%x", &addr); err != nil {
+		return 0, errcode.Errorf(errcode.ErrNoSymbol, "符号 %s 不在 %s 的 /proc/kallsyms 中", name, remote.SSH)
+	}
+	return addr, nil
+}
+
+// 解析全局变量：名称必须是模块中的变量，地址为0时（kptr_restrict）视为失败
+func ResolveGlobalSymbol(ctx *DebuggerContext, name string) (*dwarf.GlobalSymbol, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return nil, fmt.Errorf("项目中没有编译好的.ko")
+	}
+	sym := &dwarf.GlobalSymbol{Name: name}
+	typeName, size, signed, err := dwarf.DWARFGlobalType(module, name)
+	if err != nil {
+		return nil, err
+	}
+	sym.Type, sym.Size, sym.Signed = typeName, size, signed
+	addr, err := targetSymbolAddress(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if addr == 0 {
+		return nil, errcode.Errorf(errcode.ErrPerm, "/proc/kallsyms 中 %s 的地址为0（kptr_restrict，需要root）", name)
+	}
+	sym.Addr = addr
+	return sym, nil
+}
+
+// 把变量列表分为全局变量（能解析出地址）和其余按局部变量处理的名称
+func SplitGlobalWatches(ctx *DebuggerContext, names []string) ([]*dwarf.GlobalSymbol, []string) {
+	globals := make([]*dwarf.GlobalSymbol, 0)
+	locals := make([]string, 0, len(names))
+	for _, name := range names {
+		if sym, err := ResolveGlobalSymbol(ctx, name); err == nil {
+			globals = append(globals, sym)
+		} else {
+			locals = append(locals, name)
+		}
+	}
+	return globals, locals
+}
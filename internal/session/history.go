@@ -0,0 +1,183 @@
+package session
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 命令历史 ==========
+// 命令窗口的历史（命令和输出）按行数和时间限制大小，超出时丢弃最旧的行。
+// 命令窗口中按 ↑/↓ 逐条调出执行过的命令，按 Ctrl+R 反向增量搜索历史中的命令和输出（类似bash），
+// 再按 Ctrl+R 查找更早的匹配，Enter 执行匹配到的命令，Ctrl+G 取消并恢复原输入。
+
+// 默认保留的历史行数
+const DefaultHistoryLimit = 5000
+
+// 历史中的命令行前缀
+const historyCommandPrefix = "> "
+
+var ansiEscapeRegex = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// 去掉ANSI颜色控制码
+func StripANSI(s string) string {
+	return ansiEscapeRegex.ReplaceAllString(s, "")
+}
+
+// 为新增的历史行记录时间，并按行数/时间限制丢弃最旧的行（每次重绘前调用）
+func BoundCommandHistory(ctx *DebuggerContext) {
+	now := time.Now()
+	if len(ctx.HistoryTimes) > len(ctx.CommandHistory) {
+		// 历史被清空或替换过
+		ctx.HistoryTimes = ctx.HistoryTimes[:0]
+	}
+	for len(ctx.HistoryTimes) < len(ctx.CommandHistory) {
+		ctx.HistoryTimes = append(ctx.HistoryTimes, now)
+	}
+
+	limit := ctx.HistoryLimit
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	drop := 0
+	// 超出10%后再一次性裁剪，避免每次追加都复制整个历史
+	if len(ctx.CommandHistory) > limit+limit/10 {
+		drop = len(ctx.CommandHistory) - limit
+	}
+	if ctx.HistoryMaxAge > 0 {
+		for drop < len(ctx.HistoryTimes) && now.Sub(ctx.HistoryTimes[drop]) > ctx.HistoryMaxAge {
+			drop++
+		}
+	}
+	if drop == 0 {
+		return
+	}
+	ctx.CommandHistory = append([]string(nil), ctx.CommandHistory[drop:]...)
+	ctx.HistoryTimes = append([]time.Time(nil), ctx.HistoryTimes[drop:]...)
+	ctx.HistoryDropped += drop
+	if ctx.HistorySearch {
+		ctx.HistoryMatch -= drop
+		if ctx.HistoryMatch < 0 {
+			ctx.HistoryMatch = -1
+		}
+	}
+	ctx.CommandDirty = true
+}
+
+// 历史中执行过的命令（按时间顺序）
+func HistoryCommands(ctx *DebuggerContext) []string {
+	commands := make([]string, 0)
+	for _, line := range ctx.CommandHistory {
+		if strings.HasPrefix(line, historyCommandPrefix) {
+			commands = append(commands, strings.TrimPrefix(line, historyCommandPrefix))
+		}
+	}
+	return commands
+}
+
+// 历史第index行对应的命令：命令行本身，或产生该输出的命令
+func HistoryCommandAt(ctx *DebuggerContext, index int) string {
+	for i := index; i >= 0 && i < len(ctx.CommandHistory); i-- {
+		if line := ctx.CommandHistory[i]; strings.HasPrefix(line, historyCommandPrefix) {
+			return strings.TrimPrefix(line, historyCommandPrefix)
+		}
+	}
+	return ""
+}
+
+// 从第before行之前向前查找包含查询词的行（不区分大小写），找不到返回-1
+func SearchHistoryBackward(ctx *DebuggerContext, query string, before int) int {
+	if query == "" {
+		return -1
+	}
+	query = strings.ToLower(query)
+	if before > len(ctx.CommandHistory) {
+		before = len(ctx.CommandHistory)
+	}
+	for i := before - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(StripANSI(ctx.CommandHistory[i])), query) {
+			return i
+		}
+	}
+	return -1
+}
+
+// 搜索状态下命令窗口的输入行
+func HistorySearchPrompt(ctx *DebuggerContext) string {
+	status := "reverse-i-search"
+	preview := ""
+	if ctx.HistoryMatch >= 0 {
+		preview = HistoryCommandAt(ctx, ctx.HistoryMatch)
+		if line := ctx.CommandHistory[ctx.HistoryMatch]; !strings.HasPrefix(line, historyCommandPrefix) {
+			preview += "  \x1b[90m← " + project.TruncateWidth(StripANSI(line), 60) + "\x1b[0m"
+		}
+	} else if ctx.HistoryQuery != "" {
+		status = "failing reverse-i-search"
+	}
+	return fmt.Sprintf("(%s)`%s': %s", status, ctx.HistoryQuery, preview)
+}
+
+// 搜索词输入：从最新的历史重新查找
+func HistorySearchInput(ctx *DebuggerContext, query string) {
+	ctx.HistoryQuery = query
+	ctx.HistoryMatch = SearchHistoryBackward(ctx, query, len(ctx.CommandHistory))
+	ctx.CommandDirty = true
+}
+
+// 结束搜索：accept为true时使用匹配到的命令，否则恢复搜索前的输入
+func EndHistorySearch(ctx *DebuggerContext, accept bool) {
+	if accept && ctx.HistoryMatch >= 0 {
+		ctx.CurrentInput = HistoryCommandAt(ctx, ctx.HistoryMatch)
+	} else {
+		ctx.CurrentInput = ctx.HistorySavedInput
+	}
+	ctx.HistorySearch = false
+	ctx.HistoryQuery = ""
+	ctx.HistoryMatch = -1
+	ctx.HistorySavedInput = ""
+	ctx.CommandDirty = true
+}
+
+// 保存历史到文件（去掉颜色控制码，每行带时间）
+func SaveCommandHistory(ctx *DebuggerContext, path string) (int, error) {
+	BoundCommandHistory(ctx)
+	var b strings.Builder
+	for i, line := range ctx.CommandHistory {
+		fmt.Fprintf(&b, "%s %s\n", ctx.HistoryTimes[i].Format("2006-01-02 15:04:05"), StripANSI(line))
+	}
+	if err := ioutil.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return 0, fmt.Errorf("写入历史文件失败: %v", err)
+	}
+	return len(ctx.CommandHistory), nil
+}
+
+// 历史文件路径：相对路径放在项目根目录下（没有项目时为当前目录）
+func HistoryPath(ctx *DebuggerContext, name string) string {
+	if filepath.IsAbs(name) || ctx.Project == nil {
+		return name
+	}
+	return filepath.Join(ctx.Project.RootPath, name)
+}
+
+// 上下键浏览用的命令列表（去掉相邻的重复命令）
+func BrowsableCommands(ctx *DebuggerContext) []string {
+	commands := make([]string, 0)
+	for _, command := range HistoryCommands(ctx) {
+		if len(commands) > 0 && commands[len(commands)-1] == command {
+			continue
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// 结束上下键浏览（输入被修改或执行后调用）
+func ResetHistoryBrowse(ctx *DebuggerContext) {
+	ctx.HistoryBrowse = 0
+	ctx.HistoryDraft = ""
+}
@@ -0,0 +1,424 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 硬件断点 ==========
+// hwbp <地址|符号> <r|w|rw|x> [长度] 用perf_event_open(PERF_TYPE_BREAKPOINT)在每个在线CPU上
+// 设置一个调试寄存器断点。不经过kprobe，kprobe黑名单中的函数和数据访问（全局变量被谁改了）也能捕获。
+// 每次命中内核写入一条采样到该CPU的perf环形缓冲区，读取协程解析出指令地址、线程和时间戳，
+// 地址按 /proc/kallsyms 和模块行号表解析为函数和源码行，作为hwbp事件加入事件列表；
+// 写断点同时读出命中后的变量值。调试寄存器的数量有限（x86为4个），只能在本机使用。
+
+// 断点类型（HW_BREAKPOINT_*）
+const (
+	hwBreakpointR  = 1
+	hwBreakpointW  = 2
+	hwBreakpointRW = 3
+	hwBreakpointX  = 4
+)
+
+// 每个CPU的perf环形缓冲区页数（不含头部页，必须是2的幂）
+const hwbpRingPages = 8
+
+// 读取协程检查停止信号的间隔
+const hwbpPollInterval = 200 * time.Millisecond
+
+// perf_event_mmap_page 中 data_head/data_tail 的偏移
+const (
+	perfDataHeadOff = 1024
+	perfDataTailOff = 1032
+)
+
+// perf记录类型和采样内容
+const (
+	perfRecordLost   = 2
+	perfRecordSample = 9
+	hwbpSampleType   = unix.PERF_SAMPLE_IP | unix.PERF_SAMPLE_TID | unix.PERF_SAMPLE_TIME | unix.PERF_SAMPLE_CPU
+)
+
+// 一个硬件断点
+type HWBreakpoint struct {
+	ID     int
+	Target string // 用户输入的符号或地址
+	Symbol string // 符号名（输入地址时为空），写断点读出的值按该名称显示
+	Addr   uint64
+	Len    int
+	Type   string // r / w / rw / x
+	Hits   int
+	FDs    []int
+	stop   chan struct{}
+}
+
+// 解析断点类型
+func parseHWBreakpointType(s string) (uint32, bool) {
+	switch strings.ToLower(s) {
+	case "r":
+		return hwBreakpointR, true
+	case "w":
+		return hwBreakpointW, true
+	case "rw", "wr":
+		return hwBreakpointRW, true
+	case "x":
+		return hwBreakpointX, true
+	}
+	return 0, false
+}
+
+// 在线CPU列表（/sys/devices/system/cpu/online，格式 0-3,5）
+func onlineCPUs() ([]int, error) {
+	data, err := os.ReadFile("/sys/devices/system/cpu/online")
+	if err != nil {
+		return nil, err
+	}
+	cpus := make([]int, 0)
+	for _, part := range strings.Split(strings.TrimSpace(string(data)), ",") {
+		lo, hi, found := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析在线CPU列表: %s", data)
+		}
+		last := first
+		if found {
+			if last, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("无法解析在线CPU列表: %s", data)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// perf_event_open的错误说明
+func hwbpOpenError(err error, kind string) error {
+	switch err {
+	case unix.EACCES, unix.EPERM:
+		return errcode.Errorf(errcode.ErrPerm, "perf_event_open被拒绝（需要root或CAP_PERFMON，或 kernel.perf_event_paranoid<=1）: %v", err)
+	case unix.ENOSPC:
+		return errcode.Errorf(errcode.ErrInvalidArg, "调试寄存器已用完（x86只有4个），先用 hwbp del 删除不用的断点: %v", err)
+	case unix.EINVAL:
+		switch kind {
+		case "r":
+			return errcode.Errorf(errcode.ErrArch, "该架构不支持只读断点（x86只能捕获写或读写），改用 rw: %v", err)
+		case "x":
+			// x86不允许在kprobe黑名单中（或没有CONFIG_KPROBES的内核上）设置内核执行断点
+			return errcode.Errorf(errcode.ErrArch, "内核拒绝了执行断点（x86上内核地址的x断点与kprobe受同样的黑名单限制，数据断点不受限制）: %v", err)
+		}
+		return errcode.Errorf(errcode.ErrInvalidArg, "内核拒绝了该断点（地址需要按长度对齐）: %v", err)
+	case unix.ENOENT, unix.EOPNOTSUPP:
+		return errcode.Errorf(errcode.ErrArch, "内核或CPU不支持硬件断点（CONFIG_HAVE_HW_BREAKPOINT，虚拟机可能未开放调试寄存器）: %v", err)
+	}
+	return fmt.Errorf("perf_event_open失败: %v", err)
+}
+
+// 设置硬件断点：每个在线CPU打开一个perf事件并映射环形缓冲区，启动读取协程
+func ArmHWBreakpoint(ui UI, ctx *DebuggerContext, target, kind string, length int) (*HWBreakpoint, error) {
+	if err := CheckSafeMode(ctx, "硬件断点"); err != nil {
+		return nil, err
+	}
+	if StopModeBackend(CurrentBackend(ctx)) {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "%s后端不支持hwbp（硬件断点通过本机perf_event_open设置）", CurrentBackend(ctx))
+	}
+	if remote := RemoteTarget(ctx); remote != nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "hwbp 只能在本机内核上设置，远程目标 %s 不支持", remote.SSH)
+	}
+	bpType, ok := parseHWBreakpointType(kind)
+	if !ok {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "无效的断点类型: %s（r、w、rw 或 x）", kind)
+	}
+	addr, err := parseMemoryAddress(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	hw := &HWBreakpoint{Target: target, Addr: addr, Len: length, Type: strings.ToLower(kind), stop: make(chan struct{})}
+	if _, err := strconv.ParseUint(strings.TrimPrefix(target, "0x"), 16, 64); err != nil && !strings.Contains(target, "+") {
+		hw.Symbol = target
+	}
+	if bpType == hwBreakpointX {
+		// 执行断点的长度固定为 sizeof(long)
+		hw.Len = int(unsafe.Sizeof(uintptr(0)))
+	} else if hw.Len == 0 {
+		hw.Len = 4
+		if hw.Symbol != "" {
+			hw.Len = globalVariableSize(ctx, hw.Symbol)
+		}
+	}
+	switch hw.Len {
+	case 1, 2, 4, 8:
+	default:
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "长度必须是1、2、4或8: %d", hw.Len)
+	}
+	cpus, err := onlineCPUs()
+	if err != nil {
+		return nil, fmt.Errorf("读取在线CPU失败: %v", err)
+	}
+
+	attr := unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_BREAKPOINT,
+		Size:        uint32(unsafe.Sizeof(unix.PerfEventAttr{})),
+		Sample:      1,
+		Sample_type: hwbpSampleType,
+		Bits:        unix.PerfBitDisabled,
+		Wakeup:      1,
+		Bp_type:     bpType,
+		Ext1:        addr,
+		Ext2:        uint64(hw.Len),
+	}
+	rings := make([][]byte, 0, len(cpus))
+	release := func() {
+		for _, ring := range rings {
+			unix.Munmap(ring)
+		}
+		for _, fd := range hw.FDs {
+			unix.Close(fd)
+		}
+	}
+	ringSize := (1 + hwbpRingPages) * os.Getpagesize()
+	for _, cpu := range cpus {
+		fd, err := unix.PerfEventOpen(&attr, -1, cpu, -1, unix.PERF_FLAG_FD_CLOEXEC)
+		if err != nil {
+			release()
+			return nil, hwbpOpenError(err, hw.Type)
+		}
+		hw.FDs = append(hw.FDs, fd)
+		ring, err := unix.Mmap(fd, 0, ringSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("映射perf环形缓冲区失败: %v", err)
+		}
+		rings = append(rings, ring)
+	}
+	for _, fd := range hw.FDs {
+		if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+			release()
+			return nil, fmt.Errorf("启用硬件断点失败: %v", err)
+		}
+	}
+
+	// 写断点命中后读取变量的新值（BPF读取程序不可用时只记录位置）
+	var memReader *bpfMemoryReader
+	if bpType&hwBreakpointW != 0 {
+		memReader, _ = newBPFMemoryReader()
+	}
+	resolver, _ := newSymbolResolver(ctx)
+
+	for _, other := range ctx.HWBreakpoints {
+		if other.ID >= hw.ID {
+			hw.ID = other.ID + 1
+		}
+	}
+	if hw.ID == 0 {
+		hw.ID = 1
+	}
+	ctx.HWBreakpoints = append(ctx.HWBreakpoints, hw)
+	go hw.read(ui, ctx, rings, resolver, memReader, release)
+	return hw, nil
+}
+
+// 读取协程：轮询所有CPU的环形缓冲区，命中通过ui.Update加入事件列表；停止后释放资源
+func (hw *HWBreakpoint) read(ui UI, ctx *DebuggerContext, rings [][]byte, resolver *stackResolver, memReader *bpfMemoryReader, release func()) {
+	defer func() {
+		if memReader != nil {
+			memReader.Close()
+		}
+		release()
+	}()
+	pollFds := make([]unix.PollFd, len(hw.FDs))
+	for i, fd := range hw.FDs {
+		pollFds[i] = unix.PollFd{Fd: int32(fd), Events: unix.POLLIN}
+	}
+	comms := make(map[int]string)
+	for {
+		select {
+		case <-hw.stop:
+			return
+		default:
+		}
+		if _, err := unix.Poll(pollFds, int(hwbpPollInterval/time.Millisecond)); err != nil && err != unix.EINTR {
+			return
+		}
+		events := make([]DebugEvent, 0)
+		lost := 0
+		for _, ring := range rings {
+			for _, sample := range readPerfRing(ring, &lost) {
+				events = append(events, hw.sampleEvent(sample, resolver, memReader, comms))
+			}
+		}
+		if len(events) == 0 && lost == 0 {
+			continue
+		}
+		ui.Update(func(ui UI) error {
+			if !hw.active(ctx) {
+				return nil
+			}
+			hw.Hits += len(events)
+			ctx.EventsDropped += lost
+			for _, event := range events {
+				AppendEvent(ctx, event)
+			}
+			RefreshEventsPopup(ctx)
+			return nil
+		})
+	}
+}
+
+// 断点是否仍在当前工作区中设置（删除后到达的命中丢弃）
+func (hw *HWBreakpoint) active(ctx *DebuggerContext) bool {
+	for _, other := range ctx.HWBreakpoints {
+		if other == hw {
+			return true
+		}
+	}
+	return false
+}
+
+// perf采样记录：PERF_SAMPLE_IP | TID | TIME | CPU
+type perfSample struct {
+	IP   uint64
+	PID  uint32
+	TID  uint32
+	Time uint64
+	CPU  uint32
+}
+
+// 取出环形缓冲区中的全部记录（data_head由内核更新，读完后推进data_tail）
+func readPerfRing(ring []byte, lost *int) []perfSample {
+	page := os.Getpagesize()
+	data := ring[page:]
+	size := uint64(len(data))
+	head := atomic.LoadUint64((*uint64)(unsafe.Pointer(&ring[perfDataHeadOff])))
+	tail := atomic.LoadUint64((*uint64)(unsafe.Pointer(&ring[perfDataTailOff])))
+	ne := binary.NativeEndian
+	// 记录可能跨越缓冲区末尾
+	read := func(off uint64, n int) []byte {
+		buf := make([]byte, n)
+		for i := range buf {
+			buf[i] = data[(off+uint64(i))%size]
+		}
+		return buf
+	}
+	samples := make([]perfSample, 0)
+	for tail < head {
+		header := read(tail, 8)
+		recType := ne.Uint32(header[0:4])
+		recSize := uint64(ne.Uint16(header[6:8]))
+		if recSize < 8 {
+			break
+		}
+		body := read(tail+8, int(recSize-8))
+		switch {
+		case recType == perfRecordSample && len(body) >= 32:
+			samples = append(samples, perfSample{
+				IP:   ne.Uint64(body[0:8]),
+				PID:  ne.Uint32(body[8:12]),
+				TID:  ne.Uint32(body[12:16]),
+				Time: ne.Uint64(body[16:24]),
+				CPU:  ne.Uint32(body[24:28]),
+			})
+		case recType == perfRecordLost && len(body) >= 16:
+			*lost += int(ne.Uint64(body[8:16]))
+		}
+		tail += recSize
+	}
+	atomic.StoreUint64((*uint64)(unsafe.Pointer(&ring[perfDataTailOff])), tail)
+	return samples
+}
+
+// 把一次命中转换为事件
+func (hw *HWBreakpoint) sampleEvent(sample perfSample, resolver *stackResolver, memReader *bpfMemoryReader, comms map[int]string) DebugEvent {
+	event := DebugEvent{
+		Kind:         "hwbp",
+		Time:         time.Now(),
+		TraceTime:    float64(sample.Time) / 1e9,
+		BreakpointID: hw.ID,
+		PID:          int(sample.TID),
+		TGID:         int(sample.PID),
+		CPU:          int(sample.CPU),
+		Function:     fmt.Sprintf("0x%x", sample.IP),
+	}
+	if comm, ok := comms[event.PID]; ok {
+		event.Comm = comm
+	} else if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", event.PID)); err == nil {
+		event.Comm = strings.TrimSpace(string(data))
+		comms[event.PID] = event.Comm
+	}
+	if resolver != nil {
+		// 数据断点在访问指令执行完后触发，IP指向下一条指令
+		frame := resolver.frame(sample.IP, hw.Type == "x")
+		event.Function = frame.Function
+		if frame.File != "" {
+			event.Location = fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
+		}
+	}
+	if memReader != nil {
+		chunk := hw.Addr &^ (memReadChunk - 1)
+		if data, ok, err := memReader.readChunk(chunk); err == nil && ok && hw.Addr-chunk+uint64(hw.Len) <= uint64(len(data)) {
+			value := hw.Addr - chunk
+			var n uint64
+			for i := hw.Len - 1; i >= 0; i-- {
+				n = n<<8 | uint64(data[value+uint64(i)])
+			}
+			name := hw.Symbol
+			if name == "" {
+				name = fmt.Sprintf("*0x%x", hw.Addr)
+			}
+			event.Values = []project.EventValue{{Name: name, Value: strconv.FormatUint(n, 10)}}
+		}
+	}
+	event.Raw = fmt.Sprintf("%s-%d [%03d] %.6f: hw_breakpoint: [HWBP-%d] %s %s ip=0x%x", event.Comm, event.PID, event.CPU, event.TraceTime, hw.ID, hw.Type, hw.Target, sample.IP)
+	return event
+}
+
+// 删除硬件断点（读取协程在下一次轮询时关闭perf事件）
+func DeleteHWBreakpoint(ctx *DebuggerContext, id int) bool {
+	for i, hw := range ctx.HWBreakpoints {
+		if hw.ID != id {
+			continue
+		}
+		// 先关闭断点，调试寄存器在文件关闭前不再触发
+		for _, fd := range hw.FDs {
+			unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_DISABLE, 0)
+		}
+		close(hw.stop)
+		ctx.HWBreakpoints = append(ctx.HWBreakpoints[:i], ctx.HWBreakpoints[i+1:]...)
+		return true
+	}
+	return false
+}
+
+// 删除全部硬件断点，返回删除的数量
+func ClearHWBreakpoints(ctx *DebuggerContext) int {
+	n := len(ctx.HWBreakpoints)
+	for len(ctx.HWBreakpoints) > 0 {
+		DeleteHWBreakpoint(ctx, ctx.HWBreakpoints[0].ID)
+	}
+	return n
+}
+
+// hwbp 列表
+func HWBreakpointLines(ctx *DebuggerContext) []string {
+	if len(ctx.HWBreakpoints) == 0 {
+		return []string{"No hardware breakpoints", "", "Use 'hwbp <addr|symbol> <r|w|rw|x> [len]' to set one"}
+	}
+	lines := []string{fmt.Sprintf("Hardware breakpoints (%d):", len(ctx.HWBreakpoints))}
+	for _, hw := range ctx.HWBreakpoints {
+		lines = append(lines, fmt.Sprintf("  HW%d  %-2s %s @ 0x%x%s len=%d  hits=%d  (%d CPUs)", hw.ID, hw.Type, hw.Target, hw.Addr, LinkAddressNote(ctx, hw.Addr), hw.Len, hw.Hits, len(hw.FDs)))
+	}
+	return lines
+}
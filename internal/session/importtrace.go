@@ -0,0 +1,160 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 导入外部抓取的trace ==========
+// 开发板上没有TUI时可以直接 cat trace_pipe > hits.txt 或用 trace-cmd report 保存文本，
+// import-trace <file> 解析其中的 [BREAKPOINT-n]/[VAR-n]/[RETVAL-n] 标记，按命中行的 file:line
+// 与当前项目的断点对应（抓取后断点增删导致编号变化时按当前编号重新编号），
+// 再按录制相同的方式整理成帧写入 .frames 文件并开始回放。
+// 文本中只有开机时间戳，接收时间按文件修改时间（最后一个事件）倒推。
+
+// trace文本中单行的最大长度
+const maxImportLine = 1 << 20
+
+// 导入结果
+type traceImport struct {
+	Path     string      // 生成的帧文件
+	Events   int         // 识别出的命中、变量和返回值行
+	Frames   int         // 写出的帧数
+	Unparsed int         // 无法识别的非空行
+	Remapped map[int]int // 抓取时的断点编号 → 当前编号
+	Warnings []string
+}
+
+// 读取trace文本中的事件（变量行合并到同一断点、同一PID的上一次命中）
+func readTraceEvents(path string) ([]DebugEvent, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, errcode.Errorf(errcode.ErrNotFound, "打开trace文件失败: %v", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	events := make([]DebugEvent, 0)
+	unparsed := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	for scanner.Scan() {
+		line := scanner.Text()
+		event, ok := parseTraceLine(line)
+		if !ok {
+			if strings.TrimSpace(line) != "" {
+				unparsed++
+			}
+			continue
+		}
+		if n := len(events); event.Kind == "var" && n > 0 {
+			last := &events[n-1]
+			if last.Kind == "breakpoint" && last.BreakpointID == event.BreakpointID && last.PID == event.PID {
+				last.Values = append(last.Values, event.Values...)
+				last.Raw += "\n" + event.Raw
+				continue
+			}
+		}
+		event.Seq = len(events) + 1
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("读取trace文件失败: %v", err)
+	}
+
+	// 接收时间：最后一个事件在文件修改时间
+	last := 0.0
+	for _, e := range events {
+		if e.TraceTime > last {
+			last = e.TraceTime
+		}
+	}
+	for i := range events {
+		events[i].Time = info.ModTime()
+		if events[i].TraceTime > 0 {
+			events[i].Time = info.ModTime().Add(-time.Duration((last - events[i].TraceTime) * float64(time.Second)))
+		}
+	}
+	return events, unparsed, nil
+}
+
+// 按命中行把抓取时的断点编号对应到当前项目的断点
+func matchTraceBreakpoints(ctx *DebuggerContext, events []DebugEvent, result *traceImport) {
+	checked := make(map[int]bool)
+	for _, e := range events {
+		if e.Kind != "breakpoint" || checked[e.BreakpointID] {
+			continue
+		}
+		checked[e.BreakpointID] = true
+		bp := breakpointForEvent(ctx, e)
+		if bp == nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("BP%d %s: no breakpoint at this line in the current project", e.BreakpointID, e.Location))
+			continue
+		}
+		id := ArmedBreakpointID(ctx, bp)
+		if id == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("BP%d %s: breakpoint is disabled in the current project", e.BreakpointID, e.Location))
+			continue
+		}
+		if id != e.BreakpointID {
+			result.Remapped[e.BreakpointID] = id
+		}
+	}
+	for i := range events {
+		if id, ok := result.Remapped[events[i].BreakpointID]; ok {
+			events[i].BreakpointID = id
+		}
+	}
+}
+
+// 导入trace文本，生成帧文件
+func ImportTrace(ctx *DebuggerContext, path string) (*traceImport, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目（需要用项目的断点对应trace中的编号）")
+	}
+	events, unparsed, err := readTraceEvents(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "%s 中没有 [BREAKPOINT-n]/[VAR-n] 标记（共 %d 行无法识别）", path, unparsed)
+	}
+	result := &traceImport{Events: len(events), Unparsed: unparsed, Remapped: make(map[int]int)}
+	matchTraceBreakpoints(ctx, events, result)
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	result.Path = filepath.Join(ctx.Project.RootPath, base+".frames")
+	file, err := os.Create(result.Path)
+	if err != nil {
+		return nil, fmt.Errorf("创建帧文件失败: %v", err)
+	}
+	defer file.Close()
+	header := framesHeader{Format: framesFormat, Version: framesVersion, Project: ctx.Project.RootPath, Started: events[0].Time}
+	header.Arch, _ = DetectTargetArch(ctx)
+	if err := writeFrameLine(file, header); err != nil {
+		return nil, err
+	}
+	// 帧在独立的上下文中生成，不影响当前的监视值和寄存器记录
+	imported := &DebuggerContext{Project: ctx.Project, Events: events}
+	rec := &RecordingState{Path: result.Path, File: file, Started: header.Started, Latest: make(map[string]string)}
+	for _, e := range events {
+		if err := recordFrameEvent(imported, rec, e); err != nil {
+			return nil, err
+		}
+	}
+	result.Frames = rec.Frames
+	if result.Frames == 0 {
+		os.Remove(result.Path)
+		return nil, errcode.Errorf(errcode.ErrNotFound, "%s 中只有变量行，没有断点命中", path)
+	}
+	return result, nil
+}
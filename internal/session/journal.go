@@ -0,0 +1,62 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"path/filepath"
+	"encoding/json"
+	"io/ioutil"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 操作日志（可重放的项目设置步骤） ==========
+
+// bp 的哪些子命令会改变项目状态（需要记录到操作日志，其他命令见 commands/commands.go 命令表）
+func IsJournaledBreakpointCommand(args string) bool {
+	return args == "clear" || strings.HasPrefix(args, "note ") || strings.HasPrefix(args, "retval ") || strings.HasPrefix(args, "cond ") || strings.HasPrefix(args, "import ") || strings.HasPrefix(args, "repair ")
+}
+
+// 记录一条操作
+func RecordOperation(ctx *DebuggerContext, command string) {
+	if ctx == nil || ctx.Project == nil || ctx.Replaying {
+		return
+	}
+	ctx.Project.Journal = append(ctx.Project.Journal, project.DebugOperation{
+		Time:    time.Now(),
+		Command: command,
+	})
+	if err := SaveJournal(ctx); err != nil {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[WARNING] Failed to save operation journal: %v", err))
+		ctx.CommandDirty = true
+	}
+}
+
+// 保存操作日志到文件
+func SaveJournal(ctx *DebuggerContext) error {
+	if ctx.Project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+
+	data, err := json.MarshalIndent(ctx.Project.Journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化操作日志失败: %v", err)
+	}
+
+	journalPath := filepath.Join(ctx.Project.RootPath, project.JournalFile)
+	if err := ioutil.WriteFile(journalPath, data, 0644); err != nil {
+		return fmt.Errorf("保存操作日志失败: %v", err)
+	}
+
+	return nil
+}
+
+// 重放会丢弃的当前状态（断点数、监视表达式数）
+func ReplayDiscards(ctx *DebuggerContext) (int, int) {
+	watches := 0
+	if ctx.Project.Settings != nil {
+		watches = len(ctx.Project.Settings.Watches)
+	}
+	return len(ctx.Project.Breakpoints), watches
+}
@@ -0,0 +1,260 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"path/filepath"
+	"io/ioutil"
+	"debug/elf"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== KASLR检测 ==========
+
+// KASLR检测结果
+type KASLRInfo struct {
+	Enabled     bool   // 内核是否启用了KASLR
+	Known       bool   // 偏移是否已确定
+	Offset      uint64 // 运行时地址 - 链接地址
+	RuntimeText uint64 // /proc/kallsyms 中 _text 的运行时地址
+	LinkText    uint64 // 符号表中 _text 的链接地址
+	SymbolFile  string // 用于比较的符号表文件（vmlinux）
+	Reason      string // 无法确定偏移时的原因
+}
+
+// 从ELF符号表读取符号的链接地址
+func elfSymbolAddress(path, name string) (uint64, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	symbols, err := file.Symbols()
+	if err != nil {
+		return 0, err
+	}
+	for _, sym := range symbols {
+		if sym.Name == name {
+			return sym.Value, nil
+		}
+	}
+	return 0, errcode.Errorf(errcode.ErrNoSymbol, "符号 %s 不在 %s 中", name, path)
+}
+
+// 查找与当前内核匹配的vmlinux
+func findVmlinux(projectRoot string) string {
+	release := project.KernelRelease()
+	candidates := []string{}
+	if projectRoot != "" {
+		candidates = append(candidates, filepath.Join(projectRoot, "vmlinux"))
+	}
+	if release != "" {
+		candidates = append(candidates,
+			"/boot/vmlinux-"+release,
+			"/usr/lib/debug/boot/vmlinux-"+release,
+			"/usr/lib/debug/lib/modules/"+release+"/vmlinux",
+			"/lib/modules/"+release+"/build/vmlinux",
+		)
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// 检测KASLR偏移：比较 /proc/kallsyms 与vmlinux符号表中 _text 的地址
+func DetectKASLR(projectRoot string) *KASLRInfo {
+	info := &KASLRInfo{Enabled: true}
+
+	// 内核命令行显式关闭KASLR
+	if cmdline, err := ioutil.ReadFile("/proc/cmdline"); err == nil {
+		for _, arg := range strings.Fields(string(cmdline)) {
+			if arg == "nokaslr" {
+				info.Enabled = false
+				info.Known = true
+				info.Reason = "nokaslr on kernel command line"
+				return info
+			}
+		}
+	}
+
+	runtimeText, err := project.ReadKallsymsSymbol("_text")
+	if err != nil {
+		info.Reason = fmt.Sprintf("cannot read /proc/kallsyms: %v", err)
+		return info
+	}
+	if runtimeText == 0 {
+		// kptr_restrict 会把地址隐藏为0
+		info.Reason = "kallsyms addresses hidden (kptr_restrict), run as root"
+		return info
+	}
+	info.RuntimeText = runtimeText
+
+	info.SymbolFile = findVmlinux(projectRoot)
+	if info.SymbolFile == "" {
+		info.Reason = "no vmlinux found for this kernel"
+		return info
+	}
+
+	linkText, err := elfSymbolAddress(info.SymbolFile, "_text")
+	if err != nil {
+		info.Reason = fmt.Sprintf("cannot read symbol table: %v", err)
+		return info
+	}
+	info.LinkText = linkText
+	info.Offset = runtimeText - linkText
+	info.Known = true
+	info.Enabled = info.Offset != 0
+
+	return info
+}
+
+// 将链接地址（vmlinux/DWARF中的地址）转换为运行时地址
+func translateAddress(ctx *DebuggerContext, linkAddr uint64) uint64 {
+	if ctx == nil || ctx.KASLR == nil || !ctx.KASLR.Known {
+		return linkAddr
+	}
+	return linkAddr + ctx.KASLR.Offset
+}
+
+// 将运行时地址转换回链接地址
+func untranslateAddress(ctx *DebuggerContext, runtimeAddr uint64) uint64 {
+	if ctx == nil || ctx.KASLR == nil || !ctx.KASLR.Known {
+		return runtimeAddr
+	}
+	return runtimeAddr - ctx.KASLR.Offset
+}
+
+// 链接地址前缀：link:0xffffffff81000000 或 link:<符号> 表示vmlinux中的地址，使用前加上KASLR偏移
+const linkAddressPrefix = "link:"
+
+// 与当前内核匹配的vmlinux（检测KASLR时找到的，nokaslr时重新查找）
+func kaslrSymbolFile(ctx *DebuggerContext) string {
+	if ctx == nil || ctx.KASLR == nil || !ctx.KASLR.Known {
+		return ""
+	}
+	if ctx.KASLR.SymbolFile != "" {
+		return ctx.KASLR.SymbolFile
+	}
+	root := ""
+	if ctx.Project != nil {
+		root = ctx.Project.RootPath
+	}
+	return findVmlinux(root)
+}
+
+// 链接地址转换为运行时地址，偏移未知时返回错误（不能静默地使用未换算的地址）
+func linkToRuntime(ctx *DebuggerContext, linkAddr uint64) (uint64, error) {
+	if ctx == nil || ctx.KASLR == nil || !ctx.KASLR.Known {
+		return 0, errcode.Errorf(errcode.ErrInvalidArg, "KASLR偏移未知，无法换算链接地址 0x%x（env 查看原因）", linkAddr)
+	}
+	return translateAddress(ctx, linkAddr), nil
+}
+
+// vmlinux符号的运行时地址：符号表中的链接地址加上KASLR偏移
+func vmlinuxRuntimeAddress(ctx *DebuggerContext, name string) (uint64, error) {
+	vmlinux := kaslrSymbolFile(ctx)
+	if vmlinux == "" {
+		return 0, errcode.Errorf(errcode.ErrNoSymbol, "没有与当前内核匹配的vmlinux，或KASLR偏移未知，无法解析 %s", name)
+	}
+	linkAddr, err := elfSymbolAddress(vmlinux, name)
+	if err != nil {
+		return 0, err
+	}
+	return translateAddress(ctx, linkAddr), nil
+}
+
+// 符号的运行时地址：优先读 /proc/kallsyms，本机内核的符号不在其中或地址被隐藏时，
+// 用vmlinux中的链接地址加KASLR偏移
+func targetSymbolAddress(ctx *DebuggerContext, name string) (uint64, error) {
+	addr, err := targetKallsymsSymbol(ctx, name)
+	if (err != nil || addr == 0) && RemoteTarget(ctx) == nil {
+		if runtime, verr := vmlinuxRuntimeAddress(ctx, name); verr == nil {
+			return runtime, nil
+		}
+	}
+	return addr, err
+}
+
+// 运行时地址在vmlinux中的链接地址（有KASLR偏移时显示，便于用 addr2line/objdump 对照vmlinux）
+func LinkAddressNote(ctx *DebuggerContext, runtimeAddr uint64) string {
+	if ctx == nil || ctx.KASLR == nil || !ctx.KASLR.Known || ctx.KASLR.Offset == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (link 0x%x)", untranslateAddress(ctx, runtimeAddr))
+}
+
+// KASLR状态的单行描述
+func DescribeKASLR(info *KASLRInfo) string {
+	switch {
+	case info == nil:
+		return "KASLR: not checked"
+	case !info.Enabled:
+		return "KASLR: disabled"
+	case info.Known:
+		return fmt.Sprintf("KASLR: enabled, offset 0x%x", info.Offset)
+	default:
+		return fmt.Sprintf("KASLR: offset unknown (%s)", info.Reason)
+	}
+}
+
+// 显示调试环境弹出窗口
+func ShowEnvironmentPopup(ctx *DebuggerContext) {
+	if ctx == nil {
+		return
+	}
+
+	arch := dwarf.DetectCurrentArch()
+	targetArch, source := DetectTargetArch(ctx)
+	content := []string{
+		fmt.Sprintf("Kernel:       %s", project.KernelRelease()),
+		fmt.Sprintf("Architecture: %s (%s)", arch, ArchDisplayNames[arch]),
+		fmt.Sprintf("Target arch:  %s (%s)", targetArch, source),
+		"",
+		"Address translation:",
+	}
+
+	info := ctx.KASLR
+	if info == nil {
+		content = append(content, "  KASLR not checked yet (open a project first)")
+	} else {
+		content = append(content, "  "+DescribeKASLR(info))
+		if info.RuntimeText != 0 {
+			content = append(content, fmt.Sprintf("  _text runtime: 0x%016x", info.RuntimeText))
+		}
+		if info.LinkText != 0 {
+			content = append(content, fmt.Sprintf("  _text link:    0x%016x", info.LinkText))
+		}
+		if info.SymbolFile != "" {
+			content = append(content, fmt.Sprintf("  Symbols:      %s", info.SymbolFile))
+		}
+		if info.Enabled && !info.Known {
+			content = append(content, "")
+			content = append(content, "⚠️  Addresses from DWARF/vmlinux will NOT match the running kernel")
+		}
+	}
+
+	// 项目模块版本
+	if ctx.Project != nil {
+		if module := project.FindProjectModule(ctx.Project.RootPath); module != "" {
+			if mod, err := ReadModuleInfo(module); err == nil {
+				content = append(content, "")
+				content = append(content, ModuleInfoLines(mod)...)
+			}
+		}
+	}
+
+	height := len(content) + 5
+	if height > 20 {
+		height = 20
+	}
+	popup := CreatePopupWindow(ctx, "environment", "Environment", 70, height, content)
+	ShowPopupWindow(ctx, popup)
+}
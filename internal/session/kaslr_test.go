@@ -0,0 +1,36 @@
+package session
+
+import "testing"
+
+func TestKASLRTranslation(t *testing.T) {
+	ctx := &DebuggerContext{KASLR: &KASLRInfo{Enabled: true, Known: true, Offset: 0x1e00000}}
+	if got := translateAddress(ctx, 0xffffffff81000000); got != 0xffffffff82e00000 {
+		t.Fatalf("translateAddress = 0x%x", got)
+	}
+	if got := untranslateAddress(ctx, 0xffffffff82e00010); got != 0xffffffff81000010 {
+		t.Fatalf("untranslateAddress = 0x%x", got)
+	}
+	if note := LinkAddressNote(ctx, 0xffffffff82e00010); note != " (link 0xffffffff81000010)" {
+		t.Fatalf("LinkAddressNote = %q", note)
+	}
+
+	for spec, want := range map[string]uint64{
+		"link:0xffffffff81000000":      0xffffffff82e00000,
+		"link:0xffffffff81000000+0x10": 0xffffffff82e00010,
+		"link:ffffffff81000000":        0xffffffff82e00000,
+		"0xffffffff82e00000":           0xffffffff82e00000, // 运行时地址不换算
+	} {
+		got, err := parseMemoryAddress(ctx, spec)
+		if err != nil || got != want {
+			t.Errorf("parseMemoryAddress(%q) = 0x%x, %v; want 0x%x", spec, got, err, want)
+		}
+	}
+
+	unknown := &DebuggerContext{KASLR: &KASLRInfo{Enabled: true, Reason: "no vmlinux found for this kernel"}}
+	if _, err := parseMemoryAddress(unknown, "link:0xffffffff81000000"); err == nil {
+		t.Error("link address with an unknown KASLR offset should fail")
+	}
+	if note := LinkAddressNote(unknown, 0xffffffff82e00000); note != "" {
+		t.Errorf("LinkAddressNote with unknown offset = %q", note)
+	}
+}
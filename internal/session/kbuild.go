@@ -0,0 +1,158 @@
+package session
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== Makefile/Kbuild项目模型 ==========
+
+// 诊断中的路径对应的本地文件（相对路径依次按各目录解析，最后按文件名在项目中查找）
+func resolveDiagnosticPath(ctx *DebuggerContext, path string, dirs ...string) string {
+	if filepath.IsAbs(path) {
+		if project.FileExists(path) {
+			return path
+		}
+	} else {
+		for _, dir := range dirs {
+			if candidate := filepath.Join(dir, path); project.FileExists(candidate) {
+				return candidate
+			}
+		}
+	}
+	return project.FindInProject(ctx.Project.RootPath, filepath.Base(path))
+}
+
+// 显示编译输出窗口：诊断行着色，按Enter打开对应的源码位置
+func ShowDiagnosticsPopup(ctx *DebuggerContext, id, title string, output []string, dirs []string) (int, int) {
+	if len(output) > project.MaxBuildOutputLines {
+		output = output[len(output)-project.MaxBuildOutputLines:]
+	}
+	content := make([]string, 0, len(output)+2)
+	diagnostics := make(map[int]project.Diagnostic)
+	errors, warnings := 0, 0
+	for i, line := range output {
+		d, ok := project.ParseDiagnostic(line)
+		if !ok {
+			content = append(content, line)
+			continue
+		}
+		switch d.Severity {
+		case "error":
+			errors++
+		case "warning":
+			warnings++
+		}
+		diagnostics[i] = d
+		content = append(content, project.DiagnosticColor(d.Severity)+line+"\x1b[0m")
+	}
+	if len(output) == 0 {
+		content = append(content, "(no output)")
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, fmt.Sprintf("%d errors, %d warnings | Enter on a diagnostic opens file:line", errors, warnings)))
+
+	ClosePopupWindow(ctx, id)
+	popup := CreatePopupWindow(ctx, id, title, 120, 30, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		d, ok := diagnostics[index]
+		if !ok {
+			return nil
+		}
+		path := resolveDiagnosticPath(ctx, d.File, dirs...)
+		if path == "" {
+			ReportError(ctx, errcode.Errorf(errcode.ErrNotFound, "%s not found in the project", d.File))
+		} else if err := OpenSourceAt(ui, ctx, path, d.Line); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ClosePopupWindow(ctx, id)
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[%s] %s:%d: %s", strings.ToUpper(d.Severity), project.ProjectRelativePath(ctx.Project, path), d.Line, d.Message))
+			ui.Focus("code")
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	// 有错误时第一个错误滚动到窗口顶部
+	first := -1
+	for i := range diagnostics {
+		if diagnostics[i].Severity == "error" && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first >= 0 {
+		popup.ScrollY = first
+	}
+	ShowPopupWindow(ctx, popup)
+	return errors, warnings
+}
+
+// ========== make build/clean ==========
+
+// 构建命令的参数：项目有Makefile时在根目录执行make，只有Kbuild时直接调用内核构建系统
+func makeArgs(ctx *DebuggerContext, k *project.KbuildInfo, target string) []string {
+	root := ctx.Project.RootPath
+	hasMakefile := project.FileExists(filepath.Join(root, "Makefile")) || project.FileExists(filepath.Join(root, "makefile"))
+	if hasMakefile {
+		args := []string{"-C", root}
+		if target != "" {
+			args = append(args, target)
+		}
+		return args
+	}
+	if target == "" {
+		target = "modules"
+	}
+	return []string{"-C", k.KernelDir(root), "M=" + root, target}
+}
+
+// 在后台运行make，结束后显示Build Output窗口（target为空时构建默认目标）
+func StartModuleBuild(ui UI, ctx *DebuggerContext, target string) (string, error) {
+	if ctx.Project == nil {
+		return "", errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	if ctx.Building {
+		return "", fmt.Errorf("构建已在进行中")
+	}
+	k := project.ParseKbuild(ctx.Project.RootPath)
+	ctx.Project.Kbuild = k
+	if k == nil {
+		return "", errcode.Errorf(errcode.ErrNotFound, "项目根目录没有Makefile或Kbuild")
+	}
+	if _, err := exec.LookPath("make"); err != nil {
+		return "", errcode.Errorf(errcode.ErrToolMissing, "没有找到make")
+	}
+	args := makeArgs(ctx, k, target)
+	command := "make " + strings.Join(args, " ")
+	dirs := []string{ctx.Project.RootPath, k.KernelDir(ctx.Project.RootPath)}
+	ctx.Building = true
+	go func() {
+		start := time.Now()
+		cmd := exec.Command("make", args...)
+		cmd.Dir = ctx.Project.RootPath
+		out, err := cmd.CombinedOutput()
+		elapsed := time.Since(start).Truncate(100 * time.Millisecond)
+		ui.Update(func(ui UI) error {
+			ctx.Building = false
+			output := append([]string{"$ " + command, ""}, strings.Split(strings.TrimRight(string(out), "\n"), "\n")...)
+			status := "ok"
+			if err != nil {
+				status = "failed: " + err.Error()
+				output = append(output, "", fmt.Sprintf("make %s", status))
+			}
+			name := target
+			if name == "" {
+				name = "build"
+			}
+			errors, warnings := ShowDiagnosticsPopup(ctx, "build", fmt.Sprintf("Build Output: %s (%s, %s)", name, status, elapsed), output, dirs)
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[BUILD] %s %s in %s: %d errors, %d warnings", command, status, elapsed, errors, warnings))
+			ctx.CommandDirty = true
+			return nil
+		})
+	}()
+	return command, nil
+}
@@ -0,0 +1,290 @@
+package session
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== kdb串口客户端 ==========
+// backend kdb <tty> [baud] 通过kgdboc串口与内核自带的kdb对话，不需要gdb：
+//   bp <地址> / bc <n>   插入/删除断点       go   继续运行（停下时打印 Entering kdb ... due to ...）
+//   ss                  单步一条指令        rd   寄存器      mdr <地址> <字节数>  原始内存
+// 每条命令以 [0]kdb> 提示符结束，输出解析后填充寄存器窗口和内存窗口。
+// 目标正在运行时，interrupt 发送串口BREAK加 g（SysRq-g）让内核进入kdb。
+// 串口参数用 stty 设置（raw，不回显）。
+
+// 默认波特率
+const defaultKDBBaud = 115200
+
+// 等待kdb提示符的超时（go 没有超时）
+const kdbReplyTimeout = 5 * time.Second
+
+// 一次 mdr 读取的字节数
+const kdbMemChunk = 128
+
+// asm-generic 的 TCSBRK（x86/arm64/riscv主机相同）：发送约0.25秒的串口BREAK
+const tcsbrk = 0x5409
+
+var (
+	kdbPromptRe = regexp.MustCompile(`\[\d+\]kdb> $`)
+	kdbEnterRe  = regexp.MustCompile(`Entering kdb .* due to (.+?)(?: @ (0x[0-9a-fA-F]+))?\s*$`)
+	kdbBPNumRe  = regexp.MustCompile(`BP #(\d+)`)
+	kdbRegRe    = regexp.MustCompile(`\b([a-z][a-z0-9_]*)\s*[:=]\s*(?:0x)?([0-9a-fA-F]{4,16})\b`)
+)
+
+// kdb打印的寄存器名到寄存器窗口使用的名称（x86_64的pt_regs名称没有r前缀）
+var kdbRegAliases = map[string]map[string]string{
+	"x86_64": {"ax": "rax", "bx": "rbx", "cx": "rcx", "dx": "rdx", "si": "rsi", "di": "rdi",
+		"bp": "rbp", "sp": "rsp", "ip": "rip", "flags": "eflags"},
+	"arm64": {"pstate": "cpsr"},
+}
+
+// kdb串口连接
+type kdbClient struct {
+	tty     string
+	arch    string
+	port    *os.File
+	writeMu sync.Mutex
+	pending []byte
+	bps     map[uint64]int // 断点地址 → kdb中的断点编号
+}
+
+// 打开串口并等待kdb提示符（目标没有停在kdb中时先发送SysRq-g）
+func dialKDB(tty string, baud int, arch string) (*kdbClient, error) {
+	if baud <= 0 {
+		baud = defaultKDBBaud
+	}
+	if out, err := exec.Command("stty", "-F", tty, strconv.Itoa(baud), "raw", "-echo", "-ixon", "clocal").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("设置串口 %s 失败: %v %s", tty, err, strings.TrimSpace(string(out)))
+	}
+	port, err := os.OpenFile(tty, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("打开串口失败: %v", err)
+	}
+	c := &kdbClient{tty: tty, arch: arch, port: port, bps: make(map[uint64]int)}
+	if _, err := c.command("", kdbReplyTimeout); err != nil {
+		// 目标在运行：SysRq-g 进入kdb
+		if err := c.interrupt(); err != nil {
+			port.Close()
+			return nil, err
+		}
+		if _, err := c.waitPrompt(kdbReplyTimeout); err != nil {
+			port.Close()
+			return nil, errcode.Errorf(errcode.ErrTarget, "%s 上没有kdb提示符（内核需要 CONFIG_KGDB_KDB，启动参数 kgdboc=<tty>,<baud>）", tty)
+		}
+	}
+	// 关闭分页（more> 提示）
+	c.command("set LINES 10000", kdbReplyTimeout)
+	return c, nil
+}
+
+func (c *kdbClient) Close() error {
+	return c.port.Close()
+}
+
+func (c *kdbClient) write(text string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := c.port.WriteString(text); err != nil {
+		return fmt.Errorf("写入串口失败: %v", err)
+	}
+	return nil
+}
+
+// 读取到kdb提示符为止，返回之前的输出行（timeout为0时一直等待）
+func (c *kdbClient) waitPrompt(timeout time.Duration) ([]string, error) {
+	if timeout > 0 {
+		c.port.SetReadDeadline(time.Now().Add(timeout))
+	} else {
+		c.port.SetReadDeadline(time.Time{})
+	}
+	buf := make([]byte, 4096)
+	for {
+		text := string(bytes.ReplaceAll(c.pending, []byte("\r"), nil))
+		if loc := kdbPromptRe.FindStringIndex(text); loc != nil {
+			c.pending = nil
+			return strings.Split(strings.TrimRight(text[:loc[0]], "\n"), "\n"), nil
+		}
+		if strings.HasSuffix(text, "more> ") {
+			c.pending = nil
+			c.write(" ")
+			continue
+		}
+		n, err := c.port.Read(buf)
+		if err != nil {
+			if os.IsTimeout(err) {
+				return nil, errcode.Errorf(errcode.ErrTarget, "等待kdb提示符超时")
+			}
+			return nil, fmt.Errorf("读取串口失败: %v", err)
+		}
+		c.pending = append(c.pending, buf[:n]...)
+	}
+}
+
+// 执行一条kdb命令，返回输出（去掉回显的命令行）
+func (c *kdbClient) command(cmd string, timeout time.Duration) ([]string, error) {
+	if err := c.write(cmd + "\r"); err != nil {
+		return nil, err
+	}
+	lines, err := c.waitPrompt(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == cmd {
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "Unknown kdb command") || strings.Contains(line, "not supported") {
+			return lines, fmt.Errorf("kdb: %s: %s", cmd, strings.TrimSpace(line))
+		}
+	}
+	return lines, nil
+}
+
+// 从输出中解析停止原因（没有 Entering kdb 行时视为在提示符处停下）
+func parseKDBStop(lines []string) *GDBStop {
+	for i := len(lines) - 1; i >= 0; i-- {
+		if m := kdbEnterRe.FindStringSubmatch(lines[i]); m != nil {
+			stop := &GDBStop{Signal: 5, Reason: m[1], Reply: strings.TrimSpace(lines[i])}
+			if strings.Contains(m[1], "Keyboard") || strings.Contains(m[1], "NMI") {
+				stop.Signal = 2
+			}
+			return stop
+		}
+	}
+	return &GDBStop{Signal: 5, Reason: "kdb prompt"}
+}
+
+func (c *kdbClient) haltReason() (*GDBStop, error) {
+	if _, err := c.command("", kdbReplyTimeout); err != nil {
+		return nil, err
+	}
+	return &GDBStop{Signal: 5, Reason: "kdb prompt"}, nil
+}
+
+func (c *kdbClient) stepInstruction() (*GDBStop, error) {
+	lines, err := c.command("ss", kdbReplyTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return parseKDBStop(lines), nil
+}
+
+// go：阻塞到目标再次进入kdb
+func (c *kdbClient) resume() (*GDBStop, error) {
+	lines, err := c.command("go", 0)
+	if err != nil {
+		return nil, err
+	}
+	return parseKDBStop(lines), nil
+}
+
+// SysRq-g：串口BREAK后发送 g
+func (c *kdbClient) interrupt() error {
+	c.writeMu.Lock()
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, c.port.Fd(), tcsbrk, 0)
+	c.writeMu.Unlock()
+	if errno != 0 {
+		return fmt.Errorf("发送串口BREAK失败: %v", errno)
+	}
+	return c.write("g")
+}
+
+func (c *kdbClient) setBreakpoint(addr uint64, insert bool) error {
+	if !insert {
+		num, ok := c.bps[addr]
+		if !ok {
+			return nil
+		}
+		if _, err := c.command(fmt.Sprintf("bc %d", num), kdbReplyTimeout); err != nil {
+			return err
+		}
+		delete(c.bps, addr)
+		return nil
+	}
+	lines, err := c.command(fmt.Sprintf("bp 0x%x", addr), kdbReplyTimeout)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if m := kdbBPNumRe.FindStringSubmatch(line); m != nil {
+			c.bps[addr], _ = strconv.Atoi(m[1])
+			return nil
+		}
+	}
+	return fmt.Errorf("kdb没有接受断点: %s", strings.Join(lines, " "))
+}
+
+// rd：解析 名称: 值 对（按kdb输出的顺序）
+func (c *kdbClient) readRegisters() (*RegisterSnapshot, error) {
+	lines, err := c.command("rd", kdbReplyTimeout)
+	if err != nil {
+		return nil, err
+	}
+	snap := &RegisterSnapshot{Arch: c.arch, Source: "kdb " + c.tty, Time: time.Now()}
+	aliases := kdbRegAliases[c.arch]
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		for _, m := range kdbRegRe.FindAllStringSubmatch(line, -1) {
+			name := m[1]
+			if alias, ok := aliases[name]; ok {
+				name = alias
+			}
+			value, err := strconv.ParseUint(m[2], 16, 64)
+			if err != nil || seen[name] {
+				continue
+			}
+			seen[name] = true
+			snap.Names = append(snap.Names, name)
+			snap.Values = append(snap.Values, value)
+		}
+	}
+	if len(snap.Names) == 0 {
+		return nil, fmt.Errorf("无法解析kdb的rd输出")
+	}
+	return snap, nil
+}
+
+// mdr：原始内存（一行连续的十六进制），无法读取的块标为不可读
+func (c *kdbClient) readMemory(addr uint64, length int) ([]byte, []bool, error) {
+	data := make([]byte, length)
+	readable := make([]bool, length)
+	for off := 0; off < length; off += kdbMemChunk {
+		n := length - off
+		if n > kdbMemChunk {
+			n = kdbMemChunk
+		}
+		lines, err := c.command(fmt.Sprintf("mdr 0x%x %d", addr+uint64(off), n), kdbReplyTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, line := range lines {
+			raw, err := hex.DecodeString(strings.TrimSpace(line))
+			if err != nil || len(raw) == 0 {
+				continue
+			}
+			copy(data[off:], raw)
+			for i := 0; i < len(raw) && i < n; i++ {
+				readable[off+i] = true
+			}
+			break
+		}
+	}
+	return data, readable, nil
+}
+
+// 断开：让目标继续运行
+func (c *kdbClient) detach() error {
+	return c.write("go\r")
+}
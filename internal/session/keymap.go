@@ -0,0 +1,135 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 可配置的按键绑定 ==========
+
+// 配置目录名（位于 $XDG_CONFIG_HOME 或 ~/.config 下）
+const KeyConfigDir = "kdebug-tui"
+
+// 按键配置文件（keys.toml优先，其次keys.json；都不存在时返回空路径）
+func KeyConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"keys.toml", "keys.json"} {
+		if path := filepath.Join(dir, KeyConfigDir, name); project.FileExists(path) {
+			return path
+		}
+	}
+	return ""
+}
+
+// 读取按键配置：动作名 → 按键列表
+func LoadKeyConfig(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := make(map[string][]string)
+	if strings.HasSuffix(path, ".json") {
+		raw := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析失败: %v", err)
+		}
+		for action, value := range raw {
+			keys, err := parseKeyValue(string(value))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", action, err)
+			}
+			config[action] = keys
+		}
+		return config, nil
+	}
+
+	// TOML子集：每行 动作 = "按键" 或 ["按键", ...]，忽略注释和表头
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("第%d行: 应为 动作 = \"按键\"", i+1)
+		}
+		action := strings.Trim(strings.TrimSpace(line[:eq]), `"'`)
+		value, err := tomlKeyValue(line[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", i+1, err)
+		}
+		keys, err := parseKeyValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %v", i+1, err)
+		}
+		config[action] = keys
+	}
+	return config, nil
+}
+
+// 把TOML的值改写成JSON：单引号的字面量字符串（没有转义）换成双引号字符串，
+// 去掉字符串之外的行尾注释；双引号字符串原样保留（转义规则和JSON相同）
+func tomlKeyValue(value string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"':
+			j := i + 1
+			for j < len(value) && value[j] != '"' {
+				if value[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(value) {
+				return "", fmt.Errorf("字符串没有结束: %s", strings.TrimSpace(value))
+			}
+			b.WriteString(value[i : j+1])
+			i = j
+		case '\'':
+			end := strings.IndexByte(value[i+1:], '\'')
+			if end < 0 {
+				return "", fmt.Errorf("字符串没有结束: %s", strings.TrimSpace(value))
+			}
+			quoted, _ := json.Marshal(value[i+1 : i+1+end])
+			b.Write(quoted)
+			i += end + 1
+		case '#':
+			return strings.TrimSpace(b.String()), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// 按键值：字符串或字符串数组（"none"/空字符串表示取消绑定）
+func parseKeyValue(value string) ([]string, error) {
+	var keys []string
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &keys); err != nil {
+			return nil, fmt.Errorf("按键数组无效: %s", value)
+		}
+	} else {
+		var key string
+		if err := json.Unmarshal([]byte(value), &key); err != nil {
+			return nil, fmt.Errorf("按键应为带引号的字符串: %s", value)
+		}
+		keys = []string{key}
+	}
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" && !strings.EqualFold(key, "none") {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadKeyConfigTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.toml")
+	text := `# 按键配置
+[keys]
+quit = 'Ctrl+Q'
+mark = "'"         # 双引号中的单引号
+jump = '"'
+comment = ['#', "Alt+#"] # 字符串中的 # 不是注释
+path = 'C:\x'
+"search" = "none"
+`
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadKeyConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"quit":    {"Ctrl+Q"},
+		"mark":    {"'"},
+		"jump":    {`"`},
+		"comment": {"#", "Alt+#"},
+		"path":    {`C:\x`},
+		"search":  {},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("LoadKeyConfig = %q, want %q", config, want)
+	}
+
+	for _, bad := range []string{"quit = 'Ctrl+Q\n", "quit = \"Ctrl+Q\n", "quit = Ctrl+Q\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKeyConfig(path); err == nil {
+			t.Errorf("LoadKeyConfig(%q) should fail", bad)
+		}
+	}
+}
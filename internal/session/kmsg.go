@@ -0,0 +1,283 @@
+package session
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 内核日志 ==========
+// dmesg start 读取 /dev/kmsg（配置了远程目标时通过ssh读取开发板的 /dev/kmsg），每条记录作为kmsg事件加入事件列表。
+// 记录的时间戳与trace_pipe、perf buffer一样是开机以来的单调时间，appendEvent按时间戳把printk输出插到
+// 断点命中之间，事件窗口、dmesg窗口和 export perfetto 导出的时间线中两者交错显示。
+// /dev/kmsg 的记录格式（续行以空格开头，是 KEY=value 字典，忽略）：
+//   <优先级>,<序号>,<微秒>,<标志>[,caller=T123];<消息>
+
+// /dev/kmsg 单条记录的最大长度（CONSOLE_EXT_LOG_MAX），读缓冲区小于记录时read返回EINVAL
+const kmsgRecordMax = 8192
+
+// dmesg around 默认的时间窗口
+const DefaultKmsgWindow = 50 * time.Millisecond
+
+// dmesg around 最多显示的命中次数
+const maxKmsgAroundHits = 5
+
+// printk级别名称（优先级的低3位）
+var kmsgLevels = []string{"emerg", "alert", "crit", "err", "warn", "notice", "info", "debug"}
+
+// 解析 /dev/kmsg 的一条记录，续行和无法识别的行返回false
+func parseKmsgLine(line string) (DebugEvent, bool) {
+	event := DebugEvent{Kind: "kmsg", Raw: line, Time: time.Now(), CPU: -1}
+	semi := strings.Index(line, ";")
+	if semi < 0 || strings.HasPrefix(line, " ") {
+		return event, false
+	}
+	fields := strings.Split(line[:semi], ",")
+	if len(fields) < 4 {
+		return event, false
+	}
+	pri, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return event, false
+	}
+	usec, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return event, false
+	}
+	event.Function = kmsgLevels[pri&7]
+	event.TraceTime = float64(usec) / 1e6
+	// CONFIG_PRINTK_CALLER：caller=T<pid>（进程上下文）或 C<cpu>（中断上下文）
+	for _, field := range fields[4:] {
+		caller := strings.TrimPrefix(field, "caller=")
+		if caller == field || len(caller) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(caller[1:])
+		if err != nil {
+			continue
+		}
+		if caller[0] == 'T' {
+			event.PID = n
+		} else if caller[0] == 'C' {
+			event.CPU = n
+		}
+	}
+	// 消息中的不可打印字符被内核转义为 \xNN
+	event.Location = line[semi+1:]
+	return event, true
+}
+
+// 打开本地 /dev/kmsg，all为false时跳过已有的记录
+func openLocalKmsg(all bool) (io.ReadCloser, string, error) {
+	file, err := os.Open("/dev/kmsg")
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrPerm, "无法打开 /dev/kmsg（需要root或 kernel.dmesg_restrict=0）: %v", err)
+	}
+	if !all {
+		// SEEK_END 定位到下一条新记录
+		if _, err := file.Seek(0, io.SeekEnd); err != nil {
+			file.Close()
+			return nil, "", fmt.Errorf("定位 /dev/kmsg 失败: %v", err)
+		}
+	}
+	return file, "/dev/kmsg", nil
+}
+
+// 通过ssh读取开发板的 /dev/kmsg；第一行输出开发板的uptime，用于跳过已有的记录
+func openRemoteKmsg(remote *project.RemoteTarget) (io.ReadCloser, string, error) {
+	cmd := exec.Command("ssh", remote.SSHArgs("cat /proc/uptime && exec cat /dev/kmsg", "ServerAliveInterval=2")...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", fmt.Errorf("创建ssh管道失败: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrToolMissing, "启动ssh失败: %v", err)
+	}
+	return &remotePipe{cmd: cmd, stdout: stdout}, fmt.Sprintf("%s:/dev/kmsg (ssh)", remote.SSH), nil
+}
+
+// 启动内核日志读取协程，记录通过ui.Update回到UI线程；all为true时同时导入环形缓冲区中已有的记录
+func StartKmsgCapture(ui UI, ctx *DebuggerContext, all bool) (string, error) {
+	if ctx.KmsgSource != nil {
+		return "", fmt.Errorf("内核日志读取已在运行")
+	}
+	if StopModeBackend(CurrentBackend(ctx)) {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "%s后端停下时内核不会输出日志", CurrentBackend(ctx))
+	}
+	var file io.ReadCloser
+	var path string
+	var err error
+	remote := RemoteTarget(ctx)
+	if remote != nil {
+		file, path, err = openRemoteKmsg(remote)
+	} else {
+		file, path, err = openLocalKmsg(all)
+	}
+	if err != nil {
+		return "", err
+	}
+	ctx.KmsgSource = file
+
+	go func() {
+		reader := bufio.NewReaderSize(file, kmsgRecordMax)
+		// 远程输出的第一行是开发板的uptime，早于它的记录是环形缓冲区中已有的
+		since := 0.0
+		if remote != nil {
+			first, _ := reader.ReadString('\n')
+			if fields := strings.Fields(first); len(fields) > 0 && !all {
+				since, _ = strconv.ParseFloat(fields[0], 64)
+			}
+		}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				// 读取太慢时旧记录被覆盖，read返回EPIPE，之后从最早的可用记录继续
+				if errors.Is(err, syscall.EPIPE) {
+					continue
+				}
+				break
+			}
+			event, ok := parseKmsgLine(strings.TrimRight(line, "\n"))
+			if !ok || event.TraceTime < since {
+				continue
+			}
+			ui.Update(func(ui UI) error {
+				if ctx.KmsgSource != file {
+					return nil
+				}
+				AppendEvent(ctx, event)
+				RefreshEventsPopup(ctx)
+				return nil
+			})
+		}
+	}()
+	return path, nil
+}
+
+// 停止内核日志读取
+func StopKmsgCapture(ctx *DebuggerContext) bool {
+	if ctx.KmsgSource == nil {
+		return false
+	}
+	ctx.KmsgSource.Close()
+	ctx.KmsgSource = nil
+	return true
+}
+
+// 格式化内核日志行（err及以上红色，warn黄色，debug灰色）
+func formatKmsg(event DebugEvent) string {
+	color := ""
+	switch event.Function {
+	case "emerg", "alert", "crit", "err":
+		color = "\x1b[31m"
+	case "warn":
+		color = "\x1b[33m"
+	case "debug":
+		color = "\x1b[90m"
+	}
+	if color == "" {
+		return fmt.Sprintf("%-6s %s", event.Function, event.Location)
+	}
+	return fmt.Sprintf("%s%-6s %s\x1b[0m", color, event.Function, event.Location)
+}
+
+// dmesg窗口内容：内核日志，断点命中和函数返回作为分隔行插在对应时间处
+func kmsgPopupContent(ctx *DebuggerContext) []string {
+	lines := make([]string, 0)
+	for _, event := range ctx.Events {
+		switch event.Kind {
+		case "kmsg":
+			lines = append(lines, fmt.Sprintf("[%12.6f] %s", event.TraceTime, formatKmsg(event)))
+		case "breakpoint", "return":
+			lines = append(lines, "\x1b[36m── "+FormatEvent(ctx, event)+" ──\x1b[0m")
+		}
+	}
+	if len(lines) == 0 {
+		return []string{"No kernel log captured yet", "", "Use 'dmesg start' to read /dev/kmsg"}
+	}
+	return lines
+}
+
+// dmesg窗口标题
+func kmsgPopupTitle(ctx *DebuggerContext) string {
+	count := 0
+	for _, event := range ctx.Events {
+		if event.Kind == "kmsg" {
+			count++
+		}
+	}
+	title := fmt.Sprintf("Kernel Log (%d)", count)
+	if ctx.KmsgSource != nil {
+		title += " ● live"
+	}
+	return title
+}
+
+// 显示内核日志窗口
+func ShowKmsgPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, "dmesg")
+	popup := CreatePopupWindow(ctx, "dmesg", kmsgPopupTitle(ctx), 110, 25, kmsgPopupContent(ctx))
+	scrollPopupToEnd(popup)
+	ShowPopupWindow(ctx, popup)
+}
+
+// 内核日志窗口打开时刷新内容（停在末尾时跟随新记录）
+func RefreshKmsgPopup(ctx *DebuggerContext) {
+	popup := FindPopupWindow(ctx, "dmesg")
+	if popup == nil {
+		return
+	}
+	following := popup.ScrollY+(popup.Height-3) >= len(popup.Content)
+	popup.Title = kmsgPopupTitle(ctx)
+	popup.Content = kmsgPopupContent(ctx)
+	if following {
+		scrollPopupToEnd(popup)
+	}
+}
+
+// dmesg around：断点最近几次命中前后window内的内核日志
+func KmsgAroundLines(ctx *DebuggerContext, id int, window time.Duration) []string {
+	hits := make([]DebugEvent, 0)
+	for _, event := range ctx.Events {
+		if event.Kind == "breakpoint" && event.BreakpointID == id {
+			hits = append(hits, event)
+		}
+	}
+	if len(hits) == 0 {
+		return []string{fmt.Sprintf("No hits of breakpoint %d in the event list", id)}
+	}
+	if len(hits) > maxKmsgAroundHits {
+		hits = hits[len(hits)-maxKmsgAroundHits:]
+	}
+	lines := []string{fmt.Sprintf("Kernel log within ±%s of the last %d hits of BP%d:", window, len(hits), id)}
+	for _, hit := range hits {
+		at := eventSeconds(hit)
+		lines = append(lines, "\x1b[36m── "+FormatEvent(ctx, hit)+" ──\x1b[0m")
+		found := 0
+		for _, event := range ctx.Events {
+			if event.Kind != "kmsg" {
+				continue
+			}
+			delta := event.TraceTime - at
+			if delta < -window.Seconds() || delta > window.Seconds() {
+				continue
+			}
+			found++
+			lines = append(lines, fmt.Sprintf("  %+9.3fms %s", delta*1000, formatKmsg(event)))
+		}
+		if found == 0 {
+			lines = append(lines, "  (no kernel log)")
+		}
+	}
+	return lines
+}
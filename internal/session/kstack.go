@@ -0,0 +1,258 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cilium/ebpf"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 断点命中时的内核调用栈 ==========
+// 生成的断点探针用bpf_get_stackid把命中时的内核栈存入栈map（debug_stacks），
+// 事件中带上栈编号。bpf load 后perf buffer读取协程按编号取出返回地址，
+// 用 /proc/kallsyms 解析为 函数+偏移；属于项目模块的帧再按DWARF行号表解析出源码行，
+// 调用栈窗口显示命中时真实的调用链（按Enter跳转源码）。
+
+// 栈map名称和深度（与生成的BPF代码一致）
+const (
+	DebugStacksMap   = "debug_stacks"
+	kernelStackDepth = 32
+)
+
+// 生成的BPF代码：栈map定义
+func writeStackMapDecl(file *os.File) {
+	fmt.Fprintln(file, "// 命中时的内核调用栈（bpf load 时由调试器按 stack_id 读取）")
+	fmt.Fprintf(file, "#define DEBUG_STACK_DEPTH %d\n", kernelStackDepth)
+	fmt.Fprintln(file, "struct {")
+	fmt.Fprintln(file, "    __uint(type, BPF_MAP_TYPE_STACK_TRACE);")
+	fmt.Fprintln(file, "    __uint(key_size, sizeof(u32));")
+	fmt.Fprintln(file, "    __uint(value_size, DEBUG_STACK_DEPTH * sizeof(u64));")
+	fmt.Fprintln(file, "    __uint(max_entries, 1024);")
+	fmt.Fprintf(file, "} %s SEC(\".maps\");\n", DebugStacksMap)
+	fmt.Fprintln(file, "")
+}
+
+// /proc/kallsyms 中的一个代码符号
+type kallsymsEntry struct {
+	addr   uint64
+	name   string
+	module string
+}
+
+// 按地址排序的代码符号表
+type kallsymsTable struct {
+	syms []kallsymsEntry
+}
+
+// 读取 /proc/kallsyms 中的代码符号（t/T/w/W）
+func loadKallsymsTable() (*kallsymsTable, error) {
+	file, err := os.Open("/proc/kallsyms")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseKallsymsTable(file)
+}
+
+// 目标的代码符号表：配置了远程目标时通过ssh读取开发板（或虚拟机）的 /proc/kallsyms
+func loadTargetKallsymsTable(ctx *DebuggerContext) (*kallsymsTable, error) {
+	remote := RemoteTarget(ctx)
+	if remote == nil {
+		return loadKallsymsTable()
+	}
+	out, err := exec.Command("ssh", remote.SSHArgs("cat /proc/kallsyms")...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 的 /proc/kallsyms 失败: %v", remote.SSH, err)
+	}
+	return parseKallsymsTable(bytes.NewReader(out))
+}
+
+// 解析kallsyms格式的符号表
+func parseKallsymsTable(r io.Reader) (*kallsymsTable, error) {
+	table := &kallsymsTable{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// 格式: <地址> <类型> <符号名> [模块]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || !strings.ContainsAny(fields[1], "tTwW") {
+			continue
+		}
+		var addr uint64
+		if _, err := fmt.Sscanf(fields[0], "%x", &addr); err != nil || addr == 0 {
+			continue
+		}
+		entry := kallsymsEntry{addr: addr, name: fields[2]}
+		if len(fields) > 3 {
+			entry.module = strings.Trim(fields[3], "[]")
+		}
+		table.syms = append(table.syms, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(table.syms) == 0 {
+		return nil, errcode.Errorf(errcode.ErrPerm, "/proc/kallsyms 中的地址全部为0（kptr_restrict，需要root）")
+	}
+	sort.Slice(table.syms, func(i, j int) bool { return table.syms[i].addr < table.syms[j].addr })
+	return table, nil
+}
+
+// 地址所在的符号和偏移
+func (t *kallsymsTable) lookup(addr uint64) (*kallsymsEntry, uint64, bool) {
+	i := sort.Search(len(t.syms), func(i int) bool { return t.syms[i].addr > addr })
+	if i == 0 {
+		return nil, 0, false
+	}
+	sym := &t.syms[i-1]
+	return sym, addr - sym.addr, true
+}
+
+// .ko 文件在kallsyms和 /sys/kernel/btf 中的模块名（foo-bar.ko → foo_bar）
+func kallsymsModuleName(path string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(filepath.Base(path), ".ko"), "-", "_")
+}
+
+// 栈编号到调用栈帧的解析（只在perf buffer读取协程中使用）
+type stackResolver struct {
+	stacks *ebpf.Map
+	syms   *kallsymsTable
+	lines  *dwarf.LineResolver // 项目模块的行号表（没有调试信息时为nil）
+	module string              // kallsyms中的模块名（foo-bar.ko → foo_bar）
+	rows   map[string]map[uint64]dwarf.LineRow
+	cache  map[int32][]StackFrame
+	kaslr  *DebuggerContext // 只带创建时的KASLR检测结果（解析不了的地址显示vmlinux中的链接地址）
+}
+
+// 创建栈解析器（BPF目标文件中没有debug_stacks或kallsyms不可读时返回nil）
+func newStackResolver(ctx *DebuggerContext, coll *ebpf.Collection) (*stackResolver, error) {
+	m := coll.Maps[DebugStacksMap]
+	if m == nil {
+		return nil, nil
+	}
+	r, err := newSymbolResolver(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("读取 /proc/kallsyms 失败，调用栈不可用: %v", err)
+	}
+	r.stacks = m
+	return r, nil
+}
+
+// 只解析地址的解析器（没有栈map，用于硬件断点命中的指令地址）
+func newSymbolResolver(ctx *DebuggerContext) (*stackResolver, error) {
+	syms, err := loadKallsymsTable()
+	if err != nil {
+		return nil, err
+	}
+	r := &stackResolver{
+		syms:  syms,
+		rows:  make(map[string]map[uint64]dwarf.LineRow),
+		cache: make(map[int32][]StackFrame),
+		kaslr: &DebuggerContext{KASLR: ctx.KASLR},
+	}
+	if ctx.Project == nil {
+		// 没有打开项目时只解析为 函数+偏移
+		return r, nil
+	}
+	if module := project.FindProjectModule(ctx.Project.RootPath); module != "" {
+		r.module = kallsymsModuleName(module)
+	}
+	r.lines, _ = ProjectLineResolver(ctx)
+	return r, nil
+}
+
+// 事件中的栈编号（没有采集到或旧目标文件中没有该字段时为-1）
+func debugEventStackID(sample []byte) int32 {
+	if len(sample) < debugEventStackOff+4 {
+		return -1
+	}
+	return int32(binary.NativeEndian.Uint32(sample[debugEventStackOff:]))
+}
+
+// 取出并解析一个调用栈（同一调用链的编号相同，解析结果缓存）
+func (r *stackResolver) frames(id int32) []StackFrame {
+	if id < 0 {
+		return nil
+	}
+	if frames, ok := r.cache[id]; ok {
+		return frames
+	}
+	raw := make([]byte, kernelStackDepth*8)
+	if err := r.stacks.Lookup(uint32(id), raw); err != nil {
+		return nil
+	}
+	frames := make([]StackFrame, 0, kernelStackDepth)
+	for i := 0; i < kernelStackDepth; i++ {
+		addr := binary.NativeEndian.Uint64(raw[i*8:])
+		if addr == 0 {
+			break
+		}
+		frames = append(frames, r.frame(addr, i == 0))
+	}
+	r.cache[id] = frames
+	return frames
+}
+
+// 解析一个返回地址：项目模块中的函数解析到源码行，其余显示为 函数+偏移 [模块]
+func (r *stackResolver) frame(addr uint64, top bool) StackFrame {
+	sym, offset, ok := r.syms.lookup(addr)
+	if !ok {
+		return StackFrame{Function: fmt.Sprintf("0x%x", addr) + LinkAddressNote(r.kaslr, addr)}
+	}
+	if sym.module != "" && sym.module == r.module && r.lines != nil {
+		// 除栈顶（探针地址）外都是返回地址，指向call的下一条指令
+		if !top && offset > 0 {
+			offset--
+		}
+		if file, line := r.sourceLine(sym.name, offset); file != "" {
+			return StackFrame{Function: sym.name, File: file, Line: line}
+		}
+	}
+	name := fmt.Sprintf("%s+0x%x", sym.name, offset)
+	if sym.module != "" {
+		name += " [" + sym.module + "]"
+	}
+	return StackFrame{Function: name}
+}
+
+// 函数内偏移对应的源码行（不超过该地址的最后一行）
+func (r *stackResolver) sourceLine(function string, offset uint64) (string, int) {
+	fn := r.lines.LineFunction(function)
+	if fn == nil || len(fn.Ranges) == 0 {
+		return "", 0
+	}
+	rows, ok := r.rows[function]
+	if !ok {
+		rows = r.lines.FunctionRows(fn)
+		r.rows[function] = rows
+	}
+	entry := fn.Ranges[0][0]
+	for _, rg := range fn.Ranges {
+		if rg[0] < entry {
+			entry = rg[0]
+		}
+	}
+	target := entry + offset
+	var best dwarf.LineRow
+	found := false
+	for addr, row := range rows {
+		if addr <= target && (!found || addr > best.Addr) {
+			best, found = row, true
+		}
+	}
+	if !found || best.Line <= 0 {
+		return "", 0
+	}
+	return best.File, best.Line
+}
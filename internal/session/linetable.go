@@ -0,0 +1,59 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== DWARF行号表解析 ==========
+
+// 项目模块的行号表（按模块路径和修改时间缓存，没有模块或没有调试信息时返回错误）
+func ProjectLineResolver(ctx *DebuggerContext) (*dwarf.LineResolver, error) {
+	if ctx.Project == nil {
+		return nil, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return nil, fmt.Errorf("项目中没有编译好的.ko")
+	}
+	if r := ctx.LineTable; r != nil && r.Binary == module {
+		if info, err := os.Stat(module); err == nil && info.ModTime().Equal(r.ModTime) {
+			return r, nil
+		}
+	}
+	r, err := dwarf.NewLineResolver(module)
+	if err != nil {
+		return nil, err
+	}
+	ctx.LineTable = r
+	return r, nil
+}
+
+// 用行号表解析断点的探针位置，成功时更新断点的函数和偏移
+// 源文件比模块新时行号可能已经错位，不使用行号表
+func ResolveBreakpointProbe(ctx *DebuggerContext, bp *project.Breakpoint) (*dwarf.LineLocation, error) {
+	if bp.Binary != "" {
+		return nil, fmt.Errorf("用户态探针挂在 %s 中函数的入口", filepath.Base(bp.Binary))
+	}
+	r, err := ProjectLineResolver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(bp.File); err == nil && info.ModTime().After(r.ModTime) {
+		return nil, fmt.Errorf("%s 比 %s 新，请重新编译模块", filepath.Base(bp.File), filepath.Base(r.Binary))
+	}
+	loc, err := r.Resolve(bp.File, bp.Line)
+	if err != nil {
+		return nil, err
+	}
+	bp.Function = loc.Function
+	bp.Offset = loc.Offset
+	bp.InlinedFrom = loc.Inlined
+	bp.Inline = loc.Sites
+	return loc, nil
+}
@@ -0,0 +1,504 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 锁竞争和原子上下文睡眠检测 ==========
+// locks on 后 generate/vars 额外生成一组锁探针模板，只统计调用点在项目模块代码段中的加锁：
+//   mutex_lock* / _raw_spin_lock* 入口记下等待开始，返回时记为持有开始（按锁地址），
+//   mutex_unlock / _raw_spin_unlock* 时输出一次 [LOCK-N] 事件：调用点、等待时间和持有时间。
+// 模块持有自旋锁期间同一线程进入 schedule() 时输出 [SLEEP-ATOMIC] 事件（带内核调用栈）。
+// 调用点是加锁函数的返回地址，按kallsyms和DWARF行号表解析到源码行：源码窗口在该行行尾标注
+// 加锁次数、持有时间和竞争次数，原子上下文中睡眠用醒目颜色标出；locks 命令打开汇总窗口。
+// 加锁记录只做汇总，不进入事件列表。模块代码段范围在生成时从 /proc/modules 读取，
+// bpf load 时按当前加载的模块重新设置。内核把 _raw_spin_unlock 内联时释放探针挂载失败，
+// 此时没有持有时间，也不检测睡眠。
+
+// 锁汇总窗口ID
+const locksPopupID = "locks"
+
+// 等待超过该时间算作一次竞争（kretprobe本身的开销约1µs）
+const lockContendedWait = 5 * time.Microsecond
+
+// 模块代码段范围（生成的BPF代码中的常量名）
+const (
+	lockModuleStart = "lock_module_start"
+	lockModuleEnd   = "lock_module_end"
+)
+
+// 一类锁的加锁/释放函数
+type lockTemplate struct {
+	Name    string
+	Acquire []string
+	Release []string
+	Spin    bool // 自旋锁：持有期间检测睡眠
+}
+
+// 锁的种类（编号为下标+1，即 [LOCK-N] 的N）
+var lockTemplates = []lockTemplate{
+	{Name: "mutex", Acquire: []string{"mutex_lock", "mutex_lock_interruptible", "mutex_lock_killable"}, Release: []string{"mutex_unlock"}},
+	{Name: "spinlock", Acquire: []string{"_raw_spin_lock", "_raw_spin_lock_irqsave", "_raw_spin_lock_irq", "_raw_spin_lock_bh"},
+		Release: []string{"_raw_spin_unlock", "_raw_spin_unlock_irqrestore", "_raw_spin_unlock_irq", "_raw_spin_unlock_bh"}, Spin: true},
+}
+
+// 返回0才表示拿到锁的加锁函数（被信号打断时返回-EINTR）
+var lockStatusReturn = map[string]bool{"mutex_lock_interruptible": true, "mutex_lock_killable": true}
+
+// 一个加锁调用点的汇总
+type lockSite struct {
+	Kind      int
+	Caller    uint64
+	Frame     StackFrame // 调用点（解析到源码行时File不为空）
+	Acquires  int
+	Contended int
+	WaitTotal time.Duration
+	WaitMax   time.Duration
+	HoldTotal time.Duration
+	HoldMax   time.Duration
+	Sleeps    int // 持有自旋锁时睡眠的次数
+}
+
+// 锁探针的汇总（按调用点地址）
+type LockStats struct {
+	Sites    map[uint64]*lockSite
+	Resolver *stackResolver // 调用点地址解析（第一次需要时加载kallsyms）
+	Tried    bool
+}
+
+// 是否生成锁探针
+func LockProbesEnabled(ctx *DebuggerContext) bool {
+	return ctx.Project != nil && ctx.Project.Settings != nil && ctx.Project.Settings.Locks
+}
+
+// 锁的种类名称
+func lockKindName(kind int) string {
+	if kind < 1 || kind > len(lockTemplates) {
+		return "lock"
+	}
+	return lockTemplates[kind-1].Name
+}
+
+// 从 /proc/modules 读取项目模块的地址范围（未加载或没有权限读取地址时返回错误）
+func moduleTextRange(ctx *DebuggerContext) (uint64, uint64, error) {
+	module := ""
+	if ctx.Project != nil {
+		module = project.FindProjectModule(ctx.Project.RootPath)
+	}
+	if module == "" {
+		return 0, 0, errcode.Errorf(errcode.ErrNotFound, "项目中没有编译好的模块（.ko）")
+	}
+	name := kallsymsModuleName(module)
+	data, err := readTargetFile(ctx, "/proc/modules")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// drv 16384 0 - Live 0xffffffffc0a00000
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] != name {
+			continue
+		}
+		size, _ := strconv.ParseUint(fields[1], 10, 64)
+		base, err := strconv.ParseUint(strings.TrimPrefix(fields[5], "0x"), 16, 64)
+		if err != nil || base == 0 {
+			return 0, 0, errcode.Errorf(errcode.ErrPerm, "没有权限读取模块 %s 的地址（需要root，或 kptr_restrict=0）", name)
+		}
+		return base, base + size, nil
+	}
+	return 0, 0, errcode.Errorf(errcode.ErrNotFound, "模块 %s 未加载", name)
+}
+
+// bpf load 时按当前加载的模块设置代码段范围（目标文件中没有锁探针时不做任何事）
+func setLockModuleRange(ctx *DebuggerContext, spec *ebpf.CollectionSpec) error {
+	startVar, endVar := spec.Variables[lockModuleStart], spec.Variables[lockModuleEnd]
+	if startVar == nil || endVar == nil {
+		return nil
+	}
+	start, end, err := moduleTextRange(ctx)
+	if err != nil {
+		return fmt.Errorf("模块地址范围沿用生成时的值: %v", err)
+	}
+	if err := startVar.Set(start); err != nil {
+		return err
+	}
+	return endVar.Set(end)
+}
+
+// 生成的BPF代码：锁探针（返回是否生成）
+func WriteLockProbes(ctx *DebuggerContext, file *os.File, filter *project.ProbeFilter) bool {
+	if !LockProbesEnabled(ctx) {
+		return false
+	}
+	start, end, err := moduleTextRange(ctx)
+	if err != nil {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: lock probes: %v; 'bpf load' sets the module range when the module is loaded", err))
+		ctx.CommandDirty = true
+	}
+
+	fmt.Fprintln(file, "// 锁探针（locks on）：只统计调用点在项目模块代码段中的加锁")
+	fmt.Fprintf(file, "volatile const u64 %s = 0x%x;\n", lockModuleStart, start)
+	fmt.Fprintf(file, "volatile const u64 %s = 0x%x;\n", lockModuleEnd, end)
+	fmt.Fprintln(file, "struct lock_wait { u64 start; u64 caller; u64 lock; };")
+	fmt.Fprintln(file, "struct lock_hold { u64 acquired; u64 wait_ns; u64 caller; };")
+	fmt.Fprintln(file, "struct spin_held { u64 depth; u64 caller; };")
+	fmt.Fprintln(file, "// LOCK/SLEEP-ATOMIC事件中放在var_name里的附加信息")
+	fmt.Fprintln(file, "struct debug_lock_info { u64 caller; u64 wait_ns; u64 lock; };")
+	for _, m := range []struct{ name, key, value, comment string }{
+		{"lock_waits", "u64", "struct lock_wait", "正在等待的加锁（按线程）"},
+		{"lock_holds", "u64", "struct lock_hold", "持有中的锁（按锁地址）"},
+		{"lock_spin_held", "u64", "struct spin_held", "线程持有的模块自旋锁层数"},
+	} {
+		fmt.Fprintf(file, "// %s\n", m.comment)
+		fmt.Fprintln(file, "struct {")
+		fmt.Fprintln(file, "    __uint(type, BPF_MAP_TYPE_LRU_HASH);")
+		fmt.Fprintf(file, "    __uint(max_entries, %d);\n", SpanMapEntries)
+		fmt.Fprintf(file, "    __type(key, %s);\n", m.key)
+		fmt.Fprintf(file, "    __type(value, %s);\n", m.value)
+		fmt.Fprintf(file, "} %s SEC(\".maps\");\n", m.name)
+	}
+	fmt.Fprintln(file, "")
+
+	for k, tmpl := range lockTemplates {
+		kind := k + 1
+		for i, function := range tmpl.Acquire {
+			writeLockAcquire(file, kind, i, function, tmpl.Spin, filter)
+		}
+		for i, function := range tmpl.Release {
+			writeLockRelease(file, kind, i, function, tmpl)
+		}
+	}
+	writeLockSleepCheck(file)
+	return true
+}
+
+// 加锁入口（记录等待开始）和返回（记录持有开始）
+func writeLockAcquire(file *os.File, kind, index int, function string, spin bool, filter *project.ProbeFilter) {
+	fmt.Fprintf(file, "SEC(\"kprobe/%s\")\n", function)
+	fmt.Fprintf(file, "int lock_acquire_%d_%d(struct pt_regs *ctx) {\n", kind, index)
+	WriteProbeFilter(file, filter)
+	fmt.Fprintln(file, "    u64 caller = 0;")
+	fmt.Fprintln(file, "    BPF_KPROBE_READ_RET_IP(caller, ctx);")
+	fmt.Fprintf(file, "    if (caller < %s || caller >= %s)\n", lockModuleStart, lockModuleEnd)
+	fmt.Fprintln(file, "        return 0;")
+	fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+	fmt.Fprintln(file, "    struct lock_wait wait = { .start = bpf_ktime_get_ns(), .caller = caller, .lock = PT_REGS_PARM1(ctx) };")
+	fmt.Fprintln(file, "    bpf_map_update_elem(&lock_waits, &pid_tgid, &wait, BPF_ANY);")
+	fmt.Fprintln(file, "    return 0;")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintln(file, "")
+
+	fmt.Fprintf(file, "SEC(\"kretprobe/%s\")\n", function)
+	fmt.Fprintf(file, "int lock_acquired_%d_%d(struct pt_regs *ctx) {\n", kind, index)
+	fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+	fmt.Fprintln(file, "    struct lock_wait *wait = bpf_map_lookup_elem(&lock_waits, &pid_tgid);")
+	fmt.Fprintln(file, "    if (!wait)")
+	fmt.Fprintln(file, "        return 0;")
+	if lockStatusReturn[function] {
+		fmt.Fprintln(file, "    if (PT_REGS_RC(ctx) != 0) {")
+		fmt.Fprintln(file, "        bpf_map_delete_elem(&lock_waits, &pid_tgid);")
+		fmt.Fprintln(file, "        return 0;")
+		fmt.Fprintln(file, "    }")
+	}
+	fmt.Fprintln(file, "    u64 now = bpf_ktime_get_ns();")
+	fmt.Fprintln(file, "    u64 lock = wait->lock;")
+	fmt.Fprintln(file, "    struct lock_hold hold = { .acquired = now, .wait_ns = now - wait->start, .caller = wait->caller };")
+	fmt.Fprintln(file, "    bpf_map_update_elem(&lock_holds, &lock, &hold, BPF_ANY);")
+	if spin {
+		fmt.Fprintln(file, "    struct spin_held *held = bpf_map_lookup_elem(&lock_spin_held, &pid_tgid);")
+		fmt.Fprintln(file, "    if (held) {")
+		fmt.Fprintln(file, "        held->depth++;")
+		fmt.Fprintln(file, "        held->caller = hold.caller;")
+		fmt.Fprintln(file, "    } else {")
+		fmt.Fprintln(file, "        struct spin_held first = { .depth = 1, .caller = hold.caller };")
+		fmt.Fprintln(file, "        bpf_map_update_elem(&lock_spin_held, &pid_tgid, &first, BPF_ANY);")
+		fmt.Fprintln(file, "    }")
+	}
+	fmt.Fprintln(file, "    bpf_map_delete_elem(&lock_waits, &pid_tgid);")
+	fmt.Fprintln(file, "    return 0;")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintln(file, "")
+}
+
+// 释放：输出一次加锁记录
+func writeLockRelease(file *os.File, kind, index int, function string, tmpl lockTemplate) {
+	fmt.Fprintf(file, "SEC(\"kprobe/%s\")\n", function)
+	fmt.Fprintf(file, "int lock_release_%d_%d(struct pt_regs *ctx) {\n", kind, index)
+	fmt.Fprintln(file, "    u64 lock = PT_REGS_PARM1(ctx);")
+	fmt.Fprintln(file, "    struct lock_hold *hold = bpf_map_lookup_elem(&lock_holds, &lock);")
+	fmt.Fprintln(file, "    if (!hold)")
+	fmt.Fprintln(file, "        return 0;")
+	fmt.Fprintln(file, "    struct debug_event event = {};")
+	fmt.Fprintln(file, "    struct debug_lock_info info = { .caller = hold->caller, .wait_ns = hold->wait_ns, .lock = lock };")
+	fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+	fmt.Fprintln(file, "    event.pid = pid_tgid;")
+	fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+	fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+	fmt.Fprintln(file, "    event.var_value = event.timestamp - hold->acquired;")
+	fmt.Fprintln(file, "    bpf_map_delete_elem(&lock_holds, &lock);")
+	if tmpl.Spin {
+		fmt.Fprintln(file, "    struct spin_held *held = bpf_map_lookup_elem(&lock_spin_held, &pid_tgid);")
+		fmt.Fprintln(file, "    if (held && held->depth > 1)")
+		fmt.Fprintln(file, "        held->depth--;")
+		fmt.Fprintln(file, "    else if (held)")
+		fmt.Fprintln(file, "        bpf_map_delete_elem(&lock_spin_held, &pid_tgid);")
+	}
+	fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", kind)
+	fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_LOCK;")
+	fmt.Fprintln(file, "    event.cpu = bpf_get_smp_processor_id();")
+	fmt.Fprintf(file, "    event.var_type = %d;\n", DebugEventUnsigned)
+	fmt.Fprintln(file, "    event.stack_id = -1;")
+	fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+	fmt.Fprintf(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"%s\");\n", tmpl.Name)
+	fmt.Fprintln(file, "    __builtin_memcpy(event.var_name, &info, sizeof(info));")
+	WriteDebugEventOutput(file, "    ")
+	fmt.Fprintf(file, "    debug_printk(\"[LOCK-%d] caller=0x%%llx wait=%%llu hold=%%llu\\n\", info.caller, info.wait_ns, event.var_value);\n", kind)
+	fmt.Fprintln(file, "    return 0;")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintln(file, "")
+}
+
+// 持有模块的自旋锁时进入schedule()
+func writeLockSleepCheck(file *os.File) {
+	fmt.Fprintln(file, "SEC(\"kprobe/schedule\")")
+	fmt.Fprintln(file, "int lock_sleep_check(struct pt_regs *ctx) {")
+	fmt.Fprintln(file, "    u64 pid_tgid = bpf_get_current_pid_tgid();")
+	fmt.Fprintln(file, "    struct spin_held *held = bpf_map_lookup_elem(&lock_spin_held, &pid_tgid);")
+	fmt.Fprintln(file, "    if (!held)")
+	fmt.Fprintln(file, "        return 0;")
+	fmt.Fprintln(file, "    struct debug_event event = {};")
+	fmt.Fprintln(file, "    struct debug_lock_info info = { .caller = held->caller };")
+	fmt.Fprintln(file, "    event.pid = pid_tgid;")
+	fmt.Fprintln(file, "    event.tgid = pid_tgid >> 32;")
+	fmt.Fprintln(file, "    event.timestamp = bpf_ktime_get_ns();")
+	fmt.Fprintln(file, "    event.var_value = held->depth;")
+	fmt.Fprintf(file, "    event.breakpoint_id = %d;\n", lockSpinKind())
+	fmt.Fprintln(file, "    event.kind = DEBUG_EVENT_ATOMIC_SLEEP;")
+	fmt.Fprintln(file, "    event.cpu = bpf_get_smp_processor_id();")
+	fmt.Fprintf(file, "    event.var_type = %d;\n", DebugEventUnsigned)
+	fmt.Fprintf(file, "    event.stack_id = bpf_get_stackid(ctx, &%s, 0);\n", DebugStacksMap)
+	fmt.Fprintln(file, "    bpf_get_current_comm(&event.comm, sizeof(event.comm));")
+	fmt.Fprintln(file, "    bpf_probe_read_str(&event.function, sizeof(event.function), \"schedule\");")
+	fmt.Fprintln(file, "    __builtin_memcpy(event.var_name, &info, sizeof(info));")
+	WriteDebugEventOutput(file, "    ")
+	fmt.Fprintf(file, "    debug_printk(\"[SLEEP-ATOMIC] caller=0x%%llx depth=%%llu PID=%%d\\n\", info.caller, event.var_value, event.pid);\n")
+	fmt.Fprintln(file, "    return 0;")
+	fmt.Fprintln(file, "}")
+	fmt.Fprintln(file, "")
+}
+
+// 自旋锁的种类编号
+func lockSpinKind() int {
+	for i, tmpl := range lockTemplates {
+		if tmpl.Spin {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// 解码LOCK/SLEEP-ATOMIC事件中var_name里的附加信息
+func decodeLockInfo(event *DebugEvent, info []byte, value string) {
+	ne := binary.NativeEndian
+	caller := fmt.Sprintf("0x%x", ne.Uint64(info[0:8]))
+	if event.Kind == "atomic-sleep" {
+		event.Values = []project.EventValue{{Name: "caller", Value: caller}, {Name: "depth", Value: value}}
+		return
+	}
+	event.Values = []project.EventValue{
+		{Name: "caller", Value: caller},
+		{Name: "wait_ns", Value: strconv.FormatUint(ne.Uint64(info[8:16]), 10)},
+		{Name: "hold_ns", Value: value},
+	}
+}
+
+// 事件中的一个值
+func lockEventValue(event DebugEvent, name string) string {
+	for _, v := range event.Values {
+		if v.Name == name {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+// 调用点的显示位置
+func (s *lockSite) label() string {
+	if s.Frame.File != "" {
+		return fmt.Sprintf("%s:%d", filepath.Base(s.Frame.File), s.Frame.Line)
+	}
+	return s.Frame.Function
+}
+
+// 按调用点地址取得汇总（第一次出现时解析源码行）
+func lockSiteFor(ctx *DebuggerContext, kind int, caller uint64) *lockSite {
+	if ctx.LockStats == nil {
+		ctx.LockStats = &LockStats{Sites: make(map[uint64]*lockSite)}
+	}
+	stats := ctx.LockStats
+	if site, ok := stats.Sites[caller]; ok {
+		return site
+	}
+	if !stats.Tried {
+		stats.Tried = true
+		stats.Resolver, _ = newSymbolResolver(ctx)
+	}
+	site := &lockSite{Kind: kind, Caller: caller, Frame: StackFrame{Function: fmt.Sprintf("0x%x", caller)}}
+	if stats.Resolver != nil {
+		site.Frame = stats.Resolver.frame(caller, false)
+	}
+	stats.Sites[caller] = site
+	return site
+}
+
+// 汇总锁探针的事件（在appendEvent中调用），加锁记录返回true（不进入事件列表）
+func recordLockEvent(ctx *DebuggerContext, event *DebugEvent) bool {
+	if event.Kind != "lock" && event.Kind != "atomic-sleep" {
+		return false
+	}
+	caller, err := strconv.ParseUint(strings.TrimPrefix(lockEventValue(*event, "caller"), "0x"), 16, 64)
+	if err != nil {
+		return event.Kind == "lock"
+	}
+	site := lockSiteFor(ctx, event.BreakpointID, caller)
+	event.Location = site.label()
+	if event.Kind == "atomic-sleep" {
+		site.Sleeps++
+		if site.Sleeps == 1 {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %s sleeps while holding the spinlock taken at %s (pid %d)", event.Comm, site.label(), event.PID))
+			ctx.CommandDirty = true
+		}
+		return false
+	}
+	wait, _ := strconv.ParseUint(lockEventValue(*event, "wait_ns"), 10, 64)
+	hold, _ := strconv.ParseUint(lockEventValue(*event, "hold_ns"), 10, 64)
+	site.Acquires++
+	site.WaitTotal += time.Duration(wait)
+	site.HoldTotal += time.Duration(hold)
+	if time.Duration(wait) > site.WaitMax {
+		site.WaitMax = time.Duration(wait)
+	}
+	if time.Duration(hold) > site.HoldMax {
+		site.HoldMax = time.Duration(hold)
+	}
+	if time.Duration(wait) >= lockContendedWait {
+		site.Contended++
+	}
+	return true
+}
+
+// 按持有时间总和排序的调用点
+func sortedLockSites(ctx *DebuggerContext) []*lockSite {
+	sites := make([]*lockSite, 0)
+	if ctx.LockStats == nil {
+		return sites
+	}
+	for _, site := range ctx.LockStats.Sites {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Sleeps != sites[j].Sleeps {
+			return sites[i].Sleeps > sites[j].Sleeps
+		}
+		if sites[i].HoldTotal != sites[j].HoldTotal {
+			return sites[i].HoldTotal > sites[j].HoldTotal
+		}
+		return sites[i].Caller < sites[j].Caller
+	})
+	return sites
+}
+
+// 平均值
+func lockAverage(total time.Duration, n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+	return total / time.Duration(n)
+}
+
+// 源码窗口的行尾标注（行号 → 标注，按文件名匹配）
+func LockAnnotations(ctx *DebuggerContext, file string) map[int]string {
+	if ctx.LockStats == nil || len(ctx.LockStats.Sites) == 0 {
+		return nil
+	}
+	notes := make(map[int]string)
+	for _, site := range ctx.LockStats.Sites {
+		if site.Frame.File == "" || filepath.Base(site.Frame.File) != filepath.Base(file) {
+			continue
+		}
+		text := ""
+		if site.Sleeps > 0 {
+			text = Styled(ActiveTheme.Alert, fmt.Sprintf("⚠ sleeps with spinlock held ×%d", site.Sleeps)) + " "
+		}
+		if site.Acquires > 0 {
+			text += Styled(ActiveTheme.Dim, fmt.Sprintf("🔒 %s ×%d hold avg %s max %s, %d contended", lockKindName(site.Kind), site.Acquires,
+				formatStatsGap(lockAverage(site.HoldTotal, site.Acquires)), formatStatsGap(site.HoldMax), site.Contended))
+		}
+		if prev := notes[site.Frame.Line]; prev != "" {
+			text = prev + " " + text
+		}
+		notes[site.Frame.Line] = text
+	}
+	return notes
+}
+
+// 锁汇总窗口内容
+func locksReportLines(ctx *DebuggerContext) []string {
+	state := "off ('locks on' and regenerate to add lock probes)"
+	if LockProbesEnabled(ctx) {
+		state = "on"
+	}
+	lines := []string{"Lock probes: " + state}
+	if loaded := ctx.BPF; loaded != nil && LockProbesEnabled(ctx) {
+		spin := lockTemplates[lockSpinKind()-1]
+		attached := false
+		for _, probe := range loaded.Probes {
+			for _, function := range spin.Release {
+				attached = attached || probe == "kprobe/"+function
+			}
+		}
+		if !attached {
+			lines = append(lines, Styled(ActiveTheme.Warning, "Spinlock release probes not attached (unlock inlined?): no spinlock hold times, no sleep detection"))
+		}
+	}
+	sites := sortedLockSites(ctx)
+	lines = append(lines, "", fmt.Sprintf("Call sites (%d), contended = waited ≥ %v:", len(sites), lockContendedWait))
+	if len(sites) == 0 {
+		return append(lines, Styled(ActiveTheme.Dim, "  no lock acquired from the module yet"))
+	}
+	lines = append(lines, fmt.Sprintf("  %-22s %-24s %-8s %8s %9s %21s %21s", "site", "function", "kind", "acquires", "contended", "wait avg/max", "hold avg/max"))
+	for _, site := range sites {
+		function := site.Frame.Function
+		if site.Frame.File == "" {
+			function = ""
+		}
+		line := fmt.Sprintf("  %s %s %-8s %8d %9d %21s %21s", project.FitWidth(site.label(), 22), project.FitWidth(function, 24), lockKindName(site.Kind),
+			site.Acquires, site.Contended,
+			formatStatsGap(lockAverage(site.WaitTotal, site.Acquires))+"/"+formatStatsGap(site.WaitMax),
+			formatStatsGap(lockAverage(site.HoldTotal, site.Acquires))+"/"+formatStatsGap(site.HoldMax))
+		lines = append(lines, line)
+		if site.Sleeps > 0 {
+			lines = append(lines, "    "+Styled(ActiveTheme.Alert, fmt.Sprintf("⚠ slept %d times while holding this spinlock (see SLEEP events for the stack)", site.Sleeps)))
+		}
+	}
+	return lines
+}
+
+// 显示锁汇总窗口
+func ShowLocksPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, locksPopupID)
+	popup := CreatePopupWindow(ctx, locksPopupID, "Lock Contention", 140, 30, locksReportLines(ctx))
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 标记（vim风格的跨文件书签） ==========
+
+// 跳转前的位置保存在这个特殊标记中，” 可跳回
+const LastJumpMark = "'"
+
+// 标记名称只能是单个字母
+func IsValidMarkName(name string) bool {
+	r := []rune(name)
+	return len(r) == 1 && unicode.IsLetter(r[0])
+}
+
+// 获取代码视图当前所在的文件和行号（光标行，没有光标时为首个可见行）
+func CurrentCodeLocation(ui UI, ctx *DebuggerContext) (string, int, bool) {
+	if ctx.Project == nil || ctx.Project.CurrentFile == "" {
+		return "", 0, false
+	}
+	line := ctx.CodeScroll + 1
+	if ui != nil {
+		// 代码视图有2行标题：标题行、文件名行
+		if _, cy := ui.Cursor("code"); cy >= 2 {
+			line = ctx.CodeScroll + cy - 2 + 1
+		}
+	}
+	return ctx.Project.CurrentFile, line, true
+}
+
+// 标记文件的绝对路径
+func resolveMarkPath(ctx *DebuggerContext, mark project.Mark) string {
+	if filepath.IsAbs(mark.File) {
+		return mark.File
+	}
+	return filepath.Join(ctx.Project.RootPath, mark.File)
+}
+
+// 设置标记并保存到项目设置
+func SetMark(ctx *DebuggerContext, name, file string, line int) error {
+	if ctx.Project.Settings.Marks == nil {
+		ctx.Project.Settings.Marks = make(map[string]project.Mark)
+	}
+	ctx.Project.Settings.Marks[name] = project.Mark{File: project.ProjectRelativePath(ctx.Project, file), Line: line}
+	TouchWorkingSet(ctx, file, line, "mark "+name)
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 跳转到标记：打开标记所在文件并滚动到标记行，跳转前的位置记为 '
+func JumpToMark(ui UI, ctx *DebuggerContext, name string) (project.Mark, error) {
+	mark, exists := ctx.Project.Settings.Marks[name]
+	if !exists {
+		return project.Mark{}, fmt.Errorf("标记未设置: %s", name)
+	}
+
+	return mark, jumpWithMark(ui, ctx, resolveMarkPath(ctx, mark), mark.Line)
+}
+
+// 打开文件并滚动到指定行，跳转前的位置记为 '（” 跳回）
+func jumpWithMark(ui UI, ctx *DebuggerContext, path string, line int) error {
+	// 记录跳转前的位置
+	prevFile, prevLine, hadPrev := CurrentCodeLocation(ui, ctx)
+
+	if err := OpenSourceAt(ui, ctx, path, line); err != nil {
+		return err
+	}
+	if hadPrev {
+		if ctx.Project.Settings.Marks == nil {
+			ctx.Project.Settings.Marks = make(map[string]project.Mark)
+		}
+		ctx.Project.Settings.Marks[LastJumpMark] = project.Mark{File: project.ProjectRelativePath(ctx.Project, prevFile), Line: prevLine}
+	}
+
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 删除标记
+func DeleteMark(ctx *DebuggerContext, name string) bool {
+	if _, exists := ctx.Project.Settings.Marks[name]; !exists {
+		return false
+	}
+	delete(ctx.Project.Settings.Marks, name)
+	project.SaveProjectSettings(ctx.Project)
+	return true
+}
+
+// 标记所在行的源码预览
+func markPreview(ctx *DebuggerContext, mark project.Mark) string {
+	path := resolveMarkPath(ctx, mark)
+	file, exists := ctx.Project.OpenFiles[path]
+	if !exists {
+		var err error
+		if file, err = project.LoadSourceFile(path); err != nil {
+			return "<file missing>"
+		}
+	}
+	if mark.Line < 1 || mark.Line > file.Len() {
+		return "<line out of range>"
+	}
+	return strings.TrimSpace(file.Line(mark.Line - 1))
+}
+
+// 显示标记列表弹出窗口
+func ShowMarksPopup(ctx *DebuggerContext) {
+	marks := ctx.Project.Settings.Marks
+	names := make([]string, 0, len(marks))
+	for name := range marks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	content := []string{"mark  location                         text", ""}
+	for _, name := range names {
+		mark := marks[name]
+		location := fmt.Sprintf("%s:%d", mark.File, mark.Line)
+		content = append(content, fmt.Sprintf(" %s    %s %s", name, project.FitWidth(location, 32), project.TruncateWidth(markPreview(ctx, mark), 40)))
+	}
+	if len(names) == 0 {
+		content = append(content, "No marks set. Use 'm <a-z>' in the command window to set one.")
+	}
+	content = append(content, "", "' <a-z> - jump to mark    '' - jump back    delmarks <a-z> - delete")
+
+	height := len(content) + 5
+	if height > 25 {
+		height = 25
+	}
+	ClosePopupWindow(ctx, "marks")
+	popup := CreatePopupWindow(ctx, "marks", "Marks", 90, height, content)
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,316 @@
+package session
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/rlimit"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 内存窗口 ==========
+// mem read <addr|symbol> <len> 读取内核内存，在代码窗口下方的内存窗口中以 hex/ASCII 显示。
+// 优先使用进程内生成的BPF读取程序：raw_tracepoint程序用bpf_probe_read_kernel把地址处的内存
+// 复制到数组map中，通过BPF_PROG_TEST_RUN执行（不挂载任何探针，不需要clang）；
+// 内核不支持时回退到 /proc/kcore；gdb/kdb后端通过gdbstub或kdb读取目标的内存。无法读取的字节显示为 ??。
+
+// 单次读取的最大长度
+const maxMemoryRead = 64 * 1024
+
+// BPF读取程序每次复制的字节数（map值大小）
+const memReadChunk = 256
+
+// 每行字节数的范围
+const (
+	minMemoryWidth     = 4
+	maxMemoryWidth     = 64
+	defaultMemoryWidth = 16
+)
+
+// 一次内存读取的结果
+type MemoryDump struct {
+	Addr     uint64
+	Data     []byte
+	Readable []bool
+	Width    int
+	Source   string // bpf / kcore / gdb / kdb
+	ReadAt   time.Time
+}
+
+// 生成的BPF读取程序
+type bpfMemoryReader struct {
+	buffer  *ebpf.Map
+	program *ebpf.Program
+}
+
+// 创建BPF读取程序：ctx->args[0] 为地址，把 memReadChunk 字节复制到 buffer[0]，返回helper的返回值
+func newBPFMemoryReader() (*bpfMemoryReader, error) {
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return nil, errcode.Errorf(errcode.ErrPerm, "无法解除memlock限制（需要root或CAP_SYS_RESOURCE）: %v", err)
+	}
+	buffer, err := ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "mem_read_buf",
+		Type:       ebpf.Array,
+		KeySize:    4,
+		ValueSize:  memReadChunk,
+		MaxEntries: 1,
+	})
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, errcode.Errorf(errcode.ErrPerm, "创建BPF map失败（需要root或CAP_BPF）: %v", err)
+		}
+		return nil, errcode.Errorf(errcode.ErrBPFAttach, "创建BPF map失败: %v", err)
+	}
+	program, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name: "mem_read",
+		Type: ebpf.RawTracepoint,
+		Instructions: asm.Instructions{
+			asm.LoadMem(asm.R6, asm.R1, 0, asm.DWord),
+			asm.StoreImm(asm.RFP, -4, 0, asm.Word),
+			asm.LoadMapPtr(asm.R1, buffer.FD()),
+			asm.Mov.Reg(asm.R2, asm.RFP),
+			asm.Add.Imm(asm.R2, -4),
+			asm.FnMapLookupElem.Call(),
+			asm.JNE.Imm(asm.R0, 0, "read"),
+			asm.Mov.Imm(asm.R0, -1),
+			asm.Return(),
+			asm.Mov.Reg(asm.R1, asm.R0).WithSymbol("read"),
+			asm.Mov.Imm(asm.R2, memReadChunk),
+			asm.Mov.Reg(asm.R3, asm.R6),
+			asm.FnProbeReadKernel.Call(),
+			asm.Return(),
+		},
+		License: "GPL",
+	})
+	if err != nil {
+		buffer.Close()
+		var verr *ebpf.VerifierError
+		if errors.As(err, &verr) {
+			return nil, errcode.Errorf(errcode.ErrBPFVerifier, "BPF校验器拒绝了内存读取程序: %v", verr)
+		}
+		return nil, errcode.Errorf(errcode.ErrBPFAttach, "加载内存读取程序失败: %v", err)
+	}
+	return &bpfMemoryReader{buffer: buffer, program: program}, nil
+}
+
+// 读取一块内存，返回是否成功
+func (r *bpfMemoryReader) readChunk(addr uint64) ([]byte, bool, error) {
+	ret, err := r.program.Run(&ebpf.RunOptions{Context: []uint64{addr}})
+	if err != nil {
+		return nil, false, errcode.Errorf(errcode.ErrBPFAttach, "执行内存读取程序失败（需要5.10+内核）: %v", err)
+	}
+	if int32(ret) != 0 {
+		return nil, false, nil
+	}
+	data := make([]byte, memReadChunk)
+	if err := r.buffer.Lookup(uint32(0), data); err != nil {
+		return nil, false, fmt.Errorf("读取BPF map失败: %v", err)
+	}
+	return data, true, nil
+}
+
+func (r *bpfMemoryReader) Close() {
+	r.program.Close()
+	r.buffer.Close()
+}
+
+// 读取内核内存（BPF优先，失败时使用 /proc/kcore）
+func readKernelMemory(ctx *DebuggerContext, addr uint64, length int) (*MemoryDump, error) {
+	if err := CheckSafeMode(ctx, "内核内存读取"); err != nil {
+		return nil, err
+	}
+	if StopModeBackend(CurrentBackend(ctx)) {
+		// gdb/kdb后端：通过gdbstub或kdb读取目标内存
+		s, err := ActiveGDB(ctx)
+		if err != nil {
+			return nil, err
+		}
+		data, readable, err := s.client.readMemory(addr, length)
+		if err != nil {
+			return nil, err
+		}
+		return &MemoryDump{Addr: addr, Data: data, Readable: readable, Source: s.Kind, ReadAt: time.Now()}, nil
+	}
+	if remote := RemoteTarget(ctx); remote != nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "mem read 只能读取本机内核，远程目标 %s 不支持", remote.SSH)
+	}
+	dump := &MemoryDump{Addr: addr, Data: make([]byte, length), Readable: make([]bool, length), ReadAt: time.Now()}
+
+	reader, bpfErr := newBPFMemoryReader()
+	if bpfErr == nil {
+		defer reader.Close()
+		dump.Source = "bpf"
+		// 按块对齐读取，一块不会跨页，不可读的页不影响相邻页
+		end := addr + uint64(length)
+		for chunk := addr &^ (memReadChunk - 1); chunk < end; chunk += memReadChunk {
+			data, ok, err := reader.readChunk(chunk)
+			if err != nil {
+				bpfErr = err
+				break
+			}
+			if !ok {
+				continue
+			}
+			for i, b := range data {
+				if a := chunk + uint64(i); a >= addr && a < end {
+					dump.Data[a-addr] = b
+					dump.Readable[a-addr] = true
+				}
+			}
+		}
+		if bpfErr == nil {
+			return dump, nil
+		}
+	}
+
+	kcore, err := elf.Open("/proc/kcore")
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrKcore, "BPF读取失败（%v），也无法打开 /proc/kcore（需要root）: %v", bpfErr, err)
+	}
+	defer kcore.Close()
+	dump.Source = "kcore"
+	end := addr + uint64(length)
+	for start := addr; start < end; {
+		stop := (start | (memReadChunk - 1)) + 1
+		if stop > end {
+			stop = end
+		}
+		if data, err := readKcore(kcore, start, int(stop-start)); err == nil {
+			copy(dump.Data[start-addr:], data)
+			for a := start; a < stop; a++ {
+				dump.Readable[a-addr] = true
+			}
+		}
+		start = stop
+	}
+	return dump, nil
+}
+
+// 解析地址：0x开头的十六进制数、/proc/kallsyms 中的符号或不带0x的十六进制数，可带 +偏移。
+// 加 link: 前缀时按vmlinux中的链接地址（或vmlinux符号）解析，加上KASLR偏移
+func parseMemoryAddress(ctx *DebuggerContext, spec string) (uint64, error) {
+	link := strings.HasPrefix(spec, linkAddressPrefix)
+	spec = strings.TrimPrefix(spec, linkAddressPrefix)
+	base, offset := spec, uint64(0)
+	if plus := strings.LastIndex(spec, "+"); plus > 0 {
+		off, err := strconv.ParseUint(spec[plus+1:], 0, 64)
+		if err != nil {
+			return 0, errcode.Errorf(errcode.ErrInvalidArg, "无效的偏移: %s", spec[plus+1:])
+		}
+		base, offset = spec[:plus], off
+	}
+	number := func(addr uint64) (uint64, error) {
+		if link {
+			runtime, err := linkToRuntime(ctx, addr)
+			return runtime + offset, err
+		}
+		return addr + offset, nil
+	}
+	if strings.HasPrefix(base, "0x") {
+		addr, err := strconv.ParseUint(base[2:], 16, 64)
+		if err != nil {
+			return 0, errcode.Errorf(errcode.ErrInvalidArg, "无效的地址: %s", base)
+		}
+		return number(addr)
+	}
+	if link {
+		if hex, err := strconv.ParseUint(base, 16, 64); err == nil {
+			return number(hex)
+		}
+		addr, err := vmlinuxRuntimeAddress(ctx, base)
+		return addr + offset, err
+	}
+	addr, err := targetSymbolAddress(ctx, base)
+	if err != nil {
+		// 不是符号时按不带0x的地址解析（ffffffffc0a01000）
+		if hex, err := strconv.ParseUint(base, 16, 64); err == nil {
+			return number(hex)
+		}
+		return 0, errcode.Errorf(errcode.ErrNoSymbol, "%s 不是地址，也不是 /proc/kallsyms 中的符号", base)
+	}
+	if addr == 0 {
+		return 0, errcode.Errorf(errcode.ErrPerm, "/proc/kallsyms 中 %s 的地址为0（kptr_restrict，需要root）", base)
+	}
+	return addr + offset, nil
+}
+
+// 执行 mem read，结果显示在内存窗口中（保留当前的每行字节数）
+func MemoryRead(ctx *DebuggerContext, spec string, length int) (*MemoryDump, error) {
+	if length <= 0 || length > maxMemoryRead {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "长度必须在1到%d之间", maxMemoryRead)
+	}
+	addr, err := parseMemoryAddress(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+	if addr+uint64(length) < addr {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "0x%x 开始的 %d 字节超出地址空间", addr, length)
+	}
+	dump, err := readKernelMemory(ctx, addr, length)
+	if err != nil {
+		return nil, err
+	}
+	dump.Width = defaultMemoryWidth
+	if ctx.Memory != nil {
+		dump.Width = ctx.Memory.Width
+	}
+	ctx.Memory = dump
+	ctx.MemScroll = 0
+	return dump, nil
+}
+
+// 修改每行字节数
+func SetMemoryWidth(ctx *DebuggerContext, width int) int {
+	if width < minMemoryWidth {
+		width = minMemoryWidth
+	}
+	if width > maxMemoryWidth {
+		width = maxMemoryWidth
+	}
+	if ctx.Memory != nil {
+		ctx.Memory.Width = width
+	}
+	return width
+}
+
+// hex/ASCII 显示的行
+func MemoryDumpLines(dump *MemoryDump) []string {
+	width := dump.Width
+	if width <= 0 {
+		width = defaultMemoryWidth
+	}
+	lines := make([]string, 0, len(dump.Data)/width+1)
+	for off := 0; off < len(dump.Data); off += width {
+		var hex, ascii strings.Builder
+		for i := off; i < off+width; i++ {
+			if i > off && (i-off)%8 == 0 {
+				hex.WriteString(" ")
+			}
+			switch {
+			case i >= len(dump.Data):
+				hex.WriteString("   ")
+			case !dump.Readable[i]:
+				hex.WriteString("\x1b[90m??\x1b[0m ")
+				ascii.WriteString("\x1b[90m?\x1b[0m")
+			default:
+				b := dump.Data[i]
+				fmt.Fprintf(&hex, "%02x ", b)
+				if b >= 0x20 && b < 0x7f {
+					ascii.WriteByte(b)
+				} else {
+					ascii.WriteString(".")
+				}
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%016x: %s|%s|", dump.Addr+uint64(off), hex.String(), ascii.String()))
+	}
+	return lines
+}
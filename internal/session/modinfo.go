@@ -0,0 +1,241 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 模块版本信息与挂载失败诊断 ==========
+// kprobe挂载失败（ENOENT）时只看到 "No such file or directory" 很难定位，
+// 这里依次检查：符号是否在kallsyms中、模块是否已加载、已加载的模块与编译出的.ko是否同一版本
+// （srcversion）、模块是否为当前内核编译（vermagic）、函数是否可跟踪，给出具体原因。
+
+// 可跟踪函数列表
+var availableFilterFunctionsPaths = []string{
+	"/sys/kernel/tracing/available_filter_functions",
+	"/sys/kernel/debug/tracing/available_filter_functions",
+}
+
+// kprobe黑名单
+const kprobeBlacklistPath = "/sys/kernel/debug/kprobes/blacklist"
+
+// 模块版本信息
+type ModuleInfo struct {
+	Path             string
+	Name             string
+	Vermagic         string // 编译模块时的内核版本与配置
+	Srcversion       string // 模块源码的校验和
+	Loaded           bool   // 是否已加载
+	LoadedSrcversion string // 已加载模块的srcversion
+}
+
+// 从.ko的.modinfo段读取模块信息，并检查同名模块是否已加载
+func ReadModuleInfo(path string) (*ModuleInfo, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取模块 %s: %v", path, err)
+	}
+	defer file.Close()
+
+	info := &ModuleInfo{Path: path}
+	if section := file.Section(".modinfo"); section != nil {
+		data, err := section.Data()
+		if err != nil {
+			return nil, fmt.Errorf("读取.modinfo失败: %v", err)
+		}
+		for _, entry := range bytes.Split(data, []byte{0}) {
+			kv := strings.SplitN(string(entry), "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "name":
+				info.Name = kv[1]
+			case "vermagic":
+				info.Vermagic = kv[1]
+			case "srcversion":
+				info.Srcversion = kv[1]
+			}
+		}
+	}
+	if info.Name == "" {
+		info.Name = strings.Replace(strings.TrimSuffix(filepath.Base(path), ".ko"), "-", "_", -1)
+	}
+
+	sysModule := filepath.Join("/sys/module", info.Name)
+	if _, err := os.Stat(sysModule); err == nil {
+		info.Loaded = true
+		if data, err := ioutil.ReadFile(filepath.Join(sysModule, "srcversion")); err == nil {
+			info.LoadedSrcversion = strings.TrimSpace(string(data))
+		}
+	}
+	return info, nil
+}
+
+// vermagic中的内核版本（第一个字段）
+func vermagicRelease(vermagic string) string {
+	if fields := strings.Fields(vermagic); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+// 模块版本不匹配的问题（为空表示一致）
+func moduleMismatches(info *ModuleInfo) []string {
+	problems := make([]string, 0)
+	if release := vermagicRelease(info.Vermagic); release != "" && release != project.KernelRelease() {
+		problems = append(problems, fmt.Sprintf("vermagic %s does not match the running kernel %s", release, project.KernelRelease()))
+	}
+	if info.Loaded && info.Srcversion != "" && info.LoadedSrcversion != "" && info.Srcversion != info.LoadedSrcversion {
+		problems = append(problems, fmt.Sprintf("loaded srcversion %s differs from the built .ko (%s)", info.LoadedSrcversion, info.Srcversion))
+	}
+	return problems
+}
+
+// 模块信息的显示内容
+func ModuleInfoLines(info *ModuleInfo) []string {
+	loaded := "not loaded"
+	if info.Loaded {
+		loaded = "loaded"
+		if info.LoadedSrcversion != "" {
+			loaded += ", srcversion " + info.LoadedSrcversion
+		}
+	}
+	lines := []string{
+		fmt.Sprintf("Module:     %s (%s)", info.Name, info.Path),
+		fmt.Sprintf("vermagic:   %s", info.Vermagic),
+		fmt.Sprintf("srcversion: %s", info.Srcversion),
+		fmt.Sprintf("Running:    %s (%s)", project.KernelRelease(), loaded),
+	}
+	for _, problem := range moduleMismatches(info) {
+		lines = append(lines, "⚠️  "+problem)
+	}
+	return lines
+}
+
+// 在kallsyms中查找编译器改名的同名函数（foo.isra.0、foo.constprop.0等）
+func kallsymsVariants(name string) []string {
+	file, err := os.Open("/proc/kallsyms")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	variants := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 && strings.HasPrefix(fields[2], name+".") {
+			variants = append(variants, fields[2])
+		}
+	}
+	return variants
+}
+
+// 检查函数是否出现在列表文件中（文件不可读时返回ok=false）
+func fileListsFunction(paths []string, name string) (found bool, ok bool) {
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, field := range strings.Fields(line) {
+				if field == name {
+					return true, true
+				}
+			}
+		}
+		return false, true
+	}
+	return false, false
+}
+
+// 探针挂载失败的诊断：逐项检查并给出最可能的原因
+func DiagnoseAttachFailure(function, modulePath string, attachErr error) []string {
+	lines := []string{fmt.Sprintf("Diagnosis for %s():", function)}
+	if attachErr != nil && IsAttachENOENT(attachErr) {
+		lines = append(lines, "  ENOENT: the kernel could not resolve the probe target")
+	}
+	cause := ""
+	fail := func(message, likely string) {
+		lines = append(lines, "  \x1b[31m✗\x1b[0m "+message)
+		if cause == "" {
+			cause = likely
+		}
+	}
+	pass := func(message string) {
+		lines = append(lines, "  \x1b[32m✓\x1b[0m "+message)
+	}
+	unknown := func(message string) {
+		lines = append(lines, "  \x1b[90m?\x1b[0m "+message)
+	}
+
+	// 模块版本
+	if modulePath == "" {
+		unknown("no built .ko found, module checks skipped")
+	} else if info, err := ReadModuleInfo(modulePath); err != nil {
+		unknown(err.Error())
+	} else {
+		if !info.Loaded {
+			fail(fmt.Sprintf("module %s is not loaded", info.Name), fmt.Sprintf("load the module first: sudo insmod %s", info.Path))
+		} else {
+			pass(fmt.Sprintf("module %s is loaded", info.Name))
+		}
+		if release := vermagicRelease(info.Vermagic); release != "" && release != project.KernelRelease() {
+			fail(fmt.Sprintf("vermagic %s, running kernel %s", release, project.KernelRelease()), "the module was built for a different kernel, rebuild it against the running kernel's headers")
+		} else if release != "" {
+			pass("vermagic matches the running kernel " + release)
+		}
+		switch {
+		case !info.Loaded || info.LoadedSrcversion == "" || info.Srcversion == "":
+			unknown("srcversion not compared")
+		case info.Srcversion != info.LoadedSrcversion:
+			fail(fmt.Sprintf("loaded srcversion %s, built .ko %s", info.LoadedSrcversion, info.Srcversion), "the loaded module is an older build, rmmod and insmod the new .ko")
+		default:
+			pass("loaded module is the same build (srcversion " + info.Srcversion + ")")
+		}
+	}
+
+	// 符号
+	if _, err := project.ReadKallsymsSymbol(function); err == nil {
+		pass(function + " is in /proc/kallsyms")
+	} else if variants := kallsymsVariants(function); len(variants) > 0 {
+		fail(fmt.Sprintf("%s is not in kallsyms, compiler renamed it: %s", function, strings.Join(variants, ", ")), "the function was renamed by the compiler, probe "+variants[0]+" instead")
+	} else {
+		fail(function+" is not in /proc/kallsyms", "the function was inlined or the module providing it is not loaded (mark it noinline to probe it)")
+	}
+
+	// 可跟踪性
+	if found, ok := fileListsFunction(availableFilterFunctionsPaths, function); !ok {
+		unknown("available_filter_functions not readable (root required)")
+	} else if found {
+		pass(function + " is traceable")
+	} else {
+		fail(function+" is not in available_filter_functions", "the function is notrace or inlined")
+	}
+	if found, ok := fileListsFunction([]string{kprobeBlacklistPath}, function); ok && found {
+		fail(function+" is on the kprobe blacklist", "kprobes are not allowed on this function, probe a caller instead")
+	}
+
+	lines = append(lines, "")
+	if cause != "" {
+		lines = append(lines, "Likely cause: "+cause)
+	} else {
+		lines = append(lines, "All checks passed, see 'why E_BPF_ATTACH' for other causes")
+	}
+	return lines
+}
+
+// 判断挂载错误是否为ENOENT
+func IsAttachENOENT(err error) bool {
+	text := strings.ToLower(err.Error())
+	return strings.Contains(text, "no such file") || strings.Contains(text, "enoent")
+}
@@ -0,0 +1,103 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 函数大纲 ==========
+// Ctrl+O 或 outline 命令列出当前文件中的函数（来自 tree-sitter 解析，见 project/cparse.go）：起始行、
+// 参数、行数，已设置断点的函数前标记 ●（全部禁用时为 ○）和断点数，代码窗口所在的函数标记 ▶。
+// Enter 或 1-9 跳到函数定义，跳转前的位置记为标记 '（'' 跳回）。
+
+// 函数中的断点数（启用的、全部）
+func FunctionBreakpoints(ctx *DebuggerContext, file string, fn project.CFunction) (int, int) {
+	enabled, total := 0, 0
+	for _, bp := range ctx.Project.Breakpoints {
+		if bp.File != file || bp.Line < fn.StartLine || bp.Line > fn.EndLine {
+			continue
+		}
+		total++
+		if bp.Enabled {
+			enabled++
+		}
+	}
+	return enabled, total
+}
+
+// 大纲中的一行
+func outlineLine(ctx *DebuggerContext, file string, fn project.CFunction, current bool) string {
+	marker := " "
+	if current {
+		marker = Styled(ActiveTheme.Selected, "▶")
+	}
+	bpMarker, bpNote := " ", ""
+	if enabled, total := FunctionBreakpoints(ctx, file, fn); total > 0 {
+		bpMarker = Styled(ActiveTheme.BreakpointOff, "○")
+		if enabled > 0 {
+			bpMarker = Styled(ActiveTheme.Breakpoint, "●")
+		}
+		bpNote = fmt.Sprintf("  %d bp", total)
+		if total > 1 {
+			bpNote += "s"
+		}
+	}
+	params := make([]string, 0, len(fn.Params))
+	for _, p := range fn.Params {
+		params = append(params, p.Name)
+	}
+	signature := fmt.Sprintf("%s(%s)", fn.Name, strings.Join(params, ", "))
+	return fmt.Sprintf("%s%s %5d  %s %s%s", marker, bpMarker, fn.StartLine, project.FitWidth(signature, 48),
+		Styled(ActiveTheme.Dim, fmt.Sprintf("%4d lines", fn.EndLine-fn.StartLine+1)), bpNote)
+}
+
+// 显示当前文件的函数大纲，返回函数数
+func ShowOutlinePopup(ui UI, ctx *DebuggerContext) (int, error) {
+	if ctx.Project == nil || ctx.Project.CurrentFile == "" {
+		return 0, fmt.Errorf("代码窗口没有打开文件")
+	}
+	file := ctx.Project.CurrentFile
+	functions, err := project.ParseCSource(file)
+	if err != nil {
+		return 0, err
+	}
+	cursorLine := ctx.CodeScroll + 1
+	if ui != nil {
+		_, cursorLine, _ = CurrentCodeLocation(ui, ctx)
+	}
+
+	content := make([]string, 0, len(functions)+2)
+	for _, fn := range functions {
+		current := cursorLine >= fn.StartLine && cursorLine <= fn.EndLine
+		content = append(content, outlineLine(ctx, file, fn, current))
+	}
+	if len(functions) == 0 {
+		content = append(content, "No functions in this file")
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, "Enter/1-9: jump to the function ('' jumps back) | ● has breakpoints"))
+
+	ClosePopupWindow(ctx, "outline")
+	popup := CreatePopupWindow(ctx, "outline", fmt.Sprintf("Outline: %s (%d functions)", project.ProjectRelativePath(ctx.Project, file), len(functions)), 90, 25, content)
+	jump := func(ui UI, index int) error {
+		if index < 0 || index >= len(functions) {
+			return nil
+		}
+		fn := functions[index]
+		ClosePopupWindow(ctx, "outline")
+		if err := jumpWithMark(ui, ctx, file, fn.StartLine); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[OUTLINE] %s() at line %d", fn.Name, fn.StartLine))
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	popup.OnSelect = jump
+	popup.OnDigit = func(ui UI, n int) error {
+		return jump(ui, n-1)
+	}
+	ShowPopupWindow(ctx, popup)
+	return len(functions), nil
+}
@@ -0,0 +1,30 @@
+package session_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/testsupport"
+)
+
+func TestFunctionBreakpoints(t *testing.T) {
+	ctx := testsupport.NewProject(t,
+		project.Breakpoint{File: "drv.c", Line: 5, Enabled: false},
+		project.Breakpoint{File: "drv.c", Line: 6, Enabled: true},
+		project.Breakpoint{File: "drv.c", Line: 11, Enabled: false},
+	)
+	file := filepath.Join(ctx.Project.RootPath, "drv.c")
+	functions, err := project.ParseCSource(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{"scale": {1, 2}, "do_work": {0, 1}}
+	for _, fn := range functions {
+		enabled, total := session.FunctionBreakpoints(ctx, file, fn)
+		if w, ok := want[fn.Name]; ok && (enabled != w[0] || total != w[1]) {
+			t.Errorf("FunctionBreakpoints(%s) = %d, %d, want %d, %d", fn.Name, enabled, total, w[0], w[1])
+		}
+	}
+}
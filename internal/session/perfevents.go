@@ -0,0 +1,267 @@
+package session
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/perf"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== perf buffer事件 ==========
+// 生成的BPF程序把每次命中、每个变量和返回值填入 struct debug_event，
+// 用bpf_perf_event_output写入perf buffer（debug_events）。bpf load 进程内加载时
+// 由这里读取并按固定布局解码为DebugEvent，直接进入事件/变量/调用栈窗口，
+// 同时把生成代码中的 debug_use_printk 改为0，不再经过trace_pipe和文本解析。
+// 通过脚本（bpftool）加载时perf buffer不归调试器所有，仍使用bpf_printk输出。
+
+// perf buffer map名称和bpf_printk开关（与生成的BPF代码一致）
+const (
+	debugEventsMap = "debug_events"
+	printkSwitch   = "debug_use_printk"
+)
+
+// struct debug_event 的布局（生成的BPF代码与解码共用）
+const (
+	debugEventSize      = 256
+	debugEventFuncLen   = 64
+	debugEventLocLen    = 64
+	debugEventVarLen    = 64
+	debugEventCommLen   = 16
+	debugEventCommOff   = 40
+	debugEventFuncOff   = 56
+	debugEventLocOff    = 120
+	debugEventVarOff    = 184
+	debugEventValueOff  = 32
+	debugEventStackOff  = 248
+	debugEventKindBP    = 1
+	debugEventKindVar   = 2
+	debugEventKindRet   = 3
+	debugEventKindSpan  = 4
+	debugEventKindLock  = 5
+	debugEventKindSleep = 6
+	DebugEventUnsigned  = 2
+	perfBufferPageCount = 64
+)
+
+// 生成的BPF代码：事件结构、perf buffer和可关闭的bpf_printk
+func WriteDebugEventDecl(file *os.File) {
+	fmt.Fprintln(file, "// 调试事件结构（布局与调试器的解码一致，不要调整字段顺序）")
+	fmt.Fprintf(file, "#define DEBUG_EVENT_BREAKPOINT %d\n", debugEventKindBP)
+	fmt.Fprintf(file, "#define DEBUG_EVENT_VAR %d\n", debugEventKindVar)
+	fmt.Fprintf(file, "#define DEBUG_EVENT_RETURN %d\n", debugEventKindRet)
+	fmt.Fprintf(file, "#define DEBUG_EVENT_SPAN %d\n", debugEventKindSpan)
+	fmt.Fprintf(file, "#define DEBUG_EVENT_LOCK %d\n", debugEventKindLock)
+	fmt.Fprintf(file, "#define DEBUG_EVENT_ATOMIC_SLEEP %d\n", debugEventKindSleep)
+	fmt.Fprintln(file, "struct debug_event {")
+	fmt.Fprintln(file, "    u32 pid;")
+	fmt.Fprintln(file, "    u32 tgid;")
+	fmt.Fprintln(file, "    u64 timestamp;")
+	fmt.Fprintln(file, "    u32 breakpoint_id;  // 与 [BREAKPOINT-N] 的N相同")
+	fmt.Fprintln(file, "    u32 kind;           // DEBUG_EVENT_*")
+	fmt.Fprintln(file, "    u32 cpu;")
+	fmt.Fprintf(file, "    u32 var_type;       // 1=有符号 %d=无符号\n", DebugEventUnsigned)
+	fmt.Fprintln(file, "    long long var_value;")
+	fmt.Fprintf(file, "    char comm[%d];\n", debugEventCommLen)
+	fmt.Fprintf(file, "    char function[%d];\n", debugEventFuncLen)
+	fmt.Fprintf(file, "    char location[%d];  // file.c:42\n", debugEventLocLen)
+	fmt.Fprintf(file, "    char var_name[%d];\n", debugEventVarLen)
+	fmt.Fprintf(file, "    int stack_id;       // %s 中的内核调用栈，没有时为负数\n", DebugStacksMap)
+	fmt.Fprintln(file, "};")
+	fmt.Fprintln(file, "")
+	writeStackMapDecl(file)
+	fmt.Fprintln(file, "// 结构化事件（bpf load 时由调试器读取）")
+	fmt.Fprintln(file, "struct {")
+	fmt.Fprintln(file, "    __uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);")
+	fmt.Fprintln(file, "    __uint(key_size, sizeof(u32));")
+	fmt.Fprintln(file, "    __uint(value_size, sizeof(u32));")
+	fmt.Fprintf(file, "} %s SEC(\".maps\");\n", debugEventsMap)
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "// 脚本加载时输出到trace_pipe；调试器进程内加载时改为0，只走perf buffer")
+	fmt.Fprintf(file, "volatile const u32 %s = 1;\n", printkSwitch)
+	fmt.Fprintf(file, "#define debug_printk(fmt, ...) do { if (%s) bpf_printk(fmt, ##__VA_ARGS__); } while (0)\n", printkSwitch)
+	fmt.Fprintln(file, "")
+}
+
+// 生成的BPF代码：把event写入perf buffer
+func WriteDebugEventOutput(file *os.File, indent string) {
+	fmt.Fprintf(file, "%sbpf_perf_event_output(ctx, &%s, BPF_F_CURRENT_CPU, &event, sizeof(event));\n", indent, debugEventsMap)
+}
+
+// 生成的BPF代码：把一个变量值作为 DEBUG_EVENT_VAR 事件输出（value为C表达式）
+func WriteDebugVarOutput(file *os.File, indent, name, value string, signed bool) {
+	varType := 1
+	if !signed {
+		varType = DebugEventUnsigned
+	}
+	fmt.Fprintf(file, "%sevent.kind = DEBUG_EVENT_VAR;\n", indent)
+	fmt.Fprintf(file, "%sevent.var_type = %d;\n", indent, varType)
+	fmt.Fprintf(file, "%sevent.var_value = (long long)%s;\n", indent, value)
+	fmt.Fprintf(file, "%sbpf_probe_read_str(&event.var_name, sizeof(event.var_name), \"%s\");\n", indent, name)
+	WriteDebugEventOutput(file, indent)
+}
+
+// 以0结尾的C字符串
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// 解码perf buffer中的一条 struct debug_event
+func decodeDebugEvent(sample []byte) (DebugEvent, error) {
+	// 没有stack_id的旧目标文件也能解码
+	if len(sample) < debugEventStackOff {
+		return DebugEvent{}, fmt.Errorf("事件记录长度不足: %d字节", len(sample))
+	}
+	ne := binary.NativeEndian
+	event := DebugEvent{
+		Time:         time.Now(),
+		PID:          int(ne.Uint32(sample[0:4])),
+		TGID:         int(ne.Uint32(sample[4:8])),
+		TraceTime:    float64(ne.Uint64(sample[8:16])) / 1e9,
+		BreakpointID: int(ne.Uint32(sample[16:20])),
+		CPU:          int(ne.Uint32(sample[24:28])),
+		Comm:         cString(sample[debugEventCommOff : debugEventCommOff+debugEventCommLen]),
+		Function:     cString(sample[debugEventFuncOff : debugEventFuncOff+debugEventFuncLen]),
+	}
+	raw := ne.Uint64(sample[debugEventValueOff : debugEventValueOff+8])
+	value := strconv.FormatInt(int64(raw), 10)
+	if ne.Uint32(sample[28:32]) == DebugEventUnsigned {
+		value = strconv.FormatUint(raw, 10)
+	}
+	name := cString(sample[debugEventVarOff : debugEventVarOff+debugEventVarLen])
+
+	// Raw保持与trace_pipe行相同的形式（事件详情和导出沿用）
+	var msg string
+	switch kind := ne.Uint32(sample[20:24]); kind {
+	case debugEventKindBP:
+		event.Kind = "breakpoint"
+		event.Location = cString(sample[debugEventLocOff : debugEventLocOff+debugEventLocLen])
+		msg = fmt.Sprintf("[BREAKPOINT-%d] %s in %s() PID=%d TGID=%d", event.BreakpointID, event.Location, event.Function, event.PID, event.TGID)
+	case debugEventKindVar:
+		event.Kind = "var"
+		event.Values = []project.EventValue{{Name: name, Value: value}}
+		msg = fmt.Sprintf("[VAR-%d] %s:%s=%s PID=%d", event.BreakpointID, event.Function, name, value, event.PID)
+	case debugEventKindRet:
+		event.Kind = "return"
+		event.Values = []project.EventValue{{Name: "return", Value: value}}
+		msg = fmt.Sprintf("[RETVAL-%d] %s=%s PID=%d", event.BreakpointID, event.Function, value, event.PID)
+	case debugEventKindSpan:
+		event.Kind = "span"
+		event.Values = []project.EventValue{{Name: "duration_ns", Value: value}}
+		msg = fmt.Sprintf("[SPAN-%d] %s %sns PID=%d", event.BreakpointID, event.Function, value, event.PID)
+	case debugEventKindLock:
+		event.Kind = "lock"
+		decodeLockInfo(&event, sample[debugEventVarOff:debugEventVarOff+debugEventVarLen], value)
+		msg = fmt.Sprintf("[LOCK-%d] caller=%s wait=%s hold=%s", event.BreakpointID, event.Values[0].Value, event.Values[1].Value, value)
+	case debugEventKindSleep:
+		event.Kind = "atomic-sleep"
+		decodeLockInfo(&event, sample[debugEventVarOff:debugEventVarOff+debugEventVarLen], value)
+		msg = fmt.Sprintf("[SLEEP-ATOMIC] caller=%s depth=%s PID=%d", event.Values[0].Value, value, event.PID)
+	default:
+		return DebugEvent{}, fmt.Errorf("未知的事件类型: %d", kind)
+	}
+	event.Raw = fmt.Sprintf("%s-%d [%03d] %.6f: perf: %s", event.Comm, event.PID, event.CPU, event.TraceTime, msg)
+	return event, nil
+}
+
+// 没有内核调用栈时，断点命中的位置作为调用栈的栈顶
+func hitStackFrame(ctx *DebuggerContext, event DebugEvent) []StackFrame {
+	if bp := breakpointForEvent(ctx, event); bp != nil {
+		return []StackFrame{{Function: event.Function, File: bp.File, Line: bp.Line}}
+	}
+	return []StackFrame{{Function: event.Function}}
+}
+
+// 启动perf buffer读取协程（BPF目标文件中没有debug_events时返回nil，事件仍来自trace_pipe）
+func startPerfEventReader(ui UI, ctx *DebuggerContext, coll *ebpf.Collection) (io.Closer, error) {
+	m := coll.Maps[debugEventsMap]
+	if m == nil {
+		return nil, nil
+	}
+	stacks, stackErr := newStackResolver(ctx, coll)
+	if stackErr != nil {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %v", stackErr))
+	}
+	reader, err := perf.NewReader(m, perfBufferPageCount*os.Getpagesize())
+	if err != nil {
+		return nil, fmt.Errorf("打开perf buffer失败: %v", err)
+	}
+	// 记录按读取顺序成批交给UI线程（丢失计数也在队列中，保持与事件的先后）
+	batcher := newUpdateBatcher(ui, func(ui UI, records []perfRecord) {
+		for _, r := range records {
+			if r.lost > 0 {
+				// 内核侧的perf缓冲区写满时丢失的样本也计入丢弃数
+				ctx.EventsDropped += r.lost
+				continue
+			}
+			if r.err != nil {
+				ctx.EventsUnparsed++
+				continue
+			}
+			// 调用栈随事件保存，时间线选中该事件时显示
+			event := r.event
+			event.Stack = r.frames
+			AppendEvent(ctx, event)
+			if event.Kind == "breakpoint" {
+				ctx.CurrentFunc = event.Function
+				frames := r.frames
+				if len(frames) == 0 {
+					frames = hitStackFrame(ctx, event)
+				}
+				ctx.StackFrames = frames
+			}
+			recordEventLatency(ctx, time.Since(r.readAt))
+		}
+		RefreshEventsPopup(ctx)
+		RefreshStatsPopup(ctx)
+	})
+	go func() {
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				// bpf unload 关闭reader后退出
+				return
+			}
+			if record.LostSamples > 0 {
+				batcher.add(perfRecord{lost: int(record.LostSamples)})
+				continue
+			}
+			r := perfRecord{readAt: time.Now()}
+			r.event, r.err = decodeDebugEvent(record.RawSample)
+			if r.err == nil && (r.event.Kind == "breakpoint" || r.event.Kind == "atomic-sleep") && stacks != nil {
+				// 在读取协程中解析，kallsyms查找和行号表不占用UI线程
+				r.frames = stacks.frames(debugEventStackID(record.RawSample))
+			}
+			batcher.add(r)
+		}
+	}()
+	return reader, nil
+}
+
+// perf buffer中读到的一条记录（解码结果或丢失的样本数）
+type perfRecord struct {
+	event  DebugEvent
+	frames []StackFrame
+	err    error
+	lost   int
+	readAt time.Time
+}
+
+// bpf status 中perf buffer的说明
+func perfEventStatus(loaded *LoadedBPF) string {
+	if loaded.eventsReader == nil {
+		return fmt.Sprintf("Events: bpf_printk via trace_pipe ('events start'; %s has no %s map)", filepath.Base(loaded.Object), debugEventsMap)
+	}
+	return fmt.Sprintf("Events: perf buffer %s (bpf_printk off, no 'events start' needed)", debugEventsMap)
+}
@@ -0,0 +1,280 @@
+package session
+
+import (
+	"debug/elf"
+	"fmt"
+	"sort"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 探针目标可用性检查 ==========
+// generate/vars 生成探针前（以及 bp check）逐个检查断点函数能否挂载kprobe：
+// 在kprobe黑名单中、不在kallsyms中（编译器内联或改名为 foo.isra.0 / foo.constprop.0）、
+// 所在模块没有加载。结果显示在断点窗口中并在命令窗口给出警告，附上可以替代的符号：
+// 改名后的函数、以及内联时源码中调用它的函数（.ko中有独立的符号）。
+// 符号来自目标的 /proc/kallsyms（远程目标经ssh读取）和项目模块.ko的符号表。
+
+// 可用性检查的结果
+const (
+	ProbeAvailable   = "ok"
+	probeBlacklisted = "blacklisted"
+	probeRenamed     = "renamed"
+	probeInlined     = "inlined"
+	probeNotLoaded   = "module not loaded"
+	probeAbsent      = "absent"
+)
+
+// 一个断点函数的检查结果
+type ProbeCheck struct {
+	Function     string
+	Status       string   // 见上面的常量
+	Detail       string   // 原因说明
+	Alternatives []string // 可以替代的符号（最可能的在前）
+}
+
+// 替代符号最多显示的个数
+const maxProbeAlternatives = 4
+
+// 一次检查用到的符号数据（每次 generate 只读取一次）
+type probeChecker struct {
+	ctx       *DebuggerContext
+	loaded    bool
+	kallsyms  map[string]string // 函数名 -> 所在模块（内核本身为空）
+	symsErr   error
+	blacklist map[string]bool // 为nil表示黑名单不可读
+	module    string          // 项目模块在kallsyms中的名称
+	modLoaded bool            // 项目模块的符号是否在kallsyms中
+	moduleSym map[string]bool // 项目模块.ko中的函数符号（为nil表示没有.ko）
+	callers   map[string][]string
+}
+
+func NewProbeChecker(ctx *DebuggerContext) *probeChecker {
+	return &probeChecker{ctx: ctx}
+}
+
+// 读取kallsyms、黑名单和模块符号表
+func (c *probeChecker) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	if table, err := loadTargetKallsymsTable(c.ctx); err != nil {
+		c.symsErr = err
+	} else {
+		c.kallsyms = make(map[string]string, len(table.syms))
+		for _, sym := range table.syms {
+			c.kallsyms[sym.name] = sym.module
+		}
+	}
+	// 格式: 0xffffffff81000000-0xffffffff81000010	func_name
+	if data, err := readTargetFile(c.ctx, kprobeBlacklistPath); err == nil {
+		c.blacklist = make(map[string]bool)
+		for _, line := range strings.Split(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				c.blacklist[fields[len(fields)-1]] = true
+			}
+		}
+	}
+	if module := project.FindProjectModule(c.ctx.Project.RootPath); module != "" {
+		c.module = kallsymsModuleName(module)
+		for _, owner := range c.kallsyms {
+			if owner == c.module {
+				c.modLoaded = true
+				break
+			}
+		}
+		if file, err := elf.Open(module); err == nil {
+			if syms, err := file.Symbols(); err == nil {
+				c.moduleSym = make(map[string]bool)
+				for _, sym := range syms {
+					if elf.ST_TYPE(sym.Info) == elf.STT_FUNC && sym.Section != elf.SHN_UNDEF {
+						c.moduleSym[sym.Name] = true
+					}
+				}
+			}
+			file.Close()
+		}
+	}
+}
+
+// 编译器生成的同名函数（foo.isra.0、foo.constprop.0、foo.part.0，foo.cold排在最后）
+func compilerVariants(name string, symbols map[string]bool) []string {
+	variants := make([]string, 0)
+	for sym := range symbols {
+		if strings.HasPrefix(sym, name+".") {
+			variants = append(variants, sym)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool {
+		ci, cj := strings.Contains(variants[i], ".cold"), strings.Contains(variants[j], ".cold")
+		if ci != cj {
+			return cj
+		}
+		return variants[i] < variants[j]
+	})
+	return variants
+}
+
+// 源码中调用该函数的函数（按源码扫描，内联后只能在调用者中挂载探针）
+func (c *probeChecker) sourceCallers(name string) []string {
+	if c.callers == nil {
+		c.callers = make(map[string][]string)
+		for caller, def := range indexProjectFunctions(c.ctx.Project.RootPath) {
+			for _, callee := range sourceCallees(def) {
+				c.callers[callee] = append(c.callers[callee], caller)
+			}
+		}
+	}
+	callers := append([]string(nil), c.callers[name]...)
+	sort.Strings(callers)
+	return callers
+}
+
+// 是否有可以挂载的符号（模块还没有加载时看.ko的符号表）
+func (c *probeChecker) probeable(name string) bool {
+	if c.blacklist[name] {
+		return false
+	}
+	if _, ok := c.kallsyms[name]; ok {
+		return true
+	}
+	return !c.modLoaded && c.moduleSym[name]
+}
+
+// 检查一个函数
+func (c *probeChecker) check(function string) *ProbeCheck {
+	c.load()
+	result := &ProbeCheck{Function: function, Status: ProbeAvailable}
+	if c.blacklist[function] {
+		result.Status = probeBlacklisted
+		result.Detail = "on the kprobe blacklist, kprobes are not allowed here"
+		result.Alternatives = c.filterProbeable(c.sourceCallers(function))
+		return result
+	}
+	if c.kallsyms != nil {
+		if _, ok := c.kallsyms[function]; ok {
+			return result
+		}
+		kallsymsNames := make(map[string]bool)
+		for name := range c.kallsyms {
+			if strings.HasPrefix(name, function+".") {
+				kallsymsNames[name] = true
+			}
+		}
+		if variants := compilerVariants(function, kallsymsNames); len(variants) > 0 {
+			result.Status = probeRenamed
+			result.Detail = "not in kallsyms, the compiler renamed it"
+			result.Alternatives = variants
+			return result
+		}
+	}
+	switch {
+	case c.moduleSym == nil && c.kallsyms == nil:
+		// 既没有kallsyms也没有.ko，无法判断
+		result.Status = ProbeAvailable
+		result.Detail = "not checked: " + c.symsErr.Error()
+	case c.moduleSym == nil:
+		result.Status = probeAbsent
+		result.Detail = "not in kallsyms (no built .ko to check for inlining)"
+	case c.moduleSym[function]:
+		if c.kallsyms == nil {
+			result.Detail = "not checked against kallsyms: " + c.symsErr.Error()
+			return result
+		}
+		result.Status = probeNotLoaded
+		result.Detail = fmt.Sprintf("in the .ko but not in kallsyms, load module %s first", c.module)
+	default:
+		if variants := compilerVariants(function, c.moduleSym); len(variants) > 0 {
+			result.Status = probeRenamed
+			result.Detail = "the compiler renamed it in the .ko"
+			result.Alternatives = variants
+			return result
+		}
+		result.Status = probeInlined
+		result.Detail = "no out-of-line copy in the .ko, inlined into its callers"
+		result.Alternatives = c.filterProbeable(c.sourceCallers(function))
+	}
+	return result
+}
+
+// 只保留可以挂载的符号
+func (c *probeChecker) filterProbeable(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if c.probeable(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// 检查结果的一行描述
+func (p *ProbeCheck) Summary() string {
+	text := fmt.Sprintf("%s: %s", p.Status, p.Detail)
+	if len(p.Alternatives) > 0 {
+		alternatives := p.Alternatives
+		if len(alternatives) > maxProbeAlternatives {
+			alternatives = alternatives[:maxProbeAlternatives]
+		}
+		text += "; try " + strings.Join(alternatives, ", ")
+	}
+	return text
+}
+
+// 检查断点函数并记录结果（用户态探针不检查），不可用时在命令窗口给出警告
+func CheckGeneratedProbe(ctx *DebuggerContext, c *probeChecker, bp project.Breakpoint) *ProbeCheck {
+	if bp.Binary != "" || bp.Function == "" || bp.Function == "unknown" {
+		return nil
+	}
+	// 同一函数上的多个断点只检查和警告一次
+	if result := ctx.ProbeChecks[bp.Function]; result != nil {
+		return result
+	}
+	result := c.check(bp.Function)
+	if ctx.ProbeChecks == nil {
+		ctx.ProbeChecks = make(map[string]*ProbeCheck)
+	}
+	ctx.ProbeChecks[bp.Function] = result
+	if result.Status != ProbeAvailable {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %s() %s", bp.Function, result.Summary()))
+		ctx.CommandDirty = true
+	}
+	return result
+}
+
+// bp check：检查所有启用的内核断点，返回报告
+func CheckBreakpointProbes(ctx *DebuggerContext) []string {
+	c := NewProbeChecker(ctx)
+	ctx.ProbeChecks = make(map[string]*ProbeCheck)
+	lines := make([]string, 0)
+	problems := 0
+	seen := make(map[string]bool)
+	for _, bp := range ctx.Project.Breakpoints {
+		if !bp.Enabled || bp.Binary != "" || bp.Function == "" || bp.Function == "unknown" || seen[bp.Function] {
+			continue
+		}
+		seen[bp.Function] = true
+		result := c.check(bp.Function)
+		ctx.ProbeChecks[bp.Function] = result
+		if result.Status == ProbeAvailable {
+			line := "✅ " + bp.Function
+			if result.Detail != "" {
+				line += " (" + result.Detail + ")"
+			}
+			lines = append(lines, line)
+			continue
+		}
+		problems++
+		lines = append(lines, fmt.Sprintf("⚠️  %s: %s", bp.Function, result.Summary()))
+	}
+	if len(seen) == 0 {
+		return []string{"No enabled kernel breakpoints to check"}
+	}
+	header := fmt.Sprintf("Probe check: %d functions, %d not probeable", len(seen), problems)
+	if c.blacklist == nil {
+		header += " (kprobe blacklist not readable)"
+	}
+	return append([]string{header}, lines...)
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"io"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 探针计划与采集后端 ==========
+
+// 断点是否参与编号（启用且知道所在函数）
+func Probeable(bp project.Breakpoint) bool {
+	return bp.Enabled && bp.Function != "" && bp.Function != "unknown"
+}
+
+// 按事件中的编号找到断点（编号规则与生成的程序相同）
+func ArmedBreakpoint(ctx *DebuggerContext, id int) *project.Breakpoint {
+	if ctx.Project == nil {
+		return nil
+	}
+	n := 0
+	for i, bp := range ctx.Project.Breakpoints {
+		if !Probeable(bp) {
+			continue
+		}
+		n++
+		if n == id {
+			return &ctx.Project.Breakpoints[i]
+		}
+	}
+	return nil
+}
+
+// 断点在事件中的编号（未启用或没有函数时为0）
+func ArmedBreakpointID(ctx *DebuggerContext, target *project.Breakpoint) int {
+	n := 0
+	for i, bp := range ctx.Project.Breakpoints {
+		if !Probeable(bp) {
+			continue
+		}
+		n++
+		if &ctx.Project.Breakpoints[i] == target {
+			return n
+		}
+	}
+	return 0
+}
+
+// ========== 采集后端 ==========
+
+// 事件采集后端
+type captureBackend interface {
+	// 打开事件源（布防探针、启动进程），返回事件源和显示用的描述
+	Start(ctx *DebuggerContext) (io.ReadCloser, string, error)
+	// 识别后端自己的输出行（不是断点事件格式的行），没有识别时返回false
+	HandleLine(ctx *DebuggerContext, line string) bool
+	// 结束采集时撤销后端的设置
+	Stop(ctx *DebuggerContext)
+}
+
+// 已注册的采集后端（生成器所在的代码在 init 中注册）
+var CaptureBackends = map[string]captureBackend{}
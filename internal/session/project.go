@@ -0,0 +1,96 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"path/filepath"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 项目管理功能 ==========
+
+// 添加断点
+func AddBreakpoint(ctx *DebuggerContext, file string, line int) {
+	if ctx.Project == nil {
+		return
+	}
+	
+	// 记录到操作日志
+	RecordOperation(ctx, fmt.Sprintf("bp toggle %s:%d", project.ProjectRelativePath(ctx.Project, file), line))
+	
+	// 检查断点是否已存在
+	for i, bp := range ctx.Project.Breakpoints {
+		if bp.File == file && bp.Line == line {
+			ctx.Project.Breakpoints[i].Enabled = !ctx.Project.Breakpoints[i].Enabled
+			// 保存断点到文件
+			if err := project.SaveBreakpoints(ctx.Project); err != nil {
+				// 在命令历史中记录错误
+				ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[ERROR] Failed to save breakpoints: %v", err))
+				ctx.CommandDirty = true
+			}
+			return
+		}
+	}
+	
+	// 解析函数名
+	functionName := project.ParseFunctionName(file, line)
+	if functionName == "" {
+		functionName = "unknown"
+	}
+	
+	// 添加新断点
+	bp := project.Breakpoint{
+		File:     file,
+		Line:     line,
+		Function: functionName, // 使用解析出的函数名
+		Enabled:  true,
+	}
+	// 模块已编译时用DWARF行号表确定函数和指令偏移（失败时保留源码扫描的结果）
+	ResolveBreakpointProbe(ctx, &bp)
+	ctx.Project.Breakpoints = append(ctx.Project.Breakpoints, bp)
+	TouchWorkingSet(ctx, file, line, "breakpoint")
+	
+	// 保存断点到文件
+	if err := project.SaveBreakpoints(ctx.Project); err != nil {
+		// 在命令历史中记录错误
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[ERROR] Failed to save breakpoints: %v", err))
+		ctx.CommandDirty = true
+	}
+}
+
+// 设置断点备注（n从1开始，空文本表示清除）
+func SetBreakpointNote(ctx *DebuggerContext, n int, note string) error {
+	if n < 1 || n > len(ctx.Project.Breakpoints) {
+		return fmt.Errorf("断点编号超出范围: %d (共%d个)", n, len(ctx.Project.Breakpoints))
+	}
+	// 备注只占一行，去掉两端的引号
+	note = strings.Join(strings.Fields(note), " ")
+	if len(note) >= 2 && (note[0] == '"' || note[0] == '\'') && note[len(note)-1] == note[0] {
+		note = note[1 : len(note)-1]
+	}
+	ctx.Project.Breakpoints[n-1].Note = note
+	return project.SaveBreakpoints(ctx.Project)
+}
+
+// 设置断点是否报告返回值（n从1开始）
+func SetBreakpointRetVal(ctx *DebuggerContext, n int, enabled bool) error {
+	if n < 1 || n > len(ctx.Project.Breakpoints) {
+		return fmt.Errorf("断点编号超出范围: %d (共%d个)", n, len(ctx.Project.Breakpoints))
+	}
+	ctx.Project.Breakpoints[n-1].RetVal = enabled
+	return project.SaveBreakpoints(ctx.Project)
+}
+
+// 事件对应的断点（按 file:line 匹配）
+func breakpointForEvent(ctx *DebuggerContext, event DebugEvent) *project.Breakpoint {
+	if ctx.Project == nil || event.Location == "" {
+		return nil
+	}
+	for i, bp := range ctx.Project.Breakpoints {
+		if fmt.Sprintf("%s:%d", filepath.Base(bp.File), bp.Line) == event.Location {
+			return &ctx.Project.Breakpoints[i]
+		}
+	}
+	return nil
+}
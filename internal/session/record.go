@@ -0,0 +1,398 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 录制与回放 ==========
+// record start 把之后的每次断点命中（含返回值和硬件断点）整理成一帧写入 .frames 文件：
+// 命中的源码行、这次采集的变量、该时刻每个变量最后一次的值、寄存器和调用栈。
+// 变量事件和寄存器记录会晚于命中到达，所以命中在 recordFrameDelay 之后才写成帧，record stop 时全部写出。
+// 文件每行一个JSON：第一行是文件头，之后每行一帧，录制中途退出也能读到已写出的帧。
+// replay <file> 载入帧文件，F9/F10（replay prev/next）逐帧移动：代码窗口跳到该帧的源码行，
+// 寄存器、变量和调用栈窗口显示该帧记录的数据；没有载入帧文件时F9/F10在时间线上逐个选中实时事件。
+
+// 帧文件格式标识
+const framesFormat = "kdebug-frames"
+
+// 帧文件格式版本
+const framesVersion = 1
+
+// 默认帧文件名（项目根目录下）
+const defaultFramesFile = ".debug_recording.frames"
+
+// 命中之后等待变量和寄存器到达的时间
+const recordFrameDelay = 500 * time.Millisecond
+
+// 帧文件头
+type framesHeader struct {
+	Format  string
+	Version int
+	Project string `json:",omitempty"`
+	Arch    string `json:",omitempty"`
+	Started time.Time
+}
+
+// 一帧：某次命中时刻的完整状态
+type RecordedFrame struct {
+	Index        int // 帧序号（从1开始）
+	Seq          int // 录制时的事件序号
+	Time         time.Time
+	TraceTime    float64 `json:",omitempty"`
+	Kind         string
+	BreakpointID int
+	Function     string
+	File         string `json:",omitempty"`
+	Line         int    `json:",omitempty"`
+	PID          int
+	Comm         string `json:",omitempty"`
+	CPU          int
+	Values       []project.EventValue `json:",omitempty"` // 这次命中采集到的值
+	Variables    []project.EventValue `json:",omitempty"` // 该时刻每个变量最后一次的值
+	Registers    *RegisterSnapshot    `json:",omitempty"`
+	Stack        []StackFrame         `json:",omitempty"`
+	Summary      string               // 事件列表中的显示
+}
+
+// 录制状态
+type RecordingState struct {
+	Path    string
+	File    *os.File
+	Started time.Time
+	LastSeq int               // 已处理到的事件序号
+	Frames  int               // 已写出的帧数
+	Latest  map[string]string // 每个变量最后一次的值
+	Order   []string          // 变量第一次出现的顺序
+	Stop    chan struct{}
+}
+
+// 回放状态
+type ReplayState struct {
+	Path    string
+	Header  framesHeader
+	Frames  []RecordedFrame
+	Current int // 当前帧的下标
+}
+
+// 会产生帧的事件
+func frameEventKind(kind string) bool {
+	return kind == "breakpoint" || kind == "return" || kind == "hwbp"
+}
+
+// ========== 录制 ==========
+
+// 开始录制（path为空时写到项目根目录下的默认文件）
+func StartRecording(ui UI, ctx *DebuggerContext, path string) (string, error) {
+	if ctx.Recording != nil {
+		return "", errcode.Errorf(errcode.ErrInvalidArg, "已在录制到 %s，请先 record stop", ctx.Recording.Path)
+	}
+	if path == "" {
+		if ctx.Project == nil {
+			return "", errcode.Errorf(errcode.ErrNoProject, "没有打开的项目，请指定帧文件路径")
+		}
+		path = filepath.Join(ctx.Project.RootPath, defaultFramesFile)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("创建帧文件失败: %v", err)
+	}
+	header := framesHeader{Format: framesFormat, Version: framesVersion, Started: time.Now()}
+	header.Arch, _ = DetectTargetArch(ctx)
+	if ctx.Project != nil {
+		header.Project = ctx.Project.RootPath
+	}
+	if err := writeFrameLine(file, header); err != nil {
+		file.Close()
+		return "", err
+	}
+
+	stop := make(chan struct{})
+	rec := &RecordingState{
+		Path:    path,
+		File:    file,
+		Started: header.Started,
+		LastSeq: ctx.EventSeq,
+		Latest:  make(map[string]string),
+		Stop:    stop,
+	}
+	ctx.Recording = rec
+	go func() {
+		ticker := time.NewTicker(recordFrameDelay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			ui.Update(func(ui UI) error {
+				if ctx.Recording != rec {
+					return nil
+				}
+				if err := flushRecording(ctx, false); err != nil {
+					ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[RECORD] %v, recording stopped", err))
+					ctx.CommandDirty = true
+					StopRecording(ctx)
+				}
+				return nil
+			})
+		}
+	}()
+	return path, nil
+}
+
+// 停止录制，写出剩余的帧；返回录制状态（未录制时为nil）
+func StopRecording(ctx *DebuggerContext) (*RecordingState, error) {
+	rec := ctx.Recording
+	if rec == nil {
+		return nil, nil
+	}
+	err := flushRecording(ctx, true)
+	close(rec.Stop)
+	if cerr := rec.File.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("写入帧文件失败: %v", cerr)
+	}
+	ctx.Recording = nil
+	return rec, err
+}
+
+// 把已到达足够久的事件写成帧（all为true时写出全部事件）
+func flushRecording(ctx *DebuggerContext, all bool) error {
+	rec := ctx.Recording
+	if rec == nil {
+		return nil
+	}
+	// 按到达顺序处理（内核日志和硬件断点可能按时间戳插在前面）
+	pending := make([]DebugEvent, 0)
+	for _, e := range ctx.Events {
+		if e.Seq > rec.LastSeq {
+			pending = append(pending, e)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Seq < pending[j].Seq })
+	for _, e := range pending {
+		if !all && time.Since(e.Time) < recordFrameDelay {
+			break
+		}
+		rec.LastSeq = e.Seq
+		if err := recordFrameEvent(ctx, rec, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 处理一个事件：更新变量的最后值，命中事件写成一帧
+func recordFrameEvent(ctx *DebuggerContext, rec *RecordingState, e DebugEvent) error {
+	if e.Kind != "return" && e.Kind != "kmsg" {
+		for _, v := range e.Values {
+			if _, seen := rec.Latest[v.Name]; !seen {
+				rec.Order = append(rec.Order, v.Name)
+			}
+			rec.Latest[v.Name] = v.Value
+		}
+	}
+	if !frameEventKind(e.Kind) {
+		return nil
+	}
+	rec.Frames++
+	return writeFrameLine(rec.File, buildFrame(ctx, rec, e))
+}
+
+// 由命中事件生成一帧
+func buildFrame(ctx *DebuggerContext, rec *RecordingState, e DebugEvent) RecordedFrame {
+	frame := RecordedFrame{
+		Index:        rec.Frames,
+		Seq:          e.Seq,
+		Time:         e.Time,
+		TraceTime:    e.TraceTime,
+		Kind:         e.Kind,
+		BreakpointID: e.BreakpointID,
+		Function:     e.Function,
+		PID:          e.PID,
+		Comm:         e.Comm,
+		CPU:          e.CPU,
+		Values:       e.Values,
+		Registers:    registersForEvent(ctx, e),
+		Stack:        e.Stack,
+		Summary:      StripANSI(FormatEvent(ctx, e)),
+	}
+	frame.File, frame.Line = timelineEventSource(ctx, e)
+	if len(frame.Stack) == 0 && e.Function != "" {
+		frame.Stack = hitStackFrame(ctx, e)
+	}
+	for _, name := range rec.Order {
+		frame.Variables = append(frame.Variables, project.EventValue{Name: name, Value: rec.Latest[name]})
+	}
+	return frame
+}
+
+// 写入一行JSON
+func writeFrameLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入帧文件失败: %v", err)
+	}
+	return nil
+}
+
+// ========== 回放 ==========
+
+// 读取帧文件
+func LoadFrames(path string) (*ReplayState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "打开帧文件失败: %v", err)
+	}
+	defer file.Close()
+	replay := &ReplayState{Path: path}
+	dec := json.NewDecoder(file)
+	if err := dec.Decode(&replay.Header); err != nil || replay.Header.Format != framesFormat {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "%s 不是帧文件（record start 录制）", path)
+	}
+	if replay.Header.Version > framesVersion {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "帧文件版本 %d 高于支持的版本 %d", replay.Header.Version, framesVersion)
+	}
+	for {
+		var frame RecordedFrame
+		err := dec.Decode(&frame)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 录制中途退出时最后一行可能不完整，保留之前的帧
+			if len(replay.Frames) == 0 {
+				return nil, fmt.Errorf("解析帧文件失败: %v", err)
+			}
+			break
+		}
+		replay.Frames = append(replay.Frames, frame)
+	}
+	if len(replay.Frames) == 0 {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "%s 中没有录制到帧", path)
+	}
+	return replay, nil
+}
+
+// replay 参数对应的帧下标：next/prev/first/last 和帧序号返回true，其他参数是帧文件（从第一帧开始）
+func ReplayFrameIndex(replay *ReplayState, arg string) (int, bool) {
+	if replay == nil {
+		replay = &ReplayState{}
+	}
+	switch arg {
+	case "next":
+		return replay.Current + 1, true
+	case "prev":
+		return replay.Current - 1, true
+	case "first":
+		return 0, true
+	case "last":
+		return len(replay.Frames) - 1, true
+	}
+	if n, err := strconv.Atoi(arg); err == nil && len(replay.Frames) > 0 {
+		return n - 1, true
+	}
+	return 0, false
+}
+
+// 回放中的当前帧（没有回放时返回nil）
+func CurrentReplayFrame(ctx *DebuggerContext) *RecordedFrame {
+	if ctx.Replay == nil || ctx.Replay.Current < 0 || ctx.Replay.Current >= len(ctx.Replay.Frames) {
+		return nil
+	}
+	return &ctx.Replay.Frames[ctx.Replay.Current]
+}
+
+// 跳到第index帧（下标从0开始），代码窗口跳到该帧的源码行
+func JumpToReplayFrame(ui UI, ctx *DebuggerContext, index int) (*RecordedFrame, error) {
+	replay := ctx.Replay
+	if replay == nil {
+		return nil, errcode.Errorf(errcode.ErrInvalidArg, "没有载入帧文件，请先 replay <file>")
+	}
+	if index < 0 || index >= len(replay.Frames) {
+		return nil, errcode.Errorf(errcode.ErrNotFound, "没有第 %d 帧（共 %d 帧）", index+1, len(replay.Frames))
+	}
+	replay.Current = index
+	frame := &replay.Frames[index]
+	if frame.File == "" || ui == nil || ctx.Project == nil {
+		return frame, nil
+	}
+	if !project.FileExists(frame.File) {
+		return frame, fmt.Errorf("源码文件不存在: %s", frame.File)
+	}
+	return frame, OpenSourceAt(ui, ctx, frame.File, frame.Line)
+}
+
+// 当前帧的调用栈（第二个返回值表示是否在回放）
+func ReplayStackFrames(ctx *DebuggerContext) ([]StackFrame, bool) {
+	frame := CurrentReplayFrame(ctx)
+	if frame == nil {
+		return nil, false
+	}
+	return frame.Stack, true
+}
+
+// 帧的一行摘要
+func ReplayFrameSummary(replay *ReplayState, frame *RecordedFrame) string {
+	return fmt.Sprintf("Frame %d/%d %s  %s", frame.Index, len(replay.Frames), frame.Time.Format("15:04:05.000000"), frame.Summary)
+}
+
+// 变量窗口中当前帧的变量：该时刻每个变量最后一次的值，这一帧采集到的值标为 ◀
+func ReplayValueLines(ctx *DebuggerContext) ([]string, []string) {
+	frame := CurrentReplayFrame(ctx)
+	if frame == nil {
+		return nil, nil
+	}
+	current := make(map[string]bool)
+	for _, v := range frame.Values {
+		current[v.Name] = true
+	}
+	lines := []string{Styled(ActiveTheme.Warning, fmt.Sprintf("Frame %d/%d %s", frame.Index, len(ctx.Replay.Frames), frame.Time.Format("15:04:05.000")))}
+	names := []string{""}
+	for _, v := range frame.Variables {
+		line := fmt.Sprintf("%-8s %s", v.Name, FormatValue(ctx, v.Name, v.Value))
+		if current[v.Name] {
+			line += " ◀"
+		}
+		lines = append(lines, line)
+		names = append(names, v.Name)
+	}
+	for _, v := range frame.Values {
+		if v.Name == "return" {
+			lines = append(lines, fmt.Sprintf("%-8s %s ◀", v.Name, FormatValue(ctx, v.Name, v.Value)))
+			names = append(names, v.Name)
+		}
+	}
+	if len(lines) == 1 {
+		lines = append(lines, Styled(ActiveTheme.Dim, "no variable values recorded in this frame"))
+		names = append(names, "")
+	}
+	return append(lines, ""), append(names, "")
+}
+
+// 录制状态的显示
+func RecordingLines(ctx *DebuggerContext) []string {
+	lines := make([]string, 0, 2)
+	if rec := ctx.Recording; rec != nil {
+		lines = append(lines, fmt.Sprintf("Recording to %s: %d frames in %s", rec.Path, rec.Frames, time.Since(rec.Started).Round(time.Second)))
+	} else {
+		lines = append(lines, "Not recording, 'record start [file]' to record breakpoint hits as frames")
+	}
+	if replay := ctx.Replay; replay != nil {
+		lines = append(lines, fmt.Sprintf("Replaying %s: frame %d/%d (F9/F10 to step, 'replay off' for live data)", replay.Path, replay.Current+1, len(replay.Frames)))
+	}
+	return lines
+}
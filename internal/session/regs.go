@@ -0,0 +1,222 @@
+package session
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/ringbuf"
+
+	"debug-gocui/internal/dwarf"
+)
+
+// ========== 断点命中时的寄存器快照 ==========
+// 生成的BPF程序在每次命中时把pt_regs原样复制到ring buffer（regs_events），
+// bpf load 加载后由这里读取并按目标架构的pt_regs布局解码，填充寄存器窗口。
+// 通过脚本（bpftool）加载时ring buffer不归调试器所有，寄存器窗口保持为空。
+
+// ring buffer中的寄存器记录：breakpoint_id(u32) pid(u32) timestamp(u64) regs[N](u64)
+const regsEventHeader = 16
+
+// ring buffer map名称（与生成的BPF代码一致）
+const regsEventsMap = "regs_events"
+
+// 生成的BPF代码中复制的pt_regs字数（不支持的架构为0，不生成寄存器采集）
+func ptRegsWords(arch string) int {
+	if info := dwarf.TargetArchInfo(arch); info != nil {
+		return len(info.PtRegs)
+	}
+	return 0
+}
+
+// 一次命中的寄存器快照
+type RegisterSnapshot struct {
+	Arch         string
+	Source       string // 为空时是BPF命中时的pt_regs；gdb后端为 "gdb <地址>"
+	BreakpointID int
+	PID          int
+	Time         time.Time
+	Names        []string
+	Values       []uint64
+}
+
+// 按名称取寄存器值
+func (s *RegisterSnapshot) Value(name string) (uint64, bool) {
+	for i, n := range s.Names {
+		if n == name {
+			return s.Values[i], true
+		}
+	}
+	return 0, false
+}
+
+// 程序计数器
+func (s *RegisterSnapshot) PC() uint64 {
+	if info := dwarf.TargetArchInfo(s.Arch); info != nil {
+		v, _ := s.Value(info.PC)
+		return v
+	}
+	return 0
+}
+
+// 解码ring buffer中的一条记录
+func decodeRegsEvent(arch string, sample []byte) (*RegisterSnapshot, error) {
+	info := dwarf.TargetArchInfo(arch)
+	if info == nil {
+		return nil, fmt.Errorf("不支持的寄存器布局: %s", arch)
+	}
+	names := info.PtRegs
+	if len(sample) < regsEventHeader+8*len(names) {
+		return nil, fmt.Errorf("寄存器记录长度不足: %d字节", len(sample))
+	}
+	snap := &RegisterSnapshot{
+		Arch:         dwarf.RegsArch(arch),
+		BreakpointID: int(binary.NativeEndian.Uint32(sample[0:4])),
+		PID:          int(binary.NativeEndian.Uint32(sample[4:8])),
+		Time:         time.Now(),
+		Names:        names,
+		Values:       make([]uint64, len(names)),
+	}
+	for i := range names {
+		offset := regsEventHeader + 8*i
+		snap.Values[i] = binary.NativeEndian.Uint64(sample[offset : offset+8])
+	}
+	return snap, nil
+}
+
+// 启动寄存器读取协程（BPF目标文件中没有regs_events时返回nil）
+func startRegsReader(ui UI, ctx *DebuggerContext, coll *ebpf.Collection) (io.Closer, error) {
+	m := coll.Maps[regsEventsMap]
+	if m == nil {
+		return nil, nil
+	}
+	arch, _ := DetectTargetArch(ctx)
+	reader, err := ringbuf.NewReader(m)
+	if err != nil {
+		return nil, fmt.Errorf("打开寄存器ring buffer失败: %v", err)
+	}
+	go func() {
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				// bpf unload 关闭reader后退出
+				return
+			}
+			snap, err := decodeRegsEvent(arch, record.RawSample)
+			if err != nil {
+				continue
+			}
+			ui.Update(func(ui UI) error {
+				ctx.Registers = snap
+				recordRegisterHistory(ctx, snap)
+				ctx.CurrentAddr = snap.PC()
+				if event := lastBreakpointEvent(ctx, snap.BreakpointID); event != nil {
+					ctx.CurrentFunc = event.Function
+				}
+				return nil
+			})
+		}
+	}()
+	return reader, nil
+}
+
+// 保留的寄存器记录数
+const maxRegisterHistory = 1000
+
+// 记录一次寄存器读取（超出上限时丢弃最旧的）
+func recordRegisterHistory(ctx *DebuggerContext, snap *RegisterSnapshot) {
+	ctx.RegisterHistory = append(ctx.RegisterHistory, snap)
+	if len(ctx.RegisterHistory) > maxRegisterHistory {
+		ctx.RegisterHistory = ctx.RegisterHistory[len(ctx.RegisterHistory)-maxRegisterHistory:]
+	}
+}
+
+// 指定断点最近一次命中的事件
+func lastBreakpointEvent(ctx *DebuggerContext, id int) *DebugEvent {
+	for i := len(ctx.Events) - 1; i >= 0; i-- {
+		if ctx.Events[i].Kind == "breakpoint" && ctx.Events[i].BreakpointID == id {
+			return &ctx.Events[i]
+		}
+	}
+	return nil
+}
+
+// 寄存器窗口内容：关键寄存器在前，其余按pt_regs顺序两列显示
+func RegisterLines(snap *RegisterSnapshot) []string {
+	lines := []string{fmt.Sprintf("BP%d pid=%d %s (%s)", snap.BreakpointID, snap.PID, snap.Time.Format("15:04:05.000"), snap.Arch)}
+	if snap.Source != "" {
+		lines[0] = fmt.Sprintf("%s %s (%s)", snap.Source, snap.Time.Format("15:04:05.000"), snap.Arch)
+	}
+	shown := make(map[string]bool)
+	var key []string
+	if info := dwarf.TargetArchInfo(snap.Arch); info != nil {
+		key = info.Key
+	}
+	for _, name := range key {
+		if v, ok := snap.Value(name); ok {
+			lines = append(lines, fmt.Sprintf("%-6s 0x%016x", name, v))
+			shown[name] = true
+		}
+	}
+	lines = append(lines, "")
+	pending := ""
+	for i, name := range snap.Names {
+		if shown[name] {
+			continue
+		}
+		cell := fmt.Sprintf("%-6s %016x", name, snap.Values[i])
+		if pending == "" {
+			pending = cell
+		} else {
+			lines = append(lines, pending+"  "+cell)
+			pending = ""
+		}
+	}
+	if pending != "" {
+		lines = append(lines, pending)
+	}
+	return lines
+}
+
+// 生成的BPF代码：寄存器ring buffer定义（不支持的架构不生成）
+func WriteRegsCaptureDecl(file *os.File, arch string) {
+	words := ptRegsWords(arch)
+	if words == 0 {
+		fmt.Fprintf(file, "// 寄存器采集: %s 暂不支持\n\n", arch)
+		return
+	}
+	fmt.Fprintln(file, "// 寄存器快照（bpf load 时由调试器从ring buffer读取，填充寄存器窗口）")
+	fmt.Fprintf(file, "#define REGS_WORDS %d\n", words)
+	fmt.Fprintln(file, "struct regs_event {")
+	fmt.Fprintln(file, "    u32 breakpoint_id;")
+	fmt.Fprintln(file, "    u32 pid;")
+	fmt.Fprintln(file, "    u64 timestamp;")
+	fmt.Fprintln(file, "    u64 regs[REGS_WORDS];")
+	fmt.Fprintln(file, "};")
+	fmt.Fprintln(file, "")
+	fmt.Fprintln(file, "struct {")
+	fmt.Fprintln(file, "    __uint(type, BPF_MAP_TYPE_RINGBUF);")
+	fmt.Fprintln(file, "    __uint(max_entries, 256 * 1024);")
+	fmt.Fprintf(file, "} %s SEC(\".maps\");\n", regsEventsMap)
+	fmt.Fprintln(file, "")
+}
+
+// 生成的BPF代码：在探针中提交寄存器快照
+func WriteRegsCaptureSubmit(file *os.File, arch string, breakpointID int) {
+	if ptRegsWords(arch) == 0 {
+		return
+	}
+	fmt.Fprintln(file, "    // 寄存器快照")
+	fmt.Fprintf(file, "    struct regs_event *regs = bpf_ringbuf_reserve(&%s, sizeof(*regs), 0);\n", regsEventsMap)
+	fmt.Fprintln(file, "    if (regs) {")
+	fmt.Fprintf(file, "        regs->breakpoint_id = %d;\n", breakpointID)
+	fmt.Fprintln(file, "        regs->pid = event.pid;")
+	fmt.Fprintln(file, "        regs->timestamp = event.timestamp;")
+	fmt.Fprintln(file, "        bpf_probe_read_kernel(regs->regs, sizeof(regs->regs), ctx);")
+	fmt.Fprintln(file, "        bpf_ringbuf_submit(regs, 0);")
+	fmt.Fprintln(file, "    }")
+	fmt.Fprintln(file, "")
+}
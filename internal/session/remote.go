@@ -0,0 +1,362 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 远程目标与看门狗 ==========
+// 在开发板上调试时，事件通过 ssh 从开发板的trace_pipe读取。
+// 看门狗定期执行存活检查（默认 ssh <target> cat /proc/uptime，也可以换成通过串口echo的脚本）：
+//   - 连续多次无响应 → 判定为挂死：在时间线上标记，停止采集并把截至此刻的会话导出保存
+//   - 恢复响应且uptime变小 → 判定为重启：在时间线上标记，执行重新挂载探针的命令并恢复采集
+
+// 连续无响应多少次判定为挂死
+const WatchdogHangMisses = 3
+
+// 存活检查超时
+const watchdogPingTimeout = 5 * time.Second
+
+// 看门狗面板保留的检查结果数
+const watchdogHistory = 60
+
+// 挂死时保存的会话目录（项目根目录下）
+const watchdogSessionDir = ".debug_sessions"
+
+// 看门狗运行状态
+type WatchdogState struct {
+	Stop       chan struct{}
+	Interval   time.Duration
+	Status     string    // alive / no response / hung / rebooted / reattaching
+	Misses     int       // 连续无响应次数
+	LastOK     time.Time // 最近一次响应时间
+	LastUptime float64   // 最近一次读到的目标uptime（秒，-1表示未知）
+	RTTs       []int     // 最近的往返时间（毫秒，0表示无响应）
+	Hangs      int
+	Reboots    int
+	Resume     bool     // 恢复后是否重新开始事件采集
+	Log        []string // 看门狗记录
+}
+
+// 远程trace_pipe：关闭时结束ssh进程
+type remotePipe struct {
+	cmd    *exec.Cmd
+	stdout io.ReadCloser
+}
+
+func (p *remotePipe) Read(b []byte) (int, error) {
+	return p.stdout.Read(b)
+}
+
+func (p *remotePipe) Close() error {
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	go p.cmd.Wait()
+	return nil
+}
+
+// 当前项目的远程目标（未配置时返回nil）
+func RemoteTarget(ctx *DebuggerContext) *project.RemoteTarget {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return nil
+	}
+	return ctx.Project.Settings.Remote
+}
+
+// 通过ssh读取开发板上的trace_pipe
+func OpenRemoteTracePipe(remote *project.RemoteTarget) (io.ReadCloser, string, error) {
+	script := "cat " + strings.Join(TracePipePaths, " 2>/dev/null || cat ")
+	cmd := exec.Command("ssh", remote.SSHArgs(script, "ServerAliveInterval=2")...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrTracePipe, "创建ssh管道失败: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrToolMissing, "启动ssh失败: %v", err)
+	}
+	return &remotePipe{cmd: cmd, stdout: stdout}, fmt.Sprintf("%s:trace_pipe (ssh)", remote.SSH), nil
+}
+
+// 执行一次存活检查，返回目标uptime（无法解析时为-1）
+func pingRemote(remote *project.RemoteTarget) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), watchdogPingTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if remote.PingCommand != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", remote.PingCommand)
+	} else {
+		cmd = exec.CommandContext(ctx, "ssh", remote.SSHArgs("cat /proc/uptime", "ConnectTimeout=3")...)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return -1, err
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return -1, nil
+	}
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return -1, nil
+	}
+	return uptime, nil
+}
+
+// 在时间线上标记看门狗事件并写入记录
+func markWatchdog(ctx *DebuggerContext, kind, message string) {
+	AppendEvent(ctx, DebugEvent{Kind: "watchdog", Time: time.Now(), CPU: -1, Function: kind, Location: message})
+	wd := ctx.Watchdog
+	wd.Log = append(wd.Log, fmt.Sprintf("%s %s", time.Now().Format("15:04:05"), message))
+	if len(wd.Log) > watchdogHistory {
+		wd.Log = wd.Log[len(wd.Log)-watchdogHistory:]
+	}
+	ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("\x1b[41;97m[WATCHDOG]\x1b[0m %s", message))
+	ctx.CommandDirty = true
+}
+
+// 保存截至目前的会话（导出为trace文件）
+func saveHangSession(ctx *DebuggerContext) (string, error) {
+	if ctx.Project == nil || len(ctx.Events) == 0 {
+		return "", nil
+	}
+	dir := filepath.Join(ctx.Project.RootPath, watchdogSessionDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建会话目录失败: %v", err)
+	}
+	path := filepath.Join(dir, "hang-"+time.Now().Format("20060102-150405")+".json")
+	if _, err := ExportPerfetto(ctx, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// 处理一次存活检查结果（在UI线程中调用）
+func handleWatchdogPing(ui UI, ctx *DebuggerContext, uptime float64, rtt time.Duration, err error) {
+	wd := ctx.Watchdog
+	if wd == nil {
+		return
+	}
+	defer refreshWatchdogPopup(ctx)
+
+	if err != nil {
+		wd.RTTs = append(wd.RTTs, 0)
+		wd.Misses++
+		if wd.Status != "hung" {
+			wd.Status = "no response"
+		}
+		if wd.Misses == WatchdogHangMisses {
+			wd.Status = "hung"
+			wd.Hangs++
+			markWatchdog(ctx, "hang", fmt.Sprintf("target not responding (%d missed checks): %v", wd.Misses, err))
+			if StopEventCapture(ctx) {
+				wd.Resume = true
+			}
+			if path, err := saveHangSession(ctx); err != nil {
+				markWatchdog(ctx, "save", fmt.Sprintf("failed to save session: %v", err))
+			} else if path != "" {
+				wd.Log = append(wd.Log, "session saved to "+project.ProjectRelativePath(ctx.Project, path))
+			}
+		}
+	} else {
+		wd.RTTs = append(wd.RTTs, int(rtt/time.Millisecond)+1)
+		rebooted := uptime >= 0 && wd.LastUptime >= 0 && uptime < wd.LastUptime
+		wasHung := wd.Status == "hung"
+		wd.Misses = 0
+		wd.LastOK = time.Now()
+		switch {
+		case rebooted:
+			wd.Reboots++
+			wd.Status = "rebooted"
+			markWatchdog(ctx, "reboot", fmt.Sprintf("target rebooted (uptime %.0fs, was %.0fs)", uptime, wd.LastUptime))
+			// 重启后远程trace_pipe的ssh连接已经断开
+			if StopEventCapture(ctx) {
+				wd.Resume = true
+			}
+			reattachRemote(ui, ctx)
+		case wasHung && uptime < 0:
+			// 自定义检查命令不输出uptime时无法区分重启，按重启处理
+			wd.Status = "alive"
+			markWatchdog(ctx, "recovered", "target responding again (uptime unknown, re-attaching)")
+			reattachRemote(ui, ctx)
+		case wasHung:
+			wd.Status = "alive"
+			markWatchdog(ctx, "recovered", "target responding again (no reboot detected)")
+			resumeRemoteCapture(ui, ctx)
+		case wd.Status != "reattaching":
+			wd.Status = "alive"
+		}
+		wd.LastUptime = uptime
+	}
+	if len(wd.RTTs) > watchdogHistory {
+		wd.RTTs = wd.RTTs[len(wd.RTTs)-watchdogHistory:]
+	}
+}
+
+// 目标重启后重新挂载探针，然后恢复采集
+func reattachRemote(ui UI, ctx *DebuggerContext) {
+	remote := RemoteTarget(ctx)
+	if remote == nil || remote.AttachCommand == "" {
+		markWatchdog(ctx, "reattach", "probes not re-attached (set 'remote attach <command>')")
+		resumeRemoteCapture(ui, ctx)
+		return
+	}
+	ctx.Watchdog.Status = "reattaching"
+	command := remote.AttachCommand
+	go func() {
+		output, err := exec.Command("sh", "-c", command).CombinedOutput()
+		ui.Update(func(ui UI) error {
+			if ctx.Watchdog == nil {
+				return nil
+			}
+			if err != nil {
+				markWatchdog(ctx, "reattach", fmt.Sprintf("re-attach failed: %v %s", err, strings.TrimSpace(string(output))))
+			} else {
+				markWatchdog(ctx, "reattach", "probes re-attached")
+			}
+			ctx.Watchdog.Status = "alive"
+			resumeRemoteCapture(ui, ctx)
+			refreshWatchdogPopup(ctx)
+			return nil
+		})
+	}()
+}
+
+// 恢复挂死/重启前正在进行的事件采集
+func resumeRemoteCapture(ui UI, ctx *DebuggerContext) {
+	wd := ctx.Watchdog
+	if !wd.Resume {
+		return
+	}
+	wd.Resume = false
+	if path, err := StartEventCapture(ui, ctx); err != nil {
+		markWatchdog(ctx, "reconnect", fmt.Sprintf("failed to resume capture: %v", err))
+	} else {
+		markWatchdog(ctx, "reconnect", "capture resumed from "+path)
+	}
+}
+
+// 启动看门狗
+func StartWatchdog(ui UI, ctx *DebuggerContext, interval time.Duration) error {
+	remote := RemoteTarget(ctx)
+	if remote == nil {
+		return fmt.Errorf("没有配置远程目标，请先使用 remote ssh <user@host>")
+	}
+	if err := CheckSafeMode(ctx, "看门狗"); err != nil {
+		return err
+	}
+	if interval < time.Second {
+		return fmt.Errorf("检查间隔不能小于 1s")
+	}
+	StopWatchdog(ctx)
+	stop := make(chan struct{})
+	ctx.Watchdog = &WatchdogState{Stop: stop, Interval: interval, Status: "starting", LastUptime: -1}
+	target := *remote
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			start := time.Now()
+			uptime, err := pingRemote(&target)
+			rtt := time.Since(start)
+			ui.Update(func(ui UI) error {
+				if ctx.Watchdog == nil || ctx.Watchdog.Stop != stop {
+					return nil
+				}
+				handleWatchdogPing(ui, ctx, uptime, rtt, err)
+				return nil
+			})
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// 停止看门狗
+func StopWatchdog(ctx *DebuggerContext) bool {
+	if ctx.Watchdog == nil {
+		return false
+	}
+	close(ctx.Watchdog.Stop)
+	ctx.Watchdog = nil
+	return true
+}
+
+// 看门狗面板内容
+func watchdogLines(ctx *DebuggerContext) []string {
+	wd := ctx.Watchdog
+	remote := RemoteTarget(ctx)
+	if wd == nil {
+		return []string{"Watchdog: off", "Usage: watchdog on [seconds]"}
+	}
+	target := "(none)"
+	if remote != nil {
+		target = remote.SSH
+		if remote.PingCommand != "" {
+			target += "  ping: " + remote.PingCommand
+		}
+	}
+	status := wd.Status
+	switch wd.Status {
+	case "alive":
+		status = "\x1b[32malive\x1b[0m"
+	case "hung", "no response":
+		status = "\x1b[41;97m" + wd.Status + "\x1b[0m"
+	case "rebooted", "reattaching":
+		status = "\x1b[33m" + wd.Status + "\x1b[0m"
+	}
+	uptime := "unknown"
+	if wd.LastUptime >= 0 {
+		uptime = (time.Duration(wd.LastUptime) * time.Second).String()
+	}
+	lastOK := "never"
+	if !wd.LastOK.IsZero() {
+		lastOK = sinceShort(wd.LastOK)
+	}
+	lines := []string{
+		fmt.Sprintf("Target: %s   every %s", target, wd.Interval),
+		fmt.Sprintf("Status: %s   Last response: %s   Target uptime: %s", status, lastOK, uptime),
+		fmt.Sprintf("Missed checks: %d (hang after %d)   Hangs: %d   Reboots: %d", wd.Misses, WatchdogHangMisses, wd.Hangs, wd.Reboots),
+		"",
+		"\x1b[1mRound-trip time (blank = no response)\x1b[0m",
+		"  │" + statsSparkline(wd.RTTs) + "│",
+		"",
+		"\x1b[1mLog\x1b[0m",
+	}
+	if len(wd.Log) == 0 {
+		lines = append(lines, "  (nothing yet)")
+	}
+	for i := len(wd.Log) - 1; i >= 0 && i >= len(wd.Log)-12; i-- {
+		lines = append(lines, "  "+wd.Log[i])
+	}
+	return lines
+}
+
+// 显示看门狗面板
+func ShowWatchdogPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, "watchdog")
+	popup := CreatePopupWindow(ctx, "watchdog", "Target Watchdog", 96, 24, watchdogLines(ctx))
+	ShowPopupWindow(ctx, popup)
+}
+
+// 看门狗面板打开时刷新内容
+func refreshWatchdogPopup(ctx *DebuggerContext) {
+	if popup := FindPopupWindow(ctx, "watchdog"); popup != nil {
+		popup.Content = watchdogLines(ctx)
+	}
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 远程编译与加载 ==========
+// 配置了远程目标（remote ssh）后，BPF程序在开发板上加载，TUI留在主机上：
+//   remote sync     用scp把生成的BPF源码、目标文件和加载/卸载脚本复制到开发板的工作目录
+//   compile         remote build target 时在开发板上用clang编译，.bpf.o 再复制回本机
+//   bpf load/unload 同步产物后在开发板上执行加载/卸载脚本（非root用户通过 sudo -n）
+// 之后 events start 通过ssh读取开发板的trace_pipe，命中显示在事件窗口中。
+// ssh/scp在后台执行，结束后结果写入命令窗口（无界面运行时同步执行）。
+
+// 远程任务输出最多显示的行数
+const maxRemoteOutputLines = 20
+
+// 开发板上以root执行（非root用户用 sudo -n，需要免密sudo）
+func remoteAsRoot(command string) string {
+	return `if [ "$(id -u)" -ne 0 ]; then SUDO="sudo -n"; fi; $SUDO ` + command
+}
+
+// 当前使用的BPF产物（变量监控版本优先，与 compile 相同），返回项目根目录下的文件名
+type bpfArtifactSet struct {
+	Source, Object, Load, Unload string
+}
+
+func currentBPFArtifacts(root string) (*bpfArtifactSet, error) {
+	sets := []bpfArtifactSet{
+		{"debug_variables.bpf.c", "debug_variables.bpf.o", "load_debug_vars.sh", "unload_debug_vars.sh"},
+		{"debug_breakpoints.bpf.c", "debug_breakpoints.bpf.o", "load_debug_bpf.sh", "unload_debug_bpf.sh"},
+	}
+	for i := range sets {
+		if project.FileExists(filepath.Join(root, sets[i].Source)) {
+			return &sets[i], nil
+		}
+	}
+	return nil, errcode.Errorf(errcode.ErrBPFSource, "没有生成的BPF源码，请先执行 'vars' 或 'generate'")
+}
+
+// 产物中存在的文件（本地路径）
+func (a *bpfArtifactSet) existing(root string, names ...string) []string {
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		if path := filepath.Join(root, name); project.FileExists(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// 在后台依次执行ssh/scp命令，返回要显示的输出：后台执行时只有开始行，结果在结束后写入命令窗口；
+// g为nil时同步执行，结果直接包含在返回的输出中，失败时同时返回错误。done收到执行结果和完整输出，返回要追加显示的行
+func runRemoteJob(ui UI, ctx *DebuggerContext, title string, steps [][]string, done func(err error, output []string) []string) ([]string, error) {
+	if ctx.RemoteJob != "" {
+		return nil, fmt.Errorf("远程任务 '%s' 正在执行", ctx.RemoteJob)
+	}
+	for _, tool := range []string{"ssh", "scp"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return nil, errcode.Errorf(errcode.ErrToolMissing, "没有找到%s", tool)
+		}
+	}
+	ctx.RemoteJob = title
+	run := func() ([]string, error) {
+		start := time.Now()
+		var output []string
+		var err error
+		for _, step := range steps {
+			output = append(output, "$ "+strings.Join(step, " "))
+			var out []byte
+			out, err = exec.Command(step[0], step[1:]...).CombinedOutput()
+			if text := strings.TrimRight(string(out), "\n"); text != "" {
+				output = append(output, strings.Split(text, "\n")...)
+			}
+			if err != nil {
+				break
+			}
+		}
+		elapsed := time.Since(start).Truncate(100 * time.Millisecond)
+
+		var lines []string
+		if err == nil {
+			lines = append(lines, fmt.Sprintf("[REMOTE] %s done in %s", title, elapsed))
+		}
+		shown := output
+		if len(shown) > maxRemoteOutputLines {
+			shown = shown[len(shown)-maxRemoteOutputLines:]
+		}
+		for _, line := range shown {
+			lines = append(lines, "  "+line)
+		}
+		if done != nil {
+			lines = append(lines, done(err, output)...)
+		}
+		if err != nil {
+			return lines, errcode.Wrap(errcode.ErrTarget, fmt.Errorf("[REMOTE] %s failed after %s: %w", title, elapsed, err))
+		}
+		return lines, nil
+	}
+
+	header := fmt.Sprintf("🛰️ [REMOTE] %s ...", title)
+	if ui == nil {
+		lines, err := run()
+		ctx.RemoteJob = ""
+		return append([]string{header}, lines...), err
+	}
+	go func() {
+		lines, err := run()
+		ui.Update(func(ui UI) error {
+			ctx.RemoteJob = ""
+			ctx.CommandHistory = append(ctx.CommandHistory, lines...)
+			ctx.CommandDirty = true
+			if err != nil {
+				ReportError(ctx, err)
+			}
+			return nil
+		})
+	}()
+	return []string{header}, nil
+}
+
+// remote sync：把产物复制到开发板
+func StartRemoteSync(ui UI, ctx *DebuggerContext) ([]string, error) {
+	remote := RemoteTarget(ctx)
+	root := ctx.Project.RootPath
+	artifacts, err := currentBPFArtifacts(root)
+	if err != nil {
+		return nil, err
+	}
+	files := artifacts.existing(root, artifacts.Source, artifacts.Object, artifacts.Load, artifacts.Unload)
+	steps := [][]string{
+		append([]string{"ssh"}, remote.SSHArgs("mkdir -p "+ShellQuote(remote.Directory()))...),
+		append([]string{"scp"}, remote.SCPToArgs(files)...),
+	}
+	title := fmt.Sprintf("sync %d files to %s:%s", len(files), remote.Dest(), remote.Directory())
+	return runRemoteJob(ui, ctx, title, steps, nil)
+}
+
+// compile（remote build target）：在开发板上编译，目标文件复制回本机
+func StartRemoteCompile(ui UI, ctx *DebuggerContext, targetArch string) ([]string, error) {
+	remote := RemoteTarget(ctx)
+	root := ctx.Project.RootPath
+	artifacts, err := currentBPFArtifacts(root)
+	if err != nil {
+		return nil, err
+	}
+	archDefine, ok := SupportedArchitectures[targetArch]
+	if !ok {
+		return nil, errcode.Errorf(errcode.ErrArch, "不支持的架构: %s", targetArch)
+	}
+	clang := fmt.Sprintf("cd %s && clang -target bpf -O2 -g -D%s=1 -c %s -o %s", ShellQuote(remote.Directory()), archDefine, artifacts.Source, artifacts.Object)
+	steps := [][]string{
+		append([]string{"ssh"}, remote.SSHArgs("mkdir -p "+ShellQuote(remote.Directory()))...),
+		append([]string{"scp"}, remote.SCPToArgs([]string{filepath.Join(root, artifacts.Source)})...),
+		append([]string{"ssh"}, remote.SSHArgs(clang)...),
+		append([]string{"scp"}, remote.SCPFromArgs(remote.Directory()+"/"+artifacts.Object, filepath.Join(root, artifacts.Object))...),
+	}
+	title := fmt.Sprintf("compile %s on %s (%s)", artifacts.Source, remote.Dest(), targetArch)
+	ctx.CompileOutput = nil
+	return runRemoteJob(ui, ctx, title, steps, func(err error, output []string) []string {
+		if err == nil {
+			return nil
+		}
+		// clang诊断中的文件名是相对工作目录的，按项目根目录解析
+		ctx.CompileOutput = output
+		errors, warnings := ShowDiagnosticsPopup(ctx, "compile", "Compile Errors ("+remote.Dest()+")", output, []string{root})
+		return []string{fmt.Sprintf("📋 %d errors, %d warnings in the Compile Errors popup", errors, warnings)}
+	})
+}
+
+// bpf load/unload（远程目标）：同步产物后执行加载或卸载脚本
+func StartRemoteLoad(ui UI, ctx *DebuggerContext, load bool) ([]string, error) {
+	if err := CheckSafeMode(ctx, "BPF加载"); err != nil {
+		return nil, err
+	}
+	remote := RemoteTarget(ctx)
+	root := ctx.Project.RootPath
+	artifacts, err := currentBPFArtifacts(root)
+	if err != nil {
+		return nil, err
+	}
+	script, action := artifacts.Unload, "unload"
+	if load {
+		script, action = artifacts.Load, "load"
+	}
+	if !project.FileExists(filepath.Join(root, script)) {
+		return nil, errcode.Errorf(errcode.ErrBPFSource, "没有 %s，请重新执行 'vars' 或 'generate'", script)
+	}
+	steps := [][]string{
+		append([]string{"ssh"}, remote.SSHArgs("mkdir -p "+ShellQuote(remote.Directory()))...),
+		append([]string{"scp"}, remote.SCPToArgs(artifacts.existing(root, artifacts.Source, artifacts.Object, artifacts.Load, artifacts.Unload))...),
+		append([]string{"ssh"}, remote.SSHArgs(fmt.Sprintf("cd %s && %s", ShellQuote(remote.Directory()), remoteAsRoot("./"+script)))...),
+	}
+	title := fmt.Sprintf("%s %s on %s", action, artifacts.Object, remote.Dest())
+	return runRemoteJob(ui, ctx, title, steps, nil)
+}
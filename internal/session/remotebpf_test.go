@@ -0,0 +1,28 @@
+package session
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/tmp/kdebug", "/tmp/kdebug"},
+		{"", "''"},
+		{"/tmp/my dir", "'/tmp/my dir'"},
+		{"/tmp/it's", `'/tmp/it'\''s'`},
+		{"/tmp/x; rm -rf ~", "'/tmp/x; rm -rf ~'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		// 远程命令经过shell解析后得到原来的参数
+		out, err := exec.Command("sh", "-c", "printf %s "+ShellQuote(tt.in)).Output()
+		if err != nil || string(out) != tt.in {
+			t.Errorf("sh -c printf %s = %q, %v", ShellQuote(tt.in), out, err)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 项目内查找替换 ==========
+// replace <pattern> <replacement> 在项目的全部源文件中查找（使用 Ctrl+F 当前的搜索模式：
+// 正则、大小写、全词；含空格的参数写成 "a b" "c d" 或 /a b/c d/），在确认窗口中逐个确认：y 替换、n 跳过、a 替换当前及其余全部、
+// s 停止并写入已确认的替换，q 取消（不写入任何文件）。正则模式下替换文本可以用 $1、${name}
+// 引用分组。确认结束后按文件写回（保持各行原来的换行符），被改写的文件在标签栏中标记 +。
+// 写回前会检查匹配行是否仍与查找时一致，文件在此期间被修改时跳过该文件。
+
+// 一处待确认的替换
+type replaceMatch struct {
+	File       string
+	Line       int // 从1开始
+	Start, End int // 匹配在行内的字节范围
+	Replace    string // 这一处匹配替换后的文本
+}
+
+// 一次查找替换的确认状态
+type replaceSession struct {
+	pattern  string
+	matches  []replaceMatch
+	lines    map[string]*project.SourceFile // 查找时读取的文件内容（显示上下文、写回前检查）
+	accepted []bool
+	current  int
+}
+
+// 解析 replace 的参数，支持三种写法：
+//
+//	replace old new                  第一个空格之前是查找文本
+//	replace "old text" "new text"    双引号括起的参数可以含空格，\" 和 \\ 转义
+//	replace /old text/new text/      / 分隔，\/ 表示 / 本身（末尾的 / 可以省略）
+//
+// 替换文本写 "" 表示替换为空（删除匹配）
+func ParseReplaceArgs(args string) (string, string, bool) {
+	args = strings.TrimSpace(args)
+	switch {
+	case strings.HasPrefix(args, "/"):
+		parts := splitUnescaped(args[1:], '/')
+		if len(parts) == 3 && parts[2] == "" {
+			parts = parts[:2]
+		}
+		if len(parts) != 2 || parts[0] == "" {
+			return "", "", false
+		}
+		return parts[0], parts[1], true
+	case strings.HasPrefix(args, `"`):
+		pattern, rest, ok := cutQuoted(args)
+		if !ok || pattern == "" {
+			return "", "", false
+		}
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return "", "", false
+		}
+		if strings.HasPrefix(rest, `"`) {
+			replacement, tail, ok := cutQuoted(rest)
+			if !ok || strings.TrimSpace(tail) != "" {
+				return "", "", false
+			}
+			return pattern, replacement, true
+		}
+		return pattern, rest, true
+	}
+	fields := strings.SplitN(args, " ", 2)
+	if len(fields) < 2 || fields[0] == "" {
+		return "", "", false
+	}
+	replacement := strings.TrimSpace(fields[1])
+	if replacement == `""` {
+		replacement = ""
+	}
+	return fields[0], replacement, true
+}
+
+// 取出开头双引号括起的字符串，返回内容和其后的剩余部分
+func cutQuoted(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+				i++
+			}
+			b.WriteByte(s[i])
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
+
+// 按没有被 \ 转义的分隔符切分，\<sep> 还原为分隔符，其余转义（正则中的 \d 等）原样保留
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == sep:
+			b.WriteByte(sep)
+			i++
+		case s[i] == sep:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
+// 在一行中查找所有匹配并计算各自替换后的文本（跳过空匹配）
+func lineReplacements(re *regexp.Regexp, line, replacement string, regex bool) []replaceMatch {
+	var matches []replaceMatch
+	for _, m := range re.FindAllStringSubmatchIndex(line, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		text := replacement
+		if regex {
+			text = string(re.ExpandString(nil, replacement, line, m))
+		}
+		matches = append(matches, replaceMatch{Start: m[0], End: m[1], Replace: text})
+	}
+	return matches
+}
+
+// 把一行中已确认的替换（按位置排序）应用到原行
+func applyLineReplacements(line string, matches []replaceMatch) string {
+	var b strings.Builder
+	prev := 0
+	for _, m := range matches {
+		b.WriteString(line[prev:m.Start])
+		b.WriteString(m.Replace)
+		prev = m.End
+	}
+	b.WriteString(line[prev:])
+	return b.String()
+}
+
+// 在项目中查找要替换的位置，返回确认状态和是否因达到上限而截断
+func findProjectReplacements(root, pattern, replacement string, opts SearchOptions) (*replaceSession, bool, error) {
+	re, err := compileSearchPattern(pattern, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	session := &replaceSession{pattern: pattern, lines: make(map[string]*project.SourceFile)}
+	truncated := false
+	project.WalkProjectSources(root, func(path string) bool {
+		file, err := project.LoadSourceFile(path)
+		if err != nil {
+			return true
+		}
+		for i := 0; i < file.Len(); i++ {
+			for _, m := range lineReplacements(re, file.Line(i), replacement, opts.Regex) {
+				if len(session.matches) >= MaxProjectMatches {
+					truncated = true
+					return false
+				}
+				m.File, m.Line = path, i+1
+				session.matches = append(session.matches, m)
+				session.lines[path] = file
+			}
+		}
+		return true
+	})
+	session.accepted = make([]bool, len(session.matches))
+	return session, truncated, nil
+}
+
+// 写回一个文件中已确认的替换，返回替换的处数
+func writeFileReplacements(path string, original *project.SourceFile, matches []replaceMatch) (int, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	current, err := project.LoadSourceFile(path)
+	if err != nil {
+		return 0, err
+	}
+	byLine := make(map[int][]replaceMatch)
+	for _, m := range matches {
+		byLine[m.Line-1] = append(byLine[m.Line-1], m)
+	}
+	lines := make(map[int]string, len(byLine))
+	for i, ms := range byLine {
+		if current.Line(i) != original.Line(i) {
+			return 0, fmt.Errorf("line %d changed on disk since the search", i+1)
+		}
+		lines[i] = applyLineReplacements(original.Line(i), ms)
+	}
+	if err := os.WriteFile(path, current.WithLines(lines), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	return len(matches), nil
+}
+
+// 写回全部已确认的替换：更新已打开文件的缓存并标记为已修改，返回命令窗口中的结果
+func (s *replaceSession) apply(ctx *DebuggerContext) []string {
+	byFile := make(map[string][]replaceMatch)
+	var files []string
+	for i, m := range s.matches {
+		if !s.accepted[i] {
+			continue
+		}
+		if byFile[m.File] == nil {
+			files = append(files, m.File)
+		}
+		byFile[m.File] = append(byFile[m.File], m)
+	}
+	if len(files) == 0 {
+		return []string{"[REPLACE] Nothing replaced"}
+	}
+
+	proj := ctx.Project
+	if proj.Modified == nil {
+		proj.Modified = make(map[string]bool)
+	}
+	var output []string
+	replaced, written := 0, 0
+	for _, path := range files {
+		n, err := writeFileReplacements(path, s.lines[path], byFile[path])
+		if err != nil {
+			output = append(output, fmt.Sprintf("  Skipped %s: %v", project.ProjectRelativePath(ctx.Project, path), err))
+			continue
+		}
+		replaced += n
+		written++
+		proj.Modified[path] = true
+		if proj.Xref != nil {
+			proj.Xref.UpdateFile(path)
+		}
+		if _, ok := proj.OpenFiles[path]; ok {
+			if file, err := project.LoadSourceFile(path); err == nil {
+				proj.OpenFiles[path] = file
+			}
+		}
+		if path == proj.CurrentFile && ctx.SearchMode && ctx.SearchTerm != "" {
+			PerformSearch(ctx)
+		}
+	}
+	return append([]string{fmt.Sprintf("[REPLACE] Replaced %d occurrences of '%s' in %d files", replaced, s.pattern, written)}, output...)
+}
+
+// 确认窗口的内容：当前匹配的位置、前后各一行上下文、替换前后的对比
+func (s *replaceSession) content(ctx *DebuggerContext) []string {
+	m := s.matches[s.current]
+	file := s.lines[m.File]
+	line := file.Line(m.Line - 1)
+	expand := func(text string) string {
+		return strings.ReplaceAll(text, "\t", "    ")
+	}
+	content := []string{
+		Styled(ActiveTheme.Focused, fmt.Sprintf("%s:%d", project.ProjectRelativePath(ctx.Project, m.File), m.Line)),
+		"",
+	}
+	if m.Line > 1 {
+		content = append(content, Styled(ActiveTheme.Dim, "  "+expand(file.Line(m.Line-2))))
+	}
+	content = append(content,
+		"- "+expand(line[:m.Start])+Styled(ActiveTheme.DiffOld, expand(line[m.Start:m.End]))+expand(line[m.End:]),
+		"+ "+expand(line[:m.Start])+Styled(ActiveTheme.DiffNew, expand(m.Replace))+expand(line[m.End:]))
+	if m.Line < file.Len() {
+		content = append(content, Styled(ActiveTheme.Dim, "  "+expand(file.Line(m.Line))))
+	}
+	accepted := 0
+	for _, ok := range s.accepted {
+		if ok {
+			accepted++
+		}
+	}
+	return append(content, "",
+		fmt.Sprintf("Match %d of %d in %d files, %d to replace", s.current+1, len(s.matches), len(s.lines), accepted),
+		Styled(ActiveTheme.Dim, "y replace | n skip | a replace this and the rest | s stop and apply | q cancel"))
+}
+
+// 显示确认窗口，返回匹配数和涉及的文件数
+func ShowReplacePopup(ctx *DebuggerContext, pattern, replacement string) (int, int, bool, error) {
+	if ctx.Project == nil {
+		return 0, 0, false, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	session, truncated, err := findProjectReplacements(ctx.Project.RootPath, pattern, replacement, ctx.SearchOptions)
+	if err != nil || len(session.matches) == 0 {
+		return 0, 0, false, err
+	}
+
+	ClosePopupWindow(ctx, "replace")
+	title := func() string {
+		return fmt.Sprintf("Replace: %s → %s (%d/%d)", pattern, replacement, session.current+1, len(session.matches))
+	}
+	popup := CreatePopupWindow(ctx, "replace", title(), 110, 14, session.content(ctx))
+	finish := func(ui UI) error {
+		ClosePopupWindow(ctx, "replace")
+		ctx.CommandHistory = append(ctx.CommandHistory, session.apply(ctx)...)
+		ctx.CommandDirty = true
+		ui.Focus("command")
+		return nil
+	}
+	popup.OnKey = func(ui UI, ch rune) error {
+		switch ch {
+		case 'y':
+			session.accepted[session.current] = true
+		case 'n':
+		case 'a':
+			for i := session.current; i < len(session.matches); i++ {
+				session.accepted[i] = true
+			}
+			return finish(ui)
+		case 's':
+			return finish(ui)
+		default:
+			return nil
+		}
+		if session.current++; session.current >= len(session.matches) {
+			return finish(ui)
+		}
+		popup.Title = title()
+		popup.Content = session.content(ctx)
+		return nil
+	}
+	ShowPopupWindow(ctx, popup)
+	return len(session.matches), len(session.lines), truncated, nil
+}
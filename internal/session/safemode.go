@@ -0,0 +1,54 @@
+package session
+
+import (
+	"flag"
+	"path/filepath"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 安全模式 ==========
+// debug-gocui --safe
+// 错误的断点配置可能让启动卡住或者一启动就把目标机搞挂，安全模式下：
+//   - 不打开任何项目，打开项目时保存的断点只加载、不武装（不生成/编译/挂载探针）
+//   - 所有数据后端（trace_pipe采集、/proc/kcore快照、自检、远程源码获取）都被禁用
+// 在TUI中修复配置后使用 safe off 恢复正常模式。
+
+// 命令行参数中的启动脚本（见 commands/script.go）
+type startupOptions struct {
+	Script string // --script：要执行的命令脚本
+	TUI    bool   // --tui：启动界面后执行脚本（默认无界面执行后退出）
+	RPC    string // --rpc：启动时在该Unix socket上提供JSON-RPC接口（见 commands/rpc.go）
+}
+
+// 解析命令行参数
+func ParseStartupFlags(ctx *DebuggerContext) startupOptions {
+	safe := flag.Bool("safe", false, "start without a project, with breakpoints disarmed and all backends disabled")
+	script := flag.String("script", "", "run TUI commands from a file without the UI and exit (non-zero on the first failure)")
+	tui := flag.Bool("tui", false, "with --script: start the UI and run the script in the command window")
+	rpc := flag.String("rpc", "", "listen for JSON-RPC clients on this Unix socket (\"default\" for $XDG_RUNTIME_DIR/kdebug-tui.sock)")
+	flag.Parse()
+	if *safe {
+		ctx.SafeMode = true
+		ctx.DemoMode = false
+		ctx.CommandHistory = append(ctx.CommandHistory,
+			Styled(ActiveTheme.Warning, "[SAFE MODE]")+" No project loaded, breakpoints are not armed, all backends are disabled",
+			"Repair the configuration (open, bp, watch, srcmap ...), then run 'safe off'")
+	}
+	options := startupOptions{Script: *script, TUI: *tui, RPC: *rpc}
+	if options.Script != "" {
+		// 打开项目后相对路径以项目根目录为基准，这里先固定为启动目录下的路径
+		if abs, err := filepath.Abs(options.Script); err == nil {
+			options.Script = abs
+		}
+	}
+	return options
+}
+
+// 安全模式下禁止使用的功能
+func CheckSafeMode(ctx *DebuggerContext, what string) error {
+	if ctx != nil && ctx.SafeMode {
+		return errcode.Errorf(errcode.ErrSafeMode, "安全模式下已禁用%s，修复配置后使用 safe off 恢复", what)
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 代码搜索功能 ==========
+// Ctrl+F 在当前文件中搜索，默认按子串、不区分大小写匹配。代码视图中 Alt+R 切换正则表达式、
+// Alt+C 切换区分大小写、Alt+W 切换全词匹配（按键可在 keys.toml 中修改），模式显示在代码窗口
+// 标题的搜索状态中，并随会话保存。各模式统一编译为正则表达式（RE2语法）。
+
+// 搜索模式（按会话保存）
+type SearchOptions struct {
+	Regex         bool `json:"regex,omitempty"`          // 搜索词是正则表达式
+	CaseSensitive bool `json:"case_sensitive,omitempty"` // 区分大小写
+	WholeWord     bool `json:"whole_word,omitempty"`     // 只匹配完整的单词（标识符）
+}
+
+// 按搜索模式编译搜索词
+func compileSearchPattern(term string, opts SearchOptions) (*regexp.Regexp, error) {
+	pattern := term
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(term)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %v", strings.TrimPrefix(err.Error(), "error parsing regexp: "))
+	}
+	return re, nil
+}
+
+// 搜索模式的简短说明，例如 "regex, match case, whole word"
+func SearchModeLabel(opts SearchOptions) string {
+	parts := []string{"text"}
+	if opts.Regex {
+		parts[0] = "regex"
+	}
+	if opts.CaseSensitive {
+		parts = append(parts, "match case")
+	} else {
+		parts = append(parts, "ignore case")
+	}
+	if opts.WholeWord {
+		parts = append(parts, "whole word")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 代码窗口标题中的搜索状态
+func SearchStatusLine(ctx *DebuggerContext) string {
+	mode := fmt.Sprintf(" [%s]", SearchModeLabel(ctx.SearchOptions))
+	switch {
+	case ctx.SearchError != "" && ctx.SearchTerm != "":
+		return fmt.Sprintf(" | Search: \"%s\" (%s)%s", ctx.SearchTerm, ctx.SearchError, mode)
+	case len(ctx.SearchResults) > 0:
+		return fmt.Sprintf(" | Search: \"%s\" (%d/%d)%s", ctx.SearchTerm, ctx.CurrentMatch+1, len(ctx.SearchResults), mode)
+	case ctx.SearchTerm != "":
+		return fmt.Sprintf(" | Search: \"%s\" (no results)%s", ctx.SearchTerm, mode)
+	default:
+		return fmt.Sprintf(" | Search: \"%s\"%s", ctx.SearchInput, mode)
+	}
+}
+
+// 启动搜索模式
+func StartSearchMode(ctx *DebuggerContext) {
+	if ctx == nil {
+		return
+	}
+	ctx.SearchMode = true
+	ctx.SearchInput = ""
+	ctx.SearchTerm = ""
+	ctx.SearchResults = nil
+	ctx.CurrentMatch = -1
+	ctx.SearchDirty = false
+	ctx.SearchError = ""
+}
+
+// 退出搜索模式
+func ExitSearchMode(ctx *DebuggerContext) {
+	if ctx == nil {
+		return
+	}
+	ctx.SearchMode = false
+	ctx.SearchInput = ""
+	ctx.SearchTerm = ""
+	ctx.SearchResults = nil
+	ctx.CurrentMatch = -1
+	ctx.SearchDirty = false
+	ctx.SearchError = ""
+}
+
+// 执行搜索
+func PerformSearch(ctx *DebuggerContext) {
+	if ctx == nil || ctx.Project == nil || ctx.Project.CurrentFile == "" || ctx.SearchTerm == "" {
+		ctx.SearchResults = nil
+		ctx.CurrentMatch = -1
+		return
+	}
+	
+	// 获取当前文件内容
+	file, err := project.ProjectSourceFile(ctx.Project, ctx.Project.CurrentFile)
+	if err != nil {
+		ctx.SearchResults = nil
+		ctx.CurrentMatch = -1
+		return
+	}
+	
+	// 清空之前的搜索结果
+	ctx.SearchResults = nil
+	ctx.SearchError = ""
+	re, err := compileSearchPattern(ctx.SearchTerm, ctx.SearchOptions)
+	if err != nil {
+		ctx.SearchError = err.Error()
+		ctx.CurrentMatch = -1
+		return
+	}
+	
+	// 在每一行中搜索（位置是原行中的字节偏移，跳过空匹配）
+	for lineIdx := 0; lineIdx < file.Len(); lineIdx++ {
+		line := file.Line(lineIdx)
+		for _, m := range re.FindAllStringIndex(line, -1) {
+			if m[0] == m[1] {
+				continue
+			}
+			ctx.SearchResults = append(ctx.SearchResults, project.SearchResult{
+				LineNumber:  lineIdx + 1, // 从1开始的行号
+				StartColumn: m[0],
+				EndColumn:   m[1],
+				Text:        line[m[0]:m[1]],
+			})
+		}
+	}
+	
+	// 设置当前匹配项
+	if len(ctx.SearchResults) > 0 {
+		ctx.CurrentMatch = 0
+	} else {
+		ctx.CurrentMatch = -1
+	}
+}
+
+// 大小写不敏感地查找子串，返回匹配在s中的字节范围，找不到时返回-1。
+// 逐字符比较而不是先ToLower整行：有些字符转小写后字节长度会变，偏移就对不上原行了
+func indexFold(s, substr string) (int, int) {
+	if substr == "" {
+		return -1, -1
+	}
+	for i := 0; i < len(s); {
+		if n := prefixFoldLen(s[i:], substr); n >= 0 {
+			return i, i + n
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return -1, -1
+}
+
+// s以prefix开头（忽略大小写）时返回s中这段前缀的字节长度，否则返回-1
+func prefixFoldLen(s, prefix string) int {
+	i := 0
+	for _, p := range prefix {
+		if i >= len(s) {
+			return -1
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != p && unicode.ToLower(r) != unicode.ToLower(p) {
+			return -1
+		}
+		i += size
+	}
+	return i
+}
+
+// 跳转到下一个匹配项
+func JumpToNextMatch(ctx *DebuggerContext) {
+	if ctx == nil || len(ctx.SearchResults) == 0 {
+		return
+	}
+	
+	// 循环到下一个匹配项
+	ctx.CurrentMatch = (ctx.CurrentMatch + 1) % len(ctx.SearchResults)
+	
+	// 滚动代码视图到匹配项所在行
+	if ctx.CurrentMatch >= 0 && ctx.CurrentMatch < len(ctx.SearchResults) {
+		targetLine := ctx.SearchResults[ctx.CurrentMatch].LineNumber
+		// 将目标行设置为视图中心
+		ctx.CodeScroll = targetLine - 10 // 向上偏移10行，让匹配项显示在中间
+		if ctx.CodeScroll < 0 {
+			ctx.CodeScroll = 0
+		}
+	}
+}
+
+// 跳转到上一个匹配项
+func JumpToPrevMatch(ctx *DebuggerContext) {
+	if ctx == nil || len(ctx.SearchResults) == 0 {
+		return
+	}
+	
+	// 循环到上一个匹配项
+	ctx.CurrentMatch = (ctx.CurrentMatch - 1 + len(ctx.SearchResults)) % len(ctx.SearchResults)
+	
+	// 滚动代码视图到匹配项所在行
+	if ctx.CurrentMatch >= 0 && ctx.CurrentMatch < len(ctx.SearchResults) {
+		targetLine := ctx.SearchResults[ctx.CurrentMatch].LineNumber
+		// 将目标行设置为视图中心
+		ctx.CodeScroll = targetLine - 10 // 向上偏移10行，让匹配项显示在中间
+		if ctx.CodeScroll < 0 {
+			ctx.CodeScroll = 0
+		}
+	}
+}
+
+// 在文本中高亮搜索结果
+func HighlightSearchMatches(line string, lineNumber int, ctx *DebuggerContext) string {
+	if ctx == nil || !ctx.SearchMode || ctx.SearchTerm == "" || len(ctx.SearchResults) == 0 {
+		return line
+	}
+	
+	// 找到当前行的所有匹配项
+	var matches []project.SearchResult
+	for _, result := range ctx.SearchResults {
+		if result.LineNumber == lineNumber {
+			matches = append(matches, result)
+		}
+	}
+	
+	if len(matches) == 0 {
+		return line
+	}
+	
+	// 从后往前处理匹配项，避免位置偏移问题
+	result := line
+	for i := len(matches) - 1; i >= 0; i-- {
+		match := matches[i]
+		
+		// 检查是否是当前选中的匹配项
+		isCurrentMatch := false
+		if ctx.CurrentMatch >= 0 && ctx.CurrentMatch < len(ctx.SearchResults) {
+			currentResult := ctx.SearchResults[ctx.CurrentMatch]
+			if currentResult.LineNumber == match.LineNumber && 
+			   currentResult.StartColumn == match.StartColumn {
+				isCurrentMatch = true
+			}
+		}
+		
+		// 应用高亮样式
+		before := result[:match.StartColumn]
+		matchText := result[match.StartColumn:match.EndColumn]
+		after := result[match.EndColumn:]
+		
+		if isCurrentMatch {
+			// 当前匹配项
+			result = before + Styled(ActiveTheme.MatchFocus, matchText) + after
+		} else {
+			// 其他匹配项
+			result = before + Styled(ActiveTheme.Match, matchText) + after
+		}
+	}
+	
+	return result
+}
+
+// ========== 项目内多文件搜索 ==========
+// grep <term> 在项目的全部C/C++源文件和头文件中搜索（大小写不敏感，与 / 搜索一致），
+// 结果窗口按 file:line 列出匹配行，Enter 在代码窗口中打开对应位置。
+
+// 结果数上限（超出后停止搜索）
+const MaxProjectMatches = 1000
+
+// 多文件搜索的一个匹配
+type ProjectMatch struct {
+	File   string
+	Line   int // 从1开始
+	Column int // 匹配开始列（字节，从0开始）
+	Text   string
+}
+
+// 在项目中搜索，返回匹配和是否因达到上限而截断
+func searchProjectFiles(root, term string) ([]ProjectMatch, bool) {
+	matches := make([]ProjectMatch, 0)
+	truncated := false
+	project.WalkProjectSources(root, func(path string) bool {
+		lines, err := project.ReadFileContent(path)
+		if err != nil {
+			return true
+		}
+		for i, line := range lines {
+			col, _ := indexFold(line, term)
+			if col < 0 {
+				continue
+			}
+			if len(matches) >= MaxProjectMatches {
+				truncated = true
+				return false
+			}
+			matches = append(matches, ProjectMatch{File: path, Line: i + 1, Column: col, Text: line})
+		}
+		return true
+	})
+	return matches, truncated
+}
+
+// 结果窗口中的一行：file:line 和高亮了匹配的源码行
+func projectMatchLine(ctx *DebuggerContext, m ProjectMatch, term string) string {
+	text := m.Text
+	if start, end := indexFold(text, term); start >= 0 {
+		text = text[:start] + Styled(ActiveTheme.Match, text[start:end]) + text[end:]
+	}
+	location := fmt.Sprintf("%s:%d", project.ProjectRelativePath(ctx.Project, m.File), m.Line)
+	return fmt.Sprintf("%s %s", Styled(ActiveTheme.Note, fmt.Sprintf("%-32s", location)), strings.TrimSpace(strings.ReplaceAll(text, "\t", "    ")))
+}
+
+// 显示搜索结果窗口，返回匹配数、涉及的文件数和是否截断
+func ShowGrepPopup(ctx *DebuggerContext, term string) (int, int, bool, error) {
+	if ctx.Project == nil {
+		return 0, 0, false, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	matches, truncated := searchProjectFiles(ctx.Project.RootPath, term)
+	files := make(map[string]bool)
+	content := make([]string, 0, len(matches)+3)
+	for _, m := range matches {
+		files[m.File] = true
+		content = append(content, projectMatchLine(ctx, m, term))
+	}
+	if len(matches) == 0 {
+		content = append(content, fmt.Sprintf("No matches for '%s'", term))
+	}
+	footer := "Enter: open in the code view"
+	if truncated {
+		footer = fmt.Sprintf("Stopped after %d matches, refine the term | %s", MaxProjectMatches, footer)
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, footer))
+
+	ClosePopupWindow(ctx, "grep")
+	popup := CreatePopupWindow(ctx, "grep", fmt.Sprintf("Grep: %s (%d in %d files)", term, len(matches), len(files)), 110, 25, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		if index < 0 || index >= len(matches) {
+			return nil
+		}
+		m := matches[index]
+		if err := OpenSourceAt(ui, ctx, m.File, m.Line); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ClosePopupWindow(ctx, "grep")
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[GREP] %s:%d", project.ProjectRelativePath(ctx.Project, m.File), m.Line))
+			ui.Focus("code")
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	ShowPopupWindow(ctx, popup)
+	return len(matches), len(files), truncated, nil
+}
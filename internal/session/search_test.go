@@ -0,0 +1,131 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompileSearchPattern(t *testing.T) {
+	tests := []struct {
+		term string
+		opts SearchOptions
+		line string
+		want int // 匹配个数
+	}{
+		{"dev", SearchOptions{}, "struct device *dev = DEV;", 3},
+		{"dev", SearchOptions{CaseSensitive: true}, "struct device *dev = DEV;", 2},
+		{"dev", SearchOptions{WholeWord: true}, "struct device *dev = DEV;", 2},
+		{"dev", SearchOptions{WholeWord: true, CaseSensitive: true}, "struct device *dev = DEV;", 1},
+		{"a.b", SearchOptions{}, "a.b axb", 1},
+		{"a.b", SearchOptions{Regex: true}, "a.b axb", 2},
+		{`\w+_init`, SearchOptions{Regex: true, WholeWord: true}, "my_init(); xinit_initx", 1},
+	}
+	for _, tt := range tests {
+		re, err := compileSearchPattern(tt.term, tt.opts)
+		if err != nil {
+			t.Fatalf("compileSearchPattern(%q, %+v): %v", tt.term, tt.opts, err)
+		}
+		if got := len(re.FindAllStringIndex(tt.line, -1)); got != tt.want {
+			t.Errorf("compileSearchPattern(%q, %+v) on %q: %d matches, want %d", tt.term, tt.opts, tt.line, got, tt.want)
+		}
+	}
+	if _, err := compileSearchPattern("(", SearchOptions{Regex: true}); err == nil {
+		t.Error("compileSearchPattern(\"(\") with regex: expected error")
+	}
+}
+
+func TestLineReplacements(t *testing.T) {
+	line := "DBG(dev, DBG_LEVEL); dbg();"
+	re, _ := compileSearchPattern("DBG", SearchOptions{CaseSensitive: true, WholeWord: true})
+	matches := lineReplacements(re, line, "dev_dbg", false)
+	if len(matches) != 1 {
+		t.Fatalf("whole word: %d matches, want 1", len(matches))
+	}
+	if got := applyLineReplacements(line, matches); got != "dev_dbg(dev, DBG_LEVEL); dbg();" {
+		t.Errorf("applyLineReplacements = %q", got)
+	}
+
+	re, _ = compileSearchPattern(`(\w+)_LEVEL`, SearchOptions{Regex: true, CaseSensitive: true})
+	matches = lineReplacements(re, line, "${1}_VERBOSITY", true)
+	if got := applyLineReplacements(line, matches); got != "DBG(dev, DBG_VERBOSITY); dbg();" {
+		t.Errorf("regex group replacement = %q", got)
+	}
+
+	// 非正则模式下 $ 原样保留
+	re, _ = compileSearchPattern("dbg", SearchOptions{})
+	matches = lineReplacements(re, line, "$x", false)
+	if got := applyLineReplacements(line, matches[:1]); got != "$x(dev, DBG_LEVEL); dbg();" {
+		t.Errorf("literal replacement = %q", got)
+	}
+}
+
+func TestParseReplaceArgs(t *testing.T) {
+	if p, r, ok := ParseReplaceArgs(`DEBUG_PRINT  pr_debug`); !ok || p != "DEBUG_PRINT" || r != "pr_debug" {
+		t.Errorf("ParseReplaceArgs = %q, %q, %v", p, r, ok)
+	}
+	if _, r, ok := ParseReplaceArgs(`old ""`); !ok || r != "" {
+		t.Errorf("empty replacement = %q, %v", r, ok)
+	}
+	if _, _, ok := ParseReplaceArgs("old"); ok {
+		t.Error("ParseReplaceArgs without replacement: expected failure")
+	}
+
+	// 查找文本含空格
+	for args, want := range map[string][2]string{
+		`"spin_lock(&dev->lock)" "mutex_lock(&dev->mutex)"`: {"spin_lock(&dev->lock)", "mutex_lock(&dev->mutex)"},
+		`"dev->count ++" dev->count++`:                      {"dev->count ++", "dev->count++"},
+		`"say \"hi\"" ""`:                                   {`say "hi"`, ""},
+		`/unsigned long/u64/`:                               {"unsigned long", "u64"},
+		`/a\/b c/x y`:                                       {"a/b c", "x y"},
+		`/(\w+) = 0;/$1 = 1;/`:                              {`(\w+) = 0;`, "$1 = 1;"},
+	} {
+		p, r, ok := ParseReplaceArgs(args)
+		if !ok || p != want[0] || r != want[1] {
+			t.Errorf("ParseReplaceArgs(%s) = %q, %q, %v; want %q, %q", args, p, r, ok, want[0], want[1])
+		}
+	}
+	for _, args := range []string{`"unterminated old`, `"old"`, `/old/new/extra/`, `//new/`, `"old" "new" tail`} {
+		if _, _, ok := ParseReplaceArgs(args); ok {
+			t.Errorf("ParseReplaceArgs(%s): expected failure", args)
+		}
+	}
+}
+
+func TestReplacePatternWithSpaces(t *testing.T) {
+	root := t.TempDir()
+	src := "static int x;\nstatic  int y;\nunsigned long z;\n"
+	if err := os.WriteFile(filepath.Join(root, "a.c"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pattern, replacement, ok := ParseReplaceArgs(`"unsigned long" u64`)
+	if !ok {
+		t.Fatal("ParseReplaceArgs failed")
+	}
+	session, _, err := findProjectReplacements(root, pattern, replacement, SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.matches) != 1 || session.matches[0].Line != 3 {
+		t.Fatalf("matches = %+v", session.matches)
+	}
+}
+
+func TestIndexFold(t *testing.T) {
+	tests := []struct {
+		s, substr  string
+		start, end int
+	}{
+		{"int Foo = 1;", "foo", 4, 7},
+		{"/* 注释 Buffer */", "BUFFER", 10, 16},
+		{"İx foo", "foo", 4, 7},
+		{"abc", "d", -1, -1},
+		{"abc", "", -1, -1},
+	}
+	for _, tt := range tests {
+		start, end := indexFold(tt.s, tt.substr)
+		if start != tt.start || end != tt.end {
+			t.Errorf("indexFold(%q, %q) = %d, %d, want %d, %d", tt.s, tt.substr, start, end, tt.start, tt.end)
+		}
+	}
+}
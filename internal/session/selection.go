@@ -0,0 +1,37 @@
+package session
+
+import "debug-gocui/internal/project"
+
+// ========== 文本选择功能 ==========
+
+// 行中第col列（视图光标的屏幕列，宽字符占两列）所在的单词，不在单词上时返回空串
+func WordAt(s string, col int) string {
+	if col < 0 {
+		return ""
+	}
+	line := []rune(s)
+	cx := project.ColumnIndex(s, col)
+	if cx >= len(line) {
+		return ""
+	}
+	// 找到单词边界
+	start := cx
+	end := cx
+	
+	// 向左找单词开始
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	
+	// 向右找单词结束
+	for end < len(line) && isWordChar(line[end]) {
+		end++
+	}
+	return string(line[start:end])
+}
+
+// 判断是否为单词字符（非ASCII字符不属于单词）
+func isWordChar(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || 
+	       (c >= '0' && c <= '9') || c == '_' || c == 'x'
+}
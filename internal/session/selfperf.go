@@ -0,0 +1,210 @@
+package session
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ========== 自身性能报告 ==========
+// 长时间采集时调试器本身不能成为负担：记录本进程的CPU占用、RSS、协程数、
+// 事件处理延迟（从trace_pipe读到一行到UI线程处理完）以及界面刷新延迟，
+// 刷新延迟超过期限时在命令窗口和状态栏提示。
+
+// 界面刷新期限（更新协程每100ms提交一次刷新）
+const uiRefreshDeadline = 250 * time.Millisecond
+
+// 刷新超时提示的最小间隔
+const uiLagWarnInterval = 30 * time.Second
+
+// 资源采样间隔
+const perfSampleInterval = time.Second
+
+// 面板保留的采样数
+const perfHistory = 60
+
+// 自身性能统计
+type PerfStats struct {
+	Started     time.Time
+	lastSample  time.Time
+	lastCPUTime time.Duration
+
+	CPUPercent float64
+	RSS        uint64 // 字节
+	HeapAlloc  uint64 // 字节
+	Goroutines int
+	CPUHistory []int // CPU占用（百分比）
+	RSSHistory []int // RSS（MB）
+
+	FrameLatency time.Duration // 最近一次刷新从提交到执行完成的时间
+	FrameMax     time.Duration
+	Frames       int
+	MissedFrames int // 超过刷新期限的次数
+	lastLagWarn  time.Time
+
+	EventLatencyMax   time.Duration
+	eventLatencySum   time.Duration
+	eventLatencyCount int
+}
+
+// 获取（必要时创建）性能统计
+func perfStats(ctx *DebuggerContext) *PerfStats {
+	if ctx.Perf == nil {
+		ctx.Perf = &PerfStats{Started: time.Now()}
+	}
+	return ctx.Perf
+}
+
+// 本进程累计CPU时间（用户态+内核态）
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+// 本进程RSS（/proc/self/statm第二个字段，单位为页）
+func processRSS() uint64 {
+	data, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, _ := strconv.ParseUint(fields[1], 10, 64)
+	return pages * uint64(os.Getpagesize())
+}
+
+// 采样资源占用
+func samplePerf(stats *PerfStats, now time.Time) {
+	cpu := processCPUTime()
+	if !stats.lastSample.IsZero() {
+		if wall := now.Sub(stats.lastSample); wall > 0 {
+			stats.CPUPercent = float64(cpu-stats.lastCPUTime) * 100 / float64(wall)
+		}
+	}
+	stats.lastSample = now
+	stats.lastCPUTime = cpu
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats.HeapAlloc = mem.HeapAlloc
+	stats.RSS = processRSS()
+	stats.Goroutines = runtime.NumGoroutine()
+
+	stats.CPUHistory = appendBounded(stats.CPUHistory, int(stats.CPUPercent+0.5))
+	stats.RSSHistory = appendBounded(stats.RSSHistory, int(stats.RSS>>20))
+}
+
+// 追加采样并保留最近perfHistory个
+func appendBounded(values []int, v int) []int {
+	values = append(values, v)
+	if len(values) > perfHistory {
+		values = values[len(values)-perfHistory:]
+	}
+	return values
+}
+
+// 记录一次界面刷新（在更新协程提交的刷新中调用）
+// wait为提交到开始执行的等待时间，work为刷新本身耗时
+func RecordFrame(ctx *DebuggerContext, wait, work time.Duration) {
+	stats := perfStats(ctx)
+	now := time.Now()
+	stats.Frames++
+	stats.FrameLatency = wait + work
+	if stats.FrameLatency > stats.FrameMax {
+		stats.FrameMax = stats.FrameLatency
+	}
+	if stats.FrameLatency > uiRefreshDeadline {
+		stats.MissedFrames++
+		if now.Sub(stats.lastLagWarn) >= uiLagWarnInterval {
+			stats.lastLagWarn = now
+			ctx.CommandHistory = append(ctx.CommandHistory, Styled(ActiveTheme.Warning, "[PERF]")+fmt.Sprintf(" UI refresh took %s (deadline %s), see 'perf'", stats.FrameLatency.Truncate(time.Millisecond), uiRefreshDeadline))
+			ctx.CommandDirty = true
+		}
+	}
+	if now.Sub(stats.lastSample) >= perfSampleInterval {
+		samplePerf(stats, now)
+		refreshPerfPopup(ctx)
+	}
+}
+
+// 记录事件处理延迟（从读到trace_pipe行到处理完成）
+func recordEventLatency(ctx *DebuggerContext, latency time.Duration) {
+	stats := perfStats(ctx)
+	stats.eventLatencySum += latency
+	stats.eventLatencyCount++
+	if latency > stats.EventLatencyMax {
+		stats.EventLatencyMax = latency
+	}
+}
+
+// 界面刷新是否正在落后（用于状态栏提示）
+func UILagging(ctx *DebuggerContext) bool {
+	return ctx.Perf != nil && ctx.Perf.FrameLatency > uiRefreshDeadline
+}
+
+// 字节数的可读形式
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// 性能面板内容
+func perfLines(ctx *DebuggerContext) []string {
+	stats := perfStats(ctx)
+	eventAvg := time.Duration(0)
+	if stats.eventLatencyCount > 0 {
+		eventAvg = stats.eventLatencySum / time.Duration(stats.eventLatencyCount)
+	}
+	missed := 0.0
+	if stats.Frames > 0 {
+		missed = float64(stats.MissedFrames) * 100 / float64(stats.Frames)
+	}
+	frame := stats.FrameLatency.Truncate(time.Microsecond).String()
+	if stats.FrameLatency > uiRefreshDeadline {
+		frame = Styled(ActiveTheme.Alert, frame)
+	}
+	return []string{
+		fmt.Sprintf("debug-gocui pid %d   %s %s/%s   up %s", os.Getpid(), runtime.Version(), runtime.GOOS, runtime.GOARCH, time.Since(stats.Started).Truncate(time.Second)),
+		"",
+		fmt.Sprintf("CPU:        %5.1f%%  │%s│", stats.CPUPercent, statsSparkline(stats.CPUHistory)),
+		fmt.Sprintf("RSS:        %-8s │%s│", formatBytes(stats.RSS), statsSparkline(stats.RSSHistory)),
+		fmt.Sprintf("Go heap:    %s", formatBytes(stats.HeapAlloc)),
+		fmt.Sprintf("Goroutines: %d", stats.Goroutines),
+		"",
+		fmt.Sprintf("UI refresh: last %s, max %s, deadline %s", frame, stats.FrameMax.Truncate(time.Microsecond), uiRefreshDeadline),
+		fmt.Sprintf("            missed %d of %d refreshes (%.1f%%)", stats.MissedFrames, stats.Frames, missed),
+		fmt.Sprintf("Events:     %d processed, latency avg %s, max %s", stats.eventLatencyCount, eventAvg.Truncate(time.Microsecond), stats.EventLatencyMax.Truncate(time.Microsecond)),
+		fmt.Sprintf("Buffers:    %d events (limit %d), %d history lines", len(ctx.Events), maxEvents, len(ctx.CommandHistory)),
+	}
+}
+
+// 显示性能面板
+func ShowPerfPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, "perf")
+	popup := CreatePopupWindow(ctx, "perf", "About / Performance", 90, 18, perfLines(ctx))
+	ShowPopupWindow(ctx, popup)
+}
+
+// 性能面板打开时刷新内容
+func refreshPerfPopup(ctx *DebuggerContext) {
+	if popup := FindPopupWindow(ctx, "perf"); popup != nil {
+		popup.Content = perfLines(ctx)
+	}
+}
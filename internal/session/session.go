@@ -0,0 +1,115 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ========== 会话状态 ==========
+// 退出时把面板边界、最近打开的项目和文件、各面板滚动位置、焦点窗口和主题保存到
+// ~/.config/kdebug-tui/state.json，下次启动时恢复，不用每次重新拖拽窗口边界。
+// 代码搜索模式（正则/大小写/全词）也随会话保存。
+// 布局按保存时的终端尺寸记录，尺寸不同时由 reflowLayout 按比例缩放。
+// 安全模式（--safe）下只恢复布局，不打开项目。
+
+// 会话状态文件名（与 keys.toml 同目录）
+const sessionStateFile = "state.json"
+
+// 可恢复焦点的面板
+var SessionFocusViews = []string{"filebrowser", "code", "registers", "variables", "stack", "command"}
+
+// 保存的布局（只保存边界位置，不保存拖拽状态）
+type SessionLayout struct {
+	LeftPanelWidth   int `json:"left_panel_width"`
+	RightPanelWidth  int `json:"right_panel_width"`
+	CommandHeight    int `json:"command_height"`
+	RightPanelSplit1 int `json:"right_panel_split1"`
+	RightPanelSplit2 int `json:"right_panel_split2"`
+	ScreenWidth      int `json:"screen_width"`
+	ScreenHeight     int `json:"screen_height"`
+}
+
+// 会话状态
+type SessionState struct {
+	Layout  *SessionLayout `json:"layout,omitempty"`
+	Project string         `json:"project,omitempty"`
+	File    string         `json:"file,omitempty"`
+	Scroll  map[string]int `json:"scroll,omitempty"` // 面板名 → 滚动位置
+	Focus   string         `json:"focus,omitempty"`
+	Theme   string         `json:"theme,omitempty"`
+	Search  SearchOptions  `json:"search,omitempty"` // 代码搜索模式
+}
+
+// 会话状态文件路径
+func sessionStatePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, KeyConfigDir, sessionStateFile), nil
+}
+
+// 读取会话状态（文件不存在时返回nil）
+func LoadSessionState() (*SessionState, error) {
+	path, err := sessionStatePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取会话状态失败: %v", err)
+	}
+	state := &SessionState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析会话状态失败: %v", err)
+	}
+	return state, nil
+}
+
+// 保存会话状态
+func SaveSessionState(state *SessionState) error {
+	path, err := sessionStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化会话状态失败: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存会话状态失败: %v", err)
+	}
+	return nil
+}
+
+// 启动时恢复主题和布局（在第一次layout之前调用，终端尺寸不同时由 handleTerminalResize 按比例重排）
+func RestoreSessionLayout(ctx *DebuggerContext, state *SessionState) {
+	if state != nil && state.Theme != "" {
+		SetTheme(state.Theme)
+	}
+	if state != nil {
+		ctx.SearchOptions = state.Search
+	}
+	if state == nil || state.Layout == nil || state.Layout.ScreenWidth <= 0 || state.Layout.ScreenHeight <= 0 {
+		return
+	}
+	saved := state.Layout
+	ctx.Layout = &DynamicLayout{
+		LeftPanelWidth:   saved.LeftPanelWidth,
+		RightPanelWidth:  saved.RightPanelWidth,
+		CommandHeight:    saved.CommandHeight,
+		RightPanelSplit1: saved.RightPanelSplit1,
+		RightPanelSplit2: saved.RightPanelSplit2,
+		ScreenWidth:      saved.ScreenWidth,
+		ScreenHeight:     saved.ScreenHeight,
+	}
+}
@@ -0,0 +1,210 @@
+package session
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 定时快照 ==========
+// 不依赖断点命中，每隔N秒读取一次监视的全局变量，结果作为snapshot事件加入事件列表，
+// 这样计数器泄漏、内存增长等缓慢变化在没有断点触发时也能在时间线上看到。
+// 变量地址来自 /proc/kallsyms，内存通过 /proc/kcore 读取（需要root）。
+
+// 最小采样间隔
+const minSnapshotInterval = time.Second
+
+// 监视变量的读取位置
+type snapshotTarget struct {
+	Expr string
+	Addr uint64
+	Size int
+}
+
+// 监视变量的一次采样结果
+type snapshotSample struct {
+	Expr  string
+	Value string
+	Err   error
+}
+
+// 从模块符号表获取全局变量大小（找不到时按int处理）
+func globalVariableSize(ctx *DebuggerContext, name string) int {
+	if ctx.Project == nil {
+		return 4
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return 4
+	}
+	file, err := elf.Open(module)
+	if err != nil {
+		return 4
+	}
+	defer file.Close()
+	symbols, err := file.Symbols()
+	if err != nil {
+		return 4
+	}
+	for _, sym := range symbols {
+		if sym.Name == name && elf.ST_TYPE(sym.Info) == elf.STT_OBJECT {
+			switch sym.Size {
+			case 1, 2, 4, 8:
+				return int(sym.Size)
+			}
+		}
+	}
+	return 4
+}
+
+// 解析监视表达式对应的全局变量地址和大小
+func ResolveSnapshotTargets(ctx *DebuggerContext) []snapshotTarget {
+	targets := make([]snapshotTarget, 0)
+	for _, expr := range WatchExpressions(ctx) {
+		target := snapshotTarget{Expr: expr, Size: globalVariableSize(ctx, expr)}
+		if addr, err := project.ReadKallsymsSymbol(expr); err == nil {
+			target.Addr = addr
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// 通过 /proc/kcore 读取内核虚拟地址处的内存
+func readKcore(kcore *elf.File, addr uint64, size int) ([]byte, error) {
+	for _, prog := range kcore.Progs {
+		if prog.Type != elf.PT_LOAD || addr < prog.Vaddr || addr+uint64(size) > prog.Vaddr+prog.Memsz {
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := prog.ReadAt(buf, int64(addr-prog.Vaddr)); err != nil {
+			return nil, errcode.Errorf(errcode.ErrKcore, "读取 0x%x 失败: %v", addr, err)
+		}
+		return buf, nil
+	}
+	return nil, errcode.Errorf(errcode.ErrKcore, "地址 0x%x 不在 /proc/kcore 映射范围内", addr)
+}
+
+// 采样所有监视变量（按本机字节序的有符号整数解析）
+func TakeSnapshot(kcore *elf.File, targets []snapshotTarget) []snapshotSample {
+	samples := make([]snapshotSample, 0, len(targets))
+	for _, target := range targets {
+		sample := snapshotSample{Expr: target.Expr}
+		if target.Addr == 0 {
+			sample.Err = errcode.Errorf(errcode.ErrNoSymbol, "符号 %s 不在 /proc/kallsyms 中（或地址被kptr_restrict隐藏）", target.Expr)
+			samples = append(samples, sample)
+			continue
+		}
+		data, err := readKcore(kcore, target.Addr, target.Size)
+		if err != nil {
+			sample.Err = err
+			samples = append(samples, sample)
+			continue
+		}
+		var value int64
+		switch target.Size {
+		case 1:
+			value = int64(int8(data[0]))
+		case 2:
+			value = int64(int16(binary.NativeEndian.Uint16(data)))
+		case 8:
+			value = int64(binary.NativeEndian.Uint64(data))
+		default:
+			value = int64(int32(binary.NativeEndian.Uint32(data)))
+		}
+		sample.Value = fmt.Sprintf("%d", value)
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// 记录一次快照：更新监视值并加入事件列表（在UI线程中调用）
+func RecordSnapshot(ctx *DebuggerContext, samples []snapshotSample) int {
+	event := DebugEvent{Kind: "snapshot", Time: time.Now(), CPU: -1}
+	failed := 0
+	for _, sample := range samples {
+		if sample.Err != nil {
+			failed++
+			continue
+		}
+		event.Values = append(event.Values, project.EventValue{Name: sample.Expr, Value: sample.Value})
+	}
+	if len(event.Values) > 0 {
+		AppendEvent(ctx, event)
+		RefreshStatsPopup(ctx)
+	}
+	return failed
+}
+
+// 启动定时快照
+func StartSnapshots(ui UI, ctx *DebuggerContext, interval time.Duration) error {
+	if ctx.Project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	if err := CheckSafeMode(ctx, "定时快照"); err != nil {
+		return err
+	}
+	if len(WatchExpressions(ctx)) == 0 {
+		return fmt.Errorf("没有监视表达式，请先使用 watch <var> 添加")
+	}
+	if interval < minSnapshotInterval {
+		return fmt.Errorf("采样间隔不能小于 %s", minSnapshotInterval)
+	}
+	kcore, err := elf.Open("/proc/kcore")
+	if err != nil {
+		return errcode.Errorf(errcode.ErrKcore, "无法打开 /proc/kcore（需要root权限）: %v", err)
+	}
+
+	StopSnapshots(ctx)
+	targets := ResolveSnapshotTargets(ctx)
+	stop := make(chan struct{})
+	ctx.SnapshotStop = stop
+	ctx.SnapshotInterval = interval
+
+	go func() {
+		defer kcore.Close()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		reported := false
+		for {
+			samples := TakeSnapshot(kcore, targets)
+			ui.Update(func(ui UI) error {
+				if ctx.SnapshotStop != stop {
+					return nil
+				}
+				// 只报告第一次的读取错误，避免每个周期刷屏
+				if failed := RecordSnapshot(ctx, samples); failed > 0 && !reported {
+					reported = true
+					for _, sample := range samples {
+						if sample.Err != nil {
+							ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[SNAPSHOT] %s: %v", sample.Expr, sample.Err))
+						}
+					}
+					ctx.CommandDirty = true
+				}
+				return nil
+			})
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// 停止定时快照
+func StopSnapshots(ctx *DebuggerContext) bool {
+	if ctx.SnapshotStop == nil {
+		return false
+	}
+	close(ctx.SnapshotStop)
+	ctx.SnapshotStop = nil
+	ctx.SnapshotInterval = 0
+	return true
+}
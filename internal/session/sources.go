@@ -0,0 +1,210 @@
+package session
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 源码定位与按需获取 ==========
+// 调用栈和DWARF行号信息中的路径通常是内核构建机上的绝对路径，本地项目中并不存在。
+// 先按路径替换表映射，再在项目中查找，最后可从配置的内核git仓库或URL获取单个文件。
+
+// 获取的源码缓存目录（位于项目根目录下）
+const sourceCacheDir = ".debug_sources"
+
+// 调用栈帧
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// 示例调用栈（未接入数据后端时显示）
+var SampleStackFrames = []StackFrame{
+	{"taco_sys_init", "kernel_debugger_tui.c", 156},
+	{"taco_sys_mmz_alloc", "taco_sys_mmz.c", 89},
+	{"taco_sys_init", "taco_sys_init.c", 45},
+}
+
+// 当前可跳转的调用栈：回放的当前帧和时间线选中的事件优先，其次是真实数据，演示模式下使用示例数据
+func currentStackFrames(ctx *DebuggerContext) []StackFrame {
+	if frames, replaying := ReplayStackFrames(ctx); replaying {
+		return frames
+	}
+	if frames, pinned := TimelineStackFrames(ctx); pinned {
+		return frames
+	}
+	if len(ctx.StackFrames) > 0 {
+		return ctx.StackFrames
+	}
+	if ctx.DemoMode {
+		return SampleStackFrames
+	}
+	return nil
+}
+
+// 应用路径替换规则（最长前缀优先）
+func SubstituteSourcePath(ctx *DebuggerContext, path string) string {
+	best := -1
+	for i, sub := range ctx.Project.Settings.SourceMap {
+		if strings.HasPrefix(path, sub.From) && (best < 0 || len(sub.From) > len(ctx.Project.Settings.SourceMap[best].From)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return path
+	}
+	sub := ctx.Project.Settings.SourceMap[best]
+	return sub.To + strings.TrimPrefix(path, sub.From)
+}
+
+// 将调试信息中的源码路径解析为本地文件，必要时按配置获取
+// 返回本地路径和来源说明
+func resolveSourcePath(ctx *DebuggerContext, path string) (string, string, error) {
+	root := ctx.Project.RootPath
+	mapped := SubstituteSourcePath(ctx, path)
+
+	candidates := []string{mapped}
+	if !filepath.IsAbs(mapped) {
+		candidates = []string{filepath.Join(root, mapped)}
+	}
+	for _, candidate := range candidates {
+		if project.FileExists(candidate) {
+			if mapped != path {
+				return candidate, "source map", nil
+			}
+			return candidate, "local", nil
+		}
+	}
+
+	// 之前获取过的文件
+	fetchPath := strings.TrimPrefix(filepath.ToSlash(mapped), "/")
+	cached := filepath.Join(root, sourceCacheDir, filepath.FromSlash(fetchPath))
+	if project.FileExists(cached) {
+		return cached, "cache", nil
+	}
+
+	// 项目中的同名文件
+	if found := project.FindInProject(root, filepath.Base(path)); found != "" {
+		return found, "project (by name)", nil
+	}
+
+	// 从配置的内核源码获取
+	if err := CheckSafeMode(ctx, "源码获取"); err != nil {
+		return "", "", err
+	}
+	data, source, err := fetchSource(ctx.Project.Settings.SourceFetch, fetchPath)
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(cached), 0755); err != nil {
+		return "", "", fmt.Errorf("创建源码缓存目录失败: %v", err)
+	}
+	if err := ioutil.WriteFile(cached, data, 0644); err != nil {
+		return "", "", fmt.Errorf("保存获取的源码失败: %v", err)
+	}
+	return cached, source, nil
+}
+
+// 按配置获取单个源码文件
+func fetchSource(cfg *project.SourceFetchConfig, path string) ([]byte, string, error) {
+	if cfg == nil || (cfg.GitTree == "" && cfg.URL == "") {
+		return nil, "", errcode.Errorf(errcode.ErrSourceFetch, "本地找不到源码且未配置获取方式: %s (使用 srcmap 或 srcfetch)", path)
+	}
+	ref := cfg.GitRef
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	if cfg.GitTree != "" {
+		data, err := exec.Command("git", "-C", cfg.GitTree, "show", ref+":"+path).Output()
+		if err == nil {
+			return data, fmt.Sprintf("git %s@%s", cfg.GitTree, ref), nil
+		}
+		if cfg.URL == "" {
+			return nil, "", errcode.Errorf(errcode.ErrSourceFetch, "从git仓库获取 %s 失败: %v", path, err)
+		}
+	}
+
+	url := strings.NewReplacer("{path}", path, "{ref}", ref).Replace(cfg.URL)
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrSourceFetch, "下载源码失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errcode.Errorf(errcode.ErrSourceFetch, "下载源码失败: %s (%s)", resp.Status, url)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", errcode.Errorf(errcode.ErrSourceFetch, "读取下载内容失败: %v", err)
+	}
+	return data, url, nil
+}
+
+// 在代码视图中打开文件并滚动到指定行
+func OpenSourceAt(ui UI, ctx *DebuggerContext, path string, line int) error {
+	if _, err := project.ProjectSourceFile(ctx.Project, path); err != nil {
+		return fmt.Errorf("打开源码文件失败: %v", err)
+	}
+
+	SwitchCodeFile(ctx, path)
+	TouchWorkingSet(ctx, path, line, "visited")
+	ctx.CodeScroll = line - 1
+	if ctx.CodeScroll < 0 {
+		ctx.CodeScroll = 0
+	}
+	ui.SetCursor("code", 0, 2)
+	ui.Focus("code")
+	return nil
+}
+
+// 解析 path[:line]
+func ParseSourceLocation(spec string) (string, int) {
+	if idx := strings.LastIndex(spec, ":"); idx > 0 {
+		if line, err := strconv.Atoi(spec[idx+1:]); err == nil {
+			return spec[:idx], line
+		}
+	}
+	return spec, 1
+}
+
+// 打开调试信息中引用的源码位置（路径解析失败时按配置获取）
+func OpenDebugSource(ui UI, ctx *DebuggerContext, path string, line int) (string, string, error) {
+	if ctx.Project == nil {
+		return "", "", fmt.Errorf("没有打开的项目")
+	}
+	local, source, err := resolveSourcePath(ctx, path)
+	if err != nil {
+		return "", "", err
+	}
+	return local, source, OpenSourceAt(ui, ctx, local, line)
+}
+
+// 跳转到调用栈第n帧（从0开始）
+func JumpToFrame(ui UI, ctx *DebuggerContext, n int) (StackFrame, string, error) {
+	frames := currentStackFrames(ctx)
+	if n < 0 || n >= len(frames) {
+		return StackFrame{}, "", fmt.Errorf("栈帧编号超出范围: %d (共%d帧)", n, len(frames))
+	}
+	frame := frames[n]
+	if frame.File == "" {
+		return frame, "", errcode.Errorf(errcode.ErrNoDebugInfo, "栈帧 %s() 没有源码位置", frame.Function)
+	}
+	local, source, err := OpenDebugSource(ui, ctx, frame.File, frame.Line)
+	if err != nil {
+		return frame, "", err
+	}
+	return frame, fmt.Sprintf("%s (%s)", project.ProjectRelativePath(ctx.Project, local), source), nil
+}
@@ -0,0 +1,194 @@
+package session
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 成对探针耗时测量 ==========
+// span <entry> [exit] [by pid|tgid|<arg>]
+// entry函数入口记下bpf_ktime_get_ns()，存入以配对键为键的hash map；exit函数入口
+// （省略exit时为entry函数返回，kretprobe）取出时间戳，输出 [SPAN-N] 事件，值为本次调用的纳秒数。
+// 默认按线程配对；按参数配对（如 span submit_req complete_req by req）时两端读取同名参数，
+// 可以测量跨线程、跨中断的流程。参数名从BTF原型解析，也可以直接写 arg0..arg5。
+// span hist 按2的幂分桶显示耗时直方图和分位数，即funclatency的用法。
+
+// 耗时直方图窗口ID
+const spanPopupID = "span-hist"
+
+// 每个span的hash map容量（未配对的入口超过容量后不再记录）
+const SpanMapEntries = 10240
+
+var (
+	spanFuncRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	SpanArgRegex  = regexp.MustCompile(`^arg(\d)$`)
+)
+
+// 解析 span 命令的参数：<entry> [exit] [by <key>]
+func ParseSpanProbe(spec string) (project.SpanProbe, error) {
+	fields := strings.Fields(spec)
+	var s project.SpanProbe
+	if n := len(fields); n >= 2 && fields[n-2] == "by" {
+		s.Key = fields[n-1]
+		fields = fields[:n-2]
+	}
+	if len(fields) == 0 || len(fields) > 2 {
+		return s, errcode.Errorf(errcode.ErrInvalidArg, "无法解析: %s (格式: <entry> [exit] [by pid|tgid|<arg>])", spec)
+	}
+	for _, f := range fields {
+		if !spanFuncRegex.MatchString(f) {
+			return s, errcode.Errorf(errcode.ErrInvalidArg, "无效的函数名: %s", f)
+		}
+	}
+	s.Entry = fields[0]
+	if len(fields) == 2 && fields[1] != fields[0] {
+		s.Exit = fields[1]
+	}
+	if s.Key == "pid" {
+		s.Key = ""
+	}
+	if s.Key != "" && s.Key != "tgid" {
+		if !spanFuncRegex.MatchString(s.Key) {
+			return s, errcode.Errorf(errcode.ErrInvalidArg, "无效的参数名: %s", s.Key)
+		}
+		if s.Exit == "" {
+			return s, errcode.Errorf(errcode.ErrInvalidArg, "函数返回时参数寄存器已被覆盖，按参数配对需要指定exit函数")
+		}
+	}
+	return s, nil
+}
+
+// 项目中的span
+func ProjectSpans(ctx *DebuggerContext) []project.SpanProbe {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return nil
+	}
+	return ctx.Project.Settings.Spans
+}
+
+// 事件中的耗时（纳秒）
+func spanEventNanos(event DebugEvent) (uint64, bool) {
+	if event.Kind != "span" || len(event.Values) == 0 {
+		return 0, false
+	}
+	ns, err := strconv.ParseUint(event.Values[0].Value, 10, 64)
+	return ns, err == nil
+}
+
+// 耗时的显示
+func formatSpanNanos(ns uint64) string {
+	return formatStatsGap(time.Duration(ns))
+}
+
+// 某个span记录到的全部耗时（纳秒，按到达顺序）
+func SpanDurations(ctx *DebuggerContext, id int) []uint64 {
+	durations := make([]uint64, 0)
+	for _, event := range ctx.Events {
+		if event.BreakpointID != id {
+			continue
+		}
+		if ns, ok := spanEventNanos(event); ok {
+			durations = append(durations, ns)
+		}
+	}
+	return durations
+}
+
+// 已排序耗时中的分位数
+func spanPercentile(sorted []uint64, p float64) uint64 {
+	index := int(float64(len(sorted)-1) * p)
+	return sorted[index]
+}
+
+// 一行概要：次数和分位数
+func SpanSummary(durations []uint64) string {
+	if len(durations) == 0 {
+		return "no samples"
+	}
+	sorted := append([]uint64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total uint64
+	for _, ns := range sorted {
+		total += ns
+	}
+	return fmt.Sprintf("%d calls  min %s  avg %s  p50 %s  p99 %s  max %s", len(sorted),
+		formatSpanNanos(sorted[0]), formatSpanNanos(total/uint64(len(sorted))),
+		formatSpanNanos(spanPercentile(sorted, 0.5)), formatSpanNanos(spanPercentile(sorted, 0.99)),
+		formatSpanNanos(sorted[len(sorted)-1]))
+}
+
+// 按2的幂分桶的直方图（桶b包含 [2^(b-1), 2^b) 纳秒）
+func spanHistogramLines(durations []uint64) []string {
+	var buckets [65]int
+	low, high := len(buckets), 0
+	for _, ns := range durations {
+		b := bits.Len64(ns)
+		buckets[b]++
+		if b < low {
+			low = b
+		}
+		if b > high {
+			high = b
+		}
+	}
+	max := 0
+	for _, count := range buckets {
+		if count > max {
+			max = count
+		}
+	}
+	lines := make([]string, 0, high-low+2)
+	lines = append(lines, fmt.Sprintf("  %23s  %7s  distribution", "range", "count"))
+	for b := low; b <= high; b++ {
+		from, to := uint64(0), uint64(0)
+		if b > 0 {
+			from, to = uint64(1)<<(b-1), uint64(1)<<(b-1)*2-1
+		}
+		bucket := fmt.Sprintf("%s - %s", formatSpanNanos(from), formatSpanNanos(to))
+		lines = append(lines, fmt.Sprintf("  %23s  %7d  \x1b[36m%s\x1b[0m", bucket, buckets[b], statsBar(buckets[b], max)))
+	}
+	return lines
+}
+
+// 直方图窗口内容（id为0时显示全部span）
+func spanHistLines(ctx *DebuggerContext, id int) []string {
+	spans := ProjectSpans(ctx)
+	lines := make([]string, 0)
+	for i, s := range spans {
+		if id != 0 && i+1 != id {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		durations := SpanDurations(ctx, i+1)
+		lines = append(lines, fmt.Sprintf("SPAN%d  %s", i+1, s), "  "+SpanSummary(durations))
+		if len(durations) > 0 {
+			lines = append(lines, spanHistogramLines(durations)...)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No spans. Usage: span <entry> [exit] [by pid|tgid|<arg>]")
+	}
+	return lines
+}
+
+// 显示耗时直方图窗口
+func ShowSpanHistPopup(ctx *DebuggerContext, id int) {
+	ClosePopupWindow(ctx, spanPopupID)
+	title := "Span Latency"
+	if id != 0 {
+		title = fmt.Sprintf("Span Latency - SPAN%d", id)
+	}
+	ctx.SpanHist = id
+	popup := CreatePopupWindow(ctx, spanPopupID, title, 96, 30, spanHistLines(ctx, id))
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,68 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 源码文件（按行索引） ==========
+
+// 解析跳转目标：1234（行号）、50%（文件的百分比位置）、$（最后一行），返回从1开始的行号
+func parseGotoTarget(target string, total int) (int, error) {
+	if total < 1 {
+		return 0, fmt.Errorf("文件为空")
+	}
+	var line int
+	switch {
+	case target == "$":
+		line = total
+	case strings.HasSuffix(target, "%"):
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(target, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("无效的百分比: %s", target)
+		}
+		line = int(percent/100*float64(total-1)) + 1
+	default:
+		n, err := strconv.Atoi(target)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("无效的行号: %s", target)
+		}
+		line = n
+	}
+	if line > total {
+		line = total
+	}
+	return line, nil
+}
+
+// 代码窗口跳转到当前文件的指定位置（目标行显示在窗口顶部，光标停在目标行）
+func GotoCodeLine(ui UI, ctx *DebuggerContext, target string) (int, int, error) {
+	if ctx.Project == nil || ctx.Project.CurrentFile == "" || ctx.Disasm != nil {
+		return 0, 0, fmt.Errorf("代码窗口没有打开源码文件")
+	}
+	file, err := project.ProjectSourceFile(ctx.Project, ctx.Project.CurrentFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	line, err := parseGotoTarget(target, file.Len())
+	if err != nil {
+		return 0, 0, err
+	}
+	ctx.CodeScroll = line - 1
+	if ui != nil {
+		ui.SetCursor("code", 0, 2)
+		ui.Focus("code")
+	}
+	return line, file.Len(), nil
+}
+
+// 在文件中的位置：行号/总行数（百分比）
+func CodePosition(first, total int) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("L%d/%d (%d%%)", first, total, first*100/total)
+}
@@ -0,0 +1,28 @@
+package session
+
+import "testing"
+
+func TestParseGotoTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		want   int
+	}{
+		{"1", 1},
+		{"1234", 1234},
+		{"999999", 50000},
+		{"$", 50000},
+		{"0%", 1},
+		{"50%", 25000},
+		{"100%", 50000},
+	}
+	for _, tt := range tests {
+		if got, err := parseGotoTarget(tt.target, 50000); err != nil || got != tt.want {
+			t.Errorf("parseGotoTarget(%q) = %d, %v, want %d", tt.target, got, err, tt.want)
+		}
+	}
+	for _, target := range []string{"0", "-3", "abc", "101%", "x%"} {
+		if _, err := parseGotoTarget(target, 50000); err == nil {
+			t.Errorf("parseGotoTarget(%q) should fail", target)
+		}
+	}
+}
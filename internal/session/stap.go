@@ -0,0 +1,226 @@
+package session
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 在TUI中运行systemtap ==========
+// stap run 生成 .stp 脚本并由TUI启动stap（不需要切换项目的后端），输出进入事件窗口；
+// stap stop 先发SIGINT让stap执行end探针并卸载内核模块，超时后才强制结束。
+// 进程退出时读取协程补一行 [SCRIPT-EXIT]，命令窗口显示退出状态并结束采集；
+// 编译阶段失败（Pass N: ... failed）时按阶段给出排查提示，不再需要把脚本拿到别处运行。
+// bpftrace run 使用同样的进程管理（见 codegen/bpftrace.go）。
+
+// 脚本进程退出时写入输出流的标记行（读取协程据此报告退出状态）
+const scriptExitMarker = "[SCRIPT-EXIT]"
+
+// 停止后等待进程自行退出的时间（stap卸载内核模块可能需要几秒）
+const scriptStopTimeout = 10 * time.Second
+
+// 保留的诊断行数（stap/bpftrace 命令显示）
+const maxScriptDiagnostics = 20
+
+var (
+	stapPassRegex   = regexp.MustCompile(`^Pass (\d+): .*failed`)
+	scriptExitRegex = regexp.MustCompile(`^\[SCRIPT-EXIT\] pid=(\d+) (.*)$`)
+)
+
+// 各编译阶段失败时的排查提示
+var stapPassHints = map[int][]string{
+	1: {"Pass 1 (parse) failed: the generated script has a syntax error", "Check the breakpoint functions and file names in %s"},
+	2: {"Pass 2 (elaborate) failed: probe points or variables could not be resolved", "Is the module loaded (lsmod) and built with -g? Is kernel debuginfo for $(uname -r) installed?"},
+	3: {"Pass 3 (translate) failed: stap could not generate C code for the script", "Try 'stap -p3 %s' to see the full error"},
+	4: {"Pass 4 (compile) failed: the kernel module did not build", "Install kernel-devel/kernel-headers matching $(uname -r) and the gcc used to build the kernel"},
+	5: {"Pass 5 (run) failed: the kernel module could not be loaded", "Run as root (or in the stapdev group); with Secure Boot the module must be signed"},
+}
+
+// TUI运行的stap/bpftrace进程
+type ScriptRun struct {
+	Tool        string    // stap 或 bpftrace
+	Script      string    // 运行的脚本
+	PID         int       // 进程号（区分上一次运行迟到的退出行）
+	Started     time.Time // 启动时间
+	FailedPass  int       // stap失败的编译阶段（0表示没有失败）
+	Diagnostics []string  // 最近的诊断行
+	Exit        string    // 退出状态（运行中为空，stap 命令显示上一次运行的结果）
+}
+
+// tool是否在运行
+func ScriptRunning(ctx *DebuggerContext, tool string) bool {
+	return ctx.ScriptRun != nil && ctx.ScriptRun.Exit == "" && ctx.ScriptRun.Tool == tool
+}
+
+// 脚本进程作为事件源：停止时先中断，让stap清理内核模块、bpftrace打印end块
+type ScriptProcess struct {
+	Cmd    *exec.Cmd
+	reader *io.PipeReader
+	done   chan struct{}
+}
+
+func (p *ScriptProcess) Read(b []byte) (int, error) {
+	return p.reader.Read(b)
+}
+
+// 向进程组发送SIGINT（stap的staprun、perf管道中的每个进程都能收到），超时未退出时再强制结束；
+// 输出流在进程退出后由写入端关闭
+func (p *ScriptProcess) Close() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+	if err := syscall.Kill(-p.Cmd.Process.Pid, syscall.SIGINT); err != nil {
+		return p.Cmd.Process.Kill()
+	}
+	go func() {
+		select {
+		case <-p.done:
+		case <-time.After(scriptStopTimeout):
+			syscall.Kill(-p.Cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}()
+	return nil
+}
+
+// 启动脚本进程（独立的进程组），标准错误与标准输出合并；退出后执行cleanup（可为nil，
+// 不在UI线程中），写入退出标记并关闭管道，读取协程随之结束
+func RunScriptProcess(ctx *DebuggerContext, tool, script string, cmd *exec.Cmd, cleanup func()) (*ScriptProcess, error) {
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		return nil, errcode.Errorf(errcode.ErrToolMissing, "启动%s失败: %v", tool, err)
+	}
+	p := &ScriptProcess{Cmd: cmd, reader: reader, done: make(chan struct{})}
+	go func() {
+		status := "exit status 0"
+		if err := cmd.Wait(); err != nil {
+			status = err.Error()
+		}
+		if cleanup != nil {
+			cleanup()
+		}
+		fmt.Fprintf(writer, "%s pid=%d %s\n", scriptExitMarker, cmd.Process.Pid, status)
+		writer.Close()
+		close(p.done)
+	}()
+	ctx.ScriptRun = &ScriptRun{Tool: tool, Script: script, PID: cmd.Process.Pid, Started: time.Now()}
+	return p, nil
+}
+
+// 编译阶段失败的提示行
+func stapPassHintLines(pass int, script string) []string {
+	hints, ok := stapPassHints[pass]
+	if !ok {
+		return nil
+	}
+	lines := make([]string, 0, len(hints))
+	for _, h := range hints {
+		if strings.Contains(h, "%s") {
+			h = fmt.Sprintf(h, script)
+		}
+		lines = append(lines, "Hint: "+h)
+	}
+	return lines
+}
+
+// 记录脚本进程的诊断行
+func NoteScriptDiagnostic(ctx *DebuggerContext, line string) *ScriptRun {
+	run := ctx.ScriptRun
+	if run == nil {
+		return nil
+	}
+	run.Diagnostics = append(run.Diagnostics, line)
+	if n := len(run.Diagnostics); n > maxScriptDiagnostics {
+		run.Diagnostics = run.Diagnostics[n-maxScriptDiagnostics:]
+	}
+	return run
+}
+
+// 记录stap诊断行，编译阶段失败时返回提示
+func NoteStapDiagnostic(ctx *DebuggerContext, line string) []string {
+	run := NoteScriptDiagnostic(ctx, line)
+	if run == nil {
+		return nil
+	}
+	m := stapPassRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	run.FailedPass, _ = strconv.Atoi(m[1])
+	return stapPassHintLines(run.FailedPass, run.Script)
+}
+
+// 进程退出：报告状态并结束采集。stop 之后或上一次运行的退出行只记录不显示
+func handleScriptExit(ctx *DebuggerContext, line string) bool {
+	m := scriptExitRegex.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	run := ctx.ScriptRun
+	if pid, _ := strconv.Atoi(m[1]); run == nil || run.PID != pid {
+		return true
+	}
+	stopped := run.Exit != ""
+	run.Exit = m[2]
+	if stopped {
+		return true
+	}
+	elapsed := formatStatsGap(time.Since(run.Started))
+	if m[2] == "exit status 0" {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("%s exited after %s", run.Tool, elapsed))
+	} else {
+		err := fmt.Errorf("%s exited (%s) after %s", run.Tool, m[2], elapsed)
+		if run.FailedPass == 0 {
+			err = errcode.WithHints(err, fmt.Sprintf("Hint: run '%s' to see the last diagnostics", run.Tool))
+		}
+		ReportError(ctx, err)
+	}
+	ctx.CommandDirty = true
+	StopEventCapture(ctx)
+	RefreshEventsPopup(ctx)
+	return true
+}
+
+// 结束采集时标记进程已停止（真正的退出状态由退出行补上）
+func MarkScriptStopped(ctx *DebuggerContext) {
+	if ctx.ScriptRun != nil && ctx.ScriptRun.Exit == "" {
+		ctx.ScriptRun.Exit = "stopped"
+	}
+}
+
+// stap/bpftrace 命令的状态显示
+func ScriptStatusLines(ctx *DebuggerContext, tool string) []string {
+	run := ctx.ScriptRun
+	if run == nil || run.Tool != tool {
+		return []string{fmt.Sprintf("%s is not running (start it with '%s run')", tool, tool)}
+	}
+	var lines []string
+	if run.Exit == "" {
+		lines = append(lines, fmt.Sprintf("%s running: pid %d, %s, for %s", tool, run.PID, filepath.Base(run.Script), formatStatsGap(time.Since(run.Started))))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s is not running (last run: pid %d, %s, %s)", tool, run.PID, filepath.Base(run.Script), run.Exit))
+	}
+	if run.FailedPass > 0 {
+		lines = append(lines, fmt.Sprintf("Pass %d failed", run.FailedPass))
+		lines = append(lines, stapPassHintLines(run.FailedPass, run.Script)...)
+	}
+	if len(run.Diagnostics) > 0 {
+		lines = append(lines, "Last diagnostics:")
+		for _, d := range run.Diagnostics {
+			lines = append(lines, "  "+d)
+		}
+	}
+	return lines
+}
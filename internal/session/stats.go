@@ -0,0 +1,369 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 会话统计 ==========
+// 统计数据从事件缓冲区计算，因此实时采集和重放的事件都可以查看。
+// stats bp 按断点分别统计：命中次数和速率、相邻两次命中的间隔（最小/平均/最大）、命中最多的进程。
+
+// 事件速率图的时间分段数
+const statsRateBuckets = 40
+
+// 柱状图最大宽度
+const statsBarWidth = 40
+
+// 会话统计数据
+type SessionStatistics struct {
+	TotalEvents    int
+	Dropped        int // 超出缓冲区上限被丢弃的事件
+	Unparsed       int // 无法识别的trace_pipe行
+	Start          time.Time
+	End            time.Time
+	Capturing      bool
+	BreakpointHits []statsCount // 按命中次数降序
+	Processes      []statsCount // 按事件数降序
+	Rate           []int        // 每个时间段的事件数
+}
+
+// 计数项
+type statsCount struct {
+	Label string
+	Count int
+}
+
+// 采集时长：正在采集时算到当前时间；没有采集记录（重放）时使用事件的时间跨度
+func (s *SessionStatistics) Duration() time.Duration {
+	if s.Start.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// 从事件缓冲区计算会话统计
+func computeSessionStatistics(ctx *DebuggerContext) *SessionStatistics {
+	stats := &SessionStatistics{
+		TotalEvents: len(ctx.Events) + ctx.EventsDropped,
+		Dropped:     ctx.EventsDropped,
+		Unparsed:    ctx.EventsUnparsed,
+		Capturing:   ctx.EventSource != nil,
+	}
+
+	switch {
+	case !ctx.CaptureStart.IsZero():
+		stats.Start = ctx.CaptureStart
+		stats.End = ctx.CaptureStop
+		if stats.Capturing || stats.End.IsZero() {
+			stats.End = time.Now()
+		}
+	case len(ctx.Events) > 0:
+		stats.Start = ctx.Events[0].Time
+		stats.End = ctx.Events[len(ctx.Events)-1].Time
+	}
+
+	hits := make(map[string]int)
+	procs := make(map[string]int)
+	for _, event := range ctx.Events {
+		if event.Kind == "breakpoint" {
+			hits[fmt.Sprintf("BP%d %s", event.BreakpointID, event.Location)]++
+		}
+		label := fmt.Sprintf("%d", event.PID)
+		if event.Comm != "" {
+			label = fmt.Sprintf("%s (%d)", event.Comm, event.PID)
+		}
+		procs[label]++
+	}
+	stats.BreakpointHits = sortedCounts(hits)
+	stats.Processes = sortedCounts(procs)
+
+	stats.Rate = make([]int, statsRateBuckets)
+	if span := stats.End.Sub(stats.Start); span > 0 {
+		for _, event := range ctx.Events {
+			idx := int(int64(event.Time.Sub(stats.Start)) * statsRateBuckets / int64(span))
+			if idx >= 0 && idx < statsRateBuckets {
+				stats.Rate[idx]++
+			} else if idx == statsRateBuckets {
+				stats.Rate[statsRateBuckets-1]++
+			}
+		}
+	} else if len(ctx.Events) > 0 {
+		stats.Rate[statsRateBuckets-1] = len(ctx.Events)
+	}
+	return stats
+}
+
+// 计数按降序排列（相同时按标签排序，保证刷新时顺序稳定）
+func sortedCounts(counts map[string]int) []statsCount {
+	result := make([]statsCount, 0, len(counts))
+	for label, count := range counts {
+		result = append(result, statsCount{label, count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// 水平柱状图
+func statsBar(count, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	width := count * statsBarWidth / max
+	if width == 0 && count > 0 {
+		width = 1
+	}
+	return strings.Repeat("█", width)
+}
+
+// 速率迷你图
+func statsSparkline(values []int) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v == 0:
+			b.WriteRune(' ')
+		case max == 0:
+			b.WriteRune(levels[0])
+		default:
+			b.WriteRune(levels[(v*(len(levels)-1)+max-1)/max])
+		}
+	}
+	return b.String()
+}
+
+// 生成统计面板内容
+func statsDashboardLines(ctx *DebuggerContext) []string {
+	stats := computeSessionStatistics(ctx)
+	duration := stats.Duration()
+
+	state := Styled(ActiveTheme.Dim, "stopped")
+	if stats.Capturing {
+		state = Styled(ActiveTheme.Running, "capturing")
+	}
+	rate := 0.0
+	if duration > 0 {
+		rate = float64(stats.TotalEvents) / duration.Seconds()
+	}
+
+	lines := []string{
+		fmt.Sprintf("State: %s   Duration: %s   Events: %d   Rate: %.1f/s", state, duration.Truncate(time.Second), stats.TotalEvents, rate),
+		fmt.Sprintf("Dropped: %d (buffer limit %d)   Unparsed lines: %d", stats.Dropped, maxEvents, stats.Unparsed),
+		"",
+		Styled(ActiveTheme.Heading, "Event rate over time"),
+		"  │" + statsSparkline(stats.Rate) + "│",
+	}
+	if !stats.Start.IsZero() {
+		lines = append(lines, fmt.Sprintf("  %-*s%s", statsRateBuckets-6, stats.Start.Format("15:04:05"), stats.End.Format("15:04:05")))
+	}
+
+	lines = append(lines, "", Styled(ActiveTheme.Heading, "Breakpoint hits"))
+	if len(stats.BreakpointHits) == 0 {
+		lines = append(lines, "  (none)")
+	}
+	for _, hit := range stats.BreakpointHits {
+		lines = append(lines, fmt.Sprintf("  %s %6d %s", project.FitWidth(hit.Label, 30), hit.Count, Styled(ActiveTheme.Chart, statsBar(hit.Count, stats.BreakpointHits[0].Count))))
+	}
+
+	lines = append(lines, "", Styled(ActiveTheme.Heading, "Top processes"))
+	if len(stats.Processes) == 0 {
+		lines = append(lines, "  (none)")
+	}
+	for i, proc := range stats.Processes {
+		if i >= 10 {
+			lines = append(lines, fmt.Sprintf("  … %d more", len(stats.Processes)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %s %6d %s", project.FitWidth(proc.Label, 30), proc.Count, Styled(ActiveTheme.Chart, statsBar(proc.Count, stats.Processes[0].Count))))
+	}
+	return lines
+}
+
+// 单个断点的统计
+type breakpointStatistics struct {
+	ID        int
+	Label     string // drv.c:3 do_work()
+	Hits      int
+	Rate      []int // 每个时间段的命中数（与会话统计相同的时间段）
+	Intervals int   // 相邻命中的间隔数
+	MinGap    time.Duration
+	MaxGap    time.Duration
+	AvgGap    time.Duration
+	Processes []statsCount
+}
+
+// 按断点统计命中（按编号排序；id不为0时只统计该断点）
+func computeBreakpointStatistics(ctx *DebuggerContext, stats *SessionStatistics, id int) []*breakpointStatistics {
+	byID := make(map[int]*breakpointStatistics)
+	last := make(map[int]float64)
+	total := make(map[int]float64)
+	procs := make(map[int]map[string]int)
+	span := stats.End.Sub(stats.Start)
+	for _, event := range ctx.Events {
+		if event.Kind != "breakpoint" || (id != 0 && event.BreakpointID != id) {
+			continue
+		}
+		bs := byID[event.BreakpointID]
+		if bs == nil {
+			bs = &breakpointStatistics{
+				ID:    event.BreakpointID,
+				Label: fmt.Sprintf("%s %s()", event.Location, event.Function),
+				Rate:  make([]int, statsRateBuckets),
+			}
+			byID[event.BreakpointID] = bs
+			procs[event.BreakpointID] = make(map[string]int)
+		}
+		bs.Hits++
+		if span > 0 {
+			idx := int(int64(event.Time.Sub(stats.Start)) * statsRateBuckets / int64(span))
+			if idx >= statsRateBuckets {
+				idx = statsRateBuckets - 1
+			}
+			if idx >= 0 {
+				bs.Rate[idx]++
+			}
+		} else {
+			bs.Rate[statsRateBuckets-1]++
+		}
+
+		// 相邻两次命中的间隔（优先用内核时间戳）
+		t := eventSeconds(event)
+		if prev, ok := last[event.BreakpointID]; ok && t >= prev {
+			gap := time.Duration((t - prev) * float64(time.Second))
+			if bs.Intervals == 0 || gap < bs.MinGap {
+				bs.MinGap = gap
+			}
+			if gap > bs.MaxGap {
+				bs.MaxGap = gap
+			}
+			bs.Intervals++
+			total[event.BreakpointID] += t - prev
+		}
+		last[event.BreakpointID] = t
+
+		label := fmt.Sprintf("%d", event.PID)
+		if event.Comm != "" {
+			label = fmt.Sprintf("%s (%d)", event.Comm, event.PID)
+		}
+		procs[event.BreakpointID][label]++
+	}
+
+	result := make([]*breakpointStatistics, 0, len(byID))
+	for bpID, bs := range byID {
+		if bs.Intervals > 0 {
+			bs.AvgGap = time.Duration(total[bpID] / float64(bs.Intervals) * float64(time.Second))
+		}
+		bs.Processes = sortedCounts(procs[bpID])
+		result = append(result, bs)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// 间隔的显示（保留三位有效数字左右）
+func formatStatsGap(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	}
+	return d.String()
+}
+
+// 生成断点统计面板内容（id不为0时显示该断点的全部进程）
+func breakpointStatsLines(ctx *DebuggerContext, id int) []string {
+	stats := computeSessionStatistics(ctx)
+	list := computeBreakpointStatistics(ctx, stats, id)
+	duration := stats.Duration()
+	lines := []string{fmt.Sprintf("Duration: %s   Breakpoints hit: %d", formatStatsGap(duration), len(list))}
+	if !stats.Start.IsZero() {
+		// 时间轴与下面每个断点的速率图对齐
+		lines = append(lines, fmt.Sprintf("%12s%-*s%s", "", statsRateBuckets-8, stats.Start.Format("15:04:05"), stats.End.Format("15:04:05")))
+	}
+	if len(list) == 0 {
+		if id != 0 {
+			return append(lines, "", fmt.Sprintf("  No hits of BP%d in the event buffer", id))
+		}
+		return append(lines, "", "  No breakpoint hits yet ('events start' or 'bpf load' to capture)")
+	}
+	topN := 3
+	if id != 0 {
+		topN = 10
+	}
+	for _, bs := range list {
+		rate := 0.0
+		if duration > 0 {
+			rate = float64(bs.Hits) / duration.Seconds()
+		}
+		lines = append(lines, "",
+			Styled(ActiveTheme.Heading, fmt.Sprintf("BP%d %s", bs.ID, bs.Label))+fmt.Sprintf("   %d hits   %.1f/s", bs.Hits, rate),
+			"  rate     │"+Styled(ActiveTheme.Chart, statsSparkline(bs.Rate))+"│")
+		if bs.Intervals > 0 {
+			lines = append(lines, fmt.Sprintf("  interval min %s   avg %s   max %s", formatStatsGap(bs.MinGap), formatStatsGap(bs.AvgGap), formatStatsGap(bs.MaxGap)))
+		} else {
+			lines = append(lines, "  interval (single hit)")
+		}
+		top := make([]string, 0, topN)
+		for i, proc := range bs.Processes {
+			if i >= topN {
+				top = append(top, fmt.Sprintf("… %d more", len(bs.Processes)-i))
+				break
+			}
+			top = append(top, fmt.Sprintf("%s %d", proc.Label, proc.Count))
+		}
+		lines = append(lines, "  top      "+strings.Join(top, ", "))
+	}
+	return lines
+}
+
+// 显示断点统计面板
+func ShowBreakpointStatsPopup(ctx *DebuggerContext, id int) {
+	ClosePopupWindow(ctx, "bpstats")
+	title := "Breakpoint Statistics"
+	if id != 0 {
+		title = fmt.Sprintf("Breakpoint Statistics - BP%d", id)
+	}
+	ctx.StatsBreakpoint = id
+	popup := CreatePopupWindow(ctx, "bpstats", title, 96, 30, breakpointStatsLines(ctx, id))
+	ShowPopupWindow(ctx, popup)
+}
+
+// 显示统计面板
+func ShowStatsPopup(ctx *DebuggerContext) {
+	ClosePopupWindow(ctx, "stats")
+	popup := CreatePopupWindow(ctx, "stats", "Session Statistics", 96, 30, statsDashboardLines(ctx))
+	ShowPopupWindow(ctx, popup)
+}
+
+// 统计面板打开时刷新内容（采集过程中每收到事件调用一次）
+func RefreshStatsPopup(ctx *DebuggerContext) {
+	if popup := FindPopupWindow(ctx, "stats"); popup != nil {
+		popup.Content = statsDashboardLines(ctx)
+	}
+	if popup := FindPopupWindow(ctx, "bpstats"); popup != nil {
+		popup.Content = breakpointStatsLines(ctx, ctx.StatsBreakpoint)
+	}
+	if popup := FindPopupWindow(ctx, spanPopupID); popup != nil {
+		popup.Content = spanHistLines(ctx, ctx.SpanHist)
+	}
+	if popup := FindPopupWindow(ctx, locksPopupID); popup != nil {
+		popup.Content = locksReportLines(ctx)
+	}
+}
@@ -0,0 +1,30 @@
+package session
+
+import (
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/project"
+)
+
+// ========== 结构体指针展开 ==========
+
+// 监视的变量中是结构体指针的变量：变量名 -> 成员
+func WatchedStructPointers(ctx *DebuggerContext, funcName string, names []string) map[string][]dwarf.StructMember {
+	structs := make(map[string][]dwarf.StructMember)
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return structs
+	}
+	watched := make(map[string]bool)
+	for _, expr := range WatchExpressions(ctx) {
+		watched[expr] = true
+	}
+	for _, name := range names {
+		if !watched[name] {
+			continue
+		}
+		if _, members, err := dwarf.DWARFStructPointerMembers(module, funcName, name); err == nil {
+			structs[name] = members
+		}
+	}
+	return structs
+}
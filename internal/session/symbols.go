@@ -0,0 +1,179 @@
+package session
+
+import (
+	"debug/elf"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== 模块符号浏览 ==========
+// 列出目标.ko符号表中的函数和变量（导出的和static的），显示类型、绑定、段、大小和地址。
+// 地址是模块内的段偏移（.ko是可重定位文件，加载后才有运行时地址）。
+// 在函数上按Enter在其入口设置断点，在变量上按Enter添加监视表达式。
+
+// 模块中的符号
+type ModuleSymbol struct {
+	Name     string
+	Func     bool   // 函数（否则为变量）
+	Bind     string // global / local / weak
+	Section  string
+	Value    uint64 // 段内偏移
+	Size     uint64
+	Exported bool // EXPORT_SYMBOL 导出（存在 __ksymtab_<name>）
+}
+
+// 读取模块符号表中的函数和变量
+func readModuleSymbols(path string) ([]ModuleSymbol, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开模块失败: %v", err)
+	}
+	defer file.Close()
+	symbols, err := file.Symbols()
+	if err != nil {
+		return nil, errcode.Errorf(errcode.ErrNoSymbol, "%s 没有符号表: %v", filepath.Base(path), err)
+	}
+
+	exported := make(map[string]bool)
+	for _, sym := range symbols {
+		if strings.HasPrefix(sym.Name, "__ksymtab_") {
+			exported[strings.TrimPrefix(sym.Name, "__ksymtab_")] = true
+		}
+	}
+
+	result := make([]ModuleSymbol, 0)
+	for _, sym := range symbols {
+		typ := elf.ST_TYPE(sym.Info)
+		if (typ != elf.STT_FUNC && typ != elf.STT_OBJECT) || sym.Name == "" || strings.HasPrefix(sym.Name, "__ksymtab") {
+			continue
+		}
+		ms := ModuleSymbol{
+			Name:     sym.Name,
+			Func:     typ == elf.STT_FUNC,
+			Value:    sym.Value,
+			Size:     sym.Size,
+			Exported: exported[sym.Name],
+		}
+		switch elf.ST_BIND(sym.Info) {
+		case elf.STB_GLOBAL:
+			ms.Bind = "global"
+		case elf.STB_WEAK:
+			ms.Bind = "weak"
+		default:
+			ms.Bind = "local"
+		}
+		if int(sym.Section) < len(file.Sections) && sym.Section != elf.SHN_UNDEF {
+			ms.Section = file.Sections[sym.Section].Name
+		}
+		result = append(result, ms)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Func != result[j].Func {
+			return result[i].Func
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// 按名称过滤（不区分大小写的子串匹配）
+func filterModuleSymbols(symbols []ModuleSymbol, pattern string) []ModuleSymbol {
+	if pattern == "" {
+		return symbols
+	}
+	pattern = strings.ToLower(pattern)
+	filtered := make([]ModuleSymbol, 0)
+	for _, sym := range symbols {
+		if strings.Contains(strings.ToLower(sym.Name), pattern) {
+			filtered = append(filtered, sym)
+		}
+	}
+	return filtered
+}
+
+// 符号列表中的一行
+func moduleSymbolLine(sym ModuleSymbol) string {
+	kind := "var "
+	if sym.Func {
+		kind = "func"
+	}
+	name := sym.Name
+	if sym.Exported {
+		name = "\x1b[32m" + name + "\x1b[0m"
+	}
+	return fmt.Sprintf("%s %-6s %-14s %08x %6d  %s", kind, sym.Bind, sym.Section, sym.Value, sym.Size, name)
+}
+
+// 显示模块符号窗口
+func ShowSymbolsPopup(ctx *DebuggerContext, pattern string) (int, int, error) {
+	if ctx.Project == nil {
+		return 0, 0, errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	module := project.FindProjectModule(ctx.Project.RootPath)
+	if module == "" {
+		return 0, 0, errcode.Errorf(errcode.ErrNotFound, "项目中没有编译好的.ko")
+	}
+	all, err := readModuleSymbols(module)
+	if err != nil {
+		return 0, 0, err
+	}
+	symbols := filterModuleSymbols(all, pattern)
+
+	content := make([]string, 0, len(symbols)+3)
+	for _, sym := range symbols {
+		content = append(content, moduleSymbolLine(sym))
+	}
+	if len(symbols) == 0 {
+		content = append(content, fmt.Sprintf("No symbols matching '%s'", pattern))
+	}
+	content = append(content, "", "\x1b[90mtype bind section offset size name | green = exported | Enter: breakpoint on func, watch on var\x1b[0m")
+
+	title := fmt.Sprintf("Symbols: %s (%d)", filepath.Base(module), len(symbols))
+	if pattern != "" {
+		title = fmt.Sprintf("Symbols: %s /%s (%d of %d)", filepath.Base(module), pattern, len(symbols), len(all))
+	}
+	ClosePopupWindow(ctx, "symbols")
+	popup := CreatePopupWindow(ctx, "symbols", title, 90, 25, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		if index < 0 || index >= len(symbols) {
+			return nil
+		}
+		msg, err := symbolSelectMessage(ctx, symbols[index])
+		if err != nil {
+			ReportError(ctx, err)
+			return nil
+		}
+		ctx.CommandHistory = append(ctx.CommandHistory, msg)
+		ctx.CommandDirty = true
+		return nil
+	}
+	ShowPopupWindow(ctx, popup)
+	return len(symbols), len(all), nil
+}
+
+// 选中符号：函数在入口设置断点，变量添加监视表达式
+func symbolSelectMessage(ctx *DebuggerContext, sym ModuleSymbol) (string, error) {
+	if !sym.Func {
+		if !AddWatch(ctx, sym.Name) {
+			return fmt.Sprintf("[SYMBOLS] Already watching %s", sym.Name), nil
+		}
+		if err := project.SaveProjectSettings(ctx.Project); err != nil {
+			return fmt.Sprintf("Warning: Failed to save project settings: %v", err), nil
+		}
+		return fmt.Sprintf("[SYMBOLS] Watching %s, run 'vars' to read it at every breakpoint", sym.Name), nil
+	}
+	def := newCallGraphSource(ctx).defs[sym.Name]
+	if def == nil {
+		return fmt.Sprintf("[SYMBOLS] %s is not defined in the project sources, cannot set a breakpoint", sym.Name), nil
+	}
+	file, line, err := breakpointOnFunction(ctx, def)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[SYMBOLS] Breakpoint toggled at %s:%d (%s)", project.ProjectRelativePath(ctx.Project, file), line, sym.Name), nil
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== C语法高亮 ==========
+
+// 生成带语法颜色和搜索高亮的代码行，返回下一行的词法状态
+func HighlightCodeLine(line string, lineNumber int, state project.CLexState, ctx *DebuggerContext) (string, project.CLexState) {
+	tokens := make([]project.CToken, len(line))
+	next := project.ScanCLine(line, state, tokens)
+	colors := make([]string, len(line))
+	for i, token := range tokens {
+		colors[i] = tokenColor(token)
+	}
+
+	// 搜索匹配覆盖语法颜色
+	if ctx.SearchMode && ctx.SearchTerm != "" {
+		for i, result := range ctx.SearchResults {
+			if result.LineNumber != lineNumber || result.EndColumn > len(line) {
+				continue
+			}
+			color := ActiveTheme.Match
+			if i == ctx.CurrentMatch {
+				color = ActiveTheme.MatchFocus
+			}
+			for c := result.StartColumn; c < result.EndColumn; c++ {
+				colors[c] = color
+			}
+		}
+	}
+
+	var b strings.Builder
+	current := ""
+	for i := 0; i < len(line); i++ {
+		if colors[i] != current {
+			if current != "" {
+				b.WriteString("\x1b[0m")
+			}
+			b.WriteString(colors[i])
+			current = colors[i]
+		}
+		b.WriteByte(line[i])
+	}
+	if current != "" {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String(), next
+}
+
+// 词法单元在当前主题中的颜色
+func tokenColor(token project.CToken) string {
+	switch token {
+	case project.TokenPreproc:
+		return ActiveTheme.Preproc
+	case project.TokenComment:
+		return ActiveTheme.Comment
+	case project.TokenString:
+		return ActiveTheme.String
+	case project.TokenKeyword:
+		return ActiveTheme.Keyword
+	case project.TokenType:
+		return ActiveTheme.Type
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 多文件标签 ==========
+// 代码窗口的第二行是已打开文件的标签栏（单击标签切换），Ctrl+B 或 tab 命令打开文件列表窗口。
+// 每个文件记住自己的滚动位置和搜索状态，切换回来时恢复。
+
+// 标签栏中一个标签占用的列（渲染时更新，点击时查找）
+type codeTabSpan struct {
+	Path       string
+	Start, End int
+}
+
+// 把文件加入标签栏（已存在时不变）
+func addCodeTab(project *project.ProjectInfo, path string) {
+	for _, tab := range project.Tabs {
+		if tab == path {
+			return
+		}
+	}
+	project.Tabs = append(project.Tabs, path)
+}
+
+// 保存当前文件的滚动位置和搜索状态
+func SaveFileViewState(ctx *DebuggerContext) {
+	if ctx.Project == nil || ctx.Project.CurrentFile == "" {
+		return
+	}
+	if ctx.Project.FileViews == nil {
+		ctx.Project.FileViews = make(map[string]*project.FileViewState)
+	}
+	ctx.Project.FileViews[ctx.Project.CurrentFile] = &project.FileViewState{
+		Scroll:        ctx.CodeScroll,
+		SearchMode:    ctx.SearchMode,
+		SearchInput:   ctx.SearchInput,
+		SearchTerm:    ctx.SearchTerm,
+		SearchResults: ctx.SearchResults,
+		CurrentMatch:  ctx.CurrentMatch,
+	}
+}
+
+// 切换代码窗口显示的文件：加入标签栏，恢复该文件上次的滚动位置和搜索状态
+func SwitchCodeFile(ctx *DebuggerContext, path string) {
+	if ctx.Project == nil {
+		return
+	}
+	ctx.Disasm = nil
+	addCodeTab(ctx.Project, path)
+	if ctx.Project.CurrentFile == path {
+		return
+	}
+	SaveFileViewState(ctx)
+	ctx.Project.CurrentFile = path
+
+	state := ctx.Project.FileViews[path]
+	if state == nil {
+		state = &project.FileViewState{CurrentMatch: -1}
+	}
+	ctx.CodeScroll = state.Scroll
+	ctx.SearchMode = state.SearchMode
+	ctx.SearchInput = state.SearchInput
+	ctx.SearchTerm = state.SearchTerm
+	ctx.SearchResults = state.SearchResults
+	ctx.CurrentMatch = state.CurrentMatch
+	ctx.SearchDirty = false
+	ctx.SearchError = ""
+}
+
+// 关闭标签：关闭当前文件时切换到相邻的标签，没有标签时代码窗口为空
+func CloseCodeTab(ctx *DebuggerContext, path string) {
+	project := ctx.Project
+	index := -1
+	for i, tab := range project.Tabs {
+		if tab == path {
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+	project.Tabs = append(project.Tabs[:index], project.Tabs[index+1:]...)
+	delete(project.FileViews, path)
+	delete(project.OpenFiles, path)
+	if project.CurrentFile != path {
+		return
+	}
+	project.CurrentFile = ""
+	if len(project.Tabs) == 0 {
+		ExitSearchMode(ctx)
+		ctx.CodeScroll = 0
+		return
+	}
+	if index >= len(project.Tabs) {
+		index = len(project.Tabs) - 1
+	}
+	SwitchCodeFile(ctx, project.Tabs[index])
+}
+
+// 标签栏（宽度不够时从左侧隐藏标签，保证当前文件可见）
+func CodeTabBar(ctx *DebuggerContext, width int) string {
+	project := ctx.Project
+	addCodeTab(project, project.CurrentFile)
+
+	labels := make([]string, len(project.Tabs))
+	current := 0
+	for i, tab := range project.Tabs {
+		labels[i] = " " + filepath.Base(tab) + " "
+		if project.Modified[tab] {
+			labels[i] = " " + filepath.Base(tab) + " + "
+		}
+		if tab == project.CurrentFile {
+			current = i
+		}
+	}
+	tabWidth := func(from, to int) int {
+		w := 0
+		for _, label := range labels[from : to+1] {
+			w += len([]rune(label)) + 1
+		}
+		return w
+	}
+	first := 0
+	for first < current && tabWidth(first, current) > width-2 {
+		first++
+	}
+
+	var b strings.Builder
+	ctx.CodeTabSpans = ctx.CodeTabSpans[:0]
+	col := 0
+	if first > 0 {
+		b.WriteString("‹")
+		col++
+	}
+	for i := first; i < len(labels); i++ {
+		w := len([]rune(labels[i]))
+		if col+w > width && i > current {
+			b.WriteString("›")
+			break
+		}
+		ctx.CodeTabSpans = append(ctx.CodeTabSpans, codeTabSpan{Path: project.Tabs[i], Start: col, End: col + w})
+		if i == current {
+			b.WriteString(Styled(ActiveTheme.Focused, labels[i]))
+		} else {
+			b.WriteString(labels[i])
+		}
+		b.WriteString(Styled(ActiveTheme.Dim, "│"))
+		col += w + 1
+	}
+	return b.String()
+}
+
+// 标签栏中点击的列对应的文件
+func CodeTabAt(ctx *DebuggerContext, x int) string {
+	for _, span := range ctx.CodeTabSpans {
+		if x >= span.Start && x < span.End {
+			return span.Path
+		}
+	}
+	return ""
+}
+
+// 文件列表窗口：当前文件标记*，按Enter或1-9切换
+func ShowBuffersPopup(ctx *DebuggerContext) {
+	proj := ctx.Project
+	content := make([]string, 0, len(proj.Tabs)+2)
+	for i, tab := range proj.Tabs {
+		marker, scroll := " ", 0
+		if tab == proj.CurrentFile {
+			marker, scroll = "*", ctx.CodeScroll
+		} else if state := proj.FileViews[tab]; state != nil {
+			scroll = state.Scroll
+		}
+		modified := ""
+		if proj.Modified[tab] {
+			modified = " (modified)"
+		}
+		content = append(content, fmt.Sprintf("%s%2d. %-40s line %d%s", marker, i+1, project.ProjectRelativePath(ctx.Project, tab), scroll+1, modified))
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, "Enter/1-9 switch | 'tab close [n]' closes a file"))
+
+	ClosePopupWindow(ctx, "buffers")
+	popup := CreatePopupWindow(ctx, "buffers", fmt.Sprintf("Open Files (%d)", len(proj.Tabs)), 80, len(content)+4, content)
+	selectTab := func(ui UI, index int) error {
+		if index < 0 || index >= len(proj.Tabs) {
+			return nil
+		}
+		SwitchCodeFile(ctx, proj.Tabs[index])
+		ClosePopupWindow(ctx, "buffers")
+		ui.Focus("code")
+		return nil
+	}
+	popup.OnSelect = selectTab
+	popup.OnDigit = func(ui UI, n int) error {
+		return selectTab(ui, n-1)
+	}
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,187 @@
+package session
+
+import (
+	"sort"
+	"strings"
+)
+
+// ========== 配色主题 ==========
+// 状态栏、面板标题、代码窗口（语法高亮、搜索匹配、断点栏）使用的颜色集中在主题的样式表中，
+// theme <name> 切换，选择随会话状态保存。gocui的OutputNormal解析30-37/40-47、
+// 亮色90-97/100-107、粗体(1)、下划线(4)和反显(7)，主题中的颜色按此选择。
+// 终端支持256色（TERM=*-256color、COLORTERM=truecolor，或 DEBUG_TUI_COLORS=256）时以Output256
+// 模式启动，这时还可以使用 \x1b[38;5;Nm / \x1b[48;5;Nm 的主题（每个序列只能设置前景或背景之一）。
+
+// 样式表：每项是一个ANSI前缀，文字之后用 \x1b[0m 复位
+type Theme struct {
+	Name          string
+	Description   string
+	Colors256     bool   // 使用256色序列（只在Output256模式下可用）
+	Focused       string // 聚焦窗口的标题行
+	Alert         string // SIMULATED、TARGET HUNG、断言违反
+	Warning       string // UI LAG、SAFE MODE
+	Dim           string // 提示和次要信息
+	Selected      string // 文件浏览器中的当前文件
+	Error         string
+	Note          string // 断点备注
+	Breakpoint    string // 断点栏：已启用
+	BreakpointOff string // 断点栏：已禁用
+	Keyword       string
+	Type          string
+	String        string
+	Comment       string
+	Preproc       string
+	Match         string // 搜索匹配
+	MatchFocus    string // 当前搜索匹配
+	DiffOld       string // 帧对比：旧值
+	DiffNew       string // 帧对比：新值
+	Heading       string // 统计面板中的小标题
+	Running       string // 统计面板：正在采集
+	Chart         string // 统计面板：柱状图和火花线
+	Key           string // 弹出窗口中的快捷键
+}
+
+// 内置主题
+var Themes = map[string]*Theme{
+	"dark": {
+		Name:          "dark",
+		Description:   "default colors for dark terminals",
+		Focused:       "\x1b[43;30m",
+		Alert:         "\x1b[41;97m",
+		Warning:       "\x1b[43;30m",
+		Dim:           "\x1b[90m",
+		Selected:      "\x1b[32m",
+		Error:         "\x1b[31m",
+		Note:          "\x1b[36m",
+		Breakpoint:    "\x1b[31m",
+		BreakpointOff: "\x1b[90m",
+		Keyword:       "\x1b[33m",
+		Type:          "\x1b[36m",
+		String:        "\x1b[32m",
+		Comment:       "\x1b[90m",
+		Preproc:       "\x1b[35m",
+		Match:         "\x1b[43;30m",
+		MatchFocus:    "\x1b[41;37m",
+		DiffOld:       "\x1b[31m",
+		DiffNew:       "\x1b[32m",
+		Heading:       "\x1b[1m",
+		Running:       "\x1b[32m",
+		Chart:         "\x1b[36m",
+		Key:           "\x1b[33m",
+	},
+	"light": {
+		Name:          "light",
+		Description:   "dark text for light terminal backgrounds",
+		Focused:       "\x1b[44;37m",
+		Alert:         "\x1b[41;37m",
+		Warning:       "\x1b[43;30m",
+		Dim:           "\x1b[36m",
+		Selected:      "\x1b[34;1m",
+		Error:         "\x1b[31m",
+		Note:          "\x1b[34m",
+		Breakpoint:    "\x1b[31;1m",
+		BreakpointOff: "\x1b[33m",
+		Keyword:       "\x1b[34;1m",
+		Type:          "\x1b[35m",
+		String:        "\x1b[32m",
+		Comment:       "\x1b[36m",
+		Preproc:       "\x1b[31m",
+		Match:         "\x1b[43;30m",
+		MatchFocus:    "\x1b[41;37m",
+		DiffOld:       "\x1b[31m",
+		DiffNew:       "\x1b[32m",
+		Heading:       "\x1b[1m",
+		Running:       "\x1b[32m",
+		Chart:         "\x1b[34m",
+		Key:           "\x1b[34;1m",
+	},
+	"high-contrast": {
+		Name:          "high-contrast",
+		Description:   "bold primary colors, reverse video for focus",
+		Focused:       "\x1b[7;1m",
+		Alert:         "\x1b[41;37;1m",
+		Warning:       "\x1b[43;30;1m",
+		Dim:           "\x1b[37m",
+		Selected:      "\x1b[32;1;4m",
+		Error:         "\x1b[31;1m",
+		Note:          "\x1b[36;1m",
+		Breakpoint:    "\x1b[31;1m",
+		BreakpointOff: "\x1b[37m",
+		Keyword:       "\x1b[33;1m",
+		Type:          "\x1b[36;1m",
+		String:        "\x1b[32;1m",
+		Comment:       "\x1b[37m",
+		Preproc:       "\x1b[35;1m",
+		Match:         "\x1b[43;30;1m",
+		MatchFocus:    "\x1b[41;37;1m",
+		DiffOld:       "\x1b[31;1m",
+		DiffNew:       "\x1b[32;1m",
+		Heading:       "\x1b[1;4m",
+		Running:       "\x1b[32;1m",
+		Chart:         "\x1b[36;1m",
+		Key:           "\x1b[33;1m",
+	},
+	"dark256": {
+		Name:          "dark256",
+		Description:   "softer 256-color palette for dark terminals",
+		Colors256:     true,
+		Focused:       "\x1b[48;5;24m\x1b[38;5;255;1m",
+		Alert:         "\x1b[48;5;124m\x1b[38;5;231;1m",
+		Warning:       "\x1b[48;5;178m\x1b[38;5;16m",
+		Dim:           "\x1b[38;5;244m",
+		Selected:      "\x1b[38;5;114m",
+		Error:         "\x1b[38;5;203m",
+		Note:          "\x1b[38;5;116m",
+		Breakpoint:    "\x1b[38;5;196m",
+		BreakpointOff: "\x1b[38;5;240m",
+		Keyword:       "\x1b[38;5;215m",
+		Type:          "\x1b[38;5;80m",
+		String:        "\x1b[38;5;150m",
+		Comment:       "\x1b[38;5;243m",
+		Preproc:       "\x1b[38;5;176m",
+		Match:         "\x1b[48;5;58m\x1b[38;5;230m",
+		MatchFocus:    "\x1b[48;5;166m\x1b[38;5;231m",
+		DiffOld:       "\x1b[38;5;203m",
+		DiffNew:       "\x1b[38;5;114m",
+		Heading:       "\x1b[38;5;255;1m",
+		Running:       "\x1b[38;5;114m",
+		Chart:         "\x1b[38;5;80m",
+		Key:           "\x1b[38;5;215m",
+	},
+}
+
+// 当前主题（所有工作区共用）
+var ActiveTheme = Themes["dark"]
+
+// 界面是否以256色模式运行（启动时由 terminalOutputMode 决定）
+var Colors256 bool
+
+// 主题在当前输出模式下是否可用
+func ThemeAvailable(theme *Theme) bool {
+	return !theme.Colors256 || Colors256
+}
+
+// 切换主题（256色主题在8色模式下不可用）
+func SetTheme(name string) bool {
+	theme, ok := Themes[strings.ToLower(name)]
+	if !ok || !ThemeAvailable(theme) {
+		return false
+	}
+	ActiveTheme = theme
+	return true
+}
+
+// 所有主题名（排序后）
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 用样式包裹一段文字
+func Styled(style, text string) string {
+	return style + text + "\x1b[0m"
+}
@@ -0,0 +1,548 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/errcode"
+)
+
+// ========== 事件时间线 ==========
+// timeline 在命令窗口上方打开横向的时间线窗口，三条泳道按时间画出断点命中（含返回值和硬件断点）、
+// 变量值的变化（事件中的变量与上一次的值不同，包括定时快照）和内核日志。
+// ←/→ 平移，+/- 或鼠标滚轮缩放，[ / ] 选中上一个/下一个事件，单击选中附近的事件：
+// 代码窗口跳到命中的源码行，寄存器、变量和调用栈窗口改为显示该时刻记录的数据，timeline live 回到实时数据。
+// 事件时间优先使用内核时间戳（trace_pipe、perf buffer、kmsg），只有接收时间的事件按同一时钟换算。
+
+// 时间线窗口高度（边框 + 3条泳道 + 时间轴 + 选中的事件）
+const TimelineHeight = 7
+
+// 泳道标签宽度（含分隔线）
+const TimelineLabelWidth = 7
+
+// 最小缩放（窗口覆盖的时长）
+const MinTimelineSpan = 1e-6
+
+// 泳道
+const (
+	laneHits = iota
+	laneVars
+	laneKmsg
+	timelineLaneCount
+)
+
+var timelineLaneNames = [timelineLaneCount]string{"hits", "vars", "dmesg"}
+
+// 时间线窗口状态（为nil表示不显示时间线窗口）
+type TimelineState struct {
+	Start  float64 // 窗口左端（秒，相对第一个事件）
+	Span   float64 // 窗口覆盖的时长（秒），0表示显示全部事件
+	Follow bool    // 窗口右端跟随最新事件
+	Cursor int     // 选中事件的序号（Seq），0表示各窗口显示实时数据
+	Width  int     // 窗口当前的宽度（列数，绘制时更新，0表示还没有绘制过）
+}
+
+// 时间线上的一个点
+type timelinePoint struct {
+	Seq  int
+	Time float64 // 相对第一个事件的秒数
+	Lane int
+}
+
+// 接收时间（秒）
+func unixSeconds(e DebugEvent) float64 {
+	return float64(e.Time.UnixNano()) / 1e9
+}
+
+// 所有事件在时间线上的点：一个事件可以同时出现在命中和变量两条泳道中
+func timelinePoints(ctx *DebuggerContext) []timelinePoint {
+	// 只有接收时间的事件（快照、看门狗）按第一个带内核时间戳的事件换算到内核时钟
+	offset := 0.0
+	for _, e := range ctx.Events {
+		if e.TraceTime > 0 {
+			offset = unixSeconds(e) - e.TraceTime
+			break
+		}
+	}
+	points := make([]timelinePoint, 0, len(ctx.Events))
+	last := make(map[string]string)
+	origin := 0.0
+	for i, e := range ctx.Events {
+		t := e.TraceTime
+		if t <= 0 {
+			t = unixSeconds(e) - offset
+		}
+		if i == 0 || t < origin {
+			origin = t
+		}
+		switch e.Kind {
+		case "breakpoint", "return", "hwbp", "watchdog":
+			points = append(points, timelinePoint{Seq: e.Seq, Time: t, Lane: laneHits})
+		case "kmsg":
+			points = append(points, timelinePoint{Seq: e.Seq, Time: t, Lane: laneKmsg})
+		}
+		if e.Kind == "return" {
+			continue
+		}
+		changed := false
+		for _, v := range e.Values {
+			key := e.Function + ":" + v.Name
+			if prev, ok := last[key]; !ok || prev != v.Value {
+				changed = true
+			}
+			last[key] = v.Value
+		}
+		if changed {
+			points = append(points, timelinePoint{Seq: e.Seq, Time: t, Lane: laneVars})
+		}
+	}
+	for i := range points {
+		points[i].Time -= origin
+	}
+	return points
+}
+
+// 时间线覆盖的总时长
+func timelineTotal(points []timelinePoint) float64 {
+	total := 0.0
+	for _, p := range points {
+		if p.Time > total {
+			total = p.Time
+		}
+	}
+	return total
+}
+
+// 窗口实际显示的时间范围
+func (tl *TimelineState) window(points []timelinePoint) (float64, float64) {
+	total := timelineTotal(points)
+	if tl.Span <= 0 {
+		span := total * 1.02
+		if span < MinTimelineSpan {
+			span = MinTimelineSpan
+		}
+		return 0, span
+	}
+	if tl.Follow {
+		start := total - tl.Span*0.98
+		if start < 0 {
+			start = 0
+		}
+		return start, tl.Span
+	}
+	return tl.Start, tl.Span
+}
+
+// 时间所在的列
+func timelineColumn(t, start, span float64, cols int) int {
+	c := int((t - start) / span * float64(cols))
+	if c >= cols {
+		c = cols - 1
+	}
+	return c
+}
+
+// 列中事件数的显示：1个为•，2-9显示数字，更多为*
+func timelineGlyph(n int) string {
+	switch {
+	case n == 0:
+		return "·"
+	case n == 1:
+		return "•"
+	case n < 10:
+		return fmt.Sprintf("%d", n)
+	}
+	return "*"
+}
+
+// 相对时间的显示
+func formatTimelineOffset(t float64) string {
+	switch {
+	case t >= 1 || t == 0:
+		return fmt.Sprintf("+%.3fs", t)
+	case t >= 1e-3:
+		return fmt.Sprintf("+%.3fms", t*1e3)
+	}
+	return fmt.Sprintf("+%.1fµs", t*1e6)
+}
+
+// 时长的显示
+func FormatTimelineSpan(span float64) string {
+	return strings.TrimPrefix(formatTimelineOffset(span), "+")
+}
+
+// 时间线窗口内容：每条泳道一行、时间轴、选中的事件
+func TimelineLines(ctx *DebuggerContext, width int) []string {
+	tl := ctx.Timeline
+	points := timelinePoints(ctx)
+	cols := width - TimelineLabelWidth
+	if cols < 10 {
+		cols = 10
+	}
+	start, span := tl.window(points)
+	counts := make([][]int, timelineLaneCount)
+	for lane := range counts {
+		counts[lane] = make([]int, cols)
+	}
+	cursorCol := -1
+	cursorTime := 0.0
+	for _, p := range points {
+		if p.Seq == tl.Cursor {
+			cursorTime = p.Time
+		}
+		if p.Time < start || p.Time > start+span {
+			continue
+		}
+		c := timelineColumn(p.Time, start, span, cols)
+		counts[p.Lane][c]++
+		if p.Seq == tl.Cursor {
+			cursorCol = c
+		}
+	}
+
+	colors := [timelineLaneCount]string{ActiveTheme.Breakpoint, ActiveTheme.Note, ActiveTheme.Type}
+	lines := make([]string, 0, timelineLaneCount+2)
+	for lane := 0; lane < timelineLaneCount; lane++ {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%-6s│", timelineLaneNames[lane])
+		for c := 0; c < cols; c++ {
+			n := counts[lane][c]
+			switch {
+			case c == cursorCol:
+				b.WriteString(Styled(ActiveTheme.Focused, timelineGlyph(n)))
+			case n == 0:
+				b.WriteString(Styled(ActiveTheme.Dim, "·"))
+			default:
+				b.WriteString(Styled(colors[lane], timelineGlyph(n)))
+			}
+		}
+		lines = append(lines, b.String())
+	}
+
+	// 时间轴：左右两端的时间和每屏的时长
+	axis := []rune(strings.Repeat("─", cols))
+	left := formatTimelineOffset(start)
+	right := formatTimelineOffset(start + span)
+	middle := " " + FormatTimelineSpan(span) + "/screen "
+	if len(left)+len(right)+len(middle) < cols {
+		copy(axis, []rune(left))
+		copy(axis[cols-len([]rune(right)):], []rune(right))
+		copy(axis[(cols-len([]rune(middle)))/2:], []rune(middle))
+	}
+	if cursorCol >= 0 {
+		axis[cursorCol] = '▲'
+	}
+	lines = append(lines, "      └"+string(axis))
+
+	// 选中的事件
+	if event := TimelineSelected(ctx); event != nil {
+		lines = append(lines, fmt.Sprintf("  #%d %s  %s", event.Seq, formatTimelineOffset(cursorTime), FormatEvent(ctx, *event)))
+	} else if tl.Cursor != 0 {
+		lines = append(lines, Styled(ActiveTheme.Dim, fmt.Sprintf("  #%d is no longer in the event buffer, 'timeline live' to follow live data", tl.Cursor)))
+	} else if len(points) == 0 {
+		lines = append(lines, Styled(ActiveTheme.Dim, "  No events yet, 'events start' / 'bpf load' / 'dmesg start' to capture"))
+	} else {
+		lines = append(lines, Styled(ActiveTheme.Dim, fmt.Sprintf("  live: %d events, click an event or [ / ] to jump all panels to that moment", len(ctx.Events))))
+	}
+	return lines
+}
+
+// ========== 选中的时刻 ==========
+
+// 时间线选中的事件（没有选中或已被丢弃时返回nil）
+func TimelineSelected(ctx *DebuggerContext) *DebugEvent {
+	if ctx.Timeline == nil || ctx.Timeline.Cursor == 0 {
+		return nil
+	}
+	for i := range ctx.Events {
+		if ctx.Events[i].Seq == ctx.Timeline.Cursor {
+			return &ctx.Events[i]
+		}
+	}
+	return nil
+}
+
+// 选中时刻之前（含该时刻）的事件，没有选中时为全部事件
+func EventsUntilCursor(ctx *DebuggerContext) []DebugEvent {
+	if TimelineSelected(ctx) == nil {
+		return ctx.Events
+	}
+	for i := range ctx.Events {
+		if ctx.Events[i].Seq == ctx.Timeline.Cursor {
+			return ctx.Events[:i+1]
+		}
+	}
+	return ctx.Events
+}
+
+// 选中时刻的寄存器（第二个返回值表示时间线是否选中了某个时刻）
+func TimelineRegisters(ctx *DebuggerContext) (*RegisterSnapshot, bool) {
+	event := TimelineSelected(ctx)
+	if event == nil {
+		return nil, false
+	}
+	return registersForEvent(ctx, *event), true
+}
+
+// 事件的寄存器：同一断点、同一进程中接收时间最接近的记录（1秒以内）
+func registersForEvent(ctx *DebuggerContext, event DebugEvent) *RegisterSnapshot {
+	var best *RegisterSnapshot
+	bestDelta := 1.0
+	for _, snap := range ctx.RegisterHistory {
+		if snap.BreakpointID != event.BreakpointID || snap.PID != event.PID {
+			continue
+		}
+		delta := snap.Time.Sub(event.Time).Seconds()
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta <= bestDelta {
+			best, bestDelta = snap, delta
+		}
+	}
+	return best
+}
+
+// 选中时刻的调用栈
+func TimelineStackFrames(ctx *DebuggerContext) ([]StackFrame, bool) {
+	event := TimelineSelected(ctx)
+	if event == nil {
+		return nil, false
+	}
+	if len(event.Stack) > 0 {
+		return event.Stack, true
+	}
+	if event.Function == "" {
+		return nil, true
+	}
+	return hitStackFrame(ctx, *event), true
+}
+
+// 变量窗口中选中时刻的变量值：每个变量在该时刻之前最后一次的值，该事件中采集到的值标为 ◀
+func TimelineValueLines(ctx *DebuggerContext) ([]string, []string) {
+	event := TimelineSelected(ctx)
+	if event == nil {
+		return nil, nil
+	}
+	latest := make(map[string]string)
+	order := make([]string, 0)
+	for _, e := range EventsUntilCursor(ctx) {
+		if e.Kind == "return" {
+			continue
+		}
+		for _, v := range e.Values {
+			if _, seen := latest[v.Name]; !seen {
+				order = append(order, v.Name)
+			}
+			latest[v.Name] = v.Value
+		}
+	}
+	current := make(map[string]bool)
+	for _, v := range event.Values {
+		current[v.Name] = true
+	}
+	lines := []string{Styled(ActiveTheme.Warning, fmt.Sprintf("At #%d %s", event.Seq, event.Time.Format("15:04:05.000")))}
+	names := []string{""}
+	for _, name := range order {
+		line := fmt.Sprintf("%-8s %s", name, FormatValue(ctx, name, latest[name]))
+		if current[name] {
+			line += " ◀"
+		}
+		lines = append(lines, line)
+		names = append(names, name)
+	}
+	if len(order) == 0 {
+		lines = append(lines, Styled(ActiveTheme.Dim, "no variable values recorded yet"))
+		names = append(names, "")
+	}
+	return append(lines, ""), append(names, "")
+}
+
+// 事件对应的断点源码位置
+func timelineEventSource(ctx *DebuggerContext, event DebugEvent) (string, int) {
+	bp := breakpointForEvent(ctx, event)
+	if bp == nil && event.BreakpointID > 0 && (event.Kind == "var" || event.Kind == "return") {
+		bp = ArmedBreakpoint(ctx, event.BreakpointID)
+	}
+	if bp == nil || bp.Binary != "" {
+		return "", 0
+	}
+	return bp.File, bp.Line
+}
+
+// 选中事件：窗口移到事件处，代码窗口跳到命中的源码行，其他窗口在刷新时显示该时刻的记录
+func SelectTimelineEvent(ui UI, ctx *DebuggerContext, seq int) (*DebugEvent, error) {
+	tl := ctx.Timeline
+	if tl == nil {
+		tl = &TimelineState{Follow: true}
+		ctx.Timeline = tl
+	}
+	prev := tl.Cursor
+	tl.Cursor = seq
+	event := TimelineSelected(ctx)
+	if event == nil {
+		tl.Cursor = prev
+		return nil, errcode.Errorf(errcode.ErrNotFound, "事件 #%d 不在事件缓冲区中", seq)
+	}
+	points := timelinePoints(ctx)
+	start, span := tl.window(points)
+	for _, p := range points {
+		if p.Seq == seq && (p.Time < start || p.Time > start+span) {
+			// 选中的事件在窗口外时把它移到窗口中间
+			tl.Span = span
+			tl.Start = p.Time - span/2
+			if tl.Start < 0 {
+				tl.Start = 0
+			}
+			tl.Follow = false
+			break
+		}
+	}
+	if file, line := timelineEventSource(ctx, *event); file != "" && ui != nil && ctx.Project != nil {
+		focus := ui.CurrentView()
+		if err := OpenSourceAt(ui, ctx, file, line); err != nil {
+			return event, err
+		}
+		if focus == "timeline" {
+			ui.Focus("timeline")
+		}
+	}
+	return event, nil
+}
+
+// 选中上一个/下一个在时间线上的事件（没有选中时从最新的事件开始）
+func StepTimelineEvent(ui UI, ctx *DebuggerContext, dir int) (*DebugEvent, error) {
+	plotted := make(map[int]bool)
+	for _, p := range timelinePoints(ctx) {
+		plotted[p.Seq] = true
+	}
+	index := len(ctx.Events)
+	if dir > 0 {
+		index = -1
+	}
+	if ctx.Timeline != nil && ctx.Timeline.Cursor != 0 {
+		for i := range ctx.Events {
+			if ctx.Events[i].Seq == ctx.Timeline.Cursor {
+				index = i
+				break
+			}
+		}
+	}
+	for i := index + dir; i >= 0 && i < len(ctx.Events); i += dir {
+		if plotted[ctx.Events[i].Seq] {
+			return SelectTimelineEvent(ui, ctx, ctx.Events[i].Seq)
+		}
+	}
+	return nil, errcode.Errorf(errcode.ErrNotFound, "没有更多事件")
+}
+
+// 光标处（列、泳道）最近的事件，附近没有事件时返回0；泳道之外的行匹配任意泳道
+func TimelineEventAt(ctx *DebuggerContext, width, cx, cy int) int {
+	if ctx.Timeline == nil {
+		return 0
+	}
+	col := cx - TimelineLabelWidth
+	cols := width - TimelineLabelWidth
+	if col < 0 || cols < 10 {
+		return 0
+	}
+	points := timelinePoints(ctx)
+	start, span := ctx.Timeline.window(points)
+	target := start + (float64(col)+0.5)*span/float64(cols)
+	for _, anyLane := range []bool{false, true} {
+		if !anyLane && (cy < 0 || cy >= timelineLaneCount) {
+			continue
+		}
+		best, bestDelta := 0, 0.0
+		for _, p := range points {
+			if (!anyLane && p.Lane != cy) || p.Time < start || p.Time > start+span {
+				continue
+			}
+			c := timelineColumn(p.Time, start, span, cols)
+			if c < col-1 || c > col+1 {
+				continue
+			}
+			delta := p.Time - target
+			if delta < 0 {
+				delta = -delta
+			}
+			if best == 0 || delta < bestDelta {
+				best, bestDelta = p.Seq, delta
+			}
+		}
+		if best != 0 {
+			return best
+		}
+	}
+	return 0
+}
+
+// 以窗口中的某一列为中心缩放（factor<1放大）
+func ZoomTimeline(ctx *DebuggerContext, factor float64, col int) {
+	tl := ctx.Timeline
+	if tl == nil {
+		return
+	}
+	points := timelinePoints(ctx)
+	start, span := tl.window(points)
+	width := tl.Width
+	if width == 0 {
+		width = 80
+	}
+	cols := width - TimelineLabelWidth
+	if cols < 10 {
+		cols = 10
+	}
+	if col < 0 || col >= cols {
+		col = cols / 2
+	}
+	anchor := start + float64(col)/float64(cols)*span
+	newSpan := span * factor
+	if newSpan < MinTimelineSpan {
+		newSpan = MinTimelineSpan
+	}
+	total := timelineTotal(points)
+	if newSpan >= total*1.02 && factor > 1 {
+		// 缩小到能显示全部事件时回到全部显示
+		tl.Span, tl.Start, tl.Follow = 0, 0, true
+		return
+	}
+	tl.Span = newSpan
+	tl.Start = anchor - float64(col)/float64(cols)*newSpan
+	if tl.Start < 0 {
+		tl.Start = 0
+	}
+	tl.Follow = tl.Start+newSpan >= total
+}
+
+// 平移窗口（fraction为窗口宽度的比例，负数向左）
+func PanTimeline(ctx *DebuggerContext, fraction float64) {
+	tl := ctx.Timeline
+	if tl == nil {
+		return
+	}
+	points := timelinePoints(ctx)
+	start, span := tl.window(points)
+	total := timelineTotal(points)
+	tl.Span = span
+	tl.Start = start + fraction*span
+	if tl.Start < 0 {
+		tl.Start = 0
+	}
+	if tl.Start+span >= total {
+		tl.Start = total - span*0.98
+		if tl.Start < 0 {
+			tl.Start = 0
+		}
+	}
+	tl.Follow = tl.Start+span >= total
+}
+
+// 回到实时数据：取消选中，窗口跟随最新事件
+func TimelineLive(ctx *DebuggerContext) {
+	if ctx.Timeline == nil {
+		return
+	}
+	ctx.Timeline.Cursor = 0
+	ctx.Timeline.Follow = true
+}
@@ -0,0 +1,384 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// ========== BPF交叉编译工具链 ==========
+// compile 默认用PATH中的clang和主机的头文件编译BPF程序。交叉调试时（开发板是arm64/riscv64，
+// 主机是x86_64）主机的 asm/ 头文件与目标内核不一致，toolchain 按目标架构分别指定：
+//   clang    clang的路径（例如 /opt/llvm-17/bin/clang）
+//   sysroot  目标系统的根目录，用于 --sysroot 和 <sysroot>/usr/include/<triple>
+//   headers  目标内核的源码/构建目录或 make headers_install 的输出目录
+//   cflags   附加的编译选项（-D、-I、-Wno-... 等）
+// 配置保存在项目设置中，toolchain check 检查路径和clang的BPF后端，toolchain dry-run 显示完整的编译命令。
+// remote build target 在开发板上编译，不使用这里的配置。
+
+// 各架构的Debian多架构头文件目录（asm/types.h 等在 /usr/include/<triple> 下）
+var multiarchTriples = map[string]string{
+	"x86_64":  "x86_64-linux-gnu",
+	"arm64":   "aarch64-linux-gnu",
+	"riscv64": "riscv64-linux-gnu",
+	"s390x":   "s390x-linux-gnu",
+	"ppc64le": "powerpc64le-linux-gnu",
+	"mips64":  "mips64el-linux-gnuabi64",
+}
+
+// 判断目录是否存在
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// 内核源码树中的架构目录名（arch/<karch>），与 __TARGET_ARCH_<karch> 一致
+func kernelArchDir(arch string) string {
+	return strings.TrimPrefix(SupportedArchitectures[arch], "__TARGET_ARCH_")
+}
+
+// 目标架构的工具链配置（没有配置时返回nil）
+func toolchainFor(ctx *DebuggerContext, arch string) *project.ToolchainConfig {
+	if ctx == nil || ctx.Project == nil || ctx.Project.Settings == nil {
+		return nil
+	}
+	return ctx.Project.Settings.Toolchains[dwarf.RegsArch(arch)]
+}
+
+// 设置目标架构的一项工具链配置
+func SetToolchain(ctx *DebuggerContext, arch, key string, values []string) error {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	tc := toolchainFor(ctx, arch)
+	if tc == nil {
+		tc = &project.ToolchainConfig{}
+	}
+	value := strings.Join(values, " ")
+	switch key {
+	case "clang":
+		if len(values) != 1 {
+			return errcode.Errorf(errcode.ErrInvalidArg, "clang路径只能有一个: %s", value)
+		}
+		tc.Clang = expandToolchainPath(ctx, value)
+	case "sysroot", "headers":
+		if len(values) != 1 {
+			return errcode.Errorf(errcode.ErrInvalidArg, "%s目录只能有一个: %s", key, value)
+		}
+		dir := expandToolchainPath(ctx, value)
+		if !dirExists(dir) {
+			return errcode.Errorf(errcode.ErrInvalidArg, "目录不存在: %s", dir)
+		}
+		if key == "sysroot" {
+			tc.Sysroot = dir
+		} else {
+			tc.Headers = dir
+		}
+	case "cflags":
+		for _, flag := range values {
+			if !strings.HasPrefix(flag, "-") {
+				return errcode.Errorf(errcode.ErrInvalidArg, "编译选项必须以-开头: %s", flag)
+			}
+			if flag == "-o" || flag == "-c" || strings.HasPrefix(flag, "-target") || strings.HasPrefix(flag, "--target") {
+				return errcode.Errorf(errcode.ErrInvalidArg, "%s 由 compile 决定，不能在cflags中指定", flag)
+			}
+		}
+		tc.CFlags = values
+	default:
+		return errcode.Errorf(errcode.ErrInvalidArg, "未知的工具链配置: %s（可用 clang、sysroot、headers、cflags）", key)
+	}
+	if ctx.Project.Settings.Toolchains == nil {
+		ctx.Project.Settings.Toolchains = make(map[string]*project.ToolchainConfig)
+	}
+	ctx.Project.Settings.Toolchains[dwarf.RegsArch(arch)] = tc
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 清除目标架构的工具链配置（key为空时清除全部）
+func ResetToolchain(ctx *DebuggerContext, arch, key string) error {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return errcode.Errorf(errcode.ErrNoProject, "没有打开的项目")
+	}
+	tc := toolchainFor(ctx, arch)
+	switch {
+	case key == "":
+		tc = nil
+	case tc == nil:
+	case key == "clang":
+		tc.Clang = ""
+	case key == "sysroot":
+		tc.Sysroot = ""
+	case key == "headers":
+		tc.Headers = ""
+	case key == "cflags":
+		tc.CFlags = nil
+	default:
+		return errcode.Errorf(errcode.ErrInvalidArg, "未知的工具链配置: %s（可用 clang、sysroot、headers、cflags）", key)
+	}
+	if tc == nil || (tc.Clang == "" && tc.Sysroot == "" && tc.Headers == "" && len(tc.CFlags) == 0) {
+		delete(ctx.Project.Settings.Toolchains, dwarf.RegsArch(arch))
+	}
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// ~ 和相对路径（相对项目根目录）展开为绝对路径；不含/的clang名称按PATH查找，保持原样
+func expandToolchainPath(ctx *DebuggerContext, path string) string {
+	if !strings.Contains(path, "/") {
+		return path
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(ctx.Project.RootPath, path)
+	}
+	return filepath.Clean(path)
+}
+
+// 目标内核头文件的搜索目录：源码/构建树（arch/<karch>/include、include及其generated、uapi）
+// 或 headers_install 的输出目录（include），只返回存在的目录
+func kernelHeaderDirs(headers, arch string) []string {
+	karch := kernelArchDir(arch)
+	candidates := []string{
+		filepath.Join(headers, "arch", karch, "include"),
+		filepath.Join(headers, "arch", karch, "include", "generated"),
+		filepath.Join(headers, "include"),
+		filepath.Join(headers, "arch", karch, "include", "uapi"),
+		filepath.Join(headers, "arch", karch, "include", "generated", "uapi"),
+		filepath.Join(headers, "include", "uapi"),
+		filepath.Join(headers, "include", "generated", "uapi"),
+	}
+	dirs := make([]string, 0, len(candidates))
+	for _, dir := range candidates {
+		if dirExists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// 编译BPF程序的完整命令：clang路径和参数（src、obj相对项目根目录）
+func bpfCompileArgs(ctx *DebuggerContext, arch, src, obj string) (string, []string, error) {
+	archDefine, exists := SupportedArchitectures[arch]
+	if !exists {
+		return "", nil, errcode.Errorf(errcode.ErrArch, "不支持的架构: %s", arch)
+	}
+	tc := toolchainFor(ctx, arch)
+	if tc == nil {
+		tc = &project.ToolchainConfig{}
+	}
+	clang := tc.Clang
+	if clang == "" {
+		clang = "clang"
+	}
+	args := []string{"-target", "bpf", "-O2", "-g", fmt.Sprintf("-D%s=1", archDefine)}
+	if tc.Sysroot != "" {
+		args = append(args, "--sysroot="+tc.Sysroot)
+	}
+	// 内核头文件在系统头文件之前搜索
+	if tc.Headers != "" {
+		for _, dir := range kernelHeaderDirs(tc.Headers, arch) {
+			args = append(args, "-I"+dir)
+		}
+	}
+	// -target bpf 不会搜索多架构目录，asm/ 头文件按目标架构补上
+	if triple := multiarchTriples[dwarf.RegsArch(arch)]; triple != "" {
+		dir := filepath.Join(tc.Sysroot, "/usr/include", triple)
+		if dirExists(dir) {
+			args = append(args, "-idirafter", dir)
+		}
+	}
+	args = append(args, tc.CFlags...)
+	args = append(args, "-c", src, "-o", obj)
+	return clang, args, nil
+}
+
+// 编译BPF程序的命令（在项目根目录执行）
+func BPFCompileCommand(ctx *DebuggerContext, arch, src, obj string) (*exec.Cmd, error) {
+	clang, args, err := bpfCompileArgs(ctx, arch, src, obj)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(clang); err != nil {
+		if clang != "clang" {
+			return nil, errcode.Errorf(errcode.ErrToolMissing, "找不到配置的clang: %s（toolchain %s clang <path>）", clang, dwarf.RegsArch(arch))
+		}
+		return nil, errcode.Errorf(errcode.ErrToolMissing, "找不到clang编译器，请安装:\n  Ubuntu/Debian: sudo apt install clang\n  CentOS/RHEL: sudo yum install clang")
+	}
+	cmd := exec.Command(clang, args...)
+	cmd.Dir = ctx.Project.RootPath
+	return cmd, nil
+}
+
+// toolchain dry-run：显示 compile 将执行的命令（还没有生成BPF源码时按 generate 的文件名显示）
+func ToolchainDryRun(ctx *DebuggerContext, arch string) ([]string, error) {
+	src, obj := "debug_breakpoints.bpf.c", "debug_breakpoints.bpf.o"
+	if artifacts, err := currentBPFArtifacts(ctx.Project.RootPath); err == nil {
+		src, obj = artifacts.Source, artifacts.Object
+	}
+	root := ctx.Project.RootPath
+	clang, args, err := bpfCompileArgs(ctx, arch, filepath.Join(root, src), filepath.Join(root, obj))
+	if err != nil {
+		return nil, err
+	}
+	quoted := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{clang}, args...) {
+		quoted = append(quoted, ShellQuote(arg))
+	}
+	lines := []string{
+		fmt.Sprintf("Compile command for %s (in %s):", dwarf.RegsArch(arch), root),
+		"  " + strings.Join(quoted, " "),
+	}
+	if remote := RemoteTarget(ctx); remote != nil && remote.BuildOnTarget {
+		lines = append(lines, "  Note: 'remote build target' is set, compile runs clang on the board instead")
+	}
+	return lines, nil
+}
+
+// 参数中有空格或shell特殊字符时加单引号
+func ShellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// toolchain check：检查clang、BPF后端、sysroot和内核头文件，返回报告和问题数量
+func ToolchainCheck(ctx *DebuggerContext, arch string) ([]string, int) {
+	tc := toolchainFor(ctx, arch)
+	if tc == nil {
+		tc = &project.ToolchainConfig{}
+	}
+	lines := []string{fmt.Sprintf("Toolchain check for %s:", dwarf.RegsArch(arch))}
+	problems := 0
+	fail := func(format string, args ...interface{}) {
+		lines = append(lines, "  ❌ "+fmt.Sprintf(format, args...))
+		problems++
+	}
+	ok := func(format string, args ...interface{}) {
+		lines = append(lines, "  ✅ "+fmt.Sprintf(format, args...))
+	}
+
+	if _, exists := SupportedArchitectures[arch]; !exists {
+		fail("unsupported architecture: %s", arch)
+		return lines, problems
+	}
+
+	clang := tc.Clang
+	if clang == "" {
+		clang = "clang"
+	}
+	if path, err := exec.LookPath(clang); err != nil {
+		fail("clang not found: %s", clang)
+	} else {
+		version := "unknown version"
+		if out, err := exec.Command(path, "--version").Output(); err == nil {
+			version = strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+		}
+		ok("clang: %s (%s)", path, version)
+		// llc/clang 的已注册目标中应当有bpf
+		if out, err := exec.Command(path, "-print-targets").Output(); err == nil {
+			if strings.Contains(string(out), "bpf") {
+				ok("BPF backend available")
+			} else {
+				fail("%s was built without the BPF backend", path)
+			}
+		}
+	}
+
+	if tc.Sysroot != "" {
+		if !dirExists(tc.Sysroot) {
+			fail("sysroot does not exist: %s", tc.Sysroot)
+		} else if !dirExists(filepath.Join(tc.Sysroot, "usr", "include")) {
+			fail("sysroot has no usr/include: %s", tc.Sysroot)
+		} else {
+			ok("sysroot: %s", tc.Sysroot)
+		}
+	}
+
+	if tc.Headers != "" {
+		dirs := kernelHeaderDirs(tc.Headers, arch)
+		found := ""
+		for _, dir := range dirs {
+			if _, err := os.Stat(filepath.Join(dir, "linux", "bpf.h")); err == nil {
+				found = dir
+				break
+			}
+		}
+		switch {
+		case len(dirs) == 0:
+			fail("no include directories under %s", tc.Headers)
+		case found == "":
+			fail("linux/bpf.h not found under %s", tc.Headers)
+		default:
+			ok("kernel headers: %s (%d include dirs, linux/bpf.h in %s)", tc.Headers, len(dirs), found)
+		}
+		if karch := kernelArchDir(arch); !dirExists(filepath.Join(tc.Headers, "arch", karch)) &&
+			dirExists(filepath.Join(tc.Headers, "arch")) {
+			fail("%s has no arch/%s (headers for another architecture?)", tc.Headers, karch)
+		}
+	}
+
+	// asm/ 头文件：内核头文件、sysroot或主机的多架构目录中应当有一个
+	asmFound := false
+	_, args, _ := bpfCompileArgs(ctx, arch, "x.c", "x.o")
+	for i, arg := range args {
+		dir := ""
+		switch {
+		case strings.HasPrefix(arg, "-I"):
+			dir = arg[2:]
+		case arg == "-idirafter" && i+1 < len(args):
+			dir = args[i+1]
+		}
+		if dir != "" && project.FileExists(filepath.Join(dir, "asm", "types.h")) {
+			asmFound = true
+			break
+		}
+	}
+	if !asmFound && project.FileExists(filepath.Join(tc.Sysroot, "/usr/include", "asm", "types.h")) {
+		asmFound = true
+	}
+	if asmFound {
+		ok("asm/types.h found for %s", dwarf.RegsArch(arch))
+	} else {
+		fail("asm/types.h not found for %s (set 'headers' or 'sysroot')", dwarf.RegsArch(arch))
+	}
+
+	if len(tc.CFlags) > 0 {
+		ok("extra cflags: %s", strings.Join(tc.CFlags, " "))
+	}
+	return lines, problems
+}
+
+// 工具链配置的文字描述
+func ToolchainSummary(ctx *DebuggerContext, arch string) []string {
+	tc := toolchainFor(ctx, arch)
+	if tc == nil {
+		tc = &project.ToolchainConfig{}
+	}
+	orDefault := func(value, def string) string {
+		if value == "" {
+			return def
+		}
+		return value
+	}
+	cflags := "(none)"
+	if len(tc.CFlags) > 0 {
+		cflags = strings.Join(tc.CFlags, " ")
+	}
+	return []string{
+		fmt.Sprintf("Toolchain for %s:", dwarf.RegsArch(arch)),
+		"  clang:   " + orDefault(tc.Clang, "clang (from PATH)"),
+		"  sysroot: " + orDefault(tc.Sysroot, "(none)"),
+		"  headers: " + orDefault(tc.Headers, "(host headers)"),
+		"  cflags:  " + cflags,
+	}
+}
@@ -0,0 +1,226 @@
+package session
+
+import (
+	"io"
+	"time"
+
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/errcode"
+	"debug-gocui/internal/project"
+)
+
+// 调试器状态
+const (
+	DEBUG_STOPPED = iota
+	DEBUG_RUNNING
+	DEBUG_STEPPING
+	DEBUG_BREAKPOINT
+)
+
+type DebuggerState int
+
+type DebuggerContext struct {
+	State         DebuggerState
+	CurrentFocus  int
+	BpfLoaded     bool
+	CurrentFunc   string
+	CurrentAddr   uint64
+	Running       bool
+	MouseEnabled  bool
+	// 文本选择状态
+	SelectionMode bool
+	SelectionView string
+	SelectionText string
+	// 鼠标选择状态
+	MouseSelecting bool
+	SelectStartX   int
+	SelectStartY   int
+	SelectEndX     int
+	SelectEndY     int
+	// 项目管理
+	Project            *project.ProjectInfo
+	FileBrowserLines   []*project.FileNode // 文件浏览器每一行对应的节点（提示行为nil，绘制时重建）
+	CodeTabSpans       []codeTabSpan       // 代码窗口标签栏中每个标签占用的列（绘制时重建，点击时查找）
+	VariablesLineNames []string            // 变量窗口每一行对应的变量名（第0行是标题，绘制时重建，按光标行切换格式）
+	// 各窗口的滚动位置（第一个可见的内容行，按工作区保存）
+	FileScroll  int
+	RegScroll   int
+	VarScroll   int
+	StackScroll int
+	CodeScroll  int
+	MemScroll   int
+	// 动态布局支持
+	Layout        *DynamicLayout
+	// 命令窗口状态管理 - 类似终端的历史记录
+	CommandHistory []string  // 保存所有命令历史（包括命令和输出）
+	CurrentInput   string    // 当前正在输入的命令
+	CommandDirty   bool      // 标记命令窗口是否需要重绘
+	HistoryTimes   []time.Time   // 每行历史的记录时间（与CommandHistory一一对应）
+	HistoryLimit   int           // 历史行数上限（0表示默认值）
+	HistoryMaxAge  time.Duration // 历史保留时长（0表示不限）
+	HistoryDropped int           // 因超出限制被丢弃的历史行数
+	// 历史反向搜索（Ctrl+R）
+	HistorySearch     bool   // 是否处于搜索状态
+	HistoryQuery      string // 搜索词
+	HistoryMatch      int    // 当前匹配的历史行（-1表示没有匹配）
+	HistorySavedInput string // 搜索前的输入（取消时恢复）
+	HistoryBrowse     int    // ↑/↓浏览到的命令（从最新往前数，0表示未浏览）
+	HistoryDraft      string // 开始浏览前的输入（回到最新之后恢复）
+	// 双击检测状态
+	LastClickTime  time.Time // 上次点击时间
+	LastClickLine  int       // 上次点击的行号
+	// 全屏状态管理
+	IsFullscreen   bool          // 是否处于全屏状态
+	FullscreenView string        // 当前全屏的窗口名称
+	SavedLayout    *DynamicLayout // 保存的原始布局
+	// 弹出窗口系统
+	PopupWindows   []*PopupWindow // 所有弹出窗口列表
+	DraggingPopup  *PopupWindow  // 当前正在拖拽的弹出窗口
+	// 代码搜索系统
+	SearchMode     bool          // 是否处于搜索模式
+	SearchTerm     string        // 当前搜索词
+	SearchResults  []project.SearchResult // 搜索结果列表
+	CurrentMatch   int           // 当前匹配项索引
+	SearchInput    string        // 搜索输入缓冲区
+	SearchDirty    bool          // 搜索结果是否需要更新
+	SearchOptions  SearchOptions // 搜索模式：正则、大小写、全词（按会话保存）
+	SearchError    string        // 搜索词不是合法的正则表达式时的错误
+	// 地址转换
+	KASLR          *KASLRInfo    // KASLR检测结果（打开项目时检测）
+	// 操作日志
+	Replaying      bool          // 是否正在重放操作日志（重放时不重复记录）
+	LeaderPending  bool          // 是否已按下引导键(Ctrl+X)等待快捷键
+	LeaderTime     time.Time     // 引导键按下时间
+	PendingKey     rune          // 代码窗口中等待第二个键的前缀（gd/gr 的 g），没有时为0
+	DemoMode       bool          // 未接入数据后端时是否显示示例数据（demo on/off）
+	SafeMode       bool          // 安全模式（--safe）：断点不武装，数据后端全部禁用
+	HighlightOff   bool          // 代码窗口关闭C语法高亮（highlight off）
+	ScriptDepth    int           // 正在执行的脚本嵌套层数（source）
+	
+	// 事件列表
+	Events              []DebugEvent // 采集到的事件（有上限）
+	EventSeq            int          // 事件序号计数
+	EventFoldOff        bool         // 是否关闭重复事件折叠
+	ExpandedEventGroups map[int]bool // 已展开的折叠组（按组内第一个事件的序号）
+	EventFilter         map[int]bool // 事件窗口只显示这些断点编号（为空表示全部）
+	EventSource         io.ReadCloser // 正在读取的trace_pipe（本地文件或远程ssh）
+	CaptureBackend      string       // 最近一次事件采集使用的后端（probeplan.go CaptureBackends）
+	KmsgSource          io.ReadCloser // 正在读取的 /dev/kmsg（dmesg start，本地文件或远程ssh）
+	HWBreakpoints       []*HWBreakpoint // 已设置的硬件断点（hwbp）
+	EventsDropped       int          // 超出缓冲区上限被丢弃的事件数
+	EventsUnparsed      int          // 无法识别的trace_pipe行数
+	Building            bool         // make build/clean 正在后台运行
+	RemoteJob           string       // 正在后台执行的远程任务（remote sync、远程compile/bpf load，为空表示没有）
+	CompileOutput       []string     // 最近一次compile失败时clang的输出
+	CaptureStart        time.Time    // 采集开始时间
+	CaptureStop         time.Time    // 采集停止时间
+	SnapshotStop        chan struct{} // 定时快照停止信号（为nil表示未运行）
+	SnapshotInterval    time.Duration // 定时快照间隔
+	Watchdog            *WatchdogState // 远程目标看门狗（为nil表示未运行）
+	AssertStates        []assertState // 顺序断言运行状态（与设置中的断言一一对应）
+	AssertViolations    []AssertViolation // 断言违反记录
+	AssertViolated      map[int]bool  // 违反断言的事件（按事件序号）
+	WorkingSet          []WorkingSetEntry // 本次会话接触过的文件和函数（最近的在前）
+	StackFrames         []StackFrame // 最近一次命中的调用栈（由数据后端填充）
+	SelftestRunning     bool         // 自检是否正在运行
+	LastErrorCode       errcode.Code // 最近一次失败的错误码（why 命令默认显示）
+	Perf                *PerfStats   // 调试器自身的性能统计
+	BPF                 *LoadedBPF   // 进程内加载的BPF程序（bpf load）
+	Registers           *RegisterSnapshot // 最近一次命中的寄存器（bpf load 后由ring buffer填充，gdb后端停下时读取）
+	GDB                 *GDBSession       // gdb后端的gdbstub连接（为nil表示未连接）
+	LineTable           *dwarf.LineResolver // 项目模块的DWARF行号表（按模块修改时间缓存）
+	Disasm              *Disassembly      // 代码窗口显示的反汇编（为nil时显示源码）
+	Memory              *MemoryDump       // 内存窗口显示的内容（为nil时不显示内存窗口）
+	Timeline            *TimelineState    // 时间线窗口（为nil时不显示，选中事件时其他窗口显示该时刻的记录）
+	RegisterHistory     []*RegisterSnapshot // 最近的寄存器记录（时间线选中历史事件时使用）
+	Recording           *RecordingState   // 正在录制的帧文件（为nil表示未录制）
+	Replay              *ReplayState      // 回放中的帧文件（为nil时各窗口显示实时数据）
+	StatsBreakpoint     int               // 断点统计窗口只显示的断点（0表示全部）
+	SpanHist            int               // 耗时直方图窗口只显示的span（0表示全部）
+	LockStats           *LockStats        // 锁探针的调用点汇总（locks.go）
+	ScriptRun           *ScriptRun        // TUI运行的stap/bpftrace进程（为nil表示没有运行过，stap.go）
+	FtraceRoot          string            // ftrace/kprobe后端布防时的tracefs目录（events stop 时恢复）
+	KprobeEvents        []string          // kprobe后端创建的探针（debug_tui组中的事件名）
+	GraphStacks         map[int][]string  // function_graph输出中每个CPU当前的调用链
+	ProbeChecks         map[string]*ProbeCheck // 断点函数能否挂载kprobe（generate/vars 和 bp check 时检查）
+	
+	// 命令面板状态
+	PaletteOpen     bool   // 命令面板是否打开
+	PaletteQuery    string // 模糊搜索输入
+	PaletteSelected int    // 当前选中的条目
+	PalettePrevView string // 打开面板前的焦点窗口
+}
+
+// 动态布局配置
+type DynamicLayout struct {
+	// 窗口边界位置 (可调整)
+	LeftPanelWidth    int  // 左侧文件浏览器宽度
+	RightPanelWidth   int  // 右侧面板宽度
+	CommandHeight     int  // 命令窗口高度
+	RightPanelSplit1  int  // 右侧面板第一个分割点 (寄存器/变量)
+	RightPanelSplit2  int  // 右侧面板第二个分割点 (变量/堆栈)
+	
+	// 拖拽状态
+	IsDragging        bool
+	DragBoundary      string // "left", "right", "bottom", "right1", "right2"
+	DragStartX        int
+	DragStartY        int
+	DragOriginalValue int
+	
+	// 终端尺寸（用于检测窗口大小变化并按比例重排）
+	ScreenWidth       int
+	ScreenHeight      int
+}
+
+// 弹出窗口结构
+type PopupWindow struct {
+	ID         string   // 窗口唯一标识
+	Title      string   // 窗口标题
+	X, Y       int      // 窗口左上角位置
+	Width      int      // 窗口宽度  
+	Height     int      // 窗口高度
+	Content    []string // 窗口内容（按行存储）
+	Visible    bool     // 是否可见
+	Dragging   bool     // 是否正在拖拽
+	DragStartX int      // 拖拽起始X坐标
+	DragStartY int      // 拖拽起始Y坐标
+	ScrollY    int      // 垂直滚动偏移
+	OnSelect   func(ui UI, index int) error // 按Enter选中内容行时的回调（可选）
+	OnDigit    func(ui UI, n int) error     // 按数字键1-9时的回调（可选）
+	OnKey      func(ui UI, ch rune) error   // 按小写字母键时的回调（可选，q 保留用于关闭）
+}
+
+var (
+	focusNames = []string{"File Browser", "Registers", "Variables", "Call Stack", "Code View", "Memory", "Command"}
+)
+
+// 创建调试器上下文（每个工作区一个）
+func NewDebuggerContext() *DebuggerContext {
+	return &DebuggerContext{
+		State:          DEBUG_STOPPED,
+		CurrentFocus:   0,
+		BpfLoaded:      false,
+		CurrentFunc:    "main",
+		CurrentAddr:    0x400000,
+		Running:        false,
+		MouseEnabled:   false,
+		CommandHistory: make([]string, 0),  // 初始化命令历史
+		CurrentInput:   "",                 // 初始化当前输入
+		CommandDirty:   true,               // 初始时需要重绘
+		LastClickTime:  time.Time{},        // 初始化双击检测时间
+		LastClickLine:  0,                  // 初始化双击检测行号
+		IsFullscreen:   false,              // 初始化全屏状态
+		FullscreenView: "",                 // 初始化全屏视图
+		SavedLayout:    nil,                // 初始化保存的布局
+		PopupWindows:   make([]*PopupWindow, 0), // 初始化弹出窗口列表
+		DraggingPopup:  nil,                // 初始化拖拽状态
+		// 初始化搜索状态
+		SearchMode:     false,              // 初始化搜索模式
+		SearchTerm:     "",                 // 初始化搜索词
+		SearchResults:  nil,                // 初始化搜索结果
+		CurrentMatch:   -1,                 // 初始化当前匹配项
+		SearchInput:    "",                 // 初始化搜索输入
+		SearchDirty:    false,              // 初始化搜索脏标记
+		DemoMode:       true,               // 默认显示（带SIMULATED标记的）示例数据
+}
+}
@@ -0,0 +1,18 @@
+package session
+
+// 会话层对界面的要求。后台采集、跳转源码和弹出窗口的回调只通过这个接口操作界面，
+// 因此会话层不依赖gocui；没有界面时（脚本、测试）传nil，需要界面的操作直接跳过。
+type UI interface {
+	// 在界面线程中执行f：后台goroutine只能通过它修改DebuggerContext
+	Update(f func(UI) error)
+	// 把焦点切到指定窗口
+	Focus(view string)
+	// 当前获得焦点的窗口名（没有时为空）
+	CurrentView() string
+	// 窗口内的光标位置（窗口不存在时为0,0）
+	Cursor(view string) (x, y int)
+	// 设置窗口内的光标位置
+	SetCursor(view string, x, y int)
+	// 窗口当前显示的文本行
+	Lines(view string) []string
+}
@@ -0,0 +1,91 @@
+package session
+
+import "debug-gocui/internal/project"
+
+// ========== 弹出窗口系统 ==========
+
+// 创建弹出窗口
+func CreatePopupWindow(ctx *DebuggerContext, id, title string, width, height int, content []string) *PopupWindow {
+	// 计算窗口居中位置 (假设屏幕80x24，实际会在layout时调整)
+	x := (80 - width) / 2
+	y := (24 - height) / 2
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	
+	// 标题（含 " [drag] " 和两端边角）需要完整显示
+	if minWidth := project.DisplayWidth(title) + 12; width < minWidth {
+		width = minWidth
+	}
+	
+	popup := &PopupWindow{
+		ID:       id,
+		Title:    title,
+		X:        x,
+		Y:        y,
+		Width:    width,
+		Height:   height,
+		Content:  content,
+		Visible:  true,
+		Dragging: false,
+		ScrollY:  0,
+	}
+	
+	return popup
+}
+
+// 显示弹出窗口
+func ShowPopupWindow(ctx *DebuggerContext, popup *PopupWindow) {
+	if ctx == nil {
+		return
+	}
+	
+	// 检查是否已存在相同ID的窗口
+	for i, existing := range ctx.PopupWindows {
+		if existing.ID == popup.ID {
+			// 更新现有窗口
+			ctx.PopupWindows[i] = popup
+			return
+		}
+	}
+	
+	// 添加新窗口
+	ctx.PopupWindows = append(ctx.PopupWindows, popup)
+}
+
+// 关闭弹出窗口
+func ClosePopupWindow(ctx *DebuggerContext, id string) {
+	if ctx == nil {
+		return
+	}
+	
+	for i, popup := range ctx.PopupWindows {
+		if popup.ID == id {
+			// 如果正在拖拽这个窗口，停止拖拽
+			if ctx.DraggingPopup == popup {
+				ctx.DraggingPopup = nil
+			}
+
+			// 从切片中删除，视图在下次渲染时删除
+			ctx.PopupWindows = append(ctx.PopupWindows[:i], ctx.PopupWindows[i+1:]...)
+			break
+		}
+	}
+}
+
+// 查找弹出窗口
+func FindPopupWindow(ctx *DebuggerContext, id string) *PopupWindow {
+	if ctx == nil {
+		return nil
+	}
+	
+	for _, popup := range ctx.PopupWindows {
+		if popup.ID == id {
+			return popup
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+)
+
+// ========== 用户态探针 ==========
+
+// 是否为用户态探针的段名
+func isUprobeSection(section string) bool {
+	return strings.HasPrefix(section, "uprobe/") || strings.HasPrefix(section, "uretprobe/")
+}
+
+// 挂载 uprobe/<程序>:<函数> 或 uretprobe/<程序>:<函数>
+func attachUprobe(section string, prog *ebpf.Program) (link.Link, error) {
+	target := section[strings.Index(section, "/")+1:]
+	colon := strings.LastIndex(target, ":")
+	if colon <= 0 {
+		return nil, fmt.Errorf("无效的uprobe段名: %s", section)
+	}
+	exe, err := link.OpenExecutable(target[:colon])
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(section, "uretprobe/") {
+		return exe.Uretprobe(target[colon+1:], prog, nil)
+	}
+	return exe.Uprobe(target[colon+1:], prog, nil)
+}
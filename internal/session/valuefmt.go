@@ -0,0 +1,167 @@
+package session
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 数值显示格式 ==========
+// 变量窗口和事件列表中的数值可以按 十进制/十六进制/二进制/枚举名 显示，
+// 每个变量的格式保存在项目设置中
+
+// 显示格式循环顺序
+var valueFormats = []string{"dec", "hex", "bin", "enum"}
+
+// 获取变量的显示格式
+func ValueFormatFor(ctx *DebuggerContext, name string) project.ValueFormat {
+	if ctx.Project != nil && ctx.Project.Settings != nil {
+		if vf, exists := ctx.Project.Settings.ValueFormats[name]; exists {
+			return vf
+		}
+	}
+	return project.ValueFormat{Format: "dec"}
+}
+
+// 设置变量的显示格式并保存
+func SetValueFormat(ctx *DebuggerContext, name string, vf project.ValueFormat) error {
+	if ctx.Project == nil {
+		return fmt.Errorf("没有打开的项目")
+	}
+	if ctx.Project.Settings.ValueFormats == nil {
+		ctx.Project.Settings.ValueFormats = make(map[string]project.ValueFormat)
+	}
+	if vf.Format == "dec" {
+		delete(ctx.Project.Settings.ValueFormats, name)
+	} else {
+		ctx.Project.Settings.ValueFormats[name] = vf
+	}
+	return project.SaveProjectSettings(ctx.Project)
+}
+
+// 切换到下一种显示格式（没有关联枚举类型时跳过enum）
+func CycleValueFormat(ctx *DebuggerContext, name string) (project.ValueFormat, error) {
+	vf := ValueFormatFor(ctx, name)
+	next := 0
+	for i, f := range valueFormats {
+		if f == vf.Format {
+			next = (i + 1) % len(valueFormats)
+		}
+	}
+	vf.Format = valueFormats[next]
+	if vf.Format == "enum" && vf.Enum == "" {
+		vf.Format = valueFormats[0]
+	}
+	return vf, SetValueFormat(ctx, name, vf)
+}
+
+// 按格式渲染数值，无法解析为整数的值原样返回
+func FormatValue(ctx *DebuggerContext, name, raw string) string {
+	vf := ValueFormatFor(ctx, name)
+	n, err := strconv.ParseInt(raw, 0, 64)
+	if err != nil {
+		return raw
+	}
+	switch vf.Format {
+	case "hex":
+		if n < 0 {
+			return fmt.Sprintf("0x%x", uint64(n))
+		}
+		return fmt.Sprintf("0x%x", n)
+	case "bin":
+		if n < 0 {
+			return fmt.Sprintf("0b%b", uint64(n))
+		}
+		return fmt.Sprintf("0b%b", n)
+	case "enum":
+		if names := EnumConstants(ctx, vf.Enum); names != nil {
+			if constName, exists := names[n]; exists {
+				return constName
+			}
+		}
+		return fmt.Sprintf("%d (?%s)", n, vf.Enum)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// ========== 枚举定义解析 ==========
+
+var enumDefRegex = regexp.MustCompile(`(?s)enum\s+(\w+)\s*\{(.*?)\}`)
+
+// 从项目源码中解析所有命名枚举：枚举名 -> 值 -> 常量名
+func parseProjectEnums(root string) map[string]map[int64]string {
+	enums := make(map[string]map[int64]string)
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".c") && !strings.HasSuffix(path, ".h") {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, m := range enumDefRegex.FindAllStringSubmatch(string(data), -1) {
+			enums[m[1]] = parseEnumBody(m[2])
+		}
+		return nil
+	})
+	return enums
+}
+
+// 解析枚举体：支持显式赋值和隐式递增
+func parseEnumBody(body string) map[int64]string {
+	// 去掉注释
+	body = regexp.MustCompile(`(?s)/\*.*?\*/`).ReplaceAllString(body, "")
+	body = regexp.MustCompile(`//[^\n]*`).ReplaceAllString(body, "")
+
+	values := make(map[int64]string)
+	known := make(map[string]int64)
+	next := int64(0)
+	for _, item := range strings.Split(body, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name := item
+		if eq := strings.Index(item, "="); eq >= 0 {
+			name = strings.TrimSpace(item[:eq])
+			expr := strings.TrimSpace(item[eq+1:])
+			if v, err := strconv.ParseInt(expr, 0, 64); err == nil {
+				next = v
+			} else if v, exists := known[expr]; exists {
+				next = v
+			}
+		}
+		known[name] = next
+		if _, exists := values[next]; !exists {
+			values[next] = name
+		}
+		next++
+	}
+	return values
+}
+
+// 获取枚举常量表（首次使用时解析项目源码并缓存）
+func EnumConstants(ctx *DebuggerContext, enumName string) map[int64]string {
+	if ctx.Project == nil || enumName == "" {
+		return nil
+	}
+	if ctx.Project.EnumCache == nil {
+		ctx.Project.EnumCache = parseProjectEnums(ctx.Project.RootPath)
+	}
+	return ctx.Project.EnumCache[enumName]
+}
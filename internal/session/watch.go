@@ -0,0 +1,106 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 监视表达式 ==========
+
+// 添加监视表达式，已存在时返回false
+func AddWatch(ctx *DebuggerContext, expr string) bool {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return false
+	}
+	for _, w := range ctx.Project.Settings.Watches {
+		if w.Expr == expr {
+			return false
+		}
+	}
+	ctx.Project.Settings.Watches = append(ctx.Project.Settings.Watches, project.WatchExpression{Expr: expr, Stale: true})
+	return true
+}
+
+// 删除监视表达式，参数可以是序号（从1开始）或表达式本身
+func RemoveWatch(ctx *DebuggerContext, arg string) (string, bool) {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return "", false
+	}
+	watches := ctx.Project.Settings.Watches
+	for i, w := range watches {
+		if w.Expr == arg || fmt.Sprintf("%d", i+1) == arg {
+			ctx.Project.Settings.Watches = append(watches[:i], watches[i+1:]...)
+			return w.Expr, true
+		}
+	}
+	return "", false
+}
+
+// 获取所有监视表达式名称
+func WatchExpressions(ctx *DebuggerContext) []string {
+	var exprs []string
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return exprs
+	}
+	for _, w := range ctx.Project.Settings.Watches {
+		exprs = append(exprs, w.Expr)
+	}
+	return exprs
+}
+
+// 记录监视表达式的新值，值变化时保留旧值；不是监视表达式时返回false
+func updateWatchValue(ctx *DebuggerContext, expr, value string) bool {
+	if ctx.Project == nil || ctx.Project.Settings == nil {
+		return false
+	}
+	for i := range ctx.Project.Settings.Watches {
+		w := &ctx.Project.Settings.Watches[i]
+		if strings.HasPrefix(expr, w.Expr+"->") {
+			updateWatchMember(w, expr, value)
+			return true
+		}
+		if w.Expr != expr {
+			continue
+		}
+		if w.LastValue != "" && w.LastValue != value {
+			w.PrevValue = w.LastValue
+		}
+		w.LastValue = value
+		w.Stale = false
+		return true
+	}
+	return false
+}
+
+// 记录结构体成员的新值
+func updateWatchMember(w *project.WatchExpression, path, value string) {
+	w.Stale = false
+	for i := range w.Members {
+		if w.Members[i].Name == path {
+			w.Members[i].Value = value
+			return
+		}
+	}
+	w.Members = append(w.Members, project.EventValue{Name: path, Value: value})
+}
+
+// 监视值的显示：变化过的值显示 旧值 → 新值 和差值
+func WatchValueText(ctx *DebuggerContext, w project.WatchExpression) string {
+	if w.LastValue == "" {
+		return "<no value>"
+	}
+	value := FormatValue(ctx, w.Expr, w.LastValue)
+	if w.PrevValue == "" || w.PrevValue == w.LastValue {
+		return value
+	}
+	text := fmt.Sprintf("%s → \x1b[33m%s\x1b[0m", FormatValue(ctx, w.Expr, w.PrevValue), value)
+	prev, err1 := strconv.ParseInt(w.PrevValue, 0, 64)
+	last, err2 := strconv.ParseInt(w.LastValue, 0, 64)
+	if err1 == nil && err2 == nil {
+		text += fmt.Sprintf(" (%+d)", last-prev)
+	}
+	return text
+}
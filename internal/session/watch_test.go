@@ -0,0 +1,23 @@
+package session
+
+import (
+	"testing"
+
+	"debug-gocui/internal/project"
+)
+
+func TestWatchStaleUntilValueArrives(t *testing.T) {
+	ctx := &DebuggerContext{Project: &project.ProjectInfo{Settings: &project.ProjectSettings{}}}
+	AddWatch(ctx, "dev->count")
+	ctx.Project.Settings.Watches[0].LastValue = "7"
+	if w := ctx.Project.Settings.Watches[0]; !w.Stale {
+		t.Fatalf("new watch should be stale until the backend reports a value: %+v", w)
+	}
+	if !updateWatchValue(ctx, "dev->count", "9") {
+		t.Fatal("updateWatchValue did not find the watch")
+	}
+	w := ctx.Project.Settings.Watches[0]
+	if w.Stale || w.LastValue != "9" || w.PrevValue != "7" {
+		t.Fatalf("watch after update = %+v", w)
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 工作集 ==========
+// 记录本次会话中接触过的文件和函数（打开、跳转、标记、断点），按最近使用排序，
+// 内核项目的文件树太大，工作集窗口中按数字键即可跳回。
+
+// 工作集记录上限
+const maxWorkingSet = 100
+
+// 工作集条目
+type WorkingSetEntry struct {
+	File     string
+	Function string
+	Line     int
+	Reason   string // opened / visited / mark / breakpoint
+	Time     time.Time
+}
+
+// 记录一次接触（同一文件中的同一函数只保留最近一次）
+func TouchWorkingSet(ctx *DebuggerContext, file string, line int, reason string) {
+	if ctx == nil || ctx.Project == nil || file == "" {
+		return
+	}
+	function := ""
+	if line > 0 {
+		function = project.ParseFunctionName(file, line)
+	}
+	entry := WorkingSetEntry{File: file, Function: function, Line: line, Reason: reason, Time: time.Now()}
+
+	entries := make([]WorkingSetEntry, 0, len(ctx.WorkingSet)+1)
+	entries = append(entries, entry)
+	reused := false
+	for _, e := range ctx.WorkingSet {
+		switch {
+		case e.File != file:
+			entries = append(entries, e)
+		case function == "" && !reused:
+			// 只打开文件时沿用该文件最近接触的函数位置
+			reused = true
+			entries[0].Function = e.Function
+			entries[0].Line = e.Line
+		case e.Function == entries[0].Function || e.Function == "":
+			// 同一位置或只记录了文件的旧条目，被新条目取代
+		default:
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > maxWorkingSet {
+		entries = entries[:maxWorkingSet]
+	}
+	ctx.WorkingSet = entries
+}
+
+// 工作集：本次会话的记录在前，之后是尚未接触过的断点和标记所在位置
+func workingSetEntries(ctx *DebuggerContext) []WorkingSetEntry {
+	entries := append([]WorkingSetEntry{}, ctx.WorkingSet...)
+	contains := func(file, function string) bool {
+		for _, e := range entries {
+			if e.File == file && (e.Function == function || e.Function == "") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, bp := range ctx.Project.Breakpoints {
+		if !contains(bp.File, bp.Function) {
+			entries = append(entries, WorkingSetEntry{File: bp.File, Function: bp.Function, Line: bp.Line, Reason: "breakpoint"})
+		}
+	}
+	for name, mark := range ctx.Project.Settings.Marks {
+		if name == LastJumpMark {
+			continue
+		}
+		path := resolveMarkPath(ctx, mark)
+		function := project.ParseFunctionName(path, mark.Line)
+		if !contains(path, function) {
+			entries = append(entries, WorkingSetEntry{File: path, Function: function, Line: mark.Line, Reason: "mark " + name})
+		}
+	}
+	return entries
+}
+
+// 距离现在的时间（简短形式）
+func sinceShort(t time.Time) string {
+	if t.IsZero() {
+		return "earlier"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh ago", int(d.Hours()))
+}
+
+// 跳转到工作集第n项（从1开始）
+func JumpToWorkingSet(ui UI, ctx *DebuggerContext, n int) (WorkingSetEntry, error) {
+	entries := workingSetEntries(ctx)
+	if n < 1 || n > len(entries) {
+		return WorkingSetEntry{}, fmt.Errorf("工作集编号超出范围: %d (共%d项)", n, len(entries))
+	}
+	entry := entries[n-1]
+	line := entry.Line
+	if line < 1 {
+		line = 1
+	}
+	return entry, OpenSourceAt(ui, ctx, entry.File, line)
+}
+
+// 显示工作集弹出窗口
+func ShowWorkingSetPopup(ctx *DebuggerContext) int {
+	entries := workingSetEntries(ctx)
+	content := make([]string, 0, len(entries)+2)
+	for i, e := range entries {
+		key := "  "
+		if i < 9 {
+			key = Styled(ActiveTheme.Key, fmt.Sprint(i+1)) + " "
+		}
+		location := project.ProjectRelativePath(ctx.Project, e.File)
+		if e.Line > 0 {
+			location = fmt.Sprintf("%s:%d", location, e.Line)
+		}
+		function := ""
+		if e.Function != "" {
+			function = e.Function + "()"
+		}
+		content = append(content, fmt.Sprintf("%s%s %s %s", key, project.FitWidth(location, 36), project.FitWidth(function, 28), Styled(ActiveTheme.Dim, e.Reason+", "+sinceShort(e.Time))))
+	}
+	if len(entries) == 0 {
+		content = append(content, "Nothing touched yet: open files, set marks or breakpoints")
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, "1-9 jump | Enter jumps to the line under the cursor"))
+
+	jump := func(ui UI, n int) error {
+		if entry, err := JumpToWorkingSet(ui, ctx, n); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ClosePopupWindow(ctx, "workset")
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[WS] %s:%d", project.ProjectRelativePath(ctx.Project, entry.File), entry.Line))
+			ui.Focus("code")
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+
+	ClosePopupWindow(ctx, "workset")
+	popup := CreatePopupWindow(ctx, "workset", "Working Set", 100, 25, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		if index < 0 || index >= len(entries) {
+			return nil
+		}
+		return jump(ui, index+1)
+	}
+	popup.OnDigit = jump
+	ShowPopupWindow(ctx, popup)
+	return len(entries)
+}
@@ -0,0 +1,42 @@
+package session
+
+import "path/filepath"
+
+// ========== 多工作区 ==========
+
+// 工作区数量上限（Alt+1..Alt+4）
+const MaxWorkspaces = 4
+
+// 工作区
+type Workspace struct {
+	Ctx        *DebuggerContext
+	Name       string // 为空时使用项目名
+	SeenEvents int    // 切走时的事件序号（用于提示后台新事件）
+}
+
+// 工作区显示名
+func WorkspaceLabel(ws *Workspace) string {
+	if ws.Name != "" {
+		return ws.Name
+	}
+	if ws.Ctx.Project != nil {
+		return filepath.Base(ws.Ctx.Project.RootPath)
+	}
+	return "empty"
+}
+
+// 工作区的采集目标
+func WorkspaceTarget(ctx *DebuggerContext) string {
+	if remote := RemoteTarget(ctx); remote != nil {
+		return "ssh " + remote.SSH
+	}
+	return "local"
+}
+
+// 工作区的断点数
+func WorkspaceBreakpoints(ctx *DebuggerContext) int {
+	if ctx.Project == nil {
+		return 0
+	}
+	return len(ctx.Project.Breakpoints)
+}
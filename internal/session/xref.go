@@ -0,0 +1,193 @@
+package session
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+
+	"debug-gocui/internal/project"
+)
+
+// ========== 符号导航（转到定义 / 查找引用） ==========
+// 打开项目时在后台用 tree-sitter 为项目中的全部源文件和头文件建立符号索引：定义（函数、全局变量、
+// 结构体/联合体/枚举、成员、枚举常量、typedef、宏）和每个标识符出现的位置，注释和字符串中的不算。
+// 代码窗口中单击标识符后按 gd 跳到定义（当前函数的局部变量和参数优先），gr 在弹出窗口中列出引用，
+// 也可以用命令 def/refs [name]。跳转前的位置记为标记 '，'' 跳回。
+// 宏定义体中的标识符不在索引中；replace 改写过的文件会重新索引。
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// 为项目建立符号索引（项目大时耗时较长，界面中在后台调用）
+func buildSymbolIndex(root string) *project.SymbolIndex {
+	idx := &project.SymbolIndex{Defs: make(map[string][]project.XrefLocation), Refs: make(map[string][]project.XrefLocation)}
+	parser := sitter.NewParser()
+	parser.SetLanguage(c.GetLanguage())
+	project.WalkProjectSources(root, func(path string) bool {
+		if idx.IndexFile(parser, path) == nil {
+			idx.Files++
+		}
+		return true
+	})
+	return idx
+}
+
+// 打开项目后在后台建立索引，完成后写入 project.Xref
+func StartSymbolIndex(ui UI, ctx *DebuggerContext, project *project.ProjectInfo) {
+	project.XrefLoading = true
+	go func() {
+		idx := buildSymbolIndex(project.RootPath)
+		ui.Update(func(ui UI) error {
+			project.Xref = idx
+			project.XrefLoading = false
+			if ctx.Project == project {
+				ctx.CommandHistory = append(ctx.CommandHistory,
+					fmt.Sprintf("[XREF] Indexed %d symbols in %d files (gd: definition, gr: references)", len(idx.Defs), idx.Files))
+				ctx.CommandDirty = true
+			}
+			return nil
+		})
+	}()
+}
+
+// 取得项目的符号索引：还没有时同步建立（无界面执行脚本时），后台正在建立时返回错误
+func projectSymbolIndex(project *project.ProjectInfo) (*project.SymbolIndex, error) {
+	if project.Xref != nil {
+		return project.Xref, nil
+	}
+	if project.XrefLoading {
+		return nil, fmt.Errorf("符号索引正在建立，请稍后再试")
+	}
+	project.Xref = buildSymbolIndex(project.RootPath)
+	return project.Xref, nil
+}
+
+// 代码窗口光标处的标识符（单击标识符会把光标移到那里）
+func IdentifierAtCodeCursor(ui UI) string {
+	cx, cy := ui.Cursor("code")
+	lines := ui.Lines("code")
+	if cy < 2 || cy >= len(lines) {
+		return ""
+	}
+	word := WordAt(lines[cy], cx)
+	if !identifierRegex.MatchString(word) {
+		return ""
+	}
+	return word
+}
+
+// 跳转到定义：当前函数的局部变量和参数优先，有多个定义时弹出列表
+func GotoDefinition(ui UI, ctx *DebuggerContext, name string) (string, error) {
+	file, line, ok := CurrentCodeLocation(ui, ctx)
+	if ok {
+		if fn := project.CFunctionAt(file, line); fn != nil {
+			for _, decl := range append(append([]project.CDecl(nil), fn.Params...), fn.Locals...) {
+				if decl.Name == name && decl.Line <= line {
+					if err := jumpWithMark(ui, ctx, file, decl.Line); err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("[XREF] %s: %s %s in %s() at line %d", name, decl.Type, name, fn.Name, decl.Line), nil
+				}
+			}
+		}
+	}
+	idx, err := projectSymbolIndex(ctx.Project)
+	if err != nil {
+		return "", err
+	}
+	defs := idx.Definitions(name, file)
+	switch {
+	case len(defs) == 0:
+		return "", fmt.Errorf("项目中没有找到 %s 的定义", name)
+	case len(defs) == 1 || project.XrefKindRank[defs[0].Kind] < project.XrefKindRank[defs[1].Kind]:
+		def := defs[0]
+		if err := jumpWithMark(ui, ctx, def.File, def.Line); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[XREF] %s: %s at %s:%d", name, def.Kind, project.ProjectRelativePath(ctx.Project, def.File), def.Line), nil
+	}
+	showXrefPopup(ctx, "xref", fmt.Sprintf("Definitions of %s (%d)", name, len(defs)), name, defs)
+	return fmt.Sprintf("[XREF] %s: %d definitions, choose one in the popup", name, len(defs)), nil
+}
+
+// 定义列表（无界面时的 def 输出）
+func DescribeDefinitions(ctx *DebuggerContext, name string) ([]string, error) {
+	idx, err := projectSymbolIndex(ctx.Project)
+	if err != nil {
+		return nil, err
+	}
+	defs := idx.Definitions(name, "")
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("项目中没有找到 %s 的定义", name)
+	}
+	output := []string{fmt.Sprintf("Definitions of %s: %d", name, len(defs))}
+	for _, def := range defs {
+		output = append(output, fmt.Sprintf("  %-10s %s:%d", def.Kind, project.ProjectRelativePath(ctx.Project, def.File), def.Line))
+	}
+	return output, nil
+}
+
+// 列出引用
+func ShowReferences(ctx *DebuggerContext, name string) (string, error) {
+	idx, err := projectSymbolIndex(ctx.Project)
+	if err != nil {
+		return "", err
+	}
+	refs := idx.Refs[name]
+	if len(refs) == 0 {
+		return "", fmt.Errorf("项目中没有找到 %s", name)
+	}
+	files := make(map[string]bool)
+	for _, ref := range refs {
+		files[ref.File] = true
+	}
+	if len(refs) > MaxProjectMatches {
+		refs = refs[:MaxProjectMatches]
+	}
+	showXrefPopup(ctx, "xref", fmt.Sprintf("References to %s (%d in %d files)", name, len(idx.Refs[name]), len(files)), name, refs)
+	return fmt.Sprintf("[XREF] %s: %d references in %d files", name, len(idx.Refs[name]), len(files)), nil
+}
+
+// 定义/引用列表窗口：file:line、种类和高亮了名称的源码行，Enter 跳转
+func showXrefPopup(ctx *DebuggerContext, id, title, name string, locs []project.XrefLocation) {
+	files := make(map[string]*project.SourceFile)
+	content := make([]string, 0, len(locs)+2)
+	for _, loc := range locs {
+		file, ok := files[loc.File]
+		if !ok {
+			file, _ = project.LoadSourceFile(loc.File)
+			files[loc.File] = file
+		}
+		text := ""
+		if file != nil {
+			text = file.Line(loc.Line - 1)
+			if end := loc.Column + len(name); end <= len(text) && text[loc.Column:end] == name {
+				text = text[:loc.Column] + Styled(ActiveTheme.Match, name) + text[end:]
+			}
+		}
+		location := fmt.Sprintf("%s:%d", project.ProjectRelativePath(ctx.Project, loc.File), loc.Line)
+		content = append(content, fmt.Sprintf("\x1b[36m%-32s\x1b[0m %-10s %s", location, loc.Kind,
+			strings.TrimSpace(strings.ReplaceAll(text, "\t", "    "))))
+	}
+	content = append(content, "", Styled(ActiveTheme.Dim, "Enter: open in the code view ('' jumps back)"))
+
+	ClosePopupWindow(ctx, id)
+	popup := CreatePopupWindow(ctx, id, title, 110, 25, content)
+	popup.OnSelect = func(ui UI, index int) error {
+		if index < 0 || index >= len(locs) {
+			return nil
+		}
+		loc := locs[index]
+		ClosePopupWindow(ctx, id)
+		if err := jumpWithMark(ui, ctx, loc.File, loc.Line); err != nil {
+			ReportError(ctx, err)
+		} else {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("[XREF] %s:%d", project.ProjectRelativePath(ctx.Project, loc.File), loc.Line))
+		}
+		ctx.CommandDirty = true
+		return nil
+	}
+	ShowPopupWindow(ctx, popup)
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymbolIndex(t *testing.T) {
+	dir := t.TempDir()
+	header := `#define DRV_NAME "demo"
+struct demo_dev {
+	int count;
+	int (*probe)(struct demo_dev *dev);
+};
+enum demo_state { DEMO_IDLE, DEMO_BUSY };
+typedef struct demo_dev demo_t;
+int demo_probe(struct demo_dev *dev);
+`
+	source := `#include "demo.h"
+static int demo_debug;
+
+/* demo_probe is called by the core */
+int __init demo_probe(struct demo_dev *dev)
+{
+	int count = dev->count;
+	return count + demo_debug;
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "demo.h"), []byte(header), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "demo.c"), []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := buildSymbolIndex(dir)
+	tests := []struct {
+		name, kind, file string
+		line             int
+	}{
+		{"demo_probe", "function", "demo.c", 5},
+		{"DRV_NAME", "macro", "demo.h", 1},
+		{"demo_dev", "struct", "demo.h", 2},
+		{"count", "field", "demo.h", 3},
+		{"probe", "field", "demo.h", 4},
+		{"DEMO_BUSY", "enumerator", "demo.h", 6},
+		{"demo_t", "typedef", "demo.h", 7},
+		{"demo_debug", "variable", "demo.c", 2},
+	}
+	for _, tt := range tests {
+		defs := idx.Definitions(tt.name, "")
+		if len(defs) == 0 {
+			t.Errorf("%s: no definition", tt.name)
+			continue
+		}
+		if def := defs[0]; def.Kind != tt.kind || filepath.Base(def.File) != tt.file || def.Line != tt.line {
+			t.Errorf("%s: first definition %s at %s:%d, want %s at %s:%d",
+				tt.name, def.Kind, filepath.Base(def.File), def.Line, tt.kind, tt.file, tt.line)
+		}
+	}
+	// 函数原型排在定义之后，局部变量 count 不是定义
+	if defs := idx.Definitions("demo_probe", ""); len(defs) != 2 || defs[1].Kind != "prototype" {
+		t.Errorf("demo_probe definitions = %+v", defs)
+	}
+	if defs := idx.Definitions("count", ""); len(defs) != 1 {
+		t.Errorf("count definitions = %+v", defs)
+	}
+	// 注释中的 demo_probe 不是引用
+	if refs := idx.Refs["demo_probe"]; len(refs) != 2 {
+		t.Errorf("demo_probe references = %+v", refs)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "demo.c"), []byte("static int demo_debug_level;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx.UpdateFile(filepath.Join(dir, "demo.c"))
+	if len(idx.Defs["demo_debug"]) != 0 || len(idx.Defs["demo_debug_level"]) != 1 {
+		t.Errorf("after update: demo_debug=%v demo_debug_level=%v", idx.Defs["demo_debug"], idx.Defs["demo_debug_level"])
+	}
+}
@@ -0,0 +1,58 @@
+package testsupport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 测试夹具 ==========
+// project、session、codegen 的测试共用的驱动源码和临时项目。project 的测试以
+// project_test 包引用这里（本包依赖 project 和 session）。
+
+// 测试用的驱动源码（行号在测试中引用）
+const DriverSource = `#include <linux/module.h>
+
+static int scale(int x)
+{
+	int factor = 3;
+	return x * factor;
+}
+
+static int do_work(int a, int b)
+{
+	int sum = a + b;
+	sum = scale(sum);
+	return sum;
+}
+`
+
+// 把测试驱动写到临时目录，返回源文件路径
+func WriteDriver(t testing.TB) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "drv.c")
+	if err := os.WriteFile(path, []byte(DriverSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 建立只有源码（没有 .ko）的临时项目，断点文件名相对于项目根目录
+func NewProject(t testing.TB, bps ...project.Breakpoint) *session.DebuggerContext {
+	t.Helper()
+	root := filepath.Dir(WriteDriver(t))
+	for i := range bps {
+		bps[i].File = filepath.Join(root, bps[i].File)
+	}
+	ctx := session.NewDebuggerContext()
+	ctx.Project = &project.ProjectInfo{
+		RootPath:    root,
+		OpenFiles:   make(map[string]*project.SourceFile),
+		Breakpoints: bps,
+		Settings:    &project.ProjectSettings{TargetArch: "x86_64"},
+	}
+	return ctx
+}
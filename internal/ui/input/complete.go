@@ -0,0 +1,97 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令窗口Tab补全 ==========
+// 第一个词补全命令名，之后补全子命令（来自命令面板的条目）或文件路径。
+// 路径可以包含空格：从命令之后的第一个词开始尝试，取第一个能匹配到文件的位置，
+// 所以 "open /home/me/My Pro" 会整体作为路径补全。相对路径以项目根目录为基准（没有项目时为当前目录）。
+// 唯一匹配时直接补全（目录补上 /），多个匹配时补全到公共前缀，没有进展时在命令窗口列出候选。
+
+// 命令面板中的命令字符串（去掉首尾空格，去重）
+func (app *AppContext) completionCommands() []string {
+	seen := make(map[string]bool)
+	commands := make([]string, 0)
+	for _, entry := range app.paletteEntries() {
+		command := strings.TrimSpace(entry.Command)
+		if command != "" && !seen[command] {
+			seen[command] = true
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// 补全输入：返回补全后的输入，没有进展时返回多个候选
+func (app *AppContext) completeInput(input string) (string, []string) {
+	wordStart := strings.LastIndex(input, " ") + 1
+	typed := input[wordStart:]
+
+	// 命令名和子命令
+	if words := session.CompleteWords(input, app.completionCommands()); len(words) > 0 {
+		if len(words) == 1 {
+			return input[:wordStart] + words[0] + " ", nil
+		}
+		if prefix := session.LongestCommonPrefix(words); len(prefix) > len(typed) {
+			return input[:wordStart] + prefix, nil
+		}
+		return input, words
+	}
+	if wordStart == 0 {
+		return input, nil
+	}
+
+	// 文件路径
+	base, _ := os.Getwd()
+	if app.Ctx.Project != nil {
+		base = app.Ctx.Project.RootPath
+	}
+	start, dirPart, matches := session.CompletePath(input, base)
+	if start < 0 {
+		return input, nil
+	}
+	filePart := input[start+len(dirPart):]
+	if len(matches) == 1 {
+		return input[:start] + dirPart + matches[0], nil
+	}
+	if prefix := session.LongestCommonPrefix(matches); len(prefix) > len(filePart) {
+		return input[:start] + dirPart + prefix, nil
+	}
+	return input, matches
+}
+
+// Tab：命令窗口中补全，其他窗口中切换到下一个窗口
+func (app *AppContext) tabHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || v == nil || v.Name() != "command" {
+		return nextViewHandler(g, v)
+	}
+	ctx := app.Ctx
+	if ctx.HistorySearch {
+		return nil
+	}
+	completed, candidates := app.completeInput(ctx.CurrentInput)
+	if completed != ctx.CurrentInput {
+		ctx.CurrentInput = completed
+		session.ResetHistoryBrowse(ctx)
+	} else if len(candidates) > 0 {
+		// 与bash相同：列出候选，保留当前输入
+		shown := candidates
+		if len(shown) > session.MaxCompletionCandidates {
+			shown = shown[:session.MaxCompletionCandidates]
+		}
+		line := strings.Join(shown, "  ")
+		if len(candidates) > len(shown) {
+			line += session.Styled(session.ActiveTheme.Dim, fmt.Sprintf("  ... (%d more)", len(candidates)-len(shown)))
+		}
+		ctx.CommandHistory = append(ctx.CommandHistory, line)
+	}
+	ctx.CommandDirty = true
+	return nil
+}
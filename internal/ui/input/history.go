@@ -0,0 +1,84 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 命令历史 ==========
+
+// Ctrl+R：命令窗口中开始反向搜索或查找更早的匹配，其他窗口中重置布局
+func (app *AppContext) ctrlRHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	if v == nil || v.Name() != "command" {
+		return app.ResetLayout(g, v)
+	}
+	ctx := app.Ctx
+	if !ctx.HistorySearch {
+		ctx.HistorySearch = true
+		ctx.HistoryQuery = ""
+		ctx.HistoryMatch = -1
+		ctx.HistorySavedInput = ctx.CurrentInput
+	} else {
+		before := ctx.HistoryMatch
+		if before < 0 {
+			before = len(ctx.CommandHistory)
+		}
+		if match := session.SearchHistoryBackward(ctx, ctx.HistoryQuery, before); match >= 0 {
+			ctx.HistoryMatch = match
+		}
+	}
+	ctx.CommandDirty = true
+	return nil
+}
+
+// Ctrl+G：取消搜索
+func (app *AppContext) CancelHistorySearchHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx != nil && app.Ctx.HistorySearch {
+		session.EndHistorySearch(app.Ctx, false)
+	}
+	return nil
+}
+
+// ↑：命令窗口中显示上一条命令（第一次按下时保存正在输入的内容）
+func (app *AppContext) historyPrevHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.HistorySearch {
+		return nil
+	}
+	ctx := app.Ctx
+	commands := session.BrowsableCommands(ctx)
+	if ctx.HistoryBrowse >= len(commands) {
+		return nil
+	}
+	if ctx.HistoryBrowse == 0 {
+		ctx.HistoryDraft = ctx.CurrentInput
+	}
+	ctx.HistoryBrowse++
+	ctx.CurrentInput = commands[len(commands)-ctx.HistoryBrowse]
+	ctx.CommandDirty = true
+	return nil
+}
+
+// ↓：显示下一条命令，回到最新之后恢复原来的输入
+func (app *AppContext) historyNextHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.HistorySearch || app.Ctx.HistoryBrowse == 0 {
+		return nil
+	}
+	ctx := app.Ctx
+	commands := session.BrowsableCommands(ctx)
+	ctx.HistoryBrowse--
+	if ctx.HistoryBrowse > len(commands) {
+		// 浏览期间历史被裁剪
+		ctx.HistoryBrowse = len(commands)
+	}
+	if ctx.HistoryBrowse == 0 {
+		ctx.CurrentInput = ctx.HistoryDraft
+		ctx.HistoryDraft = ""
+	} else {
+		ctx.CurrentInput = commands[len(commands)-ctx.HistoryBrowse]
+	}
+	ctx.CommandDirty = true
+	return nil
+}
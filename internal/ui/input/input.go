@@ -0,0 +1,1096 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"encoding/base64"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/layout"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 文本选择功能 ==========
+
+// 复制选中文本到系统剪贴板
+func copyToClipboard(text string) error {
+	// 方法1: 尝试使用OSC52 (适用于SSH和现代终端)
+	if err := copyWithOSC52(text); err == nil {
+		return nil
+	}
+	
+	// 方法2: 尝试xclip
+	if err := exec.Command("xclip", "-selection", "clipboard").Run(); err == nil {
+		cmd := exec.Command("xclip", "-selection", "clipboard")
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	
+	// 方法3: 尝试xsel
+	if err := exec.Command("xsel", "--version").Run(); err == nil {
+		cmd := exec.Command("xsel", "--clipboard", "--input")
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return nil
+		}
+	}
+	
+	return fmt.Errorf("Cannot access clipboard, please install xclip or xsel")
+}
+
+func copyWithOSC52(text string) error {
+	// 简化的OSC52实现 - 需要base64编码
+	encoded := base64.StdEncoding.EncodeToString([]byte(text))
+	osc52Sequence := fmt.Sprintf("\033]52;c;%s\007", encoded)
+	_, err := os.Stderr.Write([]byte(osc52Sequence))
+	return err
+}
+
+// 处理Enter键选择当前行
+func (app *AppContext) selectCurrentLine(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	
+	// 获取当前光标位置
+	_, cy := v.Cursor()
+	lines := views.ViewText(g, v.Name())
+	
+	if cy < len(lines) && cy >= 0 {
+		selectedText := strings.TrimSpace(lines[cy])
+		if selectedText != "" {
+			// 复制到剪贴板
+			copyToClipboard(selectedText)
+			
+			// 显示选择结果
+			if app.Ctx != nil {
+				app.Ctx.SelectionMode = true
+				app.Ctx.SelectionView = v.Name()
+				app.Ctx.SelectionText = selectedText
+			}
+		}
+	}
+	return nil
+}
+
+// 处理双击选择单词
+func (app *AppContext) selectWordAtCursor(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	
+	cx, cy := v.Cursor()
+	lines := views.ViewText(g, v.Name())
+	
+	if cy < len(lines) && cy >= 0 {
+		if selectedText := session.WordAt(lines[cy], cx); selectedText != "" {
+			copyToClipboard(selectedText)
+			
+			if app.Ctx != nil {
+				app.Ctx.SelectionMode = true
+				app.Ctx.SelectionView = v.Name()
+				app.Ctx.SelectionText = selectedText
+			}
+		}
+	}
+	return nil
+}
+
+// 清除选择状态
+func (app *AppContext) clearSelection(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx != nil {
+		app.Ctx.SelectionMode = false
+		app.Ctx.SelectionView = ""
+		app.Ctx.SelectionText = ""
+	}
+	return nil
+}
+
+func mouseFocusHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	g.SetCurrentView(v.Name())
+	return nil
+}
+
+func (app *AppContext) MouseScrollUpHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	scrollWindowByName(app.Ctx, v.Name(), -1)
+	return nil
+}
+
+func (app *AppContext) MouseScrollDownHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	scrollWindowByName(app.Ctx, v.Name(), 1)
+	return nil
+}
+
+// ========== 键盘滚动处理 ==========
+func (app *AppContext) scrollUpHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	scrollWindowByName(app.Ctx, v.Name(), -1)
+	return nil
+}
+
+func (app *AppContext) scrollDownHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil {
+		return nil
+	}
+	scrollWindowByName(app.Ctx, v.Name(), 1)
+	return nil
+}
+
+// ========== 窗口切换处理 ==========
+func nextViewHandler(g *gocui.Gui, v *gocui.View) error {
+	views := existingViews(g, []string{"filebrowser", "registers", "variables", "stack", "code", "memory", "timeline", "command"})
+	currentView := g.CurrentView()
+	if currentView == nil {
+		g.SetCurrentView("filebrowser")
+		return nil
+	}
+	
+	currentName := currentView.Name()
+	for i, name := range views {
+		if name == currentName {
+			nextIndex := (i + 1) % len(views)
+			g.SetCurrentView(views[nextIndex])
+			break
+		}
+	}
+	return nil
+}
+
+func prevViewHandler(g *gocui.Gui, v *gocui.View) error {
+	views := existingViews(g, []string{"filebrowser", "registers", "variables", "stack", "code", "memory", "timeline", "command"})
+	currentView := g.CurrentView()
+	if currentView == nil {
+		g.SetCurrentView("filebrowser")
+		return nil
+	}
+	
+	currentName := currentView.Name()
+	for i, name := range views {
+		if name == currentName {
+			prevIndex := (i - 1 + len(views)) % len(views)
+			g.SetCurrentView(views[prevIndex])
+			break
+		}
+	}
+	return nil
+}
+
+// 过滤掉当前没有显示的窗口（内存窗口只在 mem read 后存在，时间线窗口只在 timeline 打开后存在）
+func existingViews(g *gocui.Gui, names []string) []string {
+	views := make([]string, 0, len(names))
+	for _, name := range names {
+		if _, err := g.View(name); err == nil {
+			views = append(views, name)
+		}
+	}
+	return views
+}
+
+// ========== 直接窗口切换 ==========
+func switchToFileBrowser(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("filebrowser")
+	return nil
+}
+
+func switchToRegisters(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("registers")
+	return nil
+}
+
+func switchToVariables(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("variables")
+	return nil
+}
+
+func switchToStack(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("stack")
+	return nil
+}
+
+func switchToCode(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("code")
+	return nil
+}
+
+func (app *AppContext) switchToCommand(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("command")
+	// 标记命令窗口需要重绘（获得焦点时）
+	if app.Ctx != nil {
+		app.Ctx.CommandDirty = true
+	}
+	return nil
+}
+
+func scrollWindowByName(ctx *session.DebuggerContext, name string, direction int) {
+	switch name {
+	case "filebrowser":
+		ctx.FileScroll += direction
+		if ctx.FileScroll < 0 {
+			ctx.FileScroll = 0
+		}
+	case "registers":
+		ctx.RegScroll += direction
+		if ctx.RegScroll < 0 {
+			ctx.RegScroll = 0
+		}
+	case "variables":
+		ctx.VarScroll += direction
+		if ctx.VarScroll < 0 {
+			ctx.VarScroll = 0
+		}
+	case "stack":
+		ctx.StackScroll += direction
+		if ctx.StackScroll < 0 {
+			ctx.StackScroll = 0
+		}
+	case "code":
+		ctx.CodeScroll += direction
+		if ctx.CodeScroll < 0 {
+			ctx.CodeScroll = 0
+		}
+	case "memory":
+		ctx.MemScroll += direction
+		if ctx.MemScroll < 0 {
+			ctx.MemScroll = 0
+		}
+	}
+}
+
+// ========== 事件处理函数 ==========
+
+// 处理命令输入
+func (app *AppContext) HandleCommand(g *gocui.Gui, v *gocui.View) error {
+	app.SubmitInput(views.GuiOf(g))
+	return nil
+}
+
+// 处理文件选择（旧的键盘版本，保留向后兼容）
+func (app *AppContext) HandleFileSelection(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil {
+		return nil
+	}
+	
+	// 简化实现：选择第一个C文件
+	if app.Ctx.Project.FileTree != nil {
+		for _, child := range app.Ctx.Project.FileTree.Children {
+			if !child.IsDir && strings.HasSuffix(child.Name, ".c") {
+				session.SwitchCodeFile(app.Ctx, child.Path)
+				break
+			}
+		}
+	}
+	
+	return nil
+}
+
+// 处理文件浏览器鼠标点击
+func (app *AppContext) HandleFileBrowserClick(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil {
+		// 即使没有项目，也要确保聚焦到文件浏览器
+		g.SetCurrentView("filebrowser")
+		return nil
+	}
+	
+	// 首先聚焦到文件浏览器
+	g.SetCurrentView("filebrowser")
+	
+	// 获取鼠标点击位置
+	_, cy := v.Cursor()
+	
+	// 计算实际点击的行号（考虑标题行和滚动偏移）
+	// 文件浏览器有5行标题：标题行、空行、项目名、提示行、空行
+	headerLines := 5
+	clickedLine := cy - headerLines + app.Ctx.FileScroll
+	
+	// 检查点击行是否有效
+	if clickedLine < 0 || clickedLine >= len(app.Ctx.FileBrowserLines) {
+		return nil
+	}
+	
+	// 获取对应的文件节点
+	node := app.Ctx.FileBrowserLines[clickedLine]
+	if node == nil {
+		return nil
+	}
+	
+	if node.IsDir {
+		// 点击目录：切换展开/折叠状态（第一次展开时在后台读取子节点）
+		toggleDirNode(g, node)
+		
+		// 更新文件浏览器显示
+		g.Update(func(g *gocui.Gui) error {
+			views.UpdateFileBrowserView(g, app.Ctx)
+			return nil
+		})
+		
+		// 保持在文件浏览器聚焦状态
+		
+	} else {
+		// 点击文件：在代码视图中打开
+		// 已打开过的文件恢复上次的滚动位置和搜索状态
+		session.SwitchCodeFile(app.Ctx, node.Path)
+		session.TouchWorkingSet(app.Ctx, node.Path, 0, "opened")
+		
+		// 更新所有视图以反映文件打开状态
+		g.Update(func(g *gocui.Gui) error {
+			views.UpdateAllViews(g, app.Ctx)
+			return nil
+		})
+		
+		// 自动切换到代码视图
+		g.SetCurrentView("code")
+	}
+	
+	return nil
+}
+
+// 展开/折叠目录：子节点在第一次展开时由后台协程读取（大的内核源码树不阻塞界面），之后缓存
+func toggleDirNode(g *gocui.Gui, node *project.FileNode) {
+	node.Expanded = !node.Expanded
+	if !node.Expanded || node.Loaded || node.Loading {
+		return
+	}
+	node.Loading = true
+	node.LoadErr = ""
+	go func() {
+		children, err := project.ReadDirNodes(node.Path)
+		g.Update(func(g *gocui.Gui) error {
+			node.Loading = false
+			if err != nil {
+				// 读取失败时不缓存，下次展开重试
+				node.LoadErr = err.Error()
+				return nil
+			}
+			node.Children = children
+			node.Loaded = true
+			return nil
+		})
+	}()
+}
+
+// 处理断点设置
+func (app *AppContext) HandleBreakpointToggle(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil || app.Ctx.Project.CurrentFile == "" || app.Ctx.Disasm != nil {
+		return nil
+	}
+	
+	// 获取当前行号（简化实现）
+	_, cy := v.Cursor()
+	lineNum := app.Ctx.CodeScroll + cy + 1 // 考虑滚动偏移
+	
+	// 切换断点
+	session.AddBreakpoint(app.Ctx, app.Ctx.Project.CurrentFile, lineNum)
+	
+	return nil
+}
+
+// 处理代码视图鼠标点击：单击断点栏设置/取消断点，在代码文本上双击选择单词
+func (app *AppContext) HandleCodeViewClick(g *gocui.Gui, v *gocui.View) error {
+	// 首先聚焦到代码视图
+	g.SetCurrentView("code")
+	
+	if app.Ctx == nil || app.Ctx.Project == nil || app.Ctx.Project.CurrentFile == "" || app.Ctx.Disasm != nil {
+		// 如果没有打开文件（或显示的是反汇编），只需要聚焦即可
+		return nil
+	}
+	
+	// 获取点击位置
+	cx, cy := v.Cursor()
+	currentTime := time.Now()
+	
+	// 计算实际点击的代码行号（考虑标题行和滚动偏移）
+	// 代码视图有2行标题：标题行、标签栏
+	headerLines := 2
+	clickedCodeLine := cy - headerLines + app.Ctx.CodeScroll
+	
+	// 标签栏：单击切换文件
+	if cy == 1 {
+		if path := session.CodeTabAt(app.Ctx, cx); path != "" && path != app.Ctx.Project.CurrentFile {
+			session.SwitchCodeFile(app.Ctx, path)
+		}
+		return nil
+	}
+	
+	// 检查是否是有效的代码行
+	if clickedCodeLine < 0 {
+		return nil
+	}
+	
+	// 计算实际的源代码行号（从1开始）
+	sourceLineNum := clickedCodeLine + 1
+	
+	if cx < views.CodeGutterWidth {
+		// 断点栏：单击设置/取消断点
+		app.Ctx.LastClickLine = 0
+		file, err := project.ProjectSourceFile(app.Ctx.Project, app.Ctx.Project.CurrentFile)
+		if err != nil {
+			return nil
+		}
+		
+		// 检查行号是否有效
+		if sourceLineNum <= file.Len() {
+			session.AddBreakpoint(app.Ctx, app.Ctx.Project.CurrentFile, sourceLineNum)
+			
+			// 更新所有视图以反映断点变化
+			g.Update(func(g *gocui.Gui) error {
+				views.UpdateAllViews(g, app.Ctx)
+				return nil
+			})
+		}
+		return nil
+	}
+	
+	// 检查是否是双击（300毫秒内在同一行点击两次）
+	isDoubleClick := false
+	if app.Ctx.LastClickLine == sourceLineNum && 
+	   currentTime.Sub(app.Ctx.LastClickTime) < 300*time.Millisecond {
+		isDoubleClick = true
+	}
+	
+	// 更新点击状态
+	app.Ctx.LastClickTime = currentTime
+	app.Ctx.LastClickLine = sourceLineNum
+	
+	if isDoubleClick {
+		// 双击代码文本：选择并复制光标处的单词
+		app.clearSelection(g, v)
+		app.selectWordAtCursor(g, v)
+		if app.Ctx.SelectionMode && app.Ctx.SelectionView == "code" {
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("Selected: %s", app.Ctx.SelectionText))
+			app.Ctx.CommandDirty = true
+		}
+	}
+	
+	return nil
+}
+
+// 处理字符输入
+func (app *AppContext) handleCharInput(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil {
+			return nil
+		}
+		
+		// 调试信息：仅记录关键问题
+		if ch == '.' && len(app.Ctx.CommandHistory) < 10 {
+			currentViewName := "none"
+			if g.CurrentView() != nil {
+				currentViewName = g.CurrentView().Name()
+			}
+			debugInfo := fmt.Sprintf("[DEBUG] Dot input, view: %s, current input length: %d", currentViewName, len(app.Ctx.CurrentInput))
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugInfo)
+			app.Ctx.CommandDirty = true
+		}
+		
+		// 只在命令窗口聚焦时处理字符输入
+		if g.CurrentView() != nil && g.CurrentView().Name() == "command" {
+			// 反向搜索状态下输入的是搜索词
+			if app.Ctx.HistorySearch {
+				session.HistorySearchInput(app.Ctx, app.Ctx.HistoryQuery + string(ch))
+				return nil
+			}
+			// 将字符添加到当前输入
+			app.Ctx.CurrentInput += string(ch)
+			session.ResetHistoryBrowse(app.Ctx)
+			// 标记需要重绘
+			app.Ctx.CommandDirty = true
+		}
+		
+		return nil
+	}
+}
+
+// 处理退格键
+func (app *AppContext) HandleBackspace(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	// 只在命令窗口聚焦时处理退格
+	if g.CurrentView() != nil && g.CurrentView().Name() == "command" {
+		// 反向搜索状态下删除搜索词的最后一个字符
+		if app.Ctx.HistorySearch {
+			if query := []rune(app.Ctx.HistoryQuery); len(query) > 0 {
+				session.HistorySearchInput(app.Ctx, string(query[:len(query)-1]))
+			}
+			return nil
+		}
+		// 删除当前输入的最后一个字符
+		if len(app.Ctx.CurrentInput) > 0 {
+			app.Ctx.CurrentInput = app.Ctx.CurrentInput[:len(app.Ctx.CurrentInput)-1]
+			session.ResetHistoryBrowse(app.Ctx)
+			// 标记需要重绘
+			app.Ctx.CommandDirty = true
+		}
+	}
+	
+	return nil
+}
+
+// 清空当前输入
+func (app *AppContext) clearCurrentInput(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx != nil {
+		app.Ctx.CurrentInput = ""
+		session.ResetHistoryBrowse(app.Ctx)
+		// 标记需要重绘
+		app.Ctx.CommandDirty = true
+	}
+	return nil
+}
+
+// ESC键退出全屏处理函数
+func (app *AppContext) EscapeExitFullscreenHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	// 命令面板自行处理ESC
+	if v != nil && v.Name() == "palette" {
+		return nil
+	}
+	
+	// 退出命令历史反向搜索
+	if app.Ctx.HistorySearch {
+		session.EndHistorySearch(app.Ctx, false)
+		return nil
+	}
+	
+	// 添加调试信息到命令历史
+	currentView := "none"
+	if v != nil {
+		currentView = v.Name()
+	}
+	
+	// 首先检查当前视图是否是弹出窗口
+	if v != nil && strings.HasPrefix(v.Name(), "popup_") {
+		// 如果当前聚焦的是弹出窗口，直接关闭它
+		popupID := strings.TrimPrefix(v.Name(), "popup_")
+		if err := layout.ClosePopupWindowWithView(g, app.Ctx, popupID); err != nil {
+					debugMsg := fmt.Sprintf("[ERROR] Failed to close current popup window with ESC: %s, error: %v", popupID, err)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+	} else {
+		debugMsg := fmt.Sprintf("[DEBUG] Successfully closed current popup window with ESC: %s", popupID)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+		}
+		app.Ctx.CommandDirty = true
+		return nil
+	}
+	
+	// 其次检查是否有弹出窗口需要关闭（处理其他情况）
+	if len(app.Ctx.PopupWindows) > 0 {
+		// 关闭最顶层的弹出窗口
+		lastPopup := app.Ctx.PopupWindows[len(app.Ctx.PopupWindows)-1]
+		if err := layout.ClosePopupWindowWithView(g, app.Ctx, lastPopup.ID); err != nil {
+			// 如果关闭失败，记录错误信息
+			debugMsg := fmt.Sprintf("[ERROR] Failed to close popup window with ESC: %s, error: %v", lastPopup.ID, err)
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+		} else {
+			// 调试信息
+			debugMsg := fmt.Sprintf("[DEBUG] Successfully closed popup window with ESC: %s", lastPopup.ID)
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+		}
+		app.Ctx.CommandDirty = true
+		
+		return nil
+	}
+	
+	// 只有在全屏状态下才处理ESC键退出全屏
+	if app.Ctx.IsFullscreen {
+		// 调试信息
+		debugMsg := fmt.Sprintf("[DEBUG] ESC key exit fullscreen: current view=%s, fullscreen view=%s", currentView, app.Ctx.FullscreenView)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+		app.Ctx.CommandDirty = true
+		
+		// 退出全屏：恢复之前的布局
+		if app.Ctx.SavedLayout != nil {
+			app.Ctx.Layout = app.Ctx.SavedLayout
+			app.Ctx.SavedLayout = nil
+		}
+		app.Ctx.IsFullscreen = false
+		
+		// 保存当前全屏的窗口名称，用于重新聚焦
+		previousView := app.Ctx.FullscreenView
+		app.Ctx.FullscreenView = ""
+		
+		// 重新聚焦到之前的窗口
+		if previousView != "" {
+			g.SetCurrentView(previousView)
+		}
+		
+		return nil
+	}
+	
+	// 如果不在全屏状态，ESC键保持原有功能（如清空命令输入）
+	// 检查当前是否在命令窗口
+	if v != nil && v.Name() == "command" {
+		// 调试信息
+		debugMsg := fmt.Sprintf("[DEBUG] ESC key clear command input: current input=%s", app.Ctx.CurrentInput)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+		app.Ctx.CommandDirty = true
+		
+		return app.clearCurrentInput(g, v)
+	}
+	
+	// 其他情况的调试信息
+	debugMsg := fmt.Sprintf("[DEBUG] ESC key no action: view=%s, fullscreen status=%v", currentView, app.Ctx.IsFullscreen)
+	app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+	app.Ctx.CommandDirty = true
+	
+	return nil
+}
+
+// ========== 搜索事件处理函数 ==========
+
+// Ctrl+F启动搜索模式
+func (app *AppContext) startSearchHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	// 只在代码视图中启动搜索
+	if v != nil && v.Name() == "code" {
+		if app.Ctx.Project == nil || app.Ctx.Project.CurrentFile == "" {
+					// 在命令历史中显示提示
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[INFO] Please open a file first to search")
+		app.Ctx.CommandDirty = true
+		return nil
+		}
+		
+		session.StartSearchMode(app.Ctx)
+		
+		// 在命令历史中显示搜索提示
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[SEARCH] Search mode activated, type keywords and press Enter to search, ESC to exit",
+			fmt.Sprintf("[SEARCH] Mode: %s (Alt+R regex, Alt+C case, Alt+W whole word)", session.SearchModeLabel(app.Ctx.SearchOptions)))
+		app.Ctx.CommandDirty = true
+	}
+	
+	return nil
+}
+
+// 搜索模式下的字符输入处理
+func (app *AppContext) handleSearchCharInput(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil || !app.Ctx.SearchMode {
+			return nil
+		}
+		
+		// 只在代码视图聚焦时处理搜索输入
+		if v != nil && v.Name() == "code" {
+			app.Ctx.SearchInput += string(ch)
+		}
+		
+		return nil
+	}
+}
+
+// 搜索模式下的退格键处理
+func (app *AppContext) HandleSearchBackspace(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || !app.Ctx.SearchMode {
+		return nil
+	}
+	
+	// 只在代码视图聚焦时处理搜索输入
+	if v != nil && v.Name() == "code" {
+		if len(app.Ctx.SearchInput) > 0 {
+			app.Ctx.SearchInput = app.Ctx.SearchInput[:len(app.Ctx.SearchInput)-1]
+		}
+	}
+	
+	return nil
+}
+
+// 搜索模式下的回车键处理
+func (app *AppContext) HandleSearchEnter(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || !app.Ctx.SearchMode {
+		return nil
+	}
+	
+	// 只在代码视图聚焦时处理
+	if v != nil && v.Name() == "code" {
+		if app.Ctx.SearchInput != "" {
+			// 如果是新的搜索词，执行搜索
+			if app.Ctx.SearchTerm != app.Ctx.SearchInput {
+				app.Ctx.SearchTerm = app.Ctx.SearchInput
+				session.PerformSearch(app.Ctx)
+				
+				// 显示搜索结果统计
+				if app.Ctx.SearchError != "" {
+					app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, 
+						fmt.Sprintf("[SEARCH] Error: %s", app.Ctx.SearchError))
+				} else if len(app.Ctx.SearchResults) > 0 {
+					app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, 
+						fmt.Sprintf("[SEARCH] Found %d matches", len(app.Ctx.SearchResults)))
+				} else {
+					app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, 
+						fmt.Sprintf("[SEARCH] No matches found: \"%s\"", app.Ctx.SearchTerm))
+				}
+				app.Ctx.CommandDirty = true
+			} else {
+				// 跳转到下一个匹配项
+				session.JumpToNextMatch(app.Ctx)
+			}
+		}
+	}
+	
+	return nil
+}
+
+// 搜索模式下的ESC键处理
+func (app *AppContext) HandleSearchEscape(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	if app.Ctx.SearchMode {
+		// 退出搜索模式
+		session.ExitSearchMode(app.Ctx)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[SEARCH] Search mode exited")
+		app.Ctx.CommandDirty = true
+		return nil
+	}
+	
+	// 如果不在搜索模式，调用原有的ESC处理
+	return app.EscapeExitFullscreenHandler(g, v)
+}
+
+// 切换搜索模式（正则/大小写/全词），已有搜索词时立即重新搜索
+func (app *AppContext) toggleSearchOptionHandler(option string) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil {
+			return nil
+		}
+		opts := &app.Ctx.SearchOptions
+		switch option {
+		case "regex":
+			opts.Regex = !opts.Regex
+		case "case":
+			opts.CaseSensitive = !opts.CaseSensitive
+		case "word":
+			opts.WholeWord = !opts.WholeWord
+		}
+		msg := "[SEARCH] Mode: " + session.SearchModeLabel(*opts)
+		if app.Ctx.SearchMode && app.Ctx.SearchTerm != "" {
+			session.PerformSearch(app.Ctx)
+			if app.Ctx.SearchError != "" {
+				msg += " - " + app.Ctx.SearchError
+			} else {
+				msg += fmt.Sprintf(" - %d matches", len(app.Ctx.SearchResults))
+			}
+		}
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, msg)
+		app.Ctx.CommandDirty = true
+		return nil
+	}
+}
+
+// Shift+F3跳转到上一个匹配项
+func (app *AppContext) jumpToPrevMatchHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || !app.Ctx.SearchMode {
+		return nil
+	}
+	
+	session.JumpToPrevMatch(app.Ctx)
+	return nil
+}
+
+// F3跳转到下一个匹配项
+func (app *AppContext) jumpToNextMatchHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || !app.Ctx.SearchMode {
+		return nil
+	}
+	
+	session.JumpToNextMatch(app.Ctx)
+	return nil
+}
+
+// 鼠标按下开始选择
+func (app *AppContext) mouseSelectStartHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	// 获取全局context
+	ctx := app.Ctx
+	
+	// 鼠标在视图缓冲区中的位置作为选择起点
+	x, y := mouseBufferPosition(g, v)
+	ctx.MouseSelecting = true
+	ctx.SelectStartX = x
+	ctx.SelectStartY = y
+	ctx.SelectEndX = ctx.SelectStartX
+	ctx.SelectEndY = ctx.SelectStartY
+	ctx.SelectionView = v.Name()
+	
+	return nil
+}
+
+// 鼠标拖拽选择
+func (app *AppContext) mouseDragHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	ctx := app.Ctx
+	if !ctx.MouseSelecting || ctx.SelectionView != v.Name() {
+		return nil
+	}
+	
+	// 鼠标在视图缓冲区中的位置作为选择终点
+	ctx.SelectEndX, ctx.SelectEndY = mouseBufferPosition(g, v)
+	
+	return nil
+}
+
+// 鼠标在视图缓冲区中的列和行：屏幕坐标减去视图左上角（含边框），加上视图原点
+func mouseBufferPosition(g *gocui.Gui, v *gocui.View) (int, int) {
+	mx, my := g.MousePosition()
+	ox, oy := v.Origin()
+	x0, y0, _, _, err := g.ViewPosition(v.Name())
+	if err != nil {
+		return ox, oy
+	}
+	return ox + mx - x0 - 1, oy + my - y0 - 1
+}
+
+// 鼠标释放完成选择
+func (app *AppContext) mouseSelectEndHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	ctx := app.Ctx
+	if !ctx.MouseSelecting || ctx.SelectionView != v.Name() {
+		return nil
+	}
+	
+	// 获取选中的文本
+	selectedText := getSelectedText(g, v, ctx)
+	if selectedText != "" {
+		ctx.SelectionText = selectedText
+		ctx.SelectionMode = true
+		
+		// 自动复制到剪贴板
+		if err := copyToClipboard(selectedText); err != nil {
+			// 在命令窗口显示错误
+			if cmdView, err := g.View("command"); err == nil {
+				fmt.Fprintf(cmdView, "\nCopy failed: %v", err)
+			}
+		} else {
+			// 在命令窗口显示成功信息
+			if cmdView, err := g.View("command"); err == nil {
+				fmt.Fprintf(cmdView, "\nCopied selected text: %.30s...", selectedText)
+			}
+		}
+	}
+	
+	ctx.MouseSelecting = false
+	return nil
+}
+
+// 获取选中的文本
+func getSelectedText(g *gocui.Gui, v *gocui.View, ctx *session.DebuggerContext) string {
+	if ctx.SelectStartY == ctx.SelectEndY {
+		// 同一行选择
+		return getTextFromLine(v, ctx.SelectStartY, ctx.SelectStartX, ctx.SelectEndX)
+	} else {
+		// 多行选择
+		var result strings.Builder
+		startY := ctx.SelectStartY
+		endY := ctx.SelectEndY
+		if startY > endY {
+			startY, endY = endY, startY
+		}
+		
+		for line := startY; line <= endY; line++ {
+			if line == startY {
+				// 第一行：从开始位置到行尾
+				result.WriteString(getTextFromLine(v, line, ctx.SelectStartX, -1))
+			} else if line == endY {
+				// 最后一行：从行首到结束位置
+				result.WriteString(getTextFromLine(v, line, 0, ctx.SelectEndX))
+			} else {
+				// 中间行：整行
+				result.WriteString(getTextFromLine(v, line, 0, -1))
+			}
+			if line < endY {
+				result.WriteString("\n")
+			}
+		}
+		return result.String()
+	}
+}
+
+// 从视图的指定行获取文本
+func getTextFromLine(v *gocui.View, lineNum, startX, endX int) string {
+	lines := strings.Split(v.ViewBuffer(), "\n")
+	if lineNum < 0 || lineNum >= len(lines) {
+		return ""
+	}
+	
+	// 按屏幕列取字符（宽字符占两列）
+	line := lines[lineNum]
+	if startX < 0 {
+		startX = 0
+	}
+	if endX >= 0 && startX > endX {
+		startX, endX = endX, startX
+	}
+	
+	return project.SliceColumns(line, startX, endX)
+}
+
+// ========== 拖拽事件处理 ==========
+
+// 鼠标按下处理 - 检测是否开始拖拽
+func (app *AppContext) MouseDownHandler(g *gocui.Gui, v *gocui.View) error {
+	// 首先聚焦到被点击的窗口
+	if v != nil {
+		g.SetCurrentView(v.Name())
+	}
+	
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	maxX, maxY := g.Size()
+	
+	if v != nil {
+		mouseX, mouseY := g.MousePosition()
+		
+		// 首先检查是否点击了弹出窗口
+		popup := layout.PopupWindowAt(app.Ctx, mouseX, mouseY)
+		if popup != nil {
+			// 检查是否点击了标题栏（用于拖拽）
+			if layout.IsInPopupTitleBar(popup, mouseX, mouseY) {
+				// 开始拖拽弹出窗口
+				popup.Dragging = true
+				popup.DragStartX = mouseX - popup.X
+				popup.DragStartY = mouseY - popup.Y
+				app.Ctx.DraggingPopup = popup
+				
+				// 将此窗口移到最前面
+				for i, p := range app.Ctx.PopupWindows {
+					if p.ID == popup.ID {
+						// 移除当前位置的窗口
+						app.Ctx.PopupWindows = append(app.Ctx.PopupWindows[:i], app.Ctx.PopupWindows[i+1:]...)
+						// 添加到末尾（最前面）
+						app.Ctx.PopupWindows = append(app.Ctx.PopupWindows, popup)
+						break
+					}
+				}
+				return nil
+			}
+			// 如果点击了弹出窗口但不是标题栏，不做处理，让弹出窗口获得焦点
+			return nil
+		} else if len(app.Ctx.PopupWindows) > 0 {
+			// 如果有弹出窗口但没有点击到任何一个，说明点击了窗口外部区域
+			// 关闭最顶层的弹出窗口
+			if len(app.Ctx.PopupWindows) > 0 {
+				lastPopup := app.Ctx.PopupWindows[len(app.Ctx.PopupWindows)-1]
+				if err := layout.ClosePopupWindowWithView(g, app.Ctx, lastPopup.ID); err == nil {
+							debugMsg := fmt.Sprintf("[DEBUG] Click outside area to close popup window: %s", lastPopup.ID)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+					app.Ctx.CommandDirty = true
+				}
+				return nil
+			}
+		}
+		
+		// 如果没有点击弹出窗口，检查是否在可拖拽边界上（布局调整）
+		if app.Ctx.Layout != nil {
+			boundary := layout.DetectResizeBoundary(mouseX, mouseY, app.Ctx.Layout, maxX, maxY)
+			if boundary != "" {
+				layout.StartDrag(boundary, mouseX, mouseY, app.Ctx.Layout)
+				return nil
+			}
+		}
+	}
+	
+	return nil
+}
+
+// 处理命令窗口鼠标点击
+func (app *AppContext) HandleCommandClick(g *gocui.Gui, v *gocui.View) error {
+	// 聚焦到命令窗口
+	g.SetCurrentView("command")
+	
+	// 标记命令窗口需要重绘（获得焦点时）
+	if app.Ctx != nil {
+		app.Ctx.CommandDirty = true
+	}
+	
+	return nil
+}
+
+// 鼠标拖拽处理
+func (app *AppContext) mouseDragResizeHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	maxX, maxY := g.Size()
+	
+	// 指针在边框上时v为nil，坐标仍然有效
+	mouseX, mouseY := g.MousePosition()
+	
+	// 首先检查是否在拖拽弹出窗口
+	if app.Ctx.DraggingPopup != nil && app.Ctx.DraggingPopup.Dragging {
+		// 计算新位置
+		newX := mouseX - app.Ctx.DraggingPopup.DragStartX
+		newY := mouseY - app.Ctx.DraggingPopup.DragStartY
+		
+		// 边界检查
+		if newX < 0 {
+			newX = 0
+		}
+		if newY < 0 {
+			newY = 0
+		}
+		if newX + app.Ctx.DraggingPopup.Width > maxX {
+			newX = maxX - app.Ctx.DraggingPopup.Width
+		}
+		if newY + app.Ctx.DraggingPopup.Height > maxY {
+			newY = maxY - app.Ctx.DraggingPopup.Height
+		}
+		
+		// 更新窗口位置
+		app.Ctx.DraggingPopup.X = newX
+		app.Ctx.DraggingPopup.Y = newY
+		
+		return nil
+	}
+	
+	// 如果没有在拖拽弹出窗口，检查布局拖拽
+	if app.Ctx.Layout != nil && app.Ctx.Layout.IsDragging {
+		// 处理拖拽移动
+		layout.HandleDragMove(mouseX, mouseY, app.Ctx.Layout, maxX, maxY)
+	}
+	
+	return nil
+}
+
+// 鼠标释放处理 - 结束拖拽
+func (app *AppContext) mouseUpHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx != nil {
+		app.EndMouseDrag()
+	}
+	return nil
+}
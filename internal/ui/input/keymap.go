@@ -0,0 +1,230 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/layout"
+)
+
+// ========== 可配置的按键绑定 ==========
+// 全局导航、全屏、搜索、布局调整等动作集中登记在 keyBindings 中，启动时读取
+// ~/.config/kdebug-tui/keys.toml（或 keys.json）覆盖默认按键，再统一注册到gocui。
+// 配置格式（动作名 = 按键，多个按键用数组，"none" 取消绑定）：
+//   next-view = "ctrl+n"
+//   scroll-up = ["up", "ctrl+u"]
+//   generate = "f12"
+// 单字符快捷键（` w x + -）、Enter/退格和鼠标绑定由各自的模块注册，不在这里配置。
+
+// 一个可配置的按键动作
+type keyBinding struct {
+	Action      string // 配置文件中的动作名
+	Description string
+	View        string   // 只在该窗口生效（为空表示全局）
+	Keys        []string // 默认按键
+	Handler     func(g *gocui.Gui, v *gocui.View) error
+}
+
+// 所有可配置的动作及默认按键
+func (app *AppContext) keyBindings() []keyBinding {
+	return []keyBinding{
+		{"quit", "Quit", "", []string{"ctrl+c"}, layout.Quit},
+		{"next-view", "Switch to the next window (completion in the command window)", "", []string{"tab"}, app.tabHandler},
+		{"view-files", "File browser", "", []string{"f1"}, switchToFileBrowser},
+		{"view-registers", "Registers", "", []string{"f2"}, switchToRegisters},
+		{"view-variables", "Variables", "", []string{"f3"}, switchToVariables},
+		{"view-stack", "Call stack", "", []string{"f4"}, switchToStack},
+		{"view-code", "Code view", "", []string{"f5"}, switchToCode},
+		{"view-command", "Command window", "", []string{"f6"}, app.switchToCommand},
+		{"fullscreen", "Toggle fullscreen", "", []string{"f11"}, app.ToggleFullscreenHandler},
+		{"escape", "Exit fullscreen / clear input", "", []string{"esc"}, app.EscapeExitFullscreenHandler},
+		{"search", "Search in code", "code", []string{"ctrl+f"}, app.startSearchHandler},
+		{"search-next", "Next search result", "code", []string{"f3"}, app.jumpToNextMatchHandler},
+		{"search-regex", "Toggle regex search", "code", []string{"alt+r"}, app.toggleSearchOptionHandler("regex")},
+		{"search-case", "Toggle case-sensitive search", "code", []string{"alt+c"}, app.toggleSearchOptionHandler("case")},
+		{"search-word", "Toggle whole-word search", "code", []string{"alt+w"}, app.toggleSearchOptionHandler("word")},
+		{"scroll-up", "Scroll up", "", []string{"up", "pgup"}, app.scrollUpHandler},
+		{"scroll-down", "Scroll down", "", []string{"down", "pgdn"}, app.scrollDownHandler},
+		{"history-prev", "Previous command", "command", []string{"up"}, app.historyPrevHandler},
+		{"history-next", "Next command", "command", []string{"down"}, app.historyNextHandler},
+		{"reset-layout", "Reset layout (history search in the command window)", "", []string{"ctrl+r"}, app.ctrlRHandler},
+		{"grow-command", "Grow command window", "", []string{"ctrl+j"}, app.AdjustCommandHeightHandler},
+		{"shrink-command", "Shrink command window", "", []string{"ctrl+k"}, app.ShrinkCommandHeightHandler},
+		{"grow-left", "Grow left panel", "", []string{"ctrl+l"}, app.AdjustLeftPanelHandler},
+		{"shrink-left", "Shrink left panel", "", []string{"ctrl+h"}, app.ShrinkLeftPanelHandler},
+		{"buffers", "List open files (switch with Enter/1-9)", "", []string{"ctrl+b"}, app.buffersHandler},
+		{"outline", "Functions of the current file (jump with Enter/1-9)", "", []string{"ctrl+o"}, app.outlineHandler},
+		{"replay-prev", "Previous recorded frame (previous event on the timeline when not replaying)", "", []string{"f9"}, app.replayStepHandler(-1)},
+		{"replay-next", "Next recorded frame (next event on the timeline when not replaying)", "", []string{"f10"}, app.replayStepHandler(1)},
+		{"generate", "Generate BPF code (unbound by default)", "", nil, app.commandKeyHandler("generate")},
+		{"gdb-step", "Step into, gdb backend (unbound by default)", "", nil, app.commandKeyHandler("step")},
+		{"gdb-next", "Step over, gdb backend (unbound by default)", "", nil, app.commandKeyHandler("next")},
+		{"gdb-continue", "Continue, gdb backend (unbound by default)", "", nil, app.commandKeyHandler("continue")},
+	}
+}
+
+// 按键执行一条命令（与命令面板执行命令相同）
+func (app *AppContext) commandKeyHandler(command string) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil {
+			return nil
+		}
+		app.Ctx.CurrentInput = command
+		return app.HandleCommand(g, nil)
+	}
+}
+
+// 特殊键名
+var namedKeys = map[string]gocui.Key{
+	"f1": gocui.KeyF1, "f2": gocui.KeyF2, "f3": gocui.KeyF3, "f4": gocui.KeyF4,
+	"f5": gocui.KeyF5, "f6": gocui.KeyF6, "f7": gocui.KeyF7, "f8": gocui.KeyF8,
+	"f9": gocui.KeyF9, "f10": gocui.KeyF10, "f11": gocui.KeyF11, "f12": gocui.KeyF12,
+	"tab": gocui.KeyTab, "enter": gocui.KeyEnter, "esc": gocui.KeyEsc, "space": gocui.KeySpace,
+	"insert": gocui.KeyInsert, "delete": gocui.KeyDelete, "home": gocui.KeyHome, "end": gocui.KeyEnd,
+	"pgup": gocui.KeyPgup, "pgdn": gocui.KeyPgdn, "up": gocui.KeyArrowUp, "down": gocui.KeyArrowDown,
+	"left": gocui.KeyArrowLeft, "right": gocui.KeyArrowRight,
+}
+
+// 解析按键名：ctrl+<a-z>、alt+<字符>、f1-f12、tab/esc/pgup等
+// 单个可打印字符不允许（会在命令输入时触发，应使用Ctrl+X引导键的面板快捷键）
+func parseKeyName(name string) (interface{}, gocui.Modifier, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if k, ok := namedKeys[key]; ok {
+		return k, gocui.ModNone, nil
+	}
+	if strings.HasPrefix(key, "ctrl+") {
+		c := strings.TrimPrefix(key, "ctrl+")
+		if len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+			// tcell中Ctrl+A..Ctrl+Z依次为0x01..0x1A
+			return gocui.Key(c[0]-'a') + gocui.KeyCtrlA, gocui.ModNone, nil
+		}
+		return nil, 0, fmt.Errorf("不支持的按键: %s（Ctrl只能与a-z组合）", name)
+	}
+	if strings.HasPrefix(key, "alt+") {
+		c := []rune(strings.TrimSpace(name)[len("alt+"):])
+		if len(c) == 1 {
+			return c[0], gocui.ModAlt, nil
+		}
+		return nil, 0, fmt.Errorf("不支持的按键: %s（Alt只能与单个字符组合）", name)
+	}
+	if len([]rune(key)) == 1 {
+		return nil, 0, fmt.Errorf("单个字符 %q 会在输入命令时触发，请使用 ctrl+/alt+/功能键", name)
+	}
+	return nil, 0, fmt.Errorf("未知的按键: %s", name)
+}
+
+// 生效的按键（配置覆盖默认值），用于注册、命令面板和 keys 命令
+func (app *AppContext) effectiveKeys() map[string][]string {
+	if app.keys != nil {
+		return app.keys
+	}
+	keys := make(map[string][]string)
+	for _, b := range app.keyBindings() {
+		keys[b.Action] = b.Keys
+	}
+	return keys
+}
+
+// 动作当前绑定的按键（显示用，未绑定时为空）
+func (app *AppContext) keyLabel(action string) string {
+	keys := app.effectiveKeys()[action]
+	labels := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts := strings.Split(key, "+")
+		ctrl := strings.EqualFold(parts[0], "ctrl")
+		for i, part := range parts {
+			if len(part) > 1 || i > 0 && ctrl {
+				parts[i] = strings.ToUpper(part[:1]) + part[1:]
+			}
+		}
+		labels = append(labels, strings.Join(parts, "+"))
+	}
+	return strings.Join(labels, "/")
+}
+
+// 读取配置并注册所有可配置的按键，返回需要提示用户的警告
+func (app *AppContext) BindConfigurableKeys(g *gocui.Gui) ([]string, error) {
+	var warnings []string
+	keys := make(map[string][]string)
+	for _, b := range app.keyBindings() {
+		keys[b.Action] = b.Keys
+	}
+	if path := session.KeyConfigPath(); path != "" {
+		config, err := session.LoadKeyConfig(path)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Warning: %s: %v (using default keys)", path, err))
+		}
+		actions := make([]string, 0, len(config))
+		for action := range config {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			if _, ok := keys[action]; !ok {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s: unknown action %q (see 'keys')", path, action))
+				continue
+			}
+			keys[action] = config[action]
+		}
+	}
+
+	// 同一窗口中一个按键只绑定一个动作（gocui会执行所有匹配的绑定）
+	type slot struct {
+		view string
+		key  interface{}
+		mod  gocui.Modifier
+	}
+	used := make(map[slot]string)
+	for _, b := range app.keyBindings() {
+		bound := make([]string, 0, len(keys[b.Action]))
+		for _, name := range keys[b.Action] {
+			key, mod, err := parseKeyName(name)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s: %v", b.Action, err))
+				continue
+			}
+			s := slot{b.View, key, mod}
+			if other, ok := used[s]; ok {
+				warnings = append(warnings, fmt.Sprintf("Warning: %s: %s is already bound to %s", b.Action, name, other))
+				continue
+			}
+			used[s] = b.Action
+			if err := g.SetKeybinding(b.View, key, mod, b.Handler); err != nil {
+				return warnings, fmt.Errorf("无法绑定 %s 到 %s: %v", name, b.Action, err)
+			}
+			bound = append(bound, name)
+		}
+		keys[b.Action] = bound
+	}
+	app.keys = keys
+	return warnings, nil
+}
+
+// keys 命令：列出可配置的动作和当前按键
+func (app *AppContext) keyBindingLines() []string {
+	lines := []string{"Key bindings (action = key):"}
+	for _, b := range app.keyBindings() {
+		label := app.keyLabel(b.Action)
+		if label == "" {
+			label = "(unbound)"
+		}
+		scope := ""
+		if b.View != "" {
+			scope = " [" + b.View + "]"
+		}
+		lines = append(lines, fmt.Sprintf("  %-15s %-14s %s%s", b.Action, label, b.Description, scope))
+	}
+	dir, _ := os.UserConfigDir()
+	if path := session.KeyConfigPath(); path != "" {
+		lines = append(lines, "", "Config: "+path)
+	} else {
+		lines = append(lines, "", fmt.Sprintf("Config: none (create %s)", filepath.Join(dir, session.KeyConfigDir, "keys.toml")))
+	}
+	lines = append(lines, `  Format: next-view = "ctrl+n" | scroll-up = ["up", "ctrl+u"] | generate = "f9" | "none" unbinds`)
+	return lines
+}
@@ -0,0 +1,172 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"debug-gocui/internal/gocui"
+)
+
+// ========== 作用域按键绑定 ==========
+// gocui会执行所有匹配的绑定（全局+视图），全局单字符快捷键因此会在输入命令时被误触发。
+// 这里为每个(视图, 字符)只注册一个分发函数，由分发函数根据焦点决定是输入字符还是执行快捷键。
+
+// 引导键：在任何窗口（包括命令输入）中按下后，下一个字符作为全局快捷键执行
+const leaderKey = gocui.KeyCtrlX
+
+// 引导键等待超时
+const leaderTimeout = 2 * time.Second
+
+// 单字符快捷键
+type keyAction struct {
+	Key         rune
+	Description string
+	Handler     func(g *gocui.Gui, v *gocui.View) error
+	View        string // 只在该窗口生效（为空表示所有面板）
+}
+
+// 仅在非编辑窗口中生效的单字符快捷键（也可通过引导键在任意窗口触发）
+// 生成BPF、清除断点等会丢失工作的动作不绑定单字符，通过命令或 Ctrl+P 命令面板执行
+func (app *AppContext) panelKeyActions() []keyAction {
+	return []keyAction{
+		{'`', "切换到上一个窗口", prevViewHandler, ""},
+		{'x', "切换数值显示格式", app.cycleValueFormatHandler, "variables"},
+		{'w', "工作集（最近接触的文件和函数）", app.workingSetHandler, ""},
+		{'+', "内存窗口每行多显示4字节", app.memoryWidthHandler(4), "memory"},
+		{'-', "内存窗口每行少显示4字节", app.memoryWidthHandler(-4), "memory"},
+		{'+', "时间线放大", app.timelineZoomHandler(0.5), "timeline"},
+		{'-', "时间线缩小", app.timelineZoomHandler(2), "timeline"},
+		{'[', "时间线选中上一个事件", app.timelineStepHandler(-1), "timeline"},
+		{']', "时间线选中下一个事件", app.timelineStepHandler(1), "timeline"},
+		{'0', "时间线显示全部事件", app.timelineFitHandler, "timeline"},
+		{'l', "时间线回到实时数据", app.timelineLiveHandler, "timeline"},
+		{'g', "符号导航前缀（gd 转到定义，gr 查找引用）", app.gotoPrefixHandler, "code"},
+	}
+}
+
+// 可接收单字符快捷键的面板（非编辑窗口）
+var shortcutPanels = []string{"filebrowser", "code", "registers", "variables", "stack", "memory", "timeline"}
+
+// 命令窗口可输入的字符
+const commandInputChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789" +
+	"./-_:=~+()[]{}@#$%^&*,;<>?|\\`'\"! "
+
+// 代码视图搜索模式可输入的字符（与命令窗口相同，正则表达式需要各种符号）
+const searchInputChars = commandInputChars
+
+// 查找在指定窗口中生效的单字符快捷键
+func (app *AppContext) findKeyAction(ch rune, v *gocui.View) *keyAction {
+	for _, action := range app.panelKeyActions() {
+		if action.Key == ch && (action.View == "" || (v != nil && v.Name() == action.View)) {
+			a := action
+			return &a
+		}
+	}
+	return nil
+}
+
+// 判断视图当前是否处于文本输入状态
+func (app *AppContext) isTextInput(v *gocui.View) bool {
+	if v == nil {
+		return false
+	}
+	if v.Editable {
+		return true
+	}
+	return v.Name() == "code" && app.Ctx.SearchMode
+}
+
+// 引导键处理：进入等待状态
+func (app *AppContext) leaderKeyHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	app.Ctx.LeaderPending = true
+	app.Ctx.LeaderTime = time.Now()
+
+	hints := make([]string, 0)
+	for _, action := range app.panelKeyActions() {
+		hints = append(hints, fmt.Sprintf("%c=%s", action.Key, action.Description))
+	}
+	app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[KEY] Ctrl+X: %s", strings.Join(hints, ", ")))
+	app.Ctx.CommandDirty = true
+	return nil
+}
+
+// 字符分发：引导键 > 文本输入 > 前缀键 > 面板快捷键
+func (app *AppContext) dispatchRune(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil {
+			return nil
+		}
+
+		// 引导键之后的字符作为全局快捷键执行
+		if app.Ctx.LeaderPending {
+			app.Ctx.LeaderPending = false
+			if time.Since(app.Ctx.LeaderTime) <= leaderTimeout {
+				if action := app.findKeyAction(ch, v); action != nil {
+					return action.Handler(g, v)
+				}
+				app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[KEY] Ctrl+X %c: 未绑定的快捷键", ch))
+				app.Ctx.CommandDirty = true
+				return nil
+			}
+		}
+
+		if app.isTextInput(v) {
+			if v.Name() == "command" {
+				return app.handleCharInput(ch)(g, v)
+			}
+			return app.handleSearchCharInput(ch)(g, v)
+		}
+
+		// 前缀键之后的第二个键（gd/gr）
+		if pending := app.Ctx.PendingKey; pending != 0 {
+			app.Ctx.PendingKey = 0
+			if pending == 'g' && (ch == 'd' || ch == 'r') {
+				return app.gotoSymbolHandler(ch)(g, v)
+			}
+		}
+
+		if action := app.findKeyAction(ch, v); action != nil {
+			return action.Handler(g, v)
+		}
+		return nil
+	}
+}
+
+// 注册作用域按键：每个(视图, 字符)只绑定一个分发函数
+func (app *AppContext) BindScopedKeys(g *gocui.Gui) error {
+	if err := g.SetKeybinding("", leaderKey, gocui.ModNone, app.leaderKeyHandler); err != nil {
+		return err
+	}
+
+	viewChars := map[string]string{"command": commandInputChars}
+	for _, name := range shortcutPanels {
+		viewChars[name] = ""
+	}
+	viewChars["code"] = searchInputChars
+	for name := range viewChars {
+		if name == "command" {
+			continue
+		}
+		for _, action := range app.panelKeyActions() {
+			if action.View != "" && action.View != name {
+				continue
+			}
+			if !strings.ContainsRune(viewChars[name], action.Key) {
+				viewChars[name] += string(action.Key)
+			}
+		}
+	}
+
+	for name, chars := range viewChars {
+		for _, ch := range chars {
+			if err := g.SetKeybinding(name, ch, gocui.ModNone, app.dispatchRune(ch)); err != nil {
+				return fmt.Errorf("无法绑定字符 %c 到 %s: %v", ch, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 内存窗口 ==========
+
+// 内存窗口快捷键：调整每行字节数
+func (app *AppContext) memoryWidthHandler(delta int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx.Memory != nil {
+			session.SetMemoryWidth(app.Ctx, app.Ctx.Memory.Width+delta)
+		}
+		return nil
+	}
+}
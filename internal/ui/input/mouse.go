@@ -0,0 +1,14 @@
+package input
+
+import "debug-gocui/internal/gocui"
+
+// ========== 鼠标跟踪 ==========
+// 边界和弹出窗口的抓手以及鼠标的屏幕坐标见 ui/layout/mouse.go。
+
+// 注册拖动和松开（所有视图）
+func (app *AppContext) BindMouseTracking(g *gocui.Gui) error {
+	if err := g.SetKeybinding("", gocui.MouseLeft, gocui.ModMotion, app.mouseDragResizeHandler); err != nil {
+		return err
+	}
+	return g.SetKeybinding("", gocui.MouseRelease, gocui.ModNone, app.mouseUpHandler)
+}
@@ -0,0 +1,21 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 函数大纲 ==========
+
+// Ctrl+O：当前文件的函数大纲
+func (app *AppContext) outlineHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil || app.Ctx.Project.CurrentFile == "" || app.Ctx.Disasm != nil {
+		return nil
+	}
+	if _, err := session.ShowOutlinePopup(views.GuiOf(g), app.Ctx); err != nil {
+		session.ReportError(app.Ctx, err)
+		app.Ctx.CommandDirty = true
+	}
+	return nil
+}
@@ -0,0 +1,454 @@
+package input
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/ui/layout"
+)
+
+// ========== 命令面板 ==========
+// 注意：终端无法区分Ctrl+Shift+P与Ctrl+P（两者发送相同的控制字符），因此绑定为Ctrl+P
+
+const paletteKey = gocui.KeyCtrlP
+
+// 命令面板条目
+type paletteEntry struct {
+	Name        string // 显示名称（命令或动作）
+	Key         string // 绑定的按键，命令为空
+	Description string
+	Command     string // 非空时执行该命令
+	NeedsArgs   bool   // 需要参数的命令：填入命令窗口而不是直接执行
+	Handler     func(g *gocui.Gui, v *gocui.View) error
+}
+
+// 命令面板中的所有动作（命令 + 按键绑定的动作）
+func (app *AppContext) paletteEntries() []paletteEntry {
+	entries := []paletteEntry{
+		{Name: "open", Description: "Open project directory", Command: "open ", NeedsArgs: true},
+		{Name: "close", Description: "Close current project", Command: "close"},
+		{Name: "pwd", Description: "Show current directory", Command: "pwd"},
+		{Name: "status", Description: "Show debugger status", Command: "status"},
+		{Name: "env", Description: "Show environment (kernel, arch, KASLR offset)", Command: "env"},
+		{Name: "stats", Description: "Session statistics dashboard", Command: "stats"},
+		{Name: "stats bp", Description: "Per-breakpoint hits, rate, intervals and top processes", Command: "stats bp"},
+		{Name: "span", Description: "Measure latency between an entry and exit probe (funclatency)", Command: "span ", NeedsArgs: true},
+		{Name: "span hist", Description: "Latency histogram of the measured spans", Command: "span hist"},
+		{Name: "locks", Description: "Lock hold times, contention and sleep-in-atomic per call site", Command: "locks"},
+		{Name: "locks on", Description: "Generate mutex/spinlock probes for the module's lock calls", Command: "locks on"},
+		{Name: "events", Description: "Live Events window (1-9 filters by breakpoint)", Command: "events"},
+		{Name: "events start", Description: "Stream trace_pipe hits into the Events window", Command: "events start"},
+		{Name: "bpftrace run", Description: "Generate and run a bpftrace script, hits into the Events window", Command: "bpftrace run"},
+		{Name: "stap run", Description: "Compile and run the SystemTap script, hits into the Events window", Command: "stap run"},
+		{Name: "dmesg", Description: "Kernel log panel with breakpoint hits inline", Command: "dmesg"},
+		{Name: "dmesg start", Description: "Tail /dev/kmsg into the event timeline", Command: "dmesg start"},
+		{Name: "hwbp", Description: "Hardware breakpoint on an address or kernel variable (perf)", Command: "hwbp ", NeedsArgs: true},
+		{Name: "timeline", Description: "Toggle the event timeline (hits, variable changes, dmesg)", Command: "timeline"},
+		{Name: "timeline goto", Description: "Jump all panels to the moment of an event", Command: "timeline goto ", NeedsArgs: true},
+		{Name: "record start", Description: "Record breakpoint hits as frames to a .frames file", Command: "record start"},
+		{Name: "record stop", Description: "Stop recording and write the remaining frames", Command: "record stop"},
+		{Name: "replay", Description: "Step through a recorded .frames file (F9/F10)", Command: "replay ", NeedsArgs: true},
+		{Name: "export", Description: "Export events or a recorded .frames file as Chrome trace JSON (Perfetto)", Command: "export ", NeedsArgs: true},
+		{Name: "import-trace", Description: "Replay a trace_pipe / trace-cmd text dump captured on a board", Command: "import-trace ", NeedsArgs: true},
+		{Name: "diff-frames", Description: "Compare variables, registers and stack of two recorded frames", Command: "diff-frames ", NeedsArgs: true},
+		{Name: "timeline live", Description: "Back to live registers, variables and stack", Command: "timeline live"},
+		{Name: "dmesg around", Description: "Kernel log around the last hits of a breakpoint", Command: "dmesg around ", NeedsArgs: true},
+		{Name: "backend detect", Description: "Detect available tools/kernel features and pick a backend", Command: "backend detect"},
+		{Name: "backend ftrace", Description: "Trace breakpointed functions with ftrace function_graph", Command: "backend ftrace"},
+		{Name: "backend bpftrace", Description: "Trace breakpoints with a generated bpftrace script", Command: "backend bpftrace"},
+		{Name: "backend perf", Description: "Trace breakpoints with perf probe and perf record", Command: "backend perf"},
+		{Name: "backend kprobe", Description: "Trace breakpoints through kprobe_events without loading BPF", Command: "backend kprobe"},
+		{Name: "backend bpf", Description: "Trace breakpoints with generated BPF programs", Command: "backend bpf"},
+		{Name: "backend gdb", Description: "Single-step through QEMU's gdbstub or kgdboc", Command: "backend gdb"},
+		{Name: "backend kdb", Description: "Single-step through kdb on a kgdboc serial port", Command: "backend kdb ", NeedsArgs: true},
+		{Name: "break", Description: "Insert a target breakpoint at file:line, symbol or address", Command: "break ", NeedsArgs: true},
+		{Name: "continue", Description: "Run the gdb/kdb target until a breakpoint", Command: "continue"},
+		{Name: "interrupt", Description: "Stop the running gdb/kdb target", Command: "interrupt"},
+		{Name: "step", Description: "Step to the next source line, into calls", Command: "step"},
+		{Name: "next", Description: "Step to the next source line, over calls", Command: "next"},
+		{Name: "stepi", Description: "Step one instruction", Command: "stepi"},
+		{Name: "filter pid", Description: "Only fire generated BPF probes in this process", Command: "filter pid ", NeedsArgs: true},
+		{Name: "filter comm", Description: "Only fire generated BPF probes for this command name", Command: "filter comm ", NeedsArgs: true},
+		{Name: "filter cpu", Description: "Only fire generated BPF probes on this CPU", Command: "filter cpu ", NeedsArgs: true},
+		{Name: "filter clear", Description: "Remove the pid/comm/cpu probe filters", Command: "filter clear"},
+		{Name: "toolchain", Description: "Show the BPF compile toolchain of the target arch", Command: "toolchain"},
+		{Name: "toolchain check", Description: "Validate clang, sysroot and kernel headers for the target arch", Command: "toolchain check"},
+		{Name: "toolchain dry-run", Description: "Print the full clang command 'compile' would run", Command: "toolchain dry-run"},
+		{Name: "events filter", Description: "Only show hits of the given breakpoints", Command: "events filter ", NeedsArgs: true},
+		{Name: "selftest", Description: "End-to-end check with the sample module", Command: "selftest"},
+		{Name: "safe off", Description: "Leave safe mode and enable backends", Command: "safe off"},
+		{Name: "why", Description: "Troubleshooting for the last error code", Command: "why"},
+		{Name: "diagnose", Description: "Check why probes cannot attach", Command: "diagnose"},
+		{Name: "modinfo", Description: "Module vermagic/srcversion vs. running kernel", Command: "modinfo"},
+		{Name: "perf", Description: "The debugger's own CPU, memory and latency", Command: "perf"},
+		{Name: "history", Description: "Recent commands (Ctrl+R in the command window searches)", Command: "history"},
+		{Name: "history save", Description: "Save command history to a file", Command: "history save ", NeedsArgs: true},
+		{Name: "remote sync", Description: "Copy the BPF program and load scripts to the remote target", Command: "remote sync"},
+		{Name: "remote build target", Description: "Compile BPF programs with clang on the remote target", Command: "remote build target"},
+		{Name: "watchdog", Description: "Remote target liveness panel", Command: "watchdog"},
+		{Name: "watchdog on", Description: "Start checking the remote target for hangs/reboots", Command: "watchdog on"},
+		{Name: "workspace", Description: "List capture workspaces", Command: "workspace"},
+		{Name: "workspace new", Description: "Open a new independent capture workspace", Command: "workspace new"},
+		{Name: "debuginfo", Description: "Locate DWARF for a module", Command: "debuginfo ", NeedsArgs: true},
+		{Name: "ops list", Description: "Show operation journal", Command: "ops list"},
+		{Name: "ops replay", Description: "Reset project state and replay the journal (asks first)", Command: "ops replay"},
+		{Name: "ops clear", Description: "Clear the operation journal", Command: "ops clear"},
+		{Name: "bp", Description: "View all breakpoints", Command: "bp"},
+		{Name: "bp clear", Description: "Clear all breakpoints", Command: "bp clear"},
+		{Name: "bp toggle", Description: "Toggle breakpoint at <file>:<line>", Command: "bp toggle ", NeedsArgs: true},
+		{Name: "bp note", Description: "Annotate breakpoint <n> with a note", Command: "bp note ", NeedsArgs: true},
+		{Name: "bp retval", Description: "Report the return value of breakpoint <n>'s function", Command: "bp retval ", NeedsArgs: true},
+		{Name: "bp cond", Description: "Only fire breakpoint <n> when a condition holds", Command: "bp cond ", NeedsArgs: true},
+		{Name: "bp uprobe", Description: "Breakpoint on a function of a user-space helper program", Command: "bp uprobe ", NeedsArgs: true},
+		{Name: "bp resolve", Description: "Map breakpoints to function+offset via the DWARF line table", Command: "bp resolve"},
+		{Name: "bp check", Description: "Check breakpoint functions against kallsyms and the kprobe blacklist", Command: "bp check"},
+		{Name: "bp export", Description: "Save breakpoints to a .json or text file", Command: "bp export ", NeedsArgs: true},
+		{Name: "bp import", Description: "Load breakpoints from a .json or text file (merge or overwrite)", Command: "bp import ", NeedsArgs: true},
+		{Name: "bp repair", Description: "List breakpoints whose source files are missing, with candidates", Command: "bp repair"},
+		{Name: "watch", Description: "List watch expressions", Command: "watch"},
+		{Name: "mem read", Description: "Hex/ASCII dump of kernel memory", Command: "mem read ", NeedsArgs: true},
+		{Name: "watch <expr>", Description: "Add watch expression", Command: "watch ", NeedsArgs: true},
+		{Name: "unwatch", Description: "Remove watch expression", Command: "unwatch ", NeedsArgs: true},
+		{Name: "goto", Description: "Go to a line (1234) or position (50%) in the current file", Command: ":", NeedsArgs: true},
+		{Name: "frame", Description: "Jump to stack frame source", Command: "frame ", NeedsArgs: true},
+		{Name: "ws", Description: "Working set: recently touched files and functions", Command: "ws"},
+		{Name: "callgraph", Description: "Static call tree of the function at the cursor", Command: "callgraph"},
+		{Name: "symbols", Description: "Browse module symbols, Enter sets a breakpoint", Command: "symbols"},
+		{Name: "grep", Description: "Search all project sources for a term", Command: "grep ", NeedsArgs: true},
+		{Name: "replace", Description: "Replace a pattern across project sources with confirmation", Command: "replace ", NeedsArgs: true},
+		{Name: "def", Description: "Go to the definition of a symbol (gd in the code view)", Command: "def ", NeedsArgs: true},
+		{Name: "refs", Description: "List references to a symbol (gr in the code view)", Command: "refs ", NeedsArgs: true},
+		{Name: "highlight on", Description: "Color C keywords, types, strings, comments and preprocessor lines", Command: "highlight on"},
+		{Name: "highlight off", Description: "Show code without syntax highlighting", Command: "highlight off"},
+		{Name: "theme dark", Description: "Default color scheme for dark terminals", Command: "theme dark"},
+		{Name: "theme light", Description: "Color scheme for light terminal backgrounds", Command: "theme light"},
+		{Name: "theme high-contrast", Description: "Bold colors and reverse-video focus", Command: "theme high-contrast"},
+		{Name: "theme dark256", Description: "256-color scheme (needs a 256-color terminal)", Command: "theme dark256"},
+		{Name: "make build", Description: "Build the module, errors open in Build Output", Command: "make build"},
+		{Name: "make clean", Description: "Run the module's clean target", Command: "make clean"},
+		{Name: "make info", Description: "Show obj-m, objects, ccflags and KDIR from Makefile/Kbuild", Command: "make info"},
+		{Name: "disasm", Description: "Disassemble the current function with source lines", Command: "disasm"},
+		{Name: "src", Description: "Open source referenced by debug info", Command: "src ", NeedsArgs: true},
+		{Name: "srcmap", Description: "List source path substitutions", Command: "srcmap"},
+		{Name: "vars", Description: "Auto-detect variables and generate BPF", Command: "vars"},
+		{Name: "compile", Description: "Compile BPF for the current architecture", Command: "compile"},
+		{Name: "bpf load", Description: "Load the compiled BPF object and attach kprobes", Command: "bpf load"},
+		{Name: "bpf unload", Description: "Detach kprobes and unload BPF programs", Command: "bpf unload"},
+		{Name: "bpf verify", Description: "Run the BPF object through the kernel verifier, errors mapped to source", Command: "bpf verify"},
+		{Name: "generate", Description: "Basic function monitoring only (legacy)", Command: "generate"},
+		{Name: "help", Description: "Show command reference", Command: "help"},
+		{Name: "rpc start", Description: "Listen for JSON-RPC clients (editors) on a Unix socket", Command: "rpc start"},
+		{Name: "rpc stop", Description: "Stop the JSON-RPC control socket", Command: "rpc stop"},
+		{Name: "source", Description: "Run commands from a script file", Command: "source ", NeedsArgs: true},
+		{Name: "tab", Description: "List open files and switch between them", Command: "tab"},
+		{Name: "outline", Description: "Functions of the current file, Enter jumps", Command: "outline"},
+		{Name: "tab close", Description: "Close the current file's tab", Command: "tab close"},
+		{Name: "keys", Description: "List configurable key bindings and the keys.toml path", Command: "keys"},
+		{Name: "clear", Description: "Clear command output", Command: "clear"},
+	}
+
+	actions := []paletteEntry{
+		{Name: "Next window", Key: app.keyLabel("next-view"), Handler: nextViewHandler},
+		{Name: "File browser", Key: app.keyLabel("view-files"), Handler: switchToFileBrowser},
+		{Name: "Registers", Key: app.keyLabel("view-registers"), Handler: switchToRegisters},
+		{Name: "Variables", Key: app.keyLabel("view-variables"), Handler: switchToVariables},
+		{Name: "Call stack", Key: app.keyLabel("view-stack"), Handler: switchToStack},
+		{Name: "Code view", Key: app.keyLabel("view-code"), Handler: switchToCode},
+		{Name: "Command window", Key: app.keyLabel("view-command"), Handler: app.switchToCommand},
+		{Name: "Toggle fullscreen", Key: app.keyLabel("fullscreen"), Handler: app.ToggleFullscreenHandler},
+		{Name: "Reset layout", Key: app.keyLabel("reset-layout"), Handler: app.ResetLayout},
+		{Name: "Workspace 1", Key: "Alt+1", Handler: app.workspaceKeyHandler(1)},
+		{Name: "Workspace 2", Key: "Alt+2", Handler: app.workspaceKeyHandler(2)},
+		{Name: "Grow command window", Key: app.keyLabel("grow-command"), Handler: app.AdjustCommandHeightHandler},
+		{Name: "Shrink command window", Key: app.keyLabel("shrink-command"), Handler: app.ShrinkCommandHeightHandler},
+		{Name: "Grow left panel", Key: app.keyLabel("grow-left"), Handler: app.AdjustLeftPanelHandler},
+		{Name: "Shrink left panel", Key: app.keyLabel("shrink-left"), Handler: app.ShrinkLeftPanelHandler},
+		{Name: "Search in code", Key: app.keyLabel("search"), Handler: func(g *gocui.Gui, v *gocui.View) error {
+			if _, err := g.SetCurrentView("code"); err != nil {
+				return nil
+			}
+			return app.startSearchHandler(g, g.CurrentView())
+		}},
+		{Name: "Quit", Key: app.keyLabel("quit"), Handler: layout.Quit},
+	}
+	for _, action := range app.panelKeyActions() {
+		actions = append(actions, paletteEntry{
+			Name:    action.Description,
+			Key:     fmt.Sprintf("%c / Ctrl+X %c", action.Key, action.Key),
+			Handler: action.Handler,
+		})
+	}
+
+	return append(entries, actions...)
+}
+
+// 模糊匹配：query中的字符按顺序出现在text中即为匹配，
+// 连续匹配和单词开头匹配得分更高
+func fuzzyScore(query, text string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 3 // 连续匹配
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			score += 2 // 单词开头
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// 越短的条目越优先
+	return score*100 - len(t), true
+}
+
+// 根据当前查询过滤并排序条目
+func (app *AppContext) filteredPaletteEntries() []paletteEntry {
+	type scored struct {
+		entry paletteEntry
+		score int
+	}
+	matches := make([]scored, 0)
+	for _, entry := range app.paletteEntries() {
+		if score, ok := fuzzyScore(app.Ctx.PaletteQuery, entry.Name+" "+entry.Description); ok {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]paletteEntry, len(matches))
+	for i, m := range matches {
+		result[i] = m.entry
+	}
+	return result
+}
+
+// 打开/关闭命令面板
+func (app *AppContext) togglePaletteHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	if app.Ctx.PaletteOpen {
+		return app.closePalette(g)
+	}
+	app.Ctx.PaletteOpen = true
+	app.Ctx.PaletteQuery = ""
+	app.Ctx.PaletteSelected = 0
+	app.Ctx.PalettePrevView = ""
+	if v != nil {
+		app.Ctx.PalettePrevView = v.Name()
+	}
+	return nil
+}
+
+// 关闭命令面板并恢复之前的焦点
+func (app *AppContext) closePalette(g *gocui.Gui) error {
+	app.Ctx.PaletteOpen = false
+	if err := g.DeleteView("palette"); err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	if app.Ctx.PalettePrevView != "" {
+		if _, err := g.SetCurrentView(app.Ctx.PalettePrevView); err == nil {
+			return nil
+		}
+	}
+	_, err := g.SetCurrentView("code")
+	if err == gocui.ErrUnknownView {
+		return nil
+	}
+	return err
+}
+
+// 执行选中的条目
+func (app *AppContext) executePaletteEntry(g *gocui.Gui, v *gocui.View) error {
+	entries := app.filteredPaletteEntries()
+	if len(entries) == 0 {
+		return nil
+	}
+	if app.Ctx.PaletteSelected >= len(entries) {
+		app.Ctx.PaletteSelected = len(entries) - 1
+	}
+	entry := entries[app.Ctx.PaletteSelected]
+
+	if err := app.closePalette(g); err != nil {
+		return err
+	}
+
+	if entry.Handler != nil {
+		return entry.Handler(g, g.CurrentView())
+	}
+
+	// 需要参数的命令：填入命令窗口等待用户补全
+	if entry.NeedsArgs {
+		app.Ctx.CurrentInput = entry.Command
+		app.Ctx.CommandDirty = true
+		if _, err := g.SetCurrentView("command"); err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+		return nil
+	}
+
+	app.Ctx.CurrentInput = entry.Command
+	return app.HandleCommand(g, nil)
+}
+
+// 命令面板字符输入
+func (app *AppContext) paletteCharInput(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil || !app.Ctx.PaletteOpen {
+			return nil
+		}
+		app.Ctx.PaletteQuery += string(ch)
+		app.Ctx.PaletteSelected = 0
+		return nil
+	}
+}
+
+// 命令面板退格
+func (app *AppContext) paletteBackspace(g *gocui.Gui, v *gocui.View) error {
+	if q := app.Ctx.PaletteQuery; len(q) > 0 {
+		r := []rune(q)
+		app.Ctx.PaletteQuery = string(r[:len(r)-1])
+		app.Ctx.PaletteSelected = 0
+	}
+	return nil
+}
+
+// 命令面板选择移动
+func (app *AppContext) paletteMove(delta int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		count := len(app.filteredPaletteEntries())
+		if count == 0 {
+			app.Ctx.PaletteSelected = 0
+			return nil
+		}
+		app.Ctx.PaletteSelected = (app.Ctx.PaletteSelected + delta + count) % count
+		return nil
+	}
+}
+
+// 渲染命令面板（注册在布局之后的管理器，保证位于所有窗口之上）
+func (app *AppContext) RenderPalette(g *gocui.Gui) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	if !app.Ctx.PaletteOpen {
+		// 切换工作区时只清除了打开标记
+		if err := g.DeleteView("palette"); err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+		return nil
+	}
+	maxX, maxY := g.Size()
+	width := 80
+	if width > maxX-4 {
+		width = maxX - 4
+	}
+	height := 20
+	if height > maxY-4 {
+		height = maxY - 4
+	}
+	x0 := (maxX - width) / 2
+	y0 := maxY / 6
+
+	v, err := g.SetView("palette", x0, y0, x0+width, y0+height)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = " Command Palette (Enter: run, Esc: close) "
+		v.Frame = true
+	}
+	if _, err := g.SetViewOnTop("palette"); err != nil {
+		return err
+	}
+	// 面板是模态的：打开期间始终保持焦点
+	if cur := g.CurrentView(); cur == nil || cur.Name() != "palette" {
+		if _, err := g.SetCurrentView("palette"); err != nil {
+			return err
+		}
+	}
+
+	entries := app.filteredPaletteEntries()
+	if app.Ctx.PaletteSelected >= len(entries) {
+		app.Ctx.PaletteSelected = 0
+	}
+
+	v.Clear()
+	fmt.Fprintf(v, "\x1b[33m> %s\x1b[0m_\n", app.Ctx.PaletteQuery)
+	fmt.Fprintln(v, strings.Repeat("─", width-1))
+
+	visible := height - 3
+	start := 0
+	if app.Ctx.PaletteSelected >= visible {
+		start = app.Ctx.PaletteSelected - visible + 1
+	}
+	for i := start; i < len(entries) && i < start+visible; i++ {
+		entry := entries[i]
+		label := entry.Name
+		if entry.Description != "" {
+			label = fmt.Sprintf("%-14s %s", entry.Name, entry.Description)
+		}
+		key := entry.Key
+		if entry.Command != "" && key == "" {
+			key = ":" + strings.TrimSpace(entry.Command)
+		}
+		line := fmt.Sprintf(" %s %s", project.FitWidth(label, width-20), project.TruncateWidth(key, 16))
+		if i == app.Ctx.PaletteSelected {
+			fmt.Fprintf(v, "\x1b[30;42m%s\x1b[0m\n", line)
+		} else {
+			fmt.Fprintln(v, line)
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(v, "\x1b[90m  (no matching actions)\x1b[0m")
+	}
+	return nil
+}
+
+// 注册命令面板按键
+func (app *AppContext) BindPaletteKeys(g *gocui.Gui) error {
+	if err := g.SetKeybinding("", paletteKey, gocui.ModNone, app.togglePaletteHandler); err != nil {
+		return err
+	}
+	for _, ch := range commandInputChars {
+		if err := g.SetKeybinding("palette", ch, gocui.ModNone, app.paletteCharInput(ch)); err != nil {
+			return err
+		}
+	}
+	bindings := []struct {
+		key     gocui.Key
+		handler func(g *gocui.Gui, v *gocui.View) error
+	}{
+		{gocui.KeyBackspace, app.paletteBackspace},
+		{gocui.KeyBackspace2, app.paletteBackspace},
+		{gocui.KeyArrowUp, app.paletteMove(-1)},
+		{gocui.KeyArrowDown, app.paletteMove(1)},
+		{gocui.KeyCtrlN, app.paletteMove(1)},
+		{gocui.KeyEnter, app.executePaletteEntry},
+		{gocui.KeyEsc, func(g *gocui.Gui, v *gocui.View) error { return app.closePalette(g) }},
+	}
+	for _, b := range bindings {
+		if err := g.SetKeybinding("palette", b.key, gocui.ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package input
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 录制与回放 ==========
+
+// ========== 逐帧按键 ==========
+
+// F9/F10：回放时移动到上一帧/下一帧，否则在时间线上选中上一个/下一个实时事件
+func (app *AppContext) replayStepHandler(dir int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil {
+			return nil
+		}
+		if app.Ctx.Replay == nil {
+			app.reportTimelineSelection(session.StepTimelineEvent(views.GuiOf(g), app.Ctx, dir))
+			return nil
+		}
+		frame, err := session.JumpToReplayFrame(views.GuiOf(g), app.Ctx, app.Ctx.Replay.Current+dir)
+		if frame == nil {
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[REPLAY] %v", err))
+		} else {
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[REPLAY] "+session.ReplayFrameSummary(app.Ctx.Replay, frame))
+			if err != nil {
+				app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[REPLAY] %v", err))
+			}
+		}
+		app.Ctx.CommandDirty = true
+		return nil
+	}
+}
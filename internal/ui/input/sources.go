@@ -0,0 +1,33 @@
+package input
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 源码定位与按需获取 ==========
+
+// 调用栈窗口中按Enter跳转到光标所在帧
+func (app *AppContext) StackFrameEnterHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil || v == nil {
+		return nil
+	}
+	_, cy := v.Cursor()
+	// 第0行是标题；示例数据前还有一行SIMULATED提示
+	n := app.Ctx.StackScroll + cy - 1
+	_, replaying := session.ReplayStackFrames(app.Ctx)
+	if _, pinned := session.TimelineStackFrames(app.Ctx); len(app.Ctx.StackFrames) == 0 && !pinned && !replaying {
+		n--
+	}
+	frame, where, err := session.JumpToFrame(views.GuiOf(g), app.Ctx, n)
+	if err != nil {
+		session.ReportError(app.Ctx, err)
+	} else {
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[FRAME] #%d %s() -> %s:%d", n, frame.Function, where, frame.Line))
+	}
+	app.Ctx.CommandDirty = true
+	return nil
+}
@@ -0,0 +1,18 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 多文件标签 ==========
+
+// Ctrl+B：打开文件列表
+func (app *AppContext) buffersHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil || len(app.Ctx.Project.Tabs) == 0 {
+		return nil
+	}
+	session.SaveFileViewState(app.Ctx)
+	session.ShowBuffersPopup(app.Ctx)
+	return nil
+}
@@ -0,0 +1,107 @@
+package input
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 时间线窗口按键和鼠标 ==========
+
+// 报告选中的事件
+func (app *AppContext) reportTimelineSelection(event *session.DebugEvent, err error) {
+	if err != nil {
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[TIMELINE] %v", err))
+	} else if event != nil {
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[TIMELINE] #%d %s", event.Seq, session.StripANSI(session.FormatEvent(app.Ctx, *event))))
+	}
+	app.Ctx.CommandDirty = true
+}
+
+// 单击：选中附近的事件
+func (app *AppContext) timelineClickHandler(g *gocui.Gui, v *gocui.View) error {
+	g.SetCurrentView("timeline")
+	if app.Ctx == nil || v == nil {
+		return nil
+	}
+	cx, cy := v.Cursor()
+	width, _ := v.Size()
+	if seq := session.TimelineEventAt(app.Ctx, width, cx, cy); seq != 0 {
+		app.reportTimelineSelection(session.SelectTimelineEvent(views.GuiOf(g), app.Ctx, seq))
+	}
+	return nil
+}
+
+// 鼠标滚轮：以指针所在的列为中心缩放
+func (app *AppContext) timelineWheelHandler(factor float64) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx == nil || v == nil {
+			return nil
+		}
+		cx, _ := v.Cursor()
+		session.ZoomTimeline(app.Ctx, factor, cx-session.TimelineLabelWidth)
+		return nil
+	}
+}
+
+// 键盘缩放（以窗口中间为中心）
+func (app *AppContext) timelineZoomHandler(factor float64) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		session.ZoomTimeline(app.Ctx, factor, -1)
+		return nil
+	}
+}
+
+// 键盘平移
+func (app *AppContext) timelinePanHandler(fraction float64) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		session.PanTimeline(app.Ctx, fraction)
+		return nil
+	}
+}
+
+// 上一个/下一个事件
+func (app *AppContext) timelineStepHandler(dir int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx.Timeline != nil {
+			app.reportTimelineSelection(session.StepTimelineEvent(views.GuiOf(g), app.Ctx, dir))
+		}
+		return nil
+	}
+}
+
+// 显示全部事件
+func (app *AppContext) timelineFitHandler(g *gocui.Gui, v *gocui.View) error {
+	if tl := app.Ctx.Timeline; tl != nil {
+		tl.Span, tl.Start, tl.Follow = 0, 0, true
+	}
+	return nil
+}
+
+// 回到实时数据
+func (app *AppContext) timelineLiveHandler(g *gocui.Gui, v *gocui.View) error {
+	session.TimelineLive(app.Ctx)
+	return nil
+}
+
+// 注册时间线窗口的方向键和鼠标（单字符快捷键在 panelKeyActions 中）
+func (app *AppContext) BindTimelineKeys(g *gocui.Gui) error {
+	bindings := []struct {
+		key     interface{}
+		handler func(g *gocui.Gui, v *gocui.View) error
+	}{
+		{gocui.KeyArrowLeft, app.timelinePanHandler(-0.25)},
+		{gocui.KeyArrowRight, app.timelinePanHandler(0.25)},
+		{gocui.MouseLeft, app.timelineClickHandler},
+		{gocui.MouseWheelUp, app.timelineWheelHandler(0.5)},
+		{gocui.MouseWheelDown, app.timelineWheelHandler(2)},
+	}
+	for _, b := range bindings {
+		if err := g.SetKeybinding("timeline", b.key, gocui.ModNone, b.handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
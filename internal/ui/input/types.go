@@ -0,0 +1,20 @@
+package input
+
+import (
+	"debug-gocui/internal/commands"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/layout"
+)
+
+// AppContext 输入层的应用上下文：按键、鼠标、命令面板和补全的回调
+type AppContext struct {
+	*layout.AppContext
+	keys map[string][]string // 生效的可配置按键（动作名 → 按键，见 keymap.go）
+}
+
+// 创建应用上下文：命令、布局和输入回调共享同一个工作区状态
+func NewAppContext(ctx *session.DebuggerContext) *AppContext {
+	app := &AppContext{AppContext: &layout.AppContext{AppContext: &commands.AppContext{Ctx: ctx}}}
+	app.KeyBindingLines = app.keyBindingLines
+	return app
+}
@@ -0,0 +1,32 @@
+package input
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 数值显示格式 ==========
+
+// 变量窗口中按x键切换光标所在变量的显示格式
+func (app *AppContext) cycleValueFormatHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil || v == nil {
+		return nil
+	}
+	_, cy := v.Cursor()
+	_, oy := v.Origin()
+	idx := oy + cy
+	if idx < 0 || idx >= len(app.Ctx.VariablesLineNames) || app.Ctx.VariablesLineNames[idx] == "" {
+		return nil
+	}
+	name := app.Ctx.VariablesLineNames[idx]
+	vf, err := session.CycleValueFormat(app.Ctx, name)
+	if err != nil {
+		session.ReportError(app.Ctx, err)
+	} else {
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[FMT] %s: %s", name, vf.Format))
+	}
+	app.Ctx.CommandDirty = true
+	return nil
+}
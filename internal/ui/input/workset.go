@@ -0,0 +1,17 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 工作集 ==========
+
+// 按w键打开工作集窗口
+func (app *AppContext) workingSetHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Project == nil {
+		return nil
+	}
+	session.ShowWorkingSetPopup(app.Ctx)
+	return nil
+}
@@ -0,0 +1,64 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 多工作区 ==========
+// 工作区切换键和状态栏中的工作区标签；创建、切换和关闭工作区见 commands/workspace.go。
+
+// 在状态栏标题中显示工作区标签，后台工作区显示未查看的新事件数
+func (app *AppContext) UpdateWorkspaceTitle(g *gocui.Gui) {
+	v, err := g.View("status")
+	if err != nil || len(app.Workspaces) == 0 {
+		return
+	}
+	if len(app.Workspaces) == 1 {
+		if strings.HasPrefix(v.Title, "Status  ") {
+			v.Title = "Status"
+		}
+		return
+	}
+	tabs := make([]string, 0, len(app.Workspaces))
+	for i, ws := range app.Workspaces {
+		tab := fmt.Sprintf("%d:%s", i+1, session.WorkspaceLabel(ws))
+		if i == app.Workspace {
+			tab = "[" + tab + "]"
+		} else if unseen := ws.Ctx.EventSeq - ws.SeenEvents; unseen > 0 {
+			tab += fmt.Sprintf(" +%d", unseen)
+		}
+		tabs = append(tabs, tab)
+	}
+	v.Title = "Status  " + strings.Join(tabs, "  ") + "  (Alt+N switches) "
+}
+
+// Alt+数字切换工作区
+func (app *AppContext) workspaceKeyHandler(n int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if n > len(app.WorkspaceList()) {
+			app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, fmt.Sprintf("[KEY] Alt+%d: workspace %d does not exist, use 'workspace new' to create it", n, n))
+			app.Ctx.CommandDirty = true
+			return nil
+		}
+		if err := app.SwitchWorkspace(views.GuiOf(g), n); err != nil {
+			return err
+		}
+		views.UpdateAllViews(g, app.Ctx)
+		return nil
+	}
+}
+
+// 注册工作区切换键
+func (app *AppContext) BindWorkspaceKeys(g *gocui.Gui) error {
+	for n := 1; n <= session.MaxWorkspaces; n++ {
+		if err := g.SetKeybinding("", rune('0'+n), gocui.ModAlt, app.workspaceKeyHandler(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
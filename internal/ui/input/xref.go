@@ -0,0 +1,47 @@
+package input
+
+import (
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 符号导航（转到定义 / 查找引用） ==========
+
+// 代码窗口中的 g 前缀：下一个键 d 转到定义，r 查找引用
+func (app *AppContext) gotoPrefixHandler(g *gocui.Gui, v *gocui.View) error {
+	app.Ctx.PendingKey = 'g'
+	app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, "[KEY] g: d=go to definition, r=references")
+	app.Ctx.CommandDirty = true
+	return nil
+}
+
+// gd / gr：对光标处的标识符执行 def / refs
+func (app *AppContext) gotoSymbolHandler(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		ctx := app.Ctx
+		if ctx.Project == nil || ctx.Project.CurrentFile == "" || ctx.Disasm != nil {
+			return nil
+		}
+		name := session.IdentifierAtCodeCursor(views.GuiOf(g))
+		if name == "" {
+			ctx.CommandHistory = append(ctx.CommandHistory, "[XREF] Click an identifier first, then press gd/gr")
+			ctx.CommandDirty = true
+			return nil
+		}
+		var msg string
+		var err error
+		if ch == 'd' {
+			msg, err = session.GotoDefinition(views.GuiOf(g), ctx, name)
+		} else {
+			msg, err = session.ShowReferences(ctx, name)
+		}
+		if err != nil {
+			session.ReportError(ctx, err)
+			return nil
+		}
+		ctx.CommandHistory = append(ctx.CommandHistory, msg)
+		ctx.CommandDirty = true
+		return nil
+	}
+}
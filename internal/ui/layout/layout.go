@@ -0,0 +1,716 @@
+package layout
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 动态布局系统 ==========
+
+// 全屏布局
+func layoutFullscreen(g *gocui.Gui, viewName string, maxX, maxY int) error {
+	// 状态栏始终显示
+	if v, err := g.SetView("status", 0, 0, maxX-1, 2); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Status"
+	}
+	
+	// 全屏窗口占据状态栏下方的所有空间
+	if v, err := g.SetView(viewName, 0, 3, maxX-1, maxY-1); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+		
+		// 根据窗口类型设置标题和属性
+		switch viewName {
+		case "filebrowser":
+			v.Title = "File Browser [Fullscreen] - F11/ESC to Exit"
+		case "code":
+			v.Title = "Code View [Fullscreen] - F11/ESC to Exit"
+		case "registers":
+			v.Title = "Registers [Fullscreen] - F11/ESC to Exit"
+		case "variables":
+			v.Title = "Variables [Fullscreen] - F11/ESC to Exit"
+		case "stack":
+			v.Title = "Call Stack [Fullscreen] - F11/ESC to Exit"
+		case "memory":
+			v.Title = "Memory [Fullscreen] - F11/ESC to Exit"
+		case "timeline":
+			v.Title = "Timeline [Fullscreen] - F11/ESC to Exit"
+		case "command":
+			v.Title = "Command [Fullscreen] - F11/ESC to Exit"
+			v.Editable = true
+			v.Wrap = false
+		default:
+			v.Title = fmt.Sprintf("%s [Fullscreen] - F11/ESC to Exit", viewName)
+		}
+	}
+	
+	// 隐藏其他所有窗口（通过将它们设置为不可见的大小）
+	allViews := []string{"filebrowser", "code", "registers", "variables", "stack", "memory", "timeline", "command"}
+	for _, name := range allViews {
+		if name == "memory" || name == "timeline" {
+			if _, err := g.View(name); err != nil {
+				continue
+			}
+		}
+		if name != viewName {
+			// 将其他窗口设置为不可见（位置在屏幕外）
+			if _, err := g.SetView(name, maxX, maxY, maxX, maxY); err != nil && err != gocui.ErrUnknownView {
+				return err
+			}
+		}
+	}
+	
+	return nil
+}
+
+// 初始化动态布局
+func initDynamicLayout(maxX, maxY int) *session.DynamicLayout {
+	return &session.DynamicLayout{
+		LeftPanelWidth:   35,                    // 左侧文件浏览器宽度
+		RightPanelWidth:  35,                    // 右侧面板宽度
+		CommandHeight:    5,                     // 命令窗口高度
+		RightPanelSplit1: maxY / 3,             // 寄存器窗口底部
+		RightPanelSplit2: 2 * maxY / 3,         // 变量窗口底部
+		IsDragging:       false,
+		DragBoundary:      "",
+		DragStartX:        0,
+		DragStartY:        0,
+		DragOriginalValue: 0,
+		ScreenWidth:      maxX,
+		ScreenHeight:     maxY,
+	}
+}
+
+// 按比例缩放一个尺寸值
+func scaleDimension(value, oldSize, newSize int) int {
+	if oldSize <= 0 {
+		return value
+	}
+	return (value*newSize + oldSize/2) / oldSize
+}
+
+// 终端大小变化时按比例重排布局
+func reflowLayout(layout *session.DynamicLayout, maxX, maxY int) {
+	if layout == nil {
+		return
+	}
+	oldX, oldY := layout.ScreenWidth, layout.ScreenHeight
+	layout.ScreenWidth, layout.ScreenHeight = maxX, maxY
+	if oldX <= 0 || oldY <= 0 || (oldX == maxX && oldY == maxY) {
+		return
+	}
+	
+	layout.LeftPanelWidth = scaleDimension(layout.LeftPanelWidth, oldX, maxX)
+	layout.RightPanelWidth = scaleDimension(layout.RightPanelWidth, oldX, maxX)
+	layout.CommandHeight = scaleDimension(layout.CommandHeight, oldY, maxY)
+	layout.RightPanelSplit1 = scaleDimension(layout.RightPanelSplit1, oldY, maxY)
+	layout.RightPanelSplit2 = scaleDimension(layout.RightPanelSplit2, oldY, maxY)
+	
+	// 保持与拖拽时相同的约束
+	if layout.LeftPanelWidth < 20 {
+		layout.LeftPanelWidth = 20
+	}
+	if layout.RightPanelWidth < 25 {
+		layout.RightPanelWidth = 25
+	}
+	if layout.LeftPanelWidth+layout.RightPanelWidth > maxX-20 {
+		// 为代码窗口至少保留20列
+		excess := layout.LeftPanelWidth + layout.RightPanelWidth - (maxX - 20)
+		layout.LeftPanelWidth -= excess / 2
+		layout.RightPanelWidth -= excess - excess/2
+	}
+	if layout.CommandHeight > maxY/2 {
+		layout.CommandHeight = maxY / 2
+	}
+	if layout.CommandHeight < 3 {
+		layout.CommandHeight = 3
+	}
+	safeBottomY := maxY - layout.CommandHeight - 1
+	if safeBottomY < 4 {
+		safeBottomY = 4
+	}
+	clampRightSplits(layout, safeBottomY)
+	
+	// 拖拽中途改变尺寸时放弃本次拖拽
+	layout.IsDragging = false
+	layout.DragBoundary = ""
+}
+
+// 终端大小变化时重新定位弹出窗口
+func reflowPopups(ctx *session.DebuggerContext, oldX, oldY, maxX, maxY int) {
+	if ctx == nil || oldX <= 0 || oldY <= 0 {
+		return
+	}
+	for _, popup := range ctx.PopupWindows {
+		// 弹出窗口不能大于屏幕
+		if popup.Width > maxX {
+			popup.Width = maxX
+		}
+		if popup.Height > maxY {
+			popup.Height = maxY
+		}
+		// 保持窗口中心在屏幕中的相对位置
+		centerX := scaleDimension(popup.X+popup.Width/2, oldX, maxX)
+		centerY := scaleDimension(popup.Y+popup.Height/2, oldY, maxY)
+		popup.X = centerX - popup.Width/2
+		popup.Y = centerY - popup.Height/2
+		clampPopupToScreen(popup, maxX, maxY)
+		popup.Dragging = false
+	}
+	ctx.DraggingPopup = nil
+}
+
+// 把弹出窗口移回屏幕内（左上角不小于0）
+func clampPopupToScreen(popup *session.PopupWindow, maxX, maxY int) {
+	if popup.X+popup.Width > maxX {
+		popup.X = maxX - popup.Width
+	}
+	if popup.Y+popup.Height > maxY {
+		popup.Y = maxY - popup.Height
+	}
+	if popup.X < 0 {
+		popup.X = 0
+	}
+	if popup.Y < 0 {
+		popup.Y = 0
+	}
+}
+
+// 右侧面板分割点限制在合理范围内：寄存器窗口至少从第6行开始，变量和堆栈窗口各至少3行
+func clampRightSplits(layout *session.DynamicLayout, safeBottomY int) {
+	minSplit1 := 6
+	maxSplit1 := safeBottomY - 6
+	if layout.RightPanelSplit1 < minSplit1 {
+		layout.RightPanelSplit1 = minSplit1
+	}
+	if layout.RightPanelSplit1 > maxSplit1 {
+		layout.RightPanelSplit1 = maxSplit1
+	}
+	
+	minSplit2 := layout.RightPanelSplit1 + 3
+	maxSplit2 := safeBottomY - 3
+	if layout.RightPanelSplit2 < minSplit2 {
+		layout.RightPanelSplit2 = minSplit2
+	}
+	if layout.RightPanelSplit2 > maxSplit2 {
+		layout.RightPanelSplit2 = maxSplit2
+	}
+}
+
+// 检测终端大小变化：重排布局、弹出窗口，并保持滚动位置有效
+func handleTerminalResize(ctx *session.DebuggerContext, maxX, maxY int) {
+	if ctx == nil || ctx.Layout == nil {
+		return
+	}
+	oldX, oldY := ctx.Layout.ScreenWidth, ctx.Layout.ScreenHeight
+	if oldX == maxX && oldY == maxY {
+		return
+	}
+	
+	reflowLayout(ctx.Layout, maxX, maxY)
+	reflowLayout(ctx.SavedLayout, maxX, maxY)
+	reflowPopups(ctx, oldX, oldY, maxX, maxY)
+	
+	// 保持代码视图滚动位置在文件范围内
+	if ctx.Project != nil && ctx.Project.CurrentFile != "" && ctx.Disasm == nil {
+		if file, ok := ctx.Project.OpenFiles[ctx.Project.CurrentFile]; ok && ctx.CodeScroll >= file.Len() {
+			ctx.CodeScroll = file.Len() - 1
+			if ctx.CodeScroll < 0 {
+				ctx.CodeScroll = 0
+			}
+		}
+	}
+	
+	// 命令窗口需要重绘以恢复光标位置
+	ctx.CommandDirty = true
+}
+
+// 检测鼠标是否在可拖拽边界上
+func DetectResizeBoundary(x, y int, layout *session.DynamicLayout, maxX, maxY int) string {
+	tolerance := 1 // 边界检测容差
+	
+	// 检测左侧边界 (文件浏览器右边)
+	if x >= layout.LeftPanelWidth-tolerance && x <= layout.LeftPanelWidth+tolerance && 
+	   y >= 3 && y <= maxY-layout.CommandHeight {
+		return "left"
+	}
+	
+	// 检测右侧边界 (右侧面板左边)
+	rightStart := maxX - layout.RightPanelWidth
+	if x >= rightStart-tolerance && x <= rightStart+tolerance && 
+	   y >= 3 && y <= maxY-layout.CommandHeight {
+		return "right"
+	}
+	
+	// 检测底部边界 (命令窗口上边)
+	bottomStart := maxY - layout.CommandHeight
+	if y >= bottomStart-tolerance && y <= bottomStart+tolerance && 
+	   x >= 0 && x <= maxX-1 {
+		return "bottom"
+	}
+	
+	// 检测右侧面板内部分割线1 (寄存器/变量)
+	if x >= rightStart && x <= maxX-1 && 
+	   y >= layout.RightPanelSplit1-tolerance && y <= layout.RightPanelSplit1+tolerance {
+		return "right1"
+	}
+	
+	// 检测右侧面板内部分割线2 (变量/堆栈)
+	if x >= rightStart && x <= maxX-1 && 
+	   y >= layout.RightPanelSplit2-tolerance && y <= layout.RightPanelSplit2+tolerance {
+		return "right2"
+	}
+	
+	return ""
+}
+
+// 开始拖拽
+func StartDrag(boundary string, x, y int, layout *session.DynamicLayout) {
+	layout.IsDragging = true
+	layout.DragBoundary = boundary
+	layout.DragStartX = x
+	layout.DragStartY = y
+	
+	// 保存原始值
+	switch boundary {
+	case "left":
+		layout.DragOriginalValue = layout.LeftPanelWidth
+	case "right":
+		layout.DragOriginalValue = layout.RightPanelWidth
+	case "bottom":
+		layout.DragOriginalValue = layout.CommandHeight
+	case "right1":
+		layout.DragOriginalValue = layout.RightPanelSplit1
+	case "right2":
+		layout.DragOriginalValue = layout.RightPanelSplit2
+	}
+}
+
+// 处理拖拽移动
+func HandleDragMove(x, y int, layout *session.DynamicLayout, maxX, maxY int) {
+	if !layout.IsDragging {
+		return
+	}
+	
+	switch layout.DragBoundary {
+	case "left":
+		// 左侧边界：调整文件浏览器宽度
+		newWidth := layout.DragOriginalValue + (x - layout.DragStartX)
+		if newWidth >= 20 && newWidth <= maxX-60 { // 最小20，为代码和右侧面板留60
+			layout.LeftPanelWidth = newWidth
+		}
+		
+	case "right":
+		// 右侧边界：调整右侧面板宽度
+		deltaX := layout.DragStartX - x // 向左拖拽为正
+		newWidth := layout.DragOriginalValue + deltaX
+		if newWidth >= 25 && newWidth <= maxX-40 { // 最小25，为左侧和代码留40
+			layout.RightPanelWidth = newWidth
+		}
+		
+	case "bottom":
+		// 底部边界：调整命令窗口高度
+		deltaY := layout.DragStartY - y // 向上拖拽为正
+		newHeight := layout.DragOriginalValue + deltaY
+		if newHeight >= 3 && newHeight <= maxY/2 { // 最小3行，最大屏幕一半
+			layout.CommandHeight = newHeight
+		}
+		
+	case "right1":
+		// 右侧面板分割线1：调整寄存器窗口高度
+		newSplit := layout.DragOriginalValue + (y - layout.DragStartY)
+		bottomLimit := maxY - layout.CommandHeight - 6 // 为变量和堆栈窗口留空间
+		if newSplit >= 6 && newSplit <= bottomLimit && newSplit < layout.RightPanelSplit2-3 {
+			layout.RightPanelSplit1 = newSplit
+		}
+		
+	case "right2":
+		// 右侧面板分割线2：调整变量窗口高度
+		newSplit := layout.DragOriginalValue + (y - layout.DragStartY)
+		bottomLimit := maxY - layout.CommandHeight - 3 // 为堆栈窗口留空间
+		if newSplit >= layout.RightPanelSplit1+3 && newSplit <= bottomLimit {
+			layout.RightPanelSplit2 = newSplit
+		}
+	}
+}
+
+// 结束拖拽
+func endDrag(layout *session.DynamicLayout) {
+	layout.IsDragging = false
+	layout.DragBoundary = ""
+}
+
+// 重置布局到默认值
+func (app *AppContext) ResetLayout(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	maxX, maxY := g.Size()
+	app.Ctx.Layout = initDynamicLayout(maxX, maxY)
+	
+	return nil
+}
+
+// F11全屏切换处理函数
+func (app *AppContext) ToggleFullscreenHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil {
+		return nil
+	}
+	
+	if app.Ctx.IsFullscreen {
+		// 退出全屏：恢复之前的布局
+		if app.Ctx.SavedLayout != nil {
+			app.Ctx.Layout = app.Ctx.SavedLayout
+			app.Ctx.SavedLayout = nil
+		}
+		app.Ctx.IsFullscreen = false
+		app.Ctx.FullscreenView = ""
+		
+		// 重新聚焦到之前的窗口
+		if v != nil {
+			g.SetCurrentView(v.Name())
+		}
+		
+	} else {
+		// 进入全屏：保存当前布局
+		currentView := g.CurrentView()
+		if currentView == nil {
+			// 如果没有当前视图，默认全屏代码视图
+			app.Ctx.FullscreenView = "code"
+		} else {
+			viewName := currentView.Name()
+			// 检查是否是有效的可全屏窗口
+			validViews := []string{"filebrowser", "code", "registers", "variables", "stack", "memory", "timeline", "command"}
+			isValid := false
+			for _, name := range validViews {
+				if name == viewName {
+					isValid = true
+					break
+				}
+			}
+			
+			if isValid {
+				app.Ctx.FullscreenView = viewName
+			} else {
+				// 如果当前窗口不支持全屏，默认使用代码视图
+				app.Ctx.FullscreenView = "code"
+			}
+		}
+		
+		// 保存当前布局
+		if app.Ctx.Layout != nil {
+			// 深拷贝当前布局
+			app.Ctx.SavedLayout = &session.DynamicLayout{
+				LeftPanelWidth:    app.Ctx.Layout.LeftPanelWidth,
+				RightPanelWidth:   app.Ctx.Layout.RightPanelWidth,
+				CommandHeight:     app.Ctx.Layout.CommandHeight,
+				RightPanelSplit1:  app.Ctx.Layout.RightPanelSplit1,
+				RightPanelSplit2:  app.Ctx.Layout.RightPanelSplit2,
+				IsDragging:        false, // 重置拖拽状态
+				DragBoundary:      "",
+				DragStartX:        0,
+				DragStartY:        0,
+				DragOriginalValue: 0,
+			}
+		}
+		
+		app.Ctx.IsFullscreen = true
+		
+		// 聚焦到全屏窗口
+		g.SetCurrentView(app.Ctx.FullscreenView)
+	}
+	
+	return nil
+}
+
+// 键盘调整窗口大小
+func (app *AppContext) AdjustLeftPanelHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Layout == nil {
+		return nil
+	}
+	
+	maxX, _ := g.Size()
+	newWidth := app.Ctx.Layout.LeftPanelWidth + 5
+	if newWidth <= maxX-60 {
+		app.Ctx.Layout.LeftPanelWidth = newWidth
+	}
+	
+	return nil
+}
+
+func (app *AppContext) ShrinkLeftPanelHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Layout == nil {
+		return nil
+	}
+	
+	newWidth := app.Ctx.Layout.LeftPanelWidth - 5
+	if newWidth >= 20 {
+		app.Ctx.Layout.LeftPanelWidth = newWidth
+	}
+	
+	return nil
+}
+
+func (app *AppContext) AdjustCommandHeightHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Layout == nil {
+		return nil
+	}
+	
+	_, maxY := g.Size()
+	newHeight := app.Ctx.Layout.CommandHeight + 2
+	
+	// 修复：添加commandStartY的下边界检查
+	// 确保commandStartY >= 4，为状态栏(3行)和其他窗口留出最小空间
+	commandStartY := maxY - newHeight
+	if newHeight <= maxY/2 && commandStartY >= 4 {
+		app.Ctx.Layout.CommandHeight = newHeight
+	}
+	
+	return nil
+}
+
+func (app *AppContext) ShrinkCommandHeightHandler(g *gocui.Gui, v *gocui.View) error {
+	if app.Ctx == nil || app.Ctx.Layout == nil {
+		return nil
+	}
+	
+	newHeight := app.Ctx.Layout.CommandHeight - 2
+	if newHeight >= 3 {
+		app.Ctx.Layout.CommandHeight = newHeight
+	}
+	
+	return nil
+}
+
+func (app *AppContext) Layout(g *gocui.Gui) error {
+	maxX, maxY := g.Size()
+	
+	// 检查最小终端尺寸
+	minWidth, minHeight := 120, 30
+	if maxX < minWidth || maxY < minHeight {
+		// 如果终端太小，显示错误信息
+		if v, err := g.SetView("error", 0, 0, maxX-1, maxY-1); err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Title = "Terminal Too Small"
+		}
+		if v, _ := g.View("error"); v != nil {
+			v.Clear()
+			fmt.Fprintf(v, "\n")
+			fmt.Fprintf(v, "  Terminal window is too small!\n")
+			fmt.Fprintf(v, "\n")
+			fmt.Fprintf(v, "  Current size: %dx%d\n", maxX, maxY)
+			fmt.Fprintf(v, "  Required: %dx%d or larger\n", minWidth, minHeight)
+			fmt.Fprintf(v, "\n")
+			fmt.Fprintf(v, "  Please resize your terminal and try again.\n")
+			fmt.Fprintf(v, "  Press Ctrl+C to exit.\n")
+		}
+		return nil
+	}
+	
+	// 终端恢复到足够大小后移除提示窗口
+	if err := g.DeleteView("error"); err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	
+	// 终端大小变化时按比例重排
+	handleTerminalResize(app.Ctx, maxX, maxY)
+	
+	// 检查是否处于全屏状态
+	if app.Ctx != nil && app.Ctx.IsFullscreen && app.Ctx.FullscreenView != "" {
+		deleteResizeGrips(g)
+		return layoutFullscreen(g, app.Ctx.FullscreenView, maxX, maxY)
+	}
+	
+	// 初始化动态布局（如果不存在）
+	if app.Ctx != nil && app.Ctx.Layout == nil {
+		app.Ctx.Layout = initDynamicLayout(maxX, maxY)
+	}
+	
+	// 获取布局参数
+	var layout *session.DynamicLayout
+	if app.Ctx != nil && app.Ctx.Layout != nil {
+		layout = app.Ctx.Layout
+	} else {
+		// 使用默认布局
+		layout = initDynamicLayout(maxX, maxY)
+	}
+	
+	// 修复：添加全面的边界检查和约束
+	// 确保CommandHeight不会导致其他窗口坐标异常
+	minCommandHeight := 3
+	maxCommandHeight := maxY - 7  // 为状态栏(3行)和其他窗口(至少4行)留空间
+	if maxCommandHeight < minCommandHeight {
+		maxCommandHeight = minCommandHeight
+	}
+	
+	if layout.CommandHeight < minCommandHeight {
+		layout.CommandHeight = minCommandHeight
+	}
+	if layout.CommandHeight > maxCommandHeight {
+		layout.CommandHeight = maxCommandHeight
+	}
+	
+	// 计算安全的窗口底部坐标
+	safeBottomY := maxY - layout.CommandHeight - 1
+	if safeBottomY < 4 {
+		safeBottomY = 4
+		layout.CommandHeight = maxY - safeBottomY - 1
+	}
+	
+	// 命令窗口的位置不受时间线窗口影响
+	commandStartY := safeBottomY + 1
+	if commandStartY >= maxY {
+		commandStartY = maxY - 2
+	}
+	
+	// 时间线窗口 (命令窗口上方，全宽) - 打开时其他窗口的底部上移
+	if app.Ctx != nil && app.Ctx.Timeline != nil && safeBottomY-session.TimelineHeight >= 12 {
+		if v, err := g.SetView("timeline", 0, safeBottomY-session.TimelineHeight+1, maxX-1, safeBottomY); err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Title = "Timeline"
+		}
+		safeBottomY -= session.TimelineHeight
+	} else {
+		if v := g.CurrentView(); v != nil && v.Name() == "timeline" {
+			g.SetCurrentView("code")
+		}
+		if err := g.DeleteView("timeline"); err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	
+	// 状态栏
+	if v, err := g.SetView("status", 0, 0, maxX-1, 2); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Status"
+	}
+	
+	// 文件浏览器窗口 (左侧) - 使用安全的底部坐标
+	if v, err := g.SetView("filebrowser", 0, 3, layout.LeftPanelWidth, safeBottomY); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "File Browser"
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+	}
+	
+	// 代码窗口 (中央) - 使用安全的底部坐标
+	codeStartX := layout.LeftPanelWidth + 1
+	codeEndX := maxX - layout.RightPanelWidth - 1
+	// 确保代码窗口有最小宽度
+	if codeEndX <= codeStartX {
+		codeEndX = codeStartX + 10
+	}
+	// 有内存内容时代码窗口下方的三分之一为内存窗口
+	codeBottomY := safeBottomY
+	if app.Ctx != nil && app.Ctx.Memory != nil {
+		memoryHeight := (safeBottomY - 3) / 3
+		if memoryHeight < 5 {
+			memoryHeight = 5
+		}
+		codeBottomY = safeBottomY - memoryHeight - 1
+		if v, err := g.SetView("memory", codeStartX, codeBottomY+1, codeEndX, safeBottomY); err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Title = "Memory"
+			v.Highlight = true
+			v.SelBgColor = gocui.ColorGreen
+		}
+	} else {
+		if v := g.CurrentView(); v != nil && v.Name() == "memory" {
+			g.SetCurrentView("code")
+		}
+		if err := g.DeleteView("memory"); err != nil && err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	if v, err := g.SetView("code", codeStartX, 3, codeEndX, codeBottomY); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Code View"
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+	}
+	
+	// 右侧面板起始位置
+	rightStartX := maxX - layout.RightPanelWidth
+	
+	// 确保右侧分割点在合理范围内
+	clampRightSplits(layout, safeBottomY)
+	
+	// 寄存器窗口 (右上) - 使用安全的分割点
+	if v, err := g.SetView("registers", rightStartX, 3, maxX-1, layout.RightPanelSplit1); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Registers"
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+	}
+	
+	// 变量窗口 (右中) - 使用安全的分割点
+	if v, err := g.SetView("variables", rightStartX, layout.RightPanelSplit1+1, maxX-1, layout.RightPanelSplit2); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Variables"
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+	}
+	
+	// 调用栈窗口 (右下) - 使用安全的底部坐标
+	if v, err := g.SetView("stack", rightStartX, layout.RightPanelSplit2+1, maxX-1, safeBottomY); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Call Stack"
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+	}
+	
+	// 命令窗口 (底部) - 使用安全的起始坐标
+	if v, err := g.SetView("command", 0, commandStartY, maxX-1, maxY-1); err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Title = "Command"
+		v.Editable = true
+		v.Highlight = true
+		v.SelBgColor = gocui.ColorGreen
+		v.Wrap = false       // 禁用自动换行，防止长文本被截断
+	}
+	
+	// 边界上的透明抓手（拖动调整大小，见 mouse.go）
+	if err := app.layoutResizeGrips(g, layout, maxX, commandStartY, safeBottomY); err != nil {
+		return err
+	}
+	
+	// 渲染弹出窗口 (在最后渲染，确保在顶层显示)
+	if err := app.renderPopupWindows(g); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+func Quit(g *gocui.Gui, v *gocui.View) error {
+	return gocui.ErrQuit
+}
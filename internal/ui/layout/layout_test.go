@@ -0,0 +1,30 @@
+package layout
+
+import (
+	"testing"
+
+	"debug-gocui/internal/session"
+)
+
+func TestReflowClampsAfterShrink(t *testing.T) {
+	layout := &session.DynamicLayout{
+		ScreenWidth: 200, ScreenHeight: 60,
+		LeftPanelWidth: 40, RightPanelWidth: 50, CommandHeight: 20,
+		RightPanelSplit1: 40, RightPanelSplit2: 52,
+	}
+	reflowLayout(layout, 80, 20)
+	safeBottomY := 20 - layout.CommandHeight - 1
+	if layout.CommandHeight > 10 {
+		t.Errorf("command height %d exceeds half the screen", layout.CommandHeight)
+	}
+	if layout.RightPanelSplit2 > safeBottomY-3 || layout.RightPanelSplit2 < layout.RightPanelSplit1+3 {
+		t.Errorf("splits %d/%d outside the right panel (bottom %d)", layout.RightPanelSplit1, layout.RightPanelSplit2, safeBottomY)
+	}
+
+	popup := &session.PopupWindow{X: 150, Y: 45, Width: 70, Height: 15}
+	ctx := &session.DebuggerContext{PopupWindows: []*session.PopupWindow{popup}}
+	reflowPopups(ctx, 200, 60, 60, 12)
+	if popup.X < 0 || popup.Y < 0 || popup.X+popup.Width > 60 || popup.Y+popup.Height > 12 {
+		t.Errorf("popup at %d,%d size %dx%d is off a 60x12 screen", popup.X, popup.Y, popup.Width, popup.Height)
+	}
+}
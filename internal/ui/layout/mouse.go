@@ -0,0 +1,159 @@
+package layout
+
+import (
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 鼠标跟踪 ==========
+// tcell跟踪鼠标，gocui（internal/gocui）把按住移动报告为带ModMotion的按键，松开报告为MouseRelease，
+// 回调中用 g.MousePosition() 取事件的屏幕坐标。事件交给指针下方视图内部的绑定，指针不在任何视图
+// 内部（例如在边框上）时只执行所有视图共用的绑定，视图参数为nil：
+//   - 可拖动的边界（文件浏览器右边、右侧面板左边、命令窗口上边、右侧面板的两条分割线）和弹出窗口的
+//     标题行下面放置抓手视图（无边框、无内容）。边框不属于任何视图的内部，gocui按位置查找视图时
+//     会找到下面的抓手；gocui绘制视图时会清空内部，所以抓手必须在被它覆盖边框的窗口之下：
+//     布局边界的抓手在最底层，弹出窗口的抓手紧挨在弹出窗口之下；
+//   - 拖动和松开绑定在所有视图上，按住后拖过任何位置（包括边框）都会继续移动边界或弹出窗口。
+
+// 抓手视图名前缀：grip_left、grip_right、grip_bottom、grip_right1、grip_right2、grip_popup_<id>
+const (
+	gripPrefix      = "grip_"
+	popupGripPrefix = gripPrefix + "popup_"
+)
+
+// 布局边界的抓手
+var resizeGrips = []string{"left", "right", "bottom", "right1", "right2"}
+
+// 放置（或移动）一个抓手视图，第一次创建时绑定鼠标按下
+func (app *AppContext) setGrip(g *gocui.Gui, name string, x0, y0, x1, y1 int) error {
+	v, err := g.SetView(name, x0, y0, x1, y1)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		v.Frame = false
+		g.DeleteKeybindings(name)
+		if err := g.SetKeybinding(name, gocui.MouseLeft, gocui.ModNone, app.gripMouseDownHandler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 删除抓手视图和它的绑定
+func deleteGrip(g *gocui.Gui, name string) {
+	if err := g.DeleteView(name); err == nil {
+		g.DeleteKeybindings(name)
+	}
+}
+
+// 放置布局边界的抓手，每个抓手的内部正好覆盖边界两侧窗口的边框。
+// commandStartY 为命令窗口的上边框，panelBottomY 为左中右窗口的下边框（时间线窗口打开时在它上方）
+func (app *AppContext) layoutResizeGrips(g *gocui.Gui, layout *session.DynamicLayout, maxX, commandStartY, panelBottomY int) error {
+	bottomY := commandStartY - 1
+	rightStartX := maxX - layout.RightPanelWidth
+	grips := map[string][4]int{
+		// 文件浏览器右边框和代码窗口左边框两列
+		"left": {layout.LeftPanelWidth - 1, 2, layout.LeftPanelWidth + 2, panelBottomY + 1},
+		// 代码窗口右边框和右侧面板左边框两列
+		"right": {rightStartX - 2, 2, rightStartX + 1, panelBottomY + 1},
+		// 上方窗口下边框和命令窗口上边框两行
+		"bottom": {-1, bottomY - 1, maxX, commandStartY + 1},
+		// 寄存器/变量、变量/调用栈之间的两行（不含右侧面板的左边框）
+		"right1": {rightStartX, layout.RightPanelSplit1 - 1, maxX, layout.RightPanelSplit1 + 2},
+		"right2": {rightStartX, layout.RightPanelSplit2 - 1, maxX, layout.RightPanelSplit2 + 2},
+	}
+	for _, boundary := range resizeGrips {
+		r := grips[boundary]
+		if err := app.setGrip(g, gripPrefix+boundary, r[0], r[1], r[2], r[3]); err != nil {
+			return err
+		}
+		if _, err := g.SetViewOnBottom(gripPrefix + boundary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 全屏时没有可拖动的边界
+func deleteResizeGrips(g *gocui.Gui) {
+	for _, boundary := range resizeGrips {
+		deleteGrip(g, gripPrefix+boundary)
+	}
+}
+
+// 弹出窗口标题行的抓手（不含两端的边角）。抓手和弹出窗口依次移到最上层：
+// 抓手在其他窗口之上、弹出窗口之下，多个弹出窗口按 PopupWindows 的顺序叠放
+func (app *AppContext) raisePopupWithGrip(g *gocui.Gui, popup *session.PopupWindow, viewName string) error {
+	grip := popupGripPrefix + popup.ID
+	if err := app.setGrip(g, grip, popup.X, popup.Y-1, popup.X+popup.Width-1, popup.Y+1); err != nil {
+		return err
+	}
+	if _, err := g.SetViewOnTop(grip); err != nil {
+		return err
+	}
+	_, err := g.SetViewOnTop(viewName)
+	return err
+}
+
+// 删除已经关闭或隐藏的弹出窗口的抓手
+func deleteStalePopupGrips(g *gocui.Gui, ctx *session.DebuggerContext) {
+	for _, v := range g.Views() {
+		id := strings.TrimPrefix(v.Name(), popupGripPrefix)
+		if id == v.Name() {
+			continue
+		}
+		if popup := session.FindPopupWindow(ctx, id); popup == nil || !popup.Visible {
+			deleteGrip(g, v.Name())
+		}
+	}
+}
+
+// 在抓手上按下：开始拖动边界或弹出窗口
+func (app *AppContext) gripMouseDownHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	// 上一次拖动的松开事件可能落在终端之外而丢失
+	app.EndMouseDrag()
+	x, y := g.MousePosition()
+	if id := strings.TrimPrefix(v.Name(), popupGripPrefix); id != v.Name() {
+		if popup := session.FindPopupWindow(app.Ctx, id); popup != nil {
+			app.startPopupDrag(g, popup, x, y)
+		}
+		return nil
+	}
+	if app.Ctx.Layout != nil {
+		StartDrag(strings.TrimPrefix(v.Name(), gripPrefix), x, y, app.Ctx.Layout)
+	}
+	return nil
+}
+
+// 开始拖动弹出窗口，并把它移到最上层（下一次布局时生效）、获得焦点
+func (app *AppContext) startPopupDrag(g *gocui.Gui, popup *session.PopupWindow, x, y int) {
+	popup.Dragging = true
+	popup.DragStartX = x - popup.X
+	popup.DragStartY = y - popup.Y
+	app.Ctx.DraggingPopup = popup
+	for i, p := range app.Ctx.PopupWindows {
+		if p.ID == popup.ID {
+			app.Ctx.PopupWindows = append(app.Ctx.PopupWindows[:i], app.Ctx.PopupWindows[i+1:]...)
+			app.Ctx.PopupWindows = append(app.Ctx.PopupWindows, popup)
+			break
+		}
+	}
+	g.SetCurrentView("popup_" + popup.ID)
+}
+
+// 结束正在进行的拖动
+func (app *AppContext) EndMouseDrag() {
+	if app.Ctx.DraggingPopup != nil {
+		app.Ctx.DraggingPopup.Dragging = false
+		app.Ctx.DraggingPopup = nil
+	}
+	if app.Ctx.Layout != nil && app.Ctx.Layout.IsDragging {
+		endDrag(app.Ctx.Layout)
+	}
+}
@@ -0,0 +1,374 @@
+package layout
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 弹出窗口系统 ==========
+
+// 关闭弹出窗口并删除gocui视图
+func ClosePopupWindowWithView(g *gocui.Gui, ctx *session.DebuggerContext, id string) error {
+	if ctx == nil {
+		return nil
+	}
+	
+	// 删除对应的gocui视图
+	viewName := fmt.Sprintf("popup_%s", id)
+	if err := g.DeleteView(viewName); err != nil && err != gocui.ErrUnknownView {
+		// 如果删除视图失败且不是因为视图不存在，记录错误但继续
+		log.Printf("警告: 删除弹出窗口视图失败: %v", err)
+	}
+	
+	// 从弹出窗口列表中删除
+	session.ClosePopupWindow(ctx, id)
+	return nil
+			}
+			
+// 删除已经关闭或隐藏的弹出窗口的视图（会话层关闭窗口时只修改列表，切换工作区时隐藏整个列表）。
+// 焦点在被删除的视图上时回到命令窗口
+func deleteStalePopupViews(g *gocui.Gui, ctx *session.DebuggerContext) {
+	stale := make([]*gocui.View, 0)
+	for _, v := range g.Views() {
+		id := strings.TrimPrefix(v.Name(), "popup_")
+		if id == v.Name() {
+			continue
+		}
+		if popup := session.FindPopupWindow(ctx, id); popup == nil || !popup.Visible {
+			stale = append(stale, v)
+		}
+	}
+	for _, v := range stale {
+		focused := g.CurrentView() == v
+		if err := g.DeleteView(v.Name()); err == nil && focused {
+			g.SetCurrentView("command")
+		}
+	}
+}
+
+// 检测鼠标是否在弹出窗口内
+func PopupWindowAt(ctx *session.DebuggerContext, x, y int) *session.PopupWindow {
+	if ctx == nil {
+		return nil
+	}
+	
+	// 从后往前检查 (后添加的窗口在顶层)
+	for i := len(ctx.PopupWindows) - 1; i >= 0; i-- {
+		popup := ctx.PopupWindows[i]
+		if !popup.Visible {
+			continue
+		}
+		
+		if x >= popup.X && x < popup.X+popup.Width &&
+		   y >= popup.Y && y < popup.Y+popup.Height {
+			return popup
+		}
+	}
+	return nil
+}
+
+// 检测鼠标是否在弹出窗口标题栏内
+func IsInPopupTitleBar(popup *session.PopupWindow, x, y int) bool {
+	if popup == nil {
+		return false
+	}
+	
+	// 标题栏是窗口顶部的第一行
+	return x >= popup.X && x < popup.X+popup.Width &&
+		   y == popup.Y
+}
+
+// 弹出窗口专用关闭处理函数
+func (app *AppContext) popupCloseHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	// 获取弹出窗口ID
+	viewName := v.Name()
+	if !strings.HasPrefix(viewName, "popup_") {
+		return nil
+	}
+	popupID := strings.TrimPrefix(viewName, "popup_")
+	
+	// 关闭弹出窗口
+	if err := ClosePopupWindowWithView(g, app.Ctx, popupID); err != nil {
+		debugMsg := fmt.Sprintf("[ERROR] Failed to close popup window with q key: %s, error: %v", popupID, err)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+	} else {
+		debugMsg := fmt.Sprintf("[DEBUG] Successfully closed popup window with q key: %s", popupID)
+		app.Ctx.CommandHistory = append(app.Ctx.CommandHistory, debugMsg)
+	}
+	app.Ctx.CommandDirty = true
+	
+	return nil
+}
+
+// 为弹出窗口绑定鼠标事件和键盘事件
+func (app *AppContext) bindPopupMouseEvents(g *gocui.Gui, viewName string) {
+	// 同一ID的窗口关闭后再次打开会重新绑定，先清除旧绑定避免处理函数重复执行
+	g.DeleteKeybindings(viewName)
+	
+	// 绑定鼠标左键点击事件（用于拖拽）
+	g.SetKeybinding(viewName, gocui.MouseLeft, gocui.ModNone, app.popupMouseHandler)
+	
+	// 绑定鼠标滚轮事件（用于滚动）
+	g.SetKeybinding(viewName, gocui.MouseWheelUp, gocui.ModNone, app.popupScrollUpHandler)
+	g.SetKeybinding(viewName, gocui.MouseWheelDown, gocui.ModNone, app.popupScrollDownHandler)
+	
+	// 绑定q键关闭弹出窗口（避免与全局ESC键冲突）
+	g.SetKeybinding(viewName, 'q', gocui.ModNone, app.popupCloseHandler)
+	g.SetKeybinding(viewName, 'Q', gocui.ModNone, app.popupCloseHandler)
+	
+	// 为了兼容，也绑定ESC键，但优先级较低
+	g.SetKeybinding(viewName, gocui.KeyEsc, gocui.ModNone, app.popupCloseHandler)
+	
+	// 绑定Enter键选中内容行（窗口设置了OnSelect时）
+	g.SetKeybinding(viewName, gocui.KeyEnter, gocui.ModNone, app.popupSelectHandler)
+	
+	// 绑定数字键快速选择（窗口设置了OnDigit时）
+	for ch := '1'; ch <= '9'; ch++ {
+		g.SetKeybinding(viewName, ch, gocui.ModNone, app.popupDigitHandler(int(ch-'0')))
+	}
+	
+	// 绑定字母键（窗口设置了OnKey时，例如 replace 的 y/n/a/s 确认）
+	for ch := 'a'; ch <= 'z'; ch++ {
+		if ch != 'q' {
+			g.SetKeybinding(viewName, ch, gocui.ModNone, app.popupLetterHandler(ch))
+		}
+	}
+	
+	// 绑定方向键用于滚动
+	g.SetKeybinding(viewName, gocui.KeyArrowUp, gocui.ModNone, app.popupScrollUpHandler)
+	g.SetKeybinding(viewName, gocui.KeyArrowDown, gocui.ModNone, app.popupScrollDownHandler)
+	
+	// 注意：拖拽移动事件由全局的mouseDragResizeHandler处理
+	// 鼠标释放事件由全局的mouseUpHandler处理
+}
+
+// 弹出窗口Enter键处理：把光标所在行换算为内容行号后交给OnSelect
+func (app *AppContext) popupSelectHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	popup := session.FindPopupWindow(app.Ctx, strings.TrimPrefix(v.Name(), "popup_"))
+	if popup == nil || popup.OnSelect == nil {
+		return nil
+	}
+	// 内容前有提示行和空行
+	_, cy := v.Cursor()
+	return popup.OnSelect(views.GuiOf(g), popup.ScrollY+cy-2)
+}
+
+// 弹出窗口数字键处理
+func (app *AppContext) popupDigitHandler(n int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v == nil || app.Ctx == nil {
+			return nil
+		}
+		popup := session.FindPopupWindow(app.Ctx, strings.TrimPrefix(v.Name(), "popup_"))
+		if popup == nil || popup.OnDigit == nil {
+			return nil
+		}
+		return popup.OnDigit(views.GuiOf(g), n)
+	}
+}
+
+// 弹出窗口字母键处理
+func (app *AppContext) popupLetterHandler(ch rune) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		if v == nil || app.Ctx == nil {
+			return nil
+		}
+		popup := session.FindPopupWindow(app.Ctx, strings.TrimPrefix(v.Name(), "popup_"))
+		if popup == nil || popup.OnKey == nil {
+			return nil
+		}
+		return popup.OnKey(views.GuiOf(g), ch)
+	}
+}
+
+// 弹出窗口鼠标点击处理函数
+func (app *AppContext) popupMouseHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	// 聚焦到弹出窗口
+	g.SetCurrentView(v.Name())
+	
+	// 获取弹出窗口ID
+	viewName := v.Name()
+	if !strings.HasPrefix(viewName, "popup_") {
+		return nil
+	}
+	popupID := strings.TrimPrefix(viewName, "popup_")
+	
+	// 查找对应的弹出窗口
+	popup := session.FindPopupWindow(app.Ctx, popupID)
+	if popup == nil {
+		return nil
+	}
+	
+	mouseX, mouseY := g.MousePosition()
+	
+	// 检查是否点击了标题栏（用于拖拽，标题栏上的点击通常由抓手视图收到）
+	if IsInPopupTitleBar(popup, mouseX, mouseY) {
+		app.startPopupDrag(g, popup, mouseX, mouseY)
+	}
+	
+	return nil
+}
+
+// 弹出窗口向上滚动处理函数
+func (app *AppContext) popupScrollUpHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	// 获取弹出窗口ID
+	viewName := v.Name()
+	if !strings.HasPrefix(viewName, "popup_") {
+		return nil
+	}
+	popupID := strings.TrimPrefix(viewName, "popup_")
+	
+	// 查找对应的弹出窗口
+	popup := session.FindPopupWindow(app.Ctx, popupID)
+	if popup == nil {
+		return nil
+	}
+	
+	// 向上滚动
+	if popup.ScrollY > 0 {
+		popup.ScrollY--
+	}
+	
+	return nil
+}
+
+// 弹出窗口向下滚动处理函数
+func (app *AppContext) popupScrollDownHandler(g *gocui.Gui, v *gocui.View) error {
+	if v == nil || app.Ctx == nil {
+		return nil
+	}
+	
+	// 获取弹出窗口ID
+	viewName := v.Name()
+	if !strings.HasPrefix(viewName, "popup_") {
+		return nil
+	}
+	popupID := strings.TrimPrefix(viewName, "popup_")
+	
+	// 查找对应的弹出窗口
+	popup := session.FindPopupWindow(app.Ctx, popupID)
+	if popup == nil {
+		return nil
+	}
+	
+	// 向下滚动（检查是否还有更多内容）
+	availableLines := popup.Height - 3 // 减去边框和提示行
+	if availableLines < 1 {
+		availableLines = 1
+	}
+	
+	
+	maxScroll := len(popup.Content) - availableLines
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	
+	if popup.ScrollY < maxScroll {
+		popup.ScrollY++
+	}
+	
+	return nil
+}
+
+// 渲染弹出窗口
+func (app *AppContext) renderPopupWindows(g *gocui.Gui) error {
+	ctx := app.Ctx
+	if ctx == nil {
+		return nil
+	}
+	
+	maxX, maxY := g.Size()
+	deleteStalePopupViews(g, ctx)
+	deleteStalePopupGrips(g, ctx)
+	
+	for i, popup := range ctx.PopupWindows {
+		if !popup.Visible {
+			continue
+		}
+		
+		// 调整窗口位置以适应屏幕大小
+		clampPopupToScreen(popup, maxX, maxY)
+		
+		// 创建窗口视图
+		viewName := fmt.Sprintf("popup_%s", popup.ID)
+		v, err := g.SetView(viewName, popup.X, popup.Y, popup.X+popup.Width-1, popup.Y+popup.Height-1)
+		if err != nil {
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			v.Frame = true
+			v.Highlight = true
+			v.SelBgColor = gocui.ColorBlue
+			
+			// 为新创建的弹出窗口绑定鼠标事件
+			app.bindPopupMouseEvents(g, viewName)
+			
+			// 自动聚焦到新创建的弹出窗口
+			g.SetCurrentView(viewName)
+		}
+		// 标题行的抓手跟随窗口移动，按叠放顺序移到最上层
+		if err := app.raisePopupWithGrip(g, popup, viewName); err != nil {
+			return err
+		}
+		
+		// 设置标题
+		v.Title = fmt.Sprintf(" %s [drag] ", popup.Title)
+		
+		// 清空并填充内容
+		v.Clear()
+		
+		// 显示关闭按钮提示
+		fmt.Fprintf(v, "\x1b[90mPress q to close | Drag title bar to move window\x1b[0m\n")
+		fmt.Fprintln(v, "")
+		
+		// 显示内容 (考虑滚动)
+		availableLines := popup.Height - 3 // 减去边框和提示行
+		if availableLines < 1 {
+			availableLines = 1
+		}
+		
+		startIdx := popup.ScrollY
+		endIdx := startIdx + availableLines
+		if endIdx > len(popup.Content) {
+			endIdx = len(popup.Content)
+		}
+		
+		// 按显示宽度截断，避免宽字符压在右边框上
+		for idx := startIdx; idx < endIdx; idx++ {
+			fmt.Fprintln(v, project.TruncateWidth(popup.Content[idx], popup.Width-2))
+		}
+		
+		// 如果有更多内容，显示滚动提示
+		if len(popup.Content) > availableLines {
+			fmt.Fprintf(v, "\x1b[90m[%d/%d] Use ↑↓ to scroll\x1b[0m", popup.ScrollY+1, len(popup.Content)-availableLines+1)
+		}
+		
+		// 将窗口移到最顶层 (通过设置TabStop)
+		if i == len(ctx.PopupWindows)-1 {
+			v.Highlight = true
+		}
+	}
+	
+	return nil
+}
@@ -0,0 +1,8 @@
+package layout
+
+import "debug-gocui/internal/commands"
+
+// AppContext 布局层的应用上下文：窗口布局、全屏、拖动调整大小和弹出窗口的回调
+type AppContext struct {
+	*commands.AppContext
+}
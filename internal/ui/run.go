@@ -0,0 +1,290 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/input"
+	"debug-gocui/internal/ui/views"
+)
+
+// 启动界面：解析命令行参数，恢复上次的会话状态，绑定按键后运行主循环，返回进程退出码
+func Run() int {
+	// 创建调试器上下文
+	ctx := session.NewDebuggerContext()
+	
+	// 应用上下文：通过方法接收者注入到所有回调中
+	app := input.NewAppContext(ctx)
+	
+	// 命令行参数（--safe、--script）
+	options := session.ParseStartupFlags(ctx)
+	if options.Script != "" && !options.TUI {
+		// 无界面执行脚本（CI冒烟测试），不读写会话状态
+		return app.RunScriptHeadless(options.Script)
+	}
+	
+	// 输出模式决定可用的主题，在恢复会话状态中的主题之前确定
+	outputMode := terminalOutputMode()
+	session.Colors256 = outputMode == gocui.Output256
+
+	// 上次退出时保存的会话状态：布局在第一次绘制前恢复，项目和文件在视图创建后恢复
+	state, err := session.LoadSessionState()
+	if err != nil {
+		ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %v", err))
+	}
+	session.RestoreSessionLayout(ctx, state)
+
+	// 创建GUI（终端支持时使用256色）
+	g, err := gocui.NewGui(outputMode)
+	if err != nil {
+		log.Panicln(err)
+	}
+	defer g.Close()
+
+	// 启用鼠标支持
+	g.Mouse = true
+	ctx.MouseEnabled = true
+
+	// 命令面板在布局之后绘制，位于所有窗口之上
+	g.SetManager(gocui.ManagerFunc(app.Layout), gocui.ManagerFunc(app.RenderPalette))
+
+	// 可配置的按键（导航、全屏、搜索、布局调整等，见 ui/input/keymap.go）
+	warnings, err := app.BindConfigurableKeys(g)
+	if err != nil {
+		log.Panicln(err)
+	}
+	ctx.CommandHistory = append(ctx.CommandHistory, warnings...)
+	
+	// ESC键在代码视图中的专门处理（处理搜索模式退出）
+	if err := g.SetKeybinding("code", gocui.KeyEsc, gocui.ModNone, app.HandleSearchEscape); err != nil {
+		log.Panicln(err)
+	}
+
+	// Enter键文件选择（在文件浏览器中）
+	if err := g.SetKeybinding("filebrowser", gocui.KeyEnter, gocui.ModNone, app.HandleFileSelection); err != nil {
+		log.Panicln(err)
+	}
+	
+	// Enter键设置断点（在代码视图中，非搜索模式）
+	if err := g.SetKeybinding("code", gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx != nil && app.Ctx.SearchMode {
+			return app.HandleSearchEnter(g, v)
+		}
+		return app.HandleBreakpointToggle(g, v)
+	}); err != nil {
+		log.Panicln(err)
+	}
+	
+	// Enter键跳转到栈帧源码（在调用栈窗口中）
+	if err := g.SetKeybinding("stack", gocui.KeyEnter, gocui.ModNone, app.StackFrameEnterHandler); err != nil {
+		log.Panicln(err)
+	}
+	
+	// Enter键处理命令（在命令窗口中）
+	if err := g.SetKeybinding("command", gocui.KeyEnter, gocui.ModNone, app.HandleCommand); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 退格键支持（在命令窗口中）
+	if err := g.SetKeybinding("command", gocui.KeyBackspace, gocui.ModNone, app.HandleBackspace); err != nil {
+		log.Panicln(err)
+	}
+	if err := g.SetKeybinding("command", gocui.KeyBackspace2, gocui.ModNone, app.HandleBackspace); err != nil {
+		log.Panicln(err)
+	}
+	
+	// ESC键在命令窗口中的专门处理（优先级高于全局ESC绑定）
+	if err := g.SetKeybinding("command", gocui.KeyEsc, gocui.ModNone, app.EscapeExitFullscreenHandler); err != nil {
+		log.Panicln(err)
+	}
+	
+	// ESC键现在由全局处理函数统一处理（全屏退出或清空命令输入）
+	
+	// 单字符快捷键（`/w/x等）只在非编辑窗口生效，命令输入和搜索输入由同一分发函数处理
+	// Ctrl+X 引导键可在任意窗口触发这些快捷键
+	if err := app.BindScopedKeys(g); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 时间线窗口：方向键平移，单击选中事件，滚轮缩放
+	if err := app.BindTimelineKeys(g); err != nil {
+		log.Panicln(err)
+	}
+	
+	// Ctrl+P 命令面板（终端中Ctrl+Shift+P与Ctrl+P无法区分）
+	if err := app.BindPaletteKeys(g); err != nil {
+		log.Panicln(err)
+	}
+
+	// Alt+1..Alt+4 切换工作区
+	if err := app.BindWorkspaceKeys(g); err != nil {
+		log.Panicln(err)
+	}
+
+	// Ctrl+G 取消历史反向搜索
+	if err := g.SetKeybinding("command", gocui.KeyCtrlG, gocui.ModNone, app.CancelHistorySearchHandler); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 搜索模式下的退格键
+	if err := g.SetKeybinding("code", gocui.KeyBackspace, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx != nil && app.Ctx.SearchMode {
+			return app.HandleSearchBackspace(g, v)
+		}
+		return nil
+	}); err != nil {
+		log.Panicln(err)
+	}
+	if err := g.SetKeybinding("code", gocui.KeyBackspace2, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if app.Ctx != nil && app.Ctx.SearchMode {
+			return app.HandleSearchBackspace(g, v)
+		}
+		return nil
+	}); err != nil {
+		log.Panicln(err)
+	}
+
+	// 鼠标事件绑定
+	// 文件浏览器特殊鼠标处理：点击打开文件/展开目录
+	if err := g.SetKeybinding("filebrowser", gocui.MouseLeft, gocui.ModNone, app.HandleFileBrowserClick); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 代码视图特殊鼠标处理：单击断点栏设置断点，双击选择单词
+	if err := g.SetKeybinding("code", gocui.MouseLeft, gocui.ModNone, app.HandleCodeViewClick); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 命令窗口特殊鼠标处理：点击时设置CommandDirty
+	if err := g.SetKeybinding("command", gocui.MouseLeft, gocui.ModNone, app.HandleCommandClick); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 其他窗口的标准鼠标处理
+	viewNames := []string{"registers", "variables", "stack", "memory"}
+	
+	for _, viewName := range viewNames {
+		// 鼠标单击聚焦
+		if err := g.SetKeybinding(viewName, gocui.MouseLeft, gocui.ModNone, app.MouseDownHandler); err != nil {
+			log.Panicln(err)
+		}
+		
+		// 鼠标滚轮滚动（命令窗口不需要滚动）
+		if viewName != "command" {
+			if err := g.SetKeybinding(viewName, gocui.MouseWheelUp, gocui.ModNone, app.MouseScrollUpHandler); err != nil {
+			log.Panicln(err)
+		}
+			if err := g.SetKeybinding(viewName, gocui.MouseWheelDown, gocui.ModNone, app.MouseScrollDownHandler); err != nil {
+			log.Panicln(err)
+		}
+		}
+	}
+	
+	// 代码视图滚轮支持
+	if err := g.SetKeybinding("code", gocui.MouseWheelUp, gocui.ModNone, app.MouseScrollUpHandler); err != nil {
+		log.Panicln(err)
+	}
+	if err := g.SetKeybinding("code", gocui.MouseWheelDown, gocui.ModNone, app.MouseScrollDownHandler); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 文件浏览器的滚轮支持
+	if err := g.SetKeybinding("filebrowser", gocui.MouseWheelUp, gocui.ModNone, app.MouseScrollUpHandler); err != nil {
+		log.Panicln(err)
+	}
+	if err := g.SetKeybinding("filebrowser", gocui.MouseWheelDown, gocui.ModNone, app.MouseScrollDownHandler); err != nil {
+		log.Panicln(err)
+	}
+	
+	// 拖动边界和弹出窗口（按下由边界上的抓手视图处理，见 ui/layout/mouse.go）
+	if err := app.BindMouseTracking(g); err != nil {
+		log.Panicln(err)
+	}
+
+	// 设置信号处理
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// 启动更新协程
+	go func() {
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		
+		// 首次设置初始聚焦窗口
+		firstRun := true
+
+		for {
+			select {
+			case <-ticker.C:
+				scheduled := time.Now()
+				g.Update(func(g *gocui.Gui) error {
+					start := time.Now()
+					// 首次运行时设置初始聚焦窗口
+					if firstRun {
+						if _, err := g.View("filebrowser"); err == nil {
+							firstRun = false
+							focus := restoreSession(app, g, state)
+							if options.RPC != "" {
+								path := options.RPC
+								if path == "default" {
+									path = ""
+								}
+								if path, err := app.StartRPC(views.GuiOf(g), path); err != nil {
+									ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %v", err))
+								} else {
+									ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("RPC: listening on %s", path))
+								}
+							}
+							if options.Script != "" {
+								ctx.CurrentInput = "source " + options.Script
+								app.HandleCommand(g, nil)
+							}
+							if _, err := g.SetCurrentView(focus); err != nil {
+								g.SetCurrentView("filebrowser")
+							}
+						}
+					}
+					// 只刷新当前工作区，后台工作区的采集继续写入各自的上下文
+					views.UpdateAllViews(g, app.Ctx)
+					app.UpdateWorkspaceTitle(g)
+					// 记录刷新延迟和自身资源占用
+					session.RecordFrame(app.Ctx, start.Sub(scheduled), time.Since(start))
+					return nil
+				})
+			case <-sigChan:
+				g.Update(func(g *gocui.Gui) error {
+					return gocui.ErrQuit
+				})
+				return
+			}
+		}
+	}()
+
+	// 运行主循环
+	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
+		log.Panicln(err)
+	}
+	
+	// 关闭RPC服务（删除socket文件）
+	app.StopRPC()
+	
+	// 断开gdbstub（删除断点，目标继续运行）
+	for _, ws := range app.WorkspaceList() {
+		session.DisconnectGDB(ws.Ctx)
+	}
+	
+	// 写出录制中的帧
+	for _, ws := range app.WorkspaceList() {
+		session.StopRecording(ws.Ctx)
+	}
+	
+	// 保存会话状态，下次启动时恢复（失败不影响退出）
+	session.SaveSessionState(captureSessionState(g, app.Ctx))
+	return 0
+}
@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+	"debug-gocui/internal/ui/input"
+	"debug-gocui/internal/ui/views"
+)
+
+// ========== 会话状态 ==========
+
+// 当前会话状态（全屏时保存全屏前的布局）
+func captureSessionState(g *gocui.Gui, ctx *session.DebuggerContext) *session.SessionState {
+	state := &session.SessionState{
+		Theme:  session.ActiveTheme.Name,
+		Search: ctx.SearchOptions,
+		Scroll: map[string]int{
+			"filebrowser": ctx.FileScroll,
+			"registers":   ctx.RegScroll,
+			"variables":   ctx.VarScroll,
+			"stack":       ctx.StackScroll,
+			"code":        ctx.CodeScroll,
+			"memory":      ctx.MemScroll,
+		},
+	}
+	layout := ctx.Layout
+	if ctx.IsFullscreen && ctx.SavedLayout != nil {
+		layout = ctx.SavedLayout
+	}
+	if layout != nil {
+		maxX, maxY := g.Size()
+		state.Layout = &session.SessionLayout{
+			LeftPanelWidth:   layout.LeftPanelWidth,
+			RightPanelWidth:  layout.RightPanelWidth,
+			CommandHeight:    layout.CommandHeight,
+			RightPanelSplit1: layout.RightPanelSplit1,
+			RightPanelSplit2: layout.RightPanelSplit2,
+			ScreenWidth:      maxX,
+			ScreenHeight:     maxY,
+		}
+	}
+	if ctx.Project != nil {
+		state.Project = ctx.Project.RootPath
+		state.File = ctx.Project.CurrentFile
+	}
+	if v := g.CurrentView(); v != nil {
+		for _, name := range session.SessionFocusViews {
+			if v.Name() == name {
+				state.Focus = name
+			}
+		}
+	}
+	return state
+}
+
+// 恢复项目、文件、滚动位置和焦点（视图创建后调用），返回要聚焦的窗口
+func restoreSession(app *input.AppContext, g *gocui.Gui, state *session.SessionState) string {
+	if state == nil {
+		return "filebrowser"
+	}
+	ctx := app.Ctx
+	if info, err := os.Stat(state.Project); state.Project != "" && !ctx.SafeMode && err == nil && info.IsDir() {
+		// 与手动执行 open 相同（检测KASLR、目标架构等），命令窗口中可以看到恢复了哪个项目
+		ctx.CurrentInput = "open " + state.Project
+		app.HandleCommand(g, nil)
+	}
+	if ctx.Project != nil && state.File != "" && project.FileExists(state.File) {
+		if err := session.OpenSourceAt(views.GuiOf(g), ctx, state.File, 1); err != nil {
+			ctx.CommandHistory = append(ctx.CommandHistory, fmt.Sprintf("Warning: %v", err))
+			ctx.CommandDirty = true
+		}
+	}
+	ctx.FileScroll = state.Scroll["filebrowser"]
+	ctx.RegScroll = state.Scroll["registers"]
+	ctx.VarScroll = state.Scroll["variables"]
+	ctx.StackScroll = state.Scroll["stack"]
+	ctx.MemScroll = state.Scroll["memory"]
+	if ctx.Project != nil && ctx.Project.CurrentFile != "" {
+		ctx.CodeScroll = state.Scroll["code"]
+		if file := ctx.Project.OpenFiles[ctx.Project.CurrentFile]; file == nil || ctx.CodeScroll >= file.Len() {
+			ctx.CodeScroll = 0
+		}
+	}
+	if state.Focus == "" {
+		return "filebrowser"
+	}
+	return state.Focus
+}
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"debug-gocui/internal/gocui"
+)
+
+// ========== 配色主题 ==========
+
+// 按终端能力选择输出模式：DEBUG_TUI_COLORS=256/8 优先，其次 TERM 和 COLORTERM
+func terminalOutputMode() gocui.OutputMode {
+	switch os.Getenv("DEBUG_TUI_COLORS") {
+	case "256":
+		return gocui.Output256
+	case "8":
+		return gocui.OutputNormal
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return gocui.Output256
+	}
+	switch os.Getenv("COLORTERM") {
+	case "truecolor", "24bit":
+		return gocui.Output256
+	}
+	return gocui.OutputNormal
+}
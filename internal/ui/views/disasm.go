@@ -0,0 +1,31 @@
+package views
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 反汇编视图 ==========
+
+// 在代码窗口中显示反汇编（标题之后，随代码窗口的滚动位置滚动）
+func renderDisassembly(v *gocui.View, ctx *session.DebuggerContext) {
+	d := ctx.Disasm
+	fmt.Fprintf(v, "⚙ %s() %s [%s] \x1b[90m'disasm off' for source\x1b[0m\n", d.Function, filepath.Base(d.Module), d.Tool)
+	_, viewHeight := v.Size()
+	available := viewHeight - 2
+	if available < 1 {
+		available = 1
+	}
+	if ctx.CodeScroll >= len(d.Lines) {
+		ctx.CodeScroll = len(d.Lines) - 1
+	}
+	if ctx.CodeScroll < 0 {
+		ctx.CodeScroll = 0
+	}
+	for i := ctx.CodeScroll; i < len(d.Lines) && i < ctx.CodeScroll+available; i++ {
+		fmt.Fprintln(v, d.Lines[i])
+	}
+}
@@ -0,0 +1,68 @@
+package views
+
+import (
+	"strings"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// 基于gocui的UI实现
+type gui struct {
+	g *gocui.Gui
+}
+
+// 包装gocui.Gui（g为nil时返回nil，会话层据此判断没有界面）
+func GuiOf(g *gocui.Gui) session.UI {
+	if g == nil {
+		return nil
+	}
+	return gui{g}
+}
+
+func (u gui) Update(f func(session.UI) error) {
+	u.g.Update(func(*gocui.Gui) error {
+		return f(u)
+	})
+}
+
+func (u gui) Focus(view string) {
+	u.g.SetCurrentView(view)
+}
+
+func (u gui) CurrentView() string {
+	if v := u.g.CurrentView(); v != nil {
+		return v.Name()
+	}
+	return ""
+}
+
+func (u gui) Cursor(view string) (int, int) {
+	if v, err := u.g.View(view); err == nil {
+		return v.Cursor()
+	}
+	return 0, 0
+}
+
+func (u gui) SetCursor(view string, x, y int) {
+	if v, err := u.g.View(view); err == nil {
+		v.SetCursor(x, y)
+	}
+}
+
+func (u gui) Lines(view string) []string {
+	return ViewText(u.g, view)
+}
+
+// 获取当前窗口的文本内容
+func ViewText(g *gocui.Gui, viewName string) []string {
+	v, err := g.View(viewName)
+	if err != nil {
+		return nil
+	}
+
+	// 获取视图的缓冲区内容
+	buffer := v.Buffer()
+	lines := strings.Split(strings.TrimSuffix(buffer, "\n"), "\n")
+	return lines
+}
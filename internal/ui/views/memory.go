@@ -0,0 +1,34 @@
+package views
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 内存窗口内容刷新 ==========
+func updateMemoryView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("memory")
+	if err != nil || ctx.Memory == nil {
+		return
+	}
+	v.Clear()
+	dump := ctx.Memory
+	header := fmt.Sprintf("Memory 0x%x%s (%d bytes, %d/line, %s, %s)", dump.Addr, session.LinkAddressNote(ctx, dump.Addr), len(dump.Data), dump.Width, dump.Source, dump.ReadAt.Format("15:04:05"))
+	if g.CurrentView() != nil && g.CurrentView().Name() == "memory" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ "+header)+" "+session.Styled(session.ActiveTheme.Dim, "+/- width"))
+	} else {
+		fmt.Fprintln(v, header)
+	}
+	lines := session.MemoryDumpLines(dump)
+	if ctx.MemScroll >= len(lines) {
+		ctx.MemScroll = len(lines) - 1
+	}
+	if ctx.MemScroll < 0 {
+		ctx.MemScroll = 0
+	}
+	for i := ctx.MemScroll; i < len(lines); i++ {
+		fmt.Fprintln(v, lines[i])
+	}
+}
@@ -0,0 +1,32 @@
+package views
+
+import (
+	"fmt"
+
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/session"
+)
+
+// ========== 时间线窗口内容刷新 ==========
+func updateTimelineView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("timeline")
+	if err != nil || ctx.Timeline == nil {
+		return
+	}
+	width, _ := v.Size()
+	ctx.Timeline.Width = width
+	v.Clear()
+	title := "Timeline"
+	if g.CurrentView() != nil && g.CurrentView().Name() == "timeline" {
+		title = "▶ Timeline (Focused)"
+	}
+	if ctx.Timeline.Cursor != 0 {
+		title += " ⏸ pinned"
+	} else if ctx.Timeline.Follow || ctx.Timeline.Span <= 0 {
+		title += " ● live"
+	}
+	v.Title = title + " - ←/→ pan, +/-/wheel zoom, [ ] prev/next, click jump, 0 fit, l live"
+	for _, line := range session.TimelineLines(ctx, width) {
+		fmt.Fprintln(v, line)
+	}
+}
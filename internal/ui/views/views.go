@@ -0,0 +1,851 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"path/filepath"
+	"time"
+
+	"debug-gocui/internal/codegen"
+	"debug-gocui/internal/dwarf"
+	"debug-gocui/internal/gocui"
+	"debug-gocui/internal/project"
+	"debug-gocui/internal/session"
+)
+
+// ========== 状态栏内容刷新 ==========
+func updateStatusView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("status")
+	if err != nil {
+		return
+	}
+	
+	v.Clear()
+	
+	// 显示调试器状态
+	stateStr := "STOP"
+	if ctx.BpfLoaded {
+		stateStr = "BPF_LOADED"
+	}
+	if ctx.GDB != nil {
+		stateStr = strings.ToUpper(ctx.GDB.Kind) + "_STOPPED"
+	}
+	if ctx.Running {
+		stateStr = "RUNNING"
+	}
+	
+	// 显示基本状态信息
+	fmt.Fprintf(v, "RISC-V Kernel Debugger | State: %s | Func: %s | Addr: 0x%X", 
+		stateStr, ctx.CurrentFunc, ctx.CurrentAddr)
+	if ctx.DemoMode {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Alert, "SIMULATED"))
+	}
+	if ctx.Watchdog != nil && ctx.Watchdog.Status == "hung" {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Alert, "TARGET HUNG"))
+	}
+	if session.UILagging(ctx) {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Warning, "UI LAG"))
+	}
+	if ctx.SafeMode {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Warning, "SAFE MODE"))
+	}
+	if n := len(ctx.AssertViolations); n > 0 {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Alert, fmt.Sprintf("✗ %d assertion violations", n)))
+	}
+	if event := session.TimelineSelected(ctx); event != nil {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Warning, fmt.Sprintf("⏸ timeline #%d", event.Seq)))
+	}
+	if ctx.Recording != nil {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Alert, fmt.Sprintf("● REC %d frames", ctx.Recording.Frames)))
+	}
+	if frame := session.CurrentReplayFrame(ctx); frame != nil {
+		fmt.Fprint(v, " | "+session.Styled(session.ActiveTheme.Warning, fmt.Sprintf("⏵ replay %d/%d", frame.Index, len(ctx.Replay.Frames))))
+	}
+	
+	// 显示全屏状态和操作提示
+	if ctx.IsFullscreen {
+		fmt.Fprintf(v, " | Fullscreen: %s | F11/ESC-Exit", ctx.FullscreenView)
+	} else {
+		// 显示拖拽状态和提示
+		if ctx.Layout != nil {
+			if ctx.Layout.IsDragging {
+				fmt.Fprintf(v, " | Resizing: %s", getBoundaryName(ctx.Layout.DragBoundary))
+			} else {
+				fmt.Fprint(v, " | Tip: Drag borders to resize, F11 for fullscreen")
+			}
+			
+			// 显示当前布局参数
+			fmt.Fprintf(v, " | Layout: L%d R%d C%d", 
+				ctx.Layout.LeftPanelWidth, 
+				ctx.Layout.RightPanelWidth, 
+				ctx.Layout.CommandHeight)
+		}
+	}
+}
+
+// 获取边界名称的友好显示
+func getBoundaryName(boundary string) string {
+	switch boundary {
+	case "left":
+		return "Left Border"
+	case "right":
+		return "Right Border"
+	case "bottom":
+		return "Bottom Border"
+	case "right1":
+		return "Reg/Var Split"
+	case "right2":
+		return "Var/Stack Split"
+	default:
+		return "Unknown Border"
+	}
+}
+
+// ========== 文件浏览器窗口内容刷新 ==========
+func UpdateFileBrowserView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("filebrowser")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	
+	if g.CurrentView() != nil && g.CurrentView().Name() == "filebrowser" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ File Browser (Focused)"))
+	} else {
+		fmt.Fprintln(v, "File Browser")
+	}
+	
+	if ctx.Project == nil {
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "No project opened")
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "Use command to open project:")
+		fmt.Fprintln(v, "open /path/to/project")
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "Or:")
+		fmt.Fprintln(v, "open ../tacosys_ko")
+		return
+	}
+	
+	fmt.Fprintln(v, "")
+	fmt.Fprintf(v, "Project: %s\n", filepath.Base(ctx.Project.RootPath))
+	fmt.Fprintln(v, "💡 Click file to open, click folder to expand/collapse")
+	fmt.Fprintln(v, "")
+	
+	// 显示文件树
+	if ctx.Project.FileTree != nil {
+		// 重置行映射表
+		ctx.FileBrowserLines = ctx.FileBrowserLines[:0]
+		
+		// 显示文件树并构建映射表
+		displayFileTreeWithMapping(v, ctx.Project.FileTree, 0, ctx)
+	}
+}
+
+// 显示文件树
+func displayFileTree(v *gocui.View, node *project.FileNode, depth int, scroll int) {
+	if node == nil {
+		return
+	}
+	
+	indent := strings.Repeat("  ", depth)
+	icon := "📄"
+	if node.IsDir {
+		if node.Expanded {
+			icon = "📂"
+		} else {
+			icon = "📁"
+		}
+	} else {
+		// 根据文件扩展名显示不同图标
+		ext := strings.ToLower(filepath.Ext(node.Name))
+		switch ext {
+		case ".c":
+			icon = "🔧"
+		case ".cpp":
+			icon = "⚙️"
+		case ".h", ".hpp":
+			icon = "📋"
+		default:
+			icon = "📄"
+		}
+	}
+	
+	fmt.Fprintf(v, "%s%s %s\n", indent, icon, node.Name)
+	
+	// 如果是展开的目录，显示子节点
+	if node.IsDir && node.Expanded {
+		for _, child := range node.Children {
+			displayFileTree(v, child, depth+1, scroll)
+		}
+	}
+}
+
+// 新的文件树显示函数，支持行映射和交互
+func displayFileTreeWithMapping(v *gocui.View, node *project.FileNode, depth int, ctx *session.DebuggerContext) {
+	displayFileTreeNode(v, node, depth, ctx)
+}
+
+// 递归显示文件树节点并建立映射
+func displayFileTreeNode(v *gocui.View, node *project.FileNode, depth int, ctx *session.DebuggerContext) {
+	if node == nil {
+		return
+	}
+	
+	indent := strings.Repeat("  ", depth)
+	icon := "📄"
+	highlight := ""
+	
+	if node.IsDir {
+		if node.Expanded {
+			icon = "📂"
+		} else {
+			icon = "📁"
+		}
+	} else {
+		// 根据文件扩展名显示不同图标
+		ext := strings.ToLower(filepath.Ext(node.Name))
+		switch ext {
+		case ".c":
+			icon = "🔧"
+		case ".cpp":
+			icon = "⚙️"
+		case ".h", ".hpp":
+			icon = "📋"
+		default:
+			icon = "📄"
+		}
+		
+		// 检查是否是当前打开的文件
+		if ctx.Project != nil && ctx.Project.CurrentFile == node.Path {
+			highlight = session.ActiveTheme.Selected
+		}
+	}
+	
+	// 构建显示行
+	displayLine := fmt.Sprintf("%s%s %s", indent, icon, node.Name)
+	
+	// 添加到映射表
+	ctx.FileBrowserLines = append(ctx.FileBrowserLines, node)
+	
+	// 显示行（考虑高亮）
+	if highlight != "" {
+		fmt.Fprintln(v, session.Styled(highlight, displayLine))
+	} else {
+		fmt.Fprintf(v, "%s\n", displayLine)
+	}
+	
+	// 如果是展开的目录，显示子节点
+	if node.IsDir && node.Expanded {
+		childIndent := strings.Repeat("  ", depth+1)
+		switch {
+		case node.Loading:
+			frame := spinnerFrames[int(time.Now().UnixNano()/int64(100*time.Millisecond))%len(spinnerFrames)]
+			displayFileTreeNote(v, ctx, childIndent+session.Styled(session.ActiveTheme.Dim, frame+" loading..."))
+		case node.LoadErr != "":
+			displayFileTreeNote(v, ctx, childIndent+session.Styled(session.ActiveTheme.Error, "✗ "+node.LoadErr))
+		case node.Loaded && len(node.Children) == 0:
+			displayFileTreeNote(v, ctx, childIndent+session.Styled(session.ActiveTheme.Dim, "(no source files)"))
+		}
+		for _, child := range node.Children {
+			displayFileTreeNode(v, child, depth+1, ctx)
+		}
+	}
+}
+
+// 加载中的动画帧
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// 文件树中不对应节点的提示行（映射表中占位为nil，点击时忽略）
+func displayFileTreeNote(v *gocui.View, ctx *session.DebuggerContext, line string) {
+	ctx.FileBrowserLines = append(ctx.FileBrowserLines, nil)
+	fmt.Fprintln(v, line)
+}
+
+// ========== 示例数据标记 ==========
+// 寄存器/变量/调用栈窗口尚未接入真实数据源，显示的是示例数据。
+// 示例数据前加醒目的SIMULATED标记；demo off 时直接隐藏示例数据。
+func simulatedLines(ctx *session.DebuggerContext, sample []string) []string {
+	if !ctx.DemoMode {
+		return []string{
+			session.Styled(session.ActiveTheme.Dim, "No data backend attached"),
+			session.Styled(session.ActiveTheme.Dim, "(demo off: sample data hidden, 'demo on' to show)"),
+		}
+	}
+	banner := session.Styled(session.ActiveTheme.Alert, " SIMULATED ") + " " + session.Styled(session.ActiveTheme.Dim, "sample data, 'demo off' to hide")
+	return append([]string{banner}, sample...)
+}
+
+// ========== 寄存器窗口内容刷新 ==========
+func updateRegistersView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("registers")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	if g.CurrentView() != nil && g.CurrentView().Name() == "registers" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ Registers (Focused)"))
+	} else {
+		fmt.Fprintln(v, "Registers")
+	}
+	// 回放时显示当前帧记录的寄存器
+	if frame := session.CurrentReplayFrame(ctx); frame != nil {
+		if frame.Registers == nil {
+			fmt.Fprintln(v, session.Styled(session.ActiveTheme.Dim, fmt.Sprintf("No registers recorded in frame %d", frame.Index)))
+			return
+		}
+		lines := session.RegisterLines(frame.Registers)
+		for i := ctx.RegScroll; i < len(lines); i++ {
+			fmt.Fprintln(v, lines[i])
+		}
+		return
+	}
+	// 时间线选中事件时显示该次命中记录的寄存器
+	if snap, pinned := session.TimelineRegisters(ctx); pinned {
+		if snap == nil {
+			fmt.Fprintln(v, session.Styled(session.ActiveTheme.Dim, fmt.Sprintf("No registers recorded for #%d", ctx.Timeline.Cursor)))
+			return
+		}
+		lines := session.RegisterLines(snap)
+		for i := ctx.RegScroll; i < len(lines); i++ {
+			fmt.Fprintln(v, lines[i])
+		}
+		return
+	}
+	if ctx.Registers != nil {
+		lines := session.RegisterLines(ctx.Registers)
+		for i := ctx.RegScroll; i < len(lines); i++ {
+			fmt.Fprintln(v, lines[i])
+		}
+		return
+	}
+	lines := simulatedLines(ctx, []string{
+		fmt.Sprintf("PC: 0x%016x", ctx.CurrentAddr),
+		fmt.Sprintf("RA: 0x%016x", ctx.CurrentAddr+0x100),
+		fmt.Sprintf("SP: 0x%016x", ctx.CurrentAddr+0x200),
+		"...",
+	})
+	for i := ctx.RegScroll; i < len(lines); i++ {
+		fmt.Fprintln(v, lines[i])
+	}
+}
+
+// ========== 变量窗口内容刷新 ==========
+func updateVariablesView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("variables")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	if g.CurrentView() != nil && g.CurrentView().Name() == "variables" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ Variables (Focused)"))
+	} else {
+		fmt.Fprintln(v, "Variables")
+	}
+	// 示例变量：名称、类型、值（值按变量的显示格式渲染）
+	sampleVars := func(vars [][3]string) ([]string, []string) {
+		lines := make([]string, 0, len(vars))
+		names := make([]string, 0, len(vars))
+		for _, sv := range vars {
+			lines = append(lines, fmt.Sprintf("%-8s %-15s %s", sv[0], sv[1], session.FormatValue(ctx, sv[0], sv[2])))
+			names = append(names, sv[0])
+		}
+		return lines, names
+	}
+	localLines, localNames := sampleVars([][3]string{
+		{"ctx", "debugger_ctx_t*", "0x7fff1234"},
+		{"fd", "int", "3"},
+		{"ret", "int", "-1"},
+	})
+	globalLines, globalNames := sampleVars([][3]string{
+		{"g_ctx", "debugger_ctx_t*", "0x601020"},
+		{"debug_level", "int", "2"},
+	})
+	sample := []string{"Local variables:"}
+	sample = append(sample, localLines...)
+	sample = append(sample, "...", "", "Global variables:")
+	sample = append(sample, globalLines...)
+	sample = append(sample, "...")
+	sampleNames := []string{""}
+	sampleNames = append(sampleNames, localNames...)
+	sampleNames = append(sampleNames, "", "", "")
+	sampleNames = append(sampleNames, globalNames...)
+	sampleNames = append(sampleNames, "")
+
+	lines := simulatedLines(ctx, sample)
+	names := make([]string, len(lines)-len(sample))
+	if ctx.DemoMode {
+		names = append(names, sampleNames...)
+	} else {
+		names = make([]string, len(lines))
+	}
+
+	// 函数参数（BTF，每个断点最近一次命中）
+	if argLines, argNames := session.ArgumentLines(ctx); len(argLines) > 0 {
+		lines = append(argLines, lines...)
+		names = append(argNames, names...)
+	}
+
+	// 函数返回值（bp retval，每个断点最近一次返回）
+	if retLines := returnValueLines(ctx); len(retLines) > 0 {
+		retNames := make([]string, len(retLines))
+		for i := 1; i < len(retLines)-1; i++ {
+			retNames[i] = "return"
+		}
+		lines = append(retLines, lines...)
+		names = append(retNames, names...)
+	}
+
+	// 时间线选中的时刻：该时刻之前每个变量最后一次的值
+	if atLines, atNames := session.TimelineValueLines(ctx); len(atLines) > 0 {
+		lines = append(atLines, lines...)
+		names = append(atNames, names...)
+	}
+
+	// 回放的当前帧：录制时该时刻每个变量最后一次的值
+	if atLines, atNames := session.ReplayValueLines(ctx); len(atLines) > 0 {
+		lines = append(atLines, lines...)
+		names = append(atNames, names...)
+	}
+
+	// 监视表达式（项目打开时立即显示，过期值带标记）
+	if ctx.Project != nil && ctx.Project.Settings != nil && len(ctx.Project.Settings.Watches) > 0 {
+		watchLines := []string{"Watch expressions:"}
+		watchNames := []string{""}
+		for _, w := range ctx.Project.Settings.Watches {
+			value := session.WatchValueText(ctx, w)
+			if w.Stale {
+				watchLines = append(watchLines, fmt.Sprintf("%-8s %s %s", w.Expr, value, session.Styled(session.ActiveTheme.Dim, "(stale)")))
+			} else {
+				watchLines = append(watchLines, fmt.Sprintf("%-8s %s", w.Expr, value))
+			}
+			watchNames = append(watchNames, w.Expr)
+			for _, m := range w.Members {
+				watchLines = append(watchLines, fmt.Sprintf("%-8s %s", dwarf.StructMemberLabel(m.Name), session.FormatValue(ctx, m.Name, m.Value)))
+				watchNames = append(watchNames, m.Name)
+			}
+		}
+		watchLines = append(watchLines, "")
+		watchNames = append(watchNames, "")
+		lines = append(watchLines, lines...)
+		names = append(watchNames, names...)
+	}
+
+	// 记录每个显示行对应的变量名（第0行是标题）
+	ctx.VariablesLineNames = []string{""}
+	for i := ctx.VarScroll; i < len(lines); i++ {
+		fmt.Fprintln(v, lines[i])
+		ctx.VariablesLineNames = append(ctx.VariablesLineNames, names[i])
+	}
+}
+
+// 变量窗口中的返回值：每个断点最近一次返回（标题 + 每断点一行 + 空行）
+func returnValueLines(ctx *session.DebuggerContext) []string {
+	latest := make(map[int]session.DebugEvent)
+	order := make([]int, 0)
+	for _, event := range session.EventsUntilCursor(ctx) {
+		if event.Kind != "return" {
+			continue
+		}
+		if _, seen := latest[event.BreakpointID]; !seen {
+			order = append(order, event.BreakpointID)
+		}
+		latest[event.BreakpointID] = event
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	lines := []string{"Return values:"}
+	for _, id := range order {
+		event := latest[id]
+		lines = append(lines, fmt.Sprintf("%-8s %-15s %s", event.Function+"()", fmt.Sprintf("BP%d pid %d", id, event.PID), session.FormatValue(ctx, "return", event.Values[0].Value)))
+	}
+	return append(lines, "")
+}
+
+// ========== 调用栈窗口内容刷新 ==========
+func updateStackView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("stack")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	// 回放时显示当前帧记录的调用栈，时间线选中事件时显示该次命中的调用栈
+	title := "Call Stack"
+	frames, pinned := session.ReplayStackFrames(ctx)
+	if pinned {
+		title += fmt.Sprintf(" @ frame %d", session.CurrentReplayFrame(ctx).Index)
+	} else if frames, pinned = session.TimelineStackFrames(ctx); pinned {
+		title += fmt.Sprintf(" @ #%d", ctx.Timeline.Cursor)
+	} else {
+		frames = ctx.StackFrames
+	}
+	if g.CurrentView() != nil && g.CurrentView().Name() == "stack" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ "+title+" (Focused)"))
+	} else {
+		fmt.Fprintln(v, title)
+	}
+	var lines []string
+	if pinned && len(frames) == 0 {
+		lines = []string{session.Styled(session.ActiveTheme.Dim, "No stack recorded for this event")}
+	} else if len(frames) > 0 {
+		for i, frame := range frames {
+			if frame.File == "" {
+				lines = append(lines, fmt.Sprintf("#%d %s", i, frame.Function))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("#%d %s %s:%d", i, frame.Function, filepath.Base(frame.File), frame.Line))
+		}
+	} else {
+		sample := make([]string, 0, len(session.SampleStackFrames))
+		for i, frame := range session.SampleStackFrames {
+			sample = append(sample, fmt.Sprintf("#%d %s %s:%d", i, frame.Function, frame.File, frame.Line))
+		}
+		lines = simulatedLines(ctx, sample)
+	}
+	for i := ctx.StackScroll; i < len(lines); i++ {
+		fmt.Fprintln(v, lines[i])
+	}
+}
+
+// 代码视图左侧断点栏的宽度（列）
+const CodeGutterWidth = 2
+
+// ========== 代码窗口内容刷新 ==========
+func updateCodeView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("code")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	
+	// 显示标题行，包含搜索状态
+	if g.CurrentView() != nil && g.CurrentView().Name() == "code" {
+		if ctx.SearchMode {
+			fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ Code View (Focused) "+session.SearchStatusLine(ctx)))
+		} else {
+			fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ Code View (Focused)"))
+		}
+	} else {
+		if ctx.SearchMode {
+			fmt.Fprintf(v, "Code View%s\n", session.SearchStatusLine(ctx))
+		} else {
+			fmt.Fprintln(v, "Code View")
+		}
+	}
+	
+	// 反汇编视图（disasm 命令）
+	if ctx.Disasm != nil {
+		renderDisassembly(v, ctx)
+		return
+	}
+	
+	// 如果有打开的文件，显示文件内容
+	if ctx.Project != nil && ctx.Project.CurrentFile != "" {
+		// 文件只读取一次，之后按行索引取可见的行
+		file, err := project.ProjectSourceFile(ctx.Project, ctx.Project.CurrentFile)
+		if err != nil {
+			fmt.Fprintf(v, "Cannot read file: %v\n", err)
+			return
+		}
+		
+		// 标签栏（单击切换，Ctrl+B 文件列表）
+		tabWidth, _ := v.Size()
+		fmt.Fprintln(v, session.CodeTabBar(ctx, tabWidth))
+		
+		// 显示代码行
+		maxLines := file.Len()
+		startLine := ctx.CodeScroll
+		if startLine >= maxLines {
+			startLine = maxLines - 1
+		}
+		if startLine < 0 {
+			startLine = 0
+		}
+		
+		// 计算窗口可用的显示行数
+		_, viewHeight := v.Size()
+		headerLines := 2 // 标题行："代码视图" + 标签栏
+		availableLines := viewHeight - headerLines
+		if availableLines < 1 {
+			availableLines = 1 // 至少显示1行
+		}
+		
+		// 动态适应窗口高度显示代码
+		endLine := startLine + availableLines
+		if endLine > maxLines {
+			endLine = maxLines
+		}
+		
+		// 语法高亮需要首个显示行之前的注释/宏状态
+		highlight := !ctx.HighlightOff && project.IsProjectSourceFile(ctx.Project.CurrentFile)
+		var lexState project.CLexState
+		if highlight {
+			lexState = file.LexStateAt(startLine)
+		}
+		// 锁探针汇总的调用点标注
+		lockNotes := session.LockAnnotations(ctx, ctx.Project.CurrentFile)
+		
+		for i := startLine; i < endLine; i++ {
+			lineNum := i + 1
+			line := file.Line(i)
+			
+			// 检查是否有断点（备注在行尾显示）
+			hasBreakpoint := false
+			hasDisabled := false
+			note := ""
+			for _, bp := range ctx.Project.Breakpoints {
+				if bp.File == ctx.Project.CurrentFile && bp.Line == lineNum {
+					hasBreakpoint = hasBreakpoint || bp.Enabled
+					hasDisabled = hasDisabled || !bp.Enabled
+					if bp.Note != "" {
+						note = bp.Note
+					}
+				}
+			}
+			
+			// 应用语法高亮和搜索高亮
+			var highlightedLine string
+			if highlight {
+				highlightedLine, lexState = session.HighlightCodeLine(line, lineNum, lexState, ctx)
+			} else {
+				highlightedLine = session.HighlightSearchMatches(line, lineNum, ctx)
+			}
+			if note != "" {
+				highlightedLine += "  " + session.Styled(session.ActiveTheme.Note, "✎ "+note)
+			}
+			if lockNote := lockNotes[lineNum]; lockNote != "" {
+				highlightedLine += "  " + lockNote
+			}
+			
+			// 断点栏（单击切换断点）+ 行号
+			gutter := strings.Repeat(" ", CodeGutterWidth)
+			if gdb := ctx.GDB; gdb != nil && gdb.Busy == "" && gdb.StopFile == ctx.Project.CurrentFile && gdb.Where.Line == lineNum {
+				// gdb/kdb后端：目标停在这一行
+				gutter = session.Styled(session.ActiveTheme.Selected, "▶") + " "
+			} else if hasBreakpoint {
+				gutter = session.Styled(session.ActiveTheme.Breakpoint, "●") + " "
+			} else if hasDisabled {
+				gutter = session.Styled(session.ActiveTheme.BreakpointOff, "○") + " "
+			}
+			fmt.Fprintf(v, "%s%3d: %s\n", gutter, lineNum, highlightedLine)
+		}
+		
+	} else {
+		// 没有打开文件：示例汇编（演示模式），真实的反汇编使用 disasm 命令
+		sample := make([]string, 0, len(sampleAssembly))
+		for i, inst := range sampleAssembly {
+			sample = append(sample, fmt.Sprintf("%3d:  0x%016x: %s", i+1, ctx.CurrentAddr+uint64(i*4), inst))
+		}
+		lines := append(simulatedLines(ctx, sample), "", session.Styled(session.ActiveTheme.Dim, "disasm [func] - disassemble a module function"))
+		_, viewHeight := v.Size()
+		for i := 0; i < len(lines) && i < viewHeight-1; i++ {
+			fmt.Fprintln(v, lines[i])
+		}
+	}
+}
+
+// 演示模式的示例汇编
+var sampleAssembly = []string{
+	"addi sp, sp, -32",
+	"sd   ra, 24(sp)",
+	"sd   s0, 16(sp)",
+	"addi s0, sp, 32",
+	"li   a0, 0x1000",
+	"call taco_sys_mmz_alloc",
+	"mv   s1, a0",
+	"beqz s1, .error",
+	"li   a1, 64",
+	"mv   a0, s1",
+	"call memset",
+	"ld   ra, 24(sp)",
+	"ld   s0, 16(sp)",
+	"addi sp, sp, 32",
+	"ret",
+}
+
+// ========== 断点窗口内容刷新 ==========
+func updateBreakpointsView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("stack")
+	if err != nil {
+		return
+	}
+	v.Clear()
+	
+	if g.CurrentView() != nil && g.CurrentView().Name() == "stack" {
+		fmt.Fprintln(v, session.Styled(session.ActiveTheme.Focused, "▶ Breakpoint Manager (Focused)"))
+	} else {
+		fmt.Fprintln(v, "Breakpoint Manager")
+	}
+	
+	if ctx.Project == nil {
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "No project opened")
+		return
+	}
+	
+	fmt.Fprintln(v, "")
+	if len(ctx.Project.Breakpoints) == 0 {
+		fmt.Fprintln(v, "No breakpoints")
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "Press Enter in code view to set breakpoint")
+	} else {
+		fmt.Fprintf(v, "Breakpoint List (%d):\n", len(ctx.Project.Breakpoints))
+		fmt.Fprintln(v, "")
+		
+		for i, bp := range ctx.Project.Breakpoints {
+			status := "✓"
+			if !bp.Enabled {
+				status = "✗"
+			}
+			
+			fileName := filepath.Base(bp.File)
+			fmt.Fprintf(v, "%d. %s %s:%d\n", i+1, status, fileName, bp.Line)
+			if bp.Function != "unknown" {
+				fmt.Fprintf(v, "   Function: %s\n", codegen.BreakpointTarget(bp))
+			}
+			if note := dwarf.InlineSitesNote(bp); note != "" {
+				fmt.Fprintln(v, "   ⤷ "+note)
+			}
+			if check := ctx.ProbeChecks[bp.Function]; check != nil && check.Status != session.ProbeAvailable && bp.Binary == "" {
+				fmt.Fprintln(v, "   "+session.Styled(session.ActiveTheme.Error, "⚠ "+check.Summary()))
+			}
+			if bp.RetVal {
+				fmt.Fprintln(v, "   ↩ return value reported")
+			}
+			if bp.Condition != "" {
+				fmt.Fprintf(v, "   if %s\n", bp.Condition)
+			}
+			if bp.Note != "" {
+				fmt.Fprintln(v, "   "+session.Styled(session.ActiveTheme.Note, "✎ "+bp.Note))
+			}
+		}
+		
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "g-Generate BPF  c-Clear all breakpoints")
+	}
+}
+
+// ========== 命令窗口内容刷新 ==========
+func updateCommandView(g *gocui.Gui, ctx *session.DebuggerContext) {
+	v, err := g.View("command")
+	if err != nil {
+		return
+	}
+	
+	// 限制历史大小
+	session.BoundCommandHistory(ctx)
+	
+	// 检查是否是当前聚焦窗口
+	currentView := g.CurrentView()
+	isCurrentView := currentView != nil && currentView.Name() == "command"
+	
+	if isCurrentView {
+		// 检测粘贴内容（只在非Dirty状态下检测，避免循环）
+		if !ctx.CommandDirty {
+			// 获取视图缓冲区内容
+			viewBuffer := v.ViewBuffer()
+			
+			// 简化的粘贴检测：直接从缓冲区末尾提取当前行
+			lines := strings.Split(strings.TrimSuffix(viewBuffer, "\n"), "\n")
+			if len(lines) > 0 {
+				lastLine := lines[len(lines)-1]
+				// 检查最后一行是否以 "> " 开头
+				if strings.HasPrefix(lastLine, "> ") {
+					actualInput := lastLine[2:] // 去掉 "> " 前缀
+					
+					// 如果实际输入与CurrentInput不同，说明有粘贴操作
+					if actualInput != ctx.CurrentInput {
+						// 调试信息：记录重要的输入变化
+						if len(actualInput) > 40 && len(ctx.CommandHistory) < 10 {
+							debugInfo := fmt.Sprintf("[DEBUG] Paste detected: length=%d, content=%s", len(actualInput), actualInput)
+							ctx.CommandHistory = append(ctx.CommandHistory, debugInfo)
+						}
+						ctx.CurrentInput = actualInput
+						ctx.CommandDirty = true // 标记需要重新同步光标位置
+					}
+				}
+			}
+		}
+		
+		// 只有在CommandDirty为true时才重绘，避免频繁Clear()
+		if ctx.CommandDirty {
+			// 清空视图并重新绘制
+	v.Clear()
+			
+			// 显示历史记录
+			for i, historyLine := range ctx.CommandHistory {
+				if ctx.HistorySearch && i == ctx.HistoryMatch {
+					// 高亮反向搜索匹配到的行
+					historyLine = session.Styled(session.ActiveTheme.MatchFocus, session.StripANSI(historyLine))
+				}
+				fmt.Fprintln(v, historyLine)
+			}
+			
+			// 显示当前输入行（搜索状态下显示搜索提示）
+			if ctx.HistorySearch {
+				fmt.Fprint(v, session.HistorySearchPrompt(ctx))
+			} else {
+				fmt.Fprintf(v, "> %s", ctx.CurrentInput)
+			}
+			
+			// 设置光标位置到当前输入的末尾（按显示宽度）
+			cursorX := 2 + project.DisplayWidth(ctx.CurrentInput) // "> " + 输入内容
+			cursorY := len(ctx.CommandHistory)                    // 历史记录行数
+			v.SetCursor(cursorX, cursorY)
+			
+			// 标记已更新
+			ctx.CommandDirty = false
+		}
+		
+	} else {
+		// 如果不是聚焦状态，显示简化的帮助信息
+		v.Clear()
+		
+		fmt.Fprintln(v, "Command Terminal - Press F6 to focus")
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "Basic commands:")
+		fmt.Fprintln(v, "  help         - Show help")
+		fmt.Fprintln(v, "  open <path>  - Open project")
+		fmt.Fprintln(v, "  clear        - Clear screen")
+		fmt.Fprintln(v, "")
+		fmt.Fprintln(v, "Shortcuts: Tab-Switch windows")
+		
+		// 显示项目状态
+		if ctx.Project != nil {
+			fmt.Fprintln(v, "")
+			fmt.Fprintf(v, "Project: %s", filepath.Base(ctx.Project.RootPath))
+		}
+		
+		// 显示最近的几条命令历史（如果有的话）
+		if len(ctx.CommandHistory) > 0 {
+			fmt.Fprintln(v, "")
+			fmt.Fprintln(v, "Recent commands:")
+			// 显示最后3条历史记录
+			start := len(ctx.CommandHistory) - 3
+			if start < 0 {
+				start = 0
+			}
+			for i := start; i < len(ctx.CommandHistory) && i < start+3; i++ {
+				line := ctx.CommandHistory[i]
+				if len(line) > 30 {
+					line = line[:27] + "..."
+				}
+				fmt.Fprintf(v, "  %s\n", line)
+			}
+		}
+	}
+}
+
+// ========== 刷新所有窗口 ==========
+func UpdateAllViews(g *gocui.Gui, ctx *session.DebuggerContext) {
+	updateStatusView(g, ctx)
+	UpdateFileBrowserView(g, ctx)
+	updateRegistersView(g, ctx)
+	updateVariablesView(g, ctx)
+	updateBreakpointsView(g, ctx)
+	updateCodeView(g, ctx)
+	updateMemoryView(g, ctx)
+	updateTimelineView(g, ctx)
+	updateCommandView(g, ctx)
+}
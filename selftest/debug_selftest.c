@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-2.0
+/*
+ * debug_selftest - kernel_driver_debug_tui 自检用的示例模块
+ *
+ * 加载后创建 /proc/debug_selftest，向其写入一个整数会调用
+ * debug_selftest_trigger()，selftest 命令在该函数上设置断点并验证事件能回到界面。
+ */
+#include <linux/module.h>
+#include <linux/kernel.h>
+#include <linux/proc_fs.h>
+#include <linux/uaccess.h>
+#include <linux/version.h>
+
+static int debug_selftest_hits;
+
+noinline int debug_selftest_trigger(int value)
+{
+	int result = value * 2; /* SELFTEST_BREAKPOINT */
+
+	debug_selftest_hits++;
+	return result;
+}
+EXPORT_SYMBOL_GPL(debug_selftest_trigger);
+
+static ssize_t debug_selftest_write(struct file *file, const char __user *buf,
+				    size_t count, loff_t *ppos)
+{
+	char kbuf[16];
+	int value = 0;
+	size_t len = min(count, sizeof(kbuf) - 1);
+
+	if (copy_from_user(kbuf, buf, len))
+		return -EFAULT;
+	kbuf[len] = '\0';
+	if (kstrtoint(strim(kbuf), 0, &value))
+		return -EINVAL;
+
+	debug_selftest_trigger(value);
+	return count;
+}
+
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(5, 6, 0)
+static const struct proc_ops debug_selftest_fops = {
+	.proc_write = debug_selftest_write,
+};
+#else
+static const struct file_operations debug_selftest_fops = {
+	.owner = THIS_MODULE,
+	.write = debug_selftest_write,
+};
+#endif
+
+static int __init debug_selftest_init(void)
+{
+	if (!proc_create("debug_selftest", 0200, NULL, &debug_selftest_fops))
+		return -ENOMEM;
+	pr_info("debug_selftest: loaded\n");
+	return 0;
+}
+
+static void __exit debug_selftest_exit(void)
+{
+	remove_proc_entry("debug_selftest", NULL);
+	pr_info("debug_selftest: unloaded after %d hits\n", debug_selftest_hits);
+}
+
+module_init(debug_selftest_init);
+module_exit(debug_selftest_exit);
+
+MODULE_LICENSE("GPL");
+MODULE_DESCRIPTION("kernel_driver_debug_tui self-test module");